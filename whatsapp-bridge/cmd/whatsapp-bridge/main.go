@@ -48,9 +48,38 @@ func bridgePortFromEnv() int {
 	return parsedPort
 }
 
+// runImportUpstream migrates a messages.db from the original whatsapp-mcp
+// bridge into this bridge's store. The bridge must not be running.
+func runImportUpstream(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: whatsapp-bridge import-upstream <path/to/messages.db>")
+		return 2
+	}
+
+	messageStore, err := storage.NewMessageStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize message store: %v\n", err)
+		return 1
+	}
+	result, err := messageStore.ImportUpstreamDB(args[0])
+	if closeErr := messageStore.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Import failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Imported %d chats and %d messages (%d skipped)\n", result.Chats, result.Messages, result.Skipped)
+	return 0
+}
+
 func main() {
 	loadDotenvFile()
 
+	if len(os.Args) > 1 && os.Args[1] == "import-upstream" {
+		os.Exit(runImportUpstream(os.Args[2:]))
+	}
+
 	logger := waLog.Stdout("Client", "INFO", true)
 	logger.Infof("Starting WhatsApp bridge...")
 
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"whatsapp-client/internal/whatsapp"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID bounds caller-supplied request IDs so they are safe to echo
+// into headers and logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// accessLogger writes one JSON record per API request to stdout.
+var accessLogger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+type accessLogKey struct{}
+
+// accessLogEntry collects what handlers learn about a request (its route and
+// token subject) for the access log record written once it completes.
+type accessLogEntry struct {
+	route   string
+	subject string
+}
+
+// withAccessLog assigns each request an ID, taken from a well-formed
+// X-Request-ID header or generated, echoes it in the response, passes it on
+// to WhatsApp operations, and logs the request once it completes. Paths are
+// logged as their route pattern so chat JIDs stay out of the log.
+func withAccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		entry := &accessLogEntry{}
+		ctx := context.WithValue(r.Context(), accessLogKey{}, entry)
+		ctx = whatsapp.WithRequestID(ctx, requestID)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(ctx)
+		next.ServeHTTP(recorder, r)
+
+		route := entry.route
+		if route == "" {
+			route = "unmatched"
+		}
+		accessLogger.LogAttrs(ctx, slog.LevelInfo, "http_request",
+			slog.String("request_id", requestID),
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.Int("status", recorder.status),
+			slog.Int64("bytes", recorder.written),
+			slog.Float64("duration_ms", float64(time.Since(started).Microseconds())/1000),
+			slog.String("subject", entry.subject),
+			slog.String("client_ip", requestClientIP(r)),
+		)
+	})
+}
+
+// noteAccessLog records the matched route and token subject of a request for
+// its access log record.
+func noteAccessLog(r *http.Request, subject string) {
+	if entry, ok := r.Context().Value(accessLogKey{}).(*accessLogEntry); ok {
+		entry.route = r.Pattern
+		if subject != "" {
+			entry.subject = subject
+		}
+	}
+}
+
+func newRequestID() string {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(raw)
+}
+
+// statusRecorder captures the response status and size. Unwrap keeps
+// http.ResponseController features such as flushing working for streams.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	written     int64
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if !s.wroteHeader {
+		s.status = status
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(p)
+	s.written += int64(n)
+	return n, err
+}
+
+// FlushError marks the header sent, since flushing commits the status.
+func (s *statusRecorder) FlushError() error {
+	s.wroteHeader = true
+	return http.NewResponseController(s.ResponseWriter).Flush()
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"whatsapp-client/internal/storage"
+)
+
+type AliasResponse struct {
+	AliasID     string `json:"alias_id"`
+	CanonicalID string `json:"canonical_id"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+type AliasesResponse struct {
+	Aliases []AliasResponse `json:"aliases"`
+}
+
+type SplitAliasRequest struct {
+	CanonicalID string `json:"canonical_id"`
+	AliasID     string `json:"alias_id"`
+}
+
+type SplitAliasResponse struct {
+	CanonicalID        string `json:"canonical_id"`
+	AliasID            string `json:"alias_id"`
+	MessagesReassigned int64  `json:"messages_reassigned"`
+	ChatMessagesMoved  int64  `json:"chat_messages_moved"`
+}
+
+// aliasesHandler lists how phone numbers and LIDs were merged into canonical
+// identities, filtered by canonical ID or by any one of the aliases.
+func aliasesHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 100, 1000)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		query := r.URL.Query()
+		aliases, err := messageStore.GetSenderAliases(storage.AliasQuery{
+			Canonical: strings.TrimSpace(query.Get("canonical")),
+			Alias:     strings.TrimSpace(query.Get("alias")),
+			Limit:     limit,
+		})
+		if err != nil {
+			http.Error(w, "Failed to load aliases", http.StatusInternalServerError)
+			return
+		}
+
+		response := AliasesResponse{Aliases: make([]AliasResponse, 0, len(aliases))}
+		for _, alias := range aliases {
+			response.Aliases = append(response.Aliases, AliasResponse{
+				AliasID:     alias.AliasID,
+				CanonicalID: alias.CanonicalID,
+				UpdatedAt:   formatTimestamp(alias.UpdatedAt, location),
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// splitAliasHandler undoes a wrong identity merge, giving an alias back its own
+// messages based on the sender recorded when each message was received.
+func splitAliasHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SplitAliasRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		req.CanonicalID = strings.TrimSpace(req.CanonicalID)
+		req.AliasID = strings.TrimSpace(req.AliasID)
+		if req.CanonicalID == "" || req.AliasID == "" {
+			http.Error(w, "canonical_id and alias_id are required", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		split, err := messageStore.SplitAlias(req.CanonicalID, req.AliasID)
+		if errors.Is(err, storage.ErrAliasNotFound) {
+			http.Error(w, "Alias is not merged into that canonical ID", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to split alias", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, SplitAliasResponse{
+			CanonicalID:        split.CanonicalID,
+			AliasID:            split.AliasID,
+			MessagesReassigned: split.MessagesReassigned,
+			ChatMessagesMoved:  split.ChatMessagesMoved,
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+const maxAudienceMembers = 5000
+
+var audienceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.-]{0,63}$`)
+
+// AudienceRequest defines an audience: explicit member JIDs, contacts tagged
+// with any of tags, or both.
+type AudienceRequest struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+type AudienceResponse struct {
+	ID      int64    `json:"id"`
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+	Tags    []string `json:"tags"`
+	// Recipients is who the audience resolves to now; only returned for a
+	// single audience.
+	Recipients []string `json:"recipients,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+type AudiencesResponse struct {
+	Audiences []AudienceResponse `json:"audiences"`
+}
+
+func newAudienceResponse(audience storage.Audience) AudienceResponse {
+	return AudienceResponse{
+		ID:        audience.ID,
+		Name:      audience.Name,
+		Members:   audience.Members,
+		Tags:      audience.Tags,
+		CreatedAt: audience.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: audience.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// audienceFromRequest validates an audience definition, returning a problem
+// description when it is invalid.
+func audienceFromRequest(req AudienceRequest) (storage.Audience, string) {
+	audience := storage.Audience{Name: strings.TrimSpace(req.Name)}
+	if !audienceNamePattern.MatchString(audience.Name) {
+		return storage.Audience{}, "Invalid name"
+	}
+	if len(req.Members) == 0 && len(req.Tags) == 0 {
+		return storage.Audience{}, "members or tags is required"
+	}
+	if len(req.Members) > maxAudienceMembers {
+		return storage.Audience{}, fmt.Sprintf("An audience can have at most %d members", maxAudienceMembers)
+	}
+	for _, member := range req.Members {
+		if member = strings.TrimSpace(member); !jid.IsPersonal(member) || jid.NormalizeUser(member) == "" {
+			return storage.Audience{}, fmt.Sprintf("Invalid member %q", member)
+		}
+		audience.Members = append(audience.Members, member)
+	}
+	seen := map[string]bool{}
+	for _, tag := range req.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !contactTagPattern.MatchString(tag) {
+			return storage.Audience{}, fmt.Sprintf("Invalid tag %q", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			audience.Tags = append(audience.Tags, tag)
+		}
+	}
+	return audience, ""
+}
+
+// audiencesHandler lists audiences (GET) or creates one (POST).
+func audiencesHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			audiences, err := messageStore.ListAudiences()
+			if err != nil {
+				http.Error(w, "Failed to load audiences", http.StatusInternalServerError)
+				return
+			}
+			response := AudiencesResponse{Audiences: make([]AudienceResponse, 0, len(audiences))}
+			for _, audience := range audiences {
+				response.Audiences = append(response.Audiences, newAudienceResponse(audience))
+			}
+			writeJSON(w, http.StatusOK, response)
+			return
+		}
+
+		var req AudienceRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		audience, problem := audienceFromRequest(req)
+		if problem != "" {
+			http.Error(w, problem, http.StatusBadRequest)
+			return
+		}
+		saved, err := messageStore.SaveAudience(audience)
+		if errors.Is(err, storage.ErrAudienceNameTaken) {
+			http.Error(w, "Audience name is already in use", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to save audience", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newAudienceResponse(saved))
+	}
+}
+
+// audienceHandler returns an audience with the recipients it resolves to
+// (GET), replaces its definition (PUT) or deletes it (DELETE).
+func audienceHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid audience ID", http.StatusBadRequest)
+			return
+		}
+
+		var audience storage.Audience
+		if r.Method == http.MethodPut {
+			var req AudienceRequest
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+			var problem string
+			if audience, problem = audienceFromRequest(req); problem != "" {
+				http.Error(w, problem, http.StatusBadRequest)
+				return
+			}
+			audience.ID = id
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodDelete:
+			deleted, err := messageStore.DeleteAudience(id)
+			if err != nil {
+				http.Error(w, "Failed to delete audience", http.StatusInternalServerError)
+				return
+			}
+			if !deleted {
+				http.Error(w, "Audience not found", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		case http.MethodPut:
+			saved, err := messageStore.SaveAudience(audience)
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "Audience not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, storage.ErrAudienceNameTaken) {
+				http.Error(w, "Audience name is already in use", http.StatusConflict)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Failed to save audience", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, newAudienceResponse(saved))
+
+		case http.MethodGet:
+			stored, err := messageStore.GetAudience(id)
+			if err != nil {
+				http.Error(w, "Failed to load audience", http.StatusInternalServerError)
+				return
+			}
+			if stored == nil {
+				http.Error(w, "Audience not found", http.StatusNotFound)
+				return
+			}
+			recipients, err := messageStore.ResolveAudience(*stored)
+			if err != nil {
+				http.Error(w, "Failed to resolve audience", http.StatusInternalServerError)
+				return
+			}
+			response := newAudienceResponse(*stored)
+			response.Recipients = recipients
+			writeJSON(w, http.StatusOK, response)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultMaxBodyBytes bounds request bodies on routes without their own
+	// limit.
+	defaultMaxBodyBytes   int64 = 1 << 20
+	bodyTooLargeErrorCode       = "request_body_too_large"
+)
+
+type BodyTooLargeResponse struct {
+	Error    string `json:"error"`
+	Message  string `json:"message"`
+	MaxBytes int64  `json:"max_bytes"`
+}
+
+type bodyLimitRule struct {
+	pattern  string
+	maxBytes int64
+}
+
+// bodyLimits caps request body sizes per route; the first matching rule
+// applies, then the default.
+type bodyLimits struct {
+	defaultMax int64
+	rules      []bodyLimitRule
+}
+
+// bodyLimitsFromEnv builds the limits from WHATSAPP_BRIDGE_MAX_BODY_BYTES
+// (the default) and WHATSAPP_BRIDGE_BODY_LIMITS overrides of the form
+// "/api/send=10485760,/api/views/{name}=65536".
+func bodyLimitsFromEnv() bodyLimits {
+	limits := bodyLimits{defaultMax: defaultMaxBodyBytes}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_BRIDGE_MAX_BODY_BYTES")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			fmt.Printf("Warning: invalid WHATSAPP_BRIDGE_MAX_BODY_BYTES=%q, using %d\n", raw, defaultMaxBodyBytes)
+		} else {
+			limits.defaultMax = parsed
+		}
+	}
+
+	for _, part := range strings.Split(os.Getenv("WHATSAPP_BRIDGE_BODY_LIMITS"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		pattern, rawMax, ok := strings.Cut(part, "=")
+		maxBytes, err := strconv.ParseInt(strings.TrimSpace(rawMax), 10, 64)
+		if !ok || err != nil || maxBytes <= 0 || !strings.HasPrefix(strings.TrimSpace(pattern), "/") {
+			fmt.Printf("Warning: invalid WHATSAPP_BRIDGE_BODY_LIMITS entry %q, ignoring\n", part)
+			continue
+		}
+		limits.rules = append(limits.rules, bodyLimitRule{pattern: strings.TrimSpace(pattern), maxBytes: maxBytes})
+	}
+	return limits
+}
+
+func (l bodyLimits) forPath(path string) int64 {
+	for _, rule := range l.rules {
+		if routePathMatches(rule.pattern, path) {
+			return rule.maxBytes
+		}
+	}
+	return l.defaultMax
+}
+
+// withBodyLimits rejects bodies declared larger than the route's limit up
+// front and caps the rest while they are read; decodeJSONBody turns an
+// overrun into the same 413.
+func withBodyLimits(limits bodyLimits, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxBytes := limits.forPath(r.URL.Path)
+		if r.ContentLength > maxBytes {
+			writeBodyTooLarge(w, maxBytes)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, maxBytes int64) {
+	writeJSON(w, http.StatusRequestEntityTooLarge, BodyTooLargeResponse{
+		Error:    bodyTooLargeErrorCode,
+		Message:  fmt.Sprintf("request body exceeds the %d byte limit for this route", maxBytes),
+		MaxBytes: maxBytes,
+	})
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitsFromEnv(t *testing.T) {
+	t.Setenv("WHATSAPP_BRIDGE_MAX_BODY_BYTES", "2048")
+	t.Setenv("WHATSAPP_BRIDGE_BODY_LIMITS", "/api/send=4096, bogus=1, /api/views/{name}=0, /api/chats/{jid}/notes=512")
+	limits := bodyLimitsFromEnv()
+
+	if limits.defaultMax != 2048 {
+		t.Fatalf("expected the default of 2048 bytes, got %d", limits.defaultMax)
+	}
+	if len(limits.rules) != 2 {
+		t.Fatalf("expected invalid entries to be skipped, got %+v", limits.rules)
+	}
+
+	t.Setenv("WHATSAPP_BRIDGE_MAX_BODY_BYTES", "-1")
+	t.Setenv("WHATSAPP_BRIDGE_BODY_LIMITS", "")
+	if limits := bodyLimitsFromEnv(); limits.defaultMax != defaultMaxBodyBytes || len(limits.rules) != 0 {
+		t.Fatalf("expected the built-in default and no rules, got %+v", limits)
+	}
+}
+
+func TestBodyLimitsForPath(t *testing.T) {
+	limits := bodyLimits{defaultMax: 1024, rules: []bodyLimitRule{
+		{pattern: "/api/send", maxBytes: 4096},
+		{pattern: "/api/chats/{jid}/notes", maxBytes: 512},
+		{pattern: "/api/chats/{jid}/notes", maxBytes: 8192},
+	}}
+	tests := []struct {
+		path string
+		want int64
+	}{
+		{"/api/send", 4096},
+		{"/api/send/queue", 1024},
+		{"/api/chats/15551234567/notes", 512},
+		{"/api/chats//notes", 1024},
+		{"/api/messages", 1024},
+	}
+	for _, tt := range tests {
+		if got := limits.forPath(tt.path); got != tt.want {
+			t.Errorf("forPath(%q) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWithBodyLimitsRejectsOversizedBodies(t *testing.T) {
+	limits := bodyLimits{defaultMax: 64}
+	handler := withBodyLimits(limits, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SendMessageRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	small := `{"recipient":"15551234567","message":"hi"}`
+	large := `{"recipient":"15551234567","message":"` + strings.Repeat("x", 128) + `"}`
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		want          int
+	}{
+		{"within the limit", small, int64(len(small)), http.StatusNoContent},
+		{"declared too large", large, int64(len(large)), http.StatusRequestEntityTooLarge},
+		// Chunked bodies declare no length and are cut off while read.
+		{"read past the limit", large, -1, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/send", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+			if tt.want != http.StatusRequestEntityTooLarge {
+				return
+			}
+			var response BodyTooLargeResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode 413 body: %v", err)
+			}
+			if response.Error != bodyTooLargeErrorCode || response.MaxBytes != 64 {
+				t.Fatalf("unexpected 413 body: %+v", response)
+			}
+		})
+	}
+}
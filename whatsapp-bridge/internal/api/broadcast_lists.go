@@ -0,0 +1,119 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"whatsapp-client/internal/storage"
+)
+
+type BroadcastListResponse struct {
+	JID             string   `json:"jid"`
+	Name            string   `json:"name,omitempty"`
+	LastMessageTime string   `json:"last_message_time,omitempty"`
+	Recipients      []string `json:"recipients"`
+	UpdatedAt       string   `json:"updated_at,omitempty"`
+}
+
+type BroadcastListsResponse struct {
+	BroadcastLists []BroadcastListResponse `json:"broadcast_lists"`
+}
+
+type BroadcastRecipientsRequest struct {
+	Recipients []string `json:"recipients"`
+}
+
+// broadcastListsHandler lists broadcast list chats with the recipients known
+// for each.
+func broadcastListsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		lists, err := messageStore.ListBroadcastLists()
+		if err != nil {
+			http.Error(w, "Failed to load broadcast lists", http.StatusInternalServerError)
+			return
+		}
+
+		response := BroadcastListsResponse{BroadcastLists: make([]BroadcastListResponse, 0, len(lists))}
+		for _, list := range lists {
+			recipients := list.Recipients
+			if recipients == nil {
+				recipients = []string{}
+			}
+			entry := BroadcastListResponse{JID: list.JID, Name: list.Name, Recipients: recipients}
+			if !list.LastMessageTime.IsZero() {
+				entry.LastMessageTime = formatTimestamp(list.LastMessageTime, location)
+			}
+			if !list.UpdatedAt.IsZero() {
+				entry.UpdatedAt = formatTimestamp(list.UpdatedAt, location)
+			}
+			response.BroadcastLists = append(response.BroadcastLists, entry)
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// broadcastRecipientsHandler replaces the recipients of a broadcast list.
+// WhatsApp rarely shares list membership with linked devices, so sends to a
+// list rely on recipients recorded here.
+func broadcastRecipientsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		listJID := strings.TrimSpace(r.PathValue("jid"))
+		if listJID == "" {
+			http.Error(w, "Broadcast list JID is required", http.StatusBadRequest)
+			return
+		}
+
+		var req BroadcastRecipientsRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if err := messageStore.ReplaceBroadcastRecipients(listJID, req.Recipients); err != nil {
+			if errors.Is(err, storage.ErrNotBroadcastList) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Failed to store broadcast list recipients", http.StatusInternalServerError)
+			return
+		}
+
+		recipients, err := messageStore.GetBroadcastRecipients(listJID)
+		if err != nil {
+			http.Error(w, "Failed to load broadcast list recipients", http.StatusInternalServerError)
+			return
+		}
+		if recipients == nil {
+			recipients = []string{}
+		}
+		writeJSON(w, http.StatusOK, BroadcastListResponse{JID: listJID, Recipients: recipients})
+	}
+}
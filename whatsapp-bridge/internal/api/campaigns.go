@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/whatsapp"
+)
+
+// broadcastJobKind marks broadcast sends that outlived their request.
+const broadcastJobKind = "broadcast"
+
+// BroadcastSendRequest sends one message to each recipient of an audience,
+// or to an explicit recipient list; exactly one of the two must be set.
+type BroadcastSendRequest struct {
+	AudienceID int64    `json:"audience_id,omitempty"`
+	Recipients []string `json:"recipients,omitempty"`
+	Message    string   `json:"message,omitempty"`
+	MediaPath  string   `json:"media_path,omitempty"`
+	// OverrideOptOut also sends to recipients who opted out; otherwise they
+	// are recorded as failed.
+	OverrideOptOut bool `json:"override_opt_out,omitempty"`
+	// Pacing names the pacing preset (conservative, normal, aggressive or
+	// off) for this broadcast instead of WHATSAPP_SEND_PACING.
+	Pacing string `json:"pacing,omitempty"`
+}
+
+type CampaignRecipientResponse struct {
+	RecipientJID string `json:"recipient_jid"`
+	// Status is pending, failed, sent, delivered, read or played.
+	Status      string `json:"status"`
+	MessageID   string `json:"message_id,omitempty"`
+	Error       string `json:"error,omitempty"`
+	DeliveredAt string `json:"delivered_at,omitempty"`
+	ReadAt      string `json:"read_at,omitempty"`
+	PlayedAt    string `json:"played_at,omitempty"`
+	OptedOutAt  string `json:"opted_out_at,omitempty"`
+}
+
+type BroadcastSendResponse struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	CampaignID int64  `json:"campaign_id,omitempty"`
+	Sent       int    `json:"sent"`
+	Failed     int    `json:"failed"`
+	// JobID is set with 202 Accepted when the send outlived the request; the
+	// campaign records each recipient's outcome once it finishes.
+	JobID      string                      `json:"job_id,omitempty"`
+	Recipients []CampaignRecipientResponse `json:"recipients,omitempty"`
+}
+
+type CampaignResponse struct {
+	ID           int64                       `json:"id"`
+	AudienceID   int64                       `json:"audience_id,omitempty"`
+	AudienceName string                      `json:"audience_name,omitempty"`
+	Message      string                      `json:"message,omitempty"`
+	MediaPath    string                      `json:"media_path,omitempty"`
+	CreatedAt    string                      `json:"created_at"`
+	Recipients   []CampaignRecipientResponse `json:"recipients,omitempty"`
+}
+
+type CampaignsResponse struct {
+	Campaigns []CampaignResponse `json:"campaigns"`
+}
+
+// CampaignReportResponse counts a campaign's recipients by outcome. Delivered
+// includes read recipients; rates are fractions of the recipients sent to.
+type CampaignReportResponse struct {
+	CampaignID   int64   `json:"campaign_id"`
+	AudienceName string  `json:"audience_name,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+	Recipients   int     `json:"recipients"`
+	Pending      int     `json:"pending"`
+	Failed       int     `json:"failed"`
+	Sent         int     `json:"sent"`
+	Delivered    int     `json:"delivered"`
+	Read         int     `json:"read"`
+	OptedOut     int     `json:"opted_out"`
+	DeliveryRate float64 `json:"delivery_rate"`
+	ReadRate     float64 `json:"read_rate"`
+	OptOutRate   float64 `json:"opt_out_rate"`
+}
+
+func newCampaignResponse(campaign storage.Campaign) CampaignResponse {
+	response := CampaignResponse{
+		ID:           campaign.ID,
+		AudienceID:   campaign.AudienceID,
+		AudienceName: campaign.AudienceName,
+		Message:      campaign.Message,
+		MediaPath:    campaign.MediaPath,
+		CreatedAt:    campaign.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if campaign.Recipients != nil {
+		response.Recipients = newCampaignRecipientResponses(campaign.Recipients)
+	}
+	return response
+}
+
+func newCampaignRecipientResponses(recipients []storage.CampaignRecipient) []CampaignRecipientResponse {
+	responses := make([]CampaignRecipientResponse, 0, len(recipients))
+	for _, recipient := range recipients {
+		responses = append(responses, CampaignRecipientResponse{
+			RecipientJID: recipient.RecipientJID,
+			Status:       recipient.Status(),
+			MessageID:    recipient.MessageID,
+			Error:        recipient.Error,
+			DeliveredAt:  formatOptionalTime(recipient.DeliveredAt),
+			ReadAt:       formatOptionalTime(recipient.ReadAt),
+			PlayedAt:     formatOptionalTime(recipient.PlayedAt),
+			OptedOutAt:   formatOptionalTime(recipient.OptedOutAt),
+		})
+	}
+	return responses
+}
+
+// campaignOutcomes converts fan-out results into campaign outcomes, or marks
+// every recipient failed with sendErr when nothing could be sent.
+func campaignOutcomes(recipients []string, results []whatsapp.RecipientResult, sendErr error) []storage.CampaignRecipient {
+	outcomes := make([]storage.CampaignRecipient, 0, len(recipients))
+	if sendErr != nil {
+		for _, recipient := range recipients {
+			outcomes = append(outcomes, storage.CampaignRecipient{RecipientJID: recipient, Error: sendErr.Error()})
+		}
+		return outcomes
+	}
+	for _, result := range results {
+		outcomes = append(outcomes, storage.CampaignRecipient{RecipientJID: result.Recipient, MessageID: result.MessageID, Error: result.Error})
+	}
+	return outcomes
+}
+
+// broadcastSendHandler sends one message to an audience or a recipient list
+// as individual chat messages. Each send is recorded as a campaign holding a
+// snapshot of who it was addressed to and the outcome per recipient.
+func broadcastSendHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req BroadcastSendRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if (req.AudienceID == 0) == (len(req.Recipients) == 0) {
+			http.Error(w, "Set exactly one of audience_id and recipients", http.StatusBadRequest)
+			return
+		}
+		if req.AudienceID < 0 {
+			http.Error(w, "Invalid audience_id", http.StatusBadRequest)
+			return
+		}
+		if req.Message == "" && req.MediaPath == "" {
+			http.Error(w, "Message or media path is required", http.StatusBadRequest)
+			return
+		}
+		if len(req.Recipients) > maxAudienceMembers {
+			http.Error(w, fmt.Sprintf("A broadcast can have at most %d recipients", maxAudienceMembers), http.StatusBadRequest)
+			return
+		}
+		for _, recipient := range req.Recipients {
+			if !jid.IsPersonal(strings.TrimSpace(recipient)) {
+				http.Error(w, fmt.Sprintf("Invalid recipient %q", recipient), http.StatusBadRequest)
+				return
+			}
+		}
+		if req.MediaPath != "" {
+			if _, err := whatsapp.MediaPolicyFromEnv().CheckUpload(req.MediaPath); err != nil {
+				writeSendError(w, err)
+				return
+			}
+		}
+		pacing := runtime.pacing
+		if req.Pacing != "" {
+			preset, ok := whatsapp.PacingPreset(req.Pacing)
+			if !ok {
+				http.Error(w, "pacing must be conservative, normal, aggressive or off", http.StatusBadRequest)
+				return
+			}
+			pacing = preset
+		}
+
+		client := runtime.currentClient()
+		if client == nil {
+			writeJSON(w, http.StatusServiceUnavailable, BroadcastSendResponse{
+				Success: false,
+				Message: "WhatsApp client is not initialized. Start connect first.",
+			})
+			return
+		}
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		campaign := storage.Campaign{Message: req.Message, MediaPath: req.MediaPath}
+		audience := storage.Audience{Members: req.Recipients}
+		if req.AudienceID != 0 {
+			stored, err := messageStore.GetAudience(req.AudienceID)
+			if err != nil {
+				http.Error(w, "Failed to load audience", http.StatusInternalServerError)
+				return
+			}
+			if stored == nil {
+				http.Error(w, "Audience not found", http.StatusNotFound)
+				return
+			}
+			audience = *stored
+			campaign.AudienceID, campaign.AudienceName = stored.ID, stored.Name
+		}
+		recipients, err := messageStore.ResolveAudience(audience)
+		if err != nil {
+			http.Error(w, "Failed to resolve audience", http.StatusInternalServerError)
+			return
+		}
+		if len(recipients) == 0 {
+			http.Error(w, "Audience has no recipients", http.StatusUnprocessableEntity)
+			return
+		}
+
+		campaign, err = messageStore.CreateCampaign(campaign, recipients)
+		if err != nil {
+			http.Error(w, "Failed to record campaign", http.StatusInternalServerError)
+			return
+		}
+
+		outcomes, job, err := runOrDefer(r, runtime, broadcastJobKind, fmt.Sprintf("campaign %d", campaign.ID), func(ctx context.Context) ([]storage.CampaignRecipient, error) {
+			results, sendErr := whatsapp.SendToRecipients(ctx, client, messageStore, recipients, req.Message, req.MediaPath, whatsapp.SendOptions{OverrideOptOut: req.OverrideOptOut, Pacing: pacing})
+			outcomes := campaignOutcomes(recipients, results, sendErr)
+			if err := messageStore.RecordCampaignSends(campaign.ID, outcomes); err != nil {
+				fmt.Printf("Warning: failed to record campaign %d sends: %v\n", campaign.ID, err)
+			}
+			return outcomes, sendErr
+		}, func(outcomes []storage.CampaignRecipient) string {
+			return broadcastSummary(campaign.ID, outcomes)
+		})
+		if err != nil {
+			writeSendError(w, err)
+			return
+		}
+		if job != nil {
+			writeJSON(w, http.StatusAccepted, BroadcastSendResponse{
+				Success:    false,
+				Message:    "Broadcast is still in progress; follow the job or the campaign for its outcome",
+				CampaignID: campaign.ID,
+				JobID:      job.ID,
+			})
+			return
+		}
+
+		response := BroadcastSendResponse{
+			Message:    broadcastSummary(campaign.ID, outcomes),
+			CampaignID: campaign.ID,
+			Recipients: newCampaignRecipientResponses(outcomes),
+		}
+		for _, outcome := range outcomes {
+			if outcome.Error == "" {
+				response.Sent++
+			} else {
+				response.Failed++
+			}
+		}
+		response.Success = response.Sent > 0
+		status := http.StatusOK
+		if !response.Success {
+			status = http.StatusBadGateway
+		}
+		writeJSON(w, status, response)
+	}
+}
+
+func broadcastSummary(campaignID int64, outcomes []storage.CampaignRecipient) string {
+	sent := 0
+	for _, outcome := range outcomes {
+		if outcome.Error == "" {
+			sent++
+		}
+	}
+	return fmt.Sprintf("Campaign %d: message sent to %d of %d recipients", campaignID, sent, len(outcomes))
+}
+
+// campaignsHandler lists recent campaigns, newest first.
+func campaignsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 50, 500)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		campaigns, err := messageStore.ListCampaigns(limit)
+		if err != nil {
+			http.Error(w, "Failed to load campaigns", http.StatusInternalServerError)
+			return
+		}
+		response := CampaignsResponse{Campaigns: make([]CampaignResponse, 0, len(campaigns))}
+		for _, campaign := range campaigns {
+			response.Campaigns = append(response.Campaigns, newCampaignResponse(campaign))
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// loadCampaign resolves the campaign named by the request path, writing the
+// error response and returning nil when it cannot.
+func loadCampaign(w http.ResponseWriter, r *http.Request, runtime *whatsAppRuntime) *storage.Campaign {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return nil
+	}
+
+	messageStore := runtime.currentMessageStore()
+	if messageStore == nil {
+		http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+		return nil
+	}
+
+	campaign, err := messageStore.GetCampaign(id)
+	if err != nil {
+		http.Error(w, "Failed to load campaign", http.StatusInternalServerError)
+		return nil
+	}
+	if campaign == nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+	}
+	return campaign
+}
+
+// campaignHandler returns a campaign with its recipient snapshot and each
+// recipient's send, delivery, read and opt-out status.
+func campaignHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if campaign := loadCampaign(w, r, runtime); campaign != nil {
+			writeJSON(w, http.StatusOK, newCampaignResponse(*campaign))
+		}
+	}
+}
+
+// campaignReportHandler summarizes how far a campaign got: how many
+// recipients were sent to, received and read it, and how many opted out.
+func campaignReportHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		campaign := loadCampaign(w, r, runtime)
+		if campaign == nil {
+			return
+		}
+
+		report := storage.SummarizeCampaign(campaign.Recipients)
+		response := CampaignReportResponse{
+			CampaignID:   campaign.ID,
+			AudienceName: campaign.AudienceName,
+			CreatedAt:    campaign.CreatedAt.UTC().Format(time.RFC3339),
+			Recipients:   report.Recipients,
+			Pending:      report.Pending,
+			Failed:       report.Failed,
+			Sent:         report.Sent,
+			Delivered:    report.Delivered,
+			Read:         report.Read,
+			OptedOut:     report.OptedOut,
+		}
+		if report.Sent > 0 {
+			sent := float64(report.Sent)
+			response.DeliveryRate = float64(report.Delivered) / sent
+			response.ReadRate = float64(report.Read) / sent
+			response.OptOutRate = float64(report.OptedOut) / sent
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+const (
+	defaultContextTokenBudget = 4000
+	maxContextTokenBudget     = 32000
+	maxContextMessages        = 2000
+)
+
+type ContextMessageResponse struct {
+	MessageID  string `json:"message_id"`
+	Seq        int64  `json:"seq"`
+	Timestamp  string `json:"timestamp"`
+	SenderID   string `json:"sender_id"`
+	SenderName string `json:"sender_name"`
+	IsFromMe   bool   `json:"is_from_me"`
+	Text       string `json:"text"`
+}
+
+type ChatContextResponse struct {
+	ChatJID         string                   `json:"chat_jid"`
+	ChatName        string                   `json:"chat_name,omitempty"`
+	TokenBudget     int                      `json:"token_budget"`
+	EstimatedTokens int                      `json:"estimated_tokens"`
+	Truncated       bool                     `json:"truncated"`
+	Messages        []ContextMessageResponse `json:"messages"`
+	Transcript      string                   `json:"transcript"`
+}
+
+// estimateTokens approximates LLM tokens using the common four-characters-per-token heuristic.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// mediaLabel describes media such as "voice note, 0:42", adding the duration
+// of audio and video when it is known.
+func mediaLabel(mediaType string, seconds uint32, voiceNote bool) string {
+	label := mediaType
+	if mediaType == "audio" && voiceNote {
+		label = "voice note"
+	}
+	if seconds > 0 && (mediaType == "audio" || mediaType == "video") {
+		label += fmt.Sprintf(", %d:%02d", seconds/60, seconds%60)
+	}
+	return label
+}
+
+// contextMessageText renders message content with a placeholder for media,
+// marking forwarded messages.
+func contextMessageText(msg storage.Message) string {
+	text := msg.Content
+	if msg.MediaType != "" {
+		placeholder := "[" + mediaLabel(msg.MediaType, msg.MediaSeconds, msg.VoiceNote) + "]"
+		if msg.MediaType == "document" && msg.Filename != "" {
+			placeholder = fmt.Sprintf("[document: %s]", msg.Filename)
+		}
+		text = strings.TrimSpace(placeholder + " " + msg.Content)
+	}
+	switch {
+	case msg.ForwardingScore >= storage.ForwardedManyTimesScore:
+		return "[forwarded many times] " + text
+	case msg.IsForwarded:
+		return "[forwarded] " + text
+	}
+	return text
+}
+
+// contextSenderName picks the best display name for a message author.
+func contextSenderName(msg storage.Message) string {
+	switch {
+	case msg.IsFromMe:
+		return "Me"
+	case msg.SenderName != "":
+		return msg.SenderName
+	case msg.Sender != "":
+		return msg.Sender
+	default:
+		return "Unknown"
+	}
+}
+
+func newContextMessageResponse(msg storage.Message, senderName string, text string, location *time.Location) ContextMessageResponse {
+	return ContextMessageResponse{
+		MessageID:  msg.ID,
+		Seq:        msg.Seq,
+		Timestamp:  formatTimestamp(msg.Time, location),
+		SenderID:   msg.Sender,
+		SenderName: senderName,
+		IsFromMe:   msg.IsFromMe,
+		Text:       text,
+	}
+}
+
+func formatContextLine(msg storage.Message, senderName string, text string, location *time.Location) string {
+	return fmt.Sprintf("[%s] %s: %s", msg.Time.In(location).Format("2006-01-02 15:04"), senderName, text)
+}
+
+// packMessagesToTokenBudget keeps the newest messages whose rendered lines fit
+// within budget and returns them in chronological order. The newest message is
+// truncated rather than dropped when it alone exceeds the budget.
+func packMessagesToTokenBudget(newestFirst []storage.Message, budget int, location *time.Location) ([]ContextMessageResponse, []string, int, bool) {
+	var packed []ContextMessageResponse
+	var lines []string
+	used := 0
+	truncated := false
+
+	for _, msg := range newestFirst {
+		text := contextMessageText(msg)
+		senderName := contextSenderName(msg)
+		line := formatContextLine(msg, senderName, text, location)
+		cost := estimateTokens(line) + 1
+
+		if used+cost > budget {
+			truncated = true
+			if len(packed) > 0 {
+				break
+			}
+			remaining := (budget-1)*4 - (len(line) - len(text)) - len("…")
+			if remaining <= 0 {
+				break
+			}
+			text = strings.ToValidUTF8(text[:remaining], "") + "…"
+			line = formatContextLine(msg, senderName, text, location)
+			cost = estimateTokens(line) + 1
+		}
+
+		used += cost
+		packed = append(packed, newContextMessageResponse(msg, senderName, text, location))
+		lines = append(lines, line)
+		if truncated {
+			break
+		}
+	}
+
+	for i, j := 0, len(packed)-1; i < j; i, j = i+1, j-1 {
+		packed[i], packed[j] = packed[j], packed[i]
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return packed, lines, used, truncated
+}
+
+// chatContextHandler returns recent chat history packed to an approximate token budget.
+func chatContextHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		budget := defaultContextTokenBudget
+		if raw := strings.TrimSpace(r.URL.Query().Get("tokens")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "Invalid tokens", http.StatusBadRequest)
+				return
+			}
+			budget = min(parsed, maxContextTokenBudget)
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		messages, err := messageStore.GetMessagesWithSenderNames(chatJID, maxContextMessages)
+		if err != nil {
+			http.Error(w, "Failed to load chat messages", http.StatusInternalServerError)
+			return
+		}
+		chatName, _ := messageStore.GetChatName(chatJID)
+
+		packed, lines, used, truncated := packMessagesToTokenBudget(messages, budget, location)
+		if len(messages) == maxContextMessages && !truncated {
+			truncated = true
+		}
+		if packed == nil {
+			packed = []ContextMessageResponse{}
+		}
+
+		writeJSON(w, http.StatusOK, ChatContextResponse{
+			ChatJID:         chatJID,
+			ChatName:        chatName,
+			TokenBudget:     budget,
+			EstimatedTokens: used,
+			Truncated:       truncated,
+			Messages:        packed,
+			Transcript:      strings.Join(lines, "\n"),
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+const maxDayMessages = 2000
+
+type ChatDayCountResponse struct {
+	Date           string `json:"date"`
+	Weekday        string `json:"weekday"`
+	MessageCount   int    `json:"message_count"`
+	IncomingCount  int    `json:"incoming_count"`
+	OutgoingCount  int    `json:"outgoing_count"`
+	FirstMessageAt string `json:"first_message_at"`
+	LastMessageAt  string `json:"last_message_at"`
+}
+
+type ChatDaysResponse struct {
+	ChatJID  string                 `json:"chat_jid"`
+	ChatName string                 `json:"chat_name,omitempty"`
+	Timezone string                 `json:"timezone"`
+	Days     []ChatDayCountResponse `json:"days"`
+}
+
+type ChatDayResponse struct {
+	ChatJID      string                   `json:"chat_jid"`
+	ChatName     string                   `json:"chat_name,omitempty"`
+	Date         string                   `json:"date"`
+	Weekday      string                   `json:"weekday"`
+	Timezone     string                   `json:"timezone"`
+	MessageCount int                      `json:"message_count"`
+	Truncated    bool                     `json:"truncated"`
+	Messages     []ContextMessageResponse `json:"messages"`
+	Transcript   string                   `json:"transcript"`
+}
+
+// chatDaysHandler lists the local calendar days on which a chat has messages,
+// newest first, with per-day counts.
+func chatDaysHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 30, 366)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		days, err := messageStore.GetChatDayCounts(chatJID, location, limit)
+		if err != nil {
+			http.Error(w, "Failed to load chat days", http.StatusInternalServerError)
+			return
+		}
+		chatName, _ := messageStore.GetChatName(chatJID)
+
+		response := ChatDaysResponse{
+			ChatJID:  chatJID,
+			ChatName: chatName,
+			Timezone: location.String(),
+			Days:     make([]ChatDayCountResponse, 0, len(days)),
+		}
+		for _, day := range days {
+			response.Days = append(response.Days, ChatDayCountResponse{
+				Date:           day.Date,
+				Weekday:        day.FirstAt.In(location).Weekday().String(),
+				MessageCount:   day.MessageCount,
+				IncomingCount:  day.IncomingCount,
+				OutgoingCount:  day.OutgoingCount,
+				FirstMessageAt: formatTimestamp(day.FirstAt, location),
+				LastMessageAt:  formatTimestamp(day.LastAt, location),
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// chatDayHandler returns a chat's messages from one local calendar day, oldest
+// first, with a readable transcript.
+func chatDayHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+		date, err := time.ParseInLocation(storage.DayLayout, strings.TrimSpace(r.PathValue("date")), location)
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		messages, err := messageStore.GetChatDayMessages(chatJID, date, location, maxDayMessages)
+		if err != nil {
+			http.Error(w, "Failed to load chat messages", http.StatusInternalServerError)
+			return
+		}
+		chatName, _ := messageStore.GetChatName(chatJID)
+
+		response := ChatDayResponse{
+			ChatJID:      chatJID,
+			ChatName:     chatName,
+			Date:         date.Format(storage.DayLayout),
+			Weekday:      date.Weekday().String(),
+			Timezone:     location.String(),
+			MessageCount: len(messages),
+			Truncated:    len(messages) == maxDayMessages,
+			Messages:     make([]ContextMessageResponse, 0, len(messages)),
+		}
+		lines := make([]string, 0, len(messages))
+		for _, msg := range messages {
+			text := contextMessageText(msg)
+			senderName := contextSenderName(msg)
+			response.Messages = append(response.Messages, newContextMessageResponse(msg, senderName, text, location))
+			lines = append(lines, formatContextLine(msg, senderName, text, location))
+		}
+		response.Transcript = strings.Join(lines, "\n")
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/chatexport"
+	"whatsapp-client/internal/jobs"
+	"whatsapp-client/internal/mediacrypt"
+	"whatsapp-client/internal/storage"
+)
+
+const chatExportJobKind = "chat_export"
+
+type ChatExportRequest struct {
+	// ChatJIDs limits the export to these chats; every chat is exported when empty.
+	ChatJIDs []string `json:"chat_jids,omitempty"`
+	Timezone string   `json:"tz,omitempty"`
+}
+
+// chatExportJob returns a job that writes one official-format export archive
+// per chat into dir.
+func chatExportJob(runtime *whatsAppRuntime, chatJIDs []string, dir string, location *time.Location, keys *mediacrypt.Keyring) jobs.Func {
+	return func(ctx context.Context, progress *jobs.Progress) error {
+		for _, chatJID := range chatJIDs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			messageStore := runtime.currentMessageStore()
+			if messageStore == nil {
+				return fmt.Errorf("message store is not initialized")
+			}
+
+			chatName, _ := messageStore.GetChatName(chatJID)
+			if chatName == "" {
+				chatName = chatJID
+			}
+			if _, err := chatexport.ExportChat(messageStore, chatJID, chatName, dir, location, keys); err != nil {
+				progress.Failed(chatJID, err, "")
+				continue
+			}
+			progress.Succeeded()
+		}
+		return nil
+	}
+}
+
+// chatExportHandler queues an export of chats in WhatsApp's own chat-export
+// format. The archives land in the exports directory named by the job subject.
+func chatExportHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ChatExportRequest
+		if r.ContentLength != 0 {
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+		}
+		location := time.UTC
+		if tz := strings.TrimSpace(req.Timezone); tz != "" {
+			loaded, err := time.LoadLocation(tz)
+			if err != nil {
+				http.Error(w, "Invalid tz", http.StatusBadRequest)
+				return
+			}
+			location = loaded
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		var chatJIDs []string
+		for _, chatJID := range req.ChatJIDs {
+			if chatJID = strings.TrimSpace(chatJID); chatJID != "" {
+				chatJIDs = append(chatJIDs, chatJID)
+			}
+		}
+		if len(chatJIDs) == 0 {
+			chats, err := messageStore.GetChats()
+			if err != nil {
+				http.Error(w, "Failed to load chats", http.StatusInternalServerError)
+				return
+			}
+			for _, chat := range chats {
+				chatJIDs = append(chatJIDs, chat.JID)
+			}
+			sort.Strings(chatJIDs)
+		}
+
+		keys, err := mediacrypt.KeyringFromEnv()
+		if err != nil {
+			http.Error(w, "Invalid media encryption key", http.StatusInternalServerError)
+			return
+		}
+		runtimePaths, err := storage.ResolveRuntimePathsFromEnv()
+		if err != nil {
+			http.Error(w, "Failed to resolve export directory", http.StatusInternalServerError)
+			return
+		}
+		dir := filepath.Join(runtimePaths.PersistentUserStorePath, "exports", time.Now().UTC().Format("20060102-150405"))
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			http.Error(w, "Failed to create export directory", http.StatusInternalServerError)
+			return
+		}
+
+		job, err := runtime.jobs.Start(chatExportJobKind, dir, len(chatJIDs), chatExportJob(runtime, chatJIDs, dir, location, keys))
+		if err != nil {
+			http.Error(w, "Failed to create job", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, newJobResponse(job))
+	}
+}
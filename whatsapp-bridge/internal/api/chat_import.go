@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/chatexport"
+	"whatsapp-client/internal/jobs"
+	"whatsapp-client/internal/mediacrypt"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/whatsapp"
+)
+
+const chatImportJobKind = "chat_import"
+
+type ChatImportRequest struct {
+	// Archive is the name of an export zip in the runtime imports directory.
+	Archive  string `json:"archive"`
+	ChatJID  string `json:"chat_jid"`
+	ChatName string `json:"chat_name,omitempty"`
+	// OwnName is the account owner's name as it appears in the export.
+	OwnName  string `json:"own_name,omitempty"`
+	Timezone string `json:"tz,omitempty"`
+}
+
+// importSummary describes a finished import for the job message.
+func importSummary(result chatexport.ImportResult) string {
+	summary := fmt.Sprintf("Imported %d messages (%d with media), skipped %d", result.Imported, result.Media, result.Skipped)
+	if len(result.UnmappedSenders) > 0 {
+		summary += "; unmatched senders: " + strings.Join(result.UnmappedSenders, ", ")
+	}
+	return summary
+}
+
+// chatImportJob returns a job that ingests one export archive.
+func chatImportJob(runtime *whatsAppRuntime, archivePath string, opts chatexport.ImportOptions) jobs.Func {
+	return func(ctx context.Context, progress *jobs.Progress) error {
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			return fmt.Errorf("message store is not initialized")
+		}
+		result, err := chatexport.ImportArchive(messageStore, archivePath, opts)
+		if err != nil {
+			return err
+		}
+		progress.SetMessage(importSummary(result))
+		progress.Succeeded()
+		return nil
+	}
+}
+
+// chatImportHandler queues an import of an official WhatsApp export zip,
+// backfilling history older than history sync provides. Archives are read
+// only from the imports directory next to the outbox.
+func chatImportHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ChatImportRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		archive := strings.TrimSpace(req.Archive)
+		if archive == "" || archive != filepath.Base(archive) || archive == "." || archive == ".." {
+			http.Error(w, "archive must be a file name in the imports directory", http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.ChatJID) == "" {
+			http.Error(w, "chat_jid is required", http.StatusBadRequest)
+			return
+		}
+		location := time.UTC
+		if tz := strings.TrimSpace(req.Timezone); tz != "" {
+			loaded, err := time.LoadLocation(tz)
+			if err != nil {
+				http.Error(w, "Invalid tz", http.StatusBadRequest)
+				return
+			}
+			location = loaded
+		}
+
+		runtimePaths, err := storage.ResolveRuntimePathsFromEnv()
+		if err != nil {
+			http.Error(w, "Failed to resolve imports directory", http.StatusInternalServerError)
+			return
+		}
+		archivePath := filepath.Join(runtimePaths.PersistentUserStorePath, "imports", archive)
+		if info, err := os.Stat(archivePath); err != nil || info.IsDir() {
+			http.Error(w, "Archive not found", http.StatusNotFound)
+			return
+		}
+
+		if runtime.currentMessageStore() == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		keys, err := mediacrypt.KeyringFromEnv()
+		if err != nil {
+			http.Error(w, "Invalid media encryption key", http.StatusInternalServerError)
+			return
+		}
+		policy := whatsapp.MediaPolicyFromEnv()
+		opts := chatexport.ImportOptions{
+			ChatJID:    req.ChatJID,
+			ChatName:   strings.TrimSpace(req.ChatName),
+			OwnName:    strings.TrimSpace(req.OwnName),
+			MediaDir:   runtimePaths.HotMediaRoot,
+			MediaKeys:  keys,
+			Location:   location,
+			CheckMedia: policy.CheckDownload,
+		}
+		job, err := runtime.jobs.Start(chatImportJobKind, archive, 1, chatImportJob(runtime, archivePath, opts))
+		if err != nil {
+			http.Error(w, "Failed to create job", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, newJobResponse(job))
+	}
+}
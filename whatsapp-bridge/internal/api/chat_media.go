@@ -0,0 +1,227 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"whatsapp-client/internal/mediacrypt"
+)
+
+type ChatMediaItemResponse struct {
+	MessageID        string `json:"message_id"`
+	SenderID         string `json:"sender_id"`
+	Timestamp        string `json:"timestamp"`
+	IsFromMe         bool   `json:"is_from_me"`
+	MediaType        string `json:"media_type"`
+	Filename         string `json:"filename,omitempty"`
+	FileLength       uint64 `json:"file_length"`
+	DownloadState    string `json:"download_state"`
+	Path             string `json:"path,omitempty"`
+	ThumbnailDataURL string `json:"thumbnail_data_url,omitempty"`
+}
+
+type ChatMediaTotalResponse struct {
+	MediaType  string `json:"media_type"`
+	Count      int    `json:"count"`
+	TotalBytes uint64 `json:"total_bytes"`
+}
+
+type ChatMediaResponse struct {
+	ChatJID string                   `json:"chat_jid"`
+	Items   []ChatMediaItemResponse  `json:"items"`
+	Totals  []ChatMediaTotalResponse `json:"totals"`
+}
+
+var supportedMediaTypes = map[string]struct{}{
+	"image":    {},
+	"video":    {},
+	"audio":    {},
+	"document": {},
+}
+
+// parseLimitParam reads a positive "limit" query parameter capped at maxLimit.
+func parseLimitParam(r *http.Request, defaultLimit int, maxLimit int) (int, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get("limit"))
+	if raw == "" {
+		return defaultLimit, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		return 0, false
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	return limit, true
+}
+
+// mediaDownloadState reports whether media is cached locally, fetchable, or gone.
+func mediaDownloadState(localPath string, downloadable bool) (string, string) {
+	if localPath != "" {
+		if _, err := os.Stat(localPath); err == nil {
+			return "downloaded", localPath
+		}
+	}
+	if downloadable {
+		return "available", ""
+	}
+	return "unavailable", ""
+}
+
+// chatMediaHandler lists a chat's media messages with download state and per-type totals.
+func chatMediaHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+		mediaType := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("type")))
+		if _, ok := supportedMediaTypes[mediaType]; mediaType != "" && !ok {
+			http.Error(w, "Unsupported media type", http.StatusBadRequest)
+			return
+		}
+		limit, ok := parseLimitParam(r, 50, 500)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		items, err := messageStore.GetChatMedia(chatJID, mediaType, limit)
+		if err != nil {
+			http.Error(w, "Failed to load chat media", http.StatusInternalServerError)
+			return
+		}
+		totals, err := messageStore.GetChatMediaTotals(chatJID)
+		if err != nil {
+			http.Error(w, "Failed to load chat media totals", http.StatusInternalServerError)
+			return
+		}
+
+		response := ChatMediaResponse{
+			ChatJID: chatJID,
+			Items:   make([]ChatMediaItemResponse, 0, len(items)),
+			Totals:  make([]ChatMediaTotalResponse, 0, len(totals)),
+		}
+		for _, item := range items {
+			state, path := mediaDownloadState(item.LocalPath, item.Downloadable)
+			entry := ChatMediaItemResponse{
+				MessageID:     item.ID,
+				SenderID:      item.Sender,
+				Timestamp:     formatTimestamp(item.Timestamp, location),
+				IsFromMe:      item.IsFromMe,
+				MediaType:     item.MediaType,
+				Filename:      item.Filename,
+				FileLength:    item.FileLength,
+				DownloadState: state,
+				Path:          path,
+			}
+			if len(item.Thumbnail) > 0 {
+				entry.ThumbnailDataURL = "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(item.Thumbnail)
+			}
+			response.Items = append(response.Items, entry)
+		}
+		for _, total := range totals {
+			response.Totals = append(response.Totals, ChatMediaTotalResponse{
+				MediaType:  total.MediaType,
+				Count:      total.Count,
+				TotalBytes: total.TotalBytes,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// messageMediaHandler serves a downloaded media file's contents, decrypting
+// it when media is encrypted at rest. The file is always sent as an
+// attachment so browsers never render it inline.
+func messageMediaHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageID := strings.TrimSpace(r.PathValue("id"))
+		chatJID := strings.TrimSpace(r.URL.Query().Get("chat_jid"))
+		if messageID == "" || chatJID == "" {
+			http.Error(w, "Message ID and chat_jid are required", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		raw, err := messageStore.GetRawMessage(messageID, chatJID)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load message", http.StatusInternalServerError)
+			return
+		}
+		if raw.LocalPath == "" {
+			http.Error(w, "Media has not been downloaded", http.StatusNotFound)
+			return
+		}
+
+		keys, err := mediacrypt.KeyringFromEnv()
+		if err != nil {
+			http.Error(w, "Invalid media encryption key", http.StatusInternalServerError)
+			return
+		}
+		file, size, err := mediacrypt.Open(raw.LocalPath, keys)
+		if os.IsNotExist(err) {
+			http.Error(w, "Media file no longer exists", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to read media file", http.StatusInternalServerError)
+			return
+		}
+		defer file.Close()
+
+		filename := filepath.Base(raw.LocalPath)
+		contentType := mime.TypeByExtension(filepath.Ext(filename))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		// A failed copy means the client went away or the file is corrupt;
+		// the status line is already sent, so the response is just cut short.
+		io.Copy(w, file)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"whatsapp-client/internal/jid"
+)
+
+// maxChatNameLength bounds a chat name override, in characters.
+const maxChatNameLength = 256
+
+type ChatNameRequest struct {
+	Name string `json:"name"`
+}
+
+type ChatNameResponse struct {
+	ChatJID string `json:"chat_jid"`
+	Name    string `json:"name"`
+	// SyncedName is the name synced from WhatsApp, restored when the
+	// override is cleared.
+	SyncedName string `json:"synced_name,omitempty"`
+	Overridden bool   `json:"overridden"`
+	UpdatedAt  string `json:"updated_at,omitempty"`
+}
+
+// chatNameHandler sets (PUT) or clears (DELETE) a local display name for a
+// chat that takes precedence over names synced from WhatsApp.
+func chatNameHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := jid.NormalizeChat(strings.TrimSpace(r.PathValue("jid")))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		var req ChatNameRequest
+		if r.Method == http.MethodPut {
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+			req.Name = strings.TrimSpace(req.Name)
+			if req.Name == "" {
+				http.Error(w, "name is required", http.StatusBadRequest)
+				return
+			}
+			if utf8.RuneCountInString(req.Name) > maxChatNameLength {
+				http.Error(w, "name is too long", http.StatusBadRequest)
+				return
+			}
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			name, err := messageStore.ClearChatNameOverride(chatJID)
+			if err == sql.ErrNoRows {
+				http.Error(w, "Chat has no name override", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Failed to clear chat name", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, ChatNameResponse{ChatJID: chatJID, Name: name, SyncedName: name})
+			return
+		}
+
+		override, err := messageStore.SetChatNameOverride(chatJID, req.Name)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Chat not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to save chat name", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, ChatNameResponse{
+			ChatJID:    override.ChatJID,
+			Name:       override.Name,
+			SyncedName: override.SyncedName,
+			Overridden: true,
+			UpdatedAt:  formatOptionalTime(&override.UpdatedAt),
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/whatsapp"
+)
+
+const (
+	chatReadOnlyErrorCode = "chat_read_only"
+	chatHandoffErrorCode  = "chat_human_handoff"
+	optedOutErrorCode     = "recipient_opted_out"
+)
+
+type ChatSettingsResponse struct {
+	ChatJID      string `json:"chat_jid"`
+	ReadOnly     bool   `json:"read_only"`
+	HumanHandoff bool   `json:"human_handoff"`
+	HandoffBy    string `json:"handoff_by,omitempty"`
+	HandoffAt    string `json:"handoff_at,omitempty"`
+	Tracked      bool   `json:"tracked"`
+	SnoozedUntil string `json:"snoozed_until,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+}
+
+type ChatSettingsListResponse struct {
+	Chats []ChatSettingsResponse `json:"chats"`
+}
+
+type UpdateChatSettingsRequest struct {
+	ReadOnly *bool `json:"read_only"`
+}
+
+type ChatTrackingRequest struct {
+	Tracked *bool `json:"tracked"`
+}
+
+type ChatTrackingResponse struct {
+	ChatJID string `json:"chat_jid"`
+	Tracked bool   `json:"tracked"`
+	// Mode is the bridge's ingest mode: all or allowlist.
+	Mode string `json:"mode"`
+	// Stored reports whether new messages in the chat are persisted in full.
+	Stored bool `json:"stored"`
+	// Untracked is, in allowlist mode, what happens to messages in untracked
+	// chats: stream, drop or redact.
+	Untracked string `json:"untracked,omitempty"`
+}
+
+type ChatHandoffRequest struct {
+	By string `json:"by,omitempty"`
+}
+
+type ChatSnoozeRequest struct {
+	Until string `json:"until"`
+}
+
+const snoozeCheckInterval = 30 * time.Second
+
+// chatPolicyErrorStatus maps a chat send-policy rejection to its error code and HTTP status.
+func chatPolicyErrorStatus(err error) (string, int, bool) {
+	switch {
+	case errors.Is(err, whatsapp.ErrChatReadOnly):
+		return chatReadOnlyErrorCode, http.StatusForbidden, true
+	case errors.Is(err, whatsapp.ErrChatHandoff):
+		return chatHandoffErrorCode, http.StatusConflict, true
+	case errors.Is(err, whatsapp.ErrRecipientOptedOut):
+		return optedOutErrorCode, http.StatusForbidden, true
+	default:
+		return "", 0, false
+	}
+}
+
+func newChatSettingsResponse(settings storage.ChatSettings) ChatSettingsResponse {
+	response := ChatSettingsResponse{
+		ChatJID:      settings.ChatJID,
+		ReadOnly:     settings.ReadOnly,
+		HumanHandoff: settings.HumanHandoff,
+		HandoffBy:    settings.HandoffBy,
+		HandoffAt:    formatOptionalTime(settings.HandoffAt),
+		Tracked:      settings.Tracked,
+		SnoozedUntil: formatOptionalTime(settings.SnoozedUntil),
+	}
+	if !settings.UpdatedAt.IsZero() {
+		response.UpdatedAt = settings.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return response
+}
+
+// chatSettingsListHandler lists chats with stored settings, such as read-only chats.
+func chatSettingsListHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		settings, err := messageStore.ListChatSettings()
+		if err != nil {
+			http.Error(w, "Failed to load chat settings", http.StatusInternalServerError)
+			return
+		}
+
+		response := ChatSettingsListResponse{Chats: make([]ChatSettingsResponse, 0, len(settings))}
+		for _, entry := range settings {
+			response.Chats = append(response.Chats, newChatSettingsResponse(entry))
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// chatSettingsHandler returns (GET) or updates (PUT) a chat's settings.
+func chatSettingsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			settings, err := messageStore.GetChatSettings(chatJID)
+			if err != nil {
+				http.Error(w, "Failed to load chat settings", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, newChatSettingsResponse(settings))
+			return
+		}
+
+		var req UpdateChatSettingsRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.ReadOnly == nil {
+			http.Error(w, "read_only is required", http.StatusBadRequest)
+			return
+		}
+
+		settings, err := messageStore.SetChatReadOnly(chatJID, *req.ReadOnly)
+		if err != nil {
+			http.Error(w, "Failed to save chat settings", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newChatSettingsResponse(settings))
+	}
+}
+
+// chatHandoffHandler starts (POST) or ends (DELETE) a human takeover of a chat.
+// Automated sends to the chat are refused until the takeover is cleared.
+func chatHandoffHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		var req ChatHandoffRequest
+		if r.Method == http.MethodPost && r.ContentLength != 0 {
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		settings, err := messageStore.SetChatHandoff(chatJID, r.Method == http.MethodPost, req.By)
+		if err != nil {
+			http.Error(w, "Failed to save chat settings", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newChatSettingsResponse(settings))
+	}
+}
+
+// chatSnoozeHandler snoozes a chat until a time (PUT) or returns it to the
+// inbox now (DELETE). A snoozed chat is left out of needs-attention listings
+// until the time passes or an incoming message arrives, and either emits
+// chat.returned_to_inbox.
+func chatSnoozeHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		var until *time.Time
+		if r.Method == http.MethodPut {
+			var req ChatSnoozeRequest
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+			parsed, ok := parseOptionalTime(req.Until)
+			if !ok || parsed == nil {
+				http.Error(w, "until must be an RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+			if !parsed.After(time.Now()) {
+				http.Error(w, "until must be in the future", http.StatusBadRequest)
+				return
+			}
+			until = parsed
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		settings, err := messageStore.SetChatSnooze(chatJID, until)
+		if err != nil {
+			http.Error(w, "Failed to save chat settings", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newChatSettingsResponse(settings))
+	}
+}
+
+// startSnoozeWorker returns chats to the inbox as their snoozes end.
+func startSnoozeWorker(runtime *whatsAppRuntime) {
+	go func() {
+		ticker := time.NewTicker(snoozeCheckInterval)
+		defer ticker.Stop()
+		for {
+			if messageStore := runtime.currentMessageStore(); messageStore != nil {
+				woken, err := messageStore.ExpireChatSnoozes(time.Now())
+				if err != nil {
+					fmt.Printf("Warning: failed to expire chat snoozes: %v\n", err)
+				}
+				for _, settings := range woken {
+					whatsapp.EmitChatReturned(runtime.webhooks, settings.ChatJID, *settings.SnoozedUntil, nil)
+				}
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// chatTrackingHandler returns (GET) or sets (PUT) whether a chat is tracked.
+// With WHATSAPP_INGEST_MODE=allowlist only tracked chats are stored; in the
+// default mode tracking is recorded but every chat is stored.
+func chatTrackingHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		var req ChatTrackingRequest
+		if r.Method == http.MethodPut {
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+			if req.Tracked == nil {
+				http.Error(w, "tracked is required", http.StatusBadRequest)
+				return
+			}
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		settings, err := messageStore.GetChatSettings(chatJID)
+		if r.Method == http.MethodPut {
+			settings, err = messageStore.SetChatTracking(chatJID, *req.Tracked)
+		}
+		if err != nil {
+			http.Error(w, "Failed to update chat tracking", http.StatusInternalServerError)
+			return
+		}
+
+		rules := whatsapp.IngestRulesFromEnv()
+		response := ChatTrackingResponse{
+			ChatJID: settings.ChatJID,
+			Tracked: settings.Tracked,
+			Mode:    rules.Mode(),
+			Stored:  !rules.Allowlist || settings.Tracked,
+		}
+		if rules.Allowlist {
+			response.Untracked = rules.Untracked
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
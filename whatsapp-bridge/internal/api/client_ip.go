@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// trustedProxies are the networks whose X-Forwarded-For headers are believed.
+type trustedProxies []netip.Prefix
+
+// trustedProxiesFromEnv parses WHATSAPP_BRIDGE_TRUSTED_PROXIES, a
+// comma-separated list of proxy IPs or CIDR ranges. Invalid entries are
+// skipped with a warning.
+func trustedProxiesFromEnv() trustedProxies {
+	var proxies trustedProxies
+	for _, part := range strings.Split(os.Getenv("WHATSAPP_BRIDGE_TRUSTED_PROXIES"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(part); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(part); err == nil {
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		fmt.Printf("Warning: invalid WHATSAPP_BRIDGE_TRUSTED_PROXIES entry %q, ignoring\n", part)
+	}
+	return proxies
+}
+
+func (p trustedProxies) contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client behind any trusted proxies.
+// X-Forwarded-For is only read when the direct peer is trusted, and is walked
+// from the nearest hop outwards, so a client cannot spoof its address by
+// sending the header itself: the first untrusted hop is the client.
+func (p trustedProxies) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	if !p.contains(peer) {
+		return peer.Unmap().String()
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	client := peer.Unmap().String()
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// A malformed hop ends the trusted chain; the last good hop is
+			// the best answer.
+			break
+		}
+		client = hop.Unmap().String()
+		if !p.contains(hop) {
+			break
+		}
+	}
+	return client
+}
+
+// withClientIP records each request's client address for handlers and logs.
+func withClientIP(proxies trustedProxies, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientIPKey{}, proxies.clientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestClientIP returns the client address recorded by withClientIP,
+// falling back to the direct peer.
+func requestClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// isLoopbackHost reports whether a bind host only accepts local connections.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	return err == nil && addr.IsLoopback()
+}
+
+// warnExposedBind prints a prominent warning when the API listens beyond
+// loopback without TLS, or with a weak JWT secret, since bridge tokens and
+// message content then cross the network in the clear or can be forged.
+func warnExposedBind(host string, tls bool, authConfig bridgeAuthConfig) {
+	if isLoopbackHost(host) {
+		return
+	}
+	var problems []string
+	if !tls {
+		problems = append(problems, "TLS is not enabled (set WHATSAPP_BRIDGE_TLS_CERT_FILE and WHATSAPP_BRIDGE_TLS_KEY_FILE, or terminate TLS in a proxy on the same host)")
+	}
+	if secret, err := authConfig.jwtSecret(); err == nil && len(secret) < minExposedJWTSecretBytes {
+		problems = append(problems, fmt.Sprintf("WHATSAPP_BRIDGE_JWT_SECRET is shorter than %d bytes", minExposedJWTSecretBytes))
+	}
+	if !authConfig.requireAccountID {
+		problems = append(problems, "WHATSAPP_BRIDGE_JWT_REQUIRE_ACCOUNT_ID is off")
+	}
+	if len(problems) == 0 {
+		return
+	}
+	fmt.Println("==========================================================================")
+	fmt.Printf("WARNING: the bridge API is listening on %s, reachable from other hosts, but:\n", host)
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+	fmt.Println("Bind to 127.0.0.1 (WHATSAPP_BRIDGE_HOST) unless the API must be exposed.")
+	fmt.Println("==========================================================================")
+}
+
+// minExposedJWTSecretBytes is the shortest JWT secret not flagged when the API
+// is exposed; HS256 keys should be at least as long as the hash.
+const minExposedJWTSecretBytes = 32
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestTrustedProxiesClientIP(t *testing.T) {
+	proxies := trustedProxies{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.1/32"),
+	}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{name: "no proxy", remoteAddr: "203.0.113.7:4000", want: "203.0.113.7"},
+		{name: "untrusted peer ignores forwarded header", remoteAddr: "203.0.113.7:4000", forwarded: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "trusted peer without header", remoteAddr: "10.0.0.2:4000", want: "10.0.0.2"},
+		{name: "single trusted hop", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "chain of trusted hops", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.1, 10.1.1.1, 192.168.1.1"}, want: "198.51.100.1"},
+		{name: "chain across headers", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.1", "10.1.1.1"}, want: "198.51.100.1"},
+		{name: "spoofed hop beyond client", remoteAddr: "10.0.0.2:4000", forwarded: []string{"1.2.3.4, 198.51.100.1, 10.1.1.1"}, want: "198.51.100.1"},
+		{name: "malformed hop ends chain", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.1, not-an-ip, 10.1.1.1"}, want: "10.1.1.1"},
+		{name: "malformed nearest hop", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.1, bogus"}, want: "10.0.0.2"},
+		{name: "mapped trusted peer", remoteAddr: "[::ffff:10.0.0.2]:4000", forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "mapped untrusted peer", remoteAddr: "[::ffff:203.0.113.7]:4000", forwarded: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "mapped hop", remoteAddr: "10.0.0.2:4000", forwarded: []string{"::ffff:198.51.100.1"}, want: "198.51.100.1"},
+		{name: "remote addr without port", remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/health", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := proxies.clientIP(r); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
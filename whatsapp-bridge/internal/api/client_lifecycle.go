@@ -0,0 +1,117 @@
+package api
+
+import "sync"
+
+// clientState is where the runtime's WhatsApp client is in its lifecycle.
+type clientState string
+
+const (
+	// clientIdle: no client exists.
+	clientIdle clientState = "idle"
+	// clientStarting: one caller is building the client; others wait for it.
+	clientStarting clientState = "starting"
+	// clientReady: the client is built and shared.
+	clientReady clientState = "ready"
+	// clientStopping: a caller took the client to disconnect or revoke it;
+	// no new client is built until it releases the lifecycle.
+	clientStopping clientState = "stopping"
+)
+
+// clientLifecycle makes sure at most one client exists for the linked device.
+// Building is single-flight, and building waits for a disconnect or revoke in
+// progress, so two sockets are never open for one device.
+type clientLifecycle[T comparable] struct {
+	mu     sync.Mutex
+	status clientState
+	client T
+	// changed is closed and replaced on every state change.
+	changed chan struct{}
+}
+
+// ensure returns the ready client, building it with build when there is none.
+// Concurrent callers share one build.
+func (l *clientLifecycle[T]) ensure(build func() (T, error)) (T, error) {
+	l.mu.Lock()
+	l.waitLocked()
+	if l.status == clientReady {
+		client := l.client
+		l.mu.Unlock()
+		return client, nil
+	}
+	var zero T
+	l.setLocked(clientStarting, zero)
+	l.mu.Unlock()
+
+	client, err := build()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		l.setLocked(clientIdle, zero)
+		return zero, err
+	}
+	l.setLocked(clientReady, client)
+	return client, nil
+}
+
+// current returns the ready client, or the zero value while there is none.
+func (l *clientLifecycle[T]) current() T {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.status == clientReady {
+		return l.client
+	}
+	var zero T
+	return zero
+}
+
+// stop takes the client out of the lifecycle, after any build in progress,
+// and holds the lifecycle in clientStopping until release is called. The
+// returned client is the zero value when there was none.
+func (l *clientLifecycle[T]) stop() (client T, release func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waitLocked()
+	client = l.client
+	var zero T
+	l.setLocked(clientStopping, zero)
+
+	var once sync.Once
+	return client, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.setLocked(clientIdle, zero)
+		})
+	}
+}
+
+// state returns the current lifecycle state.
+func (l *clientLifecycle[T]) state() clientState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.status == "" {
+		return clientIdle
+	}
+	return l.status
+}
+
+// waitLocked blocks, with l.mu held on entry and exit, until no build or
+// stop is in progress.
+func (l *clientLifecycle[T]) waitLocked() {
+	for l.status == clientStarting || l.status == clientStopping {
+		changed := l.changed
+		l.mu.Unlock()
+		<-changed
+		l.mu.Lock()
+	}
+}
+
+func (l *clientLifecycle[T]) setLocked(status clientState, client T) {
+	l.status = status
+	l.client = client
+	if l.changed != nil {
+		close(l.changed)
+	}
+	l.changed = make(chan struct{})
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeClient struct{ id int }
+
+func TestClientLifecycleBuildsOnce(t *testing.T) {
+	var lifecycle clientLifecycle[*fakeClient]
+	var builds atomic.Int32
+	unblock := make(chan struct{})
+	build := func() (*fakeClient, error) {
+		<-unblock
+		return &fakeClient{id: int(builds.Add(1))}, nil
+	}
+
+	var wg sync.WaitGroup
+	clients := make([]*fakeClient, 8)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, err := lifecycle.ensure(build)
+			if err != nil {
+				t.Errorf("ensure: %v", err)
+			}
+			clients[i] = client
+		}(i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(unblock)
+	wg.Wait()
+
+	if got := builds.Load(); got != 1 {
+		t.Fatalf("expected one build, got %d", got)
+	}
+	for _, client := range clients {
+		if client != clients[0] {
+			t.Fatal("expected every caller to share the built client")
+		}
+	}
+	if state := lifecycle.state(); state != clientReady {
+		t.Fatalf("expected %s, got %s", clientReady, state)
+	}
+}
+
+func TestClientLifecycleBuildFailure(t *testing.T) {
+	var lifecycle clientLifecycle[*fakeClient]
+	if _, err := lifecycle.ensure(func() (*fakeClient, error) { return nil, errors.New("boom") }); err == nil {
+		t.Fatal("expected the build error")
+	}
+	if state := lifecycle.state(); state != clientIdle {
+		t.Fatalf("expected %s after a failed build, got %s", clientIdle, state)
+	}
+	client, err := lifecycle.ensure(func() (*fakeClient, error) { return &fakeClient{id: 1}, nil })
+	if err != nil || client == nil {
+		t.Fatalf("expected a retry to build, got %v, %v", client, err)
+	}
+}
+
+func TestClientLifecycleStopBlocksBuild(t *testing.T) {
+	var lifecycle clientLifecycle[*fakeClient]
+	first, _ := lifecycle.ensure(func() (*fakeClient, error) { return &fakeClient{id: 1}, nil })
+
+	stopped, release := lifecycle.stop()
+	if stopped != first {
+		t.Fatal("expected stop to hand over the ready client")
+	}
+	if lifecycle.current() != nil {
+		t.Fatal("expected no current client while stopping")
+	}
+
+	built := make(chan *fakeClient)
+	go func() {
+		client, _ := lifecycle.ensure(func() (*fakeClient, error) { return &fakeClient{id: 2}, nil })
+		built <- client
+	}()
+	select {
+	case <-built:
+		t.Fatal("expected the build to wait for the stop to be released")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+	release()
+	if client := <-built; client == nil || client.id != 2 {
+		t.Fatalf("expected a fresh client after release, got %+v", client)
+	}
+}
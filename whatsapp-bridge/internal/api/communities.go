@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/whatsapp"
+)
+
+type CommunityGroupResponse struct {
+	JID            string `json:"jid"`
+	Name           string `json:"name,omitempty"`
+	IsAnnouncement bool   `json:"is_announcement"`
+}
+
+type CommunityResponse struct {
+	JID             string                   `json:"jid"`
+	Name            string                   `json:"name,omitempty"`
+	AnnouncementJID string                   `json:"announcement_jid,omitempty"`
+	Groups          []CommunityGroupResponse `json:"groups"`
+}
+
+type CommunitiesResponse struct {
+	Communities []CommunityResponse `json:"communities"`
+}
+
+// communitiesHandler lists communities with their linked groups. With
+// refresh=true the structure is re-read from WhatsApp first.
+func communitiesHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		refresh := false
+		if raw := strings.TrimSpace(r.URL.Query().Get("refresh")); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				http.Error(w, "Invalid refresh", http.StatusBadRequest)
+				return
+			}
+			refresh = parsed
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if refresh {
+			client := runtime.currentClient()
+			if client == nil {
+				http.Error(w, "WhatsApp client is not initialized. Start connect first.", http.StatusServiceUnavailable)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+			defer cancel()
+			if err := whatsapp.SyncCommunities(ctx, client, messageStore); err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+		}
+
+		communities, err := messageStore.ListCommunities()
+		if err != nil {
+			http.Error(w, "Failed to load communities", http.StatusInternalServerError)
+			return
+		}
+
+		response := CommunitiesResponse{Communities: make([]CommunityResponse, 0, len(communities))}
+		for _, community := range communities {
+			entry := CommunityResponse{
+				JID:             community.JID,
+				Name:            community.Name,
+				AnnouncementJID: community.AnnouncementJID,
+				Groups:          make([]CommunityGroupResponse, 0, len(community.Groups)),
+			}
+			for _, group := range community.Groups {
+				entry.Groups = append(entry.Groups, CommunityGroupResponse{
+					JID:            group.GroupJID,
+					Name:           group.Name,
+					IsAnnouncement: group.IsAnnouncement,
+				})
+			}
+			response.Communities = append(response.Communities, entry)
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/bootstrap"
+	"whatsapp-client/internal/whatsapp"
+)
+
+type ConnectionQualityResponse struct {
+	LastRTTMS             int64  `json:"last_rtt_ms"`
+	AverageRTTMS          int64  `json:"average_rtt_ms"`
+	LastProbeAt           string `json:"last_probe_at,omitempty"`
+	FailedProbes          int    `json:"failed_probes"`
+	LastKeepaliveAt       string `json:"last_keepalive_at,omitempty"`
+	MissedKeepalives      int    `json:"missed_keepalives"`
+	TotalMissedKeepalives int    `json:"total_missed_keepalives"`
+	Reconnects            int    `json:"reconnects"`
+	LastReconnectAt       string `json:"last_reconnect_at,omitempty"`
+}
+
+// AccountHealthResponse is the ban-risk score of the account, from 100 down
+// to 0, with advice on backing off.
+type AccountHealthResponse struct {
+	Score             int      `json:"score"`
+	Level             string   `json:"level"`
+	Advisories        []string `json:"advisories,omitempty"`
+	SendsSucceeded    int      `json:"sends_succeeded"`
+	SendsFailed       int      `json:"sends_failed"`
+	RecentFailures    int      `json:"recent_failures"`
+	RateLimited       int      `json:"rate_limited"`
+	Disconnects       int      `json:"disconnects"`
+	TemporaryBan      string   `json:"temporary_ban,omitempty"`
+	TemporaryBanUntil string   `json:"temporary_ban_until,omitempty"`
+}
+
+type AdminHealthResponse struct {
+	Status           string                    `json:"status"`
+	State            string                    `json:"state"`
+	Connected        bool                      `json:"connected"`
+	DisconnectReason string                    `json:"disconnect_reason,omitempty"`
+	Connection       ConnectionQualityResponse `json:"connection"`
+	Account          AccountHealthResponse     `json:"account"`
+	UpdatedAt        string                    `json:"updated_at"`
+}
+
+// formatTimeIfSet formats value, or returns "" for the zero time.
+func formatTimeIfSet(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339)
+}
+
+// startConnectionMonitor probes the WhatsApp connection on an interval and
+// reconnects when keepalives go unanswered or round trips grow too slow.
+func startConnectionMonitor(runtime *whatsAppRuntime, cfg whatsapp.ConnectionMonitorConfig) {
+	if cfg.ProbeInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(cfg.ProbeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			client := runtime.currentClient()
+			reason, reconnect := whatsapp.ProbeConnection(context.Background(), client, cfg)
+			if !reconnect {
+				continue
+			}
+			fmt.Printf("WhatsApp connection degraded (%s), reconnecting\n", reason)
+			client.Disconnect()
+			if err := bootstrap.ConnectClient(client); err != nil {
+				fmt.Printf("WhatsApp proactive reconnect failed: %v\n", err)
+			}
+		}
+	}()
+}
+
+// adminHealthHandler serves GET /api/admin/health: the auth state together
+// with keepalive and probe round-trip metrics and the account's ban-risk
+// score.
+func adminHealthHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		status := bootstrap.GetAuthStatus()
+		client := runtime.currentClient()
+		connected := client != nil && client.IsConnected()
+		quality := whatsapp.GetConnectionQuality()
+		account := whatsapp.GetAccountHealth()
+		health := "ok"
+		if !connected || quality.MissedKeepalives > 0 || quality.FailedProbes > 0 || account.Level != whatsapp.AccountHealthOK {
+			health = "degraded"
+		}
+
+		writeJSON(w, http.StatusOK, AdminHealthResponse{
+			Status:           health,
+			State:            status.State,
+			Connected:        connected,
+			DisconnectReason: string(status.DisconnectReason),
+			Connection: ConnectionQualityResponse{
+				LastRTTMS:             quality.LastRTT.Milliseconds(),
+				AverageRTTMS:          quality.AverageRTT.Milliseconds(),
+				LastProbeAt:           formatTimeIfSet(quality.LastProbeAt),
+				FailedProbes:          quality.FailedProbes,
+				LastKeepaliveAt:       formatTimeIfSet(quality.LastKeepaliveAt),
+				MissedKeepalives:      quality.MissedKeepalives,
+				TotalMissedKeepalives: quality.TotalMissedKeepalives,
+				Reconnects:            quality.Reconnects,
+				LastReconnectAt:       formatTimeIfSet(quality.LastReconnectAt),
+			},
+			Account: AccountHealthResponse{
+				Score:             account.Score,
+				Level:             account.Level,
+				Advisories:        account.Advisories,
+				SendsSucceeded:    account.SendsSucceeded,
+				SendsFailed:       account.SendsFailed,
+				RecentFailures:    account.RecentFailures,
+				RateLimited:       account.RateLimited,
+				Disconnects:       account.Disconnects,
+				TemporaryBan:      account.TemporaryBan,
+				TemporaryBanUntil: formatTimeIfSet(account.TemporaryBanUntil),
+			},
+			UpdatedAt: status.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+}
+
+// metricsHandler serves GET /metrics in the Prometheus text format.
+func metricsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		client := runtime.currentClient()
+		connected := 0.0
+		if client != nil && client.IsConnected() {
+			connected = 1
+		}
+		quality := whatsapp.GetConnectionQuality()
+		account := whatsapp.GetAccountHealth()
+		unixSeconds := func(value time.Time) float64 {
+			if value.IsZero() {
+				return 0
+			}
+			return float64(value.UnixMilli()) / 1000
+		}
+
+		var out strings.Builder
+		metric := func(name, kind, help string, value float64) {
+			fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, kind, name, value)
+		}
+		metric("whatsapp_connected", "gauge", "Whether the WhatsApp websocket is connected.", connected)
+		metric("whatsapp_probe_rtt_seconds", "gauge", "Round trip of the latest connection probe.", quality.LastRTT.Seconds())
+		metric("whatsapp_probe_rtt_average_seconds", "gauge", "Moving average round trip of connection probes.", quality.AverageRTT.Seconds())
+		metric("whatsapp_probe_failures", "gauge", "Consecutive connection probes without an answer.", float64(quality.FailedProbes))
+		metric("whatsapp_last_keepalive_timestamp_seconds", "gauge", "Unix time of the last keepalive known to succeed.", unixSeconds(quality.LastKeepaliveAt))
+		metric("whatsapp_keepalives_missed", "gauge", "Consecutive keepalives without an answer.", float64(quality.MissedKeepalives))
+		metric("whatsapp_keepalives_missed_total", "counter", "Keepalives without an answer since start.", float64(quality.TotalMissedKeepalives))
+		metric("whatsapp_reconnects_total", "counter", "Proactive reconnects after the connection degraded.", float64(quality.Reconnects))
+		metric("whatsapp_account_health_score", "gauge", "Account health from 100 (no warning signs) to 0 (restricted).", float64(account.Score))
+		metric("whatsapp_sends_failed_last_hour", "gauge", "Sends WhatsApp refused in the last hour.", float64(account.SendsFailed))
+		metric("whatsapp_sends_rate_limited_last_hour", "gauge", "Sends WhatsApp rate limited in the last hour.", float64(account.RateLimited))
+		metric("whatsapp_disconnects_last_hour", "gauge", "Connections WhatsApp dropped in the last hour.", float64(account.Disconnects))
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(out.String()))
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+
+	"whatsapp-client/internal/whatsapp"
+)
+
+type MissingChatResponse struct {
+	ChatJID      string `json:"chat_jid"`
+	MessageCount int    `json:"message_count"`
+}
+
+type ConsistencyRepairsResponse struct {
+	ChatsDeleted int `json:"chats_deleted"`
+	ChatsCreated int `json:"chats_created"`
+	LIDsPaired   int `json:"lids_paired"`
+}
+
+// ConsistencyResponse reports disagreements between the device store and
+// the message store. Repaired is set when the check ran in repair mode.
+type ConsistencyResponse struct {
+	OrphanedChats        []string                    `json:"orphaned_chats"`
+	MessagesWithoutChats []MissingChatResponse       `json:"messages_without_chats"`
+	MappableLIDs         []string                    `json:"mappable_lids"`
+	UnmappedLIDs         []string                    `json:"unmapped_lids"`
+	Repaired             *ConsistencyRepairsResponse `json:"repaired,omitempty"`
+}
+
+func newConsistencyResponse(report whatsapp.ConsistencyReport, repaired bool) ConsistencyResponse {
+	response := ConsistencyResponse{
+		OrphanedChats:        append([]string{}, report.OrphanedChats...),
+		MessagesWithoutChats: make([]MissingChatResponse, 0, len(report.MessagesWithoutChats)),
+		MappableLIDs:         append([]string{}, report.MappableLIDs...),
+		UnmappedLIDs:         append([]string{}, report.UnmappedLIDs...),
+	}
+	for _, chat := range report.MessagesWithoutChats {
+		response.MessagesWithoutChats = append(response.MessagesWithoutChats, MissingChatResponse{
+			ChatJID:      chat.ChatJID,
+			MessageCount: chat.MessageCount,
+		})
+	}
+	if repaired {
+		response.Repaired = &ConsistencyRepairsResponse{
+			ChatsDeleted: report.Repaired.ChatsDeleted,
+			ChatsCreated: report.Repaired.ChatsCreated,
+			LIDsPaired:   report.Repaired.LIDsPaired,
+		}
+	}
+	return response
+}
+
+// consistencyHandler serves GET /api/consistency, which reports problems,
+// and POST /api/consistency/repair, which also fixes them.
+func consistencyHandler(runtime *whatsAppRuntime, repair bool) http.HandlerFunc {
+	method := http.MethodGet
+	if repair {
+		method = http.MethodPost
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		report, err := whatsapp.CheckConsistency(r.Context(), runtime.currentClient(), messageStore, repair)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newConsistencyResponse(report, repair))
+	}
+}
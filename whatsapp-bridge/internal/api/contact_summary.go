@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+type ResponseLatencyResponse struct {
+	AverageSeconds int64 `json:"average_seconds"`
+	Samples        int   `json:"samples"`
+}
+
+type SharedGroupResponse struct {
+	JID             string `json:"jid"`
+	Name            string `json:"name,omitempty"`
+	MessageCount    int    `json:"message_count"`
+	LastMessageTime string `json:"last_message_time"`
+}
+
+type ContactSummaryResponse struct {
+	ContactID            string                  `json:"contact_id"`
+	Name                 string                  `json:"name,omitempty"`
+	FirstInteraction     string                  `json:"first_interaction,omitempty"`
+	LastInteraction      string                  `json:"last_interaction,omitempty"`
+	SentCount            int                     `json:"sent_count"`
+	ReceivedCount        int                     `json:"received_count"`
+	GroupMessageCount    int                     `json:"group_message_count"`
+	MyResponseLatency    ResponseLatencyResponse `json:"my_response_latency"`
+	TheirResponseLatency ResponseLatencyResponse `json:"their_response_latency"`
+	SharedGroups         []SharedGroupResponse   `json:"shared_groups"`
+}
+
+func responseLatencyResponse(latency storage.ResponseLatency) ResponseLatencyResponse {
+	return ResponseLatencyResponse{AverageSeconds: int64(latency.Average / time.Second), Samples: latency.Samples}
+}
+
+// contactSummaryHandler answers "who is this person": when the account last
+// spoke with a contact, how much, how quickly each side replies, and which
+// groups they share.
+func contactSummaryHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		contactJID := strings.TrimSpace(r.PathValue("jid"))
+		if contactJID == "" {
+			http.Error(w, "Contact JID is required", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		summary, err := messageStore.GetContactSummary(contactJID)
+		if errors.Is(err, storage.ErrContactNotFound) {
+			http.Error(w, "Contact not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load contact summary", http.StatusInternalServerError)
+			return
+		}
+
+		response := ContactSummaryResponse{
+			ContactID:            summary.ContactID,
+			Name:                 summary.Name,
+			SentCount:            summary.SentCount,
+			ReceivedCount:        summary.ReceivedCount,
+			GroupMessageCount:    summary.GroupMessageCount,
+			MyResponseLatency:    responseLatencyResponse(summary.MyResponseLatency),
+			TheirResponseLatency: responseLatencyResponse(summary.TheirResponseLatency),
+			SharedGroups:         make([]SharedGroupResponse, 0, len(summary.SharedGroups)),
+		}
+		if !summary.FirstInteraction.IsZero() {
+			response.FirstInteraction = formatTimestamp(summary.FirstInteraction, location)
+			response.LastInteraction = formatTimestamp(summary.LastInteraction, location)
+		}
+		for _, group := range summary.SharedGroups {
+			response.SharedGroups = append(response.SharedGroups, SharedGroupResponse{
+				JID:             group.JID,
+				Name:            group.Name,
+				MessageCount:    group.MessageCount,
+				LastMessageTime: formatTimestamp(group.LastMessageTime, location),
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
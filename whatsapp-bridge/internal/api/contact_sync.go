@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+
+	"whatsapp-client/internal/whatsapp"
+)
+
+// ContactSyncResponse reports the phone address book imported from app state.
+type ContactSyncResponse struct {
+	ContactsImported int    `json:"contacts_imported"`
+	ChatsNamed       int    `json:"chats_named"`
+	LastSyncedAt     string `json:"last_synced_at,omitempty"`
+	// Imported and Renamed are set by a POST: the contacts it imported and
+	// the chats it renamed.
+	Imported int `json:"imported,omitempty"`
+	Renamed  int `json:"renamed,omitempty"`
+}
+
+// contactSyncHandler reports how many saved contacts were imported (GET), or
+// re-imports them from the device's contact store (POST).
+func contactSyncHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		var response ContactSyncResponse
+		if r.Method == http.MethodPost {
+			client := runtime.currentClient()
+			if client == nil || client.Store == nil || client.Store.ID == nil {
+				http.Error(w, "WhatsApp client is not initialized. Start connect first.", http.StatusServiceUnavailable)
+				return
+			}
+			imported, renamed, err := whatsapp.ImportSavedContacts(r.Context(), client, messageStore)
+			if err != nil {
+				http.Error(w, "Failed to import saved contacts", http.StatusInternalServerError)
+				return
+			}
+			response.Imported, response.Renamed = imported, renamed
+		}
+
+		status, err := messageStore.GetContactSyncStatus()
+		if err != nil {
+			http.Error(w, "Failed to load contact sync status", http.StatusInternalServerError)
+			return
+		}
+		response.ContactsImported = status.ContactsImported
+		response.ChatsNamed = status.ChatsNamed
+		response.LastSyncedAt = formatOptionalTime(status.LastSyncedAt)
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"whatsapp-client/internal/jid"
+)
+
+const maxContactTags = 20
+
+var contactTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+type ContactTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type ContactTagsResponse struct {
+	ContactID string   `json:"contact_id"`
+	Tags      []string `json:"tags"`
+}
+
+// contactTagsHandler returns (GET) or replaces (PUT) a contact's tags. The
+// vip, important and low tags raise or lower the contact's chats in the
+// priority inbox.
+func contactTagsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rawID := strings.TrimSpace(r.PathValue("jid"))
+		contactID := jid.NormalizeUser(rawID)
+		if contactID == "" || !jid.IsPersonal(rawID) {
+			http.Error(w, "Invalid contact JID", http.StatusBadRequest)
+			return
+		}
+
+		var tags []string
+		if r.Method == http.MethodPut {
+			var req ContactTagsRequest
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+			seen := map[string]bool{}
+			for _, tag := range req.Tags {
+				tag = strings.ToLower(strings.TrimSpace(tag))
+				if !contactTagPattern.MatchString(tag) {
+					http.Error(w, "Invalid tag", http.StatusBadRequest)
+					return
+				}
+				if !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, tag)
+				}
+			}
+			if len(tags) > maxContactTags {
+				http.Error(w, "Too many tags", http.StatusBadRequest)
+				return
+			}
+			sort.Strings(tags)
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			if err := messageStore.SetContactTags(contactID, tags); err != nil {
+				http.Error(w, "Failed to save contact tags", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			var err error
+			if tags, err = messageStore.GetContactTags(contactID); err != nil {
+				http.Error(w, "Failed to load contact tags", http.StatusInternalServerError)
+				return
+			}
+		}
+		if tags == nil {
+			tags = []string{}
+		}
+		writeJSON(w, http.StatusOK, ContactTagsResponse{ContactID: contactID, Tags: tags})
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"whatsapp-client/internal/digest"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/whatsapp"
+)
+
+type DigestResponse struct {
+	ID          int64                  `json:"id"`
+	PeriodStart string                 `json:"period_start"`
+	PeriodEnd   string                 `json:"period_end"`
+	CreatedAt   string                 `json:"created_at"`
+	Chats       []storage.ChatActivity `json:"chats"`
+	Text        string                 `json:"text"`
+}
+
+// startDigestScheduler runs the daily digest job at the configured time of day.
+func startDigestScheduler(runtime *whatsAppRuntime, config digest.Config) {
+	if !config.Enabled {
+		return
+	}
+	go func() {
+		for {
+			next := config.NextRun(time.Now())
+			time.Sleep(time.Until(next))
+			if err := runDailyDigest(runtime, config, time.Now()); err != nil {
+				fmt.Printf("Warning: daily digest failed: %v\n", err)
+			}
+		}
+	}()
+}
+
+// runDailyDigest compiles and stores a digest, then delivers it to the
+// configured webhook and/or the account's self-chat.
+func runDailyDigest(runtime *whatsAppRuntime, config digest.Config, now time.Time) error {
+	messageStore := runtime.currentMessageStore()
+	if messageStore == nil {
+		return fmt.Errorf("message store is not initialized")
+	}
+	client := runtime.currentClient()
+
+	compiled, err := digest.Build(messageStore, whatsapp.OwnUserIDs(client), now)
+	if err != nil {
+		return err
+	}
+	if err := messageStore.StoreDigest(compiled); err != nil {
+		return err
+	}
+
+	if config.WebhookURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := digest.PostWebhook(ctx, config.WebhookURL, compiled)
+		cancel()
+		if err != nil {
+			fmt.Printf("Warning: failed to deliver digest webhook: %v\n", err)
+		}
+	}
+
+	if config.SelfChat {
+		if client == nil || client.Store == nil || client.Store.ID == nil {
+			fmt.Println("Warning: skipping self-chat digest, no linked device")
+		} else if ok, message := whatsapp.SendWhatsAppMessage(client, messageStore, client.Store.ID.ToNonAD().String(), digest.FormatText(compiled), "", whatsapp.SendOptions{}); !ok {
+			fmt.Printf("Warning: failed to send self-chat digest: %s\n", message)
+		}
+	}
+	return nil
+}
+
+// latestDigestHandler returns the most recently generated daily digest.
+func latestDigestHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		latest, err := messageStore.GetLatestDigest()
+		if err != nil {
+			http.Error(w, "Failed to load digest", http.StatusInternalServerError)
+			return
+		}
+		if latest == nil {
+			http.Error(w, "No digest has been generated yet", http.StatusNotFound)
+			return
+		}
+
+		chats := latest.Chats
+		if chats == nil {
+			chats = []storage.ChatActivity{}
+		}
+		writeJSON(w, http.StatusOK, DigestResponse{
+			ID:          latest.ID,
+			PeriodStart: latest.PeriodStart.UTC().Format(time.RFC3339),
+			PeriodEnd:   latest.PeriodEnd.UTC().Format(time.RFC3339),
+			CreatedAt:   latest.CreatedAt.UTC().Format(time.RFC3339),
+			Chats:       chats,
+			Text:        digest.FormatText(latest),
+		})
+	}
+}
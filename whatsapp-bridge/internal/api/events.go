@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/webhook"
+)
+
+type EventReplayResponse struct {
+	Events []webhook.Event `json:"events"`
+	// NextAfterSeq is the after_seq to pass for the following page.
+	NextAfterSeq int64 `json:"next_after_seq"`
+	HasMore      bool  `json:"has_more"`
+}
+
+// eventReplayHandler pages through the event journal after a sequence
+// number, for consumers catching up on webhook deliveries they missed. The
+// optional event, chat_jid, sender_id and message_type parameters take
+// comma-separated lists and filter the page server-side, so a filtered page
+// may hold fewer than limit events while has_more is still true.
+func eventReplayHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var afterSeq int64
+		if raw := strings.TrimSpace(r.URL.Query().Get("after_seq")); raw != "" {
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || parsed < 0 {
+				http.Error(w, "Invalid after_seq", http.StatusBadRequest)
+				return
+			}
+			afterSeq = parsed
+		}
+		limit, ok := parseLimitParam(r, 100, storage.MaxJournalEventsPage)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		// One extra row tells whether another page follows.
+		events, err := messageStore.GetEventsAfter(afterSeq, limit+1)
+		if err != nil {
+			http.Error(w, "Failed to load events", http.StatusInternalServerError)
+			return
+		}
+		query := r.URL.Query()
+		filter := webhook.NewFilter(query.Get("event"), query.Get("chat_jid"), query.Get("sender_id"), query.Get("message_type"))
+		response := EventReplayResponse{Events: []webhook.Event{}, NextAfterSeq: afterSeq}
+		if len(events) > limit {
+			events = events[:limit]
+			response.HasMore = true
+		}
+		for _, event := range events {
+			response.NextAfterSeq = event.Seq
+			if filter.Matches(event.Type, webhook.JournalSubject(event)) {
+				response.Events = append(response.Events, webhook.FromJournal(event))
+			}
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+const (
+	streamKeepaliveInterval = 15 * time.Second
+	// streamWriteTimeout bounds each write, so a client that stops reading
+	// is dropped instead of holding its handler forever.
+	streamWriteTimeout = 30 * time.Second
+)
+
+// eventStreamHandler streams live events as server-sent events. It takes the
+// replay filters plus queue_size and overflow (drop_oldest or disconnect).
+// Each event's id is its delivery_id, so a client that lost events can fetch
+// them from /api/events/replay.
+func eventStreamHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		queueSize := 0
+		if raw := strings.TrimSpace(query.Get("queue_size")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "Invalid queue_size", http.StatusBadRequest)
+				return
+			}
+			queueSize = parsed
+		}
+		policy, ok := webhook.ParseOverflowPolicy(strings.TrimSpace(query.Get("overflow")))
+		if !ok {
+			http.Error(w, "Invalid overflow", http.StatusBadRequest)
+			return
+		}
+		stream := runtime.webhooks.Stream()
+		if stream == nil {
+			http.Error(w, "Event stream is not available", http.StatusServiceUnavailable)
+			return
+		}
+
+		filter := webhook.NewFilter(query.Get("event"), query.Get("chat_jid"), query.Get("sender_id"), query.Get("message_type"))
+		subscriber := stream.Subscribe(filter, queueSize, policy)
+		defer stream.Unsubscribe(subscriber)
+
+		controller := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		write := func(frame string) bool {
+			if err := controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+				return false
+			}
+			if _, err := fmt.Fprint(w, frame); err != nil {
+				return false
+			}
+			return controller.Flush() == nil
+		}
+		if !write(": connected\n\n") {
+			return
+		}
+
+		keepalive := time.NewTicker(streamKeepaliveInterval)
+		defer keepalive.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-subscriber.Overrun():
+				write("event: overflow\ndata: {}\n\n")
+				return
+			case <-keepalive.C:
+				if !write(": keepalive\n\n") {
+					return
+				}
+			case event := <-subscriber.Events():
+				payload, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				if !write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.DeliveryID, event.Type, payload)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// eventStreamStatsHandler reports event stream counters and per-subscriber
+// queue depth.
+func eventStreamStatsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, runtime.webhooks.Stream().Stats())
+	}
+}
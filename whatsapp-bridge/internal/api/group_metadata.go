@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jobs"
+	"whatsapp-client/internal/whatsapp"
+)
+
+const (
+	groupRefreshJobKind = "group_metadata_refresh"
+	// groupRefreshRateLimitRetries bounds how often one group is retried after
+	// WhatsApp rate limits the refresh.
+	groupRefreshRateLimitRetries = 3
+)
+
+type GroupMetadataRefreshRequest struct {
+	// PlaceholdersOnly limits the refresh to groups without a real name.
+	PlaceholdersOnly bool `json:"placeholders_only,omitempty"`
+}
+
+type GroupParticipantResponse struct {
+	ParticipantID string `json:"participant_id"`
+	IsAdmin       bool   `json:"is_admin"`
+	IsSuperAdmin  bool   `json:"is_super_admin"`
+}
+
+type GroupMetadataResponse struct {
+	GroupJID     string                     `json:"group_jid"`
+	Name         string                     `json:"name"`
+	Topic        string                     `json:"topic,omitempty"`
+	PhotoID      string                     `json:"photo_id,omitempty"`
+	PhotoURL     string                     `json:"photo_url,omitempty"`
+	Participants []GroupParticipantResponse `json:"participants"`
+	RefreshedAt  string                     `json:"refreshed_at"`
+}
+
+// sleepContext waits for d, returning early with the context's error.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// groupRefreshJob returns a job that refreshes each group's metadata in turn,
+// pausing between groups and backing off when WhatsApp rate limits it.
+func groupRefreshJob(runtime *whatsAppRuntime, groupJIDs []string, interval time.Duration) jobs.Func {
+	return func(ctx context.Context, progress *jobs.Progress) error {
+		for i, groupJID := range groupJIDs {
+			if i > 0 {
+				if err := sleepContext(ctx, interval); err != nil {
+					return err
+				}
+			}
+
+			var err error
+			for attempt := 0; ; attempt++ {
+				client := runtime.currentClient()
+				messageStore := runtime.currentMessageStore()
+				if client == nil || messageStore == nil || !client.IsConnected() {
+					return errors.New("WhatsApp client disconnected")
+				}
+				err = whatsapp.RefreshGroupMetadata(ctx, client, messageStore, groupJID)
+				var sendErr *whatsapp.SendError
+				if !errors.As(err, &sendErr) || sendErr.Code != whatsapp.SendErrorRateLimited || attempt == groupRefreshRateLimitRetries {
+					break
+				}
+				if err := sleepContext(ctx, sendErr.RetryAfter); err != nil {
+					return err
+				}
+			}
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				code := ""
+				var sendErr *whatsapp.SendError
+				if errors.As(err, &sendErr) {
+					code = sendErr.Code
+				}
+				progress.Failed(groupJID, err, code)
+				continue
+			}
+			progress.Succeeded()
+		}
+		return nil
+	}
+}
+
+// groupMetadataRefreshHandler queues a background refresh of the names,
+// participants and photos of stored group chats.
+func groupMetadataRefreshHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req GroupMetadataRefreshRequest
+		if r.ContentLength != 0 {
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+		}
+
+		client := runtime.currentClient()
+		if client == nil || !client.IsConnected() {
+			http.Error(w, "WhatsApp client is not connected", http.StatusServiceUnavailable)
+			return
+		}
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		groupJIDs, err := messageStore.ListGroupChatJIDs(req.PlaceholdersOnly)
+		if err != nil {
+			http.Error(w, "Failed to load group chats", http.StatusInternalServerError)
+			return
+		}
+
+		job, err := runtime.jobs.Start(groupRefreshJobKind, "", len(groupJIDs), groupRefreshJob(runtime, groupJIDs, whatsapp.GroupRefreshInterval()))
+		if err != nil {
+			http.Error(w, "Failed to create job", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, newJobResponse(job))
+	}
+}
+
+// groupMetadataHandler returns a group's last refreshed metadata.
+func groupMetadataHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		groupJID := strings.TrimSpace(r.PathValue("jid"))
+		if groupJID == "" {
+			http.Error(w, "Group JID is required", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		metadata, err := messageStore.GetGroupMetadata(groupJID)
+		if err != nil {
+			http.Error(w, "Failed to load group metadata", http.StatusInternalServerError)
+			return
+		}
+		if metadata == nil {
+			http.Error(w, "Group metadata has not been refreshed", http.StatusNotFound)
+			return
+		}
+
+		response := GroupMetadataResponse{
+			GroupJID:     metadata.GroupJID,
+			Name:         metadata.Name,
+			Topic:        metadata.Topic,
+			PhotoID:      metadata.PhotoID,
+			PhotoURL:     metadata.PhotoURL,
+			Participants: make([]GroupParticipantResponse, 0, len(metadata.Participants)),
+			RefreshedAt:  formatTimestamp(metadata.RefreshedAt, location),
+		}
+		for _, participant := range metadata.Participants {
+			response.Participants = append(response.Participants, GroupParticipantResponse{
+				ParticipantID: participant.ParticipantID,
+				IsAdmin:       participant.IsAdmin,
+				IsSuperAdmin:  participant.IsSuperAdmin,
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
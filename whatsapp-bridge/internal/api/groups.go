@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/whatsapp"
+)
+
+const groupInviteTimeout = 60 * time.Second
+
+type GroupInviteRequest struct {
+	Phones []string `json:"phones"`
+	// Message overrides the invite DM template; {group_name} and {link} are filled in.
+	Message string `json:"message,omitempty"`
+}
+
+type GroupInviteResultResponse struct {
+	Phone         string `json:"phone"`
+	ParticipantID string `json:"participant_id,omitempty"`
+	Status        string `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	InvitedAt     string `json:"invited_at,omitempty"`
+	JoinedAt      string `json:"joined_at,omitempty"`
+}
+
+type GroupInvitesResponse struct {
+	GroupJID string                      `json:"group_jid"`
+	Invites  []GroupInviteResultResponse `json:"invites"`
+}
+
+// groupInviteHandler adds phone numbers to a group, sending the invite link by
+// DM to numbers whose privacy settings block a direct add.
+func groupInviteHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		groupJID := strings.TrimSpace(r.PathValue("jid"))
+		if groupJID == "" {
+			http.Error(w, "Group JID is required", http.StatusBadRequest)
+			return
+		}
+
+		var req GroupInviteRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if len(req.Phones) == 0 {
+			http.Error(w, "phones is required", http.StatusBadRequest)
+			return
+		}
+
+		client := runtime.currentClient()
+		if client == nil {
+			http.Error(w, "WhatsApp client is not initialized. Start connect first.", http.StatusServiceUnavailable)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), groupInviteTimeout)
+		defer cancel()
+		results, err := whatsapp.InviteToGroup(ctx, client, runtime.currentMessageStore(), groupJID, req.Phones, req.Message)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		response := GroupInvitesResponse{GroupJID: groupJID, Invites: make([]GroupInviteResultResponse, 0, len(results))}
+		for _, result := range results {
+			response.Invites = append(response.Invites, GroupInviteResultResponse{
+				Phone:         result.Phone,
+				ParticipantID: result.ParticipantID,
+				Status:        result.Status,
+				Detail:        result.Detail,
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// groupInvitesHandler lists tracked invites for a group and whether each joined.
+func groupInvitesHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		groupJID := strings.TrimSpace(r.PathValue("jid"))
+		if groupJID == "" {
+			http.Error(w, "Group JID is required", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		invites, err := messageStore.ListGroupInvites(groupJID)
+		if err != nil {
+			http.Error(w, "Failed to load group invites", http.StatusInternalServerError)
+			return
+		}
+
+		response := GroupInvitesResponse{GroupJID: groupJID, Invites: make([]GroupInviteResultResponse, 0, len(invites))}
+		for _, invite := range invites {
+			entry := GroupInviteResultResponse{
+				Phone:         invite.Phone,
+				ParticipantID: invite.ParticipantID,
+				Status:        invite.Status,
+				Detail:        invite.Detail,
+				InvitedAt:     formatTimestamp(invite.InvitedAt, location),
+			}
+			if invite.JoinedAt != nil {
+				entry.JoinedAt = formatTimestamp(*invite.JoinedAt, location)
+			}
+			response.Invites = append(response.Invites, entry)
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
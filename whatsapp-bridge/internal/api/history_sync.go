@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"whatsapp-client/internal/whatsapp"
+)
+
+// HistorySyncResponse reports whether a history sync batch is being stored
+// and whether ingestion is cancelled.
+type HistorySyncResponse struct {
+	Running   bool `json:"running"`
+	Cancelled bool `json:"cancelled"`
+}
+
+func newHistorySyncResponse(state whatsapp.HistorySyncState) HistorySyncResponse {
+	return HistorySyncResponse{Running: state.Running, Cancelled: state.Cancelled}
+}
+
+// historySyncHandler serves GET /api/history-sync.
+func historySyncHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, newHistorySyncResponse(whatsapp.GetHistorySyncState()))
+	}
+}
+
+// historySyncCancelHandler serves POST /api/history-sync/cancel, which stops
+// history sync ingestion once the conversation being stored is done and
+// skips later batches until POST /api/history-sync/resume.
+func historySyncCancelHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, newHistorySyncResponse(whatsapp.CancelHistorySync()))
+	}
+}
+
+// historySyncResumeHandler serves POST /api/history-sync/resume.
+func historySyncResumeHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, newHistorySyncResponse(whatsapp.ResumeHistorySync()))
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultInboxDays = 14
+	maxInboxDays     = 90
+)
+
+type InboxChatResponse struct {
+	ChatJID  string `json:"chat_jid"`
+	ChatName string `json:"chat_name,omitempty"`
+	Score    int    `json:"score"`
+	// Unread counts incoming messages after the last reply that the account
+	// has not read on another device; Waiting counts all of them.
+	Unread       int                 `json:"unread"`
+	Waiting      int                 `json:"waiting"`
+	LastInbound  bool                `json:"last_inbound"`
+	WaitingSince string              `json:"waiting_since"`
+	LastReplyAt  string              `json:"last_reply_at,omitempty"`
+	Tags         []string            `json:"tags,omitempty"`
+	LastMessage  InboxMessageSummary `json:"last_message"`
+}
+
+type InboxMessageSummary struct {
+	MessageID  string `json:"message_id"`
+	SenderID   string `json:"sender_id"`
+	SenderName string `json:"sender_name,omitempty"`
+	Content    string `json:"content"`
+	MediaType  string `json:"media_type,omitempty"`
+	Timestamp  string `json:"timestamp"`
+}
+
+type InboxResponse struct {
+	Chats []InboxChatResponse `json:"chats"`
+}
+
+// inboxHandler ranks the chats waiting on a reply, most urgent first, by
+// unread messages, how long they have waited and the importance tags of who
+// is waiting. Only messages from the last days (default 14) are considered.
+func inboxHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 50, 500)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+		days := defaultInboxDays
+		if raw := strings.TrimSpace(r.URL.Query().Get("days")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 || parsed > maxInboxDays {
+				http.Error(w, "Invalid days", http.StatusBadRequest)
+				return
+			}
+			days = parsed
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		now := time.Now()
+		chats, err := messageStore.GetInbox(now.AddDate(0, 0, -days), now, limit)
+		if err != nil {
+			http.Error(w, "Failed to load inbox", http.StatusInternalServerError)
+			return
+		}
+
+		response := InboxResponse{Chats: make([]InboxChatResponse, 0, len(chats))}
+		for _, chat := range chats {
+			item := InboxChatResponse{
+				ChatJID:      chat.ChatJID,
+				ChatName:     chat.ChatName,
+				Score:        chat.Score,
+				Unread:       chat.Unread,
+				Waiting:      chat.Waiting,
+				LastInbound:  chat.LastInbound,
+				WaitingSince: formatTimestamp(chat.WaitingSince, location),
+				Tags:         chat.Tags,
+				LastMessage: InboxMessageSummary{
+					MessageID:  chat.LastMessage.MessageID,
+					SenderID:   chat.LastMessage.SenderID,
+					SenderName: chat.LastMessage.SenderName,
+					Content:    chat.LastMessage.Content,
+					MediaType:  chat.LastMessage.MediaType,
+					Timestamp:  formatTimestamp(chat.LastMessage.Timestamp, location),
+				},
+			}
+			if chat.LastReplyAt != nil {
+				item.LastReplyAt = formatTimestamp(*chat.LastReplyAt, location)
+			}
+			response.Chats = append(response.Chats, item)
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
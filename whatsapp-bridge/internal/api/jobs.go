@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+type JobErrorResponse struct {
+	Item      string `json:"item"`
+	Error     string `json:"error"`
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+type JobResponse struct {
+	JobID      string             `json:"job_id"`
+	Kind       string             `json:"kind"`
+	Subject    string             `json:"subject,omitempty"`
+	State      string             `json:"state"`
+	Total      int                `json:"total"`
+	Completed  int                `json:"completed"`
+	Failed     int                `json:"failed"`
+	Errors     []JobErrorResponse `json:"errors,omitempty"`
+	Message    string             `json:"message,omitempty"`
+	CreatedAt  string             `json:"created_at"`
+	UpdatedAt  string             `json:"updated_at"`
+	FinishedAt string             `json:"finished_at,omitempty"`
+}
+
+type JobsResponse struct {
+	Jobs []JobResponse `json:"jobs"`
+}
+
+func newJobResponse(job storage.JobRecord) JobResponse {
+	response := JobResponse{
+		JobID:     job.ID,
+		Kind:      job.Kind,
+		Subject:   job.Subject,
+		State:     job.State,
+		Total:     job.Total,
+		Completed: job.Completed,
+		Failed:    job.Failed,
+		Message:   job.Message,
+		CreatedAt: job.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: job.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	for _, jobErr := range job.Errors {
+		response.Errors = append(response.Errors, JobErrorResponse{
+			Item:      jobErr.Item,
+			Error:     jobErr.Error,
+			ErrorCode: jobErr.Code,
+		})
+	}
+	if job.FinishedAt != nil {
+		response.FinishedAt = job.FinishedAt.UTC().Format(time.RFC3339)
+	}
+	return response
+}
+
+// jobsHandler lists recent background jobs, optionally filtered by kind.
+func jobsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 50, 500)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		records, err := messageStore.ListJobs(strings.TrimSpace(r.URL.Query().Get("kind")), limit)
+		if err != nil {
+			http.Error(w, "Failed to load jobs", http.StatusInternalServerError)
+			return
+		}
+
+		response := JobsResponse{Jobs: make([]JobResponse, 0, len(records))}
+		for _, record := range records {
+			if live, err := runtime.jobs.Get(record.ID); err == nil && live != nil {
+				record = *live
+			}
+			response.Jobs = append(response.Jobs, newJobResponse(record))
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// jobHandler returns a job's state (GET) or requests its cancellation (DELETE).
+func jobHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id := r.PathValue("id")
+		job, err := runtime.jobs.Get(id)
+		if err != nil {
+			http.Error(w, "Failed to load job", http.StatusInternalServerError)
+			return
+		}
+		if job == nil {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			if !runtime.jobs.Cancel(id) {
+				http.Error(w, "Job is not running", http.StatusConflict)
+				return
+			}
+			writeJSON(w, http.StatusAccepted, newJobResponse(*job))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, newJobResponse(*job))
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"whatsapp-client/internal/storage"
+)
+
+type LinkResponse struct {
+	URL       string `json:"url"`
+	Domain    string `json:"domain"`
+	Title     string `json:"title,omitempty"`
+	ChatJID   string `json:"chat_jid"`
+	MessageID string `json:"message_id"`
+	SenderID  string `json:"sender_id"`
+	Timestamp string `json:"timestamp"`
+}
+
+type LinksResponse struct {
+	Links []LinkResponse `json:"links"`
+}
+
+// linksHandler lists shared links filtered by domain, chat, or sender.
+func linksHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 50, 500)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		query := r.URL.Query()
+		links, err := messageStore.GetLinks(storage.LinkQuery{
+			Domain:  strings.TrimSpace(query.Get("domain")),
+			ChatJID: strings.TrimSpace(query.Get("chat_jid")),
+			Sender:  strings.TrimSpace(query.Get("sender_id")),
+			Limit:   limit,
+		})
+		if err != nil {
+			http.Error(w, "Failed to load links", http.StatusInternalServerError)
+			return
+		}
+
+		response := LinksResponse{Links: make([]LinkResponse, 0, len(links))}
+		for _, link := range links {
+			response.Links = append(response.Links, LinkResponse{
+				URL:       link.URL,
+				Domain:    link.Domain,
+				Title:     link.Title,
+				ChatJID:   link.ChatJID,
+				MessageID: link.MessageID,
+				SenderID:  link.Sender,
+				Timestamp: formatTimestamp(link.Timestamp, location),
+			})
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"whatsapp-client/internal/jobs"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/whatsapp"
+)
+
+const mediaBatchJobKind = "media_download"
+
+type MediaBatchDownloadRequest struct {
+	MediaType string `json:"media_type,omitempty"`
+	After     string `json:"after,omitempty"`
+	Before    string `json:"before,omitempty"`
+}
+
+// mediaBatchJob returns a job that downloads each pending media message in a
+// chat in turn, stopping early when cancelled or when the client disconnects.
+func mediaBatchJob(runtime *whatsAppRuntime, chatJID string, messageIDs []string) jobs.Func {
+	return func(ctx context.Context, progress *jobs.Progress) error {
+		for _, messageID := range messageIDs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			client := runtime.currentClient()
+			messageStore := runtime.currentMessageStore()
+			if client == nil || messageStore == nil || !client.IsConnected() {
+				return fmt.Errorf("WhatsApp client disconnected")
+			}
+
+			success, _, _, _, err := whatsapp.DownloadMedia(client, messageStore, messageID, chatJID)
+			if err == nil && !success {
+				err = fmt.Errorf("download failed")
+			}
+			if err != nil {
+				code := ""
+				if policyErr, _, ok := mediaPolicyErrorStatus(err); ok {
+					code = policyErr.Code
+				}
+				progress.Failed(messageID, err, code)
+				continue
+			}
+			progress.Succeeded()
+		}
+		return nil
+	}
+}
+
+// chatMediaDownloadHandler queues a background download of all undownloaded media in a chat.
+func chatMediaDownloadHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		var req MediaBatchDownloadRequest
+		if r.ContentLength != 0 {
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+		}
+		mediaType := strings.ToLower(strings.TrimSpace(req.MediaType))
+		if _, ok := supportedMediaTypes[mediaType]; mediaType != "" && !ok {
+			http.Error(w, "Unsupported media type", http.StatusBadRequest)
+			return
+		}
+		after, ok := parseOptionalTime(req.After)
+		if !ok {
+			http.Error(w, "Invalid after timestamp", http.StatusBadRequest)
+			return
+		}
+		before, ok := parseOptionalTime(req.Before)
+		if !ok {
+			http.Error(w, "Invalid before timestamp", http.StatusBadRequest)
+			return
+		}
+
+		client := runtime.currentClient()
+		if client == nil || !client.IsConnected() {
+			http.Error(w, "WhatsApp client is not connected", http.StatusServiceUnavailable)
+			return
+		}
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		messageIDs, err := messageStore.GetPendingMediaIDs(storage.PendingMediaQuery{
+			ChatJID:   chatJID,
+			MediaType: mediaType,
+			After:     after,
+			Before:    before,
+		})
+		if err != nil {
+			http.Error(w, "Failed to load pending media", http.StatusInternalServerError)
+			return
+		}
+
+		job, err := runtime.jobs.Start(mediaBatchJobKind, chatJID, len(messageIDs), mediaBatchJob(runtime, chatJID, messageIDs))
+		if err != nil {
+			http.Error(w, "Failed to create job", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, newJobResponse(job))
+	}
+}
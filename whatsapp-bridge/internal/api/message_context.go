@@ -0,0 +1,104 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultMessageContextSize = 20
+	maxMessageContextSize     = 200
+)
+
+type MessageContextResponse struct {
+	MessageID   string                   `json:"message_id"`
+	ChatJID     string                   `json:"chat_jid"`
+	ChatName    string                   `json:"chat_name,omitempty"`
+	AnchorIndex int                      `json:"anchor_index"`
+	Messages    []ContextMessageResponse `json:"messages"`
+	Transcript  string                   `json:"transcript"`
+}
+
+// parseContextSizeParam reads a non-negative message count query parameter,
+// capped at maxMessageContextSize.
+func parseContextSizeParam(r *http.Request, name string) (int, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get(name))
+	if raw == "" {
+		return defaultMessageContextSize, true
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return min(size, maxMessageContextSize), true
+}
+
+// messageContextHandler returns the messages surrounding one message, so a
+// search hit can be expanded into a readable excerpt in one call.
+func messageContextHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageID := strings.TrimSpace(r.PathValue("id"))
+		chatJID := strings.TrimSpace(r.URL.Query().Get("chat_jid"))
+		if messageID == "" || chatJID == "" {
+			http.Error(w, "Message ID and chat_jid are required", http.StatusBadRequest)
+			return
+		}
+		before, ok := parseContextSizeParam(r, "before")
+		if !ok {
+			http.Error(w, "Invalid before", http.StatusBadRequest)
+			return
+		}
+		after, ok := parseContextSizeParam(r, "after")
+		if !ok {
+			http.Error(w, "Invalid after", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		messages, anchor, err := messageStore.GetMessageContext(messageID, chatJID, before, after)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load message context", http.StatusInternalServerError)
+			return
+		}
+		chatName, _ := messageStore.GetChatName(chatJID)
+
+		response := MessageContextResponse{
+			MessageID:   messageID,
+			ChatJID:     chatJID,
+			ChatName:    chatName,
+			AnchorIndex: anchor,
+			Messages:    make([]ContextMessageResponse, 0, len(messages)),
+		}
+		lines := make([]string, 0, len(messages))
+		for _, msg := range messages {
+			text := contextMessageText(msg)
+			senderName := contextSenderName(msg)
+			response.Messages = append(response.Messages, newContextMessageResponse(msg, senderName, text, location))
+			lines = append(lines, formatContextLine(msg, senderName, text, location))
+		}
+		response.Transcript = strings.Join(lines, "\n")
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"whatsapp-client/internal/storage"
+)
+
+type MessageStatusRequest struct {
+	MessageIDs []string `json:"message_ids"`
+	ChatJID    string   `json:"chat_jid,omitempty"`
+}
+
+type MessageStatusResponse struct {
+	MessageID      string `json:"message_id"`
+	ChatJID        string `json:"chat_jid,omitempty"`
+	Status         string `json:"status"`
+	IsFromMe       bool   `json:"is_from_me"`
+	SentAt         string `json:"sent_at,omitempty"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+	ReadAt         string `json:"read_at,omitempty"`
+	PlayedAt       string `json:"played_at,omitempty"`
+	DeliveredCount int    `json:"delivered_count"`
+	ReadCount      int    `json:"read_count"`
+	PlayedCount    int    `json:"played_count"`
+}
+
+type MessageStatusesResponse struct {
+	Statuses []MessageStatusResponse `json:"statuses"`
+}
+
+// messageStatusHandler reports the delivery and read status of many messages
+// in one call, so callers tracking a campaign need not poll per recipient.
+func messageStatusHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req MessageStatusRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		messageIDs := make([]string, 0, len(req.MessageIDs))
+		for _, id := range req.MessageIDs {
+			if id = strings.TrimSpace(id); id != "" {
+				messageIDs = append(messageIDs, id)
+			}
+		}
+		if len(messageIDs) == 0 {
+			http.Error(w, "message_ids is required", http.StatusBadRequest)
+			return
+		}
+		if len(messageIDs) > storage.MaxMessageStatusIDs {
+			http.Error(w, fmt.Sprintf("At most %d message_ids may be queried at once", storage.MaxMessageStatusIDs), http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		statuses, err := messageStore.GetMessageStatuses(messageIDs, strings.TrimSpace(req.ChatJID))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to load message statuses: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		response := MessageStatusesResponse{Statuses: make([]MessageStatusResponse, 0, len(statuses))}
+		for _, status := range statuses {
+			response.Statuses = append(response.Statuses, MessageStatusResponse{
+				MessageID:      status.MessageID,
+				ChatJID:        status.ChatJID,
+				Status:         status.Status,
+				IsFromMe:       status.IsFromMe,
+				SentAt:         formatOptionalTime(status.SentAt),
+				DeliveredAt:    formatOptionalTime(status.DeliveredAt),
+				ReadAt:         formatOptionalTime(status.ReadAt),
+				PlayedAt:       formatOptionalTime(status.PlayedAt),
+				DeliveredCount: status.DeliveredCount,
+				ReadCount:      status.ReadCount,
+				PlayedCount:    status.PlayedCount,
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"whatsapp-client/internal/storage"
+)
+
+// SequencedMessageResponse is a message with the chat it belongs to, as
+// returned to incremental consumers.
+type SequencedMessageResponse struct {
+	ChatJID string `json:"chat_jid"`
+	ContextMessageResponse
+}
+
+type MessagesSinceResponse struct {
+	Messages []SequencedMessageResponse `json:"messages"`
+	// NextAfterSeq is the after_seq to pass for the following page.
+	NextAfterSeq int64 `json:"next_after_seq"`
+	HasMore      bool  `json:"has_more"`
+}
+
+// messagesSinceHandler pages through every chat's messages in the order they
+// were stored, after a local sequence number, so downstream syncers can fetch
+// everything new even when timestamps collide or arrive out of order.
+func messagesSinceHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var afterSeq int64
+		if raw := strings.TrimSpace(r.URL.Query().Get("after_seq")); raw != "" {
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || parsed < 0 {
+				http.Error(w, "Invalid after_seq", http.StatusBadRequest)
+				return
+			}
+			afterSeq = parsed
+		}
+		limit, ok := parseLimitParam(r, 100, storage.MaxMessagesSincePage)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		// One extra row tells whether another page follows.
+		messages, err := messageStore.GetMessagesSince(afterSeq, limit+1)
+		if err != nil {
+			http.Error(w, "Failed to load messages", http.StatusInternalServerError)
+			return
+		}
+		response := MessagesSinceResponse{Messages: make([]SequencedMessageResponse, 0, len(messages)), NextAfterSeq: afterSeq}
+		if len(messages) > limit {
+			messages = messages[:limit]
+			response.HasMore = true
+		}
+		for _, msg := range messages {
+			response.NextAfterSeq = msg.Seq
+			response.Messages = append(response.Messages, SequencedMessageResponse{
+				ChatJID:                msg.ChatJID,
+				ContextMessageResponse: newContextMessageResponse(msg, contextSenderName(msg), contextMessageText(msg), location),
+			})
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
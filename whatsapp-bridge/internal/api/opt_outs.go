@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+type OptOutResponse struct {
+	ContactID string `json:"contact_id"`
+	// Source is "keyword" when the contact sent a stop keyword and "manual"
+	// when the opt-out was added through the API.
+	Source     string `json:"source"`
+	Keyword    string `json:"keyword,omitempty"`
+	MessageID  string `json:"message_id,omitempty"`
+	OptedOutAt string `json:"opted_out_at"`
+}
+
+type OptOutsResponse struct {
+	OptOuts []OptOutResponse `json:"opt_outs"`
+}
+
+func newOptOutResponse(optOut storage.OptOut) OptOutResponse {
+	return OptOutResponse{
+		ContactID:  optOut.ContactID,
+		Source:     optOut.Source,
+		Keyword:    optOut.Keyword,
+		MessageID:  optOut.MessageID,
+		OptedOutAt: optOut.OptedOutAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// optOutsHandler lists contacts who opted out, most recent first.
+func optOutsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 100, 1000)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		optOuts, err := messageStore.ListOptOuts(limit)
+		if err != nil {
+			http.Error(w, "Failed to load opt-outs", http.StatusInternalServerError)
+			return
+		}
+		response := OptOutsResponse{OptOuts: make([]OptOutResponse, 0, len(optOuts))}
+		for _, optOut := range optOuts {
+			response.OptOuts = append(response.OptOuts, newOptOutResponse(optOut))
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// optOutHandler returns (GET), adds (PUT) or lifts (DELETE) a contact's
+// opt-out. Sends to opted-out contacts are refused unless they set
+// override_opt_out.
+func optOutHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rawID := strings.TrimSpace(r.PathValue("jid"))
+		contactID := jid.NormalizeUser(rawID)
+		if contactID == "" || !jid.IsPersonal(rawID) {
+			http.Error(w, "Invalid contact JID", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodDelete:
+			removed, err := messageStore.RemoveOptOut(contactID)
+			if err != nil {
+				http.Error(w, "Failed to remove opt-out", http.StatusInternalServerError)
+				return
+			}
+			if !removed {
+				http.Error(w, "Contact has not opted out", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+
+		case http.MethodPut:
+			if _, err := messageStore.RecordOptOut(storage.OptOut{
+				ContactID:  contactID,
+				Source:     storage.OptOutSourceManual,
+				OptedOutAt: time.Now(),
+			}); err != nil {
+				http.Error(w, "Failed to save opt-out", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		optOut, err := messageStore.GetOptOut(contactID)
+		if err != nil {
+			http.Error(w, "Failed to load opt-out", http.StatusInternalServerError)
+			return
+		}
+		if optOut == nil {
+			http.Error(w, "Contact has not opted out", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, newOptOutResponse(*optOut))
+	}
+}
@@ -0,0 +1,373 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/webhook"
+	"whatsapp-client/internal/whatsapp"
+)
+
+const (
+	// EventQueuedSendSent and EventQueuedSendFailed report the final outcome
+	// of a send queued while WhatsApp was disconnected.
+	EventQueuedSendSent   = "send.queued_sent"
+	EventQueuedSendFailed = "send.queued_failed"
+
+	outboxPollInterval = 5 * time.Second
+	outboxBatchSize    = 50
+	// maxQueuedSendAttempts gives up on a queued send after this many
+	// retryable failures, such as rate limits or timeouts.
+	maxQueuedSendAttempts = 5
+)
+
+type QueuedSendResponse struct {
+	ID             int64    `json:"id"`
+	Recipient      string   `json:"recipient"`
+	Message        string   `json:"message,omitempty"`
+	MediaPath      string   `json:"media_path,omitempty"`
+	MentionAll     bool     `json:"mention_all,omitempty"`
+	IsGIF          bool     `json:"is_gif,omitempty"`
+	OverrideOptOut bool     `json:"override_opt_out,omitempty"`
+	Force          bool     `json:"force,omitempty"`
+	State          string   `json:"state"`
+	Attempts       int      `json:"attempts"`
+	Error          string   `json:"error,omitempty"`
+	ErrorCode      string   `json:"error_code,omitempty"`
+	MessageIDs     []string `json:"message_ids,omitempty"`
+	CreatedAt      string   `json:"created_at"`
+	FinishedAt     string   `json:"finished_at,omitempty"`
+}
+
+type QueuedSendsResponse struct {
+	Sends []QueuedSendResponse `json:"sends"`
+}
+
+// QueuedSendEvent is the webhook payload of a queued send that was delivered
+// or given up on.
+type QueuedSendEvent struct {
+	QueuedID   int64    `json:"queued_id"`
+	Recipient  string   `json:"recipient"`
+	State      string   `json:"state"`
+	MessageIDs []string `json:"message_ids,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	ErrorCode  string   `json:"error_code,omitempty"`
+	QueuedAt   string   `json:"queued_at"`
+}
+
+func newQueuedSendResponse(send storage.QueuedSend) QueuedSendResponse {
+	return QueuedSendResponse{
+		ID:             send.ID,
+		Recipient:      send.Recipient,
+		Message:        send.Message,
+		MediaPath:      send.MediaPath,
+		MentionAll:     send.MentionAll,
+		IsGIF:          send.GIF,
+		OverrideOptOut: send.OverrideOptOut,
+		Force:          send.Force,
+		State:          send.State,
+		Attempts:       send.Attempts,
+		Error:          send.Error,
+		ErrorCode:      send.ErrorCode,
+		MessageIDs:     send.MessageIDs,
+		CreatedAt:      send.CreatedAt.UTC().Format(time.RFC3339),
+		FinishedAt:     formatOptionalTime(send.FinishedAt),
+	}
+}
+
+// canQueueSend reports whether a send to client should be queued rather than
+// refused: a device is linked but the socket is down.
+func canQueueSend(client *whatsmeow.Client) bool {
+	return client != nil && client.Store != nil && client.Store.ID != nil && !client.IsConnected()
+}
+
+// queueSend stores req for delivery on reconnect and answers 202 Accepted
+// with the queued ID, which it returns. Sends the chat policy or an opt-out
+// forbid are refused now rather than failing on reconnect; it returns 0 when
+// the send was refused or queueing failed.
+func queueSend(w http.ResponseWriter, client *whatsmeow.Client, messageStore *storage.MessageStore, req SendMessageRequest) int64 {
+	if messageStore == nil {
+		writeJSON(w, http.StatusServiceUnavailable, SendMessageResponse{Success: false, Message: "Message store is not initialized"})
+		return 0
+	}
+	if err := whatsapp.CheckChatSendPolicy(client, messageStore, req.Recipient); err != nil {
+		writeSendError(w, err)
+		return 0
+	}
+	if !req.OverrideOptOut {
+		if err := whatsapp.CheckOptOut(client, messageStore, req.Recipient); err != nil {
+			writeSendError(w, err)
+			return 0
+		}
+	}
+	send, err := messageStore.QueueSend(queuedSendFromRequest(req))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, SendMessageResponse{Success: false, Message: "Failed to queue message"})
+		return 0
+	}
+	writeJSON(w, http.StatusAccepted, SendMessageResponse{
+		Success:  false,
+		Message:  "WhatsApp is disconnected; the message is queued and will be sent once reconnected",
+		QueuedID: send.ID,
+	})
+	return send.ID
+}
+
+func queuedSendFromRequest(req SendMessageRequest) storage.QueuedSend {
+	return storage.QueuedSend{
+		Recipient:      req.Recipient,
+		Message:        req.Message,
+		MediaPath:      req.MediaPath,
+		MentionAll:     req.MentionAll,
+		GIF:            req.IsGIF,
+		OverrideOptOut: req.OverrideOptOut,
+		Force:          req.Force,
+	}
+}
+
+// sendRequestFromQueued is the request a queued send was made with.
+func sendRequestFromQueued(send storage.QueuedSend) SendMessageRequest {
+	return SendMessageRequest{
+		Recipient:      send.Recipient,
+		Message:        send.Message,
+		MediaPath:      send.MediaPath,
+		MentionAll:     send.MentionAll,
+		IsGIF:          send.GIF,
+		OverrideOptOut: send.OverrideOptOut,
+		Force:          send.Force,
+	}
+}
+
+// startOutboxWorker delivers sends queued while disconnected once the client
+// is connected again, oldest first. The outbox is persisted, so sends queued
+// before a restart are delivered after it.
+func startOutboxWorker(runtime *whatsAppRuntime) {
+	go func() {
+		ticker := time.NewTicker(outboxPollInterval)
+		defer ticker.Stop()
+		for {
+			deliverQueuedSends(runtime)
+			<-ticker.C
+		}
+	}()
+}
+
+func deliverQueuedSends(runtime *whatsAppRuntime) {
+	messageStore := runtime.currentMessageStore()
+	client := runtime.currentClient()
+	if messageStore == nil || client == nil || client.Store == nil || client.Store.ID == nil || !client.IsConnected() {
+		return
+	}
+	sends, err := messageStore.ListQueuedSends(storage.QueuedSendStateQueued, outboxBatchSize)
+	if err != nil {
+		fmt.Printf("Warning: failed to load queued sends: %v\n", err)
+		return
+	}
+	for _, send := range sends {
+		if !deliverQueuedSend(runtime, client, messageStore, send) {
+			return
+		}
+	}
+}
+
+// deliverQueuedSend attempts one queued send and records its outcome. It
+// returns false when the rest of the batch should wait for the next poll,
+// because the connection dropped again or WhatsApp is rate limiting. Unless
+// forced, a send is given up when an identical one went out while it waited.
+// The send is claimed first, so one cancelled after it was listed is skipped.
+func deliverQueuedSend(runtime *whatsAppRuntime, client *whatsmeow.Client, messageStore *storage.MessageStore, send storage.QueuedSend) bool {
+	if ok, err := messageStore.ClaimQueuedSend(send.ID); err != nil {
+		fmt.Printf("Warning: failed to claim queued send %d: %v\n", send.ID, err)
+		return false
+	} else if !ok {
+		return true
+	}
+
+	dedupKey := sendDedupKey(sendRequestFromQueued(send))
+	claimed := false
+	if !send.Force {
+		// The send's own entry, recorded when it was queued, is no duplicate.
+		prior, ok := runtime.sendDedup.claim(dedupKey, time.Now())
+		if !ok && prior.QueuedID != send.ID {
+			finishQueuedSend(runtime, messageStore, send, whatsapp.SendResult{}, sendErrorDuplicate,
+				errors.New("identical message was sent to this recipient while this one was queued"))
+			return true
+		}
+		claimed = ok
+	}
+
+	result, err := whatsapp.SendMessage(context.Background(), client, messageStore, send.Recipient, send.Message, send.MediaPath, whatsapp.SendOptions{
+		MentionAll:     send.MentionAll,
+		GIF:            send.GIF,
+		OverrideOptOut: send.OverrideOptOut,
+		Pacing:         runtime.pacing,
+	})
+
+	code := ""
+	var sendErr *whatsapp.SendError
+	if errors.As(err, &sendErr) {
+		code = sendErr.Code
+		if code == whatsapp.SendErrorNotConnected {
+			if claimed {
+				runtime.sendDedup.release(dedupKey)
+			}
+			if err := messageStore.ReleaseQueuedSend(send.ID); err != nil {
+				fmt.Printf("Warning: failed to requeue queued send %d: %v\n", send.ID, err)
+			}
+			return false
+		}
+		if sendErr.Retryable && send.Attempts+1 < maxQueuedSendAttempts {
+			if claimed {
+				runtime.sendDedup.release(dedupKey)
+			}
+			if err := messageStore.RecordQueuedSendAttempt(send.ID, code, err); err != nil {
+				fmt.Printf("Warning: failed to record queued send %d: %v\n", send.ID, err)
+			}
+			return code != whatsapp.SendErrorRateLimited
+		}
+	}
+	if err != nil {
+		if claimed {
+			runtime.sendDedup.release(dedupKey)
+		}
+	} else {
+		runtime.sendDedup.finish(dedupKey, time.Now(), priorSend{MessageIDs: result.MessageIDs})
+	}
+	finishQueuedSend(runtime, messageStore, send, result, code, err)
+	return true
+}
+
+// finishQueuedSend records the final outcome of a claimed send and emits its
+// webhook event once the outcome is recorded.
+func finishQueuedSend(runtime *whatsAppRuntime, messageStore *storage.MessageStore, send storage.QueuedSend, result whatsapp.SendResult, code string, err error) {
+	now := time.Now()
+	finished, finishErr := messageStore.FinishQueuedSend(send.ID, now, result.MessageIDs, code, err)
+	if finishErr != nil {
+		fmt.Printf("Warning: failed to record queued send %d: %v\n", send.ID, finishErr)
+		return
+	}
+	if !finished {
+		fmt.Printf("Warning: queued send %d was no longer claimed when it finished\n", send.ID)
+		return
+	}
+	event := QueuedSendEvent{
+		QueuedID:   send.ID,
+		Recipient:  send.Recipient,
+		State:      storage.QueuedSendStateSent,
+		MessageIDs: result.MessageIDs,
+		QueuedAt:   send.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	eventType := EventQueuedSendSent
+	if err != nil {
+		fmt.Printf("Warning: queued send %d failed: %v\n", send.ID, err)
+		event.State, event.Error, event.ErrorCode = storage.QueuedSendStateFailed, err.Error(), code
+		eventType = EventQueuedSendFailed
+	}
+	runtime.webhooks.Emit(eventType, now, webhook.Subject{ChatJID: jid.NormalizeChat(send.Recipient)}, event)
+}
+
+// queuedSendsHandler lists sends queued while disconnected, optionally by
+// state.
+func queuedSendsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 100, 1000)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		state := strings.TrimSpace(r.URL.Query().Get("state"))
+		switch state {
+		case "", storage.QueuedSendStateQueued, storage.QueuedSendStateSending, storage.QueuedSendStateSent, storage.QueuedSendStateFailed, storage.QueuedSendStateCancelled:
+		default:
+			http.Error(w, "Invalid state", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		sends, err := messageStore.ListQueuedSends(state, limit)
+		if err != nil {
+			http.Error(w, "Failed to load queued sends", http.StatusInternalServerError)
+			return
+		}
+		response := QueuedSendsResponse{Sends: make([]QueuedSendResponse, 0, len(sends))}
+		for _, send := range sends {
+			response.Sends = append(response.Sends, newQueuedSendResponse(send))
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// queuedSendHandler returns (GET) or cancels (DELETE) a queued send. Only
+// sends still queued can be cancelled; one being delivered answers 409.
+func queuedSendHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid queued send ID", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			cancelled, err := messageStore.CancelQueuedSend(id)
+			if err != nil {
+				http.Error(w, "Failed to cancel queued send", http.StatusInternalServerError)
+				return
+			}
+			if !cancelled {
+				send, err := messageStore.GetQueuedSend(id)
+				if err != nil {
+					http.Error(w, "Failed to cancel queued send", http.StatusInternalServerError)
+					return
+				}
+				if send == nil {
+					http.Error(w, "Queued send not found", http.StatusNotFound)
+					return
+				}
+				http.Error(w, fmt.Sprintf("Queued send is %s and can no longer be cancelled", send.State), http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		send, err := messageStore.GetQueuedSend(id)
+		if err != nil {
+			http.Error(w, "Failed to load queued send", http.StatusInternalServerError)
+			return
+		}
+		if send == nil {
+			http.Error(w, "Queued send not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, newQueuedSendResponse(*send))
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+)
+
+const profilingScope = "whatsapp:debug"
+
+// registerProfilingRoutes mounts the Go runtime profiler under /debug/pprof/
+// when WHATSAPP_BRIDGE_PPROF is true. Profiles expose memory contents and
+// internals, so the routes need a bridge token with the whatsapp:debug scope.
+func registerProfilingRoutes(mux *http.ServeMux, authConfig bridgeAuthConfig) {
+	if !envBool("WHATSAPP_BRIDGE_PPROF", false) {
+		return
+	}
+	mux.HandleFunc("/debug/pprof/", withRequiredBridgeJWTAuth(authConfig, pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", withRequiredBridgeJWTAuth(authConfig, pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", withRequiredBridgeJWTAuth(authConfig, pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", withRequiredBridgeJWTAuth(authConfig, pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", withRequiredBridgeJWTAuth(authConfig, pprof.Trace))
+	fmt.Println("Profiling endpoints enabled under /debug/pprof/")
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+)
+
+type QuarantinedMessageResponse struct {
+	MessageID   string   `json:"message_id"`
+	ChatJID     string   `json:"chat_jid"`
+	SenderID    string   `json:"sender_id"`
+	SenderName  string   `json:"sender_name,omitempty"`
+	Timestamp   string   `json:"timestamp"`
+	Content     string   `json:"content"`
+	MediaType   string   `json:"media_type,omitempty"`
+	Filename    string   `json:"filename,omitempty"`
+	SpamScore   int      `json:"spam_score"`
+	SpamReasons []string `json:"spam_reasons,omitempty"`
+	// Quarantined messages are left out of views; unknown-sender messages
+	// are listed for review but stay visible elsewhere.
+	Quarantined   bool `json:"quarantined"`
+	UnknownSender bool `json:"unknown_sender"`
+}
+
+type QuarantineResponse struct {
+	Messages []QuarantinedMessageResponse `json:"messages"`
+}
+
+// quarantineHandler lists messages held for review: those the spam classifier
+// scored at or above its threshold, and those from unknown senders.
+func quarantineHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 100, 1000)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		messages, err := messageStore.ListQuarantine(limit)
+		if err != nil {
+			http.Error(w, "Failed to load quarantined messages", http.StatusInternalServerError)
+			return
+		}
+
+		response := QuarantineResponse{Messages: make([]QuarantinedMessageResponse, 0, len(messages))}
+		for _, msg := range messages {
+			response.Messages = append(response.Messages, QuarantinedMessageResponse{
+				MessageID:     msg.ID,
+				ChatJID:       msg.ChatJID,
+				SenderID:      msg.Sender,
+				SenderName:    msg.SenderName,
+				Timestamp:     formatTimestamp(msg.Time, location),
+				Content:       msg.Content,
+				MediaType:     msg.MediaType,
+				Filename:      msg.Filename,
+				SpamScore:     msg.SpamScore,
+				SpamReasons:   msg.SpamReasons,
+				Quarantined:   msg.Quarantined,
+				UnknownSender: msg.UnknownSender,
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+type RawLinkResponse struct {
+	URL    string `json:"url"`
+	Domain string `json:"domain"`
+	Title  string `json:"title,omitempty"`
+}
+
+type RawMediaResponse struct {
+	MediaType     string `json:"media_type"`
+	Filename      string `json:"filename,omitempty"`
+	URL           string `json:"url,omitempty"`
+	HasMediaKey   bool   `json:"has_media_key"`
+	FileSHA256    []byte `json:"file_sha256,omitempty"`
+	FileEncSHA256 []byte `json:"file_enc_sha256,omitempty"`
+	FileLength    uint64 `json:"file_length,omitempty"`
+	Thumbnail     []byte `json:"thumbnail,omitempty"`
+	LocalPath     string `json:"local_path,omitempty"`
+	// DurationSeconds is the length of audio and video media.
+	DurationSeconds uint32 `json:"duration_seconds,omitempty"`
+	// Waveform is a voice note's 64 loudness samples, 0-100 each.
+	Waveform  []int `json:"waveform,omitempty"`
+	VoiceNote bool  `json:"voice_note,omitempty"`
+}
+
+type SelectionResponse struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// PaymentResponse carries an order or payment message's fields. Amount is
+// the decimal rendering of Amount1000, thousandths of the currency unit.
+type PaymentResponse struct {
+	Kind       string `json:"kind"`
+	Amount1000 int64  `json:"amount_1000,omitempty"`
+	Amount     string `json:"amount,omitempty"`
+	Currency   string `json:"currency,omitempty"`
+	Reference  string `json:"reference,omitempty"`
+	ItemCount  int    `json:"item_count,omitempty"`
+	Title      string `json:"title,omitempty"`
+}
+
+func newPaymentResponse(payment storage.Payment) *PaymentResponse {
+	if payment.Kind == "" {
+		return nil
+	}
+	response := &PaymentResponse{
+		Kind:       payment.Kind,
+		Amount1000: payment.Amount1000,
+		Currency:   payment.Currency,
+		Reference:  payment.Reference,
+		ItemCount:  payment.ItemCount,
+		Title:      payment.Title,
+	}
+	if payment.Amount1000 != 0 {
+		response.Amount = strconv.FormatFloat(float64(payment.Amount1000)/1000, 'f', 2, 64)
+	}
+	return response
+}
+
+type RawMessageResponse struct {
+	MessageID          string                  `json:"message_id"`
+	Seq                int64                   `json:"seq"`
+	ChatJID            string                  `json:"chat_jid"`
+	ChatName           string                  `json:"chat_name,omitempty"`
+	ChatType           string                  `json:"chat_type,omitempty"`
+	ChatServer         string                  `json:"chat_server,omitempty"`
+	Timestamp          string                  `json:"timestamp"`
+	SenderID           string                  `json:"sender_id"`
+	SenderName         string                  `json:"sender_name,omitempty"`
+	RawSender          string                  `json:"raw_sender,omitempty"`
+	SenderServer       string                  `json:"sender_server,omitempty"`
+	IsFromMe           bool                    `json:"is_from_me"`
+	IsSelfChat         bool                    `json:"is_self_chat"`
+	Content            string                  `json:"content"`
+	IsForwarded        bool                    `json:"is_forwarded"`
+	ForwardingScore    uint32                  `json:"forwarding_score,omitempty"`
+	Selection          *SelectionResponse      `json:"selection,omitempty"`
+	Payment            *PaymentResponse        `json:"payment,omitempty"`
+	QuotedMessageID    string                  `json:"quoted_message_id,omitempty"`
+	Quoted             *ThreadMessageResponse  `json:"quoted,omitempty"`
+	Replies            []ThreadMessageResponse `json:"replies"`
+	Media              *RawMediaResponse       `json:"media,omitempty"`
+	Links              []RawLinkResponse       `json:"links"`
+	EmbeddingModel     string                  `json:"embedding_model,omitempty"`
+	EmbeddingCreatedAt string                  `json:"embedding_created_at,omitempty"`
+}
+
+func newRawThreadMessage(msg storage.Message, location *time.Location) ThreadMessageResponse {
+	return ThreadMessageResponse{
+		MessageID:       msg.ID,
+		Seq:             msg.Seq,
+		Timestamp:       formatTimestamp(msg.Time, location),
+		SenderID:        msg.Sender,
+		SenderName:      contextSenderName(msg),
+		IsFromMe:        msg.IsFromMe,
+		Text:            contextMessageText(msg),
+		QuotedMessageID: msg.QuotedMessageID,
+	}
+}
+
+// newRawMessageResponse renders a stored message record. The media key is
+// reported only as present or absent: with the URL it is enough to decrypt
+// the media, which is what the download scope is for.
+func newRawMessageResponse(raw storage.RawMessage, location *time.Location) RawMessageResponse {
+	response := RawMessageResponse{
+		MessageID:       raw.ID,
+		Seq:             raw.Seq,
+		ChatJID:         raw.ChatJID,
+		ChatName:        raw.ChatName,
+		ChatType:        raw.ChatType,
+		ChatServer:      raw.ChatServer,
+		Timestamp:       formatTimestamp(raw.Timestamp, location),
+		SenderID:        raw.Sender,
+		SenderName:      raw.SenderName,
+		RawSender:       raw.RawSender,
+		SenderServer:    raw.SenderServer,
+		IsFromMe:        raw.IsFromMe,
+		IsSelfChat:      raw.IsSelfChat,
+		Content:         raw.Content,
+		IsForwarded:     raw.IsForwarded,
+		ForwardingScore: raw.ForwardingScore,
+		QuotedMessageID: raw.QuotedMessageID,
+		Replies:         make([]ThreadMessageResponse, 0, len(raw.Replies)),
+		Links:           make([]RawLinkResponse, 0, len(raw.Links)),
+		EmbeddingModel:  raw.EmbeddingModel,
+		Payment:         newPaymentResponse(raw.Payment),
+	}
+	if raw.Selection.Kind != "" {
+		response.Selection = &SelectionResponse{Kind: raw.Selection.Kind, ID: raw.Selection.ID, Title: raw.Selection.Title}
+	}
+	if raw.Quoted != nil {
+		quoted := newRawThreadMessage(*raw.Quoted, location)
+		response.Quoted = &quoted
+	}
+	for _, reply := range raw.Replies {
+		response.Replies = append(response.Replies, newRawThreadMessage(reply, location))
+	}
+	if raw.MediaType != "" {
+		response.Media = &RawMediaResponse{
+			MediaType:       raw.MediaType,
+			Filename:        raw.Filename,
+			URL:             raw.URL,
+			HasMediaKey:     len(raw.MediaKey) > 0,
+			FileSHA256:      raw.FileSHA256,
+			FileEncSHA256:   raw.FileEncSHA256,
+			FileLength:      raw.FileLength,
+			Thumbnail:       raw.Thumbnail,
+			LocalPath:       raw.LocalPath,
+			DurationSeconds: raw.Seconds,
+			VoiceNote:       raw.VoiceNote,
+		}
+		for _, sample := range raw.Waveform {
+			response.Media.Waveform = append(response.Media.Waveform, int(sample))
+		}
+	}
+	for _, link := range raw.Links {
+		response.Links = append(response.Links, RawLinkResponse{URL: link.URL, Domain: link.Domain, Title: link.Title})
+	}
+	if !raw.EmbeddingCreatedAt.IsZero() {
+		response.EmbeddingCreatedAt = formatTimestamp(raw.EmbeddingCreatedAt, location)
+	}
+	return response
+}
+
+// rawMessageHandler returns everything stored about one message, for
+// debugging and for tools that need more than the list views carry.
+func rawMessageHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageID := strings.TrimSpace(r.PathValue("id"))
+		chatJID := strings.TrimSpace(r.URL.Query().Get("chat_jid"))
+		if messageID == "" || chatJID == "" {
+			http.Error(w, "Message ID and chat_jid are required", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		raw, err := messageStore.GetRawMessage(messageID, chatJID)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to load message", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newRawMessageResponse(raw, location))
+	}
+}
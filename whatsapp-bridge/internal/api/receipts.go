@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/whatsapp"
+)
+
+const playedReceiptTimeout = 30 * time.Second
+
+type PlayedReceiptRequest struct {
+	MessageIDs []string `json:"message_ids"`
+}
+
+type PlayedReceiptResultResponse struct {
+	MessageID string `json:"message_id"`
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+type PlayedReceiptsResponse struct {
+	ChatJID  string                        `json:"chat_jid"`
+	Receipts []PlayedReceiptResultResponse `json:"receipts"`
+}
+
+// playedReceiptHandler marks voice notes in a chat as played, separately from
+// read receipts, so the sender sees the blue microphone.
+func playedReceiptHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		var req PlayedReceiptRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if len(req.MessageIDs) == 0 {
+			http.Error(w, "message_ids is required", http.StatusBadRequest)
+			return
+		}
+
+		client := runtime.currentClient()
+		if client == nil {
+			http.Error(w, "WhatsApp client is not initialized. Start connect first.", http.StatusServiceUnavailable)
+			return
+		}
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), playedReceiptTimeout)
+		defer cancel()
+		results, err := whatsapp.MarkVoiceNotesPlayed(ctx, client, messageStore, chatJID, req.MessageIDs)
+		if err != nil {
+			writeSendError(w, err)
+			return
+		}
+
+		response := PlayedReceiptsResponse{ChatJID: chatJID, Receipts: make([]PlayedReceiptResultResponse, 0, len(results))}
+		for _, result := range results {
+			response.Receipts = append(response.Receipts, PlayedReceiptResultResponse{
+				MessageID: result.MessageID,
+				Status:    result.Status,
+				Detail:    result.Detail,
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/webhook"
+	"whatsapp-client/internal/whatsapp"
+)
+
+const (
+	// EventReminderDue is emitted for due reminders delivered as events.
+	EventReminderDue = "reminder.due"
+
+	reminderPollInterval = 15 * time.Second
+	reminderBatchSize    = 50
+	maxReminderNoteRunes = 1000
+)
+
+type CreateReminderRequest struct {
+	ChatJID   string `json:"chat_jid"`
+	MessageID string `json:"message_id,omitempty"`
+	Note      string `json:"note,omitempty"`
+	DueAt     string `json:"due_at"`
+	// Delivery is "event" (default) or "self_chat".
+	Delivery string `json:"delivery,omitempty"`
+}
+
+type ReminderResponse struct {
+	ID        int64  `json:"id"`
+	ChatJID   string `json:"chat_jid"`
+	MessageID string `json:"message_id,omitempty"`
+	Note      string `json:"note,omitempty"`
+	DueAt     string `json:"due_at"`
+	Delivery  string `json:"delivery"`
+	State     string `json:"state"`
+	Error     string `json:"error,omitempty"`
+	CreatedAt string `json:"created_at"`
+	FiredAt   string `json:"fired_at,omitempty"`
+}
+
+type RemindersResponse struct {
+	Reminders []ReminderResponse `json:"reminders"`
+}
+
+// ReminderEvent is the webhook payload of a due reminder.
+type ReminderEvent struct {
+	ReminderID     int64  `json:"reminder_id"`
+	ChatJID        string `json:"chat_jid"`
+	MessageID      string `json:"message_id,omitempty"`
+	Note           string `json:"note,omitempty"`
+	DueAt          string `json:"due_at"`
+	MessageContent string `json:"message_content,omitempty"`
+}
+
+func newReminderResponse(reminder storage.Reminder) ReminderResponse {
+	return ReminderResponse{
+		ID:        reminder.ID,
+		ChatJID:   reminder.ChatJID,
+		MessageID: reminder.MessageID,
+		Note:      reminder.Note,
+		DueAt:     reminder.DueAt.UTC().Format(time.RFC3339),
+		Delivery:  reminder.Delivery,
+		State:     reminder.State,
+		Error:     reminder.Error,
+		CreatedAt: reminder.CreatedAt.UTC().Format(time.RFC3339),
+		FiredAt:   formatOptionalTime(reminder.FiredAt),
+	}
+}
+
+// startReminderWorker fires due reminders. Reminders are persisted, so any
+// that came due while the bridge was down fire on the first poll after a
+// restart. Delivery is at least once: a crash between delivering and
+// recording a reminder repeats it.
+func startReminderWorker(runtime *whatsAppRuntime) {
+	go func() {
+		ticker := time.NewTicker(reminderPollInterval)
+		defer ticker.Stop()
+		for {
+			fireDueReminders(runtime, time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+func fireDueReminders(runtime *whatsAppRuntime, now time.Time) {
+	messageStore := runtime.currentMessageStore()
+	if messageStore == nil {
+		return
+	}
+	reminders, err := messageStore.DueReminders(now, reminderBatchSize)
+	if err != nil {
+		fmt.Printf("Warning: failed to load due reminders: %v\n", err)
+		return
+	}
+	for _, reminder := range reminders {
+		retry, deliveryErr := deliverReminder(runtime, messageStore, reminder)
+		if retry {
+			continue
+		}
+		if deliveryErr != nil {
+			fmt.Printf("Warning: failed to deliver reminder %d: %v\n", reminder.ID, deliveryErr)
+		}
+		if err := messageStore.FinishReminder(reminder.ID, time.Now(), deliveryErr); err != nil {
+			fmt.Printf("Warning: failed to record reminder %d: %v\n", reminder.ID, err)
+		}
+	}
+}
+
+// deliverReminder fires one reminder. retry reports that it could not be
+// attempted yet, for example because no device is linked, and should stay
+// pending.
+func deliverReminder(runtime *whatsAppRuntime, messageStore *storage.MessageStore, reminder storage.Reminder) (retry bool, err error) {
+	var message *storage.RawMessage
+	if reminder.MessageID != "" {
+		if raw, err := messageStore.GetRawMessage(reminder.MessageID, reminder.ChatJID); err == nil {
+			message = &raw
+		}
+	}
+
+	if reminder.Delivery == storage.ReminderDeliverySelfChat {
+		client := runtime.currentClient()
+		if client == nil || client.Store == nil || client.Store.ID == nil || !client.IsConnected() {
+			return true, nil
+		}
+		if ok, result := whatsapp.SendWhatsAppMessage(client, messageStore, client.Store.ID.ToNonAD().String(), reminderText(reminder, message), "", whatsapp.SendOptions{}); !ok {
+			return false, errors.New(result)
+		}
+		return false, nil
+	}
+
+	event := ReminderEvent{
+		ReminderID: reminder.ID,
+		ChatJID:    reminder.ChatJID,
+		MessageID:  reminder.MessageID,
+		Note:       reminder.Note,
+		DueAt:      reminder.DueAt.UTC().Format(time.RFC3339),
+	}
+	if message != nil {
+		event.MessageContent = message.Content
+	}
+	runtime.webhooks.Emit(EventReminderDue, reminder.DueAt, webhook.Subject{ChatJID: reminder.ChatJID}, event)
+	return false, nil
+}
+
+// reminderText renders a reminder for the self-chat, quoting the message it
+// is about when that is still stored.
+func reminderText(reminder storage.Reminder, message *storage.RawMessage) string {
+	var text strings.Builder
+	text.WriteString("⏰ Reminder")
+	if reminder.Note != "" {
+		text.WriteString(": ")
+		text.WriteString(reminder.Note)
+	}
+	switch {
+	case message != nil:
+		sender := message.SenderName
+		if sender == "" {
+			sender = message.Sender
+		}
+		if message.IsFromMe {
+			sender = "You"
+		}
+		content := message.Content
+		if content == "" && message.MediaType != "" {
+			content = "[" + message.MediaType + "]"
+		}
+		fmt.Fprintf(&text, "\n> %s: %s", sender, content)
+		if message.ChatName != "" {
+			fmt.Fprintf(&text, "\n(in %s)", message.ChatName)
+		}
+	case reminder.MessageID != "":
+		fmt.Fprintf(&text, "\n(message %s in %s)", reminder.MessageID, reminder.ChatJID)
+	default:
+		fmt.Fprintf(&text, "\n(chat %s)", reminder.ChatJID)
+	}
+	return text.String()
+}
+
+// remindersHandler lists reminders (GET, optionally by state) or creates one
+// (POST) for a chat or one of its stored messages.
+func remindersHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			limit, ok := parseLimitParam(r, 100, 1000)
+			if !ok {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+			state := strings.TrimSpace(r.URL.Query().Get("state"))
+			switch state {
+			case "", storage.ReminderStatePending, storage.ReminderStateFired, storage.ReminderStateFailed, storage.ReminderStateCancelled:
+			default:
+				http.Error(w, "Invalid state", http.StatusBadRequest)
+				return
+			}
+			reminders, err := messageStore.ListReminders(state, limit)
+			if err != nil {
+				http.Error(w, "Failed to load reminders", http.StatusInternalServerError)
+				return
+			}
+			response := RemindersResponse{Reminders: make([]ReminderResponse, 0, len(reminders))}
+			for _, reminder := range reminders {
+				response.Reminders = append(response.Reminders, newReminderResponse(reminder))
+			}
+			writeJSON(w, http.StatusOK, response)
+			return
+		}
+
+		var req CreateReminderRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		chatJID := jid.NormalizeChat(req.ChatJID)
+		if chatJID == "" {
+			http.Error(w, "chat_jid is required", http.StatusBadRequest)
+			return
+		}
+		dueAt, err := time.Parse(time.RFC3339, strings.TrimSpace(req.DueAt))
+		if err != nil {
+			http.Error(w, "Invalid due_at", http.StatusBadRequest)
+			return
+		}
+		note := strings.TrimSpace(req.Note)
+		if len([]rune(note)) > maxReminderNoteRunes {
+			http.Error(w, fmt.Sprintf("note must be at most %d characters", maxReminderNoteRunes), http.StatusBadRequest)
+			return
+		}
+		delivery := strings.TrimSpace(req.Delivery)
+		switch delivery {
+		case "":
+			delivery = storage.ReminderDeliveryEvent
+		case storage.ReminderDeliveryEvent, storage.ReminderDeliverySelfChat:
+		default:
+			http.Error(w, "delivery must be event or self_chat", http.StatusBadRequest)
+			return
+		}
+		messageID := strings.TrimSpace(req.MessageID)
+		if messageID != "" {
+			if _, err := messageStore.GetRawMessage(messageID, chatJID); errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "Message not found", http.StatusNotFound)
+				return
+			} else if err != nil {
+				http.Error(w, "Failed to load message", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		reminder, err := messageStore.CreateReminder(storage.Reminder{
+			ChatJID:   chatJID,
+			MessageID: messageID,
+			Note:      note,
+			DueAt:     dueAt,
+			Delivery:  delivery,
+		})
+		if err != nil {
+			http.Error(w, "Failed to save reminder", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newReminderResponse(reminder))
+	}
+}
+
+// reminderHandler returns (GET) or cancels (DELETE) a reminder. Only pending
+// reminders can be cancelled.
+func reminderHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "Invalid reminder ID", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			cancelled, err := messageStore.CancelReminder(id)
+			if err != nil {
+				http.Error(w, "Failed to cancel reminder", http.StatusInternalServerError)
+				return
+			}
+			if !cancelled {
+				http.Error(w, "Pending reminder not found", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		reminder, err := messageStore.GetReminder(id)
+		if err != nil {
+			http.Error(w, "Failed to load reminder", http.StatusInternalServerError)
+			return
+		}
+		if reminder == nil {
+			http.Error(w, "Reminder not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, newReminderResponse(*reminder))
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jobs"
+	"whatsapp-client/internal/storage"
+)
+
+const (
+	// defaultRouteTimeout bounds reading and answering a request on routes
+	// without their own timeout.
+	defaultRouteTimeout = 60 * time.Second
+	// mediaRouteTimeout leaves media uploads and downloads time to finish.
+	mediaRouteTimeout = 5 * time.Minute
+	// maxDeadlineMargin caps the time kept back from a route timeout to write
+	// the response once the handler's context deadline passes.
+	maxDeadlineMargin = 5 * time.Second
+)
+
+// defaultRouteTimeoutRules size the routes that move media. The event stream
+// manages its own write deadlines and must not be cut off.
+var defaultRouteTimeoutRules = []routeTimeoutRule{
+	{pattern: "/api/send", timeout: mediaRouteTimeout},
+	{pattern: "/api/send/broadcast", timeout: mediaRouteTimeout},
+	{pattern: "/api/download", timeout: mediaRouteTimeout},
+	{pattern: "/api/chats/{jid}/media/download", timeout: mediaRouteTimeout},
+	{pattern: "/api/messages/{id}/media", timeout: mediaRouteTimeout},
+	{pattern: "/api/events/stream", timeout: 0},
+}
+
+type routeTimeoutRule struct {
+	pattern string
+	// timeout of zero disables the route's deadlines.
+	timeout time.Duration
+}
+
+// routeTimeouts holds per-route request timeouts; the first matching rule
+// applies, then the default.
+type routeTimeouts struct {
+	defaultTimeout time.Duration
+	rules          []routeTimeoutRule
+}
+
+// routeTimeoutsFromEnv builds the timeouts from the built-in media rules and
+// WHATSAPP_BRIDGE_ROUTE_TIMEOUTS overrides in seconds, of the form
+// "/api/send=600,/api/chats/{jid}/media/download=300". An override of 0
+// lifts a route's deadlines.
+func routeTimeoutsFromEnv() routeTimeouts {
+	timeouts := routeTimeouts{defaultTimeout: defaultRouteTimeout}
+	for _, part := range strings.Split(os.Getenv("WHATSAPP_BRIDGE_ROUTE_TIMEOUTS"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		pattern, rawSeconds, ok := strings.Cut(part, "=")
+		seconds, err := strconv.Atoi(strings.TrimSpace(rawSeconds))
+		if !ok || err != nil || seconds < 0 || !strings.HasPrefix(strings.TrimSpace(pattern), "/") {
+			fmt.Printf("Warning: invalid WHATSAPP_BRIDGE_ROUTE_TIMEOUTS entry %q, ignoring\n", part)
+			continue
+		}
+		timeouts.rules = append(timeouts.rules, routeTimeoutRule{
+			pattern: strings.TrimSpace(pattern),
+			timeout: time.Duration(seconds) * time.Second,
+		})
+	}
+	timeouts.rules = append(timeouts.rules, defaultRouteTimeoutRules...)
+	return timeouts
+}
+
+func (t routeTimeouts) forPath(path string) time.Duration {
+	for _, rule := range t.rules {
+		if routePathMatches(rule.pattern, path) {
+			return rule.timeout
+		}
+	}
+	return t.defaultTimeout
+}
+
+// deadlineMargin is how long before the route timeout a handler's context
+// expires, so it can still answer before the connection is cut.
+func deadlineMargin(timeout time.Duration) time.Duration {
+	return min(timeout/10, maxDeadlineMargin)
+}
+
+// withRouteTimeouts sets each request's read and write deadlines from its
+// route timeout, overriding the server-wide ones, and gives the handler a
+// context that expires slightly earlier.
+func withRouteTimeouts(timeouts routeTimeouts, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := timeouts.forPath(r.URL.Path)
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		deadline := time.Now().Add(timeout)
+		controller := http.NewResponseController(w)
+		// Not every writer supports deadlines (for example in tests); the
+		// server-wide timeouts then still apply.
+		_ = controller.SetReadDeadline(deadline)
+		_ = controller.SetWriteDeadline(deadline)
+
+		ctx, cancel := context.WithDeadline(r.Context(), deadline.Add(-deadlineMargin(timeout)))
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type deferredResult[T any] struct {
+	value T
+	err   error
+}
+
+// runOrDefer runs work detached from the request and waits for it until the
+// request context ends, normally at the deadline set by withRouteTimeouts.
+// Work still running then is adopted by a background job of the given kind,
+// whose record is returned in place of a result, so a long operation answers
+// with a job ID rather than being cut off mid-response. summarize becomes the
+// job's message when the work succeeds.
+func runOrDefer[T any](r *http.Request, runtime *whatsAppRuntime, kind string, subject string, work func(ctx context.Context) (T, error), summarize func(T) string) (T, *storage.JobRecord, error) {
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(r.Context()))
+	done := make(chan deferredResult[T], 1)
+	go func() {
+		value, err := work(workCtx)
+		done <- deferredResult[T]{value: value, err: err}
+	}()
+
+	var zero T
+	select {
+	case result := <-done:
+		cancelWork()
+		return result.value, nil, result.err
+	case <-r.Context().Done():
+	}
+
+	job, err := runtime.jobs.Start(kind, subject, 1, func(ctx context.Context, progress *jobs.Progress) error {
+		defer cancelWork()
+		select {
+		case result := <-done:
+			if result.err != nil {
+				return result.err
+			}
+			progress.Succeeded()
+			progress.SetMessage(summarize(result.value))
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		cancelWork()
+		return zero, nil, fmt.Errorf("operation did not finish in time and could not be continued as a job: %v", err)
+	}
+	return zero, &job, nil
+}
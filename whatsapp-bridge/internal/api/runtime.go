@@ -7,36 +7,48 @@ import (
 	"go.mau.fi/whatsmeow"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	"whatsapp-client/internal/bootstrap"
+	"whatsapp-client/internal/embedding"
+	"whatsapp-client/internal/jobs"
 	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/webhook"
 	"whatsapp-client/internal/whatsapp"
 )
 
 type whatsAppRuntime struct {
-	mu           sync.RWMutex
-	client       *whatsmeow.Client
-	logger       waLog.Logger
-	messageStore *storage.MessageStore
+	mu             sync.RWMutex
+	clients        clientLifecycle[*whatsmeow.Client]
+	logger         waLog.Logger
+	messageStore   *storage.MessageStore
+	indexer        *embedding.Indexer
+	webhooks       *webhook.Emitter
+	jobs           *jobs.Manager
+	spamClassifier *whatsapp.SpamClassifier
+	sendDedup      *sendDedup
+	pacing         whatsapp.PacingProfile
 }
 
 func newWhatsAppRuntime(logger waLog.Logger, messageStore *storage.MessageStore) *whatsAppRuntime {
-	return &whatsAppRuntime{
-		logger:       logger,
-		messageStore: messageStore,
+	runtime := &whatsAppRuntime{
+		logger:         logger,
+		messageStore:   messageStore,
+		indexer:        embedding.NewIndexer(embedding.ConfigFromEnv()),
+		spamClassifier: whatsapp.SpamClassifierFromEnv(),
+		sendDedup:      newSendDedup(sendDedupConfigFromEnv()),
+		pacing:         whatsapp.PacingFromEnv(),
 	}
+	runtime.jobs = jobs.NewManager(runtime.currentMessageStore)
+	runtime.webhooks = webhook.NewEmitter(webhook.ConfigFromEnv(), runtime.currentMessageStore)
+	return runtime
 }
 
 func (r *whatsAppRuntime) currentClient() *whatsmeow.Client {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return r.client
+	return r.clients.current()
 }
 
-func (r *whatsAppRuntime) detachClient() *whatsmeow.Client {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	client := r.client
-	r.client = nil
-	return client
+// stopClient takes the client out of the runtime for disconnecting or
+// revoking it. No new client is built until release is called.
+func (r *whatsAppRuntime) stopClient() (*whatsmeow.Client, func()) {
+	return r.clients.stop()
 }
 
 func (r *whatsAppRuntime) currentMessageStore() *storage.MessageStore {
@@ -86,32 +98,13 @@ func (r *whatsAppRuntime) newClient() (*whatsmeow.Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize WhatsApp client: %w", err)
 	}
-	whatsapp.WireEventHandlers(client, messageStore, r.logger)
+	pipeline := whatsapp.NewMessagePipeline(messageStore, r.indexer, r.webhooks, r.spamClassifier)
+	whatsapp.WireEventHandlers(client, messageStore, r.indexer, pipeline, r.webhooks, r.logger)
 	return client, nil
 }
 
+// ensureClient returns the runtime's client, building it on first use.
+// Concurrent callers share one build, so only one socket is ever opened.
 func (r *whatsAppRuntime) ensureClient() (*whatsmeow.Client, error) {
-	r.mu.RLock()
-	existing := r.client
-	r.mu.RUnlock()
-	if existing != nil {
-		return existing, nil
-	}
-
-	client, err := r.newClient()
-	if err != nil {
-		return nil, err
-	}
-
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	// Another request may have initialized while we built this one.
-	if r.client != nil {
-		if client.IsConnected() {
-			client.Disconnect()
-		}
-		return r.client, nil
-	}
-	r.client = client
-	return client, nil
+	return r.clients.ensure(r.newClient)
 }
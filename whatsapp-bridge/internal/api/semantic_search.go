@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+type SemanticSearchResult struct {
+	MessageID     string  `json:"message_id"`
+	ChatJID       string  `json:"chat_jid"`
+	SenderID      string  `json:"sender_id"`
+	SenderName    string  `json:"sender_name,omitempty"`
+	Timestamp     string  `json:"timestamp"`
+	IsFromMe      bool    `json:"is_from_me"`
+	Content       string  `json:"content"`
+	Score         float64 `json:"score"`
+	SemanticScore float64 `json:"semantic_score"`
+	KeywordMatch  bool    `json:"keyword_match"`
+}
+
+type SemanticSearchResponse struct {
+	Query   string                 `json:"query"`
+	Mode    string                 `json:"mode"`
+	Results []SemanticSearchResult `json:"results"`
+}
+
+// semanticSearchHandler ranks messages by embedding similarity blended with
+// keyword matches. It falls back to keyword-only search when embeddings are
+// disabled or the embedding endpoint fails.
+func semanticSearchHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		queryText := strings.TrimSpace(r.URL.Query().Get("q"))
+		if queryText == "" {
+			http.Error(w, "Query is required", http.StatusBadRequest)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 20, 100)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		query := storage.SemanticQuery{
+			Text:    queryText,
+			ChatJID: strings.TrimSpace(r.URL.Query().Get("chat_jid")),
+			Limit:   limit,
+		}
+		mode := "keyword"
+		if client := runtime.indexer.Client(); client != nil {
+			ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+			vector, err := client.Embed(ctx, queryText)
+			cancel()
+			if err != nil {
+				runtime.logger.Warnf("Semantic search falling back to keyword matching: %v", err)
+			} else {
+				query.Vector = vector
+				query.Model = client.Model()
+				mode = "hybrid"
+			}
+		}
+
+		results, err := messageStore.SemanticSearch(query)
+		if err != nil {
+			http.Error(w, "Failed to search messages", http.StatusInternalServerError)
+			return
+		}
+
+		response := SemanticSearchResponse{
+			Query:   queryText,
+			Mode:    mode,
+			Results: make([]SemanticSearchResult, 0, len(results)),
+		}
+		for _, result := range results {
+			response.Results = append(response.Results, SemanticSearchResult{
+				MessageID:     result.ID,
+				ChatJID:       result.ChatJID,
+				SenderID:      result.Sender,
+				SenderName:    result.SenderName,
+				Timestamp:     formatTimestamp(result.Time, location),
+				IsFromMe:      result.IsFromMe,
+				Content:       result.Content,
+				Score:         result.Score,
+				SemanticScore: result.SemanticScore,
+				KeywordMatch:  result.KeywordMatch,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+const (
+	defaultSendDedupWindow = time.Minute
+
+	// sendDedupReject refuses a duplicate send with 409 Conflict;
+	// sendDedupDedupe answers it with the original send's outcome.
+	sendDedupReject = "reject"
+	sendDedupDedupe = "dedupe"
+
+	sendErrorDuplicate = "duplicate_send"
+)
+
+// sendDedupConfig says how long identical sends are suppressed and how.
+type sendDedupConfig struct {
+	// Window is how long after a send an identical one is a duplicate; zero
+	// turns suppression off.
+	Window time.Duration
+	Mode   string
+}
+
+// sendDedupConfigFromEnv reads WHATSAPP_SEND_DEDUP_SECONDS (0 disables) and
+// WHATSAPP_SEND_DEDUP_MODE (reject or dedupe).
+func sendDedupConfigFromEnv() sendDedupConfig {
+	cfg := sendDedupConfig{Window: defaultSendDedupWindow, Mode: sendDedupReject}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_SEND_DEDUP_SECONDS")); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 {
+			fmt.Printf("Warning: invalid WHATSAPP_SEND_DEDUP_SECONDS=%q, using %d\n", raw, int(defaultSendDedupWindow/time.Second))
+		} else {
+			cfg.Window = time.Duration(seconds) * time.Second
+		}
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("WHATSAPP_SEND_DEDUP_MODE"))); mode {
+	case "", sendDedupReject:
+	case sendDedupDedupe:
+		cfg.Mode = sendDedupDedupe
+	default:
+		fmt.Printf("Warning: invalid WHATSAPP_SEND_DEDUP_MODE=%q, using %s\n", mode, sendDedupReject)
+	}
+	return cfg
+}
+
+// sendDedupKey identifies a send by its recipient and everything that makes
+// up its content.
+func sendDedupKey(req SendMessageRequest) string {
+	recipient := jid.NormalizeChat(strings.TrimPrefix(strings.TrimSpace(req.Recipient), "+"))
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%t\x00%t", req.Message, req.MediaPath, req.IsGIF, req.MentionAll)))
+	return recipient + "\x00" + hex.EncodeToString(sum[:])
+}
+
+// priorSend is the send an identical request duplicates.
+type priorSend struct {
+	At time.Time
+	// InFlight is set until the original send finishes.
+	InFlight   bool
+	MessageIDs []string
+	JobID      string
+	QueuedID   int64
+}
+
+// sendDedup remembers recent sends so retry storms from upstream clients do
+// not message a recipient twice.
+type sendDedup struct {
+	mu     sync.Mutex
+	window time.Duration
+	mode   string
+	sends  map[string]*priorSend
+}
+
+func newSendDedup(cfg sendDedupConfig) *sendDedup {
+	return &sendDedup{window: cfg.Window, mode: cfg.Mode, sends: map[string]*priorSend{}}
+}
+
+// claim reserves key for a send at now. When an identical send was claimed
+// within the window it returns a copy of that send and false instead.
+func (d *sendDedup) claim(key string, now time.Time) (priorSend, bool) {
+	if d == nil || d.window <= 0 {
+		return priorSend{}, true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for existingKey, send := range d.sends {
+		if !send.InFlight && now.Sub(send.At) >= d.window {
+			delete(d.sends, existingKey)
+		}
+	}
+	if send, ok := d.sends[key]; ok {
+		prior := *send
+		prior.MessageIDs = append([]string(nil), send.MessageIDs...)
+		return prior, false
+	}
+	d.sends[key] = &priorSend{At: now, InFlight: true}
+	return priorSend{}, true
+}
+
+// finish records how the send claimed under key ended; the window runs from
+// now.
+func (d *sendDedup) finish(key string, now time.Time, outcome priorSend) {
+	if d == nil || d.window <= 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	outcome.At = now
+	outcome.InFlight = false
+	d.sends[key] = &outcome
+}
+
+// release forgets the send claimed under key, for a send that failed and may
+// be retried at once.
+func (d *sendDedup) release(key string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sends, key)
+}
+
+// writeDuplicateSend answers a suppressed duplicate: 409 Conflict in reject
+// mode, or the original send's outcome in dedupe mode.
+func writeDuplicateSend(w http.ResponseWriter, dedup *sendDedup, prior priorSend) {
+	ago := time.Since(prior.At).Round(time.Second)
+	if dedup.mode == sendDedupDedupe {
+		response := SendMessageResponse{
+			Success:    !prior.InFlight && prior.JobID == "" && prior.QueuedID == 0,
+			Message:    fmt.Sprintf("Identical message was sent to this recipient %s ago; not sent again", ago),
+			MessageIDs: prior.MessageIDs,
+			JobID:      prior.JobID,
+			QueuedID:   prior.QueuedID,
+			Duplicate:  true,
+		}
+		statusCode := http.StatusOK
+		if !response.Success {
+			statusCode = http.StatusAccepted
+			response.Message = fmt.Sprintf("Identical message to this recipient is still being sent (started %s ago); not sent again", ago)
+		}
+		writeJSON(w, statusCode, response)
+		return
+	}
+	writeJSON(w, http.StatusConflict, SendMessageResponse{
+		Success:    false,
+		Message:    fmt.Sprintf("Identical message was sent to this recipient %s ago; set force to send it again", ago),
+		ErrorCode:  sendErrorDuplicate,
+		MessageIDs: prior.MessageIDs,
+		JobID:      prior.JobID,
+		QueuedID:   prior.QueuedID,
+	})
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSendDedupKey(t *testing.T) {
+	base := SendMessageRequest{Recipient: "15551234567", Message: "hello"}
+	if sendDedupKey(base) != sendDedupKey(SendMessageRequest{Recipient: "+15551234567", Message: "hello"}) {
+		t.Fatal("expected a leading + to name the same recipient")
+	}
+	if sendDedupKey(base) != sendDedupKey(SendMessageRequest{Recipient: "15551234567@s.whatsapp.net", Message: "hello"}) {
+		t.Fatal("expected a full JID to name the same recipient")
+	}
+	for _, other := range []SendMessageRequest{
+		{Recipient: "15550000000", Message: "hello"},
+		{Recipient: "15551234567", Message: "hello!"},
+		{Recipient: "15551234567", Message: "hello", MediaPath: "/tmp/a.jpg"},
+	} {
+		if sendDedupKey(base) == sendDedupKey(other) {
+			t.Fatalf("expected %+v to differ from %+v", other, base)
+		}
+	}
+}
+
+func TestSendDedupClaim(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	dedup := newSendDedup(sendDedupConfig{Window: time.Minute, Mode: sendDedupReject})
+
+	if _, ok := dedup.claim("a", now); !ok {
+		t.Fatal("expected the first send to be claimed")
+	}
+	prior, ok := dedup.claim("a", now.Add(time.Second))
+	if ok || !prior.InFlight {
+		t.Fatalf("expected a duplicate of the in-flight send, got %+v, %v", prior, ok)
+	}
+
+	dedup.finish("a", now.Add(2*time.Second), priorSend{MessageIDs: []string{"ID1"}})
+	prior, ok = dedup.claim("a", now.Add(30*time.Second))
+	if ok || prior.InFlight || len(prior.MessageIDs) != 1 {
+		t.Fatalf("expected a duplicate of the finished send, got %+v, %v", prior, ok)
+	}
+	if _, ok := dedup.claim("a", now.Add(2*time.Second+time.Minute)); !ok {
+		t.Fatal("expected the send to be claimable once the window passed")
+	}
+
+	dedup.release("a")
+	if _, ok := dedup.claim("a", now.Add(2*time.Minute)); !ok {
+		t.Fatal("expected a released send to be claimable at once")
+	}
+}
+
+func TestSendDedupDisabled(t *testing.T) {
+	dedup := newSendDedup(sendDedupConfig{})
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, ok := dedup.claim("a", now); !ok {
+			t.Fatal("expected no suppression with a zero window")
+		}
+	}
+}
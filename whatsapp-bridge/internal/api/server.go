@@ -8,6 +8,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -17,19 +18,46 @@ import (
 	"go.mau.fi/whatsmeow"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	"whatsapp-client/internal/bootstrap"
+	"whatsapp-client/internal/digest"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/mediacrypt"
+	"whatsapp-client/internal/secrets"
 	"whatsapp-client/internal/storage"
 	"whatsapp-client/internal/whatsapp"
 )
 
 type SendMessageResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	// MessageIDs identify the sent messages for later status queries.
+	MessageIDs []string `json:"message_ids,omitempty"`
+	// Retryable and RetryAfterSeconds hint whether a failed send may succeed later.
+	Retryable         bool `json:"retryable,omitempty"`
+	RetryAfterSeconds int  `json:"retry_after_seconds,omitempty"`
+	// JobID is set with 202 Accepted when the send outlived the request and
+	// continues as a background job.
+	JobID string `json:"job_id,omitempty"`
+	// QueuedID is set with 202 Accepted when WhatsApp was disconnected and
+	// the send waits in the outbox for the connection to return.
+	QueuedID int64 `json:"queued_id,omitempty"`
+	// Duplicate is set when the send repeated one made moments before and
+	// was not sent again; the IDs are those of the original send.
+	Duplicate bool `json:"duplicate,omitempty"`
 }
 
 type SendMessageRequest struct {
-	Recipient string `json:"recipient"`
-	Message   string `json:"message"`
-	MediaPath string `json:"media_path,omitempty"`
+	Recipient  string `json:"recipient"`
+	Message    string `json:"message"`
+	MediaPath  string `json:"media_path,omitempty"`
+	MentionAll bool   `json:"mention_all,omitempty"`
+	// IsGIF sends an MP4 media_path as a GIF that loops inline.
+	IsGIF bool `json:"is_gif,omitempty"`
+	// OverrideOptOut sends even though the recipient opted out.
+	OverrideOptOut bool `json:"override_opt_out,omitempty"`
+	// Force sends even when an identical send to the recipient was made
+	// within the duplicate suppression window.
+	Force bool `json:"force,omitempty"`
 }
 
 type DownloadMediaRequest struct {
@@ -38,10 +66,25 @@ type DownloadMediaRequest struct {
 }
 
 type DownloadMediaResponse struct {
-	Success  bool   `json:"success"`
-	Message  string `json:"message"`
-	Filename string `json:"filename,omitempty"`
-	Path     string `json:"path,omitempty"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Filename  string `json:"filename,omitempty"`
+	Path      string `json:"path,omitempty"`
+	// Encrypted is set when media is encrypted at rest, so Path holds
+	// ciphertext; ContentURL then serves the decrypted file.
+	Encrypted  bool   `json:"encrypted,omitempty"`
+	ContentURL string `json:"content_url,omitempty"`
+	// JobID is set with 202 Accepted when the download outlived the request
+	// and continues as a background job.
+	JobID string `json:"job_id,omitempty"`
+}
+
+// mediaDownloadResult is the outcome of a single media download.
+type mediaDownloadResult struct {
+	mediaType string
+	filename  string
+	path      string
 }
 
 type AuthStatusResponse struct {
@@ -53,7 +96,18 @@ type AuthStatusResponse struct {
 	SyncProgress   int    `json:"sync_progress,omitempty"`
 	SyncCurrent    int    `json:"sync_current,omitempty"`
 	SyncTotal      int    `json:"sync_total,omitempty"`
-	UpdatedAt      string `json:"updated_at"`
+	// SyncChatRef, SyncMessagesStored and SyncETASeconds describe history
+	// sync progress; see bootstrap.AuthStatus.
+	SyncChatRef        string `json:"sync_chat_ref,omitempty"`
+	SyncMessagesStored int    `json:"sync_messages_stored,omitempty"`
+	SyncETASeconds     int    `json:"sync_eta_seconds,omitempty"`
+	// DisconnectReason is stream_replaced, logged_out, network_error or
+	// manual. ReauthRequired means a QR code must be scanned again; without
+	// it the client reconnects by itself or on POST /api/connect.
+	DisconnectReason string `json:"disconnect_reason,omitempty"`
+	ReauthRequired   bool   `json:"reauth_required,omitempty"`
+	LastTransitionAt string `json:"last_transition_at"`
+	UpdatedAt        string `json:"updated_at"`
 }
 
 type DisconnectResponse struct {
@@ -79,15 +133,24 @@ type HealthResponse struct {
 }
 
 type bridgeAuthConfig struct {
-	jwtSecret              []byte
+	// jwtSecret is resolved per request, so a rotated secret file or store
+	// value applies without a restart.
+	jwtSecret              func() ([]byte, error)
 	audience               string
 	issuer                 string
 	allowedSubjectPrefixes []string
+	// accountID is the WhatsApp account this bridge serves: its runtime user
+	// scope. Tokens naming another account are refused.
+	accountID string
+	// requireAccountID rejects tokens that carry no account_id claim, for
+	// bridges shared behind a gateway that mints per-account tokens.
+	requireAccountID bool
 }
 
 type bridgeJWTClaims struct {
 	Scope     string `json:"scope"`
 	RuntimeID string `json:"runtime_id,omitempty"`
+	AccountID string `json:"account_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -95,9 +158,15 @@ type bridgeJWTClaims struct {
 func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
 	defer r.Body.Close()
 
-	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	// The body is capped per route by withBodyLimits.
+	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(dst); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeBodyTooLarge(w, tooLarge.Limit)
+			return false
+		}
 		http.Error(w, "Invalid request format", http.StatusBadRequest)
 		return false
 	}
@@ -119,6 +188,25 @@ func writeJSON(w http.ResponseWriter, statusCode int, payload interface{}) {
 	}
 }
 
+// mediaPolicyErrorStatus maps a media policy rejection to its HTTP status code.
+func mediaPolicyErrorStatus(err error) (*whatsapp.MediaPolicyError, int, bool) {
+	var policyErr *whatsapp.MediaPolicyError
+	if !errors.As(err, &policyErr) {
+		return nil, 0, false
+	}
+	switch policyErr.Code {
+	case whatsapp.MediaErrorTooLarge:
+		return policyErr, http.StatusRequestEntityTooLarge, true
+	case whatsapp.MediaErrorTypeBlocked, whatsapp.MediaErrorGIFNeedsVideo:
+		return policyErr, http.StatusUnsupportedMediaType, true
+	default:
+		return policyErr, http.StatusForbidden, true
+	}
+}
+
+// sendJobKind marks sends that outlived their request.
+const sendJobKind = "send"
+
 // sendHandler handles POST requests for outbound WhatsApp messages.
 func sendHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -140,6 +228,31 @@ func sendHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 			http.Error(w, "Message or media path is required", http.StatusBadRequest)
 			return
 		}
+		if req.MentionAll && !jid.IsGroup(req.Recipient) {
+			http.Error(w, "mention_all requires a group recipient", http.StatusBadRequest)
+			return
+		}
+
+		if req.IsGIF {
+			if err := whatsapp.CheckGIF(req.MediaPath); err != nil {
+				writeSendError(w, err)
+				return
+			}
+		}
+		if req.MediaPath != "" {
+			if _, err := whatsapp.MediaPolicyFromEnv().CheckUpload(req.MediaPath); err != nil {
+				if policyErr, statusCode, ok := mediaPolicyErrorStatus(err); ok {
+					writeJSON(w, statusCode, SendMessageResponse{
+						Success:   false,
+						Message:   policyErr.Message,
+						ErrorCode: policyErr.Code,
+					})
+					return
+				}
+				writeJSON(w, http.StatusBadRequest, SendMessageResponse{Success: false, Message: err.Error()})
+				return
+			}
+		}
 
 		client := runtime.currentClient()
 		if client == nil {
@@ -150,14 +263,113 @@ func sendHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 			return
 		}
 
-		success, message := whatsapp.SendWhatsAppMessage(client, req.Recipient, req.Message, req.MediaPath)
-		statusCode := http.StatusOK
-		if !success {
-			statusCode = http.StatusInternalServerError
+		messageStore := runtime.currentMessageStore()
+		if err := whatsapp.CheckChatSendPolicy(client, messageStore, req.Recipient); err != nil {
+			if code, statusCode, ok := chatPolicyErrorStatus(err); ok {
+				writeJSON(w, statusCode, SendMessageResponse{
+					Success:   false,
+					Message:   err.Error(),
+					ErrorCode: code,
+				})
+				return
+			}
 		}
 
-		writeJSON(w, statusCode, SendMessageResponse{Success: success, Message: message})
+		dedupKey := sendDedupKey(req)
+		if !req.Force {
+			if prior, ok := runtime.sendDedup.claim(dedupKey, time.Now()); !ok {
+				writeDuplicateSend(w, runtime.sendDedup, prior)
+				return
+			}
+		}
+
+		result, job, err := runOrDefer(r, runtime, sendJobKind, req.Recipient, func(ctx context.Context) (whatsapp.SendResult, error) {
+			return whatsapp.SendMessage(ctx, client, messageStore, req.Recipient, req.Message, req.MediaPath, whatsapp.SendOptions{
+				MentionAll:     req.MentionAll,
+				GIF:            req.IsGIF,
+				OverrideOptOut: req.OverrideOptOut,
+				Pacing:         runtime.pacing,
+			})
+		}, func(result whatsapp.SendResult) string {
+			return fmt.Sprintf("%s (message IDs: %s)", result.Summary, strings.Join(result.MessageIDs, ", "))
+		})
+		var sendErr *whatsapp.SendError
+		if errors.As(err, &sendErr) && sendErr.Code == whatsapp.SendErrorNotConnected && canQueueSend(client) {
+			if queuedID := queueSend(w, client, messageStore, req); queuedID > 0 {
+				runtime.sendDedup.finish(dedupKey, time.Now(), priorSend{QueuedID: queuedID})
+			} else if !req.Force {
+				runtime.sendDedup.release(dedupKey)
+			}
+			return
+		}
+		if err != nil {
+			if !req.Force {
+				runtime.sendDedup.release(dedupKey)
+			}
+			writeSendError(w, err)
+			return
+		}
+		if job != nil {
+			runtime.sendDedup.finish(dedupKey, time.Now(), priorSend{JobID: job.ID})
+			writeJSON(w, http.StatusAccepted, SendMessageResponse{
+				Success: false,
+				Message: "Send is still in progress; follow the job for its outcome",
+				JobID:   job.ID,
+			})
+			return
+		}
+
+		runtime.sendDedup.finish(dedupKey, time.Now(), priorSend{MessageIDs: result.MessageIDs})
+		writeJSON(w, http.StatusOK, SendMessageResponse{Success: true, Message: result.Summary, MessageIDs: result.MessageIDs})
+	}
+}
+
+// sendErrorStatus maps a classified send failure code to its HTTP status.
+func sendErrorStatus(code string) int {
+	switch code {
+	case whatsapp.SendErrorNotConnected, whatsapp.SendErrorNotLoggedIn:
+		return http.StatusServiceUnavailable
+	case whatsapp.SendErrorRecipientNotOnWhatsApp:
+		return http.StatusUnprocessableEntity
+	case whatsapp.SendErrorRateLimited:
+		return http.StatusTooManyRequests
+	case whatsapp.SendErrorMediaReuploadNeeded:
+		return http.StatusGone
+	case whatsapp.SendErrorTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// writeSendError reports a failed send with its error code and retry hint,
+// setting Retry-After when the failure is worth retrying later.
+func writeSendError(w http.ResponseWriter, err error) {
+	if code, statusCode, ok := chatPolicyErrorStatus(err); ok {
+		writeJSON(w, statusCode, SendMessageResponse{Success: false, Message: err.Error(), ErrorCode: code})
+		return
+	}
+	if policyErr, statusCode, ok := mediaPolicyErrorStatus(err); ok {
+		writeJSON(w, statusCode, SendMessageResponse{Success: false, Message: policyErr.Message, ErrorCode: policyErr.Code})
+		return
+	}
+
+	var sendErr *whatsapp.SendError
+	if !errors.As(err, &sendErr) {
+		writeJSON(w, http.StatusInternalServerError, SendMessageResponse{Success: false, Message: err.Error()})
+		return
 	}
+	response := SendMessageResponse{
+		Success:   false,
+		Message:   sendErr.Message,
+		ErrorCode: sendErr.Code,
+		Retryable: sendErr.Retryable,
+	}
+	if sendErr.RetryAfter > 0 {
+		response.RetryAfterSeconds = int(sendErr.RetryAfter / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(response.RetryAfterSeconds))
+	}
+	writeJSON(w, sendErrorStatus(sendErr.Code), response)
 }
 
 // downloadHandler handles POST requests for message media download.
@@ -195,32 +407,77 @@ func downloadHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 			return
 		}
 
-		success, mediaType, filename, path, err := whatsapp.DownloadMedia(client, messageStore, req.MessageID, req.ChatJID)
-		if !success || err != nil {
-			errMsg := "Unknown error"
-			if err != nil {
-				errMsg = err.Error()
+		// DownloadMedia cannot be interrupted, so a deferred download runs to
+		// completion even if its job is cancelled.
+		result, job, err := runOrDefer(r, runtime, mediaBatchJobKind, req.ChatJID, func(context.Context) (mediaDownloadResult, error) {
+			success, mediaType, filename, path, err := whatsapp.DownloadMedia(client, messageStore, req.MessageID, req.ChatJID)
+			if err == nil && !success {
+				err = errors.New("unknown error")
 			}
+			return mediaDownloadResult{mediaType: mediaType, filename: filename, path: path}, err
+		}, func(result mediaDownloadResult) string {
+			return fmt.Sprintf("Downloaded %s media to %s", result.mediaType, result.path)
+		})
+		if job != nil {
+			writeJSON(w, http.StatusAccepted, DownloadMediaResponse{
+				Success: false,
+				Message: "Download is still in progress; follow the job for its outcome",
+				JobID:   job.ID,
+			})
+			return
+		}
+		if policyErr, statusCode, ok := mediaPolicyErrorStatus(err); ok {
+			writeJSON(w, statusCode, DownloadMediaResponse{
+				Success:   false,
+				Message:   fmt.Sprintf("Failed to download media: %s", policyErr.Message),
+				ErrorCode: policyErr.Code,
+			})
+			return
+		}
+		var sendErr *whatsapp.SendError
+		if errors.As(err, &sendErr) && sendErr.Code == whatsapp.SendErrorMediaReuploadNeeded {
+			writeJSON(w, http.StatusGone, DownloadMediaResponse{
+				Success:   false,
+				Message:   sendErr.Message,
+				ErrorCode: sendErr.Code,
+			})
+			return
+		}
+		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, DownloadMediaResponse{
 				Success: false,
-				Message: fmt.Sprintf("Failed to download media: %s", errMsg),
+				Message: fmt.Sprintf("Failed to download media: %s", err.Error()),
 			})
 			return
 		}
 
-		writeJSON(w, http.StatusOK, DownloadMediaResponse{
+		response := DownloadMediaResponse{
 			Success:  true,
-			Message:  fmt.Sprintf("Successfully downloaded %s media", mediaType),
-			Filename: filename,
-			Path:     path,
-		})
+			Message:  fmt.Sprintf("Successfully downloaded %s media", result.mediaType),
+			Filename: result.filename,
+			Path:     result.path,
+		}
+		if encrypted, _ := mediacrypt.IsEncrypted(result.path); encrypted {
+			response.Encrypted = true
+			response.ContentURL = fmt.Sprintf("/api/messages/%s/media?chat_jid=%s", url.PathEscape(req.MessageID), url.QueryEscape(req.ChatJID))
+		}
+		writeJSON(w, http.StatusOK, response)
 	}
 }
 
 func loadBridgeAuthConfig() (bridgeAuthConfig, error) {
-	secret := strings.TrimSpace(os.Getenv("WHATSAPP_BRIDGE_JWT_SECRET"))
-	if secret == "" {
-		return bridgeAuthConfig{}, errors.New("WHATSAPP_BRIDGE_JWT_SECRET is required for bridge JWT auth")
+	jwtSecret := func() ([]byte, error) {
+		secret, err := secrets.Lookup("WHATSAPP_BRIDGE_JWT_SECRET")
+		if err != nil {
+			return nil, err
+		}
+		if secret == "" {
+			return nil, errors.New("WHATSAPP_BRIDGE_JWT_SECRET is required for bridge JWT auth")
+		}
+		return []byte(secret), nil
+	}
+	if _, err := jwtSecret(); err != nil {
+		return bridgeAuthConfig{}, err
 	}
 
 	audience := strings.TrimSpace(os.Getenv("WHATSAPP_BRIDGE_JWT_AUDIENCE"))
@@ -238,11 +495,27 @@ func loadBridgeAuthConfig() (bridgeAuthConfig, error) {
 		[]string{"omicron-api:", "whatsapp-session-controller:"},
 	)
 
+	runtimePaths, err := storage.ResolveRuntimePathsFromEnv()
+	if err != nil {
+		return bridgeAuthConfig{}, err
+	}
+
+	requireAccountID := false
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_BRIDGE_JWT_REQUIRE_ACCOUNT_ID")); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return bridgeAuthConfig{}, fmt.Errorf("invalid WHATSAPP_BRIDGE_JWT_REQUIRE_ACCOUNT_ID=%q", raw)
+		}
+		requireAccountID = parsed
+	}
+
 	return bridgeAuthConfig{
-		jwtSecret:              []byte(secret),
+		jwtSecret:              jwtSecret,
 		audience:               audience,
 		issuer:                 issuer,
 		allowedSubjectPrefixes: allowedSubjectPrefixes,
+		accountID:              runtimePaths.UserScope,
+		requireAccountID:       requireAccountID,
 	}, nil
 }
 
@@ -282,10 +555,40 @@ func hasAllowedSubjectPrefix(subject string, allowedPrefixes []string) bool {
 	return false
 }
 
+// routePathMatches reports whether path matches pattern, where pattern
+// segments wrapped in braces (for example "{jid}") match any single segment.
+func routePathMatches(pattern string, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func requiredScopeForRoute(method string, path string) (string, bool) {
 	switch {
 	case method == http.MethodPost && path == "/api/send":
 		return "whatsapp:send", true
+	case method == http.MethodPost && path == "/api/send/broadcast":
+		return "whatsapp:send", true
+	case method == http.MethodGet && path == "/api/send/queue":
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && routePathMatches("/api/send/queue/{id}", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodDelete && routePathMatches("/api/send/queue/{id}", path):
+		return "whatsapp:send", true
 	case method == http.MethodPost && path == "/api/download":
 		return "whatsapp:download", true
 	case method == http.MethodPost && path == "/api/connect":
@@ -296,18 +599,188 @@ func requiredScopeForRoute(method string, path string) (string, bool) {
 		return "whatsapp:disconnect", true
 	case method == http.MethodPost && path == "/api/disconnect/revoke":
 		return "whatsapp:disconnect", true
+	case method == http.MethodGet && path == "/api/history-sync":
+		return "whatsapp:status", true
+	case method == http.MethodPost && (path == "/api/history-sync/cancel" || path == "/api/history-sync/resume"):
+		return "whatsapp:connect", true
+	case method == http.MethodPost && path == "/api/sync/rebuild":
+		return "whatsapp:connect", true
+	case method == http.MethodGet && (path == "/api/admin/health" || path == "/metrics"):
+		return "whatsapp:status", true
+	case method == http.MethodGet && path == "/api/consistency":
+		return "whatsapp:status", true
+	case method == http.MethodPost && path == "/api/consistency/repair":
+		return "whatsapp:aliases", true
+	case method == http.MethodGet && routePathMatches("/api/chats/{jid}/media", path):
+		return "whatsapp:media", true
+	case method == http.MethodGet && path == "/api/links":
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/aliases":
+		return "whatsapp:read:contacts", true
+	case method == http.MethodPost && path == "/api/aliases/split":
+		return "whatsapp:aliases", true
+	case method == http.MethodPost && routePathMatches("/api/groups/{jid}/invite", path):
+		return "whatsapp:groups", true
+	case method == http.MethodGet && routePathMatches("/api/groups/{jid}/invites", path):
+		return "whatsapp:read:contacts", true
+	case method == http.MethodPost && path == "/api/groups/metadata/refresh":
+		return "whatsapp:groups", true
+	case method == http.MethodGet && routePathMatches("/api/groups/{jid}/metadata", path):
+		return "whatsapp:read:contacts", true
+	case method == http.MethodGet && path == "/api/communities":
+		return "whatsapp:read:contacts", true
+	case method == http.MethodPost && routePathMatches("/api/chats/{jid}/played", path):
+		return "whatsapp:send", true
+	case method == http.MethodGet && routePathMatches("/api/messages/{id}/media", path):
+		return "whatsapp:media", true
+	case method == http.MethodGet && routePathMatches("/api/messages/{id}/raw", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && routePathMatches("/api/messages/{id}/context", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/messages/since":
+		return "whatsapp:read:messages", true
+	case method == http.MethodPost && path == "/api/messages/status":
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/contacts/sync":
+		return "whatsapp:read:contacts", true
+	case method == http.MethodPost && path == "/api/contacts/sync":
+		return "whatsapp:connect", true
+	case method == http.MethodGet && routePathMatches("/api/contacts/{jid}/summary", path):
+		return "whatsapp:read:contacts", true
+	case method == http.MethodGet && routePathMatches("/api/contacts/{jid}/tags", path):
+		return "whatsapp:read:contacts", true
+	case method == http.MethodPut && routePathMatches("/api/contacts/{jid}/tags", path):
+		return "whatsapp:settings", true
+	case method == http.MethodPost && path == "/api/exports":
+		return "whatsapp:read:messages", true
+	case method == http.MethodPost && path == "/api/imports":
+		return "whatsapp:settings", true
+	case method == http.MethodGet && path == "/api/events/replay":
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/events/stream":
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/events/stream/stats":
+		return "whatsapp:read", true
+	case method == http.MethodGet && path == "/api/broadcast-lists":
+		return "whatsapp:read:contacts", true
+	case method == http.MethodPut && routePathMatches("/api/broadcast-lists/{jid}/recipients", path):
+		return "whatsapp:settings", true
+	case method == http.MethodGet && routePathMatches("/api/chats/{jid}/context", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && routePathMatches("/api/chats/{jid}/recent-threads", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && routePathMatches("/api/chats/{jid}/days", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && routePathMatches("/api/chats/{jid}/day/{date}", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/chat-settings":
+		return "whatsapp:read", true
+	case method == http.MethodGet && routePathMatches("/api/chats/{jid}/settings", path):
+		return "whatsapp:read", true
+	case method == http.MethodPut && routePathMatches("/api/chats/{jid}/settings", path):
+		return "whatsapp:settings", true
+	case (method == http.MethodPost || method == http.MethodDelete) && routePathMatches("/api/chats/{jid}/handoff", path):
+		return "whatsapp:settings", true
+	case (method == http.MethodPut || method == http.MethodDelete) && routePathMatches("/api/chats/{jid}/snooze", path):
+		return "whatsapp:settings", true
+	case (method == http.MethodPut || method == http.MethodDelete) && routePathMatches("/api/chats/{jid}/name", path):
+		return "whatsapp:settings", true
+	case method == http.MethodGet && routePathMatches("/api/chats/{jid}/tracking", path):
+		return "whatsapp:read", true
+	case method == http.MethodPut && routePathMatches("/api/chats/{jid}/tracking", path):
+		return "whatsapp:settings", true
+	case method == http.MethodGet && path == "/api/search/semantic":
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/views":
+		return "whatsapp:read", true
+	case method == http.MethodPost && path == "/api/views":
+		return "whatsapp:views", true
+	case method == http.MethodGet && routePathMatches("/api/views/{name}", path):
+		return "whatsapp:read", true
+	case method == http.MethodDelete && routePathMatches("/api/views/{name}", path):
+		return "whatsapp:views", true
+	case method == http.MethodGet && routePathMatches("/api/views/{name}/messages", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/reminders":
+		return "whatsapp:read", true
+	case method == http.MethodPost && path == "/api/reminders":
+		return "whatsapp:reminders", true
+	case method == http.MethodGet && routePathMatches("/api/reminders/{id}", path):
+		return "whatsapp:read", true
+	case method == http.MethodDelete && routePathMatches("/api/reminders/{id}", path):
+		return "whatsapp:reminders", true
+	case method == http.MethodGet && path == "/api/state":
+		return "whatsapp:read", true
+	case (method == http.MethodPut || method == http.MethodDelete) && path == "/api/state":
+		return "whatsapp:state", true
+	case method == http.MethodGet && path == "/api/quarantine":
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/inbox":
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/audiences":
+		return "whatsapp:read:contacts", true
+	case method == http.MethodPost && path == "/api/audiences":
+		return "whatsapp:settings", true
+	case method == http.MethodGet && routePathMatches("/api/audiences/{id}", path):
+		return "whatsapp:read:contacts", true
+	case (method == http.MethodPut || method == http.MethodDelete) && routePathMatches("/api/audiences/{id}", path):
+		return "whatsapp:settings", true
+	case method == http.MethodGet && path == "/api/campaigns":
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/opt-outs":
+		return "whatsapp:read:contacts", true
+	case method == http.MethodGet && routePathMatches("/api/opt-outs/{jid}", path):
+		return "whatsapp:read:contacts", true
+	case (method == http.MethodPut || method == http.MethodDelete) && routePathMatches("/api/opt-outs/{jid}", path):
+		return "whatsapp:settings", true
+	case method == http.MethodGet && routePathMatches("/api/campaigns/{id}", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && routePathMatches("/api/campaigns/{id}/report", path):
+		return "whatsapp:read:messages", true
+	case method == http.MethodGet && path == "/api/digests/latest":
+		return "whatsapp:read:messages", true
+	case method == http.MethodPost && routePathMatches("/api/chats/{jid}/media/download", path):
+		return "whatsapp:download", true
+	case method == http.MethodGet && path == "/api/jobs":
+		return "whatsapp:read", true
+	case method == http.MethodGet && routePathMatches("/api/jobs/{id}", path):
+		return "whatsapp:read", true
+	case method == http.MethodDelete && routePathMatches("/api/jobs/{id}", path):
+		return "whatsapp:jobs", true
+	case (method == http.MethodGet || method == http.MethodPost) && strings.HasPrefix(path, "/debug/pprof/"):
+		return profilingScope, true
 	default:
 		return "", false
 	}
 }
 
+// accountAllowed reports whether a token's account_id claim lets it act on
+// this bridge's account. Without a claim the token is accepted unless account
+// IDs are required.
+func accountAllowed(claimAccountID string, authConfig bridgeAuthConfig) bool {
+	accountID := strings.TrimSpace(claimAccountID)
+	if accountID == "" {
+		return !authConfig.requireAccountID
+	}
+	return strings.EqualFold(accountID, authConfig.accountID)
+}
+
+// parentScopes maps the granular read scopes to the broader scope that
+// also grants them, so tokens minted before the split keep working.
+var parentScopes = map[string]string{
+	"whatsapp:read:messages": "whatsapp:read",
+	"whatsapp:read:contacts": "whatsapp:read",
+	"whatsapp:media":         "whatsapp:read",
+}
+
 func hasRequiredScope(claimScope string, requiredScope string) bool {
 	if requiredScope == "" {
 		return false
 	}
 
+	parentScope := parentScopes[requiredScope]
 	for _, scope := range strings.FieldsFunc(claimScope, func(r rune) bool { return r == ',' || r == ' ' }) {
-		if scope == requiredScope || scope == "whatsapp:*" {
+		if scope == requiredScope || scope == "whatsapp:*" || (parentScope != "" && scope == parentScope) {
 			return true
 		}
 	}
@@ -316,6 +789,7 @@ func hasRequiredScope(claimScope string, requiredScope string) bool {
 
 func withRequiredBridgeJWTAuth(authConfig bridgeAuthConfig, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		noteAccessLog(r, "")
 		authHeader := strings.TrimSpace(r.Header.Get("Authorization"))
 		if len(authHeader) <= len("Bearer ") || !strings.HasPrefix(authHeader, "Bearer ") {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -337,12 +811,13 @@ func withRequiredBridgeJWTAuth(authConfig bridgeAuthConfig, next http.HandlerFun
 				if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
 					return nil, fmt.Errorf("unexpected signing algorithm: %s", token.Method.Alg())
 				}
-				return authConfig.jwtSecret, nil
+				return authConfig.jwtSecret()
 			},
 			jwt.WithAudience(authConfig.audience),
 			jwt.WithIssuer(authConfig.issuer),
 		)
 		if err != nil || !parsedToken.Valid {
+			fmt.Printf("Rejected bridge token: client=%s method=%s route=%s request_id=%s\n", requestClientIP(r), r.Method, r.Pattern, whatsapp.RequestID(r.Context()))
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
@@ -351,6 +826,7 @@ func withRequiredBridgeJWTAuth(authConfig bridgeAuthConfig, next http.HandlerFun
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
+		noteAccessLog(r, claims.Subject)
 		if !hasAllowedSubjectPrefix(claims.Subject, authConfig.allowedSubjectPrefixes) {
 			http.Error(w, "Forbidden", http.StatusForbidden)
 			return
@@ -359,6 +835,10 @@ func withRequiredBridgeJWTAuth(authConfig bridgeAuthConfig, next http.HandlerFun
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
+		if !accountAllowed(claims.AccountID, authConfig) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
 		if !hasRequiredScope(claims.Scope, requiredScope) {
 			http.Error(w, "Forbidden", http.StatusForbidden)
 			return
@@ -426,6 +906,7 @@ func waitForPostConnectStatus(timeout time.Duration) bootstrap.AuthStatus {
 // healthHandler returns basic liveness/readiness metadata for orchestration probes.
 func healthHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		noteAccessLog(r, "")
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -463,21 +944,29 @@ func authStatusHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 			(status.State == "connected" || status.State == "disconnected") {
 			status.State = "connected"
 			status.Connected = true
+			status.DisconnectReason = ""
+			status.ReauthRequired = false
 			if status.Message == "" {
 				status.Message = "WhatsApp connected"
 			}
 		}
 
 		writeJSON(w, http.StatusOK, AuthStatusResponse{
-			State:          status.State,
-			Connected:      status.Connected,
-			Message:        status.Message,
-			QRCode:         status.QRCode,
-			QRImageDataURL: status.QRImageDataURL,
-			SyncProgress:   status.SyncProgress,
-			SyncCurrent:    status.SyncCurrent,
-			SyncTotal:      status.SyncTotal,
-			UpdatedAt:      status.UpdatedAt.Format(time.RFC3339),
+			State:              status.State,
+			Connected:          status.Connected,
+			Message:            status.Message,
+			QRCode:             status.QRCode,
+			QRImageDataURL:     status.QRImageDataURL,
+			SyncProgress:       status.SyncProgress,
+			SyncCurrent:        status.SyncCurrent,
+			SyncTotal:          status.SyncTotal,
+			SyncChatRef:        status.SyncChatRef,
+			SyncMessagesStored: status.SyncMessagesStored,
+			SyncETASeconds:     status.SyncETASeconds,
+			DisconnectReason:   string(status.DisconnectReason),
+			ReauthRequired:     status.ReauthRequired,
+			LastTransitionAt:   status.LastTransitionAt.Format(time.RFC3339),
+			UpdatedAt:          status.UpdatedAt.Format(time.RFC3339),
 		})
 	}
 }
@@ -490,7 +979,8 @@ func disconnectHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 			return
 		}
 
-		client := runtime.detachClient()
+		client, release := runtime.stopClient()
+		defer release()
 		if client == nil {
 			writeJSON(w, http.StatusOK, DisconnectResponse{
 				Success: true,
@@ -502,7 +992,7 @@ func disconnectHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 		if client.IsConnected() {
 			client.Disconnect()
 		}
-		bootstrap.SetDisconnected("WhatsApp disconnected")
+		bootstrap.SetDisconnectedReason(bootstrap.DisconnectManual, "WhatsApp disconnected")
 
 		writeJSON(w, http.StatusOK, DisconnectResponse{
 			Success: true,
@@ -588,7 +1078,10 @@ func revokeDisconnectHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 			return
 		}
 
-		client := runtime.detachClient()
+		// The lifecycle stays stopping until the revoke is done, so no other
+		// request connects a client for the device being removed.
+		client, release := runtime.stopClient()
+		defer release()
 		if client == nil {
 			var err error
 			client, err = runtime.newClient()
@@ -727,7 +1220,9 @@ func connectHandler(runtime *whatsAppRuntime) http.HandlerFunc {
 }
 
 // StartRESTServer starts the bridge HTTP API for send and download routes.
-// It binds to 127.0.0.1 by default and can be overridden with WHATSAPP_BRIDGE_HOST.
+// It binds to 127.0.0.1 by default and can be overridden with WHATSAPP_BRIDGE_HOST;
+// WHATSAPP_BRIDGE_TLS_CERT_FILE and WHATSAPP_BRIDGE_TLS_KEY_FILE enable TLS, and
+// serverTuningFromEnv sets up HTTP/2 and connection reuse.
 func StartRESTServer(logger waLog.Logger, messageStore *storage.MessageStore, port int) error {
 	authConfig, err := loadBridgeAuthConfig()
 	if err != nil {
@@ -735,33 +1230,124 @@ func StartRESTServer(logger waLog.Logger, messageStore *storage.MessageStore, po
 	}
 	runtime := newWhatsAppRuntime(logger, messageStore)
 	autoConnectOnStartup(runtime)
+	startDigestScheduler(runtime, digest.ConfigFromEnv())
+	startReminderWorker(runtime)
+	startOutboxWorker(runtime)
+	startSnoozeWorker(runtime)
+	startConnectionMonitor(runtime, whatsapp.ConnectionMonitorConfigFromEnv())
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", healthHandler(runtime))
 	mux.HandleFunc("/api/send", withRequiredBridgeJWTAuth(authConfig, sendHandler(runtime)))
+	mux.HandleFunc("/api/send/broadcast", withRequiredBridgeJWTAuth(authConfig, broadcastSendHandler(runtime)))
+	mux.HandleFunc("/api/send/queue", withRequiredBridgeJWTAuth(authConfig, queuedSendsHandler(runtime)))
+	mux.HandleFunc("/api/send/queue/{id}", withRequiredBridgeJWTAuth(authConfig, queuedSendHandler(runtime)))
 	mux.HandleFunc("/api/download", withRequiredBridgeJWTAuth(authConfig, downloadHandler(runtime)))
 	mux.HandleFunc("/api/connect", withRequiredBridgeJWTAuth(authConfig, connectHandler(runtime)))
 	mux.HandleFunc("/api/auth/status", withRequiredBridgeJWTAuth(authConfig, authStatusHandler(runtime)))
+	mux.HandleFunc("/api/admin/health", withRequiredBridgeJWTAuth(authConfig, adminHealthHandler(runtime)))
+	mux.HandleFunc("/metrics", withRequiredBridgeJWTAuth(authConfig, metricsHandler(runtime)))
 	mux.HandleFunc("/api/disconnect", withRequiredBridgeJWTAuth(authConfig, disconnectHandler(runtime)))
 	mux.HandleFunc("/api/disconnect/revoke", withRequiredBridgeJWTAuth(authConfig, revokeDisconnectHandler(runtime)))
+	mux.HandleFunc("/api/history-sync", withRequiredBridgeJWTAuth(authConfig, historySyncHandler(runtime)))
+	mux.HandleFunc("/api/history-sync/cancel", withRequiredBridgeJWTAuth(authConfig, historySyncCancelHandler(runtime)))
+	mux.HandleFunc("/api/history-sync/resume", withRequiredBridgeJWTAuth(authConfig, historySyncResumeHandler(runtime)))
+	mux.HandleFunc("/api/sync/rebuild", withRequiredBridgeJWTAuth(authConfig, syncRebuildHandler(runtime)))
+	mux.HandleFunc("/api/consistency", withRequiredBridgeJWTAuth(authConfig, consistencyHandler(runtime, false)))
+	mux.HandleFunc("/api/consistency/repair", withRequiredBridgeJWTAuth(authConfig, consistencyHandler(runtime, true)))
+	mux.HandleFunc("/api/chats/{jid}/media", withRequiredBridgeJWTAuth(authConfig, chatMediaHandler(runtime)))
+	mux.HandleFunc("/api/links", withRequiredBridgeJWTAuth(authConfig, linksHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/context", withRequiredBridgeJWTAuth(authConfig, chatContextHandler(runtime)))
+	mux.HandleFunc("/api/search/semantic", withRequiredBridgeJWTAuth(authConfig, semanticSearchHandler(runtime)))
+	mux.HandleFunc("/api/views", withRequiredBridgeJWTAuth(authConfig, viewsHandler(runtime)))
+	mux.HandleFunc("/api/views/{name}", withRequiredBridgeJWTAuth(authConfig, viewHandler(runtime)))
+	mux.HandleFunc("/api/views/{name}/messages", withRequiredBridgeJWTAuth(authConfig, viewMessagesHandler(runtime)))
+	mux.HandleFunc("/api/reminders", withRequiredBridgeJWTAuth(authConfig, remindersHandler(runtime)))
+	mux.HandleFunc("/api/reminders/{id}", withRequiredBridgeJWTAuth(authConfig, reminderHandler(runtime)))
+	mux.HandleFunc("/api/state", withRequiredBridgeJWTAuth(authConfig, stateHandler(runtime)))
+	mux.HandleFunc("/api/quarantine", withRequiredBridgeJWTAuth(authConfig, quarantineHandler(runtime)))
+	mux.HandleFunc("/api/inbox", withRequiredBridgeJWTAuth(authConfig, inboxHandler(runtime)))
+	mux.HandleFunc("/api/audiences", withRequiredBridgeJWTAuth(authConfig, audiencesHandler(runtime)))
+	mux.HandleFunc("/api/audiences/{id}", withRequiredBridgeJWTAuth(authConfig, audienceHandler(runtime)))
+	mux.HandleFunc("/api/campaigns", withRequiredBridgeJWTAuth(authConfig, campaignsHandler(runtime)))
+	mux.HandleFunc("/api/opt-outs", withRequiredBridgeJWTAuth(authConfig, optOutsHandler(runtime)))
+	mux.HandleFunc("/api/opt-outs/{jid}", withRequiredBridgeJWTAuth(authConfig, optOutHandler(runtime)))
+	mux.HandleFunc("/api/campaigns/{id}", withRequiredBridgeJWTAuth(authConfig, campaignHandler(runtime)))
+	mux.HandleFunc("/api/campaigns/{id}/report", withRequiredBridgeJWTAuth(authConfig, campaignReportHandler(runtime)))
+	mux.HandleFunc("/api/digests/latest", withRequiredBridgeJWTAuth(authConfig, latestDigestHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/media/download", withRequiredBridgeJWTAuth(authConfig, chatMediaDownloadHandler(runtime)))
+	mux.HandleFunc("/api/jobs", withRequiredBridgeJWTAuth(authConfig, jobsHandler(runtime)))
+	mux.HandleFunc("/api/jobs/{id}", withRequiredBridgeJWTAuth(authConfig, jobHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/recent-threads", withRequiredBridgeJWTAuth(authConfig, recentThreadsHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/days", withRequiredBridgeJWTAuth(authConfig, chatDaysHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/day/{date}", withRequiredBridgeJWTAuth(authConfig, chatDayHandler(runtime)))
+	mux.HandleFunc("/api/chat-settings", withRequiredBridgeJWTAuth(authConfig, chatSettingsListHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/settings", withRequiredBridgeJWTAuth(authConfig, chatSettingsHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/handoff", withRequiredBridgeJWTAuth(authConfig, chatHandoffHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/snooze", withRequiredBridgeJWTAuth(authConfig, chatSnoozeHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/tracking", withRequiredBridgeJWTAuth(authConfig, chatTrackingHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/name", withRequiredBridgeJWTAuth(authConfig, chatNameHandler(runtime)))
+	mux.HandleFunc("/api/aliases", withRequiredBridgeJWTAuth(authConfig, aliasesHandler(runtime)))
+	mux.HandleFunc("/api/aliases/split", withRequiredBridgeJWTAuth(authConfig, splitAliasHandler(runtime)))
+	mux.HandleFunc("/api/groups/{jid}/invite", withRequiredBridgeJWTAuth(authConfig, groupInviteHandler(runtime)))
+	mux.HandleFunc("/api/groups/{jid}/invites", withRequiredBridgeJWTAuth(authConfig, groupInvitesHandler(runtime)))
+	mux.HandleFunc("/api/groups/metadata/refresh", withRequiredBridgeJWTAuth(authConfig, groupMetadataRefreshHandler(runtime)))
+	mux.HandleFunc("/api/groups/{jid}/metadata", withRequiredBridgeJWTAuth(authConfig, groupMetadataHandler(runtime)))
+	mux.HandleFunc("/api/communities", withRequiredBridgeJWTAuth(authConfig, communitiesHandler(runtime)))
+	mux.HandleFunc("/api/chats/{jid}/played", withRequiredBridgeJWTAuth(authConfig, playedReceiptHandler(runtime)))
+	mux.HandleFunc("/api/messages/{id}/raw", withRequiredBridgeJWTAuth(authConfig, rawMessageHandler(runtime)))
+	mux.HandleFunc("/api/messages/{id}/context", withRequiredBridgeJWTAuth(authConfig, messageContextHandler(runtime)))
+	mux.HandleFunc("/api/messages/since", withRequiredBridgeJWTAuth(authConfig, messagesSinceHandler(runtime)))
+	mux.HandleFunc("/api/messages/{id}/media", withRequiredBridgeJWTAuth(authConfig, messageMediaHandler(runtime)))
+	mux.HandleFunc("/api/messages/status", withRequiredBridgeJWTAuth(authConfig, messageStatusHandler(runtime)))
+	mux.HandleFunc("/api/contacts/sync", withRequiredBridgeJWTAuth(authConfig, contactSyncHandler(runtime)))
+	mux.HandleFunc("/api/contacts/{jid}/summary", withRequiredBridgeJWTAuth(authConfig, contactSummaryHandler(runtime)))
+	mux.HandleFunc("/api/contacts/{jid}/tags", withRequiredBridgeJWTAuth(authConfig, contactTagsHandler(runtime)))
+	mux.HandleFunc("/api/exports", withRequiredBridgeJWTAuth(authConfig, chatExportHandler(runtime)))
+	mux.HandleFunc("/api/imports", withRequiredBridgeJWTAuth(authConfig, chatImportHandler(runtime)))
+	mux.HandleFunc("/api/events/replay", withRequiredBridgeJWTAuth(authConfig, eventReplayHandler(runtime)))
+	mux.HandleFunc("/api/events/stream", withRequiredBridgeJWTAuth(authConfig, eventStreamHandler(runtime)))
+	mux.HandleFunc("/api/events/stream/stats", withRequiredBridgeJWTAuth(authConfig, eventStreamStatsHandler(runtime)))
+	mux.HandleFunc("/api/broadcast-lists", withRequiredBridgeJWTAuth(authConfig, broadcastListsHandler(runtime)))
+	mux.HandleFunc("/api/broadcast-lists/{jid}/recipients", withRequiredBridgeJWTAuth(authConfig, broadcastRecipientsHandler(runtime)))
+	registerProfilingRoutes(mux, authConfig)
 
 	host := os.Getenv("WHATSAPP_BRIDGE_HOST")
 	if host == "" {
 		host = "127.0.0.1"
 	}
+	certFile := strings.TrimSpace(os.Getenv("WHATSAPP_BRIDGE_TLS_CERT_FILE"))
+	keyFile := strings.TrimSpace(os.Getenv("WHATSAPP_BRIDGE_TLS_KEY_FILE"))
+	if (certFile == "") != (keyFile == "") {
+		return errors.New("WHATSAPP_BRIDGE_TLS_CERT_FILE and WHATSAPP_BRIDGE_TLS_KEY_FILE must be set together")
+	}
+	useTLS := certFile != ""
+	warnExposedBind(host, useTLS, authConfig)
+
 	serverAddr := net.JoinHostPort(host, strconv.Itoa(port))
 	server := &http.Server{
 		Addr:              serverAddr,
-		Handler:           mux,
+		Handler:           withClientIP(trustedProxiesFromEnv(), withAccessLog(withRouteTimeouts(routeTimeoutsFromEnv(), withBodyLimits(bodyLimitsFromEnv(), mux)))),
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
-		WriteTimeout:      60 * time.Second,
-		IdleTimeout:       120 * time.Second,
+		WriteTimeout:      defaultRouteTimeout,
+	}
+	tuning := serverTuningFromEnv()
+	tuning.apply(server)
+	listener, err := tuning.listen(serverAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serverAddr, err)
 	}
 
-	fmt.Printf("Starting REST API server on %s...\n", serverAddr)
+	fmt.Printf("Starting REST API server on %s (tls=%t, h2c=%t)...\n", serverAddr, useTLS, tuning.h2c && !useTLS)
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if useTLS {
+			err = server.ServeTLS(listener, certFile, keyFile)
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			fmt.Printf("REST API server error: %v\n", err)
 		}
 	}()
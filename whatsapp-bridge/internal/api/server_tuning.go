@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/netutil"
+)
+
+const (
+	defaultIdleTimeout  = 120 * time.Second
+	defaultTCPKeepAlive = 30 * time.Second
+)
+
+// serverTuning holds connection settings for clients that poll the API
+// often, where reusing connections matters more than the per-request cost.
+type serverTuning struct {
+	// h2c serves HTTP/2 without TLS to clients that ask for it with prior
+	// knowledge. HTTP/2 over TLS is always offered.
+	h2c          bool
+	keepAlives   bool
+	idleTimeout  time.Duration
+	tcpKeepAlive time.Duration
+	// maxConnections caps concurrently open connections; zero is unlimited.
+	maxConnections int
+	// maxStreams caps concurrent HTTP/2 streams per connection; zero keeps
+	// the Go default.
+	maxStreams int
+}
+
+// serverTuningFromEnv reads WHATSAPP_BRIDGE_H2C, WHATSAPP_BRIDGE_KEEPALIVES,
+// WHATSAPP_BRIDGE_IDLE_TIMEOUT_SECONDS, WHATSAPP_BRIDGE_TCP_KEEPALIVE_SECONDS,
+// WHATSAPP_BRIDGE_MAX_CONNECTIONS and WHATSAPP_BRIDGE_HTTP2_MAX_STREAMS.
+// Invalid values are ignored with a warning.
+func serverTuningFromEnv() serverTuning {
+	return serverTuning{
+		h2c:            envBool("WHATSAPP_BRIDGE_H2C", false),
+		keepAlives:     envBool("WHATSAPP_BRIDGE_KEEPALIVES", true),
+		idleTimeout:    time.Duration(envNonNegativeInt("WHATSAPP_BRIDGE_IDLE_TIMEOUT_SECONDS", int(defaultIdleTimeout/time.Second))) * time.Second,
+		tcpKeepAlive:   time.Duration(envNonNegativeInt("WHATSAPP_BRIDGE_TCP_KEEPALIVE_SECONDS", int(defaultTCPKeepAlive/time.Second))) * time.Second,
+		maxConnections: envNonNegativeInt("WHATSAPP_BRIDGE_MAX_CONNECTIONS", 0),
+		maxStreams:     envNonNegativeInt("WHATSAPP_BRIDGE_HTTP2_MAX_STREAMS", 0),
+	}
+}
+
+func envBool(name string, fallback bool) bool {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(raw)
+	if err != nil {
+		fmt.Printf("Warning: invalid %s=%q, using %t\n", name, raw, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func envNonNegativeInt(name string, fallback int) int {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed < 0 {
+		fmt.Printf("Warning: invalid %s=%q, using %d\n", name, raw, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// apply configures the protocols and keep-alive behaviour of server.
+func (t serverTuning) apply(server *http.Server) {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(t.h2c)
+	server.Protocols = protocols
+	server.HTTP2 = &http.HTTP2Config{MaxConcurrentStreams: t.maxStreams}
+	server.IdleTimeout = t.idleTimeout
+	server.SetKeepAlivesEnabled(t.keepAlives)
+}
+
+// listen opens the API listener with TCP keep-alive probes and the
+// connection cap applied.
+func (t serverTuning) listen(addr string) (net.Listener, error) {
+	keepAlive := t.tcpKeepAlive
+	if keepAlive == 0 {
+		keepAlive = -1
+	}
+	config := net.ListenConfig{KeepAlive: keepAlive}
+	listener, err := config.Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if t.maxConnections > 0 {
+		listener = netutil.LimitListener(listener, t.maxConnections)
+	}
+	return listener, nil
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+var stateKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]{0,127}$`)
+
+// StateRequest sets one state key of a chat or a contact. ExpectedVersion
+// makes the write conditional: 0 creates the key only if it is unset, any
+// other value must match the stored version.
+type StateRequest struct {
+	ChatJID         string          `json:"chat_jid,omitempty"`
+	ContactID       string          `json:"contact_id,omitempty"`
+	Key             string          `json:"key"`
+	Value           json.RawMessage `json:"value"`
+	ExpectedVersion *int64          `json:"expected_version,omitempty"`
+}
+
+type StateEntryResponse struct {
+	Scope     string          `json:"scope"`
+	Subject   string          `json:"subject"`
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value"`
+	Version   int64           `json:"version"`
+	UpdatedAt string          `json:"updated_at"`
+}
+
+type StateListResponse struct {
+	Scope   string               `json:"scope"`
+	Subject string               `json:"subject"`
+	Entries []StateEntryResponse `json:"entries"`
+}
+
+// StateConflictResponse is returned with 409 when a conditional write loses;
+// Current is the stored entry to retry against, absent if the key is unset.
+type StateConflictResponse struct {
+	Error   string              `json:"error"`
+	Current *StateEntryResponse `json:"current,omitempty"`
+}
+
+func newStateEntryResponse(entry storage.StateEntry) StateEntryResponse {
+	return StateEntryResponse{
+		Scope:     entry.Scope,
+		Subject:   entry.Subject,
+		Key:       entry.Key,
+		Value:     json.RawMessage(entry.Value),
+		Version:   entry.Version,
+		UpdatedAt: entry.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// stateSubject resolves which chat or contact a state request addresses;
+// exactly one of chatJID and contactID must be set.
+func stateSubject(chatJID, contactID string) (string, string, string) {
+	chatJID = strings.TrimSpace(chatJID)
+	contactID = strings.TrimSpace(contactID)
+	switch {
+	case chatJID != "" && contactID != "":
+		return "", "", "Set only one of chat_jid and contact_id"
+	case chatJID != "":
+		if normalized := jid.NormalizeChat(chatJID); normalized != "" {
+			return storage.StateScopeChat, normalized, ""
+		}
+		return "", "", "Invalid chat_jid"
+	case contactID != "":
+		if normalized := jid.NormalizeUser(contactID); normalized != "" {
+			return storage.StateScopeContact, normalized, ""
+		}
+		return "", "", "Invalid contact_id"
+	default:
+		return "", "", "chat_jid or contact_id is required"
+	}
+}
+
+func writeStateConflict(w http.ResponseWriter, current *storage.StateEntry) {
+	response := StateConflictResponse{Error: "State version conflict"}
+	if current != nil {
+		entry := newStateEntryResponse(*current)
+		response.Current = &entry
+	}
+	writeJSON(w, http.StatusConflict, response)
+}
+
+// stateHandler is a small versioned key-value store for bot dialogue state,
+// scoped to a chat or a contact: GET lists a subject's keys or returns one,
+// PUT sets a key, optionally compare-and-swap on its version, and DELETE
+// removes one.
+func stateHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req StateRequest
+		if r.Method == http.MethodPut {
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+		} else {
+			query := r.URL.Query()
+			req.ChatJID = query.Get("chat_jid")
+			req.ContactID = query.Get("contact_id")
+			req.Key = query.Get("key")
+			if raw := strings.TrimSpace(query.Get("expected_version")); raw != "" {
+				version, err := strconv.ParseInt(raw, 10, 64)
+				if err != nil || version < 1 {
+					http.Error(w, "Invalid expected_version", http.StatusBadRequest)
+					return
+				}
+				req.ExpectedVersion = &version
+			}
+		}
+
+		scope, subject, problem := stateSubject(req.ChatJID, req.ContactID)
+		if problem != "" {
+			http.Error(w, problem, http.StatusBadRequest)
+			return
+		}
+		key := strings.TrimSpace(req.Key)
+		if key != "" && !stateKeyPattern.MatchString(key) {
+			http.Error(w, "Invalid key", http.StatusBadRequest)
+			return
+		}
+		if key == "" && r.Method != http.MethodGet {
+			http.Error(w, "key is required", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if key != "" {
+				entry, err := messageStore.GetState(scope, subject, key)
+				if err != nil {
+					http.Error(w, "Failed to load state", http.StatusInternalServerError)
+					return
+				}
+				if entry == nil {
+					http.Error(w, "State key not found", http.StatusNotFound)
+					return
+				}
+				writeJSON(w, http.StatusOK, newStateEntryResponse(*entry))
+				return
+			}
+			entries, err := messageStore.ListState(scope, subject)
+			if err != nil {
+				http.Error(w, "Failed to load state", http.StatusInternalServerError)
+				return
+			}
+			response := StateListResponse{Scope: scope, Subject: subject, Entries: make([]StateEntryResponse, 0, len(entries))}
+			for _, entry := range entries {
+				response.Entries = append(response.Entries, newStateEntryResponse(entry))
+			}
+			writeJSON(w, http.StatusOK, response)
+
+		case http.MethodPut:
+			if len(req.Value) == 0 || string(req.Value) == "null" {
+				http.Error(w, "value is required", http.StatusBadRequest)
+				return
+			}
+			if req.ExpectedVersion != nil && *req.ExpectedVersion < 0 {
+				http.Error(w, "Invalid expected_version", http.StatusBadRequest)
+				return
+			}
+			entry, err := messageStore.PutState(scope, subject, key, req.Value, req.ExpectedVersion)
+			if errors.Is(err, storage.ErrStateConflict) {
+				writeStateConflict(w, entry)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Failed to save state", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, newStateEntryResponse(*entry))
+
+		case http.MethodDelete:
+			deleted, err := messageStore.DeleteState(scope, subject, key, req.ExpectedVersion)
+			if errors.Is(err, storage.ErrStateConflict) {
+				current, loadErr := messageStore.GetState(scope, subject, key)
+				if loadErr != nil {
+					http.Error(w, "Failed to load state", http.StatusInternalServerError)
+					return
+				}
+				writeStateConflict(w, current)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Failed to delete state", http.StatusInternalServerError)
+				return
+			}
+			if !deleted {
+				http.Error(w, "State key not found", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
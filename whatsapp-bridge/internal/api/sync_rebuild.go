@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"whatsapp-client/internal/jobs"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/whatsapp"
+)
+
+const (
+	syncRebuildJobKind = "history_resync"
+	// syncRebuildRequestInterval paces the per-chat history requests, which
+	// the phone answers one by one.
+	syncRebuildRequestInterval = time.Second
+)
+
+type SyncRebuildRequest struct {
+	// MessagesPerChat is how many older messages to request for each chat,
+	// defaulting to whatsapp.ResyncMessagesPerChat.
+	MessagesPerChat int `json:"messages_per_chat,omitempty"`
+}
+
+// syncRebuildHandler serves POST /api/sync/rebuild: it clears the message
+// cache while keeping the linked device, then requests each chat's history
+// again from the phone in a background job. Each chat's newest message is
+// kept, since WhatsApp only sends history from before a message the device
+// has.
+func syncRebuildHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SyncRebuildRequest
+		if r.ContentLength != 0 {
+			if !decodeJSONBody(w, r, &req) {
+				return
+			}
+		}
+		if req.MessagesPerChat < 0 {
+			http.Error(w, "messages_per_chat must not be negative", http.StatusBadRequest)
+			return
+		}
+		if req.MessagesPerChat == 0 {
+			req.MessagesPerChat = whatsapp.ResyncMessagesPerChat
+		}
+
+		client := runtime.currentClient()
+		if client == nil || !client.IsConnected() || client.Store == nil || client.Store.ID == nil {
+			http.Error(w, "WhatsApp client is not connected", http.StatusServiceUnavailable)
+			return
+		}
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		anchors, err := messageStore.ClearForResync()
+		if err != nil {
+			http.Error(w, "Failed to clear message store", http.StatusInternalServerError)
+			return
+		}
+		whatsapp.ResumeHistorySync()
+
+		job, err := runtime.jobs.Start(syncRebuildJobKind, "", len(anchors), syncRebuildJob(runtime, anchors, req.MessagesPerChat))
+		if err != nil {
+			http.Error(w, "Failed to create job", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, newJobResponse(job))
+	}
+}
+
+// syncRebuildJob requests each chat's history before its anchor in turn.
+func syncRebuildJob(runtime *whatsAppRuntime, anchors []storage.HistoryAnchor, count int) jobs.Func {
+	return func(ctx context.Context, progress *jobs.Progress) error {
+		for i, anchor := range anchors {
+			if i > 0 {
+				if err := sleepContext(ctx, syncRebuildRequestInterval); err != nil {
+					return err
+				}
+			}
+			client := runtime.currentClient()
+			if client == nil || !client.IsConnected() {
+				return errors.New("WhatsApp client disconnected")
+			}
+			if err := whatsapp.RequestChatHistory(ctx, client, anchor, count); err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				code := ""
+				var sendErr *whatsapp.SendError
+				if errors.As(err, &sendErr) {
+					code = sendErr.Code
+				}
+				progress.Failed(anchor.ChatJID, err, code)
+				continue
+			}
+			progress.Succeeded()
+		}
+		return nil
+	}
+}
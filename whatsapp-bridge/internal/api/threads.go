@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+type ThreadMessageResponse struct {
+	MessageID       string `json:"message_id"`
+	Seq             int64  `json:"seq"`
+	Timestamp       string `json:"timestamp"`
+	SenderID        string `json:"sender_id"`
+	SenderName      string `json:"sender_name"`
+	IsFromMe        bool   `json:"is_from_me"`
+	Text            string `json:"text"`
+	QuotedMessageID string `json:"quoted_message_id,omitempty"`
+}
+
+type ReplyThreadResponse struct {
+	StartedAt      string                  `json:"started_at"`
+	LastActivityAt string                  `json:"last_activity_at"`
+	MessageCount   int                     `json:"message_count"`
+	Participants   []string                `json:"participants"`
+	AwaitingReply  bool                    `json:"awaiting_reply"`
+	Unanswered     []string                `json:"unanswered_message_ids"`
+	Messages       []ThreadMessageResponse `json:"messages"`
+}
+
+type RecentThreadsResponse struct {
+	ChatJID    string                `json:"chat_jid"`
+	ChatName   string                `json:"chat_name,omitempty"`
+	GapMinutes int                   `json:"gap_minutes"`
+	Threads    []ReplyThreadResponse `json:"threads"`
+}
+
+func newReplyThreadResponse(thread storage.ReplyThread, location *time.Location) ReplyThreadResponse {
+	response := ReplyThreadResponse{
+		StartedAt:      formatTimestamp(thread.Messages[0].Time, location),
+		LastActivityAt: formatTimestamp(thread.Messages[len(thread.Messages)-1].Time, location),
+		MessageCount:   len(thread.Messages),
+		Participants:   []string{},
+		AwaitingReply:  len(thread.Unanswered) > 0,
+		Unanswered:     thread.Unanswered,
+		Messages:       make([]ThreadMessageResponse, 0, len(thread.Messages)),
+	}
+	if response.Unanswered == nil {
+		response.Unanswered = []string{}
+	}
+
+	seen := map[string]struct{}{}
+	for _, msg := range thread.Messages {
+		senderName := contextSenderName(msg)
+		if _, ok := seen[senderName]; !ok {
+			seen[senderName] = struct{}{}
+			response.Participants = append(response.Participants, senderName)
+		}
+		response.Messages = append(response.Messages, ThreadMessageResponse{
+			MessageID:       msg.ID,
+			Seq:             msg.Seq,
+			Timestamp:       formatTimestamp(msg.Time, location),
+			SenderID:        msg.Sender,
+			SenderName:      senderName,
+			IsFromMe:        msg.IsFromMe,
+			Text:            contextMessageText(msg),
+			QuotedMessageID: msg.QuotedMessageID,
+		})
+	}
+	return response
+}
+
+// recentThreadsHandler groups a chat's latest messages into reply threads,
+// newest first, flagging inbound messages that have not been answered.
+func recentThreadsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		chatJID := strings.TrimSpace(r.PathValue("jid"))
+		if chatJID == "" {
+			http.Error(w, "Chat JID is required", http.StatusBadRequest)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 100, 1000)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		gap := storage.DefaultThreadGap
+		if raw := strings.TrimSpace(r.URL.Query().Get("gap_minutes")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "Invalid gap_minutes", http.StatusBadRequest)
+				return
+			}
+			gap = time.Duration(parsed) * time.Minute
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		newestFirst, err := messageStore.GetMessagesWithSenderNames(chatJID, limit)
+		if err != nil {
+			http.Error(w, "Failed to load chat messages", http.StatusInternalServerError)
+			return
+		}
+		chronological := make([]storage.Message, len(newestFirst))
+		for i, msg := range newestFirst {
+			chronological[len(newestFirst)-1-i] = msg
+		}
+		chatName, _ := messageStore.GetChatName(chatJID)
+
+		threads := storage.GroupReplyThreads(chronological, gap)
+		response := RecentThreadsResponse{
+			ChatJID:    chatJID,
+			ChatName:   chatName,
+			GapMinutes: int(gap / time.Minute),
+			Threads:    make([]ReplyThreadResponse, 0, len(threads)),
+		}
+		for i := len(threads) - 1; i >= 0; i-- {
+			response.Threads = append(response.Threads, newReplyThreadResponse(threads[i], location))
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
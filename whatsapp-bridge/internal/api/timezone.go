@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// parseTimezoneParam reads the optional tz query parameter, an IANA zone name
+// such as "Europe/Berlin", used to format response timestamps and to decide
+// where calendar days begin.
+func parseTimezoneParam(r *http.Request) (*time.Location, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get("tz"))
+	if raw == "" {
+		return time.UTC, true
+	}
+	location, err := time.LoadLocation(raw)
+	if err != nil {
+		return nil, false
+	}
+	return location, true
+}
+
+// formatTimestamp renders a stored UTC timestamp as RFC 3339 in location.
+func formatTimestamp(value time.Time, location *time.Location) string {
+	return value.In(location).Format(time.RFC3339)
+}
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+var viewNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.-]{0,63}$`)
+
+type ViewRequest struct {
+	Name       string   `json:"name"`
+	ChatJIDs   []string `json:"chat_jids,omitempty"`
+	Sender     string   `json:"sender,omitempty"`
+	Keywords   string   `json:"keywords,omitempty"`
+	After      string   `json:"after,omitempty"`
+	Before     string   `json:"before,omitempty"`
+	WithinDays int      `json:"within_days,omitempty"`
+	Forwarded  *bool    `json:"forwarded,omitempty"`
+}
+
+type ViewResponse struct {
+	Name       string   `json:"name"`
+	ChatJIDs   []string `json:"chat_jids,omitempty"`
+	Sender     string   `json:"sender,omitempty"`
+	Keywords   string   `json:"keywords,omitempty"`
+	After      string   `json:"after,omitempty"`
+	Before     string   `json:"before,omitempty"`
+	WithinDays int      `json:"within_days,omitempty"`
+	Forwarded  *bool    `json:"forwarded,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+type ViewsResponse struct {
+	Views []ViewResponse `json:"views"`
+}
+
+type ViewMessageResponse struct {
+	MessageID  string `json:"message_id"`
+	Seq        int64  `json:"seq"`
+	ChatJID    string `json:"chat_jid"`
+	SenderID   string `json:"sender_id"`
+	SenderName string `json:"sender_name,omitempty"`
+	Timestamp  string `json:"timestamp"`
+	IsFromMe   bool   `json:"is_from_me"`
+	IsSelfChat bool   `json:"is_self_chat"`
+	Content    string `json:"content"`
+	MediaType  string `json:"media_type,omitempty"`
+	Filename   string `json:"filename,omitempty"`
+	// MediaLabel describes the media for display, e.g. "voice note, 0:42".
+	MediaLabel      string `json:"media_label,omitempty"`
+	DurationSeconds uint32 `json:"duration_seconds,omitempty"`
+	VoiceNote       bool   `json:"voice_note,omitempty"`
+	IsForwarded     bool   `json:"is_forwarded"`
+	ForwardingScore uint32 `json:"forwarding_score,omitempty"`
+}
+
+type ViewMessagesResponse struct {
+	Name     string                `json:"name"`
+	Messages []ViewMessageResponse `json:"messages"`
+}
+
+func formatOptionalTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339)
+}
+
+func parseOptionalTime(raw string) (*time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, true
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, false
+	}
+	return &parsed, true
+}
+
+func newViewResponse(view storage.SavedView) ViewResponse {
+	return ViewResponse{
+		Name:       view.Name,
+		ChatJIDs:   view.Definition.ChatJIDs,
+		Sender:     view.Definition.Sender,
+		Keywords:   view.Definition.Keywords,
+		After:      formatOptionalTime(view.Definition.After),
+		Before:     formatOptionalTime(view.Definition.Before),
+		WithinDays: view.Definition.WithinDays,
+		Forwarded:  view.Definition.Forwarded,
+		CreatedAt:  view.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:  view.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// viewDefinitionFromRequest validates a view request and converts it to a stored definition.
+func viewDefinitionFromRequest(req ViewRequest) (storage.ViewDefinition, string) {
+	if !viewNamePattern.MatchString(req.Name) {
+		return storage.ViewDefinition{}, "Invalid view name"
+	}
+	after, ok := parseOptionalTime(req.After)
+	if !ok {
+		return storage.ViewDefinition{}, "Invalid after timestamp"
+	}
+	before, ok := parseOptionalTime(req.Before)
+	if !ok {
+		return storage.ViewDefinition{}, "Invalid before timestamp"
+	}
+	if after != nil && before != nil && !after.Before(*before) {
+		return storage.ViewDefinition{}, "after must be earlier than before"
+	}
+	if req.WithinDays < 0 {
+		return storage.ViewDefinition{}, "Invalid within_days"
+	}
+
+	var chatJIDs []string
+	for _, chatJID := range req.ChatJIDs {
+		if trimmed := strings.TrimSpace(chatJID); trimmed != "" {
+			chatJIDs = append(chatJIDs, trimmed)
+		}
+	}
+
+	return storage.ViewDefinition{
+		ChatJIDs:   chatJIDs,
+		Sender:     strings.TrimSpace(req.Sender),
+		Keywords:   strings.TrimSpace(req.Keywords),
+		After:      after,
+		Before:     before,
+		WithinDays: req.WithinDays,
+		Forwarded:  req.Forwarded,
+	}, ""
+}
+
+// viewsHandler lists saved views (GET) or creates/replaces one (POST).
+func viewsHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			views, err := messageStore.ListViews()
+			if err != nil {
+				http.Error(w, "Failed to load views", http.StatusInternalServerError)
+				return
+			}
+			response := ViewsResponse{Views: make([]ViewResponse, 0, len(views))}
+			for _, view := range views {
+				response.Views = append(response.Views, newViewResponse(view))
+			}
+			writeJSON(w, http.StatusOK, response)
+			return
+		}
+
+		var req ViewRequest
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		definition, problem := viewDefinitionFromRequest(req)
+		if problem != "" {
+			http.Error(w, problem, http.StatusBadRequest)
+			return
+		}
+		if err := messageStore.SaveView(req.Name, definition); err != nil {
+			http.Error(w, "Failed to save view", http.StatusInternalServerError)
+			return
+		}
+		view, err := messageStore.GetView(req.Name)
+		if err != nil || view == nil {
+			http.Error(w, "Failed to load view", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newViewResponse(*view))
+	}
+}
+
+// viewHandler returns (GET) or deletes (DELETE) a single saved view.
+func viewHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		name := r.PathValue("name")
+		if r.Method == http.MethodDelete {
+			deleted, err := messageStore.DeleteView(name)
+			if err != nil {
+				http.Error(w, "Failed to delete view", http.StatusInternalServerError)
+				return
+			}
+			if !deleted {
+				http.Error(w, "View not found", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		view, err := messageStore.GetView(name)
+		if err != nil {
+			http.Error(w, "Failed to load view", http.StatusInternalServerError)
+			return
+		}
+		if view == nil {
+			http.Error(w, "View not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, newViewResponse(*view))
+	}
+}
+
+// viewMessagesHandler runs a saved view and returns its current matching messages.
+func viewMessagesHandler(runtime *whatsAppRuntime) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit, ok := parseLimitParam(r, 100, 1000)
+		if !ok {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		location, ok := parseTimezoneParam(r)
+		if !ok {
+			http.Error(w, "Invalid tz", http.StatusBadRequest)
+			return
+		}
+
+		messageStore := runtime.currentMessageStore()
+		if messageStore == nil {
+			http.Error(w, "Message store is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+
+		view, err := messageStore.GetView(r.PathValue("name"))
+		if err != nil {
+			http.Error(w, "Failed to load view", http.StatusInternalServerError)
+			return
+		}
+		if view == nil {
+			http.Error(w, "View not found", http.StatusNotFound)
+			return
+		}
+
+		messages, err := messageStore.FindMessages(view.Definition, time.Now(), limit)
+		if err != nil {
+			http.Error(w, "Failed to load view messages", http.StatusInternalServerError)
+			return
+		}
+
+		response := ViewMessagesResponse{Name: view.Name, Messages: make([]ViewMessageResponse, 0, len(messages))}
+		for _, msg := range messages {
+			message := ViewMessageResponse{
+				MessageID:       msg.ID,
+				Seq:             msg.Seq,
+				ChatJID:         msg.ChatJID,
+				SenderID:        msg.Sender,
+				SenderName:      msg.SenderName,
+				Timestamp:       formatTimestamp(msg.Time, location),
+				IsFromMe:        msg.IsFromMe,
+				IsSelfChat:      msg.IsSelfChat,
+				Content:         msg.Content,
+				MediaType:       msg.MediaType,
+				Filename:        msg.Filename,
+				DurationSeconds: msg.MediaSeconds,
+				VoiceNote:       msg.VoiceNote,
+				IsForwarded:     msg.IsForwarded,
+				ForwardingScore: msg.ForwardingScore,
+			}
+			if msg.MediaType != "" {
+				message.MediaLabel = mediaLabel(msg.MediaType, msg.MediaSeconds, msg.VoiceNote)
+			}
+			response.Messages = append(response.Messages, message)
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
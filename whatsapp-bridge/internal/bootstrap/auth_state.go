@@ -9,22 +9,53 @@ import (
 )
 
 type AuthStatus struct {
-	State          string    `json:"state"`
-	Connected      bool      `json:"connected"`
-	Message        string    `json:"message,omitempty"`
-	QRCode         string    `json:"qr_code,omitempty"`
-	QRImageDataURL string    `json:"qr_image_data_url,omitempty"`
-	SyncProgress   int       `json:"sync_progress,omitempty"`
-	SyncCurrent    int       `json:"sync_current,omitempty"`
-	SyncTotal      int       `json:"sync_total,omitempty"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	State          string `json:"state"`
+	Connected      bool   `json:"connected"`
+	Message        string `json:"message,omitempty"`
+	QRCode         string `json:"qr_code,omitempty"`
+	QRImageDataURL string `json:"qr_image_data_url,omitempty"`
+	SyncProgress   int    `json:"sync_progress,omitempty"`
+	SyncCurrent    int    `json:"sync_current,omitempty"`
+	SyncTotal      int    `json:"sync_total,omitempty"`
+	// SyncChatRef is an obfuscated reference to the conversation history
+	// sync is storing, SyncMessagesStored counts the messages stored so far
+	// and SyncETASeconds estimates the time left, from the pace so far.
+	SyncChatRef        string `json:"sync_chat_ref,omitempty"`
+	SyncMessagesStored int    `json:"sync_messages_stored,omitempty"`
+	SyncETASeconds     int    `json:"sync_eta_seconds,omitempty"`
+	// DisconnectReason says why the client last went offline, and
+	// ReauthRequired whether the device must be linked again by scanning a
+	// QR code rather than reconnected.
+	DisconnectReason DisconnectReason `json:"disconnect_reason,omitempty"`
+	ReauthRequired   bool             `json:"reauth_required,omitempty"`
+	// LastTransitionAt is when State last changed; UpdatedAt also moves
+	// with progress updates.
+	LastTransitionAt time.Time `json:"last_transition_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
+// DisconnectReason classifies why the WhatsApp client went offline.
+type DisconnectReason string
+
+const (
+	// DisconnectStreamReplaced: another client connected with the same
+	// device, and this one will not reconnect by itself.
+	DisconnectStreamReplaced DisconnectReason = "stream_replaced"
+	// DisconnectLoggedOut: WhatsApp rejected the device (401), or it was
+	// unlinked; it must be linked again.
+	DisconnectLoggedOut DisconnectReason = "logged_out"
+	// DisconnectNetworkError: the connection dropped and the client is
+	// reconnecting.
+	DisconnectNetworkError DisconnectReason = "network_error"
+	// DisconnectManual: the bridge was told to disconnect.
+	DisconnectManual DisconnectReason = "manual"
+)
+
 var authStatusState = struct {
 	mu     sync.RWMutex
 	status AuthStatus
 }{
-	status: AuthStatus{State: "disconnected", Connected: false, UpdatedAt: time.Now().UTC()},
+	status: AuthStatus{State: "disconnected", Connected: false, LastTransitionAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()},
 }
 
 func GetAuthStatus() AuthStatus {
@@ -34,8 +65,14 @@ func GetAuthStatus() AuthStatus {
 }
 
 func setAuthStatus(status AuthStatus) {
-	status.UpdatedAt = time.Now().UTC()
+	now := time.Now().UTC()
+	status.UpdatedAt = now
 	authStatusState.mu.Lock()
+	if previous := authStatusState.status; previous.State == status.State && !previous.LastTransitionAt.IsZero() {
+		status.LastTransitionAt = previous.LastTransitionAt
+	} else {
+		status.LastTransitionAt = now
+	}
 	authStatusState.status = status
 	authStatusState.mu.Unlock()
 }
@@ -93,11 +130,23 @@ func SetDisconnected(message string) {
 	})
 }
 
+// SetDisconnectedReason records that the client went offline for reason.
+func SetDisconnectedReason(reason DisconnectReason, message string) {
+	setAuthStatus(AuthStatus{
+		State:            "disconnected",
+		Connected:        false,
+		Message:          message,
+		DisconnectReason: reason,
+	})
+}
+
 func SetLoggedOut(message string) {
 	setAuthStatus(AuthStatus{
-		State:     "logged_out",
-		Connected: false,
-		Message:   message,
+		State:            "logged_out",
+		Connected:        false,
+		Message:          message,
+		DisconnectReason: DisconnectLoggedOut,
+		ReauthRequired:   true,
 	})
 }
 
@@ -134,6 +183,28 @@ func SetSyncingProgress(progress int, current int, total int) {
 	if status.State != "syncing" {
 		status.State = "syncing"
 		status.Connected = false
+		status.DisconnectReason = ""
+		status.ReauthRequired = false
+		if status.Message == "" {
+			status.Message = "Syncing WhatsApp messages"
+		}
+	}
+	status.SyncProgress = clampProgress(progress)
+	status.SyncCurrent = current
+	status.SyncTotal = total
+	setAuthStatus(status)
+}
+
+// SetSyncingConversation records history sync progress after a conversation:
+// the conversation's obfuscated reference, the messages stored so far and the
+// estimated time left.
+func SetSyncingConversation(progress int, current int, total int, chatRef string, messagesStored int, eta time.Duration) {
+	status := GetAuthStatus()
+	if status.State != "syncing" {
+		status.State = "syncing"
+		status.Connected = false
+		status.DisconnectReason = ""
+		status.ReauthRequired = false
 		if status.Message == "" {
 			status.Message = "Syncing WhatsApp messages"
 		}
@@ -141,5 +212,8 @@ func SetSyncingProgress(progress int, current int, total int) {
 	status.SyncProgress = clampProgress(progress)
 	status.SyncCurrent = current
 	status.SyncTotal = total
+	status.SyncChatRef = chatRef
+	status.SyncMessagesStored = messagesStored
+	status.SyncETASeconds = int(eta.Round(time.Second) / time.Second)
 	setAuthStatus(status)
 }
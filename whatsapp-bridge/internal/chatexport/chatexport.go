@@ -0,0 +1,251 @@
+package chatexport
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/mediacrypt"
+	"whatsapp-client/internal/storage"
+)
+
+// ChatFileName is the transcript file inside an official WhatsApp export.
+const ChatFileName = "_chat.txt"
+
+// MediaOmitted replaces media that is not included in the export.
+const MediaOmitted = "<Media omitted>"
+
+// attachedSuffix follows an attachment's file name on its transcript line.
+const attachedSuffix = " (file attached)"
+
+// lineTimeLayout is the day-first, 24-hour Android export timestamp.
+const lineTimeLayout = "02/01/2006, 15:04"
+
+// ownSenderName labels the account's own messages, whose profile name the
+// bridge does not store.
+const ownSenderName = "Me"
+
+// Entry is one message of an exported chat.
+type Entry struct {
+	Time   time.Time
+	Sender string
+	Text   string
+	// Attachment is the archive name of the message's media file.
+	Attachment string
+	// MediaOmitted marks media whose file is not part of the export.
+	MediaOmitted bool
+}
+
+// FormatEntry renders an entry the way WhatsApp writes it to _chat.txt.
+// Multi-line text continues on the following lines unprefixed, and a caption
+// follows its attachment on the next line.
+func FormatEntry(entry Entry, location *time.Location) string {
+	var body string
+	switch {
+	case entry.Attachment != "":
+		body = entry.Attachment + attachedSuffix
+		if entry.Text != "" {
+			body += "\n" + entry.Text
+		}
+	case entry.MediaOmitted:
+		body = MediaOmitted
+	default:
+		body = entry.Text
+	}
+	return fmt.Sprintf("%s - %s: %s", entry.Time.In(location).Format(lineTimeLayout), entry.Sender, body)
+}
+
+// WriteChat writes entries as an official _chat.txt transcript.
+func WriteChat(w io.Writer, entries []Entry, location *time.Location) error {
+	buffered := bufio.NewWriter(w)
+	for _, entry := range entries {
+		if _, err := buffered.WriteString(FormatEntry(entry, location) + "\n"); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+// attachmentPrefixes are the file name prefixes WhatsApp gives media by type.
+var attachmentPrefixes = map[string]string{
+	"image": "IMG",
+	"video": "VID",
+	"audio": "AUD",
+}
+
+// AttachmentNamer names exported media files after WhatsApp's convention,
+// such as IMG-20240501-WA0003.jpg, numbering files per type and day.
+// Documents keep their own names, made unique within the export.
+type AttachmentNamer struct {
+	counters map[string]int
+	used     map[string]bool
+}
+
+// NewAttachmentNamer returns a namer for one export archive.
+func NewAttachmentNamer() *AttachmentNamer {
+	return &AttachmentNamer{counters: map[string]int{}, used: map[string]bool{}}
+}
+
+// Name returns the archive name for a media file. filename is the stored
+// name, used for documents and for the extension of other media.
+func (namer *AttachmentNamer) Name(mediaType, filename string, sentAt time.Time) string {
+	filename = cleanFileName(filename)
+	ext := filepath.Ext(filename)
+
+	var name string
+	if prefix, ok := attachmentPrefixes[mediaType]; ok {
+		day := sentAt.Format("20060102")
+		key := prefix + "-" + day
+		namer.counters[key]++
+		name = fmt.Sprintf("%s-WA%04d%s", key, namer.counters[key], ext)
+	} else {
+		name = filename
+		if name == "" {
+			name = "DOC-" + sentAt.Format("20060102")
+		}
+	}
+
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; namer.used[name]; i++ {
+		name = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	namer.used[name] = true
+	return name
+}
+
+// cleanFileName reduces a name to a single safe path component.
+func cleanFileName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	var builder strings.Builder
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			continue
+		case strings.ContainsRune(`<>:"|?*`, r):
+			builder.WriteRune('_')
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return strings.Trim(builder.String(), " .")
+}
+
+// ArchiveName is the file name WhatsApp gives a chat export.
+func ArchiveName(chatName string) string {
+	name := cleanFileName(strings.NewReplacer("/", "_", "\\", "_").Replace(chatName))
+	if name == "" {
+		name = "Unknown"
+	}
+	return "WhatsApp Chat with " + name + ".zip"
+}
+
+// senderName picks the name shown for a message's author.
+func senderName(msg storage.ExportMessage) string {
+	switch {
+	case msg.IsFromMe:
+		return ownSenderName
+	case msg.SenderName != "":
+		return msg.SenderName
+	default:
+		return msg.Sender
+	}
+}
+
+// ExportChat writes one chat as an official-format export archive in dir and
+// returns its path. Downloaded media is included under WhatsApp-style names;
+// media that was never downloaded, or has since been removed, is written as
+// <Media omitted>. keys decrypts media encrypted at rest; it may be nil.
+func ExportChat(store *storage.MessageStore, chatJID, chatName, dir string, location *time.Location, keys *mediacrypt.Keyring) (string, error) {
+	messages, err := store.GetChatExportMessages(chatJID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load messages: %w", err)
+	}
+
+	path := filepath.Join(dir, ArchiveName(chatName))
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(dir, strings.TrimSuffix(ArchiveName(chatName), ".zip")+fmt.Sprintf(" (%d).zip", i))
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err
+	}
+	archive := zip.NewWriter(file)
+	if err := writeArchive(archive, messages, location, keys); err != nil {
+		archive.Close()
+		file.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := archive.Close(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+func writeArchive(archive *zip.Writer, messages []storage.ExportMessage, location *time.Location, keys *mediacrypt.Keyring) error {
+	namer := NewAttachmentNamer()
+	entries := make([]Entry, 0, len(messages))
+	for _, msg := range messages {
+		entry := Entry{Time: msg.Time, Sender: senderName(msg), Text: msg.Content}
+		if msg.MediaType != "" {
+			entry.MediaOmitted = true
+			if msg.LocalPath != "" {
+				name := namer.Name(msg.MediaType, msg.Filename, msg.Time.In(location))
+				included, err := addFile(archive, name, msg.LocalPath, keys)
+				if err != nil {
+					return err
+				}
+				if included {
+					entry.Attachment, entry.MediaOmitted = name, false
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	transcript, err := archive.Create(ChatFileName)
+	if err != nil {
+		return err
+	}
+	return WriteChat(transcript, entries, location)
+}
+
+// addFile copies a media file into the archive, reporting false when the
+// file no longer exists.
+func addFile(archive *zip.Writer, name, localPath string, keys *mediacrypt.Keyring) (bool, error) {
+	source, _, err := mediacrypt.Open(localPath, keys)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer source.Close()
+
+	target, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		return false, fmt.Errorf("failed to copy %s: %w", name, err)
+	}
+	return true, nil
+}
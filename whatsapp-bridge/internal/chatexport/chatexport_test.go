@@ -0,0 +1,58 @@
+package chatexport
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteChatUsesOfficialLineFormat(t *testing.T) {
+	at := time.Date(2024, 5, 1, 21, 5, 0, 0, time.UTC)
+	var out strings.Builder
+	err := WriteChat(&out, []Entry{
+		{Time: at, Sender: "Alice", Text: "two\nlines"},
+		{Time: at, Sender: "Me", Attachment: "IMG-20240501-WA0001.jpg", Text: "caption"},
+		{Time: at, Sender: "Alice", MediaOmitted: true},
+	}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "01/05/2024, 21:05 - Alice: two\nlines\n" +
+		"01/05/2024, 21:05 - Me: IMG-20240501-WA0001.jpg (file attached)\ncaption\n" +
+		"01/05/2024, 21:05 - Alice: <Media omitted>\n"
+	if out.String() != want {
+		t.Fatalf("unexpected transcript:\n%s", out.String())
+	}
+}
+
+func TestAttachmentNamerNumbersPerTypeAndDay(t *testing.T) {
+	day := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	namer := NewAttachmentNamer()
+	got := []string{
+		namer.Name("image", "image_20240501_090000.jpg", day),
+		namer.Name("image", "image_20240501_090500.jpg", day),
+		namer.Name("video", "video_20240501_090000.mp4", day),
+		namer.Name("image", "image_20240502_090000.jpg", day.Add(24*time.Hour)),
+		namer.Name("document", "../report.pdf", day),
+		namer.Name("document", "report.pdf", day),
+	}
+	want := []string{
+		"IMG-20240501-WA0001.jpg",
+		"IMG-20240501-WA0002.jpg",
+		"VID-20240501-WA0001.mp4",
+		"IMG-20240502-WA0001.jpg",
+		"report.pdf",
+		"report (1).pdf",
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("name %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestArchiveNameStripsPathCharacters(t *testing.T) {
+	if got := ArchiveName("Team/Ops: 2024"); got != "WhatsApp Chat with Team_Ops_ 2024.zip" {
+		t.Fatalf("unexpected archive name %q", got)
+	}
+}
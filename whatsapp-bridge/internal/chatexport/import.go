@@ -0,0 +1,292 @@
+package chatexport
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/mediacrypt"
+	"whatsapp-client/internal/storage"
+)
+
+// importedIDPrefix marks message IDs synthesized for imported history, which
+// exports do not carry.
+const importedIDPrefix = "import-"
+
+// ImportOptions controls how an export archive is ingested.
+type ImportOptions struct {
+	// ChatJID is the chat the archive belongs to; exports do not record it.
+	ChatJID  string
+	ChatName string
+	// OwnName is the account owner's name as it appears in the export.
+	// Messages from "Me", as written by ExportChat, also count as own.
+	OwnName string
+	// MediaDir receives attached media, in a subdirectory per chat.
+	MediaDir string
+	// MediaKeys encrypts extracted media at rest when set.
+	MediaKeys *mediacrypt.Keyring
+	// Location is the time zone the export's timestamps were written in.
+	Location *time.Location
+	// CheckMedia vets an attachment before it is extracted, returning true
+	// when it must be quarantined. Attachments it rejects are left out.
+	CheckMedia func(mediaType, filename string, size uint64) (bool, error)
+}
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Imported int
+	// Skipped counts messages with nothing to store, such as omitted media.
+	Skipped int
+	Media   int
+	// UnmappedSenders lists names that matched no known contact; their
+	// messages keep the name as the sender.
+	UnmappedSenders []string
+}
+
+// mediaTypesByExtension maps attachment extensions to stored media types;
+// anything else is a document.
+var mediaTypesByExtension = map[string]string{
+	".jpg": "image", ".jpeg": "image", ".png": "image", ".webp": "image", ".gif": "image",
+	".mp4": "video", ".3gp": "video", ".mov": "video",
+	".opus": "audio", ".ogg": "audio", ".m4a": "audio", ".mp3": "audio", ".aac": "audio", ".amr": "audio",
+}
+
+func mediaTypeForAttachment(name string) string {
+	if mediaType, ok := mediaTypesByExtension[strings.ToLower(filepath.Ext(name))]; ok {
+		return mediaType
+	}
+	return "document"
+}
+
+// importedMessageID derives a stable ID for an imported message, so
+// importing the same archive twice updates rather than duplicates it.
+// occurrence separates identical messages sent in the same minute.
+func importedMessageID(chatJID string, entry Entry, occurrence int) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		chatJID,
+		strconv.FormatInt(entry.Time.Unix(), 10),
+		entry.Sender,
+		entry.Text,
+		entry.Attachment,
+		strconv.Itoa(occurrence),
+	}, "\x00")))
+	return importedIDPrefix + strings.ToUpper(hex.EncodeToString(sum[:10]))
+}
+
+// findTranscript returns the chat transcript in an export archive: _chat.txt
+// on iOS, "WhatsApp Chat with <name>.txt" on Android.
+func findTranscript(archive *zip.Reader) (*zip.File, error) {
+	var candidates []*zip.File
+	for _, file := range archive.File {
+		if path.Base(file.Name) == ChatFileName {
+			return file, nil
+		}
+		if strings.EqualFold(path.Ext(file.Name), ".txt") && !file.FileInfo().IsDir() {
+			candidates = append(candidates, file)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	return nil, errors.New("archive has no chat transcript")
+}
+
+// ImportArchive ingests an official WhatsApp export zip into the store.
+// Senders are matched to contacts by name and stored under their canonical
+// IDs; attachments are extracted next to downloaded media.
+func ImportArchive(store *storage.MessageStore, archivePath string, opts ImportOptions) (ImportResult, error) {
+	chatJID := jid.NormalizeChat(opts.ChatJID)
+	if chatJID == "" {
+		return ImportResult{}, errors.New("chat JID is required")
+	}
+	location := opts.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	transcript, err := findTranscript(&archive.Reader)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	reader, err := transcript.Open()
+	if err != nil {
+		return ImportResult{}, err
+	}
+	entries, err := ParseChat(reader, location)
+	reader.Close()
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("failed to parse %s: %w", transcript.Name, err)
+	}
+
+	attachments := map[string]*zip.File{}
+	for _, file := range archive.File {
+		attachments[path.Base(file.Name)] = file
+	}
+
+	// Messages reference their chat, so it is stored first.
+	var lastMessageTime time.Time
+	for _, entry := range entries {
+		if entry.Time.After(lastMessageTime) {
+			lastMessageTime = entry.Time
+		}
+	}
+	if len(entries) > 0 {
+		if err := store.StoreImportedChat(chatJID, opts.ChatName, lastMessageTime); err != nil {
+			return ImportResult{}, err
+		}
+	}
+
+	var result ImportResult
+	isDirect := jid.IsPersonal(chatJID)
+	senders := map[string]string{}
+	unmapped := map[string]bool{}
+	occurrences := map[string]int{}
+	for _, entry := range entries {
+		isFromMe := entry.Sender == ownSenderName || (opts.OwnName != "" && entry.Sender == opts.OwnName)
+
+		sender := ""
+		switch {
+		case isFromMe:
+		case isDirect:
+			sender = chatJID
+		default:
+			resolved, ok := senders[entry.Sender]
+			if !ok {
+				if resolved, err = store.ResolveSenderName(entry.Sender); err != nil {
+					return result, err
+				}
+				senders[entry.Sender] = resolved
+			}
+			sender = resolved
+			if sender == "" {
+				sender = entry.Sender
+				if !unmapped[entry.Sender] {
+					unmapped[entry.Sender] = true
+					result.UnmappedSenders = append(result.UnmappedSenders, entry.Sender)
+				}
+			}
+		}
+
+		// The first occurrence's ID doubles as the key for counting repeats.
+		key := importedMessageID(chatJID, entry, 0)
+		id := importedMessageID(chatJID, entry, occurrences[key])
+		occurrences[key]++
+
+		msg := storage.StoredMessage{
+			ID:        id,
+			ChatJID:   chatJID,
+			Sender:    sender,
+			Content:   entry.Text,
+			Timestamp: entry.Time,
+			IsFromMe:  isFromMe,
+		}
+		var localPath string
+		if file, ok := attachments[entry.Attachment]; ok && entry.Attachment != "" {
+			msg.MediaType = mediaTypeForAttachment(entry.Attachment)
+			msg.Filename = entry.Attachment
+			msg.FileLength = file.UncompressedSize64
+			if localPath, err = extractAttachment(file, chatJID, msg.MediaType, opts); err != nil {
+				return result, err
+			}
+		}
+		if msg.Content == "" && msg.MediaType == "" {
+			result.Skipped++
+			continue
+		}
+
+		if err := store.StoreMessage(msg); err != nil {
+			return result, fmt.Errorf("failed to store message: %w", err)
+		}
+		if localPath != "" {
+			if err := store.MarkMediaDownloaded(id, chatJID, localPath); err != nil {
+				return result, err
+			}
+			result.Media++
+		}
+		result.Imported++
+	}
+	return result, nil
+}
+
+// extractAttachment writes an attachment into the chat's media directory and
+// returns its absolute path, or "" when policy rejects it. Files already
+// extracted by an earlier import are reused.
+func extractAttachment(file *zip.File, chatJID, mediaType string, opts ImportOptions) (string, error) {
+	if opts.MediaDir == "" {
+		return "", nil
+	}
+	name := cleanFileName(path.Base(file.Name))
+	if name == "" {
+		return "", nil
+	}
+	root := opts.MediaDir
+	if opts.CheckMedia != nil {
+		quarantine, err := opts.CheckMedia(mediaType, name, file.UncompressedSize64)
+		if err != nil {
+			return "", nil
+		}
+		if quarantine {
+			root = filepath.Join(root, "quarantine")
+		}
+	}
+
+	dir := filepath.Join(root, cleanFileName(strings.ReplaceAll(chatJID, ":", "_")))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create media directory: %w", err)
+	}
+	target, err := filepath.Abs(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	if existing, size, err := mediacrypt.Open(target, opts.MediaKeys); err == nil {
+		existing.Close()
+		if uint64(size) == file.UncompressedSize64 {
+			return target, nil
+		}
+	}
+
+	source, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+	out, err := os.CreateTemp(dir, ".import-*")
+	if err != nil {
+		return "", err
+	}
+	if opts.MediaKeys != nil {
+		err = opts.MediaKeys.Encrypt(out, source, int64(file.UncompressedSize64))
+	} else {
+		_, err = io.Copy(out, source)
+	}
+	if err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := os.Rename(out.Name(), target); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return target, nil
+}
@@ -0,0 +1,173 @@
+package chatexport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// androidLine matches "31/12/2023, 21:41 - rest" and its 12-hour and
+// dotted-date variants; iosLine matches "[31/12/2023, 21:41:05] rest".
+var (
+	androidLine = regexp.MustCompile(`^(\d{1,2})[/.](\d{1,2})[/.](\d{2,4}),? (\d{1,2})[:.](\d{2})(?:[:.](\d{2}))? ?([AaPp]\.? ?[Mm]\.?)? - (.*)$`)
+	iosLine     = regexp.MustCompile(`^\[(\d{1,2})[/.](\d{1,2})[/.](\d{2,4}),? (\d{1,2})[:.](\d{2})(?:[:.](\d{2}))? ?([AaPp]\.? ?[Mm]\.?)?\] (.*)$`)
+	iosAttached = regexp.MustCompile(`^<attached: (.+)>$`)
+)
+
+// encryptionBanner opens every export, attributed to the chat on iOS.
+const encryptionBanner = "Messages and calls are end-to-end encrypted"
+
+// omittedBodies are the placeholders exports write for media left out.
+var omittedBodies = map[string]bool{
+	MediaOmitted:           true,
+	"image omitted":        true,
+	"video omitted":        true,
+	"audio omitted":        true,
+	"sticker omitted":      true,
+	"document omitted":     true,
+	"GIF omitted":          true,
+	"Contact card omitted": true,
+}
+
+// rawLine is a transcript line that starts a message, before its date is
+// interpreted.
+type rawLine struct {
+	dateA, dateB, year, hour, minute, seconds int
+	meridiem                                  string
+	rest                                      string
+}
+
+// cleanLine drops the direction marks and narrow spaces WhatsApp sprinkles
+// through exports.
+func cleanLine(line string) string {
+	line = strings.NewReplacer("\u200e", "", "\u200f", "", "\ufeff", "", "\u202f", " ", "\u00a0", " ").Replace(line)
+	return strings.TrimRight(line, "\r")
+}
+
+func parseRawLine(line string) (rawLine, bool) {
+	match := androidLine.FindStringSubmatch(line)
+	if match == nil {
+		match = iosLine.FindStringSubmatch(line)
+	}
+	if match == nil {
+		return rawLine{}, false
+	}
+	numbers := make([]int, 6)
+	for i := range numbers {
+		if match[i+1] != "" {
+			numbers[i], _ = strconv.Atoi(match[i+1])
+		}
+	}
+	return rawLine{
+		dateA: numbers[0], dateB: numbers[1], year: numbers[2],
+		hour: numbers[3], minute: numbers[4], seconds: numbers[5],
+		meridiem: strings.ToLower(strings.NewReplacer(".", "", " ", "").Replace(match[7])),
+		rest:     match[8],
+	}, true
+}
+
+// dayFirst decides whether dates read day/month or month/day. Exports follow
+// the phone's locale, so any component above 12 settles it; otherwise
+// day-first, the more common layout, is assumed.
+func dayFirst(lines []rawLine) bool {
+	for _, line := range lines {
+		if line.dateA > 12 {
+			return true
+		}
+		if line.dateB > 12 {
+			return false
+		}
+	}
+	return true
+}
+
+func (line rawLine) time(dayFirst bool, location *time.Location) (time.Time, error) {
+	day, month := line.dateA, line.dateB
+	if !dayFirst {
+		day, month = month, day
+	}
+	year := line.year
+	if year < 100 {
+		year += 2000
+	}
+	hour := line.hour
+	switch line.meridiem {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || line.minute > 59 || line.seconds > 59 {
+		return time.Time{}, fmt.Errorf("invalid date %d/%d/%d %d:%02d", line.dateA, line.dateB, line.year, line.hour, line.minute)
+	}
+	return time.Date(year, time.Month(month), day, hour, line.minute, line.seconds, 0, location), nil
+}
+
+// ParseChat reads a _chat.txt transcript from an official export, Android or
+// iOS, into entries. Lines that do not start a message continue the one
+// before; system notices such as the encryption banner are skipped.
+// Timestamps are read in location, since exports carry no time zone.
+func ParseChat(r io.Reader, location *time.Location) ([]Entry, error) {
+	var starts []rawLine
+	var bodies [][]string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := cleanLine(scanner.Text())
+		if raw, ok := parseRawLine(line); ok {
+			starts = append(starts, raw)
+			bodies = append(bodies, []string{raw.rest})
+			continue
+		}
+		if len(bodies) > 0 {
+			bodies[len(bodies)-1] = append(bodies[len(bodies)-1], line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	order := dayFirst(starts)
+	var entries []Entry
+	for i, raw := range starts {
+		sender, text, ok := strings.Cut(strings.Join(bodies[i], "\n"), ": ")
+		if !ok || sender == "" || strings.HasPrefix(text, encryptionBanner) {
+			continue
+		}
+		at, err := raw.time(order, location)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, parseBody(Entry{Time: at, Sender: strings.TrimSpace(sender)}, text))
+	}
+	return entries, nil
+}
+
+// parseBody splits a message body into its attachment, omitted-media marker
+// and text.
+func parseBody(entry Entry, body string) Entry {
+	first, rest, _ := strings.Cut(body, "\n")
+	trimmed := strings.TrimSpace(first)
+	switch {
+	case strings.HasSuffix(trimmed, attachedSuffix):
+		entry.Attachment = strings.TrimSuffix(trimmed, attachedSuffix)
+		entry.Text = rest
+	case iosAttached.MatchString(trimmed):
+		entry.Attachment = iosAttached.FindStringSubmatch(trimmed)[1]
+		entry.Text = rest
+	case omittedBodies[trimmed]:
+		entry.MediaOmitted = true
+		entry.Text = rest
+	default:
+		entry.Text = body
+	}
+	return entry
+}
@@ -0,0 +1,72 @@
+package chatexport
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseChatReadsAndroidExport(t *testing.T) {
+	transcript := "01/05/2024, 09:00 - Messages and calls are end-to-end encrypted. No one outside of this chat can read them.\n" +
+		"01/05/2024, 09:00 - Alice: two\n" +
+		"lines\n" +
+		"13/05/2024, 21:05 - Bob: IMG-20240513-WA0001.jpg (file attached)\n" +
+		"caption\n" +
+		"13/05/2024, 21:06 - Alice: <Media omitted>\n"
+
+	entries, err := ParseChat(strings.NewReader(transcript), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if entries[0].Sender != "Alice" || entries[0].Text != "two\nlines" || !entries[0].Time.Equal(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Attachment != "IMG-20240513-WA0001.jpg" || entries[1].Text != "caption" {
+		t.Fatalf("unexpected attachment entry: %+v", entries[1])
+	}
+	if !entries[2].MediaOmitted || entries[2].Text != "" {
+		t.Fatalf("unexpected omitted entry: %+v", entries[2])
+	}
+}
+
+func TestParseChatReadsIOSExportWithMonthFirstDates(t *testing.T) {
+	transcript := "[5/13/24, 9:05:12 PM] Team: \u200eMessages and calls are end-to-end encrypted.\n" +
+		"[5/13/24, 9:05:12 PM] Alice: hello\n" +
+		"\u200e[5/14/24, 12:01:00 AM] Bob: \u200e<attached: 00000012-PHOTO-2024-05-14-00-01-00.jpg>\n"
+
+	entries, err := ParseChat(strings.NewReader(transcript), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	if !entries[0].Time.Equal(time.Date(2024, 5, 13, 21, 5, 12, 0, time.UTC)) || entries[0].Text != "hello" {
+		t.Fatalf("unexpected first entry: %+v", entries[0])
+	}
+	if !entries[1].Time.Equal(time.Date(2024, 5, 14, 0, 1, 0, 0, time.UTC)) || entries[1].Attachment != "00000012-PHOTO-2024-05-14-00-01-00.jpg" {
+		t.Fatalf("unexpected attachment entry: %+v", entries[1])
+	}
+}
+
+func TestParseChatRoundTripsWriteChat(t *testing.T) {
+	at := time.Date(2024, 5, 1, 21, 5, 0, 0, time.UTC)
+	written := []Entry{
+		{Time: at, Sender: "Alice", Text: "hi: there"},
+		{Time: at, Sender: "Me", Attachment: "report.pdf", Text: "see\nthis"},
+	}
+	var out strings.Builder
+	if err := WriteChat(&out, written, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ParseChat(strings.NewReader(out.String()), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0] != written[0] || entries[1] != written[1] {
+		t.Fatalf("round trip mismatch: %+v", entries)
+	}
+}
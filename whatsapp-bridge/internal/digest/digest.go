@@ -0,0 +1,173 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+const maxDigestPreviewRunes = 80
+
+// Config controls the daily digest job.
+type Config struct {
+	Enabled    bool
+	Hour       int
+	Minute     int
+	Location   *time.Location
+	WebhookURL string
+	SelfChat   bool
+}
+
+// ConfigFromEnv loads digest settings. The job is disabled unless
+// WHATSAPP_DIGEST_ENABLED is true, and runs at WHATSAPP_DIGEST_TIME (HH:MM,
+// default 08:00) in WHATSAPP_DIGEST_TIMEZONE (default local time).
+func ConfigFromEnv() Config {
+	config := Config{
+		Enabled:    parseBoolEnv("WHATSAPP_DIGEST_ENABLED"),
+		Hour:       8,
+		Location:   time.Local,
+		WebhookURL: strings.TrimSpace(os.Getenv("WHATSAPP_DIGEST_WEBHOOK_URL")),
+		SelfChat:   parseBoolEnv("WHATSAPP_DIGEST_SELF_CHAT"),
+	}
+
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_DIGEST_TIME")); raw != "" {
+		parsed, err := time.Parse("15:04", raw)
+		if err != nil {
+			fmt.Printf("Warning: invalid WHATSAPP_DIGEST_TIME=%q, using 08:00\n", raw)
+		} else {
+			config.Hour, config.Minute = parsed.Hour(), parsed.Minute()
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_DIGEST_TIMEZONE")); raw != "" {
+		location, err := time.LoadLocation(raw)
+		if err != nil {
+			fmt.Printf("Warning: invalid WHATSAPP_DIGEST_TIMEZONE=%q, using local time\n", raw)
+		} else {
+			config.Location = location
+		}
+	}
+	return config
+}
+
+func parseBoolEnv(name string) bool {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return false
+	}
+	parsed, err := strconv.ParseBool(raw)
+	if err != nil {
+		fmt.Printf("Warning: invalid %s=%q, treating as false\n", name, raw)
+		return false
+	}
+	return parsed
+}
+
+// NextRun returns the first scheduled run strictly after now.
+func (c Config) NextRun(now time.Time) time.Time {
+	local := now.In(c.Location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), c.Hour, c.Minute, 0, 0, c.Location)
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Build compiles activity for the 24 hours ending at now.
+func Build(store *storage.MessageStore, selfIDs []string, now time.Time) (*storage.Digest, error) {
+	digest := &storage.Digest{
+		PeriodStart: now.Add(-24 * time.Hour),
+		PeriodEnd:   now,
+		CreatedAt:   now,
+	}
+	chats, err := store.GetChatActivity(digest.PeriodStart, digest.PeriodEnd, selfIDs)
+	if err != nil {
+		return nil, err
+	}
+	digest.Chats = chats
+	return digest, nil
+}
+
+func previewText(msg *storage.DigestMessage) string {
+	text := strings.Join(strings.Fields(msg.Content), " ")
+	if text == "" && msg.MediaType != "" {
+		text = "[" + msg.MediaType + "]"
+	}
+	if runes := []rune(text); len(runes) > maxDigestPreviewRunes {
+		text = string(runes[:maxDigestPreviewRunes]) + "…"
+	}
+	return text
+}
+
+// FormatText renders a digest as a plain-text message.
+func FormatText(digest *storage.Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Daily digest (%s – %s)\n",
+		digest.PeriodStart.Format("Jan 2 15:04"), digest.PeriodEnd.Format("Jan 2 15:04"))
+	if len(digest.Chats) == 0 {
+		b.WriteString("No activity.")
+		return b.String()
+	}
+
+	for _, chat := range digest.Chats {
+		name := chat.ChatName
+		if name == "" {
+			name = chat.ChatJID
+		}
+		fmt.Fprintf(&b, "\n• %s: %d messages (%d in, %d out)", name, chat.MessageCount, chat.IncomingCount, chat.OutgoingCount)
+		if chat.Mentions > 0 {
+			fmt.Fprintf(&b, ", %d mentions of you", chat.Mentions)
+		}
+		if chat.FirstUnreplied != nil {
+			fmt.Fprintf(&b, "\n  Unreplied since %s: %s", chat.FirstUnreplied.Timestamp.Format("Jan 2 15:04"), previewText(chat.FirstUnreplied))
+		}
+	}
+	return b.String()
+}
+
+type webhookPayload struct {
+	Event       string                 `json:"event"`
+	DigestID    int64                  `json:"digest_id"`
+	PeriodStart string                 `json:"period_start"`
+	PeriodEnd   string                 `json:"period_end"`
+	Chats       []storage.ChatActivity `json:"chats"`
+	Text        string                 `json:"text"`
+}
+
+// PostWebhook delivers a digest as JSON to url.
+func PostWebhook(ctx context.Context, url string, digest *storage.Digest) error {
+	payload, err := json.Marshal(webhookPayload{
+		Event:       "digest.daily",
+		DigestID:    digest.ID,
+		PeriodStart: digest.PeriodStart.UTC().Format(time.RFC3339),
+		PeriodEnd:   digest.PeriodEnd.UTC().Format(time.RFC3339),
+		Chats:       digest.Chats,
+		Text:        FormatText(digest),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("digest webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("digest webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
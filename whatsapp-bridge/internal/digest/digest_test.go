@@ -0,0 +1,52 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+func TestNextRunSameDayBeforeScheduledTime(t *testing.T) {
+	config := Config{Hour: 8, Minute: 30, Location: time.UTC}
+	now := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+	if got := config.NextRun(now); !got.Equal(want) {
+		t.Fatalf("unexpected next run: got %v want %v", got, want)
+	}
+}
+
+func TestNextRunRollsToNextDay(t *testing.T) {
+	config := Config{Hour: 8, Location: time.UTC}
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 3, 3, 8, 0, 0, 0, time.UTC)
+	if got := config.NextRun(now); !got.Equal(want) {
+		t.Fatalf("unexpected next run: got %v want %v", got, want)
+	}
+}
+
+func TestFormatTextIncludesUnrepliedAndMentions(t *testing.T) {
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	text := FormatText(&storage.Digest{
+		PeriodStart: now.Add(-24 * time.Hour),
+		PeriodEnd:   now,
+		Chats: []storage.ChatActivity{{
+			ChatJID:       "123",
+			ChatName:      "Alice",
+			MessageCount:  3,
+			IncomingCount: 2,
+			OutgoingCount: 1,
+			Mentions:      1,
+			FirstUnreplied: &storage.DigestMessage{
+				Content:   "are you   around?",
+				Timestamp: now.Add(-time.Hour),
+			},
+		}},
+	})
+	for _, want := range []string{"Alice: 3 messages (2 in, 1 out)", "1 mentions of you", "are you around?"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in digest text:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config describes an OpenAI-compatible embedding endpoint.
+type Config struct {
+	Endpoint string
+	Model    string
+	APIKey   string
+}
+
+// Enabled reports whether an embedding endpoint is configured.
+func (c Config) Enabled() bool {
+	return c.Endpoint != ""
+}
+
+// ConfigFromEnv loads embedding endpoint settings. Embeddings are disabled
+// unless WHATSAPP_EMBEDDING_ENDPOINT is set.
+func ConfigFromEnv() Config {
+	return Config{
+		Endpoint: strings.TrimSpace(os.Getenv("WHATSAPP_EMBEDDING_ENDPOINT")),
+		Model:    strings.TrimSpace(os.Getenv("WHATSAPP_EMBEDDING_MODEL")),
+		APIKey:   strings.TrimSpace(os.Getenv("WHATSAPP_EMBEDDING_API_KEY")),
+	}
+}
+
+// Client requests embeddings for text from the configured endpoint.
+type Client struct {
+	config     Config
+	httpClient *http.Client
+}
+
+// NewClient builds an embedding client for the given configuration.
+func NewClient(config Config) *Client {
+	return &Client{
+		config:     config,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Model returns the configured model name, used to tag stored vectors.
+func (c *Client) Model() string {
+	if c.config.Model == "" {
+		return "default"
+	}
+	return c.config.Model
+}
+
+type embeddingRequest struct {
+	Model string `json:"model,omitempty"`
+	Input string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// Embed returns the embedding vector for text. Both the OpenAI response shape
+// ({"data":[{"embedding":[...]}]}) and a bare {"embedding":[...]} are accepted.
+func (c *Client) Embed(ctx context.Context, text string) ([]float32, error) {
+	payload, err := json.Marshal(embeddingRequest{Model: c.config.Model, Input: text})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("embedding endpoint returned status %d", resp.StatusCode)
+	}
+
+	var parsed embeddingResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+	vector := parsed.Embedding
+	if len(parsed.Data) > 0 {
+		vector = parsed.Data[0].Embedding
+	}
+	if len(vector) == 0 {
+		return nil, fmt.Errorf("embedding response contained no vector")
+	}
+	return vector, nil
+}
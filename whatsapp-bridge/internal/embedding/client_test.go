@@ -0,0 +1,44 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEmbedParsesOpenAIResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("unexpected authorization header: %q", got)
+		}
+		var req embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Input != "hello" || req.Model != "mini" {
+			t.Errorf("unexpected request: %+v err=%v", req, err)
+		}
+		w.Write([]byte(`{"data":[{"embedding":[0.5,-0.25]}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Endpoint: server.URL, Model: "mini", APIKey: "secret"})
+	vector, err := client.Embed(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Embed returned error: %v", err)
+	}
+	if !reflect.DeepEqual(vector, []float32{0.5, -0.25}) {
+		t.Fatalf("unexpected vector: %v", vector)
+	}
+}
+
+func TestEmbedRejectsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(Config{Endpoint: server.URL}).Embed(context.Background(), "hello"); err == nil {
+		t.Fatal("expected error for non-2xx response")
+	}
+}
@@ -0,0 +1,75 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+const indexerQueueSize = 1000
+
+type indexJob struct {
+	store     *storage.MessageStore
+	messageID string
+	chatJID   string
+	text      string
+}
+
+// Indexer embeds stored message text in the background so ingestion never
+// waits on the embedding endpoint.
+type Indexer struct {
+	client *Client
+	queue  chan indexJob
+}
+
+// NewIndexer starts a background indexer, or returns nil when embeddings are disabled.
+func NewIndexer(config Config) *Indexer {
+	if !config.Enabled() {
+		return nil
+	}
+	indexer := &Indexer{
+		client: NewClient(config),
+		queue:  make(chan indexJob, indexerQueueSize),
+	}
+	go indexer.run()
+	return indexer
+}
+
+// Client returns the embedding client used by the indexer.
+func (i *Indexer) Client() *Client {
+	if i == nil {
+		return nil
+	}
+	return i.client
+}
+
+// Enqueue schedules a message for embedding. Messages without text are skipped
+// and the message is dropped when the queue is full.
+func (i *Indexer) Enqueue(store *storage.MessageStore, messageID string, chatJID string, text string) {
+	if i == nil || store == nil || strings.TrimSpace(text) == "" {
+		return
+	}
+	select {
+	case i.queue <- indexJob{store: store, messageID: messageID, chatJID: chatJID, text: text}:
+	default:
+		fmt.Println("Warning: embedding queue is full, skipping message")
+	}
+}
+
+func (i *Indexer) run() {
+	for job := range i.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		vector, err := i.client.Embed(ctx, job.text)
+		cancel()
+		if err != nil {
+			fmt.Printf("Warning: failed to embed message: %v\n", err)
+			continue
+		}
+		if err := job.store.StoreMessageEmbedding(job.messageID, job.chatJID, i.client.Model(), vector); err != nil {
+			fmt.Printf("Warning: failed to store message embedding: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package jid normalizes WhatsApp identifiers into the keys the bridge stores.
+//
+// Personal identities (phone numbers and LIDs) are stored as bare user IDs so
+// that aliases of one person can be merged; groups, newsletters and broadcast
+// lists keep their full JID because their user part is not a person.
+package jid
+
+import "strings"
+
+const (
+	UserServer       = "s.whatsapp.net"
+	LegacyUserServer = "c.us"
+	LIDServer        = "lid"
+	GroupServer      = "g.us"
+	NewsletterServer = "newsletter"
+	BroadcastServer  = "broadcast"
+)
+
+// Kind classifies an identifier by its server.
+type Kind string
+
+const (
+	KindEmpty      Kind = ""
+	KindBare       Kind = "bare"
+	KindPhone      Kind = "phone"
+	KindLID        Kind = "lid"
+	KindGroup      Kind = "group"
+	KindNewsletter Kind = "newsletter"
+	KindBroadcast  Kind = "broadcast"
+	KindOther      Kind = "other"
+)
+
+// Split trims an identifier and returns its user and server parts. The server
+// is lower-cased, the legacy c.us server maps to s.whatsapp.net, and device
+// suffixes ("user:3@server") are dropped from personal JIDs.
+func Split(raw string) (user, server string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ""
+	}
+	user, server, found := strings.Cut(raw, "@")
+	if !found {
+		return stripDevice(user), ""
+	}
+	server = NormalizeServer(server)
+	if IsPersonalServer(server) {
+		user = stripDevice(user)
+	}
+	return strings.TrimSpace(user), server
+}
+
+// NormalizeServer lower-cases a server name and maps the legacy c.us server to
+// s.whatsapp.net.
+func NormalizeServer(server string) string {
+	server = strings.ToLower(strings.TrimSpace(server))
+	if server == LegacyUserServer {
+		return UserServer
+	}
+	return server
+}
+
+func stripDevice(user string) string {
+	if i := strings.IndexByte(user, ':'); i >= 0 {
+		return user[:i]
+	}
+	return user
+}
+
+// IsPersonalServer reports whether a server hosts personal identities, which
+// includes bare IDs with no server at all.
+func IsPersonalServer(server string) bool {
+	switch server {
+	case "", UserServer, LegacyUserServer, LIDServer:
+		return true
+	default:
+		return false
+	}
+}
+
+// Classify returns the kind of an identifier.
+func Classify(raw string) Kind {
+	user, server := Split(raw)
+	if user == "" && server == "" {
+		return KindEmpty
+	}
+	switch server {
+	case "":
+		return KindBare
+	case UserServer:
+		return KindPhone
+	case LIDServer:
+		return KindLID
+	case GroupServer:
+		return KindGroup
+	case NewsletterServer:
+		return KindNewsletter
+	case BroadcastServer:
+		return KindBroadcast
+	default:
+		return KindOther
+	}
+}
+
+// IsGroup reports whether an identifier is a group JID.
+func IsGroup(raw string) bool {
+	return Classify(raw) == KindGroup
+}
+
+// IsPersonal reports whether an identifier names a person rather than a group,
+// newsletter or broadcast list.
+func IsPersonal(raw string) bool {
+	switch Classify(raw) {
+	case KindBare, KindPhone, KindLID:
+		return true
+	default:
+		return false
+	}
+}
+
+// NormalizeUser returns the bare user ID of an identifier, dropping the server
+// and any device suffix.
+func NormalizeUser(raw string) string {
+	user, _ := Split(raw)
+	return user
+}
+
+// NormalizeChat returns the key a chat is stored under: bare user IDs for
+// personal chats, the full JID for everything else.
+func NormalizeChat(raw string) string {
+	user, server := Split(raw)
+	if user == "" || IsPersonalServer(server) {
+		return user
+	}
+	return user + "@" + server
+}
+
+// StatusBroadcast is the pseudo broadcast list that carries status updates.
+const StatusBroadcast = "status@" + BroadcastServer
+
+// Chat types stored alongside each chat.
+const (
+	ChatTypeDirect     = "direct"
+	ChatTypeGroup      = "group"
+	ChatTypeBroadcast  = "broadcast"
+	ChatTypeStatus     = "status"
+	ChatTypeNewsletter = "newsletter"
+	ChatTypeOther      = "other"
+)
+
+// ChatType returns the chat type of a chat identifier. Broadcast lists and
+// the status broadcast share a server but are reported separately.
+func ChatType(raw string) string {
+	switch Classify(raw) {
+	case KindBare, KindPhone, KindLID:
+		return ChatTypeDirect
+	case KindGroup:
+		return ChatTypeGroup
+	case KindNewsletter:
+		return ChatTypeNewsletter
+	case KindBroadcast:
+		if user, _ := Split(raw); strings.EqualFold(user, "status") {
+			return ChatTypeStatus
+		}
+		return ChatTypeBroadcast
+	default:
+		return ChatTypeOther
+	}
+}
+
+// IsBroadcastList reports whether an identifier is a broadcast list other
+// than the status broadcast.
+func IsBroadcastList(raw string) bool {
+	return ChatType(raw) == ChatTypeBroadcast
+}
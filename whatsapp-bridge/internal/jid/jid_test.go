@@ -0,0 +1,153 @@
+package jid
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	cases := []struct {
+		raw, user, server string
+	}{
+		{"", "", ""},
+		{"   ", "", ""},
+		{"15551234567", "15551234567", ""},
+		{" 15551234567 ", "15551234567", ""},
+		{"15551234567@s.whatsapp.net", "15551234567", UserServer},
+		{"15551234567@S.WhatsApp.Net", "15551234567", UserServer},
+		{"15551234567@c.us", "15551234567", UserServer},
+		{"15551234567:12@s.whatsapp.net", "15551234567", UserServer},
+		{"123456789012345@lid", "123456789012345", LIDServer},
+		{"123456789012345:3@lid", "123456789012345", LIDServer},
+		{"120363000000000000@g.us", "120363000000000000", GroupServer},
+		{"15551234567-1600000000@g.us", "15551234567-1600000000", GroupServer},
+		{"120363000000000001@newsletter", "120363000000000001", NewsletterServer},
+		{"status@broadcast", "status", BroadcastServer},
+		{"1600000000@broadcast", "1600000000", BroadcastServer},
+	}
+	for _, tc := range cases {
+		user, server := Split(tc.raw)
+		if user != tc.user || server != tc.server {
+			t.Errorf("Split(%q) = (%q, %q), want (%q, %q)", tc.raw, user, server, tc.user, tc.server)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cases := map[string]Kind{
+		"":                              KindEmpty,
+		"15551234567":                   KindBare,
+		"15551234567@s.whatsapp.net":    KindPhone,
+		"15551234567@c.us":              KindPhone,
+		"15551234567:2@s.whatsapp.net":  KindPhone,
+		"123456789012345@lid":           KindLID,
+		"120363000000000000@g.us":       KindGroup,
+		"120363000000000001@newsletter": KindNewsletter,
+		"status@broadcast":              KindBroadcast,
+		"bot@bot":                       KindOther,
+	}
+	for raw, want := range cases {
+		if got := Classify(raw); got != want {
+			t.Errorf("Classify(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestIsGroupAndIsPersonal(t *testing.T) {
+	cases := []struct {
+		raw      string
+		group    bool
+		personal bool
+	}{
+		{"", false, false},
+		{"15551234567", false, true},
+		{"15551234567@s.whatsapp.net", false, true},
+		{"123456789012345@lid", false, true},
+		{"120363000000000000@g.us", true, false},
+		{"120363000000000000@G.US", true, false},
+		{"120363000000000001@newsletter", false, false},
+		{"status@broadcast", false, false},
+	}
+	for _, tc := range cases {
+		if got := IsGroup(tc.raw); got != tc.group {
+			t.Errorf("IsGroup(%q) = %v, want %v", tc.raw, got, tc.group)
+		}
+		if got := IsPersonal(tc.raw); got != tc.personal {
+			t.Errorf("IsPersonal(%q) = %v, want %v", tc.raw, got, tc.personal)
+		}
+	}
+}
+
+func TestNormalizeUser(t *testing.T) {
+	cases := map[string]string{
+		"":                              "",
+		" 15551234567 ":                 "15551234567",
+		"15551234567@s.whatsapp.net":    "15551234567",
+		"15551234567:7@s.whatsapp.net":  "15551234567",
+		"123456789012345:1@lid":         "123456789012345",
+		"120363000000000000@g.us":       "120363000000000000",
+		"120363000000000001@newsletter": "120363000000000001",
+		"status@broadcast":              "status",
+	}
+	for raw, want := range cases {
+		if got := NormalizeUser(raw); got != want {
+			t.Errorf("NormalizeUser(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestNormalizeChat(t *testing.T) {
+	cases := map[string]string{
+		"":                              "",
+		"15551234567":                   "15551234567",
+		"15551234567@s.whatsapp.net":    "15551234567",
+		"15551234567@c.us":              "15551234567",
+		"15551234567:4@s.whatsapp.net":  "15551234567",
+		"123456789012345@lid":           "123456789012345",
+		" 120363000000000000@g.us ":     "120363000000000000@g.us",
+		"120363000000000000@G.US":       "120363000000000000@g.us",
+		"120363000000000001@newsletter": "120363000000000001@newsletter",
+		"status@broadcast":              "status@broadcast",
+		"@g.us":                         "",
+	}
+	for raw, want := range cases {
+		if got := NormalizeChat(raw); got != want {
+			t.Errorf("NormalizeChat(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestNormalizeServer(t *testing.T) {
+	cases := map[string]string{
+		"":                 "",
+		" S.WhatsApp.Net ": UserServer,
+		"c.us":             UserServer,
+		"LID":              LIDServer,
+		"g.us":             GroupServer,
+		"newsletter":       NewsletterServer,
+	}
+	for raw, want := range cases {
+		if got := NormalizeServer(raw); got != want {
+			t.Errorf("NormalizeServer(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestChatType(t *testing.T) {
+	cases := map[string]string{
+		"15551234567":                   ChatTypeDirect,
+		"15551234567@s.whatsapp.net":    ChatTypeDirect,
+		"123456789012345@lid":           ChatTypeDirect,
+		"120363000000000000@g.us":       ChatTypeGroup,
+		"120363000000000001@newsletter": ChatTypeNewsletter,
+		"1700000000@broadcast":          ChatTypeBroadcast,
+		"status@broadcast":              ChatTypeStatus,
+		"STATUS@Broadcast":              ChatTypeStatus,
+		"bot@bot":                       ChatTypeOther,
+	}
+	for raw, want := range cases {
+		if got := ChatType(raw); got != want {
+			t.Errorf("ChatType(%q) = %q, want %q", raw, got, want)
+		}
+	}
+	if !IsBroadcastList("1700000000@broadcast") || IsBroadcastList("status@broadcast") {
+		t.Error("IsBroadcastList should match broadcast lists but not the status broadcast")
+	}
+}
@@ -0,0 +1,207 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+const maxRecordedErrors = 20
+
+// Func performs a job's work, reporting through progress. It should return
+// promptly once ctx is cancelled.
+type Func func(ctx context.Context, progress *Progress) error
+
+// Manager runs background jobs and persists their state in the message store.
+type Manager struct {
+	store func() *storage.MessageStore
+
+	mu      sync.Mutex
+	running map[string]*Progress
+}
+
+// NewManager creates a job manager. store is consulted on every update so
+// jobs keep working when the message store is recreated.
+func NewManager(store func() *storage.MessageStore) *Manager {
+	return &Manager{
+		store:   store,
+		running: map[string]*Progress{},
+	}
+}
+
+// Progress is a running job's mutable state.
+type Progress struct {
+	manager *Manager
+	cancel  context.CancelFunc
+
+	mu     sync.Mutex
+	record storage.JobRecord
+}
+
+func newJobID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Start records a new job of the given kind and runs fn in the background.
+// subject identifies what the job operates on, for example a chat JID.
+func (m *Manager) Start(kind string, subject string, total int, fn Func) (storage.JobRecord, error) {
+	id, err := newJobID()
+	if err != nil {
+		return storage.JobRecord{}, err
+	}
+	now := time.Now().UTC()
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := &Progress{
+		manager: m,
+		cancel:  cancel,
+		record: storage.JobRecord{
+			ID:        id,
+			Kind:      kind,
+			Subject:   subject,
+			State:     storage.JobStateQueued,
+			Total:     total,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+	if err := m.persist(progress.record); err != nil {
+		cancel()
+		return storage.JobRecord{}, err
+	}
+
+	m.mu.Lock()
+	m.running[id] = progress
+	m.mu.Unlock()
+
+	go m.run(ctx, progress, fn)
+	return progress.Snapshot(), nil
+}
+
+func (m *Manager) run(ctx context.Context, progress *Progress, fn Func) {
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, progress.record.ID)
+		m.mu.Unlock()
+		progress.cancel()
+	}()
+
+	progress.update(func(record *storage.JobRecord) {
+		record.State = storage.JobStateRunning
+	})
+
+	err := fn(ctx, progress)
+	progress.update(func(record *storage.JobRecord) {
+		switch {
+		case errors.Is(err, context.Canceled) || ctx.Err() != nil:
+			record.State = storage.JobStateCancelled
+		case err != nil:
+			record.State = storage.JobStateFailed
+			record.Message = err.Error()
+		default:
+			record.State = storage.JobStateCompleted
+		}
+		finishedAt := time.Now().UTC()
+		record.FinishedAt = &finishedAt
+	})
+}
+
+// Cancel requests cancellation of a running job and reports whether it was running.
+func (m *Manager) Cancel(id string) bool {
+	m.mu.Lock()
+	progress := m.running[id]
+	m.mu.Unlock()
+	if progress == nil {
+		return false
+	}
+	progress.cancel()
+	return true
+}
+
+// Get returns the live state of a running job, falling back to the stored record.
+func (m *Manager) Get(id string) (*storage.JobRecord, error) {
+	m.mu.Lock()
+	progress := m.running[id]
+	m.mu.Unlock()
+	if progress != nil {
+		record := progress.Snapshot()
+		return &record, nil
+	}
+
+	store := m.store()
+	if store == nil {
+		return nil, fmt.Errorf("message store is not initialized")
+	}
+	return store.GetJob(id)
+}
+
+func (m *Manager) persist(record storage.JobRecord) error {
+	store := m.store()
+	if store == nil {
+		return fmt.Errorf("message store is not initialized")
+	}
+	return store.SaveJob(record)
+}
+
+// Snapshot returns a copy of the job's current state.
+func (p *Progress) Snapshot() storage.JobRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	record := p.record
+	record.Errors = append([]storage.JobError(nil), p.record.Errors...)
+	return record
+}
+
+func (p *Progress) update(mutate func(record *storage.JobRecord)) {
+	p.mu.Lock()
+	mutate(&p.record)
+	p.record.UpdatedAt = time.Now().UTC()
+	record := p.record
+	record.Errors = append([]storage.JobError(nil), p.record.Errors...)
+	p.mu.Unlock()
+
+	if err := p.manager.persist(record); err != nil {
+		fmt.Printf("Warning: failed to persist job %s: %v\n", record.ID, err)
+	}
+}
+
+// SetTotal updates the number of items the job expects to process.
+func (p *Progress) SetTotal(total int) {
+	p.update(func(record *storage.JobRecord) {
+		record.Total = total
+	})
+}
+
+// Succeeded records one successfully processed item.
+func (p *Progress) Succeeded() {
+	p.update(func(record *storage.JobRecord) {
+		record.Completed++
+	})
+}
+
+// Failed records one failed item. Only the first few errors are kept.
+func (p *Progress) Failed(item string, err error, code string) {
+	p.update(func(record *storage.JobRecord) {
+		record.Failed++
+		if len(record.Errors) < maxRecordedErrors {
+			record.Errors = append(record.Errors, storage.JobError{Item: item, Error: err.Error(), Code: code})
+		}
+	})
+}
+
+// SetMessage records a human-readable note on the job, such as a summary of
+// what it did.
+func (p *Progress) SetMessage(message string) {
+	p.update(func(record *storage.JobRecord) {
+		record.Message = message
+	})
+}
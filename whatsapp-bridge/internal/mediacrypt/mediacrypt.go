@@ -0,0 +1,358 @@
+// Package mediacrypt encrypts downloaded media files at rest.
+//
+// Each file gets its own random AES-256 key, stored in the file header
+// wrapped (AES-GCM) by a master key. The body is sealed in fixed-size
+// AES-GCM chunks so files are encrypted and decrypted as streams, and the
+// header, including the plaintext size, is authenticated with every chunk,
+// so a truncated or spliced file fails to decrypt. Files without the header
+// are plaintext from before encryption was enabled and are read as-is.
+package mediacrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"whatsapp-client/internal/secrets"
+)
+
+const (
+	magic     = "WBMEDIA1"
+	keyIDSize = 8
+	keySize   = 32
+	nonceSize = 12
+	tagSize   = 16
+	// headerSize is magic, master key ID, wrap nonce, wrapped file key and
+	// plaintext size.
+	headerSize = len(magic) + keyIDSize + nonceSize + keySize + tagSize + 8
+	chunkSize  = 64 * 1024
+)
+
+var (
+	// ErrNoKey is returned for an encrypted file when no configured master
+	// key matches the one it was written with.
+	ErrNoKey = errors.New("media file is encrypted with an unknown key")
+	// ErrCorrupt is returned when an encrypted file fails authentication.
+	ErrCorrupt = errors.New("encrypted media file is corrupt or truncated")
+)
+
+// Keyring holds the master key new files are encrypted with and older keys
+// that files may still be encrypted with.
+type Keyring struct {
+	primary masterKey
+	keys    map[[keyIDSize]byte]masterKey
+}
+
+type masterKey struct {
+	id  [keyIDSize]byte
+	key []byte
+}
+
+func newMasterKey(key []byte) (masterKey, error) {
+	if len(key) != keySize {
+		return masterKey{}, fmt.Errorf("media encryption key must be %d bytes, got %d", keySize, len(key))
+	}
+	sum := sha256.Sum256(append([]byte("whatsapp-bridge media key id:"), key...))
+	master := masterKey{key: append([]byte(nil), key...)}
+	copy(master.id[:], sum[:keyIDSize])
+	return master, nil
+}
+
+// NewKeyring returns a keyring that encrypts with primary and also decrypts
+// files written with any of previous.
+func NewKeyring(primary []byte, previous ...[]byte) (*Keyring, error) {
+	current, err := newMasterKey(primary)
+	if err != nil {
+		return nil, err
+	}
+	keyring := &Keyring{primary: current, keys: map[[keyIDSize]byte]masterKey{current.id: current}}
+	for _, key := range previous {
+		old, err := newMasterKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("previous %v", err)
+		}
+		keyring.keys[old.id] = old
+	}
+	return keyring, nil
+}
+
+// ParseKey decodes a base64 master key, such as `openssl rand -base64 32`.
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("media encryption key is not valid base64: %v", err)
+	}
+	return key, nil
+}
+
+// KeyringFromEnv loads WHATSAPP_MEDIA_ENCRYPTION_KEY and the comma-separated
+// WHATSAPP_MEDIA_ENCRYPTION_PREVIOUS_KEYS, either of which may come from a
+// file or secret store (see package secrets). It returns nil when encryption
+// is not configured.
+func KeyringFromEnv() (*Keyring, error) {
+	raw, err := secrets.Lookup("WHATSAPP_MEDIA_ENCRYPTION_KEY")
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	primary, err := ParseKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	previousKeys, err := secrets.Lookup("WHATSAPP_MEDIA_ENCRYPTION_PREVIOUS_KEYS")
+	if err != nil {
+		return nil, err
+	}
+	var previous [][]byte
+	for _, part := range strings.Split(previousKeys, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, err := ParseKey(part)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, key)
+	}
+	return NewKeyring(primary, previous...)
+}
+
+// Encrypt writes size bytes read from src to dst in encrypted form.
+func (k *Keyring) Encrypt(dst io.Writer, src io.Reader, size int64) error {
+	fileKey := make([]byte, keySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return err
+	}
+	wrap, err := newGCM(k.primary.key)
+	if err != nil {
+		return err
+	}
+	wrapNonce := make([]byte, nonceSize)
+	if _, err := rand.Read(wrapNonce); err != nil {
+		return err
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = append(header, k.primary.id[:]...)
+	header = append(header, wrapNonce...)
+	header = wrap.Seal(header, wrapNonce, fileKey, k.primary.id[:])
+	header = binary.BigEndian.AppendUint64(header, uint64(size))
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	body, err := newGCM(fileKey)
+	if err != nil {
+		return err
+	}
+	plain := make([]byte, chunkSize)
+	sealed := make([]byte, 0, chunkSize+tagSize)
+	var written int64
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(src, plain[:min(int64(chunkSize), size-written)])
+		if err != nil {
+			return fmt.Errorf("media file is shorter than %d bytes: %w", size, err)
+		}
+		written += int64(n)
+		sealed = body.Seal(sealed[:0], chunkNonce(counter), plain[:n], header)
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+		if written == size {
+			return nil
+		}
+	}
+}
+
+// EncryptFile encrypts the plaintext file at path in place, keeping its mode.
+// The file is replaced atomically, so readers see either version whole.
+func (k *Keyring) EncryptFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	target, err := os.CreateTemp(filepath.Dir(path), ".encrypt-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(target.Name())
+	if err := k.Encrypt(target, source, info.Size()); err != nil {
+		target.Close()
+		return err
+	}
+	if err := target.Sync(); err != nil {
+		target.Close()
+		return err
+	}
+	if err := target.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(target.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(target.Name(), path)
+}
+
+// Open returns a reader of the plaintext of the media file at path and its
+// plaintext size, decrypting it when it is encrypted. keys may be nil when
+// encryption is not configured; encrypted files then fail with ErrNoKey.
+func Open(path string, keys *Keyring) (io.ReadCloser, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		file.Close()
+		return nil, 0, err
+	}
+	if n < headerSize || !bytes.HasPrefix(header, []byte(magic)) {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			file.Close()
+			return nil, 0, err
+		}
+		return file, info.Size(), nil
+	}
+
+	reader, size, err := keys.newReader(file, header)
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, file}, size, nil
+}
+
+// IsEncrypted reports whether the file at path is an encrypted media file.
+func IsEncrypted(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	prefix := make([]byte, len(magic))
+	if _, err := io.ReadFull(file, prefix); err != nil {
+		return false, nil
+	}
+	return string(prefix) == magic, nil
+}
+
+func (k *Keyring) newReader(src io.Reader, header []byte) (io.Reader, int64, error) {
+	if k == nil {
+		return nil, 0, ErrNoKey
+	}
+	offset := len(magic)
+	var id [keyIDSize]byte
+	copy(id[:], header[offset:offset+keyIDSize])
+	offset += keyIDSize
+	master, ok := k.keys[id]
+	if !ok {
+		return nil, 0, ErrNoKey
+	}
+	wrap, err := newGCM(master.key)
+	if err != nil {
+		return nil, 0, err
+	}
+	wrapNonce := header[offset : offset+nonceSize]
+	offset += nonceSize
+	fileKey, err := wrap.Open(nil, wrapNonce, header[offset:offset+keySize+tagSize], id[:])
+	if err != nil {
+		return nil, 0, ErrCorrupt
+	}
+	offset += keySize + tagSize
+	size := int64(binary.BigEndian.Uint64(header[offset:]))
+	if size < 0 {
+		return nil, 0, ErrCorrupt
+	}
+	body, err := newGCM(fileKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &decryptReader{src: src, body: body, header: header, remaining: size}, size, nil
+}
+
+// decryptReader opens one chunk at a time and hands out its plaintext.
+type decryptReader struct {
+	src       io.Reader
+	body      cipher.AEAD
+	header    []byte
+	remaining int64
+	counter   uint64
+	sealed    []byte
+	plain     []byte
+	started   bool
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		// A zero-length file still has one empty chunk to authenticate.
+		if r.remaining == 0 && r.started {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *decryptReader) next() error {
+	want := min(int64(chunkSize), r.remaining)
+	if cap(r.sealed) < chunkSize+tagSize {
+		r.sealed = make([]byte, chunkSize+tagSize)
+	}
+	sealed := r.sealed[:want+tagSize]
+	if _, err := io.ReadFull(r.src, sealed); err != nil {
+		return ErrCorrupt
+	}
+	plain, err := r.body.Open(sealed[:0], chunkNonce(r.counter), sealed, r.header)
+	if err != nil {
+		return ErrCorrupt
+	}
+	r.counter++
+	r.started = true
+	r.remaining -= int64(len(plain))
+	r.plain = plain
+	return nil
+}
+
+func chunkNonce(counter uint64) []byte {
+	nonce := make([]byte, nonceSize)
+	binary.BigEndian.PutUint64(nonce[nonceSize-8:], counter)
+	return nonce
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
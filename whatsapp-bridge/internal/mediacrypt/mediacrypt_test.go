@@ -0,0 +1,144 @@
+package mediacrypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(fill byte) []byte {
+	return bytes.Repeat([]byte{fill}, keySize)
+}
+
+func writeEncrypted(t *testing.T, keys *Keyring, plaintext []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "media.bin")
+	if err := os.WriteFile(path, plaintext, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.EncryptFile(path); err != nil {
+		t.Fatalf("EncryptFile: %v", err)
+	}
+	return path
+}
+
+func readAll(t *testing.T, path string, keys *Keyring) ([]byte, int64, error) {
+	t.Helper()
+	reader, size, err := Open(path, keys)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	return data, size, err
+}
+
+func TestEncryptFileRoundTrip(t *testing.T) {
+	keys, err := NewKeyring(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, length := range []int{0, 1, chunkSize - 1, chunkSize, 2*chunkSize + 17} {
+		plaintext := make([]byte, length)
+		for i := range plaintext {
+			plaintext[i] = byte(i * 7)
+		}
+		path := writeEncrypted(t, keys, plaintext)
+
+		raw, _ := os.ReadFile(path)
+		if length > 16 && bytes.Contains(raw, plaintext[:16]) {
+			t.Fatalf("length %d: ciphertext contains plaintext", length)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+			t.Fatalf("length %d: mode = %v, want 0640", length, info.Mode().Perm())
+		}
+		if encrypted, _ := IsEncrypted(path); !encrypted {
+			t.Fatalf("length %d: expected file to be encrypted", length)
+		}
+
+		got, size, err := readAll(t, path, keys)
+		if err != nil {
+			t.Fatalf("length %d: %v", length, err)
+		}
+		if size != int64(length) || !bytes.Equal(got, plaintext) {
+			t.Fatalf("length %d: round trip mismatch (size %d, got %d bytes)", length, size, len(got))
+		}
+	}
+}
+
+func TestOpenPlaintextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, size, err := readAll(t, path, nil)
+	if err != nil || size != 5 || string(got) != "hello" {
+		t.Fatalf("got %q, %d, %v", got, size, err)
+	}
+}
+
+func TestOpenWithRotatedKeys(t *testing.T) {
+	old, _ := NewKeyring(testKey(1))
+	path := writeEncrypted(t, old, []byte("secret photo"))
+
+	rotated, err := NewKeyring(testKey(2), testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _, err := readAll(t, path, rotated); err != nil || string(got) != "secret photo" {
+		t.Fatalf("previous key: got %q, %v", got, err)
+	}
+
+	unrelated, _ := NewKeyring(testKey(3))
+	if _, _, err := Open(path, unrelated); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("unknown key: err = %v, want ErrNoKey", err)
+	}
+	if _, _, err := Open(path, nil); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("no keyring: err = %v, want ErrNoKey", err)
+	}
+}
+
+func TestOpenDetectsTampering(t *testing.T) {
+	keys, _ := NewKeyring(testKey(1))
+	plaintext := bytes.Repeat([]byte("x"), chunkSize+100)
+
+	flipped := writeEncrypted(t, keys, plaintext)
+	raw, _ := os.ReadFile(flipped)
+	raw[len(raw)-1] ^= 1
+	os.WriteFile(flipped, raw, 0o644)
+	if _, _, err := readAll(t, flipped, keys); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("flipped bit: err = %v, want ErrCorrupt", err)
+	}
+
+	truncated := writeEncrypted(t, keys, plaintext)
+	raw, _ = os.ReadFile(truncated)
+	os.WriteFile(truncated, raw[:headerSize+chunkSize+tagSize], 0o644)
+	if _, _, err := readAll(t, truncated, keys); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("truncated: err = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestKeyringFromEnv(t *testing.T) {
+	t.Setenv("WHATSAPP_MEDIA_ENCRYPTION_KEY", "")
+	if keys, err := KeyringFromEnv(); keys != nil || err != nil {
+		t.Fatalf("unset: got %v, %v", keys, err)
+	}
+
+	t.Setenv("WHATSAPP_MEDIA_ENCRYPTION_KEY", "c2hvcnQ=")
+	if _, err := KeyringFromEnv(); err == nil {
+		t.Fatal("expected a short key to be rejected")
+	}
+
+	t.Setenv("WHATSAPP_MEDIA_ENCRYPTION_KEY", "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=")
+	t.Setenv("WHATSAPP_MEDIA_ENCRYPTION_PREVIOUS_KEYS", " AgICAgICAgICAgICAgICAgICAgICAgICAgICAgICAgI= ,")
+	keys, err := KeyringFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.keys) != 2 {
+		t.Fatalf("expected primary and one previous key, got %d", len(keys.keys))
+	}
+}
@@ -0,0 +1,235 @@
+// Package secrets resolves secret configuration values from files, an HTTP
+// secret store such as Vault, or plain environment variables.
+//
+// For a secret named NAME, Lookup reads, in order:
+//
+//   - NAME_FILE: a file holding the value, such as a Docker or Kubernetes
+//     secret mount. The file is re-read whenever it changes.
+//   - NAME_URL: an HTTP(S) URL returning the value. Vault KV responses are
+//     understood; the URL fragment names the field (default "value"), e.g.
+//     https://vault:8200/v1/secret/data/bridge#jwt_secret. Any other
+//     response body is used as-is. Values are refetched after
+//     WHATSAPP_SECRETS_REFRESH_SECONDS (default 60), and the last good value
+//     is kept while the store is unreachable.
+//   - NAME itself.
+//
+// Callers look secrets up when they use them rather than once at startup,
+// so a rotated file or store value takes effect without a restart.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRefresh = time.Minute
+	fetchTimeout   = 10 * time.Second
+	// maxSecretBytes bounds how much of a file or response is read.
+	maxSecretBytes = 64 * 1024
+	// vaultTokenName is the secret holding the token sent to secret URLs as
+	// X-Vault-Token. It may itself come from a file.
+	vaultTokenName = "WHATSAPP_SECRETS_VAULT_TOKEN"
+)
+
+// Resolver looks secrets up and caches file and URL values.
+type Resolver struct {
+	Getenv  func(string) string
+	Client  *http.Client
+	Refresh time.Duration
+	Now     func() time.Time
+
+	mu    sync.Mutex
+	files map[string]fileValue
+	urls  map[string]urlValue
+}
+
+type fileValue struct {
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+type urlValue struct {
+	fetchedAt time.Time
+	value     string
+}
+
+// NewResolver returns a Resolver reading the process environment.
+func NewResolver() *Resolver {
+	refresh := defaultRefresh
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_SECRETS_REFRESH_SECONDS")); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds <= 0 {
+			fmt.Printf("Warning: invalid WHATSAPP_SECRETS_REFRESH_SECONDS=%q, using %d\n", raw, int(defaultRefresh/time.Second))
+		} else {
+			refresh = time.Duration(seconds) * time.Second
+		}
+	}
+	return &Resolver{
+		Getenv:  os.Getenv,
+		Client:  &http.Client{Timeout: fetchTimeout},
+		Refresh: refresh,
+		Now:     time.Now,
+	}
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultResolver *Resolver
+)
+
+// Lookup resolves name with the process-wide Resolver. A secret that is not
+// configured at all resolves to the empty string.
+func Lookup(name string) (string, error) {
+	defaultOnce.Do(func() { defaultResolver = NewResolver() })
+	return defaultResolver.Lookup(name)
+}
+
+// Lookup resolves name from NAME_FILE, NAME_URL or NAME, in that order.
+func (r *Resolver) Lookup(name string) (string, error) {
+	if path := strings.TrimSpace(r.Getenv(name + "_FILE")); path != "" {
+		return r.readFile(path)
+	}
+	if rawURL := strings.TrimSpace(r.Getenv(name + "_URL")); rawURL != "" {
+		return r.fetchURL(name, rawURL)
+	}
+	return strings.TrimSpace(r.Getenv(name)), nil
+}
+
+func (r *Resolver) readFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	r.mu.Lock()
+	cached, ok := r.files[path]
+	r.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.value, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxSecretBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	value := strings.TrimSpace(string(data))
+
+	r.mu.Lock()
+	if r.files == nil {
+		r.files = map[string]fileValue{}
+	}
+	r.files[path] = fileValue{modTime: info.ModTime(), size: info.Size(), value: value}
+	r.mu.Unlock()
+	return value, nil
+}
+
+func (r *Resolver) fetchURL(name, rawURL string) (string, error) {
+	r.mu.Lock()
+	cached, ok := r.urls[rawURL]
+	r.mu.Unlock()
+	if ok && r.Now().Sub(cached.fetchedAt) < r.Refresh {
+		return cached.value, nil
+	}
+
+	value, err := r.fetch(rawURL)
+	if err != nil {
+		if ok {
+			fmt.Printf("Warning: failed to refresh secret %s, keeping the previous value: %v\n", name, err)
+			return cached.value, nil
+		}
+		return "", fmt.Errorf("failed to fetch secret %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	if r.urls == nil {
+		r.urls = map[string]urlValue{}
+	}
+	r.urls[rawURL] = urlValue{fetchedAt: r.Now(), value: value}
+	r.mu.Unlock()
+	return value, nil
+}
+
+func (r *Resolver) fetch(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	field := parsed.Fragment
+	if field == "" {
+		field = "value"
+	}
+	parsed.Fragment = ""
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	// The token is read from a file or the environment only, never a URL.
+	token := strings.TrimSpace(r.Getenv(vaultTokenName))
+	if path := strings.TrimSpace(r.Getenv(vaultTokenName + "_FILE")); path != "" {
+		if token, err = r.readFile(path); err != nil {
+			return "", err
+		}
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSecretBytes))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("secret store returned status %d", resp.StatusCode)
+	}
+	return parseSecretBody(body, field)
+}
+
+// parseSecretBody extracts field from a Vault KV v2 ({"data":{"data":{...}}})
+// or v1 ({"data":{...}}) response, or returns a non-JSON body as-is.
+func parseSecretBody(body []byte, field string) (string, error) {
+	var envelope struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Data == nil {
+		return strings.TrimSpace(string(body)), nil
+	}
+	data := envelope.Data
+	if nested, ok := data["data"]; ok {
+		var inner map[string]json.RawMessage
+		if err := json.Unmarshal(nested, &inner); err == nil {
+			data = inner
+		}
+	}
+	raw, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("secret store response has no %q field", field)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("secret field %q is not a string", field)
+	}
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,127 @@
+package secrets
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testResolver(env map[string]string) *Resolver {
+	return &Resolver{
+		Getenv:  func(name string) string { return env[name] },
+		Client:  http.DefaultClient,
+		Refresh: time.Minute,
+		Now:     time.Now,
+	}
+}
+
+func TestLookupPrefersFileThenURLThenEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "from-url")
+	}))
+	defer server.Close()
+
+	env := map[string]string{"S": " from-env ", "S_URL": server.URL, "S_FILE": path}
+	resolver := testResolver(env)
+	for _, want := range []string{"from-file", "from-url", "from-env"} {
+		got, err := resolver.Lookup("S")
+		if err != nil || got != want {
+			t.Fatalf("Lookup = %q, %v; want %q", got, err, want)
+		}
+		if env["S_FILE"] != "" {
+			delete(env, "S_FILE")
+		} else {
+			delete(env, "S_URL")
+		}
+	}
+	if got, err := resolver.Lookup("MISSING"); got != "" || err != nil {
+		t.Fatalf("unset secret: got %q, %v", got, err)
+	}
+}
+
+func TestLookupRereadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(path, []byte("first"), 0o600)
+	resolver := testResolver(map[string]string{"S_FILE": path})
+	if got, _ := resolver.Lookup("S"); got != "first" {
+		t.Fatalf("got %q", got)
+	}
+
+	os.WriteFile(path, []byte("rotated"), 0o600)
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+	if got, _ := resolver.Lookup("S"); got != "rotated" {
+		t.Fatalf("expected the rotated value, got %q", got)
+	}
+
+	os.Remove(path)
+	if _, err := resolver.Lookup("S"); err == nil {
+		t.Fatal("expected a missing secret file to fail")
+	}
+}
+
+func TestLookupURLCachesAndKeepsLastGoodValue(t *testing.T) {
+	value, status, requests := "v1", http.StatusOK, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"data":{"data":{"jwt":%q},"metadata":{"version":3}}}`, value)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	resolver := testResolver(map[string]string{"S_URL": server.URL + "/v1/secret/data/bridge#jwt", vaultTokenName: "token"})
+	resolver.Now = func() time.Time { return now }
+
+	if got, err := resolver.Lookup("S"); err != nil || got != "v1" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	value = "v2"
+	if got, _ := resolver.Lookup("S"); got != "v1" || requests != 1 {
+		t.Fatalf("expected the cached value, got %q after %d requests", got, requests)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if got, _ := resolver.Lookup("S"); got != "v2" {
+		t.Fatalf("expected the refreshed value, got %q", got)
+	}
+
+	status = http.StatusServiceUnavailable
+	now = now.Add(2 * time.Minute)
+	if got, err := resolver.Lookup("S"); err != nil || got != "v2" {
+		t.Fatalf("expected the last good value while the store is down, got %q, %v", got, err)
+	}
+}
+
+func TestParseSecretBody(t *testing.T) {
+	tests := []struct {
+		body    string
+		field   string
+		want    string
+		wantErr bool
+	}{
+		{`{"data":{"data":{"value":"kv2"}}}`, "value", "kv2", false},
+		{`{"data":{"value":"kv1"}}`, "value", "kv1", false},
+		{`{"data":{"other":"x"}}`, "value", "", true},
+		{`{"data":{"value":5}}`, "value", "", true},
+		{"plain-secret\n", "value", "plain-secret", false},
+	}
+	for _, tc := range tests {
+		got, err := parseSecretBody([]byte(tc.body), tc.field)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Fatalf("parseSecretBody(%s) = %q, %v", tc.body, got, err)
+		}
+	}
+}
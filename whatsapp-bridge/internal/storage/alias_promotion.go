@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// aliasPromotionDelay is how long queued promotions wait so aliases learned
+// from a burst of messages are promoted together.
+const aliasPromotionDelay = 2 * time.Second
+
+// aliasPromotions collects the aliases waiting to be promoted, keyed by
+// canonical ID, and runs them in the background off the message hot path.
+type aliasPromotions struct {
+	mu      sync.Mutex
+	pending map[string]map[string]struct{}
+	timer   *time.Timer
+	closed  bool
+	// running is held while promotions run, so Close waits for them.
+	running sync.Mutex
+}
+
+// QueueAliasPromotion schedules rewriting stored sender and chat IDs in
+// aliases to canonicalID. Call it when StoreSenderAliases learned a mapping;
+// messages stored afterwards already resolve their sender through it.
+func (store *MessageStore) QueueAliasPromotion(canonicalID string, aliases []string) {
+	promotions := &store.promotions
+	promotions.mu.Lock()
+	defer promotions.mu.Unlock()
+	if promotions.closed {
+		return
+	}
+	if promotions.pending == nil {
+		promotions.pending = map[string]map[string]struct{}{}
+	}
+	queued := promotions.pending[canonicalID]
+	if queued == nil {
+		queued = map[string]struct{}{}
+		promotions.pending[canonicalID] = queued
+	}
+	for _, alias := range aliases {
+		queued[alias] = struct{}{}
+	}
+	if promotions.timer == nil {
+		promotions.timer = time.AfterFunc(aliasPromotionDelay, store.runAliasPromotions)
+	}
+}
+
+// runAliasPromotions promotes every queued canonical ID.
+func (store *MessageStore) runAliasPromotions() {
+	promotions := &store.promotions
+	promotions.running.Lock()
+	defer promotions.running.Unlock()
+	promotions.mu.Lock()
+	pending := promotions.pending
+	promotions.pending = nil
+	promotions.timer = nil
+	promotions.mu.Unlock()
+
+	for canonical, queued := range pending {
+		aliases := make([]string, 0, len(queued))
+		for alias := range queued {
+			aliases = append(aliases, alias)
+		}
+		if err := store.PromoteCanonicalSender(canonical, aliases); err != nil {
+			fmt.Printf("Warning: failed to promote sender aliases of %s: %v\n", canonical, err)
+		}
+		if err := store.PromoteCanonicalChat(canonical, aliases); err != nil {
+			fmt.Printf("Warning: failed to promote chat aliases of %s: %v\n", canonical, err)
+		}
+	}
+}
+
+// closeAliasPromotions runs the queued promotions now and stops queueing
+// more, so none are lost on shutdown.
+func (store *MessageStore) closeAliasPromotions() {
+	promotions := &store.promotions
+	promotions.mu.Lock()
+	promotions.closed = true
+	if promotions.timer != nil {
+		promotions.timer.Stop()
+	}
+	promotions.mu.Unlock()
+	store.runAliasPromotions()
+}
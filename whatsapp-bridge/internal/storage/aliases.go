@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// ErrAliasNotFound reports a split request for an alias that is not merged
+// into the given canonical ID.
+var ErrAliasNotFound = errors.New("alias is not merged into that canonical ID")
+
+// ensureAliasSplitsSchema creates the table recording alias merges that an
+// operator undid, so alias syncing does not merge them again.
+func ensureAliasSplitsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS sender_alias_splits (
+			alias_id TEXT NOT NULL,
+			canonical_id TEXT NOT NULL,
+			split_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (alias_id, canonical_id)
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure sender_alias_splits table: %v", err)
+	}
+	return nil
+}
+
+// SenderAlias is one alias-to-canonical mapping from sender_id_aliases.
+type SenderAlias struct {
+	AliasID     string
+	CanonicalID string
+	UpdatedAt   time.Time
+}
+
+// AliasQuery filters the alias table. Alias matches the whole identity the
+// alias was merged into, so its sibling aliases are returned too.
+type AliasQuery struct {
+	Canonical string
+	Alias     string
+	Limit     int
+}
+
+// GetSenderAliases lists alias mappings grouped by canonical ID.
+func (store *MessageStore) GetSenderAliases(query AliasQuery) ([]SenderAlias, error) {
+	var conditions []string
+	var args []interface{}
+	if canonical := jid.NormalizeUser(query.Canonical); canonical != "" {
+		conditions = append(conditions, "canonical_id = ?")
+		args = append(args, canonical)
+	}
+	if alias := jid.NormalizeUser(query.Alias); alias != "" {
+		conditions = append(conditions, "canonical_id IN (SELECT canonical_id FROM sender_id_aliases WHERE alias_id = ? OR canonical_id = ?)")
+		args = append(args, alias, alias)
+	}
+
+	stmt := "SELECT alias_id, canonical_id, updated_at FROM sender_id_aliases"
+	if len(conditions) > 0 {
+		stmt += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	stmt += " ORDER BY canonical_id, alias_id"
+	if query.Limit > 0 {
+		stmt += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := store.db.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []SenderAlias
+	for rows.Next() {
+		var alias SenderAlias
+		if err := rows.Scan(&alias.AliasID, &alias.CanonicalID, &alias.UpdatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+// splitAliases returns the aliases that were split away from a canonical ID.
+func (store *MessageStore) splitAliases(canonical string) (map[string]struct{}, error) {
+	stmt, err := store.prepared("SELECT alias_id FROM sender_alias_splits WHERE canonical_id = ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(canonical)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	split := map[string]struct{}{}
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		split[alias] = struct{}{}
+	}
+	return split, rows.Err()
+}
+
+// AliasSplit summarizes what SplitAlias reassigned.
+type AliasSplit struct {
+	CanonicalID        string
+	AliasID            string
+	MessagesReassigned int64
+	ChatMessagesMoved  int64
+}
+
+// SplitAlias undoes a wrong merge of aliasID into canonicalID. The alias becomes
+// its own identity, and messages whose raw_sender is the alias get it back as
+// sender. Inbound messages in the canonical direct chat move to a chat keyed by
+// the alias; outgoing messages and rows stored without raw_sender stay put.
+func (store *MessageStore) SplitAlias(canonicalID, aliasID string) (AliasSplit, error) {
+	canonical := jid.NormalizeUser(canonicalID)
+	alias := jid.NormalizeUser(aliasID)
+	if canonical == "" || alias == "" {
+		return AliasSplit{}, fmt.Errorf("canonical and alias IDs are required")
+	}
+	if canonical == alias {
+		return AliasSplit{}, fmt.Errorf("cannot split a canonical ID from itself")
+	}
+	result := AliasSplit{CanonicalID: canonical, AliasID: alias}
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return AliasSplit{}, err
+	}
+
+	var exists int
+	err = tx.QueryRow(
+		"SELECT 1 FROM sender_id_aliases WHERE alias_id = ? AND canonical_id = ?",
+		alias, canonical,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return AliasSplit{}, ErrAliasNotFound
+	}
+	if err != nil {
+		tx.Rollback()
+		return AliasSplit{}, err
+	}
+
+	now := normalizeToUTC(time.Now())
+	if _, err := tx.Exec(
+		"UPDATE sender_id_aliases SET canonical_id = ?, updated_at = ? WHERE alias_id = ?",
+		alias, now, alias,
+	); err != nil {
+		tx.Rollback()
+		return AliasSplit{}, err
+	}
+	if _, err := tx.Exec(
+		"INSERT OR REPLACE INTO sender_alias_splits (alias_id, canonical_id, split_at) VALUES (?, ?, ?)",
+		alias, canonical, now,
+	); err != nil {
+		tx.Rollback()
+		return AliasSplit{}, err
+	}
+
+	res, err := tx.Exec(
+		"UPDATE messages SET sender = ? WHERE sender = ? AND raw_sender = ?",
+		alias, canonical, alias,
+	)
+	if err != nil {
+		tx.Rollback()
+		return AliasSplit{}, err
+	}
+	result.MessagesReassigned, _ = res.RowsAffected()
+
+	if _, err := tx.Exec(
+		`UPDATE links SET sender = ?
+		WHERE sender = ? AND EXISTS (
+			SELECT 1 FROM messages m
+			WHERE m.id = links.message_id AND m.chat_jid = links.chat_jid AND m.sender = ?
+		)`,
+		alias, canonical, alias,
+	); err != nil {
+		tx.Rollback()
+		return AliasSplit{}, err
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO chats (jid, name, last_message_time, chat_type)
+		SELECT ?, NULL, MAX(timestamp), ? FROM messages
+		WHERE chat_jid = ? AND raw_sender = ? AND is_from_me = 0
+		HAVING COUNT(*) > 0
+		ON CONFLICT(jid) DO NOTHING`,
+		alias, jid.ChatTypeDirect, canonical, alias,
+	); err != nil {
+		tx.Rollback()
+		return AliasSplit{}, err
+	}
+
+	moved := "SELECT id FROM messages WHERE chat_jid = ? AND raw_sender = ? AND is_from_me = 0"
+	for _, stmt := range []string{
+		"UPDATE OR IGNORE links SET chat_jid = ? WHERE chat_jid = ? AND message_id IN (" + moved + ")",
+		"UPDATE OR IGNORE message_embeddings SET chat_jid = ? WHERE chat_jid = ? AND message_id IN (" + moved + ")",
+	} {
+		if _, err := tx.Exec(stmt, alias, canonical, canonical, alias); err != nil {
+			tx.Rollback()
+			return AliasSplit{}, err
+		}
+	}
+	res, err = tx.Exec(
+		"UPDATE OR IGNORE messages SET chat_jid = ? WHERE chat_jid = ? AND raw_sender = ? AND is_from_me = 0",
+		alias, canonical, alias,
+	)
+	if err != nil {
+		tx.Rollback()
+		return AliasSplit{}, err
+	}
+	result.ChatMessagesMoved, _ = res.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return AliasSplit{}, err
+	}
+	return result, nil
+}
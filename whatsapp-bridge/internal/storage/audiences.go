@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrAudienceNameTaken rejects an audience name another audience already uses.
+var ErrAudienceNameTaken = errors.New("audience name is already in use")
+
+// Audience is a named set of broadcast recipients: explicit members plus every
+// contact carrying any of Tags at send time.
+type Audience struct {
+	ID        int64
+	Name      string
+	Members   []string
+	Tags      []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ensureAudiencesSchema creates the audiences table.
+func ensureAudiencesSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS audiences (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			members TEXT NOT NULL,
+			tags TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure audiences table: %v", err)
+	}
+	return nil
+}
+
+const audienceColumns = "id, name, members, tags, created_at, updated_at"
+
+func scanAudience(scanner interface{ Scan(...interface{}) error }) (Audience, error) {
+	var audience Audience
+	var members, tags string
+	if err := scanner.Scan(&audience.ID, &audience.Name, &members, &tags, &audience.CreatedAt, &audience.UpdatedAt); err != nil {
+		return Audience{}, err
+	}
+	if err := json.Unmarshal([]byte(members), &audience.Members); err != nil {
+		return Audience{}, fmt.Errorf("failed to decode audience %d members: %v", audience.ID, err)
+	}
+	if err := json.Unmarshal([]byte(tags), &audience.Tags); err != nil {
+		return Audience{}, fmt.Errorf("failed to decode audience %d tags: %v", audience.ID, err)
+	}
+	return audience, nil
+}
+
+// normalizeAudienceMembers keeps each personal member once, in recipient JID
+// form, sorted.
+func normalizeAudienceMembers(members []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, member := range members {
+		recipient := normalizeRecipientJID(strings.TrimSpace(member))
+		if recipient != "" && !seen[recipient] {
+			seen[recipient] = true
+			normalized = append(normalized, recipient)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// SaveAudience creates an audience when audience.ID is 0 and otherwise
+// replaces the definition of an existing one, returning the stored audience.
+// It returns sql.ErrNoRows when the audience to update does not exist and
+// ErrAudienceNameTaken when another audience has the name.
+func (store *MessageStore) SaveAudience(audience Audience) (Audience, error) {
+	audience.Members = normalizeAudienceMembers(audience.Members)
+	if audience.Tags == nil {
+		audience.Tags = []string{}
+	}
+	members, err := json.Marshal(audience.Members)
+	if err != nil {
+		return Audience{}, err
+	}
+	tags, err := json.Marshal(audience.Tags)
+	if err != nil {
+		return Audience{}, err
+	}
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return Audience{}, err
+	}
+	defer tx.Rollback()
+
+	var existingID int64
+	err = tx.QueryRow("SELECT id FROM audiences WHERE name = ? AND id != ?", audience.Name, audience.ID).Scan(&existingID)
+	if err == nil {
+		return Audience{}, ErrAudienceNameTaken
+	}
+	if err != sql.ErrNoRows {
+		return Audience{}, err
+	}
+
+	now := time.Now().UTC()
+	if audience.ID == 0 {
+		result, err := tx.Exec(
+			"INSERT INTO audiences (name, members, tags, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+			audience.Name, string(members), string(tags), now, now,
+		)
+		if err != nil {
+			return Audience{}, err
+		}
+		if audience.ID, err = result.LastInsertId(); err != nil {
+			return Audience{}, err
+		}
+	} else {
+		result, err := tx.Exec(
+			"UPDATE audiences SET name = ?, members = ?, tags = ?, updated_at = ? WHERE id = ?",
+			audience.Name, string(members), string(tags), now, audience.ID,
+		)
+		if err != nil {
+			return Audience{}, err
+		}
+		if affected, err := result.RowsAffected(); err != nil {
+			return Audience{}, err
+		} else if affected == 0 {
+			return Audience{}, sql.ErrNoRows
+		}
+	}
+
+	saved, err := scanAudience(tx.QueryRow("SELECT "+audienceColumns+" FROM audiences WHERE id = ?", audience.ID))
+	if err != nil {
+		return Audience{}, err
+	}
+	return saved, tx.Commit()
+}
+
+// GetAudience returns an audience, or nil when it does not exist.
+func (store *MessageStore) GetAudience(id int64) (*Audience, error) {
+	audience, err := scanAudience(store.db.QueryRow("SELECT "+audienceColumns+" FROM audiences WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &audience, nil
+}
+
+// ListAudiences returns all audiences ordered by name.
+func (store *MessageStore) ListAudiences() ([]Audience, error) {
+	rows, err := store.db.Query("SELECT " + audienceColumns + " FROM audiences ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var audiences []Audience
+	for rows.Next() {
+		audience, err := scanAudience(rows)
+		if err != nil {
+			return nil, err
+		}
+		audiences = append(audiences, audience)
+	}
+	return audiences, rows.Err()
+}
+
+// DeleteAudience removes an audience and reports whether it existed. Campaigns
+// sent to it keep their recipient snapshots.
+func (store *MessageStore) DeleteAudience(id int64) (bool, error) {
+	result, err := store.db.Exec("DELETE FROM audiences WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ResolveAudience returns the recipients an audience currently stands for:
+// its members and every contact tagged with one of its tags, sorted.
+func (store *MessageStore) ResolveAudience(audience Audience) ([]string, error) {
+	recipients := append([]string(nil), audience.Members...)
+	if len(audience.Tags) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(audience.Tags)), ", ")
+		args := make([]interface{}, 0, len(audience.Tags))
+		for _, tag := range audience.Tags {
+			args = append(args, tag)
+		}
+		rows, err := store.db.Query("SELECT DISTINCT contact_id FROM contact_tags WHERE tag IN ("+placeholders+")", args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var contactID string
+			if err := rows.Scan(&contactID); err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, contactID)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return normalizeAudienceMembers(recipients), nil
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Benchmarks for the storage hot paths: live message writes, history sync
+// batches and the queries behind search. Run them with
+//
+//	go test ./internal/storage -run '^$' -bench . -benchmem
+//
+// and compare runs with benchstat to spot regressions.
+
+var benchWords = []string{
+	"invoice", "meeting", "tomorrow", "lunch", "project", "deadline", "photo",
+	"call", "weekend", "report", "thanks", "address", "payment", "flight",
+}
+
+func newBenchStore(b *testing.B) *MessageStore {
+	b.Helper()
+	b.Setenv("WHATSAPP_MESSAGE_STORE_MODE", "direct")
+	b.Setenv("WHATSAPP_MESSAGE_STORE_PERSISTENT_DIR", b.TempDir())
+	b.Setenv("WHATSAPP_RUNTIME_USER_SCOPE", "")
+	b.Setenv("WHATSAPP_RUNTIME_ECS_MODE", "false")
+	store, err := NewMessageStore()
+	if err != nil {
+		b.Fatalf("NewMessageStore: %v", err)
+	}
+	b.Cleanup(func() { store.Close() })
+	return store
+}
+
+func benchChatJID(i int) string {
+	return fmt.Sprintf("1555%07d@s.whatsapp.net", i)
+}
+
+func benchMessage(rng *rand.Rand, chatJID string, i int, at time.Time) StoredMessage {
+	content := ""
+	for w := 0; w < 8; w++ {
+		content += benchWords[rng.Intn(len(benchWords))] + " "
+	}
+	if i%20 == 0 {
+		content += "https://example.com/page/" + fmt.Sprint(i)
+	}
+	return StoredMessage{
+		ID:        fmt.Sprintf("BENCH%012d", i),
+		ChatJID:   chatJID,
+		Sender:    fmt.Sprintf("1444%07d", i%50),
+		Content:   content,
+		Timestamp: at,
+		IsFromMe:  i%3 == 0,
+	}
+}
+
+// seedBenchMessages stores perChat messages in each of chats chats.
+func seedBenchMessages(b *testing.B, store *MessageStore, chats, perChat int) {
+	b.Helper()
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := 0
+	for c := 0; c < chats; c++ {
+		chatJID := benchChatJID(c)
+		if err := store.StoreChat(chatJID, fmt.Sprintf("Chat %d", c), start); err != nil {
+			b.Fatalf("StoreChat: %v", err)
+		}
+		for m := 0; m < perChat; m++ {
+			msg := benchMessage(rng, chatJID, n, start.Add(time.Duration(n)*time.Minute))
+			if err := store.StoreMessage(msg); err != nil {
+				b.Fatalf("StoreMessage: %v", err)
+			}
+			n++
+		}
+	}
+}
+
+func BenchmarkStoreMessage(b *testing.B) {
+	store := newBenchStore(b)
+	chatJID := benchChatJID(0)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := store.StoreChat(chatJID, "Chat", start); err != nil {
+		b.Fatalf("StoreChat: %v", err)
+	}
+	rng := rand.New(rand.NewSource(1))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := store.StoreMessage(benchMessage(rng, chatJID, i, start.Add(time.Duration(i)*time.Second))); err != nil {
+			b.Fatalf("StoreMessage: %v", err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "msgs/s")
+}
+
+// BenchmarkStoreChat measures the chat upsert every live message triggers.
+func BenchmarkStoreChat(b *testing.B) {
+	store := newBenchStore(b)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := store.StoreChat(benchChatJID(i%100), "Chat", start.Add(time.Duration(i)*time.Second)); err != nil {
+			b.Fatalf("StoreChat: %v", err)
+		}
+	}
+}
+
+// BenchmarkStoreSenderAliases measures the alias upsert run for senders seen
+// under both a phone number and a LID.
+func BenchmarkStoreSenderAliases(b *testing.B) {
+	store := newBenchStore(b)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		canonical := fmt.Sprintf("1444%07d", i%50)
+		if _, err := store.StoreSenderAliases(canonical, []string{fmt.Sprintf("9%014d", i%50)}, start.Add(time.Duration(i)*time.Second)); err != nil {
+			b.Fatalf("StoreSenderAliases: %v", err)
+		}
+	}
+}
+
+// BenchmarkStoreMessageRestore measures re-storing known messages, as history
+// sync does for messages already received live.
+func BenchmarkStoreMessageRestore(b *testing.B) {
+	store := newBenchStore(b)
+	seedBenchMessages(b, store, 1, 1000)
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := i % 1000
+		if err := store.StoreMessage(benchMessage(rng, benchChatJID(0), n, start.Add(time.Duration(n)*time.Minute))); err != nil {
+			b.Fatalf("StoreMessage: %v", err)
+		}
+	}
+}
+
+// BenchmarkHistorySyncBatch stores one history sync batch per iteration: a
+// chat upsert per conversation followed by its messages, written directly or
+// through the ingest buffer as history sync does with WHATSAPP_INGEST_BUFFER.
+func BenchmarkHistorySyncBatch(b *testing.B) {
+	for _, buffered := range []bool{false, true} {
+		for _, size := range []struct{ chats, perChat int }{{10, 10}, {20, 50}} {
+			b.Run(fmt.Sprintf("buffered=%t/chats=%d/messages=%d", buffered, size.chats, size.perChat), func(b *testing.B) {
+				b.Setenv("WHATSAPP_INGEST_BUFFER", fmt.Sprint(buffered))
+				store := newBenchStore(b)
+				rng := rand.New(rand.NewSource(1))
+				start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+				n := 0
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					for c := 0; c < size.chats; c++ {
+						chatJID := benchChatJID(i*size.chats + c)
+						if err := store.StoreChat(chatJID, fmt.Sprintf("Chat %d", c), start); err != nil {
+							b.Fatalf("StoreChat: %v", err)
+						}
+						for m := 0; m < size.perChat; m++ {
+							if err := store.BufferMessage(benchMessage(rng, chatJID, n, start.Add(time.Duration(n)*time.Second))); err != nil {
+								b.Fatalf("BufferMessage: %v", err)
+							}
+							n++
+						}
+					}
+					if err := store.FlushIngestBuffer(); err != nil {
+						b.Fatalf("FlushIngestBuffer: %v", err)
+					}
+				}
+				b.ReportMetric(float64(n)/b.Elapsed().Seconds(), "msgs/s")
+			})
+		}
+	}
+}
+
+func BenchmarkGetMessages(b *testing.B) {
+	store := newBenchStore(b)
+	seedBenchMessages(b, store, 5, 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.GetMessages(benchChatJID(i%5), 50); err != nil {
+			b.Fatalf("GetMessages: %v", err)
+		}
+	}
+}
+
+// BenchmarkFindMessages measures keyword search through saved view filters.
+func BenchmarkFindMessages(b *testing.B) {
+	store := newBenchStore(b)
+	seedBenchMessages(b, store, 10, 500)
+	now := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, bench := range []struct {
+		name       string
+		definition ViewDefinition
+	}{
+		{"keywords", ViewDefinition{Keywords: "invoice deadline"}},
+		{"chat_keywords", ViewDefinition{ChatJIDs: []string{benchChatJID(3)}, Keywords: "payment"}},
+		{"sender_window", ViewDefinition{Sender: "14440000007", WithinDays: 30}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := store.FindMessages(bench.definition, now, 50); err != nil {
+					b.Fatalf("FindMessages: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetLinks(b *testing.B) {
+	store := newBenchStore(b)
+	seedBenchMessages(b, store, 10, 500)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.GetLinks(LinkQuery{Limit: 50}); err != nil {
+			b.Fatalf("GetLinks: %v", err)
+		}
+	}
+}
+
+// BenchmarkSemanticSearch measures hybrid search over stored embeddings.
+func BenchmarkSemanticSearch(b *testing.B) {
+	const dims = 64
+	store := newBenchStore(b)
+	seedBenchMessages(b, store, 4, 500)
+	rng := rand.New(rand.NewSource(2))
+	randomVector := func() []float32 {
+		vector := make([]float32, dims)
+		for d := range vector {
+			vector[d] = rng.Float32()*2 - 1
+		}
+		return vector
+	}
+	for n := 0; n < 2000; n++ {
+		if err := store.StoreMessageEmbedding(fmt.Sprintf("BENCH%012d", n), benchChatJID(n/500), "bench", randomVector()); err != nil {
+			b.Fatalf("StoreMessageEmbedding: %v", err)
+		}
+	}
+	query := SemanticQuery{Text: "invoice payment", Vector: randomVector(), Model: "bench", Limit: 20}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.SemanticSearch(query); err != nil {
+			b.Fatalf("SemanticSearch: %v", err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// ErrNotBroadcastList rejects broadcast list operations on other chats.
+var ErrNotBroadcastList = errors.New("chat is not a broadcast list")
+
+// BroadcastList is a stored broadcast list chat with its known recipients.
+type BroadcastList struct {
+	JID             string
+	Name            string
+	LastMessageTime time.Time
+	// Recipients holds recipient JIDs; empty when WhatsApp has not shared them.
+	Recipients []string
+	UpdatedAt  time.Time
+}
+
+// chatTypeExpr returns SQL computing the chat type of a chat ID column,
+// mirroring jid.ChatType.
+func chatTypeExpr(column string) string {
+	server := fmt.Sprintf("LOWER(SUBSTR(%[1]s, INSTR(%[1]s, '@') + 1))", column)
+	personalServers := fmt.Sprintf("'%s', '%s', '%s'", jid.UserServer, jid.LegacyUserServer, jid.LIDServer)
+	return fmt.Sprintf(`CASE
+			WHEN %[1]s IS NULL OR %[1]s = '' THEN '%[3]s'
+			WHEN INSTR(%[1]s, '@') = 0 OR %[2]s IN (%[4]s) THEN '%[5]s'
+			WHEN %[2]s = '%[6]s' THEN '%[7]s'
+			WHEN %[2]s = '%[8]s' THEN '%[9]s'
+			WHEN %[2]s = '%[10]s' AND LOWER(SUBSTR(%[1]s, 1, INSTR(%[1]s, '@') - 1)) = 'status' THEN '%[11]s'
+			WHEN %[2]s = '%[10]s' THEN '%[12]s'
+			ELSE '%[3]s'
+		END`,
+		column, server, jid.ChatTypeOther, personalServers, jid.ChatTypeDirect,
+		jid.GroupServer, jid.ChatTypeGroup, jid.NewsletterServer, jid.ChatTypeNewsletter,
+		jid.BroadcastServer, jid.ChatTypeStatus, jid.ChatTypeBroadcast)
+}
+
+// ensureBroadcastListsSchema creates the broadcast_list_recipients table.
+func ensureBroadcastListsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS broadcast_list_recipients (
+			list_jid TEXT NOT NULL,
+			recipient_jid TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (list_jid, recipient_jid)
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure broadcast_list_recipients table: %v", err)
+	}
+	return nil
+}
+
+// normalizeRecipientJID strips device suffixes and the legacy c.us server so a
+// recipient is stored once however it was reported.
+func normalizeRecipientJID(raw string) string {
+	user, server := jid.Split(raw)
+	if user == "" {
+		return ""
+	}
+	if server == "" {
+		server = jid.UserServer
+	}
+	return user + "@" + server
+}
+
+// ReplaceBroadcastRecipients replaces the stored recipients of a broadcast
+// list, creating its chat if no message has been seen yet. Only personal JIDs
+// are kept.
+func (store *MessageStore) ReplaceBroadcastRecipients(listJID string, recipients []string) error {
+	listID := jid.NormalizeChat(listJID)
+	if !jid.IsBroadcastList(listID) {
+		return ErrNotBroadcastList
+	}
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO chats (jid, chat_type) VALUES (?, ?) ON CONFLICT(jid) DO NOTHING",
+		listID, jid.ChatTypeBroadcast,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM broadcast_list_recipients WHERE list_jid = ?", listID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	now := normalizeToUTC(time.Now())
+	for _, recipient := range recipients {
+		if !jid.IsPersonal(recipient) {
+			continue
+		}
+		if _, err := tx.Exec(
+			"INSERT OR IGNORE INTO broadcast_list_recipients (list_jid, recipient_jid, updated_at) VALUES (?, ?, ?)",
+			listID, normalizeRecipientJID(recipient), now,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetBroadcastRecipients returns the stored recipient JIDs of a broadcast list.
+func (store *MessageStore) GetBroadcastRecipients(listJID string) ([]string, error) {
+	rows, err := store.db.Query(
+		"SELECT recipient_jid FROM broadcast_list_recipients WHERE list_jid = ? ORDER BY recipient_jid",
+		jid.NormalizeChat(listJID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []string
+	for rows.Next() {
+		var recipient string
+		if err := rows.Scan(&recipient); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, rows.Err()
+}
+
+// ListBroadcastLists returns broadcast list chats, most recently active first,
+// with their stored recipients.
+func (store *MessageStore) ListBroadcastLists() ([]BroadcastList, error) {
+	rows, err := store.db.Query(`
+		SELECT c.jid, COALESCE(c.name, ''), c.last_message_time, r.recipient_jid, r.updated_at
+		FROM chats c
+		LEFT JOIN broadcast_list_recipients r ON r.list_jid = c.jid
+		WHERE c.chat_type = ?
+		ORDER BY c.last_message_time DESC, c.jid, r.recipient_jid
+	`, jid.ChatTypeBroadcast)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lists []BroadcastList
+	index := map[string]int{}
+	for rows.Next() {
+		var (
+			listJID, name string
+			lastMessage   sql.NullTime
+			recipient     sql.NullString
+			updatedAt     sql.NullTime
+		)
+		if err := rows.Scan(&listJID, &name, &lastMessage, &recipient, &updatedAt); err != nil {
+			return nil, err
+		}
+		i, ok := index[listJID]
+		if !ok {
+			i = len(lists)
+			index[listJID] = i
+			lists = append(lists, BroadcastList{JID: listJID, Name: name, LastMessageTime: lastMessage.Time})
+		}
+		if recipient.Valid && strings.TrimSpace(recipient.String) != "" {
+			lists[i].Recipients = append(lists[i].Recipients, recipient.String)
+		}
+		if updatedAt.Valid && updatedAt.Time.After(lists[i].UpdatedAt) {
+			lists[i].UpdatedAt = updatedAt.Time
+		}
+	}
+	return lists, rows.Err()
+}
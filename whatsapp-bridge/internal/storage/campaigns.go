@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Campaign is one broadcast send, with the recipients it was addressed to
+// frozen when it was sent.
+type Campaign struct {
+	ID int64
+	// AudienceID and AudienceName record the audience the campaign targeted,
+	// if any; the name survives the audience being renamed or deleted.
+	AudienceID   int64
+	AudienceName string
+	Message      string
+	MediaPath    string
+	CreatedAt    time.Time
+	Recipients   []CampaignRecipient
+}
+
+// Campaign recipient statuses besides the message statuses a sent message
+// progresses through (sent, delivered, read, played).
+const (
+	CampaignStatusPending = "pending"
+	CampaignStatusFailed  = "failed"
+)
+
+// CampaignRecipient is one recipient of a campaign and the outcome of sending
+// to it: a message ID once sent, or the send error. Delivery progress comes
+// from the recipient's receipts for that message.
+type CampaignRecipient struct {
+	RecipientJID string
+	MessageID    string
+	Error        string
+	DeliveredAt  *time.Time
+	ReadAt       *time.Time
+	PlayedAt     *time.Time
+	// OptedOutAt is when the recipient replied with an opt-out keyword after
+	// the campaign.
+	OptedOutAt *time.Time
+}
+
+// Status is the furthest stage the recipient's copy reached.
+func (recipient CampaignRecipient) Status() string {
+	switch {
+	case recipient.Error != "":
+		return CampaignStatusFailed
+	case recipient.MessageID == "":
+		return CampaignStatusPending
+	case recipient.PlayedAt != nil:
+		return MessageStatusPlayed
+	case recipient.ReadAt != nil:
+		return MessageStatusRead
+	case recipient.DeliveredAt != nil:
+		return MessageStatusDelivered
+	default:
+		return MessageStatusSent
+	}
+}
+
+// CampaignReport counts a campaign's recipients by outcome. Delivered
+// includes recipients who read the message, and Read those who played it.
+type CampaignReport struct {
+	Recipients int
+	Pending    int
+	Failed     int
+	Sent       int
+	Delivered  int
+	Read       int
+	OptedOut   int
+}
+
+// SummarizeCampaign reports how far a campaign's recipients got.
+func SummarizeCampaign(recipients []CampaignRecipient) CampaignReport {
+	report := CampaignReport{Recipients: len(recipients)}
+	for _, recipient := range recipients {
+		if recipient.OptedOutAt != nil {
+			report.OptedOut++
+		}
+		switch recipient.Status() {
+		case CampaignStatusPending:
+			report.Pending++
+			continue
+		case CampaignStatusFailed:
+			report.Failed++
+			continue
+		case MessageStatusPlayed, MessageStatusRead:
+			report.Read++
+			report.Delivered++
+		case MessageStatusDelivered:
+			report.Delivered++
+		}
+		report.Sent++
+	}
+	return report
+}
+
+// ensureCampaignsSchema creates the campaigns and campaign_recipients tables.
+func ensureCampaignsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS campaigns (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			audience_id INTEGER,
+			audience_name TEXT,
+			message TEXT,
+			media_path TEXT,
+			created_at TIMESTAMP NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS campaign_recipients (
+			campaign_id INTEGER NOT NULL,
+			recipient_jid TEXT NOT NULL,
+			message_id TEXT,
+			error TEXT,
+			PRIMARY KEY (campaign_id, recipient_jid),
+			FOREIGN KEY (campaign_id) REFERENCES campaigns(id)
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure campaigns tables: %v", err)
+	}
+	return ensureTableColumns(db, "campaign_recipients", []schemaColumn{
+		{name: "opted_out_at", definition: "TIMESTAMP"},
+	})
+}
+
+// CreateCampaign stores a campaign with its recipient snapshot before anything
+// is sent, and returns it with its ID.
+func (store *MessageStore) CreateCampaign(campaign Campaign, recipients []string) (Campaign, error) {
+	campaign.CreatedAt = time.Now().UTC()
+	tx, err := store.db.Begin()
+	if err != nil {
+		return Campaign{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO campaigns (audience_id, audience_name, message, media_path, created_at)
+		VALUES (NULLIF(?, 0), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)`,
+		campaign.AudienceID, campaign.AudienceName, campaign.Message, campaign.MediaPath, campaign.CreatedAt,
+	)
+	if err != nil {
+		return Campaign{}, err
+	}
+	if campaign.ID, err = result.LastInsertId(); err != nil {
+		return Campaign{}, err
+	}
+	campaign.Recipients = make([]CampaignRecipient, 0, len(recipients))
+	for _, recipient := range recipients {
+		if _, err := tx.Exec(
+			"INSERT OR IGNORE INTO campaign_recipients (campaign_id, recipient_jid) VALUES (?, ?)",
+			campaign.ID, recipient,
+		); err != nil {
+			return Campaign{}, err
+		}
+		campaign.Recipients = append(campaign.Recipients, CampaignRecipient{RecipientJID: recipient})
+	}
+	return campaign, tx.Commit()
+}
+
+// RecordCampaignSends stores the outcome of sending to a campaign's recipients.
+func (store *MessageStore) RecordCampaignSends(campaignID int64, outcomes []CampaignRecipient) error {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, outcome := range outcomes {
+		if _, err := tx.Exec(
+			"UPDATE campaign_recipients SET message_id = NULLIF(?, ''), error = NULLIF(?, '') WHERE campaign_id = ? AND recipient_jid = ?",
+			outcome.MessageID, outcome.Error, campaignID, outcome.RecipientJID,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+const campaignColumns = "id, audience_id, COALESCE(audience_name, ''), COALESCE(message, ''), COALESCE(media_path, ''), created_at"
+
+func scanCampaign(scanner interface{ Scan(...interface{}) error }) (Campaign, error) {
+	var campaign Campaign
+	var audienceID sql.NullInt64
+	if err := scanner.Scan(&campaign.ID, &audienceID, &campaign.AudienceName, &campaign.Message, &campaign.MediaPath, &campaign.CreatedAt); err != nil {
+		return Campaign{}, err
+	}
+	campaign.AudienceID = audienceID.Int64
+	return campaign, nil
+}
+
+// ListCampaigns returns the most recent campaigns first, without their
+// recipients.
+func (store *MessageStore) ListCampaigns(limit int) ([]Campaign, error) {
+	rows, err := store.db.Query("SELECT "+campaignColumns+" FROM campaigns ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []Campaign
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+	return campaigns, rows.Err()
+}
+
+// GetCampaign returns a campaign with its recipients and their delivery
+// progress, or nil when it does not exist.
+func (store *MessageStore) GetCampaign(id int64) (*Campaign, error) {
+	campaign, err := scanCampaign(store.db.QueryRow("SELECT "+campaignColumns+" FROM campaigns WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.db.Query(
+		`SELECT cr.recipient_jid, COALESCE(cr.message_id, ''), COALESCE(cr.error, ''), cr.opted_out_at,
+			mr.delivered_at, mr.read_at, mr.played_at
+		FROM campaign_recipients cr
+		LEFT JOIN message_receipts mr ON mr.message_id = cr.message_id AND mr.recipient_id != ''
+		WHERE cr.campaign_id = ?
+		ORDER BY cr.recipient_jid`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaign.Recipients = []CampaignRecipient{}
+	for rows.Next() {
+		var recipient CampaignRecipient
+		var optedOutAt, deliveredAt, readAt, playedAt sql.NullTime
+		if err := rows.Scan(&recipient.RecipientJID, &recipient.MessageID, &recipient.Error, &optedOutAt, &deliveredAt, &readAt, &playedAt); err != nil {
+			return nil, err
+		}
+		// A recipient's linked devices may each report receipts; keep the
+		// earliest time every stage was reached.
+		if n := len(campaign.Recipients); n > 0 && campaign.Recipients[n-1].RecipientJID == recipient.RecipientJID {
+			last := &campaign.Recipients[n-1]
+			last.DeliveredAt = earliest(last.DeliveredAt, deliveredAt)
+			last.ReadAt = earliest(last.ReadAt, readAt)
+			last.PlayedAt = earliest(last.PlayedAt, playedAt)
+			continue
+		}
+		recipient.OptedOutAt = earliest(nil, optedOutAt)
+		recipient.DeliveredAt = earliest(nil, deliveredAt)
+		recipient.ReadAt = earliest(nil, readAt)
+		recipient.PlayedAt = earliest(nil, playedAt)
+		campaign.Recipients = append(campaign.Recipients, recipient)
+	}
+	return &campaign, rows.Err()
+}
+
+// RecordCampaignOptOut marks the recipient opted out of every campaign that
+// reached it before at, and returns how many campaigns that affected.
+func (store *MessageStore) RecordCampaignOptOut(chatJID string, at time.Time) (int64, error) {
+	result, err := store.db.Exec(
+		`UPDATE campaign_recipients SET opted_out_at = ?
+		WHERE recipient_jid = ? AND message_id IS NOT NULL AND opted_out_at IS NULL
+			AND campaign_id IN (SELECT id FROM campaigns WHERE created_at <= ?)`,
+		normalizeToUTC(at), normalizeRecipientJID(chatJID), normalizeToUTC(at),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
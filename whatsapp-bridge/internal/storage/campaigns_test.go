@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeCampaign(t *testing.T) {
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	recipients := []CampaignRecipient{
+		{RecipientJID: "1@s.whatsapp.net"},
+		{RecipientJID: "2@s.whatsapp.net", Error: "not on WhatsApp"},
+		{RecipientJID: "3@s.whatsapp.net", MessageID: "A"},
+		{RecipientJID: "4@s.whatsapp.net", MessageID: "B", DeliveredAt: &at},
+		{RecipientJID: "5@s.whatsapp.net", MessageID: "C", DeliveredAt: &at, ReadAt: &at, OptedOutAt: &at},
+		{RecipientJID: "6@s.whatsapp.net", MessageID: "D", PlayedAt: &at},
+	}
+	want := CampaignReport{Recipients: 6, Pending: 1, Failed: 1, Sent: 4, Delivered: 3, Read: 2, OptedOut: 1}
+	if got := SummarizeCampaign(recipients); got != want {
+		t.Fatalf("SummarizeCampaign = %+v, want %+v", got, want)
+	}
+
+	statuses := []string{CampaignStatusPending, CampaignStatusFailed, MessageStatusSent, MessageStatusDelivered, MessageStatusRead, MessageStatusPlayed}
+	for i, recipient := range recipients {
+		if got := recipient.Status(); got != statuses[i] {
+			t.Errorf("%s: Status = %q, want %q", recipient.RecipientJID, got, statuses[i])
+		}
+	}
+}
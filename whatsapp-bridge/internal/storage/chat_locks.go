@@ -0,0 +1,43 @@
+package storage
+
+import "sync"
+
+// chatLocks serializes writes to the same chat, so the history and live
+// handlers racing on one chat row apply their read-then-write steps one at a
+// time. Locks are dropped once no writer holds or waits for them.
+type chatLocks struct {
+	mu    sync.Mutex
+	locks map[string]*chatLock
+}
+
+type chatLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock blocks until the caller holds chatJID's lock and returns the function
+// releasing it.
+func (l *chatLocks) lock(chatJID string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*chatLock)
+	}
+	entry, ok := l.locks[chatJID]
+	if !ok {
+		entry = &chatLock{}
+		l.locks[chatJID] = entry
+	}
+	entry.refs++
+	l.mu.Unlock()
+
+	entry.Lock()
+	return func() {
+		entry.Unlock()
+		l.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(l.locks, chatJID)
+		}
+		l.mu.Unlock()
+	}
+}
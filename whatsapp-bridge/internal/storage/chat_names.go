@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// placeholderChatNameSQL matches chats stored without a real name: none at
+// all, the bare ID a direct chat falls back to, or the "Group <id>" name a
+// group gets when its info could not be fetched.
+const placeholderChatNameSQL = `(COALESCE(name, '') = '' OR name = jid OR name = 'Group ' || substr(jid, 1, instr(jid, '@') - 1))`
+
+// isPlaceholderChatName reports whether name is a placeholder for chatJID,
+// mirroring placeholderChatNameSQL.
+func isPlaceholderChatName(chatJID, name string) bool {
+	if name == "" || name == chatJID {
+		return true
+	}
+	user, _, ok := strings.Cut(chatJID, "@")
+	return ok && name == "Group "+user
+}
+
+// ListPlaceholderChatJIDs returns the chats of the given type (see
+// jid.ChatType) still stored under a placeholder name.
+func (store *MessageStore) ListPlaceholderChatJIDs(chatType string) ([]string, error) {
+	rows, err := store.db.Query(`SELECT jid FROM chats WHERE chat_type = ? AND `+placeholderChatNameSQL+` ORDER BY jid`, chatType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chats []string
+	for rows.Next() {
+		var chatJID string
+		if err := rows.Scan(&chatJID); err != nil {
+			return nil, err
+		}
+		chats = append(chats, chatJID)
+	}
+	return chats, rows.Err()
+}
+
+// UpdateChatNameIfPlaceholder names a chat still stored under a placeholder
+// name, leaving real names and local overrides alone, and reports whether it
+// did.
+func (store *MessageStore) UpdateChatNameIfPlaceholder(chatJID, name string) (bool, error) {
+	if name == "" {
+		return false, nil
+	}
+	unlock := store.chatLocks.lock(chatJID)
+	defer unlock()
+
+	result, err := store.db.Exec(
+		`UPDATE chats SET name = ? WHERE jid = ? AND `+placeholderChatNameSQL+`
+		AND jid NOT IN (SELECT chat_jid FROM chat_name_overrides)`,
+		name, chatJID,
+	)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	return updated > 0, err
+}
+
+// ChatNameOverride is a display name set locally for a chat. It takes
+// precedence over names synced from WhatsApp, which are kept as SyncedName so
+// clearing the override restores them.
+type ChatNameOverride struct {
+	ChatJID    string
+	Name       string
+	SyncedName string
+	UpdatedAt  time.Time
+}
+
+// ensureChatNameOverridesSchema creates the chat_name_overrides table.
+func ensureChatNameOverridesSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS chat_name_overrides (
+			chat_jid TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			synced_name TEXT,
+			updated_at TIMESTAMP NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure chat_name_overrides table: %v", err)
+	}
+	return nil
+}
+
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// overriddenChatName returns the name to store for a chat given a synced
+// name: the chat's override when it has one, recording the synced name
+// behind it, or else the synced name itself.
+func overriddenChatName(db rowQuerier, chatJID, syncedName string) (string, error) {
+	// Synced writes echo the stored name, which is the override itself.
+	var override string
+	err := db.QueryRow(
+		`UPDATE chat_name_overrides
+		SET synced_name = CASE WHEN name = ?1 THEN synced_name ELSE ?1 END
+		WHERE chat_jid = ?2
+		RETURNING name`,
+		syncedName, chatJID,
+	).Scan(&override)
+	if err == sql.ErrNoRows {
+		return syncedName, nil
+	}
+	return override, err
+}
+
+// GetChatNameOverride returns a chat's name override, or nil if it has none.
+func (store *MessageStore) GetChatNameOverride(chatJID string) (*ChatNameOverride, error) {
+	override := ChatNameOverride{ChatJID: jid.NormalizeChat(chatJID)}
+	err := store.db.QueryRow(
+		`SELECT name, COALESCE(synced_name, ''), updated_at FROM chat_name_overrides WHERE chat_jid = ?`,
+		override.ChatJID,
+	).Scan(&override.Name, &override.SyncedName, &override.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// SetChatNameOverride sets the display name of a stored chat, replacing any
+// earlier override. It returns sql.ErrNoRows if the chat is not stored.
+func (store *MessageStore) SetChatNameOverride(chatJID, name string) (*ChatNameOverride, error) {
+	normalized := jid.NormalizeChat(chatJID)
+	unlock := store.chatLocks.lock(normalized)
+	defer unlock()
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var syncedName sql.NullString
+	if err := tx.QueryRow(`SELECT name FROM chats WHERE jid = ?`, normalized).Scan(&syncedName); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO chat_name_overrides (chat_jid, name, synced_name, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET name = excluded.name, updated_at = excluded.updated_at`,
+		normalized, name, syncedName.String, time.Now().UTC(),
+	); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE chats SET name = ? WHERE jid = ?`, name, normalized); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return store.GetChatNameOverride(normalized)
+}
+
+// ClearChatNameOverride removes a chat's name override, restoring and
+// returning the last synced name. It returns sql.ErrNoRows if the chat has no
+// override.
+func (store *MessageStore) ClearChatNameOverride(chatJID string) (string, error) {
+	normalized := jid.NormalizeChat(chatJID)
+	unlock := store.chatLocks.lock(normalized)
+	defer unlock()
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return "", err
+	}
+
+	var syncedName sql.NullString
+	if err := tx.QueryRow(`SELECT synced_name FROM chat_name_overrides WHERE chat_jid = ?`, normalized).Scan(&syncedName); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	if _, err := tx.Exec(`UPDATE chats SET name = ? WHERE jid = ?`, syncedName.String, normalized); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	if _, err := tx.Exec(`DELETE FROM chat_name_overrides WHERE chat_jid = ?`, normalized); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	return syncedName.String, tx.Commit()
+}
@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// ChatSettings holds per-chat automation policy. Chats without a row use the
+// zero value, which allows sending.
+type ChatSettings struct {
+	ChatJID string
+	// ReadOnly marks an observe-only chat that automated sends must never post to.
+	ReadOnly bool
+	// HumanHandoff pauses automated sends while a person handles the chat.
+	HumanHandoff bool
+	HandoffBy    string
+	HandoffAt    *time.Time
+	// Tracked opts the chat into storage when only allowlisted chats are kept.
+	Tracked bool
+	// SnoozedUntil keeps the chat out of needs-attention listings until then,
+	// or until a new incoming message arrives.
+	SnoozedUntil *time.Time
+	UpdatedAt    time.Time
+}
+
+// ensureChatSettingsSchema creates the chat_settings table.
+func ensureChatSettingsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS chat_settings (
+			chat_jid TEXT PRIMARY KEY,
+			read_only BOOLEAN NOT NULL DEFAULT 0,
+			human_handoff BOOLEAN NOT NULL DEFAULT 0,
+			handoff_by TEXT,
+			handoff_at TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure chat_settings table: %v", err)
+	}
+	return ensureTableColumns(db, "chat_settings", []schemaColumn{
+		{name: "human_handoff", definition: "BOOLEAN NOT NULL DEFAULT 0"},
+		{name: "handoff_by", definition: "TEXT"},
+		{name: "handoff_at", definition: "TIMESTAMP"},
+		{name: "tracked", definition: "BOOLEAN NOT NULL DEFAULT 0"},
+		{name: "snoozed_until", definition: "TIMESTAMP"},
+	})
+}
+
+const chatSettingsColumns = "chat_jid, read_only, human_handoff, COALESCE(handoff_by, ''), handoff_at, tracked, snoozed_until, updated_at"
+
+func scanChatSettings(scanner interface{ Scan(...interface{}) error }) (ChatSettings, error) {
+	var settings ChatSettings
+	var handoffAt, snoozedUntil sql.NullTime
+	if err := scanner.Scan(&settings.ChatJID, &settings.ReadOnly, &settings.HumanHandoff, &settings.HandoffBy, &handoffAt, &settings.Tracked, &snoozedUntil, &settings.UpdatedAt); err != nil {
+		return ChatSettings{}, err
+	}
+	if handoffAt.Valid {
+		settings.HandoffAt = &handoffAt.Time
+	}
+	if snoozedUntil.Valid {
+		settings.SnoozedUntil = &snoozedUntil.Time
+	}
+	return settings, nil
+}
+
+// GetChatSettings returns the settings for a chat, defaulting when none are stored.
+func (store *MessageStore) GetChatSettings(chatJID string) (ChatSettings, error) {
+	normalized := jid.NormalizeChat(chatJID)
+	settings, err := scanChatSettings(store.db.QueryRow(
+		"SELECT "+chatSettingsColumns+" FROM chat_settings WHERE chat_jid = ?",
+		normalized,
+	))
+	if err == sql.ErrNoRows {
+		return ChatSettings{ChatJID: normalized}, nil
+	}
+	return settings, err
+}
+
+// SetChatReadOnly marks a chat as observe-only, or clears the mark.
+func (store *MessageStore) SetChatReadOnly(chatJID string, readOnly bool) (ChatSettings, error) {
+	normalized := jid.NormalizeChat(chatJID)
+	if normalized == "" {
+		return ChatSettings{}, fmt.Errorf("chat JID is required")
+	}
+	if _, err := store.db.Exec(
+		`INSERT INTO chat_settings (chat_jid, read_only, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET
+			read_only = excluded.read_only,
+			updated_at = excluded.updated_at`,
+		normalized, readOnly, normalizeToUTC(time.Now()),
+	); err != nil {
+		return ChatSettings{}, err
+	}
+	return store.GetChatSettings(normalized)
+}
+
+// SetChatTracking opts a chat into storage under allowlist mode, or out of it.
+func (store *MessageStore) SetChatTracking(chatJID string, tracked bool) (ChatSettings, error) {
+	normalized := jid.NormalizeChat(chatJID)
+	if normalized == "" {
+		return ChatSettings{}, fmt.Errorf("chat JID is required")
+	}
+	if _, err := store.db.Exec(
+		`INSERT INTO chat_settings (chat_jid, tracked, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET
+			tracked = excluded.tracked,
+			updated_at = excluded.updated_at`,
+		normalized, tracked, normalizeToUTC(time.Now()),
+	); err != nil {
+		return ChatSettings{}, err
+	}
+	return store.GetChatSettings(normalized)
+}
+
+// IsChatTracked reports whether a chat has opted into storage.
+func (store *MessageStore) IsChatTracked(chatJID string) (bool, error) {
+	var tracked bool
+	err := store.db.QueryRow("SELECT tracked FROM chat_settings WHERE chat_jid = ?", chatJID).Scan(&tracked)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return tracked, err
+}
+
+// SetChatHandoff starts a human takeover of a chat, recording who took it
+// over, or clears the takeover so automated sends resume.
+func (store *MessageStore) SetChatHandoff(chatJID string, active bool, by string) (ChatSettings, error) {
+	normalized := jid.NormalizeChat(chatJID)
+	if normalized == "" {
+		return ChatSettings{}, fmt.Errorf("chat JID is required")
+	}
+	now := normalizeToUTC(time.Now())
+	var handoffBy, handoffAt interface{}
+	if active {
+		handoffBy = strings.TrimSpace(by)
+		handoffAt = now
+	}
+	if _, err := store.db.Exec(
+		`INSERT INTO chat_settings (chat_jid, human_handoff, handoff_by, handoff_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET
+			human_handoff = excluded.human_handoff,
+			handoff_by = excluded.handoff_by,
+			handoff_at = excluded.handoff_at,
+			updated_at = excluded.updated_at`,
+		normalized, active, handoffBy, handoffAt, now,
+	); err != nil {
+		return ChatSettings{}, err
+	}
+	return store.GetChatSettings(normalized)
+}
+
+// SetChatSnooze snoozes a chat until the given time, or clears the snooze
+// when until is nil.
+func (store *MessageStore) SetChatSnooze(chatJID string, until *time.Time) (ChatSettings, error) {
+	normalized := jid.NormalizeChat(chatJID)
+	if normalized == "" {
+		return ChatSettings{}, fmt.Errorf("chat JID is required")
+	}
+	var snoozedUntil interface{}
+	if until != nil {
+		snoozedUntil = normalizeToUTC(*until)
+	}
+	if _, err := store.db.Exec(
+		`INSERT INTO chat_settings (chat_jid, snoozed_until, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET
+			snoozed_until = excluded.snoozed_until,
+			updated_at = excluded.updated_at`,
+		normalized, snoozedUntil, normalizeToUTC(time.Now()),
+	); err != nil {
+		return ChatSettings{}, err
+	}
+	return store.GetChatSettings(normalized)
+}
+
+// WakeSnoozedChat clears a chat's snooze, if it has one, and returns when the
+// snooze was due to end.
+func (store *MessageStore) WakeSnoozedChat(chatJID string) (*time.Time, error) {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var snoozedUntil time.Time
+	err = tx.QueryRow(
+		"SELECT snoozed_until FROM chat_settings WHERE chat_jid = ? AND snoozed_until IS NOT NULL",
+		chatJID,
+	).Scan(&snoozedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(
+		"UPDATE chat_settings SET snoozed_until = NULL, updated_at = ? WHERE chat_jid = ?",
+		normalizeToUTC(time.Now()), chatJID,
+	); err != nil {
+		return nil, err
+	}
+	return &snoozedUntil, tx.Commit()
+}
+
+// ExpireChatSnoozes clears every snooze due at or before now and returns the
+// chats woken, with the snooze end they had.
+func (store *MessageStore) ExpireChatSnoozes(now time.Time) ([]ChatSettings, error) {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		"SELECT chat_jid, snoozed_until FROM chat_settings WHERE snoozed_until IS NOT NULL AND snoozed_until <= ?",
+		normalizeToUTC(now),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var woken []ChatSettings
+	for rows.Next() {
+		var settings ChatSettings
+		var snoozedUntil time.Time
+		if err := rows.Scan(&settings.ChatJID, &snoozedUntil); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		settings.SnoozedUntil = &snoozedUntil
+		woken = append(woken, settings)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+	if len(woken) == 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE chat_settings SET snoozed_until = NULL, updated_at = ? WHERE snoozed_until IS NOT NULL AND snoozed_until <= ?",
+		normalizeToUTC(time.Now()), normalizeToUTC(now),
+	); err != nil {
+		return nil, err
+	}
+	return woken, tx.Commit()
+}
+
+// ListChatSettings returns every chat with stored settings.
+func (store *MessageStore) ListChatSettings() ([]ChatSettings, error) {
+	rows, err := store.db.Query("SELECT " + chatSettingsColumns + " FROM chat_settings ORDER BY chat_jid")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settings []ChatSettings
+	for rows.Next() {
+		entry, err := scanChatSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, entry)
+	}
+	return settings, rows.Err()
+}
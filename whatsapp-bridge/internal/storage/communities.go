@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// CommunityGroup is a community parent or a group linked to one.
+type CommunityGroup struct {
+	GroupJID  string
+	ParentJID string
+	Name      string
+	// IsCommunity marks the community parent itself, which cannot be messaged.
+	IsCommunity bool
+	// IsAnnouncement marks the community's default announcement group.
+	IsAnnouncement bool
+	UpdatedAt      time.Time
+}
+
+// Community is a community parent with its linked groups.
+type Community struct {
+	JID             string
+	Name            string
+	AnnouncementJID string
+	Groups          []CommunityGroup
+}
+
+// ensureCommunitiesSchema creates the community_groups table.
+func ensureCommunitiesSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS community_groups (
+			group_jid TEXT PRIMARY KEY,
+			parent_jid TEXT,
+			name TEXT,
+			is_community BOOLEAN NOT NULL DEFAULT 0,
+			is_announcement BOOLEAN NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_community_groups_parent ON community_groups(parent_jid);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure community_groups table: %v", err)
+	}
+	return nil
+}
+
+// ReplaceCommunityGroups replaces the stored community structure with a fresh
+// snapshot of the account's joined groups.
+func (store *MessageStore) ReplaceCommunityGroups(groups []CommunityGroup) error {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM community_groups"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO community_groups
+		(group_jid, parent_jid, name, is_community, is_announcement, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	now := normalizeToUTC(time.Now())
+	for _, group := range groups {
+		var parent interface{}
+		if group.ParentJID != "" {
+			parent = jid.NormalizeChat(group.ParentJID)
+		}
+		if _, err := stmt.Exec(jid.NormalizeChat(group.GroupJID), parent, group.Name, group.IsCommunity, group.IsAnnouncement, now); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListCommunities returns every known community with its linked groups, the
+// announcement group first.
+func (store *MessageStore) ListCommunities() ([]Community, error) {
+	rows, err := store.db.Query(`
+		SELECT c.group_jid, COALESCE(c.name, ''), g.group_jid, COALESCE(g.name, ''), g.is_announcement, g.updated_at
+		FROM community_groups c
+		LEFT JOIN community_groups g ON g.parent_jid = c.group_jid
+		WHERE c.is_community = 1
+		ORDER BY c.name, c.group_jid, g.is_announcement DESC, g.name, g.group_jid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var communities []Community
+	for rows.Next() {
+		var communityJID, communityName string
+		var groupJID, groupName sql.NullString
+		var isAnnouncement sql.NullBool
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&communityJID, &communityName, &groupJID, &groupName, &isAnnouncement, &updatedAt); err != nil {
+			return nil, err
+		}
+		if len(communities) == 0 || communities[len(communities)-1].JID != communityJID {
+			communities = append(communities, Community{JID: communityJID, Name: communityName})
+		}
+		if !groupJID.Valid {
+			continue
+		}
+		community := &communities[len(communities)-1]
+		group := CommunityGroup{
+			GroupJID:       groupJID.String,
+			ParentJID:      communityJID,
+			Name:           groupName.String,
+			IsAnnouncement: isAnnouncement.Bool,
+			UpdatedAt:      updatedAt.Time,
+		}
+		if group.IsAnnouncement {
+			community.AnnouncementJID = group.GroupJID
+		}
+		community.Groups = append(community.Groups, group)
+	}
+	return communities, rows.Err()
+}
+
+// CommunityAnnouncementJID returns the announcement group of a community
+// parent, or "" when chatJID is not a known community.
+func (store *MessageStore) CommunityAnnouncementJID(chatJID string) (string, error) {
+	var announcement string
+	err := store.db.QueryRow(
+		`SELECT g.group_jid FROM community_groups c
+		JOIN community_groups g ON g.parent_jid = c.group_jid AND g.is_announcement = 1
+		WHERE c.group_jid = ? AND c.is_community = 1
+		LIMIT 1`,
+		jid.NormalizeChat(chatJID),
+	).Scan(&announcement)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return announcement, err
+}
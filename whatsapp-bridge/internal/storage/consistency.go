@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"database/sql"
+
+	"whatsapp-client/internal/jid"
+)
+
+// MissingChat is a chat ID that messages are stored under without a chats row.
+type MissingChat struct {
+	ChatJID      string
+	MessageCount int
+}
+
+// orphanedChatsSQL selects chats left behind by canonical promotion: chats
+// without messages whose ID is now an alias of another identity.
+const orphanedChatsSQL = `SELECT c.jid FROM chats c
+	WHERE NOT EXISTS (SELECT 1 FROM messages m WHERE m.chat_jid = c.jid)
+		AND EXISTS (SELECT 1 FROM sender_id_aliases a WHERE a.alias_id = c.jid AND a.canonical_id <> c.jid)`
+
+// FindMessagesWithoutChats lists chat IDs that have messages but no chat row.
+func (store *MessageStore) FindMessagesWithoutChats() ([]MissingChat, error) {
+	return findMessagesWithoutChats(store.db)
+}
+
+func findMessagesWithoutChats(db *sql.DB) ([]MissingChat, error) {
+	rows, err := db.Query(`
+		SELECT m.chat_jid, COUNT(*) FROM messages m
+		WHERE m.chat_jid IS NOT NULL AND NOT EXISTS (SELECT 1 FROM chats c WHERE c.jid = m.chat_jid)
+		GROUP BY m.chat_jid
+		ORDER BY m.chat_jid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var missing []MissingChat
+	for rows.Next() {
+		var chat MissingChat
+		if err := rows.Scan(&chat.ChatJID, &chat.MessageCount); err != nil {
+			return nil, err
+		}
+		missing = append(missing, chat)
+	}
+	return missing, rows.Err()
+}
+
+// RecreateMissingChats adds a chat row, without a name, for every chat ID
+// that has messages but no row, and returns how many it added. Names are
+// filled in as contacts and history sync name the chats.
+func (store *MessageStore) RecreateMissingChats() (int, error) {
+	missing, err := store.FindMessagesWithoutChats()
+	if err != nil {
+		return 0, err
+	}
+	return recreateMissingChats(store.db, missing)
+}
+
+func recreateMissingChats(db *sql.DB, missing []MissingChat) (int, error) {
+	created := 0
+	for _, chat := range missing {
+		result, err := db.Exec(
+			`INSERT INTO chats (jid, name, last_message_time, chat_type)
+			SELECT ?, NULL, MAX(timestamp), ? FROM messages WHERE chat_jid = ?
+			ON CONFLICT(jid) DO NOTHING`,
+			chat.ChatJID, jid.ChatType(chat.ChatJID), chat.ChatJID,
+		)
+		if err != nil {
+			return created, err
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			created += int(affected)
+		}
+	}
+	return created, nil
+}
+
+// FindOrphanedChats lists chats without messages whose ID was merged into
+// another identity, which canonical promotion left behind.
+func (store *MessageStore) FindOrphanedChats() ([]string, error) {
+	rows, err := store.db.Query(orphanedChatsSQL + ` ORDER BY c.jid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chats []string
+	for rows.Next() {
+		var chatJID string
+		if err := rows.Scan(&chatJID); err != nil {
+			return nil, err
+		}
+		chats = append(chats, chatJID)
+	}
+	return chats, rows.Err()
+}
+
+// DeleteOrphanedChats deletes the chats FindOrphanedChats reports and returns
+// how many it deleted.
+func (store *MessageStore) DeleteOrphanedChats() (int, error) {
+	result, err := store.db.Exec(`DELETE FROM chats WHERE jid IN (` + orphanedChatsSQL + `)`)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// FindUnpairedLIDs lists LID user IDs that stored messages were received
+// from and that the alias table pairs with no other ID, so their messages
+// are not merged with the same person's phone-number identity.
+func (store *MessageStore) FindUnpairedLIDs() ([]string, error) {
+	rows, err := store.db.Query(`
+		SELECT DISTINCT m.raw_sender FROM messages m
+		WHERE m.sender_server = 'lid' AND COALESCE(m.raw_sender, '') <> ''
+			AND NOT EXISTS (
+				SELECT 1 FROM sender_id_aliases a
+				WHERE (a.alias_id = m.raw_sender AND a.canonical_id <> m.raw_sender)
+					OR (a.canonical_id = m.raw_sender AND a.alias_id <> m.raw_sender)
+			)
+		ORDER BY m.raw_sender
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lids []string
+	for rows.Next() {
+		var lid string
+		if err := rows.Scan(&lid); err != nil {
+			return nil, err
+		}
+		lids = append(lids, lid)
+	}
+	return lids, rows.Err()
+}
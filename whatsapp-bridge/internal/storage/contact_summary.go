@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// ErrContactNotFound reports a contact the store has no record of.
+var ErrContactNotFound = errors.New("contact not found")
+
+// DefaultResponseWindow is the longest pause still counted as a reply; a
+// later message is treated as a new conversation rather than a slow answer.
+const DefaultResponseWindow = 24 * time.Hour
+
+// ResponseLatency is how quickly one side of a direct chat answers the other,
+// measured from the first unanswered message to the reply.
+type ResponseLatency struct {
+	Average time.Duration
+	Samples int
+}
+
+// SharedGroup is a group chat in which the contact has posted. Group
+// membership is not stored, so silent members do not show up.
+type SharedGroup struct {
+	JID             string
+	Name            string
+	MessageCount    int
+	LastMessageTime time.Time
+}
+
+// ContactSummary describes the account's history with one person.
+type ContactSummary struct {
+	ContactID        string
+	Name             string
+	FirstInteraction time.Time
+	LastInteraction  time.Time
+	// SentCount and ReceivedCount cover the direct chat only.
+	SentCount     int
+	ReceivedCount int
+	// GroupMessageCount counts the contact's messages across shared groups.
+	GroupMessageCount int
+	// MyResponseLatency is how fast the account answers the contact, and
+	// TheirResponseLatency how fast the contact answers the account.
+	MyResponseLatency    ResponseLatency
+	TheirResponseLatency ResponseLatency
+	SharedGroups         []SharedGroup
+}
+
+// messageTurn is one direct chat message reduced to what latency needs.
+type messageTurn struct {
+	Time     time.Time
+	IsFromMe bool
+}
+
+// responseLatencies averages reply times in a chronological direct chat. A
+// reply is the first message after the other side started writing; pauses
+// longer than window are not counted as replies.
+func responseLatencies(turns []messageTurn, window time.Duration) (mine, theirs ResponseLatency) {
+	var myTotal, theirTotal time.Duration
+	for i := 0; i < len(turns); {
+		start := turns[i]
+		j := i + 1
+		for j < len(turns) && turns[j].IsFromMe == start.IsFromMe {
+			j++
+		}
+		if j < len(turns) {
+			if gap := turns[j].Time.Sub(start.Time); gap >= 0 && gap <= window {
+				if turns[j].IsFromMe {
+					myTotal += gap
+					mine.Samples++
+				} else {
+					theirTotal += gap
+					theirs.Samples++
+				}
+			}
+		}
+		i = j
+	}
+	if mine.Samples > 0 {
+		mine.Average = myTotal / time.Duration(mine.Samples)
+	}
+	if theirs.Samples > 0 {
+		theirs.Average = theirTotal / time.Duration(theirs.Samples)
+	}
+	return mine, theirs
+}
+
+// widenInteraction stretches the first and last interaction to cover t.
+func (summary *ContactSummary) widenInteraction(t time.Time) {
+	if summary.FirstInteraction.IsZero() || t.Before(summary.FirstInteraction) {
+		summary.FirstInteraction = t
+	}
+	if t.After(summary.LastInteraction) {
+		summary.LastInteraction = t
+	}
+}
+
+// GetContactSummary summarizes the account's history with a contact: its
+// direct chat, reply times there, and the groups where the contact posts.
+// Aliases resolve to their canonical ID. It returns ErrContactNotFound when
+// the contact has no chat and no messages.
+func (store *MessageStore) GetContactSummary(contactJID string) (ContactSummary, error) {
+	contactID := jid.NormalizeUser(contactJID)
+	if contactID == "" || !jid.IsPersonal(contactJID) {
+		return ContactSummary{}, ErrContactNotFound
+	}
+	var canonical string
+	err := store.db.QueryRow("SELECT canonical_id FROM sender_id_aliases WHERE alias_id = ?", contactID).Scan(&canonical)
+	if err != nil && err != sql.ErrNoRows {
+		return ContactSummary{}, err
+	}
+	if canonical != "" {
+		contactID = canonical
+	}
+
+	summary := ContactSummary{ContactID: contactID}
+	err = store.db.QueryRow("SELECT COALESCE(name, '') FROM chats WHERE jid = ?", contactID).Scan(&summary.Name)
+	if err != nil && err != sql.ErrNoRows {
+		return ContactSummary{}, err
+	}
+	knownChat := err == nil
+
+	rows, err := store.db.Query(
+		"SELECT timestamp, COALESCE(is_from_me, 0) FROM messages WHERE chat_jid = ? ORDER BY timestamp ASC",
+		contactID,
+	)
+	if err != nil {
+		return ContactSummary{}, err
+	}
+	defer rows.Close()
+	var turns []messageTurn
+	for rows.Next() {
+		var turn messageTurn
+		if err := rows.Scan(&turn.Time, &turn.IsFromMe); err != nil {
+			return ContactSummary{}, err
+		}
+		if turn.IsFromMe {
+			summary.SentCount++
+		} else {
+			summary.ReceivedCount++
+		}
+		summary.widenInteraction(turn.Time)
+		turns = append(turns, turn)
+	}
+	if err := rows.Err(); err != nil {
+		return ContactSummary{}, err
+	}
+	summary.MyResponseLatency, summary.TheirResponseLatency = responseLatencies(turns, DefaultResponseWindow)
+
+	groupRows, err := store.db.Query(
+		`SELECT m.chat_jid, COALESCE(c.name, ''), m.timestamp
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.chat_jid
+		WHERE m.sender = ? AND m.chat_jid LIKE ?
+		ORDER BY m.timestamp ASC`,
+		contactID, "%@"+jid.GroupServer,
+	)
+	if err != nil {
+		return ContactSummary{}, err
+	}
+	defer groupRows.Close()
+	groupIndex := map[string]int{}
+	for groupRows.Next() {
+		var group SharedGroup
+		if err := groupRows.Scan(&group.JID, &group.Name, &group.LastMessageTime); err != nil {
+			return ContactSummary{}, err
+		}
+		i, ok := groupIndex[group.JID]
+		if !ok {
+			i = len(summary.SharedGroups)
+			groupIndex[group.JID] = i
+			summary.SharedGroups = append(summary.SharedGroups, group)
+		}
+		summary.SharedGroups[i].MessageCount++
+		summary.SharedGroups[i].LastMessageTime = group.LastMessageTime
+		summary.GroupMessageCount++
+		summary.widenInteraction(group.LastMessageTime)
+	}
+	if err := groupRows.Err(); err != nil {
+		return ContactSummary{}, err
+	}
+	sort.SliceStable(summary.SharedGroups, func(a, b int) bool {
+		return summary.SharedGroups[a].LastMessageTime.After(summary.SharedGroups[b].LastMessageTime)
+	})
+
+	if !knownChat && len(turns) == 0 && summary.GroupMessageCount == 0 {
+		return ContactSummary{}, ErrContactNotFound
+	}
+	return summary, nil
+}
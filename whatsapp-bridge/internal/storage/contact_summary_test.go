@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResponseLatenciesMeasuresFromFirstUnansweredMessage(t *testing.T) {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	turns := []messageTurn{
+		{Time: base},
+		{Time: base.Add(2 * time.Minute)},
+		{Time: base.Add(10 * time.Minute), IsFromMe: true},
+		{Time: base.Add(11 * time.Minute), IsFromMe: true},
+		{Time: base.Add(15 * time.Minute)},
+		{Time: base.Add(25 * time.Minute), IsFromMe: true},
+	}
+
+	mine, theirs := responseLatencies(turns, time.Hour)
+	if mine.Samples != 2 || mine.Average != 10*time.Minute {
+		t.Fatalf("unexpected own latency: %+v", mine)
+	}
+	if theirs.Samples != 1 || theirs.Average != 5*time.Minute {
+		t.Fatalf("unexpected contact latency: %+v", theirs)
+	}
+}
+
+func TestResponseLatenciesSkipsPausesBeyondWindow(t *testing.T) {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	mine, theirs := responseLatencies([]messageTurn{
+		{Time: base},
+		{Time: base.Add(48 * time.Hour), IsFromMe: true},
+	}, DefaultResponseWindow)
+	if mine.Samples != 0 || theirs.Samples != 0 {
+		t.Fatalf("expected no samples, got %+v %+v", mine, theirs)
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SavedContact is a name the user saved for someone in their phone's address
+// book, as synced through WhatsApp app state.
+type SavedContact struct {
+	// UserID is the canonical bare user ID the contact's chat is stored under.
+	UserID    string
+	FullName  string
+	FirstName string
+	UpdatedAt time.Time
+}
+
+// ContactSyncStatus summarizes the address book imported from app state.
+type ContactSyncStatus struct {
+	ContactsImported int
+	// ChatsNamed counts direct chats currently named after a saved contact.
+	ChatsNamed   int
+	LastSyncedAt *time.Time
+}
+
+// ensureSavedContactsSchema creates the saved_contacts table.
+func ensureSavedContactsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS saved_contacts (
+			user_id TEXT PRIMARY KEY,
+			full_name TEXT NOT NULL,
+			first_name TEXT,
+			updated_at TIMESTAMP NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure saved_contacts table: %v", err)
+	}
+	return nil
+}
+
+// SaveContacts stores saved contact names and renames each contact's direct
+// chat after them, over any push name it carried. Chats with a local name
+// override keep it, with the saved name recorded behind it. It returns how
+// many chats were renamed.
+func (store *MessageStore) SaveContacts(contacts []SavedContact) (int, error) {
+	renamed := 0
+	for _, contact := range contacts {
+		if contact.UserID == "" || contact.FullName == "" {
+			continue
+		}
+		updated, err := store.saveContact(contact)
+		if err != nil {
+			return renamed, err
+		}
+		if updated {
+			renamed++
+		}
+	}
+	return renamed, nil
+}
+
+func (store *MessageStore) saveContact(contact SavedContact) (bool, error) {
+	unlock := store.chatLocks.lock(contact.UserID)
+	defer unlock()
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO saved_contacts (user_id, full_name, first_name, updated_at) VALUES (?, ?, NULLIF(?, ''), ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			full_name = excluded.full_name,
+			first_name = excluded.first_name,
+			updated_at = excluded.updated_at`,
+		contact.UserID, contact.FullName, contact.FirstName, normalizeToUTC(contact.UpdatedAt),
+	); err != nil {
+		return false, err
+	}
+	name, err := overriddenChatName(tx, contact.UserID, contact.FullName)
+	if err != nil {
+		return false, err
+	}
+	result, err := tx.Exec(`UPDATE chats SET name = ? WHERE jid = ? AND COALESCE(name, '') <> ?`, name, contact.UserID, name)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated > 0, tx.Commit()
+}
+
+// GetSavedContactName returns the saved name of a direct chat's contact, or
+// "" when the address book has none.
+func (store *MessageStore) GetSavedContactName(userID string) (string, error) {
+	stmt, err := store.prepared(`SELECT full_name FROM saved_contacts WHERE user_id = ?`)
+	if err != nil {
+		return "", err
+	}
+	var name string
+	err = stmt.QueryRow(userID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return name, err
+}
+
+// GetContactSyncStatus counts the imported contacts and the chats named
+// after them.
+func (store *MessageStore) GetContactSyncStatus() (ContactSyncStatus, error) {
+	var status ContactSyncStatus
+	if err := store.db.QueryRow(
+		`SELECT
+			(SELECT COUNT(*) FROM saved_contacts),
+			(SELECT COUNT(*) FROM chats c JOIN saved_contacts s ON s.user_id = c.jid AND s.full_name = c.name)`,
+	).Scan(&status.ContactsImported, &status.ChatsNamed); err != nil {
+		return ContactSyncStatus{}, err
+	}
+	var lastSynced time.Time
+	err := store.db.QueryRow(`SELECT updated_at FROM saved_contacts ORDER BY updated_at DESC LIMIT 1`).Scan(&lastSynced)
+	if err == sql.ErrNoRows {
+		return status, nil
+	}
+	if err != nil {
+		return ContactSyncStatus{}, err
+	}
+	status.LastSyncedAt = &lastSynced
+	return status, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromoteCanonicalChatMovesSavedContact(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	const (
+		canonical = "15551234567"
+		alias     = "123456789012345"
+	)
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.StoreChat(canonical, "Ally (push name)", at); err != nil {
+		t.Fatalf("StoreChat: %v", err)
+	}
+	if err := store.StoreChat(alias, "", at); err != nil {
+		t.Fatalf("StoreChat: %v", err)
+	}
+	if _, err := store.SaveContacts([]SavedContact{{UserID: alias, FullName: "Alice Smith", FirstName: "Alice", UpdatedAt: at}}); err != nil {
+		t.Fatalf("SaveContacts: %v", err)
+	}
+
+	if err := store.PromoteCanonicalChat(canonical, []string{alias}); err != nil {
+		t.Fatalf("PromoteCanonicalChat: %v", err)
+	}
+
+	if name, err := store.GetSavedContactName(canonical); err != nil || name != "Alice Smith" {
+		t.Fatalf("expected the saved name under the canonical ID, got %q (%v)", name, err)
+	}
+	if name, err := store.GetSavedContactName(alias); err != nil || name != "" {
+		t.Fatalf("expected no saved name left under the alias, got %q (%v)", name, err)
+	}
+	if name, err := store.GetChatName(canonical); err != nil || name != "Alice Smith" {
+		t.Fatalf("expected the promoted chat to be named after the saved contact, got %q (%v)", name, err)
+	}
+}
+
+func TestPromoteCanonicalChatKeepsChatSettings(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	const (
+		canonical = "15551234567"
+		alias     = "123456789012345"
+	)
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, chatJID := range []string{canonical, alias} {
+		if err := store.StoreChat(chatJID, "Alice", at); err != nil {
+			t.Fatalf("StoreChat: %v", err)
+		}
+	}
+	if _, err := store.SetChatTracking(alias, true); err != nil {
+		t.Fatalf("SetChatTracking: %v", err)
+	}
+	if _, err := store.SetChatReadOnly(alias, true); err != nil {
+		t.Fatalf("SetChatReadOnly: %v", err)
+	}
+	if _, err := store.SetChatHandoff(alias, true, "agent-1"); err != nil {
+		t.Fatalf("SetChatHandoff: %v", err)
+	}
+
+	if err := store.PromoteCanonicalChat(canonical, []string{alias}); err != nil {
+		t.Fatalf("PromoteCanonicalChat: %v", err)
+	}
+
+	settings, err := store.GetChatSettings(canonical)
+	if err != nil {
+		t.Fatalf("GetChatSettings: %v", err)
+	}
+	if !settings.Tracked || !settings.ReadOnly || !settings.HumanHandoff || settings.HandoffBy != "agent-1" {
+		t.Fatalf("expected the alias's settings on the canonical chat, got %+v", settings)
+	}
+	if tracked, err := store.IsChatTracked(canonical); err != nil || !tracked {
+		t.Fatalf("expected the canonical chat to stay tracked, got %v (%v)", tracked, err)
+	}
+	if settings, err := store.GetChatSettings(alias); err != nil || settings.Tracked || settings.ReadOnly {
+		t.Fatalf("expected no settings left under the alias, got %+v (%v)", settings, err)
+	}
+}
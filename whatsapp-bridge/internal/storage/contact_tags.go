@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Importance tags recognised by the priority inbox. Other tags are stored
+// and returned but do not affect ranking.
+const (
+	ContactTagVIP       = "vip"
+	ContactTagImportant = "important"
+	ContactTagLow       = "low"
+)
+
+// ensureContactTagsSchema creates the contact_tags table.
+func ensureContactTagsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS contact_tags (
+			contact_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (contact_id, tag)
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure contact_tags table: %v", err)
+	}
+	return nil
+}
+
+// GetContactTags returns a contact's tags in alphabetical order.
+func (store *MessageStore) GetContactTags(contactID string) ([]string, error) {
+	rows, err := store.db.Query("SELECT tag FROM contact_tags WHERE contact_id = ? ORDER BY tag", contactID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// SetContactTags replaces a contact's tags; an empty list clears them.
+func (store *MessageStore) SetContactTags(contactID string, tags []string) error {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM contact_tags WHERE contact_id = ?", contactID); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, tag := range tags {
+		if _, err := tx.Exec(
+			"INSERT OR IGNORE INTO contact_tags (contact_id, tag, created_at) VALUES (?, ?, ?)",
+			contactID, tag, now,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// allContactTags returns every tagged contact's tags, keyed by contact ID.
+func (store *MessageStore) allContactTags() (map[string][]string, error) {
+	rows, err := store.db.Query("SELECT contact_id, tag FROM contact_tags ORDER BY contact_id, tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := map[string][]string{}
+	for rows.Next() {
+		var contactID, tag string
+		if err := rows.Scan(&contactID, &tag); err != nil {
+			return nil, err
+		}
+		tags[contactID] = append(tags[contactID], tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// senderNameMessageColumns selects a message with its sender's resolved
+// display name, as read by scanMessagesWithSenderNames.
+const senderNameMessageColumns = `m.id, m.chat_jid, COALESCE(m.sender, ''), ` + resolvedSenderNameSQL + `, COALESCE(m.content, ''), m.timestamp,
+			COALESCE(m.is_from_me, 0), COALESCE(m.media_type, ''), COALESCE(m.filename, ''),
+			COALESCE(m.quoted_message_id, ''), COALESCE(m.media_seconds, 0), COALESCE(m.is_voice_note, 0),
+			COALESCE(m.is_forwarded, 0), COALESCE(m.forwarding_score, 0), COALESCE(m.seq, 0)
+		FROM messages m`
+
+// GetMessagesWithSenderNames returns recent messages for a chat ordered by
+// timestamp desc, with sender display names resolved server-side.
+func (store *MessageStore) GetMessagesWithSenderNames(chatJID string, limit int) ([]Message, error) {
+	rows, err := store.db.Query(
+		`SELECT `+senderNameMessageColumns+`
+		WHERE m.chat_jid = ?
+		ORDER BY m.timestamp DESC
+		LIMIT ?`,
+		chatJID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanMessagesWithSenderNames(rows)
+}
+
+// scanMessagesWithSenderNames reads the rows of a query selecting
+// senderNameMessageColumns.
+func scanMessagesWithSenderNames(rows *sql.Rows) ([]Message, error) {
+	var messages []Message
+	for rows.Next() {
+		var msg Message
+		var timestamp time.Time
+		if err := rows.Scan(&msg.ID, &msg.ChatJID, &msg.Sender, &msg.SenderName, &msg.Content, &timestamp, &msg.IsFromMe, &msg.MediaType, &msg.Filename, &msg.QuotedMessageID, &msg.MediaSeconds, &msg.VoiceNote, &msg.IsForwarded, &msg.ForwardingScore, &msg.Seq); err != nil {
+			return nil, err
+		}
+		msg.Time = timestamp
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// GetMessageContext returns the message id in chatJID together with up to
+// before earlier and after later messages of the chat, oldest first, and the
+// index of the message itself. It returns sql.ErrNoRows when the message is
+// not stored. Messages sharing a timestamp are ordered by id.
+func (store *MessageStore) GetMessageContext(id, chatJID string, before, after int) ([]Message, int, error) {
+	rows, err := store.db.Query(
+		`SELECT `+senderNameMessageColumns+`
+		WHERE m.id = ? AND m.chat_jid = ?`,
+		id, chatJID,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	anchor, err := scanMessagesWithSenderNames(rows)
+	rows.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(anchor) == 0 {
+		return nil, 0, sql.ErrNoRows
+	}
+	timestamp := normalizeToUTC(anchor[0].Time)
+
+	var earlier []Message
+	if before > 0 {
+		rows, err := store.db.Query(
+			`SELECT `+senderNameMessageColumns+`
+			WHERE m.chat_jid = ? AND (m.timestamp < ? OR (m.timestamp = ? AND m.id < ?))
+			ORDER BY m.timestamp DESC, m.id DESC
+			LIMIT ?`,
+			chatJID, timestamp, timestamp, id, before,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		earlier, err = scanMessagesWithSenderNames(rows)
+		rows.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var later []Message
+	if after > 0 {
+		rows, err := store.db.Query(
+			`SELECT `+senderNameMessageColumns+`
+			WHERE m.chat_jid = ? AND (m.timestamp > ? OR (m.timestamp = ? AND m.id > ?))
+			ORDER BY m.timestamp ASC, m.id ASC
+			LIMIT ?`,
+			chatJID, timestamp, timestamp, id, after,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		later, err = scanMessagesWithSenderNames(rows)
+		rows.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	messages := make([]Message, 0, len(earlier)+1+len(later))
+	for i := len(earlier) - 1; i >= 0; i-- {
+		messages = append(messages, earlier[i])
+	}
+	messages = append(messages, anchor[0])
+	messages = append(messages, later...)
+	return messages, len(earlier), nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"time"
+)
+
+// DayLayout is the format of calendar day keys such as "2026-03-10".
+const DayLayout = "2006-01-02"
+
+// DayCount summarises one local calendar day of a chat.
+type DayCount struct {
+	Date          string
+	MessageCount  int
+	IncomingCount int
+	OutgoingCount int
+	FirstAt       time.Time
+	LastAt        time.Time
+}
+
+// dayCounter folds messages, newest first, into per-day counts for location,
+// keeping at most limit days.
+type dayCounter struct {
+	location *time.Location
+	limit    int
+	days     []DayCount
+}
+
+// add counts one message and reports whether the counter still has room,
+// i.e. whether older messages can still land in a kept day.
+func (c *dayCounter) add(timestamp time.Time, isFromMe bool) bool {
+	local := timestamp.In(c.location)
+	key := local.Format(DayLayout)
+	if len(c.days) == 0 || c.days[len(c.days)-1].Date != key {
+		if len(c.days) == c.limit {
+			return false
+		}
+		c.days = append(c.days, DayCount{Date: key, FirstAt: timestamp, LastAt: timestamp})
+	}
+	day := &c.days[len(c.days)-1]
+	day.MessageCount++
+	if isFromMe {
+		day.OutgoingCount++
+	} else {
+		day.IncomingCount++
+	}
+	day.FirstAt = timestamp
+	return true
+}
+
+// GetChatDayCounts returns the latest limit calendar days, in location, on
+// which the chat has messages, newest first, with per-day message counts.
+func (store *MessageStore) GetChatDayCounts(chatJID string, location *time.Location, limit int) ([]DayCount, error) {
+	rows, err := store.db.Query(
+		`SELECT timestamp, COALESCE(is_from_me, 0)
+		FROM messages
+		WHERE chat_jid = ?
+		ORDER BY timestamp DESC`,
+		chatJID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counter := dayCounter{location: location, limit: limit}
+	for rows.Next() {
+		var timestamp time.Time
+		var isFromMe bool
+		if err := rows.Scan(&timestamp, &isFromMe); err != nil {
+			return nil, err
+		}
+		if !counter.add(timestamp, isFromMe) {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return counter.days, nil
+}
+
+// GetChatDayMessages returns up to limit messages of the chat sent on the
+// calendar day date, in location, oldest first, with sender display names.
+func (store *MessageStore) GetChatDayMessages(chatJID string, date time.Time, location *time.Location, limit int) ([]Message, error) {
+	local := date.In(location)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	end := start.AddDate(0, 0, 1)
+
+	rows, err := store.db.Query(
+		`SELECT `+senderNameMessageColumns+`
+		WHERE m.chat_jid = ? AND m.timestamp >= ? AND m.timestamp < ?
+		ORDER BY m.timestamp ASC
+		LIMIT ?`,
+		chatJID, normalizeToUTC(start), normalizeToUTC(end), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanMessagesWithSenderNames(rows)
+}
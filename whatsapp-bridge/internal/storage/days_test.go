@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDayCounterGroupsByLocalDay(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	counter := dayCounter{location: berlin, limit: 2}
+	messages := []struct {
+		at       time.Time
+		isFromMe bool
+	}{
+		// 23:30 UTC on March 10 is already March 11 in Berlin.
+		{time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC), false},
+		{time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC), true},
+		{time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC), false},
+		{time.Date(2026, 3, 8, 8, 0, 0, 0, time.UTC), false},
+	}
+	for i, msg := range messages {
+		kept := counter.add(msg.at, msg.isFromMe)
+		if want := i < 3; kept != want {
+			t.Fatalf("message %d: add = %v, want %v", i, kept, want)
+		}
+	}
+
+	if len(counter.days) != 2 {
+		t.Fatalf("expected 2 days, got %+v", counter.days)
+	}
+	if day := counter.days[0]; day.Date != "2026-03-11" || day.MessageCount != 1 {
+		t.Fatalf("unexpected first day %+v", day)
+	}
+	day := counter.days[1]
+	if day.Date != "2026-03-10" || day.MessageCount != 2 || day.IncomingCount != 1 || day.OutgoingCount != 1 {
+		t.Fatalf("unexpected second day %+v", day)
+	}
+	if !day.FirstAt.Equal(messages[2].at) || !day.LastAt.Equal(messages[1].at) {
+		t.Fatalf("unexpected day bounds %v - %v", day.FirstAt, day.LastAt)
+	}
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"whatsapp-client/internal/jid"
+)
+
+// canonicalChatExpr returns SQL resolving a chat ID column to the key it is
+// normalized to, mirroring jid.NormalizeChat: groups, newsletters and broadcast
+// lists stay whole, personal chats map through sender aliases.
+func canonicalChatExpr(column string) string {
+	personalServers := fmt.Sprintf("'%s', '%s', '%s'", jid.UserServer, jid.LegacyUserServer, jid.LIDServer)
+	return fmt.Sprintf(`CASE
+			WHEN INSTR(%[1]s, '@') > 0 AND LOWER(SUBSTR(%[1]s, INSTR(%[1]s, '@') + 1)) NOT IN (%[2]s) THEN %[1]s
+			WHEN INSTR(%[1]s, '@') > 0 THEN COALESCE(
+				(SELECT canonical_id FROM sender_id_aliases WHERE alias_id = SUBSTR(%[1]s, 1, INSTR(%[1]s, '@') - 1) LIMIT 1),
+				SUBSTR(%[1]s, 1, INSTR(%[1]s, '@') - 1)
+			)
+			ELSE COALESCE(
+				(SELECT canonical_id FROM sender_id_aliases WHERE alias_id = %[1]s LIMIT 1),
+				%[1]s
+			)
+		END`, column, personalServers)
+}
+
+// messageCopy is one stored row of a message ID that may exist under several chat IDs.
+type messageCopy struct {
+	ID            string
+	ChatJID       string
+	CanonicalChat string
+}
+
+// duplicateMerge folds the row stored under From into the row stored under Into.
+type duplicateMerge struct {
+	ID   string
+	From string
+	Into string
+}
+
+// planDuplicateMerges groups copies of the same message that normalize to the
+// same chat and picks a row to keep: the one already under the canonical chat
+// ID, otherwise the lowest chat ID so repeated runs agree.
+func planDuplicateMerges(copies []messageCopy) []duplicateMerge {
+	type groupKey struct{ id, chat string }
+	groups := map[groupKey][]string{}
+	var keys []groupKey
+	for _, entry := range copies {
+		key := groupKey{entry.ID, entry.CanonicalChat}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], entry.ChatJID)
+	}
+
+	var merges []duplicateMerge
+	for _, key := range keys {
+		chats := groups[key]
+		if len(chats) < 2 {
+			continue
+		}
+		sort.Strings(chats)
+		keeper := chats[0]
+		for _, chat := range chats {
+			if chat == key.chat {
+				keeper = chat
+				break
+			}
+		}
+		for _, chat := range chats {
+			if chat != keeper {
+				merges = append(merges, duplicateMerge{ID: key.id, From: chat, Into: keeper})
+			}
+		}
+	}
+	return merges
+}
+
+// mergeFillColumns are copied from a duplicate only where the kept row lacks them.
+var mergeFillColumns = []string{
+	"sender", "content", "media_type", "filename", "url", "media_key", "file_sha256",
+	"file_enc_sha256", "thumbnail", "local_path", "quoted_message_id", "raw_sender",
+	"sender_server", "chat_server", "media_waveform", "spam_reasons", "selection_kind",
+	"selection_id", "selection_title", "payment_kind", "payment_currency", "payment_reference",
+	"payment_title", "language",
+}
+
+// mergeCountColumns are copied from a duplicate where the kept row has zero.
+var mergeCountColumns = []string{"file_length", "media_seconds", "payment_amount_1000", "payment_item_count"}
+
+// mergeFlagColumns keep the larger value of the two rows, so a flag or score
+// set on either copy survives.
+var mergeFlagColumns = []string{
+	"is_self_chat", "is_voice_note", "is_forwarded", "forwarding_score", "spam_score", "quarantined",
+}
+
+// mergeMessageInto fills gaps in the row (id, into) from the row (id, from),
+// moves its links, embeddings and receipts across, and deletes it.
+func mergeMessageInto(tx *sql.Tx, id, from, into string) error {
+	assignments := make([]string, 0, len(mergeFillColumns)+len(mergeCountColumns)+len(mergeFlagColumns))
+	for _, column := range mergeFillColumns {
+		assignments = append(assignments, fmt.Sprintf(
+			"%[1]s = CASE WHEN messages.%[1]s IS NULL OR LENGTH(messages.%[1]s) = 0 THEN dup.%[1]s ELSE messages.%[1]s END",
+			column,
+		))
+	}
+	for _, column := range mergeCountColumns {
+		assignments = append(assignments, fmt.Sprintf(
+			"%[1]s = CASE WHEN COALESCE(messages.%[1]s, 0) = 0 THEN dup.%[1]s ELSE messages.%[1]s END",
+			column,
+		))
+	}
+	for _, column := range mergeFlagColumns {
+		assignments = append(assignments, fmt.Sprintf(
+			"%[1]s = MAX(COALESCE(messages.%[1]s, 0), COALESCE(dup.%[1]s, 0))",
+			column,
+		))
+	}
+	if _, err := tx.Exec(
+		`UPDATE messages SET `+strings.Join(assignments, ", ")+`
+		FROM messages AS dup
+		WHERE messages.id = ? AND messages.chat_jid = ? AND dup.id = messages.id AND dup.chat_jid = ?`,
+		id, into, from,
+	); err != nil {
+		return fmt.Errorf("failed to merge duplicate message %s: %v", id, err)
+	}
+
+	statements := []string{
+		"UPDATE OR IGNORE links SET chat_jid = ? WHERE message_id = ? AND chat_jid = ?",
+		"UPDATE OR IGNORE message_embeddings SET chat_jid = ? WHERE message_id = ? AND chat_jid = ?",
+		"UPDATE OR IGNORE message_receipts SET chat_jid = ? WHERE message_id = ? AND chat_jid = ?",
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, into, id, from); err != nil {
+			return fmt.Errorf("failed to move duplicate message %s: %v", id, err)
+		}
+	}
+	cleanup := []string{
+		"DELETE FROM links WHERE message_id = ? AND chat_jid = ?",
+		"DELETE FROM message_embeddings WHERE message_id = ? AND chat_jid = ?",
+		"DELETE FROM message_receipts WHERE message_id = ? AND chat_jid = ?",
+		"DELETE FROM messages WHERE id = ? AND chat_jid = ?",
+	}
+	for _, stmt := range cleanup {
+		if _, err := tx.Exec(stmt, id, from); err != nil {
+			return fmt.Errorf("failed to remove duplicate message %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// mergeAliasDuplicates folds copies of msgID stored under other chat IDs that
+// normalize to the same chat as chatJID into the row under chatJID.
+func mergeAliasDuplicates(tx *sql.Tx, msgID, chatJID string) error {
+	if !jid.IsPersonal(chatJID) {
+		return nil
+	}
+	rows, err := tx.Query(
+		`WITH incoming(jid) AS (SELECT ?)
+		SELECT m.chat_jid
+		FROM messages m, incoming
+		WHERE m.id = ? AND m.chat_jid <> incoming.jid AND m.chat_jid NOT LIKE '%@g.us'
+			AND `+canonicalChatExpr("m.chat_jid")+` = `+canonicalChatExpr("incoming.jid"),
+		chatJID, msgID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to look up duplicate messages: %v", err)
+	}
+	var duplicates []string
+	for rows.Next() {
+		var chat string
+		if err := rows.Scan(&chat); err != nil {
+			rows.Close()
+			return err
+		}
+		duplicates = append(duplicates, chat)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, chat := range duplicates {
+		if err := mergeMessageInto(tx, msgID, chat, chatJID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeChatDuplicates folds messages present in both chats into the copy under
+// into, so the alias chat's remaining rows can be re-keyed without conflicts.
+func mergeChatDuplicates(tx *sql.Tx, from, into string) error {
+	rows, err := tx.Query(
+		"SELECT id FROM messages WHERE chat_jid = ? AND id IN (SELECT id FROM messages WHERE chat_jid = ?)",
+		from, into,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to look up duplicate messages: %v", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := mergeMessageInto(tx, id, from, into); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repairDuplicateMessages merges direct-chat messages stored more than once
+// under chat IDs that normalize to the same chat, such as a live copy keyed by
+// phone number and a history-sync copy keyed by LID.
+func repairDuplicateMessages(db *sql.DB) error {
+	rows, err := db.Query(`
+		SELECT id, chat_jid, ` + canonicalChatExpr("chat_jid") + `
+		FROM messages
+		WHERE chat_jid NOT LIKE '%@g.us'
+			AND id IN (
+				SELECT id FROM messages
+				WHERE chat_jid NOT LIKE '%@g.us'
+				GROUP BY id
+				HAVING COUNT(*) > 1
+			)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to scan duplicate messages: %v", err)
+	}
+	var copies []messageCopy
+	for rows.Next() {
+		var entry messageCopy
+		if err := rows.Scan(&entry.ID, &entry.ChatJID, &entry.CanonicalChat); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read duplicate message: %v", err)
+		}
+		copies = append(copies, entry)
+	}
+	rows.Close()
+
+	merges := planDuplicateMerges(copies)
+	if len(merges) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, merge := range merges {
+		if err := mergeMessageInto(tx, merge.ID, merge.From, merge.Into); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
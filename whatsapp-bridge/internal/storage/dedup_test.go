@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanDuplicateMergesKeepsCanonicalChat(t *testing.T) {
+	merges := planDuplicateMerges([]messageCopy{
+		{ID: "m1", ChatJID: "15551234567@s.whatsapp.net", CanonicalChat: "15551234567"},
+		{ID: "m1", ChatJID: "15551234567", CanonicalChat: "15551234567"},
+		{ID: "m1", ChatJID: "9876@lid", CanonicalChat: "15551234567"},
+		{ID: "m2", ChatJID: "other", CanonicalChat: "other"},
+	})
+	want := []duplicateMerge{
+		{ID: "m1", From: "15551234567@s.whatsapp.net", Into: "15551234567"},
+		{ID: "m1", From: "9876@lid", Into: "15551234567"},
+	}
+	if !reflect.DeepEqual(merges, want) {
+		t.Fatalf("unexpected merges: %+v", merges)
+	}
+}
+
+func TestPlanDuplicateMergesFallsBackToLowestChat(t *testing.T) {
+	merges := planDuplicateMerges([]messageCopy{
+		{ID: "m1", ChatJID: "b@lid", CanonicalChat: "c"},
+		{ID: "m1", ChatJID: "a@s.whatsapp.net", CanonicalChat: "c"},
+		{ID: "m1", ChatJID: "x", CanonicalChat: "x"},
+	})
+	want := []duplicateMerge{{ID: "m1", From: "b@lid", Into: "a@s.whatsapp.net"}}
+	if !reflect.DeepEqual(merges, want) {
+		t.Fatalf("unexpected merges: %+v", merges)
+	}
+}
+
+// mergedColumnValues are set only on the duplicate row in
+// TestMergeMessageIntoKeepsDuplicateData and must survive the merge.
+var mergedColumnValues = map[string]any{
+	"media_type":          "image",
+	"filename":            "photo.jpg",
+	"url":                 "https://mmg.whatsapp.net/photo",
+	"media_key":           []byte("media-key"),
+	"quoted_message_id":   "Q1",
+	"file_length":         int64(2048),
+	"is_self_chat":        true,
+	"media_seconds":       int64(7),
+	"media_waveform":      []byte{1, 2, 3},
+	"is_voice_note":       true,
+	"is_forwarded":        true,
+	"forwarding_score":    int64(5),
+	"spam_score":          int64(80),
+	"spam_reasons":        "link,new_sender",
+	"quarantined":         true,
+	"selection_kind":      "list",
+	"selection_id":        "row-2",
+	"selection_title":     "Tomorrow",
+	"payment_kind":        "order",
+	"payment_amount_1000": int64(12500),
+	"payment_currency":    "EUR",
+	"payment_reference":   "ORD-7",
+	"payment_item_count":  int64(3),
+	"payment_title":       "Groceries",
+	"language":            "de",
+}
+
+func TestMergeMessageIntoKeepsDuplicateData(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	const (
+		id   = "M1"
+		into = "15551234567"
+		from = "15551234567@s.whatsapp.net"
+	)
+	for _, chat := range []string{into, from} {
+		if _, err := store.db.Exec(`INSERT INTO chats (jid, name) VALUES (?, 'Alice')`, chat); err != nil {
+			t.Fatalf("insert chat: %v", err)
+		}
+	}
+	if _, err := store.db.Exec(`INSERT INTO messages (id, chat_jid, sender, content) VALUES (?, ?, '15551234567', 'hello')`, id, into); err != nil {
+		t.Fatalf("insert kept message: %v", err)
+	}
+	columns := []string{"id", "chat_jid"}
+	values := []any{id, from}
+	for column, value := range mergedColumnValues {
+		columns = append(columns, column)
+		values = append(values, value)
+	}
+	if _, err := store.db.Exec(
+		`INSERT INTO messages (`+strings.Join(columns, ", ")+`) VALUES (?`+strings.Repeat(", ?", len(values)-1)+`)`,
+		values...,
+	); err != nil {
+		t.Fatalf("insert duplicate message: %v", err)
+	}
+	if _, err := store.db.Exec(
+		`INSERT INTO message_receipts (message_id, chat_jid, recipient_id, read_at) VALUES (?, ?, '15550000000', CURRENT_TIMESTAMP)`,
+		id, from,
+	); err != nil {
+		t.Fatalf("insert receipt: %v", err)
+	}
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mergeMessageInto(tx, id, from, into); err != nil {
+		tx.Rollback()
+		t.Fatalf("mergeMessageInto: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	for column, want := range mergedColumnValues {
+		var got any
+		if err := store.db.QueryRow(`SELECT `+column+` FROM messages WHERE id = ? AND chat_jid = ?`, id, into).Scan(&got); err != nil {
+			t.Fatalf("read %s: %v", column, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s = %v after the merge, want %v", column, got, want)
+		}
+	}
+	var remaining, receipts int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM messages WHERE id = ?`, id).Scan(&remaining); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM message_receipts WHERE message_id = ? AND chat_jid = ?`, id, into).Scan(&receipts); err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 1 || receipts != 1 {
+		t.Fatalf("expected one merged message with its receipt, got %d messages and %d receipts", remaining, receipts)
+	}
+}
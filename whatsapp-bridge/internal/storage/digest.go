@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ChatActivity summarizes one chat's messages within a digest period.
+type ChatActivity struct {
+	ChatJID        string         `json:"chat_jid"`
+	ChatName       string         `json:"chat_name,omitempty"`
+	MessageCount   int            `json:"message_count"`
+	IncomingCount  int            `json:"incoming_count"`
+	OutgoingCount  int            `json:"outgoing_count"`
+	Mentions       int            `json:"mentions"`
+	FirstUnreplied *DigestMessage `json:"first_unreplied,omitempty"`
+}
+
+// DigestMessage is the subset of a message kept in a digest.
+type DigestMessage struct {
+	MessageID  string    `json:"message_id"`
+	SenderID   string    `json:"sender_id"`
+	SenderName string    `json:"sender_name,omitempty"`
+	Content    string    `json:"content"`
+	MediaType  string    `json:"media_type,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// Digest is a stored activity summary for a period.
+type Digest struct {
+	ID          int64
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	CreatedAt   time.Time
+	Chats       []ChatActivity
+}
+
+// ensureDigestsSchema creates the digests table.
+func ensureDigestsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS digests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			period_start TIMESTAMP NOT NULL,
+			period_end TIMESTAMP NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			chats TEXT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure digests table: %v", err)
+	}
+	return nil
+}
+
+// GetChatActivity summarizes per-chat activity between since and until. selfIDs
+// are the account's own user IDs; incoming messages containing "@<id>" count as
+// mentions. A chat's first unreplied message is its earliest incoming message in
+// the period that arrived after our last outgoing message in that chat; chats
+// snoozed past until have none.
+func (store *MessageStore) GetChatActivity(since, until time.Time, selfIDs []string) ([]ChatActivity, error) {
+	mentionExpr := "0"
+	var mentionArgs []interface{}
+	if len(selfIDs) > 0 {
+		clauses := make([]string, 0, len(selfIDs))
+		for _, id := range selfIDs {
+			clauses = append(clauses, `m.content LIKE ? ESCAPE '\'`)
+			mentionArgs = append(mentionArgs, "%@"+escapeLikePattern(id)+"%")
+		}
+		mentionExpr = fmt.Sprintf("CASE WHEN COALESCE(m.is_from_me, 0) = 0 AND (%s) THEN 1 ELSE 0 END", strings.Join(clauses, " OR "))
+	}
+
+	args := append(mentionArgs, normalizeToUTC(since), normalizeToUTC(until))
+	rows, err := store.db.Query(
+		fmt.Sprintf(
+			`SELECT m.chat_jid, COALESCE(c.name, ''), COUNT(*),
+				SUM(CASE WHEN COALESCE(m.is_from_me, 0) = 0 THEN 1 ELSE 0 END),
+				SUM(%s)
+			FROM messages m
+			LEFT JOIN chats c ON c.jid = m.chat_jid
+			WHERE m.timestamp >= ? AND m.timestamp < ?
+			GROUP BY m.chat_jid
+			ORDER BY COUNT(*) DESC, m.chat_jid`,
+			mentionExpr,
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var activity []ChatActivity
+	index := map[string]int{}
+	for rows.Next() {
+		var chat ChatActivity
+		if err := rows.Scan(&chat.ChatJID, &chat.ChatName, &chat.MessageCount, &chat.IncomingCount, &chat.Mentions); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		chat.OutgoingCount = chat.MessageCount - chat.IncomingCount
+		index[chat.ChatJID] = len(activity)
+		activity = append(activity, chat)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = store.db.Query(
+		`SELECT m.chat_jid, m.id, COALESCE(m.sender, ''), COALESCE(s.name, ''), COALESCE(m.content, ''),
+			COALESCE(m.media_type, ''), m.timestamp
+		FROM messages m
+		LEFT JOIN chats s ON s.jid = m.sender
+		WHERE COALESCE(m.is_from_me, 0) = 0
+			AND m.timestamp >= ? AND m.timestamp < ?
+			AND NOT EXISTS (
+				SELECT 1 FROM messages o
+				WHERE o.chat_jid = m.chat_jid AND o.is_from_me = 1 AND o.timestamp >= m.timestamp
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM chat_settings cs WHERE cs.chat_jid = m.chat_jid AND cs.snoozed_until > ?
+			)
+		ORDER BY m.chat_jid, m.timestamp ASC`,
+		normalizeToUTC(since), normalizeToUTC(until), normalizeToUTC(until),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var chatJID string
+		var msg DigestMessage
+		if err := rows.Scan(&chatJID, &msg.MessageID, &msg.SenderID, &msg.SenderName, &msg.Content, &msg.MediaType, &msg.Timestamp); err != nil {
+			return nil, err
+		}
+		i, ok := index[chatJID]
+		if !ok || activity[i].FirstUnreplied != nil {
+			continue
+		}
+		activity[i].FirstUnreplied = &msg
+	}
+	return activity, rows.Err()
+}
+
+// StoreDigest persists a digest and sets its ID.
+func (store *MessageStore) StoreDigest(digest *Digest) error {
+	encoded, err := json.Marshal(digest.Chats)
+	if err != nil {
+		return err
+	}
+	result, err := store.db.Exec(
+		"INSERT INTO digests (period_start, period_end, created_at, chats) VALUES (?, ?, ?, ?)",
+		normalizeToUTC(digest.PeriodStart), normalizeToUTC(digest.PeriodEnd), normalizeToUTC(digest.CreatedAt), string(encoded),
+	)
+	if err != nil {
+		return err
+	}
+	digest.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetLatestDigest returns the most recently created digest, or nil when none exist.
+func (store *MessageStore) GetLatestDigest() (*Digest, error) {
+	var digest Digest
+	var encoded string
+	err := store.db.QueryRow(
+		"SELECT id, period_start, period_end, created_at, chats FROM digests ORDER BY id DESC LIMIT 1",
+	).Scan(&digest.ID, &digest.PeriodStart, &digest.PeriodEnd, &digest.CreatedAt, &encoded)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(encoded), &digest.Chats); err != nil {
+		return nil, fmt.Errorf("failed to decode digest %d: %v", digest.ID, err)
+	}
+	return &digest, nil
+}
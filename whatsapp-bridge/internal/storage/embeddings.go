@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	semanticScoreWeight = 0.7
+	keywordScoreWeight  = 0.3
+	maxKeywordMatches   = 500
+)
+
+// SemanticQuery describes a blended semantic and keyword message search.
+// Vector may be nil, in which case only keyword matches are returned.
+type SemanticQuery struct {
+	Text    string
+	Vector  []float32
+	Model   string
+	ChatJID string
+	Limit   int
+}
+
+// SearchResult is a message ranked by SemanticSearch.
+type SearchResult struct {
+	Message
+	Score         float64
+	SemanticScore float64
+	KeywordMatch  bool
+}
+
+// ensureEmbeddingsSchema creates the message_embeddings table. Vectors are stored
+// as little-endian float32 blobs, the layout used by sqlite-vec and FAISS.
+func ensureEmbeddingsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_embeddings (
+			message_id TEXT NOT NULL,
+			chat_jid TEXT NOT NULL,
+			model TEXT NOT NULL,
+			dimensions INTEGER NOT NULL,
+			vector BLOB NOT NULL,
+			created_at TIMESTAMP,
+			PRIMARY KEY (message_id, chat_jid)
+		);
+		CREATE INDEX IF NOT EXISTS idx_message_embeddings_chat ON message_embeddings(chat_jid);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure message_embeddings table: %v", err)
+	}
+	return nil
+}
+
+// encodeVector serializes a vector as little-endian float32 values.
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, value := range vector {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(value))
+	}
+	return buf
+}
+
+// decodeVector parses a little-endian float32 blob.
+func decodeVector(blob []byte) []float32 {
+	vector := make([]float32, len(blob)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[i*4:]))
+	}
+	return vector
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 when
+// the vectors differ in length or either has zero magnitude.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// StoreMessageEmbedding upserts the embedding vector for a message.
+func (store *MessageStore) StoreMessageEmbedding(messageID, chatJID, model string, vector []float32) error {
+	_, err := store.db.Exec(
+		`INSERT OR REPLACE INTO message_embeddings (message_id, chat_jid, model, dimensions, vector, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		messageID, chatJID, model, len(vector), encodeVector(vector), time.Now().UTC(),
+	)
+	return err
+}
+
+type searchKey struct {
+	messageID string
+	chatJID   string
+}
+
+// SemanticSearch ranks messages by a weighted blend of embedding similarity and
+// keyword matches. Similarity is computed in Go over the stored vectors.
+func (store *MessageStore) SemanticSearch(query SemanticQuery) ([]SearchResult, error) {
+	candidates := make(map[searchKey]*SearchResult)
+
+	if len(query.Vector) > 0 {
+		conditions := []string{"model = ?", "dimensions = ?"}
+		args := []interface{}{query.Model, len(query.Vector)}
+		if query.ChatJID != "" {
+			conditions = append(conditions, "chat_jid = ?")
+			args = append(args, query.ChatJID)
+		}
+		rows, err := store.db.Query(
+			fmt.Sprintf(
+				"SELECT message_id, chat_jid, vector FROM message_embeddings WHERE %s",
+				strings.Join(conditions, " AND "),
+			),
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var key searchKey
+			var blob []byte
+			if err := rows.Scan(&key.messageID, &key.chatJID, &blob); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			similarity := cosineSimilarity(query.Vector, decodeVector(blob))
+			if similarity <= 0 {
+				continue
+			}
+			candidates[key] = &SearchResult{Message: Message{ChatJID: key.chatJID}, SemanticScore: similarity}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rows.Close()
+	}
+
+	if terms := strings.Fields(query.Text); len(terms) > 0 {
+		conditions := []string{}
+		args := []interface{}{}
+		for _, term := range terms {
+			conditions = append(conditions, `content LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+escapeLikePattern(term)+"%")
+		}
+		if query.ChatJID != "" {
+			conditions = append(conditions, "chat_jid = ?")
+			args = append(args, query.ChatJID)
+		}
+		args = append(args, maxKeywordMatches)
+		rows, err := store.db.Query(
+			fmt.Sprintf(
+				"SELECT id, chat_jid FROM messages WHERE %s ORDER BY timestamp DESC LIMIT ?",
+				strings.Join(conditions, " AND "),
+			),
+			args...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var key searchKey
+			if err := rows.Scan(&key.messageID, &key.chatJID); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			result, ok := candidates[key]
+			if !ok {
+				result = &SearchResult{Message: Message{ChatJID: key.chatJID}}
+				candidates[key] = result
+			}
+			result.KeywordMatch = true
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rows.Close()
+	}
+
+	ranked := make([]*SearchResult, 0, len(candidates))
+	keys := make(map[*SearchResult]searchKey, len(candidates))
+	for key, result := range candidates {
+		if len(query.Vector) > 0 {
+			result.Score = semanticScoreWeight * result.SemanticScore
+			if result.KeywordMatch {
+				result.Score += keywordScoreWeight
+			}
+		} else if result.KeywordMatch {
+			result.Score = 1
+		}
+		ranked = append(ranked, result)
+		keys[result] = key
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return keys[ranked[i]].messageID < keys[ranked[j]].messageID
+	})
+	if query.Limit > 0 && len(ranked) > query.Limit {
+		ranked = ranked[:query.Limit]
+	}
+
+	results := make([]SearchResult, 0, len(ranked))
+	for _, result := range ranked {
+		key := keys[result]
+		var timestamp time.Time
+		err := store.db.QueryRow(
+			`SELECT m.id, COALESCE(m.sender, ''), COALESCE(c.name, ''), COALESCE(m.content, ''), m.timestamp,
+				COALESCE(m.is_from_me, 0), COALESCE(m.media_type, ''), COALESCE(m.filename, '')
+			FROM messages m
+			LEFT JOIN chats c ON c.jid = m.sender
+			WHERE m.id = ? AND m.chat_jid = ?`,
+			key.messageID, key.chatJID,
+		).Scan(&result.ID, &result.Sender, &result.SenderName, &result.Content, &timestamp, &result.IsFromMe, &result.MediaType, &result.Filename)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Time = timestamp
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// escapeLikePattern escapes LIKE wildcards so search terms match literally.
+func escapeLikePattern(term string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(term)
+}
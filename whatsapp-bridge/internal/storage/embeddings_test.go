@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVectorEncodingRoundTrip(t *testing.T) {
+	vector := []float32{0.25, -1.5, 3}
+	encoded := encodeVector(vector)
+	if len(encoded) != 12 {
+		t.Fatalf("expected 12 bytes, got %d", len(encoded))
+	}
+	if got := decodeVector(encoded); !reflect.DeepEqual(got, vector) {
+		t.Fatalf("unexpected decoded vector: %v", got)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := cosineSimilarity([]float32{1, 0}, []float32{1, 0}); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("expected identical vectors to score 1, got %f", got)
+	}
+	if got := cosineSimilarity([]float32{1, 0}, []float32{0, 1}); got != 0 {
+		t.Fatalf("expected orthogonal vectors to score 0, got %f", got)
+	}
+	if got := cosineSimilarity([]float32{1, 0}, []float32{1, 0, 0}); got != 0 {
+		t.Fatalf("expected mismatched dimensions to score 0, got %f", got)
+	}
+}
+
+func TestEscapeLikePattern(t *testing.T) {
+	if got := escapeLikePattern(`50%_off\`); got != `50\%\_off\\` {
+		t.Fatalf("unexpected escaped pattern: %q", got)
+	}
+}
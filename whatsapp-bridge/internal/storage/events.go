@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MaxJournalEventsPage caps how many journal events one API page returns.
+const MaxJournalEventsPage = 1000
+
+// JournalEvent is one bridge event recorded in the event journal.
+type JournalEvent struct {
+	// Seq increases with every appended event and is never reused.
+	Seq        int64
+	Type       string
+	OccurredAt time.Time
+	// ChatJID, SenderID and MessageType describe the event for subscription
+	// filters; they are empty when they do not apply.
+	ChatJID     string
+	SenderID    string
+	MessageType string
+	Data        json.RawMessage
+}
+
+// ensureEventJournalSchema creates the event journal and the cursors that
+// track how far each consumer has acknowledged it.
+func ensureEventJournalSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_journal (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_type TEXT NOT NULL,
+			occurred_at TIMESTAMP NOT NULL,
+			data TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_event_journal_created_at ON event_journal(created_at);
+
+		CREATE TABLE IF NOT EXISTS event_cursors (
+			name TEXT PRIMARY KEY,
+			seq INTEGER NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure event journal tables: %v", err)
+	}
+	return ensureTableColumns(db, "event_journal", []schemaColumn{
+		{name: "chat_jid", definition: "TEXT NOT NULL DEFAULT ''"},
+		{name: "sender_id", definition: "TEXT NOT NULL DEFAULT ''"},
+		{name: "message_type", definition: "TEXT NOT NULL DEFAULT ''"},
+	})
+}
+
+// AppendEvent records an event and returns its sequence number; event.Seq is
+// ignored.
+func (store *MessageStore) AppendEvent(event JournalEvent) (int64, error) {
+	result, err := store.db.Exec(
+		`INSERT INTO event_journal (event_type, occurred_at, chat_jid, sender_id, message_type, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		event.Type, normalizeToUTC(event.OccurredAt), event.ChatJID, event.SenderID, event.MessageType,
+		string(event.Data), time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetEventsAfter returns up to limit journal events with a sequence number
+// above afterSeq, oldest first.
+func (store *MessageStore) GetEventsAfter(afterSeq int64, limit int) ([]JournalEvent, error) {
+	if limit <= 0 {
+		limit = MaxJournalEventsPage
+	}
+	rows, err := store.db.Query(
+		`SELECT seq, event_type, occurred_at, chat_jid, sender_id, message_type, data
+		FROM event_journal WHERE seq > ? ORDER BY seq ASC LIMIT ?`,
+		afterSeq, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []JournalEvent
+	for rows.Next() {
+		var event JournalEvent
+		var data string
+		if err := rows.Scan(&event.Seq, &event.Type, &event.OccurredAt, &event.ChatJID, &event.SenderID,
+			&event.MessageType, &data); err != nil {
+			return nil, err
+		}
+		event.Data = json.RawMessage(data)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// LatestEventSeq returns the sequence number of the newest journal event, or
+// zero when the journal has never held one.
+func (store *MessageStore) LatestEventSeq() (int64, error) {
+	var seq int64
+	err := store.db.QueryRow("SELECT seq FROM sqlite_sequence WHERE name = 'event_journal'").Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// GetEventCursor returns the last sequence number a consumer acknowledged,
+// and false when it has no cursor yet.
+func (store *MessageStore) GetEventCursor(name string) (int64, bool, error) {
+	var seq int64
+	err := store.db.QueryRow("SELECT seq FROM event_cursors WHERE name = ?", name).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return seq, true, nil
+}
+
+// SetEventCursor records a consumer's acknowledged sequence number. Cursors
+// only move forward.
+func (store *MessageStore) SetEventCursor(name string, seq int64) error {
+	_, err := store.db.Exec(
+		`INSERT INTO event_cursors (name, seq, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET seq = MAX(seq, excluded.seq), updated_at = excluded.updated_at`,
+		name, seq, time.Now().UTC(),
+	)
+	return err
+}
+
+// PruneEvents deletes journal events recorded before cutoff.
+func (store *MessageStore) PruneEvents(cutoff time.Time) (int64, error) {
+	result, err := store.db.Exec("DELETE FROM event_journal WHERE created_at < ?", cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,36 @@
+package storage
+
+// ExportMessage is a message as written to a chat export, with the path of
+// its downloaded media when there is one.
+type ExportMessage struct {
+	Message
+	LocalPath string
+}
+
+// GetChatExportMessages returns every stored message in a chat, oldest first.
+func (store *MessageStore) GetChatExportMessages(chatJID string) ([]ExportMessage, error) {
+	rows, err := store.db.Query(
+		`SELECT `+messageColumns+`, COALESCE(m.local_path, '')
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.sender
+		WHERE m.chat_jid = ?
+		ORDER BY m.timestamp ASC`,
+		chatJID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []ExportMessage
+	for rows.Next() {
+		var msg ExportMessage
+		if err := rows.Scan(&msg.ID, &msg.ChatJID, &msg.Sender, &msg.SenderName, &msg.Content, &msg.Time,
+			&msg.IsFromMe, &msg.IsSelfChat, &msg.MediaType, &msg.Filename, &msg.QuotedMessageID,
+			&msg.MediaSeconds, &msg.VoiceNote, &msg.IsForwarded, &msg.ForwardingScore, &msg.Seq, &msg.LocalPath); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
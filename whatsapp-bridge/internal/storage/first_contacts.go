@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ensureFirstContactsSchema creates the first_contacts table, which remembers
+// every sender a first-contact check has run for.
+func ensureFirstContactsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS first_contacts (
+			sender_id TEXT PRIMARY KEY,
+			chat_jid TEXT NOT NULL,
+			message_id TEXT NOT NULL,
+			push_name TEXT,
+			first_seen_at TIMESTAMP NOT NULL,
+			is_new BOOLEAN NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure first_contacts table: %v", err)
+	}
+	return nil
+}
+
+// RecordFirstContact records that senderID messaged the account in messageID
+// and reports whether this is their first contact: the first time the sender
+// was checked, with no stored message from them or in their direct chat
+// besides this one. It reports true at most once per sender.
+func (store *MessageStore) RecordFirstContact(senderID, chatJID, messageID, pushName string, at time.Time) (bool, error) {
+	if senderID == "" {
+		return false, nil
+	}
+	tx, err := store.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var known int
+	err = tx.QueryRow(`SELECT 1 FROM first_contacts WHERE sender_id = ?`, senderID).Scan(&known)
+	if err == nil {
+		return false, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	var prior int
+	err = tx.QueryRow(
+		`SELECT 1 FROM messages WHERE (sender = ? OR chat_jid = ?) AND NOT (id = ? AND chat_jid = ?) LIMIT 1`,
+		senderID, senderID, messageID, chatJID,
+	).Scan(&prior)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	isNew := err == sql.ErrNoRows
+
+	if _, err := tx.Exec(
+		`INSERT INTO first_contacts (sender_id, chat_jid, message_id, push_name, first_seen_at, is_new) VALUES (?, ?, ?, NULLIF(?, ''), ?, ?)`,
+		senderID, chatJID, messageID, pushName, normalizeToUTC(at), isNew,
+	); err != nil {
+		return false, err
+	}
+	return isNew, tx.Commit()
+}
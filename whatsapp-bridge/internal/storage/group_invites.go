@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// Group invite statuses tracked per invited phone number.
+const (
+	GroupInviteAdded         = "added"
+	GroupInviteAlreadyMember = "already_member"
+	GroupInviteInvited       = "invited"
+	GroupInviteJoined        = "joined"
+	GroupInviteFailed        = "failed"
+)
+
+// GroupInvite tracks one phone number invited to a group.
+type GroupInvite struct {
+	GroupJID      string
+	Phone         string
+	ParticipantID string
+	Status        string
+	Detail        string
+	InvitedAt     time.Time
+	JoinedAt      *time.Time
+}
+
+// ensureGroupInvitesSchema creates the group_invites table.
+func ensureGroupInvitesSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS group_invites (
+			group_jid TEXT NOT NULL,
+			phone TEXT NOT NULL,
+			participant_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			detail TEXT,
+			invited_at TIMESTAMP NOT NULL,
+			joined_at TIMESTAMP,
+			PRIMARY KEY (group_jid, phone)
+		);
+		CREATE INDEX IF NOT EXISTS idx_group_invites_participant ON group_invites(group_jid, participant_id);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure group_invites table: %v", err)
+	}
+	return nil
+}
+
+// RecordGroupInvite stores the outcome of inviting a phone number to a group.
+func (store *MessageStore) RecordGroupInvite(invite GroupInvite) error {
+	var joinedAt interface{}
+	if invite.Status == GroupInviteAdded || invite.Status == GroupInviteAlreadyMember {
+		joinedAt = normalizeToUTC(invite.InvitedAt)
+	}
+	_, err := store.db.Exec(
+		`INSERT INTO group_invites (group_jid, phone, participant_id, status, detail, invited_at, joined_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(group_jid, phone) DO UPDATE SET
+			participant_id = excluded.participant_id,
+			status = excluded.status,
+			detail = excluded.detail,
+			invited_at = excluded.invited_at,
+			joined_at = excluded.joined_at`,
+		jid.NormalizeChat(invite.GroupJID), jid.NormalizeUser(invite.Phone), jid.NormalizeUser(invite.ParticipantID),
+		invite.Status, invite.Detail, normalizeToUTC(invite.InvitedAt), joinedAt,
+	)
+	return err
+}
+
+// MarkGroupInvitesJoined marks pending invites as joined for members who just
+// joined the group, matched by participant ID or phone number.
+func (store *MessageStore) MarkGroupInvitesJoined(groupJID string, memberIDs []string, joinedAt time.Time) error {
+	var ids []interface{}
+	for _, id := range memberIDs {
+		if normalized := jid.NormalizeUser(id); normalized != "" {
+			ids = append(ids, normalized)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+
+	args := []interface{}{GroupInviteJoined, normalizeToUTC(joinedAt), jid.NormalizeChat(groupJID), GroupInviteInvited}
+	args = append(args, ids...)
+	args = append(args, ids...)
+	_, err := store.db.Exec(
+		`UPDATE group_invites SET status = ?, joined_at = ?
+		WHERE group_jid = ? AND status = ?
+			AND (participant_id IN (`+placeholders+`) OR phone IN (`+placeholders+`))`,
+		args...,
+	)
+	return err
+}
+
+// ListGroupInvites returns a group's tracked invites, newest first.
+func (store *MessageStore) ListGroupInvites(groupJID string) ([]GroupInvite, error) {
+	rows, err := store.db.Query(
+		`SELECT group_jid, phone, participant_id, status, COALESCE(detail, ''), invited_at, joined_at
+		FROM group_invites WHERE group_jid = ?
+		ORDER BY invited_at DESC, phone`,
+		jid.NormalizeChat(groupJID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []GroupInvite
+	for rows.Next() {
+		var invite GroupInvite
+		var joinedAt sql.NullTime
+		if err := rows.Scan(&invite.GroupJID, &invite.Phone, &invite.ParticipantID, &invite.Status, &invite.Detail, &invite.InvitedAt, &joinedAt); err != nil {
+			return nil, err
+		}
+		if joinedAt.Valid {
+			invite.JoinedAt = &joinedAt.Time
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
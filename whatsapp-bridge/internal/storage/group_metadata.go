@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// GroupParticipant is a member of a group as last refreshed from WhatsApp.
+type GroupParticipant struct {
+	ParticipantID string
+	IsAdmin       bool
+	IsSuperAdmin  bool
+}
+
+// GroupMetadata is a group's name, topic, photo and members as last
+// refreshed from WhatsApp.
+type GroupMetadata struct {
+	GroupJID     string
+	Name         string
+	Topic        string
+	PhotoID      string
+	PhotoURL     string
+	Participants []GroupParticipant
+	RefreshedAt  time.Time
+}
+
+// ensureGroupMetadataSchema creates the group_metadata and group_participants
+// tables.
+func ensureGroupMetadataSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS group_metadata (
+			group_jid TEXT PRIMARY KEY,
+			name TEXT,
+			topic TEXT,
+			photo_id TEXT,
+			photo_url TEXT,
+			participant_count INTEGER NOT NULL DEFAULT 0,
+			refreshed_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS group_participants (
+			group_jid TEXT NOT NULL,
+			participant_id TEXT NOT NULL,
+			is_admin BOOLEAN NOT NULL DEFAULT 0,
+			is_super_admin BOOLEAN NOT NULL DEFAULT 0,
+			PRIMARY KEY (group_jid, participant_id)
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure group metadata tables: %v", err)
+	}
+	return nil
+}
+
+// ListGroupChatJIDs returns the stored group chats. With placeholdersOnly it
+// keeps only groups without a real name: no name, or the "Group <id>" name
+// stored when group info could not be fetched.
+func (store *MessageStore) ListGroupChatJIDs(placeholdersOnly bool) ([]string, error) {
+	if placeholdersOnly {
+		return store.ListPlaceholderChatJIDs(jid.ChatTypeGroup)
+	}
+	rows, err := store.db.Query(`SELECT jid FROM chats WHERE chat_type = ? ORDER BY jid`, jid.ChatTypeGroup)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []string
+	for rows.Next() {
+		var groupJID string
+		if err := rows.Scan(&groupJID); err != nil {
+			return nil, err
+		}
+		groups = append(groups, groupJID)
+	}
+	return groups, rows.Err()
+}
+
+// SaveGroupMetadata stores a refreshed snapshot of a group, replacing its
+// participant list and, when the snapshot has a name and no local override
+// is set, the chat's name.
+func (store *MessageStore) SaveGroupMetadata(metadata GroupMetadata) error {
+	groupJID := jid.NormalizeChat(metadata.GroupJID)
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if metadata.Name != "" {
+		name, err := overriddenChatName(tx, groupJID, metadata.Name)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE chats SET name = ? WHERE jid = ?`, name, groupJID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.Exec(
+		`INSERT OR REPLACE INTO group_metadata (group_jid, name, topic, photo_id, photo_url, participant_count, refreshed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		groupJID, metadata.Name, metadata.Topic, metadata.PhotoID, metadata.PhotoURL, len(metadata.Participants), normalizeToUTC(metadata.RefreshedAt),
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM group_participants WHERE group_jid = ?`, groupJID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO group_participants (group_jid, participant_id, is_admin, is_super_admin) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, participant := range metadata.Participants {
+		participantID := jid.NormalizeUser(participant.ParticipantID)
+		if participantID == "" {
+			continue
+		}
+		if _, err := stmt.Exec(groupJID, participantID, participant.IsAdmin, participant.IsSuperAdmin); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetGroupMetadata returns the last refreshed snapshot of a group, or nil if
+// it has never been refreshed.
+func (store *MessageStore) GetGroupMetadata(groupJID string) (*GroupMetadata, error) {
+	groupJID = jid.NormalizeChat(groupJID)
+	metadata := GroupMetadata{GroupJID: groupJID}
+	err := store.db.QueryRow(
+		`SELECT COALESCE(name, ''), COALESCE(topic, ''), COALESCE(photo_id, ''), COALESCE(photo_url, ''), refreshed_at
+		FROM group_metadata WHERE group_jid = ?`,
+		groupJID,
+	).Scan(&metadata.Name, &metadata.Topic, &metadata.PhotoID, &metadata.PhotoURL, &metadata.RefreshedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.db.Query(
+		`SELECT participant_id, is_admin, is_super_admin FROM group_participants
+		WHERE group_jid = ? ORDER BY is_super_admin DESC, is_admin DESC, participant_id`,
+		groupJID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var participant GroupParticipant
+		if err := rows.Scan(&participant.ParticipantID, &participant.IsAdmin, &participant.IsSuperAdmin); err != nil {
+			return nil, err
+		}
+		metadata.Participants = append(metadata.Participants, participant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"whatsapp-client/internal/jid"
+)
+
+// repairGroupSenders clears the sender of incoming group messages that were
+// attributed to the group itself, as history sync did when a message named no
+// participant. An unknown sender is filled in later, when the message is
+// synced again or quoted with its author; a wrong one never would be.
+func repairGroupSenders(db *sql.DB) error {
+	if _, err := db.Exec(`
+		UPDATE messages SET sender = '', raw_sender = NULL, sender_server = NULL
+		WHERE chat_jid LIKE '%@g.us'
+			AND COALESCE(is_from_me, 0) = 0
+			AND sender = substr(chat_jid, 1, instr(chat_jid, '@') - 1)
+	`); err != nil {
+		return fmt.Errorf("failed to repair group message senders: %v", err)
+	}
+	return nil
+}
+
+// AttributeUnknownSender sets the sender of a stored message whose author is
+// unknown, typically learned from a later message quoting it. Messages with a
+// known sender are left alone. It reports whether a message was updated.
+func (store *MessageStore) AttributeUnknownSender(id, chatJID, sender, rawSender, senderServer string) (bool, error) {
+	if id == "" || sender == "" {
+		return false, nil
+	}
+	result, err := store.db.Exec(
+		`UPDATE messages SET
+			sender = COALESCE((SELECT canonical_id FROM sender_id_aliases WHERE alias_id = ?), ?),
+			raw_sender = NULLIF(?, ''),
+			sender_server = NULLIF(?, '')
+		WHERE id = ? AND chat_jid = ? AND COALESCE(is_from_me, 0) = 0 AND COALESCE(sender, '') = ''`,
+		sender, sender, jid.NormalizeUser(rawSender), jid.NormalizeServer(senderServer), id, chatJID,
+	)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	return updated > 0, err
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// StoreImportedChat records a chat for imported history. An existing chat
+// keeps its name and only moves its last message time forward, so older
+// history never makes a chat look stale.
+func (store *MessageStore) StoreImportedChat(chatJID, name string, lastMessageTime time.Time) error {
+	_, err := store.db.Exec(
+		`INSERT INTO chats (jid, name, last_message_time, chat_type) VALUES (?, ?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			name = COALESCE(NULLIF(chats.name, ''), excluded.name),
+			last_message_time = CASE
+				WHEN chats.last_message_time IS NULL OR chats.last_message_time < excluded.last_message_time
+				THEN excluded.last_message_time ELSE chats.last_message_time END`,
+		chatJID, name, normalizeToUTC(lastMessageTime), jid.ChatType(chatJID),
+	)
+	return err
+}
+
+// phoneNameCharacters are the characters WhatsApp uses to show an unsaved
+// contact's phone number in place of a name.
+const phoneNameCharacters = "+0123456789 -()"
+
+// ResolveSenderName maps a display name, as written in chat exports, to the
+// sender ID it belongs to. Names shown as phone numbers become that number;
+// other names match a direct chat's stored name, preferring the most recent
+// chat when several share it. Aliases resolve to their canonical ID. It
+// returns "" when the name is unknown.
+func (store *MessageStore) ResolveSenderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil
+	}
+
+	var senderID string
+	if strings.Trim(name, phoneNameCharacters) == "" && strings.ContainsAny(name, "0123456789") {
+		senderID = strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, name)
+	} else {
+		err := store.db.QueryRow(
+			`SELECT jid FROM chats WHERE name = ? AND chat_type = ?
+			ORDER BY last_message_time DESC LIMIT 1`,
+			name, jid.ChatTypeDirect,
+		).Scan(&senderID)
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var canonical string
+	err := store.db.QueryRow("SELECT canonical_id FROM sender_id_aliases WHERE alias_id = ?", senderID).Scan(&canonical)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if canonical != "" {
+		return canonical, nil
+	}
+	return senderID, nil
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// InboxChat is a chat waiting on the account: its last message is incoming.
+type InboxChat struct {
+	ChatJID  string
+	ChatName string
+	// Unread counts incoming messages after both the account's last reply and
+	// the last time it read the chat on another device.
+	Unread int
+	// Waiting counts incoming messages since the account's last reply.
+	Waiting      int
+	LastInbound  bool
+	LastMessage  DigestMessage
+	LastReplyAt  *time.Time
+	WaitingSince time.Time
+	// Tags are the importance tags of the chat's contact, or of the group
+	// members it is waiting on.
+	Tags  []string
+	Score int
+}
+
+// ensureChatReadsSchema creates the chat_reads table, which keeps the latest
+// time the account read each chat on one of its devices.
+func ensureChatReadsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS chat_reads (
+			chat_jid TEXT PRIMARY KEY,
+			read_at TIMESTAMP NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure chat_reads table: %v", err)
+	}
+	return nil
+}
+
+// MarkChatRead records that the account read a chat up to at. Earlier marks
+// never move it back.
+func (store *MessageStore) MarkChatRead(chatJID string, at time.Time) error {
+	_, err := store.db.Exec(
+		`INSERT INTO chat_reads (chat_jid, read_at) VALUES (?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET read_at = MAX(read_at, excluded.read_at)`,
+		chatJID, normalizeToUTC(at),
+	)
+	return err
+}
+
+// inboxTagWeights is how much each importance tag adds to a chat's score.
+var inboxTagWeights = map[string]int{
+	ContactTagVIP:       40,
+	ContactTagImportant: 20,
+	ContactTagLow:       -15,
+}
+
+// maxInboxWaitHours caps how much waiting time adds to a chat's score, so
+// chats left unanswered for weeks do not bury fresh ones.
+const maxInboxWaitHours = 72
+
+// scoreInboxChat ranks a chat needing attention: unanswered chats start at 20,
+// each unread message adds 5 (up to 10 messages), every two hours waited adds
+// 1 (up to three days) and importance tags add their weight. Chats whose last
+// message is outgoing score 0.
+func scoreInboxChat(chat InboxChat, now time.Time) int {
+	if !chat.LastInbound {
+		return 0
+	}
+	score := 20
+	score += 5 * min(chat.Unread, 10)
+	waited := int(now.Sub(chat.WaitingSince).Hours())
+	score += max(0, min(waited, maxInboxWaitHours)) / 2
+
+	// The strongest boosting tag wins; a lowering tag only applies alone.
+	tagWeight := 0
+	for _, tag := range chat.Tags {
+		if weight := inboxTagWeights[tag]; weight > tagWeight || (tagWeight <= 0 && weight < tagWeight) {
+			tagWeight = weight
+		}
+	}
+	return max(1, score+tagWeight)
+}
+
+// GetInbox returns the chats waiting on the account, highest score first. It
+// looks at messages since since; chats snoozed past now, quarantined
+// messages, status updates, newsletters and broadcast lists are left out.
+func (store *MessageStore) GetInbox(since, now time.Time, limit int) ([]InboxChat, error) {
+	readAt, err := store.chatReads()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := store.allContactTags()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.db.Query(
+		`SELECT m.chat_jid, COALESCE(c.name, ''), m.id, COALESCE(m.sender, ''), COALESCE(s.name, ''),
+			COALESCE(m.content, ''), COALESCE(m.media_type, ''), m.timestamp, COALESCE(m.is_from_me, 0)
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.chat_jid
+		LEFT JOIN chats s ON s.jid = m.sender
+		WHERE m.timestamp >= ?
+			AND COALESCE(m.quarantined, 0) = 0
+			AND NOT EXISTS (
+				SELECT 1 FROM chat_settings cs WHERE cs.chat_jid = m.chat_jid AND cs.snoozed_until > ?
+			)
+		ORDER BY m.chat_jid, m.timestamp DESC`,
+		normalizeToUTC(since), normalizeToUTC(now),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Rows come newest first per chat: a chat is in the inbox when its first
+	// row is incoming, and its waiting messages run until the first reply.
+	var chats []InboxChat
+	waitingOn := map[string][]string{}
+	lastChat, done := "", false
+	for rows.Next() {
+		var chatJID, chatName string
+		var msg DigestMessage
+		var fromMe bool
+		if err := rows.Scan(&chatJID, &chatName, &msg.MessageID, &msg.SenderID, &msg.SenderName, &msg.Content, &msg.MediaType, &msg.Timestamp, &fromMe); err != nil {
+			return nil, err
+		}
+		if chatJID != lastChat {
+			lastChat, done = chatJID, fromMe
+			if !done && (jid.IsGroup(chatJID) || jid.IsPersonal(chatJID)) {
+				chats = append(chats, InboxChat{ChatJID: chatJID, ChatName: chatName, LastInbound: true, LastMessage: msg})
+			} else {
+				done = true
+			}
+		}
+		if done {
+			continue
+		}
+		chat := &chats[len(chats)-1]
+		if fromMe {
+			replyAt := msg.Timestamp
+			chat.LastReplyAt = &replyAt
+			done = true
+			continue
+		}
+		chat.Waiting++
+		chat.WaitingSince = msg.Timestamp
+		if read, ok := readAt[chatJID]; !ok || msg.Timestamp.After(read) {
+			chat.Unread++
+		}
+		if jid.IsGroup(chatJID) && msg.SenderID != "" {
+			waitingOn[chatJID] = append(waitingOn[chatJID], msg.SenderID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range chats {
+		chats[i].Tags = inboxTags(tags, chats[i].ChatJID, waitingOn[chats[i].ChatJID])
+		chats[i].Score = scoreInboxChat(chats[i], now)
+	}
+	sort.SliceStable(chats, func(i, j int) bool {
+		if chats[i].Score != chats[j].Score {
+			return chats[i].Score > chats[j].Score
+		}
+		return chats[i].LastMessage.Timestamp.After(chats[j].LastMessage.Timestamp)
+	})
+	if limit > 0 && len(chats) > limit {
+		chats = chats[:limit]
+	}
+	return chats, nil
+}
+
+// inboxTags returns the importance tags of a direct chat's contact, or the
+// combined tags of the group members a group chat is waiting on.
+func inboxTags(tags map[string][]string, chatJID string, senders []string) []string {
+	if !jid.IsGroup(chatJID) {
+		return tags[chatJID]
+	}
+	seen := map[string]bool{}
+	var combined []string
+	for _, sender := range senders {
+		for _, tag := range tags[sender] {
+			if !seen[tag] {
+				seen[tag] = true
+				combined = append(combined, tag)
+			}
+		}
+	}
+	sort.Strings(combined)
+	return combined
+}
+
+func (store *MessageStore) chatReads() (map[string]time.Time, error) {
+	rows, err := store.db.Query("SELECT chat_jid, read_at FROM chat_reads")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reads := map[string]time.Time{}
+	for rows.Next() {
+		var chatJID string
+		var readAt time.Time
+		if err := rows.Scan(&chatJID, &readAt); err != nil {
+			return nil, err
+		}
+		reads[chatJID] = readAt
+	}
+	return reads, rows.Err()
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScoreInboxChat(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	waiting := func(hours int, unread int, tags ...string) InboxChat {
+		return InboxChat{LastInbound: true, Unread: unread, WaitingSince: now.Add(-time.Duration(hours) * time.Hour), Tags: tags}
+	}
+
+	cases := []struct {
+		name string
+		chat InboxChat
+		want int
+	}{
+		{"answered", InboxChat{Unread: 3}, 0},
+		{"fresh", waiting(0, 1), 25},
+		{"unread capped", waiting(0, 40), 70},
+		{"wait capped", waiting(500, 0), 56},
+		{"vip", waiting(4, 1, ContactTagVIP), 67},
+		{"vip beats low", waiting(4, 1, ContactTagLow, ContactTagVIP), 67},
+		{"low", waiting(4, 1, ContactTagLow), 12},
+		{"unknown tag", waiting(4, 1, "family"), 27},
+	}
+	for _, tc := range cases {
+		if got := scoreInboxChat(tc.chat, now); got != tc.want {
+			t.Errorf("%s: scoreInboxChat = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
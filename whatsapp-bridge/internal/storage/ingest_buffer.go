@@ -0,0 +1,330 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ingestJournalName          = "ingest-journal.jsonl"
+	ingestFlushingSuffix       = ".flushing"
+	defaultIngestBatchSize     = 500
+	defaultIngestFlushInterval = time.Second
+)
+
+type ingestBufferConfig struct {
+	enabled       bool
+	batchSize     int
+	flushInterval time.Duration
+}
+
+// parseIngestBufferConfig reads WHATSAPP_INGEST_BUFFER, which turns on the
+// write-ahead ingest buffer, and its WHATSAPP_INGEST_BUFFER_BATCH_SIZE and
+// WHATSAPP_INGEST_BUFFER_FLUSH_MS tuning.
+func parseIngestBufferConfig() ingestBufferConfig {
+	cfg := ingestBufferConfig{batchSize: defaultIngestBatchSize, flushInterval: defaultIngestFlushInterval}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_INGEST_BUFFER")); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			fmt.Printf("Warning: invalid WHATSAPP_INGEST_BUFFER=%q, treating as false\n", raw)
+		}
+		cfg.enabled = enabled
+	}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_INGEST_BUFFER_BATCH_SIZE")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			cfg.batchSize = parsed
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_INGEST_BUFFER_FLUSH_MS")); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			cfg.flushInterval = time.Duration(parsed) * time.Millisecond
+		}
+	}
+	return cfg
+}
+
+// ingestBuffer holds history-synced messages appended to an on-disk journal
+// but not yet written to sqlite. Appending and syncing a conversation's lines
+// is cheap next to a sqlite commit per message, so history sync never waits
+// on sqlite; batches are stored in one transaction each, and a journal left
+// behind by a crash is replayed on the next start. Live messages bypass the
+// buffer: the message pipeline reads them back as soon as they are stored.
+type ingestBuffer struct {
+	mu        sync.Mutex
+	path      string
+	file      *os.File
+	pending   []StoredMessage
+	batchSize int
+	// flushMu serializes flushes, which each rotate the journal.
+	flushMu sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// startIngestBuffer replays any journal left in dir by an earlier run, then
+// starts buffering messages passed to BufferMessages. A journal that fails to
+// replay is kept under a new name and its error returned.
+func (store *MessageStore) startIngestBuffer(dir string, cfg ingestBufferConfig) error {
+	path := filepath.Join(dir, ingestJournalName)
+	// A crash mid-flush leaves the batch being written in the flushing
+	// journal, which predates everything in the live one.
+	for _, journal := range []string{path + ingestFlushingSuffix, path} {
+		replayed, err := store.replayIngestJournal(journal)
+		if err != nil {
+			return err
+		}
+		if replayed > 0 {
+			fmt.Printf("Replayed %d buffered messages from the ingest journal\n", replayed)
+		}
+	}
+
+	file, err := openIngestJournal(path)
+	if err != nil {
+		return err
+	}
+	buffer := &ingestBuffer{
+		path:      path,
+		file:      file,
+		batchSize: cfg.batchSize,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	store.ingest = buffer
+
+	go func() {
+		defer close(buffer.done)
+		ticker := time.NewTicker(cfg.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := store.FlushIngestBuffer(); err != nil {
+					fmt.Printf("Warning: failed to flush ingest buffer: %v\n", err)
+				}
+			case <-buffer.stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func openIngestJournal(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ingest journal: %v", err)
+	}
+	return file, nil
+}
+
+// setAsideIngestJournal renames a journal that could not be stored out of
+// the way of later runs, so its messages can still be recovered by hand.
+func setAsideIngestJournal(path string) string {
+	aside := fmt.Sprintf("%s.failed-%d", path, time.Now().UnixNano())
+	if err := os.Rename(path, aside); err != nil {
+		fmt.Printf("Warning: failed to set aside ingest journal %s: %v\n", path, err)
+		return path
+	}
+	return aside
+}
+
+// replayIngestJournal stores the messages of a journal left by a run that
+// did not flush it, removes it, and returns how many it found. Unless every
+// message is stored the journal is set aside instead and an error returned.
+func (store *MessageStore) replayIngestJournal(path string) (int, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open ingest journal: %v", err)
+	}
+
+	var messages []StoredMessage
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg StoredMessage
+		// A crash mid-append leaves a torn last line; skip it.
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	err = scanner.Err()
+	file.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read ingest journal, kept as %s: %v", setAsideIngestJournal(path), err)
+	}
+	if err := store.storeMessageBatch(messages); err != nil {
+		return 0, fmt.Errorf("failed to replay ingest journal, kept as %s: %v", setAsideIngestJournal(path), err)
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, fmt.Errorf("failed to remove replayed ingest journal: %v", err)
+	}
+	return len(messages), nil
+}
+
+// BufferMessage buffers one message; see BufferMessages.
+func (store *MessageStore) BufferMessage(msg StoredMessage) error {
+	if store.ingest == nil {
+		return store.StoreMessage(msg)
+	}
+	return store.BufferMessages([]StoredMessage{msg})
+}
+
+// BufferMessages queues messages for storage through the ingest buffer when
+// it is enabled, and otherwise stores them right away in one transaction.
+// They are journaled and synced to disk together before it returns, and
+// become visible to readers once their batch is flushed.
+func (store *MessageStore) BufferMessages(messages []StoredMessage) error {
+	buffer := store.ingest
+	if buffer == nil {
+		return store.storeMessageBatch(messages)
+	}
+	var lines []byte
+	kept := make([]StoredMessage, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Content == "" && msg.MediaType == "" {
+			continue
+		}
+		line, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+		kept = append(kept, msg)
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	buffer.mu.Lock()
+	if _, err := buffer.file.Write(lines); err != nil {
+		buffer.mu.Unlock()
+		return fmt.Errorf("failed to append to ingest journal: %v", err)
+	}
+	if err := buffer.file.Sync(); err != nil {
+		buffer.mu.Unlock()
+		return fmt.Errorf("failed to sync ingest journal: %v", err)
+	}
+	buffer.pending = append(buffer.pending, kept...)
+	full := len(buffer.pending) >= buffer.batchSize
+	buffer.mu.Unlock()
+
+	if full {
+		return store.FlushIngestBuffer()
+	}
+	return nil
+}
+
+// FlushIngestBuffer stores every buffered message. The pending batch and its
+// journal are swapped out under the lock, so messages keep being buffered
+// into a fresh journal while the batch is written; the batch's journal is
+// removed once stored, or set aside when any of its messages failed.
+func (store *MessageStore) FlushIngestBuffer() error {
+	buffer := store.ingest
+	if buffer == nil {
+		return nil
+	}
+	buffer.flushMu.Lock()
+	defer buffer.flushMu.Unlock()
+
+	flushing := buffer.path + ingestFlushingSuffix
+	buffer.mu.Lock()
+	pending := buffer.pending
+	if len(pending) == 0 {
+		buffer.mu.Unlock()
+		return nil
+	}
+	if err := os.Rename(buffer.path, flushing); err != nil {
+		buffer.mu.Unlock()
+		return fmt.Errorf("failed to rotate ingest journal: %v", err)
+	}
+	file, err := openIngestJournal(buffer.path)
+	if err != nil {
+		os.Rename(flushing, buffer.path)
+		buffer.mu.Unlock()
+		return err
+	}
+	previous := buffer.file
+	buffer.file, buffer.pending = file, nil
+	buffer.mu.Unlock()
+
+	if err := previous.Close(); err != nil {
+		fmt.Printf("Warning: failed to close ingest journal: %v\n", err)
+	}
+	if err := store.storeMessageBatch(pending); err != nil {
+		return fmt.Errorf("%v; journal kept as %s", err, setAsideIngestJournal(flushing))
+	}
+	if err := os.Remove(flushing); err != nil {
+		return fmt.Errorf("failed to remove flushed ingest journal: %v", err)
+	}
+	return nil
+}
+
+// storeMessageBatch stores messages in one transaction. If that fails it
+// falls back to storing them one by one, so a single bad message cannot hold
+// back the rest, and returns an error counting the messages that still
+// failed.
+func (store *MessageStore) storeMessageBatch(messages []StoredMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	tx, err := store.db.Begin()
+	if err == nil {
+		for _, msg := range messages {
+			if msg.Content == "" && msg.MediaType == "" {
+				continue
+			}
+			if err = store.storeMessage(tx, msg); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			if err = tx.Commit(); err == nil {
+				return nil
+			}
+		} else {
+			tx.Rollback()
+		}
+	}
+
+	fmt.Printf("Warning: failed to store a batch of %d buffered messages, storing them one by one: %v\n", len(messages), err)
+	failed := 0
+	var lastErr error
+	for _, msg := range messages {
+		if err := store.StoreMessage(msg); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to store %d of %d buffered messages: %v", failed, len(messages), lastErr)
+	}
+	return nil
+}
+
+// closeIngestBuffer stops the flush ticker and stores what is still buffered.
+func (store *MessageStore) closeIngestBuffer() {
+	buffer := store.ingest
+	if buffer == nil {
+		return
+	}
+	close(buffer.stop)
+	<-buffer.done
+	if err := store.FlushIngestBuffer(); err != nil {
+		fmt.Printf("Warning: final ingest buffer flush failed: %v\n", err)
+	}
+	if err := buffer.file.Close(); err != nil {
+		fmt.Printf("Warning: failed to close ingest journal: %v\n", err)
+	}
+	store.ingest = nil
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeIngestJournal(t *testing.T, path string, messages ...StoredMessage) {
+	t.Helper()
+	var lines []byte
+	for _, msg := range messages {
+		line, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+	if err := os.WriteFile(path, lines, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testJournalDir is the directory a store opened with the current
+// environment keeps its ingest journal in.
+func testJournalDir(t *testing.T) string {
+	t.Helper()
+	paths, err := ResolveRuntimePathsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Dir(paths.PersistentMessagesDB)
+}
+
+func TestIngestBufferJournalsAndFlushes(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("WHATSAPP_INGEST_BUFFER", "true")
+	t.Setenv("WHATSAPP_INGEST_BUFFER_FLUSH_MS", "3600000")
+	store := newTestStore(t, dir)
+	journalDir := testJournalDir(t)
+
+	chatJID := "15550001111"
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.StoreChat(chatJID, "Alice", at); err != nil {
+		t.Fatalf("StoreChat: %v", err)
+	}
+	if err := store.BufferMessages([]StoredMessage{
+		{ID: "A1", ChatJID: chatJID, Sender: "15550001111", Content: "first", Timestamp: at},
+		{ID: "A2", ChatJID: chatJID, Sender: "15550001111", Content: "second", Timestamp: at.Add(time.Second)},
+	}); err != nil {
+		t.Fatalf("BufferMessages: %v", err)
+	}
+
+	journal, err := os.ReadFile(filepath.Join(journalDir, ingestJournalName))
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	if lines := strings.Count(string(journal), "\n"); lines != 2 {
+		t.Fatalf("expected 2 journaled messages before the flush, got %d", lines)
+	}
+
+	if err := store.FlushIngestBuffer(); err != nil {
+		t.Fatalf("FlushIngestBuffer: %v", err)
+	}
+	messages, err := store.GetMessages(chatJID, 10)
+	if err != nil {
+		t.Fatalf("GetMessages: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 stored messages after the flush, got %d", len(messages))
+	}
+	if _, err := os.Stat(filepath.Join(journalDir, ingestJournalName+ingestFlushingSuffix)); !os.IsNotExist(err) {
+		t.Fatalf("expected the flushed journal to be removed, stat err %v", err)
+	}
+}
+
+func TestIngestJournalReplay(t *testing.T) {
+	dir := t.TempDir()
+	chatJID := "15550001111"
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(t, dir)
+	if err := store.StoreChat(chatJID, "Alice", at); err != nil {
+		t.Fatalf("StoreChat: %v", err)
+	}
+	store.Close()
+
+	journalDir := testJournalDir(t)
+	writeIngestJournal(t, filepath.Join(journalDir, ingestJournalName+ingestFlushingSuffix),
+		StoredMessage{ID: "A1", ChatJID: chatJID, Sender: "15550001111", Content: "mid-flush", Timestamp: at})
+	writeIngestJournal(t, filepath.Join(journalDir, ingestJournalName),
+		StoredMessage{ID: "A2", ChatJID: chatJID, Sender: "15550001111", Content: "appended", Timestamp: at.Add(time.Second)})
+
+	t.Setenv("WHATSAPP_INGEST_BUFFER", "true")
+	store = newTestStore(t, dir)
+	messages, err := store.GetMessages(chatJID, 10)
+	if err != nil {
+		t.Fatalf("GetMessages: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected both journals replayed, got %d messages", len(messages))
+	}
+	if _, err := os.Stat(filepath.Join(journalDir, ingestJournalName+ingestFlushingSuffix)); !os.IsNotExist(err) {
+		t.Fatalf("expected the replayed journal to be removed, stat err %v", err)
+	}
+}
+
+func TestIngestJournalReplayFailureKeepsJournal(t *testing.T) {
+	dir := t.TempDir()
+	newTestStore(t, dir).Close()
+	journalDir := testJournalDir(t)
+
+	// Messages must reference a stored chat, so this one cannot be replayed.
+	writeIngestJournal(t, filepath.Join(journalDir, ingestJournalName),
+		StoredMessage{ID: "A1", ChatJID: "15550009999", Sender: "15550009999", Content: "orphan", Timestamp: time.Now()})
+
+	t.Setenv("WHATSAPP_INGEST_BUFFER", "true")
+	if store, err := NewMessageStore(); err == nil {
+		store.Close()
+		t.Fatal("expected the failed replay to be reported")
+	}
+
+	kept, err := filepath.Glob(filepath.Join(journalDir, ingestJournalName+".failed-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 1 {
+		t.Fatalf("expected the journal to be set aside, found %v", kept)
+	}
+	if journal, err := os.ReadFile(kept[0]); err != nil || !strings.Contains(string(journal), "orphan") {
+		t.Fatalf("expected the set-aside journal to keep its message, got %q (%v)", journal, err)
+	}
+}
+
+func TestSweepOrphansKeepsBufferedMessageEmbeddings(t *testing.T) {
+	t.Setenv("WHATSAPP_INGEST_BUFFER", "true")
+	t.Setenv("WHATSAPP_INGEST_BUFFER_FLUSH_MS", "3600000")
+	store := newTestStore(t, t.TempDir())
+
+	chatJID := "15550001111"
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.StoreChat(chatJID, "Alice", at); err != nil {
+		t.Fatalf("StoreChat: %v", err)
+	}
+	if err := store.BufferMessage(StoredMessage{ID: "B1", ChatJID: chatJID, Sender: chatJID, Content: "buffered", Timestamp: at}); err != nil {
+		t.Fatalf("BufferMessage: %v", err)
+	}
+	if err := store.StoreMessageEmbedding("B1", chatJID, "test", []float32{1, 0}); err != nil {
+		t.Fatalf("StoreMessageEmbedding: %v", err)
+	}
+	if _, err := store.db.Exec(
+		`INSERT INTO message_embeddings (message_id, chat_jid, model, dimensions, vector, created_at) VALUES ('GONE', ?, 'test', 2, x'00', ?)`,
+		chatJID, at,
+	); err != nil {
+		t.Fatalf("insert orphaned embedding: %v", err)
+	}
+
+	sweep, err := store.SweepOrphans()
+	if err != nil {
+		t.Fatalf("SweepOrphans: %v", err)
+	}
+	if sweep.EmbeddingsDeleted != 1 {
+		t.Fatalf("EmbeddingsDeleted = %d, want 1", sweep.EmbeddingsDeleted)
+	}
+	var ids []string
+	rows, err := store.db.Query("SELECT message_id FROM message_embeddings ORDER BY message_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if strings.Join(ids, ",") != "B1" {
+		t.Fatalf("embeddings after sweep = %v, want [B1]", ids)
+	}
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultOrphanSweepInterval = time.Hour
+
+// ensureReferentialIntegrity makes every message's chat exist. Chat rows
+// missing for stored messages are recreated. Databases whose messages table
+// predates its foreign key to chats get triggers enforcing the same rule,
+// since SQLite cannot add a foreign key to an existing table. Violations that
+// remain are reported rather than failing startup.
+func ensureReferentialIntegrity(db *sql.DB) error {
+	missing, err := findMessagesWithoutChats(db)
+	if err != nil {
+		return fmt.Errorf("failed to find messages without chats: %v", err)
+	}
+	if created, err := recreateMissingChats(db, missing); err != nil {
+		return fmt.Errorf("failed to recreate missing chats: %v", err)
+	} else if created > 0 {
+		fmt.Printf("Recreated %d missing chat rows for stored messages\n", created)
+	}
+
+	hasForeignKey, err := messagesReferenceChats(db)
+	if err != nil {
+		return err
+	}
+	if !hasForeignKey {
+		if _, err := db.Exec(`
+			CREATE TRIGGER IF NOT EXISTS messages_chat_insert_fk BEFORE INSERT ON messages
+			WHEN NEW.chat_jid IS NOT NULL AND NOT EXISTS (SELECT 1 FROM chats WHERE jid = NEW.chat_jid)
+			BEGIN SELECT RAISE(ABORT, 'FOREIGN KEY constraint failed'); END;
+
+			CREATE TRIGGER IF NOT EXISTS messages_chat_update_fk BEFORE UPDATE OF chat_jid ON messages
+			WHEN NEW.chat_jid IS NOT NULL AND NOT EXISTS (SELECT 1 FROM chats WHERE jid = NEW.chat_jid)
+			BEGIN SELECT RAISE(ABORT, 'FOREIGN KEY constraint failed'); END;
+
+			CREATE TRIGGER IF NOT EXISTS chats_messages_delete_fk BEFORE DELETE ON chats
+			WHEN EXISTS (SELECT 1 FROM messages WHERE chat_jid = OLD.jid)
+			BEGIN SELECT RAISE(ABORT, 'FOREIGN KEY constraint failed'); END;
+		`); err != nil {
+			return fmt.Errorf("failed to create chat reference triggers: %v", err)
+		}
+	}
+
+	rows, err := db.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys: %v", err)
+	}
+	defer rows.Close()
+	violations := map[string]int{}
+	for rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var fkIndex int
+		if err := rows.Scan(&table, &rowID, &parent, &fkIndex); err != nil {
+			return fmt.Errorf("failed to read foreign key check: %v", err)
+		}
+		violations[table+" -> "+parent]++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read foreign key check: %v", err)
+	}
+	for reference, count := range violations {
+		fmt.Printf("Warning: %d rows violate the foreign key %s\n", count, reference)
+	}
+	return nil
+}
+
+// messagesReferenceChats reports whether the messages table was created with
+// its foreign key to chats.
+func messagesReferenceChats(db *sql.DB) (bool, error) {
+	rows, err := db.Query(`SELECT "table" FROM pragma_foreign_key_list('messages')`)
+	if err != nil {
+		return false, fmt.Errorf("failed to read messages foreign keys: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var parent string
+		if err := rows.Scan(&parent); err != nil {
+			return false, err
+		}
+		if strings.EqualFold(parent, "chats") {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// OrphanSweep counts what one orphan sweep fixed.
+type OrphanSweep struct {
+	ChatsCreated      int
+	ChatsDeleted      int
+	LinksDeleted      int
+	EmbeddingsDeleted int
+}
+
+// SweepOrphans recreates missing chat rows, deletes chats orphaned by alias
+// promotion, and deletes links and embeddings whose message is gone. Buffered
+// messages are flushed first, and embeddings written since the sweep started
+// are left alone, since their message may still be waiting in the buffer.
+func (store *MessageStore) SweepOrphans() (OrphanSweep, error) {
+	var sweep OrphanSweep
+	started := normalizeToUTC(time.Now())
+	if err := store.FlushIngestBuffer(); err != nil {
+		return sweep, fmt.Errorf("failed to flush ingest buffer before sweep: %v", err)
+	}
+	var err error
+	if sweep.ChatsCreated, err = store.RecreateMissingChats(); err != nil {
+		return sweep, fmt.Errorf("failed to recreate missing chats: %v", err)
+	}
+	if sweep.ChatsDeleted, err = store.DeleteOrphanedChats(); err != nil {
+		return sweep, fmt.Errorf("failed to delete orphaned chats: %v", err)
+	}
+	for _, target := range []struct {
+		table  string
+		filter string
+		args   []interface{}
+		count  *int
+	}{
+		{"links", "", nil, &sweep.LinksDeleted},
+		{"message_embeddings", "(created_at IS NULL OR created_at < ?) AND ", []interface{}{started}, &sweep.EmbeddingsDeleted},
+	} {
+		result, err := store.db.Exec(`DELETE FROM `+target.table+` WHERE `+target.filter+`NOT EXISTS (
+			SELECT 1 FROM messages m WHERE m.id = `+target.table+`.message_id AND m.chat_jid = `+target.table+`.chat_jid
+		)`, target.args...)
+		if err != nil {
+			return sweep, fmt.Errorf("failed to delete orphaned %s: %v", target.table, err)
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			*target.count = int(affected)
+		}
+	}
+	return sweep, nil
+}
+
+// orphanSweepIntervalFromEnv reads WHATSAPP_ORPHAN_SWEEP_MINUTES; zero turns
+// the periodic sweep off.
+func orphanSweepIntervalFromEnv() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("WHATSAPP_ORPHAN_SWEEP_MINUTES"))
+	if raw == "" {
+		return defaultOrphanSweepInterval
+	}
+	minutes, err := strconv.Atoi(raw)
+	if err != nil || minutes < 0 {
+		fmt.Printf("Warning: invalid WHATSAPP_ORPHAN_SWEEP_MINUTES=%q, using %d\n", raw, int(defaultOrphanSweepInterval/time.Minute))
+		return defaultOrphanSweepInterval
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// startOrphanSweepTicker runs SweepOrphans every interval until Close.
+func (store *MessageStore) startOrphanSweepTicker(interval time.Duration) {
+	store.sweepTickerStop = make(chan struct{})
+	store.sweepTickerDone = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		defer close(store.sweepTickerDone)
+		for {
+			select {
+			case <-ticker.C:
+				sweep, err := store.SweepOrphans()
+				if err != nil {
+					fmt.Printf("Warning: orphan sweep failed: %v\n", err)
+				} else if sweep != (OrphanSweep{}) {
+					fmt.Printf("Orphan sweep: %d chats recreated, %d chats deleted, %d links and %d embeddings deleted\n",
+						sweep.ChatsCreated, sweep.ChatsDeleted, sweep.LinksDeleted, sweep.EmbeddingsDeleted)
+				}
+			case <-store.sweepTickerStop:
+				return
+			}
+		}
+	}()
+}
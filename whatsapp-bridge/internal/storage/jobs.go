@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Job states.
+const (
+	JobStateQueued      = "queued"
+	JobStateRunning     = "running"
+	JobStateCompleted   = "completed"
+	JobStateFailed      = "failed"
+	JobStateCancelled   = "cancelled"
+	JobStateInterrupted = "interrupted"
+)
+
+// JobError records a failed item within a job.
+type JobError struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+// JobRecord is the persisted state of a background job.
+type JobRecord struct {
+	ID         string
+	Kind       string
+	Subject    string
+	State      string
+	Total      int
+	Completed  int
+	Failed     int
+	Errors     []JobError
+	Message    string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// ensureJobsSchema creates the jobs table and marks jobs left running by a
+// previous process as interrupted.
+func ensureJobsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS jobs (
+			id TEXT PRIMARY KEY,
+			kind TEXT NOT NULL,
+			subject TEXT,
+			state TEXT NOT NULL,
+			total INTEGER NOT NULL DEFAULT 0,
+			completed INTEGER NOT NULL DEFAULT 0,
+			failed INTEGER NOT NULL DEFAULT 0,
+			errors TEXT,
+			message TEXT,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			finished_at TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs(created_at DESC);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure jobs table: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if _, err := db.Exec(
+		"UPDATE jobs SET state = ?, updated_at = ?, finished_at = ? WHERE state IN (?, ?)",
+		JobStateInterrupted, now, now, JobStateQueued, JobStateRunning,
+	); err != nil {
+		return fmt.Errorf("failed to mark interrupted jobs: %v", err)
+	}
+	return nil
+}
+
+// SaveJob inserts or updates a job record.
+func (store *MessageStore) SaveJob(job JobRecord) error {
+	errorsJSON, err := json.Marshal(job.Errors)
+	if err != nil {
+		return err
+	}
+	var finishedAt interface{}
+	if job.FinishedAt != nil {
+		finishedAt = normalizeToUTC(*job.FinishedAt)
+	}
+	_, err = store.db.Exec(
+		`INSERT INTO jobs (id, kind, subject, state, total, completed, failed, errors, message, created_at, updated_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			state = excluded.state,
+			total = excluded.total,
+			completed = excluded.completed,
+			failed = excluded.failed,
+			errors = excluded.errors,
+			message = excluded.message,
+			updated_at = excluded.updated_at,
+			finished_at = excluded.finished_at`,
+		job.ID, job.Kind, job.Subject, job.State, job.Total, job.Completed, job.Failed, string(errorsJSON), job.Message,
+		normalizeToUTC(job.CreatedAt), normalizeToUTC(job.UpdatedAt), finishedAt,
+	)
+	return err
+}
+
+func scanJob(scanner interface{ Scan(...interface{}) error }) (JobRecord, error) {
+	var job JobRecord
+	var errorsJSON string
+	var finishedAt sql.NullTime
+	if err := scanner.Scan(
+		&job.ID, &job.Kind, &job.Subject, &job.State, &job.Total, &job.Completed, &job.Failed,
+		&errorsJSON, &job.Message, &job.CreatedAt, &job.UpdatedAt, &finishedAt,
+	); err != nil {
+		return JobRecord{}, err
+	}
+	if errorsJSON != "" && errorsJSON != "null" {
+		if err := json.Unmarshal([]byte(errorsJSON), &job.Errors); err != nil {
+			return JobRecord{}, fmt.Errorf("failed to decode job %s errors: %v", job.ID, err)
+		}
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
+
+const jobColumns = `id, kind, COALESCE(subject, ''), state, total, completed, failed,
+	COALESCE(errors, ''), COALESCE(message, ''), created_at, updated_at, finished_at`
+
+// GetJob returns a job record, or nil when it does not exist.
+func (store *MessageStore) GetJob(id string) (*JobRecord, error) {
+	job, err := scanJob(store.db.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs returns the most recent jobs, optionally restricted to one kind.
+func (store *MessageStore) ListJobs(kind string, limit int) ([]JobRecord, error) {
+	rows, err := store.db.Query(
+		"SELECT "+jobColumns+" FROM jobs WHERE (? = '' OR kind = ?) ORDER BY created_at DESC LIMIT ?",
+		kind, kind, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []JobRecord
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
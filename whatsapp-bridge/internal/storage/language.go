@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// chatLanguageWindow is how many of a chat's latest incoming messages with a
+// detected language decide the chat's language.
+const chatLanguageWindow = 50
+
+// ensureChatLanguagesSchema adds the messages.language column and creates the
+// chat_languages table.
+func ensureChatLanguagesSchema(db *sql.DB) error {
+	if err := ensureTableColumns(db, "messages", []schemaColumn{
+		{name: "language", definition: "TEXT"},
+	}); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS chat_languages (
+			chat_jid TEXT PRIMARY KEY,
+			language TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_messages_language_timestamp ON messages(language, timestamp DESC);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure chat_languages table: %v", err)
+	}
+	return nil
+}
+
+// SetMessageLanguage records the detected language of a stored message and
+// re-derives its chat's language: the most common one among the chat's
+// latest incoming messages.
+func (store *MessageStore) SetMessageLanguage(id, chatJID, language string) error {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"UPDATE messages SET language = ? WHERE id = ? AND chat_jid = ?",
+		language, id, chatJID,
+	); err != nil {
+		return err
+	}
+
+	var chatLanguage string
+	err = tx.QueryRow(
+		`SELECT language FROM (
+			SELECT language, timestamp FROM messages
+			WHERE chat_jid = ? AND COALESCE(is_from_me, 0) = 0 AND language IS NOT NULL AND language <> ''
+			ORDER BY timestamp DESC
+			LIMIT ?
+		)
+		GROUP BY language
+		ORDER BY COUNT(*) DESC, MAX(timestamp) DESC
+		LIMIT 1`,
+		chatJID, chatLanguageWindow,
+	).Scan(&chatLanguage)
+	if err == sql.ErrNoRows {
+		return tx.Commit()
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO chat_languages (chat_jid, language, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET language = excluded.language, updated_at = excluded.updated_at`,
+		chatJID, chatLanguage, normalizeToUTC(time.Now()),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// Link is a URL shared in a message.
+type Link struct {
+	MessageID string
+	ChatJID   string
+	Sender    string
+	URL       string
+	Domain    string
+	Title     string
+	Timestamp time.Time
+}
+
+// LinkQuery filters links returned by GetLinks.
+type LinkQuery struct {
+	Domain  string
+	ChatJID string
+	Sender  string
+	Limit   int
+}
+
+// ExtractLinks returns the distinct URLs found in free-form message text.
+func ExtractLinks(content string) []string {
+	matches := linkPattern.FindAllString(content, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(matches))
+	links := make([]string, 0, len(matches))
+	for _, match := range matches {
+		link := strings.TrimRight(match, ".,;:!?)]}'")
+		if strings.HasPrefix(strings.ToLower(link), "www.") {
+			link = "https://" + link
+		}
+		if linkDomain(link) == "" {
+			continue
+		}
+		if _, ok := seen[link]; ok {
+			continue
+		}
+		seen[link] = struct{}{}
+		links = append(links, link)
+	}
+	return links
+}
+
+// linkDomain returns the lowercased host of a URL without a leading "www.".
+func linkDomain(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// normalizeDomainFilter lowercases a domain filter and strips scheme and "www.".
+func normalizeDomainFilter(domain string) string {
+	normalized := strings.ToLower(strings.TrimSpace(domain))
+	if strings.Contains(normalized, "://") {
+		normalized = linkDomain(normalized)
+	}
+	return strings.TrimPrefix(strings.TrimSuffix(normalized, "/"), "www.")
+}
+
+// ensureLinksSchema creates the links table and backfills it from stored messages
+// the first time it is created.
+func ensureLinksSchema(db *sql.DB) error {
+	var existing int
+	if err := db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'links'",
+	).Scan(&existing); err != nil {
+		return fmt.Errorf("failed to inspect links table: %v", err)
+	}
+
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS links (
+			message_id TEXT NOT NULL,
+			chat_jid TEXT NOT NULL,
+			url TEXT NOT NULL,
+			domain TEXT NOT NULL,
+			sender TEXT,
+			title TEXT,
+			timestamp TIMESTAMP,
+			PRIMARY KEY (message_id, chat_jid, url)
+		);
+		CREATE INDEX IF NOT EXISTS idx_links_domain_timestamp ON links(domain, timestamp DESC);
+		CREATE INDEX IF NOT EXISTS idx_links_chat_timestamp ON links(chat_jid, timestamp DESC);
+		CREATE INDEX IF NOT EXISTS idx_links_timestamp ON links(timestamp DESC);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure links table: %v", err)
+	}
+	if existing > 0 {
+		if _, err := db.Exec(`
+			UPDATE links
+			SET timestamp = COALESCE(strftime('%Y-%m-%d %H:%M:%S', timestamp) || '+00:00', timestamp)
+			WHERE timestamp IS NOT NULL
+		`); err != nil {
+			return fmt.Errorf("failed to normalize links.timestamp to UTC: %v", err)
+		}
+		return nil
+	}
+
+	rows, err := db.Query(`
+		SELECT id, chat_jid, COALESCE(sender, ''), content, timestamp
+		FROM messages
+		WHERE timestamp IS NOT NULL AND (content LIKE '%http%' OR content LIKE '%www.%')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to scan messages for links: %v", err)
+	}
+	var pending []StoredMessage
+	for rows.Next() {
+		var msg StoredMessage
+		if err := rows.Scan(&msg.ID, &msg.ChatJID, &msg.Sender, &msg.Content, &msg.Timestamp); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read message for link backfill: %v", err)
+		}
+		pending = append(pending, msg)
+	}
+	rows.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, msg := range pending {
+		if err := storeMessageLinks(tx, msg); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to backfill links: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// storeMessageLinks indexes the URLs found in a message's content.
+func storeMessageLinks(tx *sql.Tx, msg StoredMessage) error {
+	links := ExtractLinks(msg.Content)
+	if len(links) == 0 {
+		return nil
+	}
+
+	for _, link := range links {
+		title := ""
+		if msg.LinkTitle != "" && (msg.LinkURL == "" || msg.LinkURL == link || strings.Contains(link, msg.LinkURL)) {
+			title = msg.LinkTitle
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO links (message_id, chat_jid, url, domain, sender, title, timestamp)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(message_id, chat_jid, url) DO UPDATE SET
+				sender = excluded.sender,
+				title = COALESCE(NULLIF(excluded.title, ''), links.title),
+				timestamp = excluded.timestamp`,
+			msg.ID, msg.ChatJID, link, linkDomain(link), msg.Sender, title, normalizeToUTC(msg.Timestamp),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLinks returns shared links ordered by timestamp desc.
+func (store *MessageStore) GetLinks(query LinkQuery) ([]Link, error) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	if domain := normalizeDomainFilter(query.Domain); domain != "" {
+		conditions = append(conditions, "(domain = ? OR domain LIKE ?)")
+		args = append(args, domain, "%."+domain)
+	}
+	if query.ChatJID != "" {
+		conditions = append(conditions, "chat_jid = ?")
+		args = append(args, query.ChatJID)
+	}
+	if query.Sender != "" {
+		conditions = append(conditions, "sender = ?")
+		args = append(args, jid.NormalizeUser(query.Sender))
+	}
+	args = append(args, query.Limit)
+
+	rows, err := store.db.Query(
+		fmt.Sprintf(
+			`SELECT message_id, chat_jid, COALESCE(sender, ''), url, domain, COALESCE(title, ''), timestamp
+			FROM links
+			WHERE %s
+			ORDER BY timestamp DESC
+			LIMIT ?`,
+			strings.Join(conditions, " AND "),
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []Link
+	for rows.Next() {
+		var link Link
+		if err := rows.Scan(&link.MessageID, &link.ChatJID, &link.Sender, &link.URL, &link.Domain, &link.Title, &link.Timestamp); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
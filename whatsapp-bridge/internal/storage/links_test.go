@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractLinks(t *testing.T) {
+	content := "Read https://Example.com/post?id=1, then www.news.org/story. Again: https://Example.com/post?id=1 (http://a.io/x)"
+	got := ExtractLinks(content)
+	want := []string{
+		"https://Example.com/post?id=1",
+		"https://www.news.org/story",
+		"http://a.io/x",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected links: got %q want %q", got, want)
+	}
+}
+
+func TestExtractLinksIgnoresPlainText(t *testing.T) {
+	if got := ExtractLinks("no links here, just http and www words"); len(got) != 0 {
+		t.Fatalf("expected no links, got %q", got)
+	}
+}
+
+func TestLinkDomainStripsWWWAndLowercases(t *testing.T) {
+	if got := linkDomain("https://WWW.Example.COM:8443/path"); got != "example.com" {
+		t.Fatalf("unexpected domain: %q", got)
+	}
+	if got := normalizeDomainFilter("https://www.example.com/"); got != "example.com" {
+		t.Fatalf("unexpected domain filter: %q", got)
+	}
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// ChatMediaItem is a media message listed in a chat's media view.
+type ChatMediaItem struct {
+	ID           string
+	Sender       string
+	Timestamp    time.Time
+	IsFromMe     bool
+	MediaType    string
+	Filename     string
+	FileLength   uint64
+	Thumbnail    []byte
+	LocalPath    string
+	Downloadable bool
+}
+
+// ChatMediaTotal aggregates media message counts and sizes for one media type.
+type ChatMediaTotal struct {
+	MediaType  string
+	Count      int
+	TotalBytes uint64
+}
+
+// GetChatMedia returns media messages for a chat ordered by timestamp desc,
+// optionally restricted to one media type.
+func (store *MessageStore) GetChatMedia(chatJID string, mediaType string, limit int) ([]ChatMediaItem, error) {
+	rows, err := store.db.Query(
+		`SELECT id, COALESCE(sender, ''), timestamp, COALESCE(is_from_me, 0), media_type,
+			COALESCE(filename, ''), COALESCE(file_length, 0), thumbnail, COALESCE(local_path, ''),
+			COALESCE(url, '') <> '' AND LENGTH(COALESCE(media_key, '')) > 0
+		FROM messages
+		WHERE chat_jid = ? AND COALESCE(media_type, '') <> '' AND (? = '' OR media_type = ?)
+		ORDER BY timestamp DESC
+		LIMIT ?`,
+		chatJID, mediaType, mediaType, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ChatMediaItem
+	for rows.Next() {
+		var item ChatMediaItem
+		if err := rows.Scan(
+			&item.ID,
+			&item.Sender,
+			&item.Timestamp,
+			&item.IsFromMe,
+			&item.MediaType,
+			&item.Filename,
+			&item.FileLength,
+			&item.Thumbnail,
+			&item.LocalPath,
+			&item.Downloadable,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetChatMediaTotals returns media counts and total byte sizes per type for a chat.
+func (store *MessageStore) GetChatMediaTotals(chatJID string) ([]ChatMediaTotal, error) {
+	rows, err := store.db.Query(
+		`SELECT media_type, COUNT(*), COALESCE(SUM(file_length), 0)
+		FROM messages
+		WHERE chat_jid = ? AND COALESCE(media_type, '') <> ''
+		GROUP BY media_type
+		ORDER BY media_type`,
+		chatJID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []ChatMediaTotal
+	for rows.Next() {
+		var total ChatMediaTotal
+		if err := rows.Scan(&total.MediaType, &total.Count, &total.TotalBytes); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// MarkMediaDownloaded records where a message's media was saved locally.
+func (store *MessageStore) MarkMediaDownloaded(id, chatJID, localPath string) error {
+	_, err := store.db.Exec(
+		"UPDATE messages SET local_path = ? WHERE id = ? AND chat_jid = ?",
+		localPath, id, chatJID,
+	)
+	return err
+}
+
+// PendingMediaQuery filters media messages that still need downloading.
+type PendingMediaQuery struct {
+	ChatJID   string
+	MediaType string
+	After     *time.Time
+	Before    *time.Time
+}
+
+// GetPendingMediaIDs returns IDs of downloadable media messages in a chat that
+// have no recorded local copy, oldest first.
+func (store *MessageStore) GetPendingMediaIDs(query PendingMediaQuery) ([]string, error) {
+	conditions := []string{
+		"chat_jid = ?",
+		"COALESCE(media_type, '') <> ''",
+		"COALESCE(url, '') <> ''",
+		"LENGTH(COALESCE(media_key, '')) > 0",
+		"COALESCE(local_path, '') = ''",
+	}
+	args := []interface{}{query.ChatJID}
+	if query.MediaType != "" {
+		conditions = append(conditions, "media_type = ?")
+		args = append(args, query.MediaType)
+	}
+	if query.After != nil {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, normalizeToUTC(*query.After))
+	}
+	if query.Before != nil {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, normalizeToUTC(*query.Before))
+	}
+
+	rows, err := store.db.Query(
+		"SELECT id FROM messages WHERE "+strings.Join(conditions, " AND ")+" ORDER BY timestamp ASC",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Opt-out sources: the contact sent a stop keyword, or the opt-out was added
+// through the API.
+const (
+	OptOutSourceKeyword = "keyword"
+	OptOutSourceManual  = "manual"
+)
+
+// OptOut records that a contact asked not to be messaged.
+type OptOut struct {
+	ContactID string
+	Source    string
+	// Keyword and MessageID identify the stop message for keyword opt-outs.
+	Keyword    string
+	MessageID  string
+	OptedOutAt time.Time
+}
+
+// ensureOptOutsSchema creates the opt_outs table.
+func ensureOptOutsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS opt_outs (
+			contact_id TEXT PRIMARY KEY,
+			source TEXT NOT NULL,
+			keyword TEXT,
+			message_id TEXT,
+			opted_out_at TIMESTAMP NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure opt_outs table: %v", err)
+	}
+	return nil
+}
+
+const optOutColumns = "contact_id, source, COALESCE(keyword, ''), COALESCE(message_id, ''), opted_out_at"
+
+func scanOptOut(scanner interface{ Scan(...interface{}) error }) (OptOut, error) {
+	var optOut OptOut
+	err := scanner.Scan(&optOut.ContactID, &optOut.Source, &optOut.Keyword, &optOut.MessageID, &optOut.OptedOutAt)
+	return optOut, err
+}
+
+// RecordOptOut stores an opt-out and reports whether the contact was not
+// already opted out; an existing opt-out is kept as it was.
+func (store *MessageStore) RecordOptOut(optOut OptOut) (bool, error) {
+	result, err := store.db.Exec(
+		`INSERT OR IGNORE INTO opt_outs (contact_id, source, keyword, message_id, opted_out_at)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)`,
+		optOut.ContactID, optOut.Source, optOut.Keyword, optOut.MessageID, normalizeToUTC(optOut.OptedOutAt),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// GetOptOut returns a contact's opt-out, or nil when it has none.
+func (store *MessageStore) GetOptOut(contactID string) (*OptOut, error) {
+	optOut, err := scanOptOut(store.db.QueryRow("SELECT "+optOutColumns+" FROM opt_outs WHERE contact_id = ?", contactID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &optOut, nil
+}
+
+// ListOptOuts returns opt-outs, most recent first.
+func (store *MessageStore) ListOptOuts(limit int) ([]OptOut, error) {
+	rows, err := store.db.Query("SELECT "+optOutColumns+" FROM opt_outs ORDER BY opted_out_at DESC, contact_id LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var optOuts []OptOut
+	for rows.Next() {
+		optOut, err := scanOptOut(rows)
+		if err != nil {
+			return nil, err
+		}
+		optOuts = append(optOuts, optOut)
+	}
+	return optOuts, rows.Err()
+}
+
+// RemoveOptOut lets a contact be messaged again and reports whether it was
+// opted out.
+func (store *MessageStore) RemoveOptOut(contactID string) (bool, error) {
+	result, err := store.db.Exec("DELETE FROM opt_outs WHERE contact_id = ?", contactID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
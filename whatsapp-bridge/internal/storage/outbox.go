@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Queued send states.
+const (
+	QueuedSendStateQueued    = "queued"
+	QueuedSendStateSending   = "sending"
+	QueuedSendStateSent      = "sent"
+	QueuedSendStateFailed    = "failed"
+	QueuedSendStateCancelled = "cancelled"
+)
+
+// QueuedSend is a send accepted while WhatsApp was disconnected, delivered
+// once the connection is back.
+type QueuedSend struct {
+	ID             int64
+	Recipient      string
+	Message        string
+	MediaPath      string
+	MentionAll     bool
+	GIF            bool
+	OverrideOptOut bool
+	// Force delivers the send even if an identical one went out meanwhile.
+	Force      bool
+	State      string
+	Attempts   int
+	Error      string
+	ErrorCode  string
+	MessageIDs []string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// ensureOutboxSchema creates the outbox table.
+func ensureOutboxSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			recipient TEXT NOT NULL,
+			message TEXT,
+			media_path TEXT,
+			mention_all BOOLEAN NOT NULL DEFAULT 0,
+			gif BOOLEAN NOT NULL DEFAULT 0,
+			override_opt_out BOOLEAN NOT NULL DEFAULT 0,
+			force BOOLEAN NOT NULL DEFAULT 0,
+			state TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			error TEXT,
+			error_code TEXT,
+			message_ids TEXT,
+			created_at TIMESTAMP NOT NULL,
+			finished_at TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_outbox_state ON outbox(state, id);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure outbox table: %v", err)
+	}
+	if err := ensureTableColumns(db, "outbox", []schemaColumn{
+		{name: "force", definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	}); err != nil {
+		return err
+	}
+	// A send claimed by a worker that did not survive to record its outcome
+	// is delivered again.
+	if _, err := db.Exec("UPDATE outbox SET state = ? WHERE state = ?", QueuedSendStateQueued, QueuedSendStateSending); err != nil {
+		return fmt.Errorf("failed to requeue interrupted sends: %v", err)
+	}
+	return nil
+}
+
+const queuedSendColumns = `id, recipient, COALESCE(message, ''), COALESCE(media_path, ''), mention_all, gif, override_opt_out, force,
+	state, attempts, COALESCE(error, ''), COALESCE(error_code, ''), COALESCE(message_ids, ''), created_at, finished_at`
+
+func scanQueuedSend(scanner interface{ Scan(...interface{}) error }) (QueuedSend, error) {
+	var send QueuedSend
+	var messageIDs string
+	var finishedAt sql.NullTime
+	if err := scanner.Scan(
+		&send.ID, &send.Recipient, &send.Message, &send.MediaPath, &send.MentionAll, &send.GIF, &send.OverrideOptOut, &send.Force,
+		&send.State, &send.Attempts, &send.Error, &send.ErrorCode, &messageIDs, &send.CreatedAt, &finishedAt,
+	); err != nil {
+		return QueuedSend{}, err
+	}
+	if messageIDs != "" {
+		send.MessageIDs = strings.Split(messageIDs, ",")
+	}
+	if finishedAt.Valid {
+		send.FinishedAt = &finishedAt.Time
+	}
+	return send, nil
+}
+
+func queryQueuedSends(db *sql.DB, query string, args ...interface{}) ([]QueuedSend, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sends []QueuedSend
+	for rows.Next() {
+		send, err := scanQueuedSend(rows)
+		if err != nil {
+			return nil, err
+		}
+		sends = append(sends, send)
+	}
+	return sends, rows.Err()
+}
+
+// QueueSend stores a send for delivery once connected and returns it with
+// its ID.
+func (store *MessageStore) QueueSend(send QueuedSend) (QueuedSend, error) {
+	send.State = QueuedSendStateQueued
+	send.CreatedAt = time.Now().UTC()
+	result, err := store.db.Exec(
+		`INSERT INTO outbox (recipient, message, media_path, mention_all, gif, override_opt_out, force, state, created_at)
+		VALUES (?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, ?, ?)`,
+		send.Recipient, send.Message, send.MediaPath, send.MentionAll, send.GIF, send.OverrideOptOut, send.Force, send.State, send.CreatedAt,
+	)
+	if err != nil {
+		return QueuedSend{}, err
+	}
+	send.ID, err = result.LastInsertId()
+	return send, err
+}
+
+// GetQueuedSend returns a queued send, or nil when it does not exist.
+func (store *MessageStore) GetQueuedSend(id int64) (*QueuedSend, error) {
+	send, err := scanQueuedSend(store.db.QueryRow("SELECT "+queuedSendColumns+" FROM outbox WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &send, nil
+}
+
+// ListQueuedSends returns sends in state, or in any state when it is empty,
+// oldest first.
+func (store *MessageStore) ListQueuedSends(state string, limit int) ([]QueuedSend, error) {
+	if state == "" {
+		return queryQueuedSends(store.db, "SELECT "+queuedSendColumns+" FROM outbox ORDER BY id LIMIT ?", limit)
+	}
+	return queryQueuedSends(store.db, "SELECT "+queuedSendColumns+" FROM outbox WHERE state = ? ORDER BY id LIMIT ?", state, limit)
+}
+
+// ClaimQueuedSend moves a queued send to sending before it is delivered, so
+// it can no longer be cancelled, and reports whether it was still queued.
+func (store *MessageStore) ClaimQueuedSend(id int64) (bool, error) {
+	return store.updateQueuedSend(
+		"UPDATE outbox SET state = ? WHERE id = ? AND state = ?",
+		QueuedSendStateSending, id, QueuedSendStateQueued,
+	)
+}
+
+// ReleaseQueuedSend returns a claimed send to the queue without counting an
+// attempt, for a delivery that could not be tried.
+func (store *MessageStore) ReleaseQueuedSend(id int64) error {
+	_, err := store.updateQueuedSend(
+		"UPDATE outbox SET state = ? WHERE id = ? AND state = ?",
+		QueuedSendStateQueued, id, QueuedSendStateSending,
+	)
+	return err
+}
+
+// RecordQueuedSendAttempt counts a delivery attempt of a claimed send that
+// will be retried, keeps its error and returns the send to the queue.
+func (store *MessageStore) RecordQueuedSendAttempt(id int64, code string, attemptErr error) error {
+	_, err := store.updateQueuedSend(
+		"UPDATE outbox SET state = ?, attempts = attempts + 1, error = ?, error_code = NULLIF(?, '') WHERE id = ? AND state = ?",
+		QueuedSendStateQueued, attemptErr.Error(), code, id, QueuedSendStateSending,
+	)
+	return err
+}
+
+// FinishQueuedSend marks a claimed send sent with messageIDs, or failed with
+// sendErr and its code, and reports whether the send was still claimed.
+func (store *MessageStore) FinishQueuedSend(id int64, at time.Time, messageIDs []string, code string, sendErr error) (bool, error) {
+	state, message := QueuedSendStateSent, ""
+	if sendErr != nil {
+		state, message = QueuedSendStateFailed, sendErr.Error()
+	}
+	return store.updateQueuedSend(
+		`UPDATE outbox SET state = ?, attempts = attempts + 1, error = NULLIF(?, ''), error_code = NULLIF(?, ''),
+			message_ids = NULLIF(?, ''), finished_at = ?
+		WHERE id = ? AND state = ?`,
+		state, message, code, strings.Join(messageIDs, ","), normalizeToUTC(at), id, QueuedSendStateSending,
+	)
+}
+
+// CancelQueuedSend cancels a send still queued and reports whether it was. A
+// send already claimed for delivery cannot be cancelled.
+func (store *MessageStore) CancelQueuedSend(id int64) (bool, error) {
+	return store.updateQueuedSend(
+		"UPDATE outbox SET state = ?, finished_at = ? WHERE id = ? AND state = ?",
+		QueuedSendStateCancelled, time.Now().UTC(), id, QueuedSendStateQueued,
+	)
+}
+
+// updateQueuedSend runs a state transition and reports whether it matched
+// a row.
+func (store *MessageStore) updateQueuedSend(query string, args ...interface{}) (bool, error) {
+	result, err := store.db.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
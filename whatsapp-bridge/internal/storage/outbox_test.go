@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueuedSendRoundTrip(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+
+	queued, err := store.QueueSend(QueuedSend{
+		Recipient:      "15551234567",
+		Message:        "hello",
+		MentionAll:     true,
+		OverrideOptOut: true,
+		Force:          true,
+	})
+	if err != nil {
+		t.Fatalf("QueueSend: %v", err)
+	}
+	other, err := store.QueueSend(QueuedSend{Recipient: "15557654321", MediaPath: "/tmp/clip.mp4", GIF: true})
+	if err != nil {
+		t.Fatalf("QueueSend: %v", err)
+	}
+
+	got, err := store.GetQueuedSend(queued.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetQueuedSend = %v, %v", got, err)
+	}
+	if got.Recipient != "15551234567" || got.Message != "hello" || got.MediaPath != "" ||
+		!got.MentionAll || got.GIF || !got.OverrideOptOut || !got.Force || got.State != QueuedSendStateQueued {
+		t.Fatalf("GetQueuedSend = %+v", got)
+	}
+	got, err = store.GetQueuedSend(other.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetQueuedSend = %v, %v", got, err)
+	}
+	if got.MediaPath != "/tmp/clip.mp4" || !got.GIF || got.Force {
+		t.Fatalf("GetQueuedSend = %+v", got)
+	}
+	if missing, err := store.GetQueuedSend(other.ID + 1); err != nil || missing != nil {
+		t.Fatalf("GetQueuedSend(missing) = %v, %v", missing, err)
+	}
+
+	if claimed, err := store.ClaimQueuedSend(queued.ID); err != nil || !claimed {
+		t.Fatalf("ClaimQueuedSend = %v, %v", claimed, err)
+	}
+	if claimed, err := store.ClaimQueuedSend(queued.ID); err != nil || claimed {
+		t.Fatalf("ClaimQueuedSend(claimed) = %v, %v", claimed, err)
+	}
+	if err := store.RecordQueuedSendAttempt(queued.ID, "timeout", errors.New("timed out")); err != nil {
+		t.Fatalf("RecordQueuedSendAttempt: %v", err)
+	}
+	if got, err := store.GetQueuedSend(queued.ID); err != nil || got.State != QueuedSendStateQueued || got.Attempts != 1 {
+		t.Fatalf("retried send = %+v, %v", got, err)
+	}
+	if claimed, err := store.ClaimQueuedSend(queued.ID); err != nil || !claimed {
+		t.Fatalf("ClaimQueuedSend(retry) = %v, %v", claimed, err)
+	}
+	if finished, err := store.FinishQueuedSend(queued.ID, time.Now(), []string{"A", "B"}, "", nil); err != nil || !finished {
+		t.Fatalf("FinishQueuedSend = %v, %v", finished, err)
+	}
+	got, err = store.GetQueuedSend(queued.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetQueuedSend = %v, %v", got, err)
+	}
+	if got.State != QueuedSendStateSent || got.Attempts != 2 || !reflect.DeepEqual(got.MessageIDs, []string{"A", "B"}) || got.FinishedAt == nil {
+		t.Fatalf("finished send = %+v", got)
+	}
+
+	if cancelled, err := store.CancelQueuedSend(queued.ID); err != nil || cancelled {
+		t.Fatalf("CancelQueuedSend(sent) = %v, %v", cancelled, err)
+	}
+	if cancelled, err := store.CancelQueuedSend(other.ID); err != nil || !cancelled {
+		t.Fatalf("CancelQueuedSend(queued) = %v, %v", cancelled, err)
+	}
+
+	for state, want := range map[string][]int64{
+		"":                       {queued.ID, other.ID},
+		QueuedSendStateQueued:    nil,
+		QueuedSendStateSent:      {queued.ID},
+		QueuedSendStateCancelled: {other.ID},
+	} {
+		sends, err := store.ListQueuedSends(state, 10)
+		if err != nil {
+			t.Fatalf("ListQueuedSends(%q): %v", state, err)
+		}
+		var ids []int64
+		for _, send := range sends {
+			ids = append(ids, send.ID)
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("ListQueuedSends(%q) = %v, want %v", state, ids, want)
+		}
+	}
+}
+
+func TestQueuedSendCancelledAfterListing(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+
+	queued, err := store.QueueSend(QueuedSend{Recipient: "15551234567", Message: "hello"})
+	if err != nil {
+		t.Fatalf("QueueSend: %v", err)
+	}
+	listed, err := store.ListQueuedSends(QueuedSendStateQueued, 10)
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("ListQueuedSends = %v, %v", listed, err)
+	}
+	if cancelled, err := store.CancelQueuedSend(queued.ID); err != nil || !cancelled {
+		t.Fatalf("CancelQueuedSend = %v, %v", cancelled, err)
+	}
+
+	if claimed, err := store.ClaimQueuedSend(listed[0].ID); err != nil || claimed {
+		t.Fatalf("ClaimQueuedSend(cancelled) = %v, %v", claimed, err)
+	}
+	if finished, err := store.FinishQueuedSend(listed[0].ID, time.Now(), []string{"A"}, "", nil); err != nil || finished {
+		t.Fatalf("FinishQueuedSend(cancelled) = %v, %v", finished, err)
+	}
+	got, err := store.GetQueuedSend(queued.ID)
+	if err != nil || got == nil || got.State != QueuedSendStateCancelled || got.Attempts != 0 {
+		t.Fatalf("cancelled send = %+v, %v", got, err)
+	}
+
+	// A send claimed for delivery can no longer be cancelled.
+	other, err := store.QueueSend(QueuedSend{Recipient: "15551234567", Message: "again"})
+	if err != nil {
+		t.Fatalf("QueueSend: %v", err)
+	}
+	if claimed, err := store.ClaimQueuedSend(other.ID); err != nil || !claimed {
+		t.Fatalf("ClaimQueuedSend = %v, %v", claimed, err)
+	}
+	if cancelled, err := store.CancelQueuedSend(other.ID); err != nil || cancelled {
+		t.Fatalf("CancelQueuedSend(sending) = %v, %v", cancelled, err)
+	}
+}
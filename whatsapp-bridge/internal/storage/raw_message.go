@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RawMessage is everything the bridge stores about one message: every column
+// of its row plus the records that hang off it.
+type RawMessage struct {
+	StoredMessage
+	SenderName string
+	LocalPath  string
+	Seq        int64
+	ChatName   string
+	ChatType   string
+	// Quoted is the message this one replies to, when it is stored.
+	Quoted *Message
+	// Replies are stored messages quoting this one, oldest first.
+	Replies []Message
+	Links   []Link
+	// EmbeddingModel is set when the message has been embedded for semantic search.
+	EmbeddingModel     string
+	EmbeddingCreatedAt time.Time
+}
+
+// messageColumns selects a Message with its sender's display name; the query
+// must alias messages as m and join chats on the sender as c.
+const messageColumns = `m.id, m.chat_jid, COALESCE(m.sender, ''), COALESCE(c.name, ''), COALESCE(m.content, ''), m.timestamp,
+	COALESCE(m.is_from_me, 0), COALESCE(m.is_self_chat, 0), COALESCE(m.media_type, ''), COALESCE(m.filename, ''),
+	COALESCE(m.quoted_message_id, ''), COALESCE(m.media_seconds, 0), COALESCE(m.is_voice_note, 0),
+	COALESCE(m.is_forwarded, 0), COALESCE(m.forwarding_score, 0), COALESCE(m.seq, 0)`
+
+func scanMessage(row interface{ Scan(...interface{}) error }) (Message, error) {
+	var msg Message
+	err := row.Scan(&msg.ID, &msg.ChatJID, &msg.Sender, &msg.SenderName, &msg.Content, &msg.Time,
+		&msg.IsFromMe, &msg.IsSelfChat, &msg.MediaType, &msg.Filename, &msg.QuotedMessageID, &msg.MediaSeconds, &msg.VoiceNote,
+		&msg.IsForwarded, &msg.ForwardingScore, &msg.Seq)
+	return msg, err
+}
+
+// GetRawMessage returns the full stored record of a message, or sql.ErrNoRows
+// when the chat has no message with that ID.
+func (store *MessageStore) GetRawMessage(id, chatJID string) (RawMessage, error) {
+	var raw RawMessage
+	var fileLength sql.NullInt64
+	err := store.db.QueryRow(
+		`SELECT m.id, m.chat_jid, COALESCE(m.sender, ''), COALESCE(s.name, ''), COALESCE(m.content, ''), m.timestamp,
+			COALESCE(m.is_from_me, 0), COALESCE(m.is_self_chat, 0), COALESCE(m.quoted_message_id, ''),
+			COALESCE(m.raw_sender, ''), COALESCE(m.sender_server, ''), COALESCE(m.chat_server, ''),
+			COALESCE(m.media_type, ''), COALESCE(m.filename, ''), COALESCE(m.url, ''), m.media_key, m.file_sha256,
+			m.file_enc_sha256, m.file_length, m.thumbnail, COALESCE(m.local_path, ''),
+			COALESCE(m.media_seconds, 0), m.media_waveform, COALESCE(m.is_voice_note, 0),
+			COALESCE(m.is_forwarded, 0), COALESCE(m.forwarding_score, 0),
+			COALESCE(m.selection_kind, ''), COALESCE(m.selection_id, ''), COALESCE(m.selection_title, ''),
+			COALESCE(m.payment_kind, ''), COALESCE(m.payment_amount_1000, 0), COALESCE(m.payment_currency, ''),
+			COALESCE(m.payment_reference, ''), COALESCE(m.payment_item_count, 0), COALESCE(m.payment_title, ''),
+			COALESCE(m.seq, 0), COALESCE(c.name, ''), COALESCE(c.chat_type, '')
+		FROM messages m
+		LEFT JOIN chats s ON s.jid = m.sender
+		LEFT JOIN chats c ON c.jid = m.chat_jid
+		WHERE m.id = ? AND m.chat_jid = ?`,
+		id, chatJID,
+	).Scan(&raw.ID, &raw.ChatJID, &raw.Sender, &raw.SenderName, &raw.Content, &raw.Timestamp,
+		&raw.IsFromMe, &raw.IsSelfChat, &raw.QuotedMessageID,
+		&raw.RawSender, &raw.SenderServer, &raw.ChatServer,
+		&raw.MediaType, &raw.Filename, &raw.URL, &raw.MediaKey, &raw.FileSHA256,
+		&raw.FileEncSHA256, &fileLength, &raw.Thumbnail, &raw.LocalPath,
+		&raw.Seconds, &raw.Waveform, &raw.VoiceNote,
+		&raw.IsForwarded, &raw.ForwardingScore,
+		&raw.Selection.Kind, &raw.Selection.ID, &raw.Selection.Title,
+		&raw.Payment.Kind, &raw.Payment.Amount1000, &raw.Payment.Currency,
+		&raw.Payment.Reference, &raw.Payment.ItemCount, &raw.Payment.Title,
+		&raw.Seq, &raw.ChatName, &raw.ChatType)
+	if err != nil {
+		return RawMessage{}, err
+	}
+	if fileLength.Valid && fileLength.Int64 > 0 {
+		raw.FileLength = uint64(fileLength.Int64)
+	}
+
+	if raw.QuotedMessageID != "" {
+		quoted, err := scanMessage(store.db.QueryRow(
+			`SELECT `+messageColumns+` FROM messages m LEFT JOIN chats c ON c.jid = m.sender
+			WHERE m.id = ? AND m.chat_jid = ?`,
+			raw.QuotedMessageID, chatJID,
+		))
+		if err == nil {
+			raw.Quoted = &quoted
+		} else if err != sql.ErrNoRows {
+			return RawMessage{}, err
+		}
+	}
+
+	rows, err := store.db.Query(
+		`SELECT `+messageColumns+` FROM messages m LEFT JOIN chats c ON c.jid = m.sender
+		WHERE m.chat_jid = ? AND m.quoted_message_id = ?
+		ORDER BY m.timestamp ASC`,
+		chatJID, id,
+	)
+	if err != nil {
+		return RawMessage{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		reply, err := scanMessage(rows)
+		if err != nil {
+			return RawMessage{}, err
+		}
+		raw.Replies = append(raw.Replies, reply)
+	}
+	if err := rows.Err(); err != nil {
+		return RawMessage{}, err
+	}
+
+	linkRows, err := store.db.Query(
+		`SELECT url, domain, COALESCE(title, '') FROM links WHERE message_id = ? AND chat_jid = ? ORDER BY url`,
+		id, chatJID,
+	)
+	if err != nil {
+		return RawMessage{}, err
+	}
+	defer linkRows.Close()
+	for linkRows.Next() {
+		link := Link{MessageID: raw.ID, ChatJID: raw.ChatJID, Sender: raw.Sender, Timestamp: raw.Timestamp}
+		if err := linkRows.Scan(&link.URL, &link.Domain, &link.Title); err != nil {
+			return RawMessage{}, err
+		}
+		raw.Links = append(raw.Links, link)
+	}
+	if err := linkRows.Err(); err != nil {
+		return RawMessage{}, err
+	}
+
+	var embeddedAt sql.NullTime
+	err = store.db.QueryRow(
+		"SELECT model, created_at FROM message_embeddings WHERE message_id = ? AND chat_jid = ?",
+		id, chatJID,
+	).Scan(&raw.EmbeddingModel, &embeddedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return RawMessage{}, err
+	}
+	raw.EmbeddingCreatedAt = embeddedAt.Time
+
+	return raw, nil
+}
@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message delivery statuses, in increasing order of progress.
+const (
+	MessageStatusUnknown   = "unknown"
+	MessageStatusReceived  = "received"
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusRead      = "read"
+	MessageStatusPlayed    = "played"
+)
+
+// MaxMessageStatusIDs caps how many messages one status query may ask about.
+const MaxMessageStatusIDs = 500
+
+// MessageStatus is the delivery progress of one message. Counts are per
+// recipient, so a group message read by three members has ReadCount 3.
+type MessageStatus struct {
+	MessageID      string
+	ChatJID        string
+	Found          bool
+	IsFromMe       bool
+	Status         string
+	SentAt         *time.Time
+	DeliveredAt    *time.Time
+	ReadAt         *time.Time
+	PlayedAt       *time.Time
+	DeliveredCount int
+	ReadCount      int
+	PlayedCount    int
+}
+
+// ensureReceiptsSchema creates the message_receipts table. Each row is one
+// recipient's progress on one message; the row with an empty recipient marks
+// when the bridge itself sent the message.
+func ensureReceiptsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_receipts (
+			message_id TEXT NOT NULL,
+			chat_jid TEXT NOT NULL,
+			recipient_id TEXT NOT NULL DEFAULT '',
+			sent_at TIMESTAMP,
+			delivered_at TIMESTAMP,
+			read_at TIMESTAMP,
+			played_at TIMESTAMP,
+			PRIMARY KEY (message_id, chat_jid, recipient_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_message_receipts_message ON message_receipts(message_id);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure message_receipts table: %v", err)
+	}
+	return nil
+}
+
+// RecordMessageSent notes that the bridge sent a message, so its status is
+// known before any receipt arrives.
+func (store *MessageStore) RecordMessageSent(messageID, chatJID string, sentAt time.Time) error {
+	_, err := store.db.Exec(
+		`INSERT INTO message_receipts (message_id, chat_jid, recipient_id, sent_at) VALUES (?, ?, '', ?)
+		ON CONFLICT(message_id, chat_jid, recipient_id) DO UPDATE SET sent_at = COALESCE(message_receipts.sent_at, excluded.sent_at)`,
+		messageID, chatJID, normalizeToUTC(sentAt),
+	)
+	return err
+}
+
+// RecordReceipt stores a delivered, read or played receipt from recipientID
+// for messages in a chat. The first time each stage is reached is kept, and
+// reading or playing a message implies it was delivered.
+func (store *MessageStore) RecordReceipt(chatJID, recipientID string, messageIDs []string, status string, at time.Time) error {
+	var column string
+	switch status {
+	case MessageStatusDelivered:
+		column = "delivered_at"
+	case MessageStatusRead:
+		column = "read_at"
+	case MessageStatusPlayed:
+		column = "played_at"
+	default:
+		return fmt.Errorf("unsupported receipt status %q", status)
+	}
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf(
+		`INSERT INTO message_receipts (message_id, chat_jid, recipient_id, delivered_at, %[1]s) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(message_id, chat_jid, recipient_id) DO UPDATE SET
+			delivered_at = COALESCE(message_receipts.delivered_at, excluded.delivered_at),
+			%[1]s = COALESCE(message_receipts.%[1]s, excluded.%[1]s)`,
+		column,
+	))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	at = normalizeToUTC(at)
+	for _, messageID := range messageIDs {
+		if _, err := stmt.Exec(messageID, chatJID, recipientID, at, at); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// earliest keeps the earlier of a recorded stage time and a new one.
+func earliest(current *time.Time, value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return current
+	}
+	if current == nil || value.Time.Before(*current) {
+		t := value.Time
+		return &t
+	}
+	return current
+}
+
+// messageStatusOf derives the overall status from the furthest stage any
+// recipient reached.
+func messageStatusOf(status MessageStatus) string {
+	switch {
+	case status.PlayedCount > 0:
+		return MessageStatusPlayed
+	case status.ReadCount > 0:
+		return MessageStatusRead
+	case status.DeliveredCount > 0:
+		return MessageStatusDelivered
+	case status.SentAt != nil || (status.Found && status.IsFromMe):
+		return MessageStatusSent
+	case status.Found:
+		return MessageStatusReceived
+	default:
+		return MessageStatusUnknown
+	}
+}
+
+// GetMessageStatuses returns the delivery status of each message ID, in the
+// order asked. chatJID optionally restricts the lookup to one chat; without
+// it the first chat holding the ID is used. IDs the bridge has never seen are
+// reported with status unknown.
+func (store *MessageStore) GetMessageStatuses(messageIDs []string, chatJID string) ([]MessageStatus, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+	if len(messageIDs) > MaxMessageStatusIDs {
+		return nil, fmt.Errorf("at most %d message IDs may be queried at once", MaxMessageStatusIDs)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(messageIDs)), ",")
+	args := make([]interface{}, 0, len(messageIDs)+1)
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+	chatFilter := ""
+	if chatJID != "" {
+		chatFilter = " AND chat_jid = ?"
+		args = append(args, chatJID)
+	}
+
+	byKey := map[string]*MessageStatus{}
+	var order []string
+	track := func(messageID, chatID string) *MessageStatus {
+		key := messageID + "|" + chatID
+		status, ok := byKey[key]
+		if !ok {
+			status = &MessageStatus{MessageID: messageID, ChatJID: chatID}
+			byKey[key] = status
+			order = append(order, key)
+		}
+		return status
+	}
+
+	rows, err := store.db.Query(
+		`SELECT id, chat_jid, COALESCE(is_from_me, 0) FROM messages WHERE id IN (`+placeholders+`)`+chatFilter+` ORDER BY chat_jid`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var messageID, chatID string
+		var isFromMe bool
+		if err := rows.Scan(&messageID, &chatID, &isFromMe); err != nil {
+			return nil, err
+		}
+		status := track(messageID, chatID)
+		status.Found = true
+		status.IsFromMe = isFromMe
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Receipts also cover messages the bridge sent, which are only stored
+	// once they come back through sync.
+	receiptRows, err := store.db.Query(
+		`SELECT message_id, chat_jid, recipient_id, sent_at, delivered_at, read_at, played_at
+		FROM message_receipts WHERE message_id IN (`+placeholders+`)`+chatFilter+` ORDER BY chat_jid`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer receiptRows.Close()
+	for receiptRows.Next() {
+		var messageID, chatID, recipientID string
+		var sentAt, deliveredAt, readAt, playedAt sql.NullTime
+		if err := receiptRows.Scan(&messageID, &chatID, &recipientID, &sentAt, &deliveredAt, &readAt, &playedAt); err != nil {
+			return nil, err
+		}
+		status := track(messageID, chatID)
+		if recipientID == "" {
+			status.SentAt = earliest(status.SentAt, sentAt)
+			status.IsFromMe = true
+			continue
+		}
+		status.DeliveredAt = earliest(status.DeliveredAt, deliveredAt)
+		status.ReadAt = earliest(status.ReadAt, readAt)
+		status.PlayedAt = earliest(status.PlayedAt, playedAt)
+		if deliveredAt.Valid {
+			status.DeliveredCount++
+		}
+		if readAt.Valid {
+			status.ReadCount++
+		}
+		if playedAt.Valid {
+			status.PlayedCount++
+		}
+	}
+	if err := receiptRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// An ID found in several chats reports the first one in chat order.
+	byID := map[string]*MessageStatus{}
+	sort.Strings(order)
+	for _, key := range order {
+		status := byKey[key]
+		if _, ok := byID[status.MessageID]; !ok {
+			byID[status.MessageID] = status
+		}
+	}
+
+	statuses := make([]MessageStatus, 0, len(messageIDs))
+	for _, id := range messageIDs {
+		status := MessageStatus{MessageID: id, ChatJID: chatJID}
+		if found, ok := byID[id]; ok {
+			status = *found
+		}
+		status.Status = messageStatusOf(status)
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Reminder states.
+const (
+	ReminderStatePending   = "pending"
+	ReminderStateFired     = "fired"
+	ReminderStateFailed    = "failed"
+	ReminderStateCancelled = "cancelled"
+)
+
+// Reminder deliveries: an event on the events webhook and stream, or a
+// message to the account's own chat.
+const (
+	ReminderDeliveryEvent    = "event"
+	ReminderDeliverySelfChat = "self_chat"
+)
+
+// Reminder is a note due at a time, about a chat or one of its messages.
+type Reminder struct {
+	ID        int64
+	ChatJID   string
+	MessageID string
+	Note      string
+	DueAt     time.Time
+	Delivery  string
+	State     string
+	Error     string
+	CreatedAt time.Time
+	FiredAt   *time.Time
+}
+
+// ensureRemindersSchema creates the reminders table.
+func ensureRemindersSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS reminders (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			chat_jid TEXT NOT NULL,
+			message_id TEXT,
+			note TEXT,
+			due_at TIMESTAMP NOT NULL,
+			delivery TEXT NOT NULL,
+			state TEXT NOT NULL,
+			error TEXT,
+			created_at TIMESTAMP NOT NULL,
+			fired_at TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_reminders_due ON reminders(state, due_at);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure reminders table: %v", err)
+	}
+	return nil
+}
+
+const reminderColumns = "id, chat_jid, COALESCE(message_id, ''), COALESCE(note, ''), due_at, delivery, state, COALESCE(error, ''), created_at, fired_at"
+
+func scanReminder(scanner interface{ Scan(...interface{}) error }) (Reminder, error) {
+	var reminder Reminder
+	var firedAt sql.NullTime
+	if err := scanner.Scan(&reminder.ID, &reminder.ChatJID, &reminder.MessageID, &reminder.Note, &reminder.DueAt, &reminder.Delivery, &reminder.State, &reminder.Error, &reminder.CreatedAt, &firedAt); err != nil {
+		return Reminder{}, err
+	}
+	if firedAt.Valid {
+		reminder.FiredAt = &firedAt.Time
+	}
+	return reminder, nil
+}
+
+func queryReminders(db *sql.DB, query string, args ...interface{}) ([]Reminder, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+// CreateReminder stores a pending reminder and returns it with its ID.
+func (store *MessageStore) CreateReminder(reminder Reminder) (Reminder, error) {
+	reminder.State = ReminderStatePending
+	reminder.DueAt = normalizeToUTC(reminder.DueAt)
+	reminder.CreatedAt = time.Now().UTC()
+	result, err := store.db.Exec(
+		`INSERT INTO reminders (chat_jid, message_id, note, due_at, delivery, state, created_at)
+		VALUES (?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?)`,
+		reminder.ChatJID, reminder.MessageID, reminder.Note, reminder.DueAt, reminder.Delivery, reminder.State, reminder.CreatedAt,
+	)
+	if err != nil {
+		return Reminder{}, err
+	}
+	reminder.ID, err = result.LastInsertId()
+	return reminder, err
+}
+
+// GetReminder returns a reminder, or nil when it does not exist.
+func (store *MessageStore) GetReminder(id int64) (*Reminder, error) {
+	reminder, err := scanReminder(store.db.QueryRow("SELECT "+reminderColumns+" FROM reminders WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// ListReminders returns reminders in state, or in any state when it is empty,
+// soonest due first.
+func (store *MessageStore) ListReminders(state string, limit int) ([]Reminder, error) {
+	if state == "" {
+		return queryReminders(store.db, "SELECT "+reminderColumns+" FROM reminders ORDER BY due_at, id LIMIT ?", limit)
+	}
+	return queryReminders(store.db, "SELECT "+reminderColumns+" FROM reminders WHERE state = ? ORDER BY due_at, id LIMIT ?", state, limit)
+}
+
+// DueReminders returns pending reminders due at or before now, oldest first.
+func (store *MessageStore) DueReminders(now time.Time, limit int) ([]Reminder, error) {
+	return queryReminders(
+		store.db,
+		"SELECT "+reminderColumns+" FROM reminders WHERE state = ? AND due_at <= ? ORDER BY due_at, id LIMIT ?",
+		ReminderStatePending, normalizeToUTC(now), limit,
+	)
+}
+
+// FinishReminder marks a pending reminder fired, or failed with deliveryErr.
+func (store *MessageStore) FinishReminder(id int64, at time.Time, deliveryErr error) error {
+	state, message := ReminderStateFired, ""
+	if deliveryErr != nil {
+		state, message = ReminderStateFailed, deliveryErr.Error()
+	}
+	_, err := store.db.Exec(
+		"UPDATE reminders SET state = ?, error = NULLIF(?, ''), fired_at = ? WHERE id = ? AND state = ?",
+		state, message, normalizeToUTC(at), id, ReminderStatePending,
+	)
+	return err
+}
+
+// CancelReminder cancels a pending reminder and reports whether it was
+// pending.
+func (store *MessageStore) CancelReminder(id int64) (bool, error) {
+	result, err := store.db.Exec("UPDATE reminders SET state = ? WHERE id = ? AND state = ?", ReminderStateCancelled, id, ReminderStatePending)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
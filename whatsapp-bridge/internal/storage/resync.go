@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// HistoryAnchor is the newest cached message of a chat. WhatsApp only sends
+// history on demand as the messages before one the device already has, so
+// anchors are kept when the cache is cleared for a resync.
+type HistoryAnchor struct {
+	ChatJID string
+	// ChatServer is the server the chat JID was received with.
+	ChatServer string
+	MessageID  string
+	IsFromMe   bool
+	Timestamp  time.Time
+}
+
+// ClearForResync deletes cached messages, chats, aliases and what is derived
+// from them, keeping each chat's newest message and its chat row as the
+// anchor to request older history from, and returns the anchors. User data
+// such as notes, tags, views and chat settings is kept.
+func (store *MessageStore) ClearForResync() ([]HistoryAnchor, error) {
+	if err := store.FlushIngestBuffer(); err != nil {
+		return nil, fmt.Errorf("failed to flush ingest buffer before resync: %v", err)
+	}
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start resync transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		CREATE TEMP TABLE IF NOT EXISTS resync_anchors (msg_rowid INTEGER PRIMARY KEY);
+		DELETE FROM resync_anchors;
+		INSERT INTO resync_anchors (msg_rowid)
+		SELECT (SELECT m.rowid FROM messages m WHERE m.chat_jid = c.chat_jid
+			ORDER BY m.timestamp DESC, m.id DESC LIMIT 1)
+		FROM (SELECT DISTINCT chat_jid FROM messages) c;
+	`); err != nil {
+		return nil, fmt.Errorf("failed to select resync anchors: %v", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT m.chat_jid, COALESCE(m.chat_server, ''), m.id, COALESCE(m.is_from_me, 0), m.timestamp
+		FROM messages m JOIN resync_anchors a ON a.msg_rowid = m.rowid
+		ORDER BY m.timestamp DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resync anchors: %v", err)
+	}
+	var anchors []HistoryAnchor
+	for rows.Next() {
+		var anchor HistoryAnchor
+		if err := rows.Scan(&anchor.ChatJID, &anchor.ChatServer, &anchor.MessageID, &anchor.IsFromMe, &anchor.Timestamp); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		anchors = append(anchors, anchor)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	statements := []string{
+		"DELETE FROM links;",
+		"DELETE FROM message_embeddings;",
+		"DELETE FROM digests;",
+		"DELETE FROM chat_languages;",
+		"DELETE FROM messages WHERE rowid NOT IN (SELECT msg_rowid FROM resync_anchors);",
+		`DELETE FROM message_receipts WHERE NOT EXISTS (
+			SELECT 1 FROM messages m WHERE m.id = message_receipts.message_id AND m.chat_jid = message_receipts.chat_jid
+		);`,
+		"DELETE FROM chats WHERE jid NOT IN (SELECT chat_jid FROM messages WHERE chat_jid IS NOT NULL);",
+		"DELETE FROM sender_id_aliases;",
+		"DROP TABLE resync_anchors;",
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("failed to clear message store for resync: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit resync transaction: %v", err)
+	}
+	if err := store.flushSnapshot(); err != nil {
+		return nil, fmt.Errorf("failed to flush resync snapshot: %v", err)
+	}
+	return anchors, nil
+}
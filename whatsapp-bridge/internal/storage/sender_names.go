@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// resolvedSenderNameSQL is the best-known display name of the sender of the
+// message aliased m: a local name override, a real synced contact or chat
+// name stored under the sender or its canonical ID, then the latest push
+// name the sender chose, or empty when none is known.
+const resolvedSenderNameSQL = `COALESCE(
+		(SELECT name FROM chat_name_overrides WHERE chat_jid = m.sender),
+		(SELECT name FROM chats WHERE jid = m.sender AND NOT ` + placeholderChatNameSQL + `),
+		(SELECT chats.name FROM sender_id_aliases a JOIN chats ON chats.jid = a.canonical_id
+			WHERE a.alias_id = m.sender AND NOT ` + placeholderChatNameSQL + `),
+		(SELECT push_name FROM sender_push_names WHERE sender_id = m.sender),
+		'')`
+
+// ensureSenderNamesSchema creates the sender_push_names table and the
+// messages_with_names view, which adds each message's resolved sender and
+// chat names so clients need not keep their own ID-to-name mapping. The view
+// is recreated on startup so it picks up the current name sources.
+func ensureSenderNamesSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS sender_push_names (
+			sender_id TEXT PRIMARY KEY,
+			push_name TEXT NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+		DROP VIEW IF EXISTS messages_with_names;
+		CREATE VIEW messages_with_names AS
+			SELECT m.*,
+				CASE WHEN COALESCE(m.is_from_me, 0) = 1 THEN 'Me' ELSE ` + resolvedSenderNameSQL + ` END AS sender_name,
+				COALESCE(c.name, '') AS chat_name
+			FROM messages m
+			LEFT JOIN chats c ON c.jid = m.chat_jid;
+	`); err != nil {
+		return fmt.Errorf("failed to ensure sender names schema: %v", err)
+	}
+	return nil
+}
+
+// RecordSenderPushName remembers the push name a sender chose, as seen on one
+// of their messages, unless a newer one is already stored.
+func (store *MessageStore) RecordSenderPushName(senderID, pushName string, seenAt time.Time) error {
+	if senderID == "" || pushName == "" {
+		return nil
+	}
+	_, err := store.db.Exec(
+		`INSERT INTO sender_push_names (sender_id, push_name, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(sender_id) DO UPDATE SET push_name = excluded.push_name, updated_at = excluded.updated_at
+		WHERE excluded.updated_at >= sender_push_names.updated_at`,
+		senderID, pushName, normalizeToUTC(seenAt),
+	)
+	return err
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// MaxMessagesSincePage caps how many messages one page of GetMessagesSince
+// returns.
+const MaxMessagesSincePage = 1000
+
+// ensureMessageSequenceSchema adds the messages.seq column, numbering
+// existing rows in the order they were stored, and the message_sequence
+// counter that hands out the next number. Unlike timestamps, which collide
+// and arrive out of order from history sync, seq only ever grows, so
+// consumers can ask for everything stored after the last seq they saw.
+func ensureMessageSequenceSchema(db *sql.DB) error {
+	if err := ensureTableColumns(db, "messages", []schemaColumn{
+		{name: "seq", definition: "INTEGER"},
+	}); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_sequence (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			value INTEGER NOT NULL
+		);
+		UPDATE messages SET seq = rowid + (SELECT COALESCE(MAX(seq), 0) FROM messages)
+		WHERE seq IS NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_seq ON messages(seq);
+		INSERT INTO message_sequence (id, value) VALUES (1, (SELECT COALESCE(MAX(seq), 0) FROM messages))
+		ON CONFLICT(id) DO UPDATE SET value = MAX(value, excluded.value);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure message sequence: %v", err)
+	}
+	return nil
+}
+
+// advanceMessageSequence moves the counter past any seq handed out in tx, so
+// numbers are never reused even after the newest message is deleted.
+func advanceMessageSequence(tx *sql.Tx) error {
+	_, err := tx.Exec(
+		`UPDATE message_sequence SET value = MAX(value, (SELECT COALESCE(MAX(seq), 0) FROM messages)) WHERE id = 1`,
+	)
+	return err
+}
+
+// GetMessagesSince returns up to limit messages stored after seq afterSeq,
+// across all chats, in the order they were stored.
+func (store *MessageStore) GetMessagesSince(afterSeq int64, limit int) ([]Message, error) {
+	rows, err := store.db.Query(
+		`SELECT `+senderNameMessageColumns+`
+		WHERE m.seq > ?
+		ORDER BY m.seq ASC
+		LIMIT ?`,
+		afterSeq, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanMessagesWithSenderNames(rows)
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// SpamScore is the spam classifier's verdict on one message.
+type SpamScore struct {
+	MessageID string
+	ChatJID   string
+	// Score runs from 0 (clean) to 100.
+	Score   int
+	Reasons []string
+	// Quarantined holds the message back from normal queries until reviewed.
+	Quarantined bool
+}
+
+// QuarantinedMessage is a message held for review.
+type QuarantinedMessage struct {
+	Message
+	SpamScore   int
+	SpamReasons []string
+	Quarantined bool
+	// UnknownSender marks messages in a direct chat the user has never
+	// written in.
+	UnknownSender bool
+}
+
+// unknownSenderSQL matches messages in direct chats (joined as ch) the user
+// has never written in.
+const unknownSenderSQL = `(COALESCE(ch.chat_type, '') = '` + jid.ChatTypeDirect + `' AND NOT EXISTS (
+	SELECT 1 FROM messages mine WHERE mine.chat_jid = m.chat_jid AND mine.is_from_me = 1
+))`
+
+// SetSpamScore records a classifier verdict on a stored message.
+func (store *MessageStore) SetSpamScore(score SpamScore) error {
+	_, err := store.db.Exec(
+		`UPDATE messages SET spam_score = ?, spam_reasons = ?, quarantined = ? WHERE id = ? AND chat_jid = ?`,
+		score.Score, strings.Join(score.Reasons, ","), score.Quarantined, score.MessageID, score.ChatJID,
+	)
+	return err
+}
+
+// ListQuarantine returns the newest incoming messages held for review: those
+// the spam classifier quarantined, and those from unknown senders.
+func (store *MessageStore) ListQuarantine(limit int) ([]QuarantinedMessage, error) {
+	rows, err := store.db.Query(`
+		SELECT m.id, m.chat_jid, COALESCE(m.sender, ''), COALESCE(c.name, ''), COALESCE(m.content, ''), m.timestamp,
+			COALESCE(m.media_type, ''), COALESCE(m.filename, ''),
+			COALESCE(m.spam_score, 0), COALESCE(m.spam_reasons, ''), COALESCE(m.quarantined, 0), `+unknownSenderSQL+`
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.sender
+		LEFT JOIN chats ch ON ch.jid = m.chat_jid
+		WHERE COALESCE(m.is_from_me, 0) = 0 AND (COALESCE(m.quarantined, 0) = 1 OR `+unknownSenderSQL+`)
+		ORDER BY m.timestamp DESC
+		LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []QuarantinedMessage
+	for rows.Next() {
+		var msg QuarantinedMessage
+		var timestamp time.Time
+		var reasons string
+		if err := rows.Scan(&msg.ID, &msg.ChatJID, &msg.Sender, &msg.SenderName, &msg.Content, &timestamp, &msg.MediaType, &msg.Filename, &msg.SpamScore, &reasons, &msg.Quarantined, &msg.UnknownSender); err != nil {
+			return nil, err
+		}
+		msg.Time = timestamp
+		if reasons != "" {
+			msg.SpamReasons = strings.Split(reasons, ",")
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// State scopes: entries belong to a chat or to a contact.
+const (
+	StateScopeChat    = "chat"
+	StateScopeContact = "contact"
+)
+
+// ErrStateConflict is returned when a state write's expected version does not
+// match the stored one.
+var ErrStateConflict = errors.New("state version conflict")
+
+// StateEntry is one key of conversation state kept for external bot logic.
+// Version starts at 1 and increases with every write.
+type StateEntry struct {
+	Scope     string
+	Subject   string
+	Key       string
+	Value     []byte
+	Version   int64
+	UpdatedAt time.Time
+}
+
+// ensureStateSchema creates the conversation_state table.
+func ensureStateSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS conversation_state (
+			scope TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (scope, subject, key)
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure conversation_state table: %v", err)
+	}
+	return nil
+}
+
+const stateColumns = "scope, subject, key, value, version, updated_at"
+
+func scanStateEntry(scanner interface{ Scan(...interface{}) error }) (StateEntry, error) {
+	var entry StateEntry
+	var value string
+	if err := scanner.Scan(&entry.Scope, &entry.Subject, &entry.Key, &value, &entry.Version, &entry.UpdatedAt); err != nil {
+		return StateEntry{}, err
+	}
+	entry.Value = []byte(value)
+	return entry, nil
+}
+
+// GetState returns one state entry, or nil when the key is not set.
+func (store *MessageStore) GetState(scope, subject, key string) (*StateEntry, error) {
+	entry, err := scanStateEntry(store.db.QueryRow(
+		"SELECT "+stateColumns+" FROM conversation_state WHERE scope = ? AND subject = ? AND key = ?",
+		scope, subject, key,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListState returns every state entry of a chat or contact, ordered by key.
+func (store *MessageStore) ListState(scope, subject string) ([]StateEntry, error) {
+	rows, err := store.db.Query(
+		"SELECT "+stateColumns+" FROM conversation_state WHERE scope = ? AND subject = ? ORDER BY key",
+		scope, subject,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []StateEntry
+	for rows.Next() {
+		entry, err := scanStateEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// PutState sets a key and returns the stored entry. With expectedVersion nil
+// the write is unconditional; 0 requires the key to be unset, and any other
+// value must equal the stored version. On a mismatch it returns
+// ErrStateConflict with the current entry, if any.
+func (store *MessageStore) PutState(scope, subject, key string, value []byte, expectedVersion *int64) (*StateEntry, error) {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := scanStateEntry(tx.QueryRow(
+		"SELECT "+stateColumns+" FROM conversation_state WHERE scope = ? AND subject = ? AND key = ?",
+		scope, subject, key,
+	))
+	exists := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if expectedVersion != nil && *expectedVersion != current.Version {
+		if exists {
+			return &current, ErrStateConflict
+		}
+		return nil, ErrStateConflict
+	}
+
+	entry := StateEntry{
+		Scope:     scope,
+		Subject:   subject,
+		Key:       key,
+		Value:     value,
+		Version:   current.Version + 1,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO conversation_state (scope, subject, key, value, version, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(scope, subject, key) DO UPDATE SET
+			value = excluded.value,
+			version = excluded.version,
+			updated_at = excluded.updated_at`,
+		entry.Scope, entry.Subject, entry.Key, string(entry.Value), entry.Version, entry.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DeleteState removes a key and reports whether it was set. A non-nil
+// expectedVersion must equal the stored version, or ErrStateConflict is
+// returned and nothing is deleted.
+func (store *MessageStore) DeleteState(scope, subject, key string, expectedVersion *int64) (bool, error) {
+	query := "DELETE FROM conversation_state WHERE scope = ? AND subject = ? AND key = ?"
+	args := []interface{}{scope, subject, key}
+	if expectedVersion != nil {
+		query += " AND version = ?"
+		args = append(args, *expectedVersion)
+	}
+	result, err := store.db.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 || expectedVersion == nil {
+		return affected > 0, nil
+	}
+	existing, err := store.GetState(scope, subject, key)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return false, ErrStateConflict
+	}
+	return false, nil
+}
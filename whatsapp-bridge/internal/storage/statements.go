@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// statementCache keeps prepared statements for the write hot paths, so live
+// messages and history sync do not re-parse the same SQL on every call.
+type statementCache struct {
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// prepared returns the cached statement for query, preparing it on first use.
+func (store *MessageStore) prepared(query string) (*sql.Stmt, error) {
+	cache := &store.statements
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if stmt, ok := cache.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := store.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	if cache.stmts == nil {
+		cache.stmts = map[string]*sql.Stmt{}
+	}
+	cache.stmts[query] = stmt
+	return stmt, nil
+}
+
+// preparedTx returns the cached statement for query bound to tx.
+func (store *MessageStore) preparedTx(tx *sql.Tx, query string) (*sql.Stmt, error) {
+	stmt, err := store.prepared(query)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Stmt(stmt), nil
+}
+
+// closeStatements closes every cached statement.
+func (store *MessageStore) closeStatements() {
+	cache := &store.statements
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for _, stmt := range cache.stmts {
+		stmt.Close()
+	}
+	cache.stmts = nil
+}
@@ -10,16 +10,128 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"whatsapp-client/internal/jid"
 )
 
 // Message represents a chat message for our client.
 type Message struct {
-	Time      time.Time
+	ID         string
+	ChatJID    string
+	Time       time.Time
+	Sender     string
+	SenderName string
+	Content    string
+	IsFromMe   bool
+	IsSelfChat bool
+	MediaType  string
+	Filename   string
+	// MediaSeconds is the length of audio and video media, when known.
+	MediaSeconds uint32
+	// VoiceNote marks audio recorded as a push-to-talk voice note.
+	VoiceNote bool
+	// IsForwarded marks forwarded messages; ForwardingScore counts how often
+	// they were forwarded (see ForwardedManyTimesScore).
+	IsForwarded     bool
+	ForwardingScore uint32
+	// QuotedMessageID is the ID of the message this one replies to, if any.
+	QuotedMessageID string
+	// Seq is the local sequence number the message was stored under; it only
+	// ever grows.
+	Seq int64
+}
+
+// ForwardedManyTimesScore is the forwarding score from which WhatsApp labels
+// a message "Forwarded many times".
+const ForwardedManyTimesScore = 5
+
+// Selection kinds of interactive replies.
+const (
+	SelectionList   = "list"
+	SelectionButton = "button"
+)
+
+// Selection is the choice carried by a reply to an interactive list or
+// button message: the picked row or button's ID and its displayed title.
+type Selection struct {
+	Kind  string
+	ID    string
+	Title string
+}
+
+// Payment kinds of WhatsApp Business order and payment messages.
+const (
+	PaymentKindOrder     = "order"
+	PaymentKindRequest   = "payment_request"
+	PaymentKindSent      = "payment_sent"
+	PaymentKindInvite    = "payment_invite"
+	PaymentKindDeclined  = "payment_declined"
+	PaymentKindCancelled = "payment_cancelled"
+)
+
+// Payment holds the structured fields of an order or payment message.
+// Amount1000 is the amount in thousandths of the currency unit, as WhatsApp
+// encodes it. Reference is the order ID for orders, and the ID of the payment
+// request that sent, declined and cancelled payments answer.
+type Payment struct {
+	Kind       string
+	Amount1000 int64
+	Currency   string
+	Reference  string
+	ItemCount  int
+	Title      string
+}
+
+// MessageMedia holds media metadata extracted from a WhatsApp message.
+type MessageMedia struct {
+	MediaType     string
+	Filename      string
+	URL           string
+	MediaKey      []byte
+	FileSHA256    []byte
+	FileEncSHA256 []byte
+	FileLength    uint64
+	Thumbnail     []byte
+	// Seconds is the duration of audio and video media.
+	Seconds uint32
+	// Waveform holds a voice note's 64 loudness samples, 0-100 each.
+	Waveform  []byte
+	VoiceNote bool
+}
+
+// StoredMessage is a message row as persisted in the messages table.
+type StoredMessage struct {
+	ID        string
+	ChatJID   string
 	Sender    string
 	Content   string
+	Timestamp time.Time
 	IsFromMe  bool
-	MediaType string
-	Filename  string
+	// IsSelfChat marks messages in the account's own "message yourself" chat.
+	IsSelfChat bool
+	// QuotedMessageID is the ID of the message this one replies to, if any.
+	QuotedMessageID string
+	// RawSender is the sender's user ID as received, before canonicalization,
+	// kept so a wrong alias merge can be split again.
+	RawSender string
+	// SenderServer and ChatServer are the servers the sender and chat JIDs were
+	// received with (s.whatsapp.net, lid, g.us, ...), which normalization strips.
+	SenderServer string
+	ChatServer   string
+	// LinkURL and LinkTitle carry WhatsApp's link preview, when one was attached.
+	LinkURL   string
+	LinkTitle string
+	// IsForwarded and ForwardingScore come from the message's context info.
+	IsForwarded     bool
+	ForwardingScore uint32
+	// Selection is the list row or button an interactive reply picked.
+	Selection Selection
+	// Payment is set for order and payment messages.
+	Payment Payment
+	// PushName is the display name the sender chose, as received with a live
+	// message. It is passed to pipeline stages but not stored.
+	PushName string
+	MessageMedia
 }
 
 // MessageStore manages chat/message persistence.
@@ -27,8 +139,14 @@ type MessageStore struct {
 	db               *sql.DB
 	flushTickerStop  chan struct{}
 	flushTickerDone  chan struct{}
+	sweepTickerStop  chan struct{}
+	sweepTickerDone  chan struct{}
 	flushMutex       sync.Mutex
 	persistentDBPath string
+	chatLocks        chatLocks
+	ingest           *ingestBuffer
+	statements       statementCache
+	promotions       aliasPromotions
 }
 
 type messageStoreMode string
@@ -45,6 +163,8 @@ type messageStoreConfig struct {
 	mode                messageStoreMode
 	syncIntervalSeconds int
 	runtimePaths        RuntimePaths
+	ingestBuffer        ingestBufferConfig
+	orphanSweepInterval time.Duration
 }
 
 func parseMessageStoreConfig() (messageStoreConfig, error) {
@@ -73,6 +193,8 @@ func parseMessageStoreConfig() (messageStoreConfig, error) {
 		mode:                normalizedMode,
 		syncIntervalSeconds: syncInterval,
 		runtimePaths:        runtimePaths,
+		ingestBuffer:        parseIngestBufferConfig(),
+		orphanSweepInterval: orphanSweepIntervalFromEnv(),
 	}, nil
 }
 
@@ -106,11 +228,13 @@ func quoteSQLitePath(path string) string {
 	return "'" + strings.ReplaceAll(path, "'", "''") + "'"
 }
 
+// normalizeToUTC converts a timestamp to whole-second UTC so every stored
+// value serializes as "YYYY-MM-DD HH:MM:SS+00:00" and compares correctly as text.
 func normalizeToUTC(value time.Time) time.Time {
 	if value.IsZero() {
 		return value
 	}
-	return value.UTC()
+	return value.UTC().Truncate(time.Second)
 }
 
 type schemaColumn struct {
@@ -170,6 +294,7 @@ func runSchemaMigrations(db *sql.DB) error {
 		{name: "jid", definition: "TEXT"},
 		{name: "name", definition: "TEXT"},
 		{name: "last_message_time", definition: "TIMESTAMP"},
+		{name: "chat_type", definition: "TEXT"},
 	}); err != nil {
 		return err
 	}
@@ -188,6 +313,30 @@ func runSchemaMigrations(db *sql.DB) error {
 		{name: "file_sha256", definition: "BLOB"},
 		{name: "file_enc_sha256", definition: "BLOB"},
 		{name: "file_length", definition: "INTEGER"},
+		{name: "thumbnail", definition: "BLOB"},
+		{name: "local_path", definition: "TEXT"},
+		{name: "is_self_chat", definition: "BOOLEAN"},
+		{name: "quoted_message_id", definition: "TEXT"},
+		{name: "raw_sender", definition: "TEXT"},
+		{name: "sender_server", definition: "TEXT"},
+		{name: "chat_server", definition: "TEXT"},
+		{name: "media_seconds", definition: "INTEGER"},
+		{name: "media_waveform", definition: "BLOB"},
+		{name: "is_voice_note", definition: "BOOLEAN"},
+		{name: "is_forwarded", definition: "BOOLEAN"},
+		{name: "forwarding_score", definition: "INTEGER"},
+		{name: "spam_score", definition: "INTEGER"},
+		{name: "spam_reasons", definition: "TEXT"},
+		{name: "quarantined", definition: "BOOLEAN"},
+		{name: "selection_kind", definition: "TEXT"},
+		{name: "selection_id", definition: "TEXT"},
+		{name: "selection_title", definition: "TEXT"},
+		{name: "payment_kind", definition: "TEXT"},
+		{name: "payment_amount_1000", definition: "INTEGER"},
+		{name: "payment_currency", definition: "TEXT"},
+		{name: "payment_reference", definition: "TEXT"},
+		{name: "payment_item_count", definition: "INTEGER"},
+		{name: "payment_title", definition: "TEXT"},
 	}); err != nil {
 		return err
 	}
@@ -196,10 +345,28 @@ func runSchemaMigrations(db *sql.DB) error {
 		CREATE INDEX IF NOT EXISTS idx_chats_last_message_time ON chats(last_message_time DESC);
 		CREATE INDEX IF NOT EXISTS idx_messages_chat_timestamp ON messages(chat_jid, timestamp DESC);
 		CREATE INDEX IF NOT EXISTS idx_messages_sender_timestamp ON messages(sender, timestamp DESC);
+		CREATE INDEX IF NOT EXISTS idx_messages_chat_media_timestamp ON messages(chat_jid, media_type, timestamp DESC);
+		CREATE INDEX IF NOT EXISTS idx_messages_media_timestamp ON messages(media_type, timestamp DESC);
+		CREATE INDEX IF NOT EXISTS idx_messages_from_me_timestamp ON messages(is_from_me, timestamp DESC);
+		CREATE INDEX IF NOT EXISTS idx_messages_chat_from_me_timestamp ON messages(chat_jid, is_from_me, timestamp DESC);
+		CREATE INDEX IF NOT EXISTS idx_messages_content_length ON messages(LENGTH(content));
 	`); err != nil {
 		return fmt.Errorf("failed to ensure performance indexes: %v", err)
 	}
 
+	// Keep what the full JIDs said before sender and chat IDs are normalized.
+	if _, err := db.Exec(`
+		UPDATE messages
+		SET raw_sender = COALESCE(raw_sender, SUBSTR(sender, 1, INSTR(sender, '@') - 1)),
+			sender_server = LOWER(SUBSTR(sender, INSTR(sender, '@') + 1))
+		WHERE sender_server IS NULL AND INSTR(sender, '@') > 1;
+
+		UPDATE messages SET chat_server = LOWER(SUBSTR(chat_jid, INSTR(chat_jid, '@') + 1))
+		WHERE chat_server IS NULL AND INSTR(chat_jid, '@') > 1;
+	`); err != nil {
+		return fmt.Errorf("failed to backfill message JID servers: %v", err)
+	}
+
 	if _, err := db.Exec(`
 		UPDATE messages SET sender = SUBSTR(sender, 1, INSTR(sender, '@') - 1)
 		WHERE INSTR(sender, '@') > 1
@@ -239,6 +406,122 @@ func runSchemaMigrations(db *sql.DB) error {
 		return fmt.Errorf("failed to backfill sender_id_aliases: %v", err)
 	}
 
+	if err := ensureLinksSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureEmbeddingsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureViewsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureDigestsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureJobsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureChatSettingsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureAliasSplitsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureGroupInvitesSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureCommunitiesSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureGroupMetadataSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureChatNameOverridesSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureBroadcastListsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureReceiptsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureEventJournalSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureFirstContactsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureStateSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureRemindersSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureOutboxSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureSavedContactsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureContactTagsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureChatReadsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureAudiencesSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureCampaignsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureOptOutsSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureChatLanguagesSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureSenderNamesSchema(db); err != nil {
+		return err
+	}
+
+	if err := ensureMessageSequenceSchema(db); err != nil {
+		return err
+	}
+
+	if err := repairGroupSenders(db); err != nil {
+		return err
+	}
+
+	if err := repairDuplicateMessages(db); err != nil {
+		return err
+	}
+
 	if _, err := db.Exec(`
 		CREATE TEMP TABLE IF NOT EXISTS chat_id_map (
 			old_id TEXT PRIMARY KEY,
@@ -247,18 +530,7 @@ func runSchemaMigrations(db *sql.DB) error {
 		DELETE FROM chat_id_map;
 
 		INSERT OR REPLACE INTO chat_id_map(old_id, new_id)
-		SELECT source_id,
-			CASE
-				WHEN source_id LIKE '%@g.us' THEN source_id
-				WHEN INSTR(source_id, '@') > 0 THEN COALESCE(
-					(SELECT canonical_id FROM sender_id_aliases WHERE alias_id = SUBSTR(source_id, 1, INSTR(source_id, '@') - 1) LIMIT 1),
-					SUBSTR(source_id, 1, INSTR(source_id, '@') - 1)
-				)
-				ELSE COALESCE(
-					(SELECT canonical_id FROM sender_id_aliases WHERE alias_id = source_id LIMIT 1),
-					source_id
-				)
-			END AS normalized_id
+		SELECT source_id, ` + canonicalChatExpr("source_id") + ` AS normalized_id
 		FROM (
 			SELECT jid AS source_id FROM chats
 			UNION
@@ -300,6 +572,39 @@ func runSchemaMigrations(db *sql.DB) error {
 			SELECT 1 FROM chat_id_map WHERE old_id = messages.chat_jid AND new_id <> old_id
 		);
 
+		UPDATE OR REPLACE links
+		SET chat_jid = (
+			SELECT new_id FROM chat_id_map WHERE old_id = links.chat_jid
+		)
+		WHERE EXISTS (
+			SELECT 1 FROM chat_id_map WHERE old_id = links.chat_jid AND new_id <> old_id
+		);
+
+		UPDATE OR REPLACE message_embeddings
+		SET chat_jid = (
+			SELECT new_id FROM chat_id_map WHERE old_id = message_embeddings.chat_jid
+		)
+		WHERE EXISTS (
+			SELECT 1 FROM chat_id_map WHERE old_id = message_embeddings.chat_jid AND new_id <> old_id
+		);
+
+		INSERT INTO chat_settings (chat_jid, read_only, human_handoff, handoff_by, handoff_at, tracked, updated_at)
+		SELECT map.new_id, settings.read_only, settings.human_handoff, settings.handoff_by, settings.handoff_at, settings.tracked, settings.updated_at
+		FROM chat_settings settings
+		JOIN chat_id_map map ON map.old_id = settings.chat_jid
+		WHERE map.new_id <> map.old_id
+		ON CONFLICT(chat_jid) DO UPDATE SET
+			read_only = MAX(chat_settings.read_only, excluded.read_only),
+			human_handoff = MAX(chat_settings.human_handoff, excluded.human_handoff),
+			handoff_by = COALESCE(chat_settings.handoff_by, excluded.handoff_by),
+			handoff_at = COALESCE(chat_settings.handoff_at, excluded.handoff_at),
+			tracked = MAX(chat_settings.tracked, excluded.tracked);
+
+		DELETE FROM chat_settings
+		WHERE chat_jid IN (
+			SELECT old_id FROM chat_id_map WHERE new_id <> old_id
+		);
+
 		DELETE FROM chats
 		WHERE jid IN (
 			SELECT old_id FROM chat_id_map WHERE new_id <> old_id
@@ -310,6 +615,14 @@ func runSchemaMigrations(db *sql.DB) error {
 		return fmt.Errorf("failed to normalize chats/messages chat IDs: %v", err)
 	}
 
+	if _, err := db.Exec(`UPDATE chats SET chat_type = ` + chatTypeExpr("jid") + ` WHERE chat_type IS NULL`); err != nil {
+		return fmt.Errorf("failed to backfill chats.chat_type: %v", err)
+	}
+
+	if err := ensureReferentialIntegrity(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -336,7 +649,8 @@ func openMessageDB(path string) (*sql.DB, error) {
 		CREATE TABLE IF NOT EXISTS chats (
 			jid TEXT PRIMARY KEY,
 			name TEXT,
-			last_message_time TIMESTAMP
+			last_message_time TIMESTAMP,
+			chat_type TEXT
 		);
 
 		CREATE TABLE IF NOT EXISTS messages (
@@ -353,6 +667,13 @@ func openMessageDB(path string) (*sql.DB, error) {
 			file_sha256 BLOB,
 			file_enc_sha256 BLOB,
 			file_length INTEGER,
+			thumbnail BLOB,
+			local_path TEXT,
+			is_self_chat BOOLEAN,
+			quoted_message_id TEXT,
+			raw_sender TEXT,
+			sender_server TEXT,
+			chat_server TEXT,
 			PRIMARY KEY (id, chat_jid),
 			FOREIGN KEY (chat_jid) REFERENCES chats(jid)
 		);
@@ -475,9 +796,21 @@ func NewMessageStore() (*MessageStore, error) {
 	}
 	store.db = db
 
+	if cfg.ingestBuffer.enabled {
+		// The journal lives next to the persistent database so it survives a
+		// crash even when sqlite runs from the hot store.
+		if err := store.startIngestBuffer(persistentDir, cfg.ingestBuffer); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
 	if cfg.mode == messageStoreModeHotLocalSync {
 		store.startSnapshotTicker(time.Duration(cfg.syncIntervalSeconds) * time.Second)
 	}
+	if cfg.orphanSweepInterval > 0 {
+		store.startOrphanSweepTicker(cfg.orphanSweepInterval)
+	}
 	return store, nil
 }
 
@@ -486,6 +819,15 @@ func (store *MessageStore) Close() error {
 	if store == nil || store.db == nil {
 		return nil
 	}
+	// The orphan sweep flushes the ingest buffer, so it stops first.
+	if store.sweepTickerStop != nil {
+		close(store.sweepTickerStop)
+		<-store.sweepTickerDone
+		store.sweepTickerStop = nil
+		store.sweepTickerDone = nil
+	}
+	store.closeIngestBuffer()
+	store.closeAliasPromotions()
 	if store.flushTickerStop != nil {
 		close(store.flushTickerStop)
 		if store.flushTickerDone != nil {
@@ -497,6 +839,7 @@ func (store *MessageStore) Close() error {
 	if err := store.flushSnapshot(); err != nil {
 		fmt.Printf("Warning: final message snapshot flush failed: %v\n", err)
 	}
+	store.closeStatements()
 	return store.db.Close()
 }
 
@@ -505,6 +848,11 @@ func (store *MessageStore) Reset() error {
 	if store == nil || store.db == nil {
 		return nil
 	}
+	// Flush first so buffered messages are deleted too rather than stored
+	// into the emptied cache afterwards.
+	if err := store.FlushIngestBuffer(); err != nil {
+		return fmt.Errorf("failed to flush ingest buffer before reset: %v", err)
+	}
 
 	tx, err := store.db.Begin()
 	if err != nil {
@@ -512,6 +860,9 @@ func (store *MessageStore) Reset() error {
 	}
 
 	statements := []string{
+		"DELETE FROM links;",
+		"DELETE FROM message_embeddings;",
+		"DELETE FROM digests;",
 		"DELETE FROM messages;",
 		"DELETE FROM chats;",
 		"DELETE FROM sender_id_aliases;",
@@ -532,38 +883,64 @@ func (store *MessageStore) Reset() error {
 	return nil
 }
 
+// storeChatSQL merges a chat into its stored row instead of replacing it: a
+// write carrying no name or only a placeholder keeps the stored name, and
+// history sync catching up never moves last_message_time backwards.
+const storeChatSQL = `INSERT INTO chats (jid, name, last_message_time, chat_type) VALUES (?1, ?2, ?3, ?4)
+	ON CONFLICT(jid) DO UPDATE SET
+		name = CASE WHEN ?5 AND COALESCE(name, '') <> '' THEN name ELSE excluded.name END,
+		last_message_time = CASE
+			WHEN last_message_time IS NULL OR excluded.last_message_time > last_message_time THEN excluded.last_message_time
+			ELSE last_message_time
+		END,
+		chat_type = excluded.chat_type`
+
 // StoreChat upserts chat metadata with its latest message timestamp.
-func (store *MessageStore) StoreChat(jid, name string, lastMessageTime time.Time) error {
-	_, err := store.db.Exec(
-		"INSERT OR REPLACE INTO chats (jid, name, last_message_time) VALUES (?, ?, ?)",
-		jid, name, normalizeToUTC(lastMessageTime),
-	)
-	return err
-}
+func (store *MessageStore) StoreChat(chatJID, name string, lastMessageTime time.Time) error {
+	unlock := store.chatLocks.lock(chatJID)
+	defer unlock()
 
-// normalizeSenderID strips server suffixes and surrounding whitespace.
-func normalizeSenderID(id string) string {
-	normalized := strings.TrimSpace(id)
-	if normalized == "" {
-		return ""
+	name, err := overriddenChatName(store.db, chatJID, name)
+	if err != nil {
+		return err
 	}
-	if strings.Contains(normalized, "@") {
-		return strings.SplitN(normalized, "@", 2)[0]
+	stmt, err := store.prepared(storeChatSQL)
+	if err != nil {
+		return err
 	}
-	return normalized
+	_, err = stmt.Exec(chatJID, name, normalizeToUTC(lastMessageTime), jid.ChatType(chatJID), isPlaceholderChatName(chatJID, name))
+	return err
 }
 
-// StoreSenderAliases upserts alias-to-canonical mappings for a sender.
-func (store *MessageStore) StoreSenderAliases(canonicalID string, aliases []string, updatedAt time.Time) error {
-	canonical := normalizeSenderID(canonicalID)
+// storeSenderAliasSQL upserts one alias-to-canonical mapping, keeping the
+// latest updated_at.
+const storeSenderAliasSQL = `INSERT INTO sender_id_aliases (alias_id, canonical_id, updated_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(alias_id) DO UPDATE SET
+		canonical_id = excluded.canonical_id,
+		updated_at = CASE
+			WHEN excluded.updated_at > sender_id_aliases.updated_at THEN excluded.updated_at
+			ELSE sender_id_aliases.updated_at
+		END`
+
+// StoreSenderAliases upserts alias-to-canonical mappings for a sender. It
+// reports whether any alias was new or mapped to another ID before, which is
+// when stored rows need promoting.
+func (store *MessageStore) StoreSenderAliases(canonicalID string, aliases []string, updatedAt time.Time) (bool, error) {
+	canonical := jid.NormalizeUser(canonicalID)
 	if canonical == "" {
-		return nil
+		return false, nil
+	}
+
+	split, err := store.splitAliases(canonical)
+	if err != nil {
+		return false, err
 	}
 
 	unique := map[string]struct{}{canonical: {}}
 	for _, alias := range aliases {
-		normalized := normalizeSenderID(alias)
-		if normalized == "" {
+		normalized := jid.NormalizeUser(alias)
+		if _, ok := split[normalized]; normalized == "" || ok {
 			continue
 		}
 		unique[normalized] = struct{}{}
@@ -571,44 +948,56 @@ func (store *MessageStore) StoreSenderAliases(canonicalID string, aliases []stri
 
 	tx, err := store.db.Begin()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	stmt, err := tx.Prepare(`INSERT INTO sender_id_aliases (alias_id, canonical_id, updated_at)
-		 VALUES (?, ?, ?)
-		 ON CONFLICT(alias_id) DO UPDATE SET
-		 	canonical_id = excluded.canonical_id,
-		 	updated_at = CASE
-		 		WHEN excluded.updated_at > sender_id_aliases.updated_at THEN excluded.updated_at
-		 		ELSE sender_id_aliases.updated_at
-		 	END`)
+	lookup, err := store.preparedTx(tx, "SELECT canonical_id FROM sender_id_aliases WHERE alias_id = ?")
 	if err != nil {
 		tx.Rollback()
-		return err
+		return false, err
+	}
+	stmt, err := store.preparedTx(tx, storeSenderAliasSQL)
+	if err != nil {
+		tx.Rollback()
+		return false, err
 	}
-	defer stmt.Close()
 
+	learned := false
 	for alias := range unique {
+		if alias != canonical {
+			var previous string
+			err := lookup.QueryRow(alias).Scan(&previous)
+			if err != nil && err != sql.ErrNoRows {
+				tx.Rollback()
+				return false, err
+			}
+			learned = learned || previous != canonical
+		}
 		if _, err := stmt.Exec(alias, canonical, normalizeToUTC(updatedAt)); err != nil {
 			tx.Rollback()
-			return err
+			return false, err
 		}
 	}
 
-	return tx.Commit()
+	return learned, tx.Commit()
 }
 
 // PromoteCanonicalSender rewrites message sender IDs to their canonical form.
 func (store *MessageStore) PromoteCanonicalSender(canonicalID string, aliases []string) error {
-	canonical := normalizeSenderID(canonicalID)
+	canonical := jid.NormalizeUser(canonicalID)
 	if canonical == "" {
 		return nil
 	}
 
+	split, err := store.splitAliases(canonical)
+	if err != nil {
+		return err
+	}
+
 	unique := map[string]struct{}{}
 	for _, alias := range aliases {
-		normalized := normalizeSenderID(alias)
-		if normalized == "" || normalized == canonical {
+		normalized := jid.NormalizeUser(alias)
+		if _, ok := split[normalized]; normalized == "" || normalized == canonical || ok {
 			continue
 		}
 		unique[normalized] = struct{}{}
@@ -634,21 +1023,34 @@ func (store *MessageStore) PromoteCanonicalSender(canonicalID string, aliases []
 		"UPDATE messages SET sender = ? WHERE sender IN (%s)",
 		strings.Join(placeholders, ","),
 	)
-	_, err := store.db.Exec(query, args...)
+	if _, err := store.db.Exec(query, args...); err != nil {
+		return err
+	}
+
+	linksQuery := fmt.Sprintf(
+		"UPDATE links SET sender = ? WHERE sender IN (%s)",
+		strings.Join(placeholders, ","),
+	)
+	_, err = store.db.Exec(linksQuery, args...)
 	return err
 }
 
 // PromoteCanonicalChat rewrites chat IDs to a canonical contact ID.
 func (store *MessageStore) PromoteCanonicalChat(canonicalID string, aliases []string) error {
-	canonical := normalizeSenderID(canonicalID)
+	canonical := jid.NormalizeUser(canonicalID)
 	if canonical == "" {
 		return nil
 	}
 
+	split, err := store.splitAliases(canonical)
+	if err != nil {
+		return err
+	}
+
 	unique := map[string]struct{}{}
 	for _, alias := range aliases {
-		normalized := normalizeSenderID(alias)
-		if normalized == "" || normalized == canonical {
+		normalized := jid.NormalizeUser(alias)
+		if _, ok := split[normalized]; normalized == "" || normalized == canonical || ok {
 			continue
 		}
 		unique[normalized] = struct{}{}
@@ -664,8 +1066,8 @@ func (store *MessageStore) PromoteCanonicalChat(canonicalID string, aliases []st
 
 	for alias := range unique {
 		if _, err := tx.Exec(
-			`INSERT INTO chats (jid, name, last_message_time)
-			 SELECT ?, name, last_message_time
+			`INSERT INTO chats (jid, name, last_message_time, chat_type)
+			 SELECT ?, name, last_message_time, chat_type
 			 FROM chats
 			 WHERE jid = ?
 			 ON CONFLICT(jid) DO UPDATE SET
@@ -685,6 +1087,11 @@ func (store *MessageStore) PromoteCanonicalChat(canonicalID string, aliases []st
 			return err
 		}
 
+		if err := mergeChatDuplicates(tx, alias, canonical); err != nil {
+			tx.Rollback()
+			return err
+		}
+
 		if _, err := tx.Exec(
 			"UPDATE messages SET chat_jid = ? WHERE chat_jid = ?",
 			canonical, alias,
@@ -693,6 +1100,177 @@ func (store *MessageStore) PromoteCanonicalChat(canonicalID string, aliases []st
 			return err
 		}
 
+		if _, err := tx.Exec(
+			"UPDATE OR REPLACE links SET chat_jid = ? WHERE chat_jid = ?",
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			"UPDATE OR REPLACE message_embeddings SET chat_jid = ? WHERE chat_jid = ?",
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			"UPDATE OR REPLACE message_receipts SET chat_jid = ? WHERE chat_jid = ?",
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			`INSERT INTO chat_settings (chat_jid, read_only, human_handoff, handoff_by, handoff_at, tracked, snoozed_until, updated_at)
+			 SELECT ?, read_only, human_handoff, handoff_by, handoff_at, tracked, snoozed_until, updated_at FROM chat_settings WHERE chat_jid = ?
+			 ON CONFLICT(chat_jid) DO UPDATE SET
+			 	read_only = MAX(chat_settings.read_only, excluded.read_only),
+			 	human_handoff = MAX(chat_settings.human_handoff, excluded.human_handoff),
+			 	handoff_by = COALESCE(chat_settings.handoff_by, excluded.handoff_by),
+			 	handoff_at = COALESCE(chat_settings.handoff_at, excluded.handoff_at),
+			 	tracked = MAX(chat_settings.tracked, excluded.tracked),
+			 	snoozed_until = COALESCE(chat_settings.snoozed_until, excluded.snoozed_until)`,
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM chat_settings WHERE chat_jid = ?", alias); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO chat_name_overrides (chat_jid, name, synced_name, updated_at)
+			 SELECT ?, name, synced_name, updated_at FROM chat_name_overrides WHERE chat_jid = ?`,
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			`UPDATE chats SET name = (SELECT name FROM chat_name_overrides WHERE chat_jid = ?)
+			 WHERE jid = ? AND EXISTS (SELECT 1 FROM chat_name_overrides WHERE chat_jid = ?)`,
+			canonical, canonical, canonical,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM chat_name_overrides WHERE chat_jid = ?", alias); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		// Bot state follows the chat; keys already set on the canonical chat win.
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO conversation_state (scope, subject, key, value, version, updated_at)
+			 SELECT scope, ?, key, value, version, updated_at FROM conversation_state WHERE scope = ? AND subject = ?`,
+			canonical, StateScopeChat, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM conversation_state WHERE scope = ? AND subject = ?", StateScopeChat, alias); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec("UPDATE reminders SET chat_jid = ? WHERE chat_jid = ?", canonical, alias); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO contact_tags (contact_id, tag, created_at)
+			 SELECT ?, tag, created_at FROM contact_tags WHERE contact_id = ?`,
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM contact_tags WHERE contact_id = ?", alias); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			`INSERT INTO chat_reads (chat_jid, read_at)
+			 SELECT ?, read_at FROM chat_reads WHERE chat_jid = ?
+			 ON CONFLICT(chat_jid) DO UPDATE SET read_at = MAX(read_at, excluded.read_at)`,
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM chat_reads WHERE chat_jid = ?", alias); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO opt_outs (contact_id, source, keyword, message_id, opted_out_at)
+			 SELECT ?, source, keyword, message_id, opted_out_at FROM opt_outs WHERE contact_id = ?`,
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM opt_outs WHERE contact_id = ?", alias); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO chat_languages (chat_jid, language, updated_at)
+			 SELECT ?, language, updated_at FROM chat_languages WHERE chat_jid = ?`,
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM chat_languages WHERE chat_jid = ?", alias); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		// The saved contact name follows the chat and names it, unless a
+		// local override does.
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO saved_contacts (user_id, full_name, first_name, updated_at)
+			 SELECT ?, full_name, first_name, updated_at FROM saved_contacts WHERE user_id = ?`,
+			canonical, alias,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(
+			`UPDATE chats SET name = (SELECT full_name FROM saved_contacts WHERE user_id = ?)
+			 WHERE jid = ? AND EXISTS (SELECT 1 FROM saved_contacts WHERE user_id = ?)
+			 	AND NOT EXISTS (SELECT 1 FROM chat_name_overrides WHERE chat_jid = ?)`,
+			canonical, canonical, canonical, canonical,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM saved_contacts WHERE user_id = ?", alias); err != nil {
+			tx.Rollback()
+			return err
+		}
+
 		if _, err := tx.Exec("DELETE FROM chats WHERE jid = ?", alias); err != nil {
 			tx.Rollback()
 			return err
@@ -702,33 +1280,97 @@ func (store *MessageStore) PromoteCanonicalChat(canonicalID string, aliases []st
 	return tx.Commit()
 }
 
-// StoreMessage upserts a message row and media metadata when present.
-func (store *MessageStore) StoreMessage(
-	id,
-	chatJID,
-	sender,
-	content string,
-	timestamp time.Time,
-	isFromMe bool,
-	mediaType,
-	filename,
-	url string,
-	mediaKey,
-	fileSHA256,
-	fileEncSHA256 []byte,
-	fileLength uint64,
-) error {
-	if content == "" && mediaType == "" {
+// StoreMessage upserts a message row and media metadata when present, and
+// indexes any links found in its content. Copies of the same message stored
+// under an alias of the chat are merged into this row.
+func (store *MessageStore) StoreMessage(msg StoredMessage) error {
+	if msg.Content == "" && msg.MediaType == "" {
 		return nil
 	}
 
-	_, err := store.db.Exec(
-		`INSERT OR REPLACE INTO messages
-		(id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, url, media_key, file_sha256, file_enc_sha256, file_length)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		id, chatJID, sender, content, normalizeToUTC(timestamp), isFromMe, mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength,
-	)
-	return err
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := store.storeMessage(tx, msg); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// storeMessageSQL upserts one message; see storeMessage.
+const storeMessageSQL = `INSERT INTO messages
+	(id, chat_jid, sender, content, timestamp, is_from_me, media_type, filename, url, media_key, file_sha256, file_enc_sha256, file_length, thumbnail, is_self_chat, quoted_message_id, raw_sender, sender_server, chat_server, media_seconds, media_waveform, is_voice_note, is_forwarded, forwarding_score, selection_kind, selection_id, selection_title, payment_kind, payment_amount_1000, payment_currency, payment_reference, payment_item_count, payment_title, seq)
+	VALUES (?, ?, COALESCE(
+		(SELECT alias_id FROM sender_alias_splits WHERE alias_id = ? AND canonical_id = ?),
+		(SELECT canonical_id FROM sender_id_aliases WHERE alias_id = ?),
+		?
+	), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+		(SELECT value + 1 FROM message_sequence WHERE id = 1))
+	ON CONFLICT(id, chat_jid) DO UPDATE SET
+		sender = COALESCE(NULLIF(excluded.sender, ''), messages.sender),
+		content = COALESCE(NULLIF(excluded.content, ''), messages.content),
+		timestamp = excluded.timestamp,
+		is_from_me = excluded.is_from_me,
+		media_type = COALESCE(NULLIF(excluded.media_type, ''), messages.media_type),
+		filename = COALESCE(NULLIF(excluded.filename, ''), messages.filename),
+		url = COALESCE(NULLIF(excluded.url, ''), messages.url),
+		media_key = CASE WHEN LENGTH(excluded.media_key) > 0 THEN excluded.media_key ELSE messages.media_key END,
+		file_sha256 = CASE WHEN LENGTH(excluded.file_sha256) > 0 THEN excluded.file_sha256 ELSE messages.file_sha256 END,
+		file_enc_sha256 = CASE WHEN LENGTH(excluded.file_enc_sha256) > 0 THEN excluded.file_enc_sha256 ELSE messages.file_enc_sha256 END,
+		file_length = CASE WHEN excluded.file_length > 0 THEN excluded.file_length ELSE messages.file_length END,
+		thumbnail = CASE WHEN LENGTH(excluded.thumbnail) > 0 THEN excluded.thumbnail ELSE messages.thumbnail END,
+		is_self_chat = excluded.is_self_chat,
+		quoted_message_id = COALESCE(NULLIF(excluded.quoted_message_id, ''), messages.quoted_message_id),
+		raw_sender = COALESCE(NULLIF(excluded.raw_sender, ''), messages.raw_sender),
+		sender_server = COALESCE(NULLIF(excluded.sender_server, ''), messages.sender_server),
+		chat_server = COALESCE(NULLIF(excluded.chat_server, ''), messages.chat_server),
+		media_seconds = CASE WHEN excluded.media_seconds > 0 THEN excluded.media_seconds ELSE messages.media_seconds END,
+		media_waveform = CASE WHEN LENGTH(excluded.media_waveform) > 0 THEN excluded.media_waveform ELSE messages.media_waveform END,
+		is_voice_note = MAX(COALESCE(messages.is_voice_note, 0), excluded.is_voice_note),
+		is_forwarded = MAX(COALESCE(messages.is_forwarded, 0), excluded.is_forwarded),
+		forwarding_score = MAX(COALESCE(messages.forwarding_score, 0), excluded.forwarding_score),
+		selection_kind = COALESCE(NULLIF(excluded.selection_kind, ''), messages.selection_kind),
+		selection_id = COALESCE(NULLIF(excluded.selection_id, ''), messages.selection_id),
+		selection_title = COALESCE(NULLIF(excluded.selection_title, ''), messages.selection_title),
+		payment_kind = COALESCE(NULLIF(excluded.payment_kind, ''), messages.payment_kind),
+		payment_amount_1000 = CASE WHEN excluded.payment_amount_1000 <> 0 THEN excluded.payment_amount_1000 ELSE messages.payment_amount_1000 END,
+		payment_currency = COALESCE(NULLIF(excluded.payment_currency, ''), messages.payment_currency),
+		payment_reference = COALESCE(NULLIF(excluded.payment_reference, ''), messages.payment_reference),
+		payment_item_count = CASE WHEN excluded.payment_item_count > 0 THEN excluded.payment_item_count ELSE messages.payment_item_count END,
+		payment_title = COALESCE(NULLIF(excluded.payment_title, ''), messages.payment_title)`
+
+// storeMessage writes msg within tx; see StoreMessage.
+func (store *MessageStore) storeMessage(tx *sql.Tx, msg StoredMessage) error {
+	// Re-stored messages (live then history sync, or the reverse) merge into
+	// the existing row: incoming values win, but never blank out stored ones.
+	// A raw sender split away from its canonical ID keeps its own identity.
+	rawSender := jid.NormalizeUser(msg.RawSender)
+	stmt, err := store.preparedTx(tx, storeMessageSQL)
+	if err != nil {
+		return err
+	}
+	if _, err := stmt.Exec(
+		msg.ID, msg.ChatJID, rawSender, msg.Sender, msg.Sender, msg.Sender, msg.Content, normalizeToUTC(msg.Timestamp), msg.IsFromMe,
+		msg.MediaType, msg.Filename, msg.URL, msg.MediaKey, msg.FileSHA256, msg.FileEncSHA256, msg.FileLength, msg.Thumbnail,
+		msg.IsSelfChat, msg.QuotedMessageID, rawSender, jid.NormalizeServer(msg.SenderServer), jid.NormalizeServer(msg.ChatServer),
+		msg.Seconds, msg.Waveform, msg.VoiceNote, msg.IsForwarded, msg.ForwardingScore,
+		msg.Selection.Kind, msg.Selection.ID, msg.Selection.Title,
+		msg.Payment.Kind, msg.Payment.Amount1000, msg.Payment.Currency, msg.Payment.Reference, msg.Payment.ItemCount, msg.Payment.Title,
+	); err != nil {
+		return err
+	}
+
+	if err := advanceMessageSequence(tx); err != nil {
+		return err
+	}
+
+	if err := mergeAliasDuplicates(tx, msg.ID, msg.ChatJID); err != nil {
+		return err
+	}
+
+	return storeMessageLinks(tx, msg)
 }
 
 // GetMessages returns recent messages for a chat ordered by timestamp desc.
@@ -756,25 +1398,64 @@ func (store *MessageStore) GetMessages(chatJID string, limit int) ([]Message, er
 	return messages, nil
 }
 
-// GetChats returns chats keyed by JID with their latest message timestamp.
-func (store *MessageStore) GetChats() (map[string]time.Time, error) {
-	rows, err := store.db.Query("SELECT jid, last_message_time FROM chats ORDER BY last_message_time DESC")
+// chatPreviewLength is how many characters of a chat's last message GetChats
+// returns.
+const chatPreviewLength = 120
+
+// Chat is a stored chat with a preview of its latest message.
+type Chat struct {
+	JID             string
+	Name            string
+	LastMessageTime time.Time
+	// LastMessage is the start of the latest message's text, empty for media
+	// without a caption.
+	LastMessage   string
+	LastMediaType string
+	LastSender    string
+	LastIsFromMe  bool
+	// Unread counts incoming messages after both the account's last reply and
+	// the last time it read the chat on another device.
+	Unread int
+}
+
+// GetChats returns every chat, most recently active first, with its latest
+// message and unread count. Quarantined messages are left out of both.
+func (store *MessageStore) GetChats() ([]Chat, error) {
+	rows, err := store.db.Query(`
+		SELECT c.jid, COALESCE(c.name, ''), c.last_message_time,
+			SUBSTR(COALESCE(m.content, ''), 1, ?), COALESCE(m.media_type, ''), COALESCE(m.sender, ''), COALESCE(m.is_from_me, 0),
+			(SELECT COUNT(*) FROM messages u
+				WHERE u.chat_jid = c.jid AND u.is_from_me = 0 AND COALESCE(u.quarantined, 0) = 0
+					AND u.timestamp > MAX(
+						COALESCE(r.read_at, ''),
+						COALESCE((SELECT MAX(o.timestamp) FROM messages o WHERE o.chat_jid = c.jid AND o.is_from_me = 1), '')
+					))
+		FROM chats c
+		LEFT JOIN messages m ON m.chat_jid = c.jid AND m.id = (
+			SELECT l.id FROM messages l
+			WHERE l.chat_jid = c.jid AND COALESCE(l.quarantined, 0) = 0
+			ORDER BY l.timestamp DESC LIMIT 1
+		)
+		LEFT JOIN chat_reads r ON r.chat_jid = c.jid
+		ORDER BY c.last_message_time DESC`,
+		chatPreviewLength,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	chats := make(map[string]time.Time)
+	var chats []Chat
 	for rows.Next() {
-		var jid string
-		var lastMessageTime time.Time
-		if err := rows.Scan(&jid, &lastMessageTime); err != nil {
+		var chat Chat
+		var lastMessageTime sql.NullTime
+		if err := rows.Scan(&chat.JID, &chat.Name, &lastMessageTime, &chat.LastMessage, &chat.LastMediaType, &chat.LastSender, &chat.LastIsFromMe, &chat.Unread); err != nil {
 			return nil, err
 		}
-		chats[jid] = lastMessageTime
+		chat.LastMessageTime = lastMessageTime.Time
+		chats = append(chats, chat)
 	}
-
-	return chats, nil
+	return chats, rows.Err()
 }
 
 // GetChatName returns a stored display name for the given chat JID.
@@ -807,6 +1488,26 @@ func (store *MessageStore) GetMediaInfo(id, chatJID string) (string, string, str
 	return mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength, err
 }
 
+// MessageOrigin holds the JID parts of a stored message as WhatsApp reported
+// them, before chat and sender IDs were normalized.
+type MessageOrigin struct {
+	ChatServer   string
+	Sender       string
+	SenderServer string
+	IsFromMe     bool
+}
+
+// GetMessageOrigin returns the raw sender and JID servers of a message row.
+func (store *MessageStore) GetMessageOrigin(id, chatJID string) (MessageOrigin, error) {
+	var origin MessageOrigin
+	err := store.db.QueryRow(
+		`SELECT COALESCE(chat_server, ''), COALESCE(NULLIF(raw_sender, ''), sender, ''), COALESCE(sender_server, ''), COALESCE(is_from_me, 0)
+		FROM messages WHERE id = ? AND chat_jid = ?`,
+		id, chatJID,
+	).Scan(&origin.ChatServer, &origin.Sender, &origin.SenderServer, &origin.IsFromMe)
+	return origin, err
+}
+
 // GetMessageMediaTypeAndFilename returns basic media fields for a message row.
 func (store *MessageStore) GetMessageMediaTypeAndFilename(id, chatJID string) (string, string, error) {
 	var mediaType, filename string
@@ -3,8 +3,25 @@ package storage
 import (
 	"testing"
 	"time"
+
+	_ "github.com/mattn/go-sqlite3"
 )
 
+// newTestStore opens a direct-mode message store in dir.
+func newTestStore(t *testing.T, dir string) *MessageStore {
+	t.Helper()
+	t.Setenv("WHATSAPP_MESSAGE_STORE_MODE", "direct")
+	t.Setenv("WHATSAPP_MESSAGE_STORE_PERSISTENT_DIR", dir)
+	t.Setenv("WHATSAPP_RUNTIME_USER_SCOPE", "")
+	t.Setenv("WHATSAPP_RUNTIME_ECS_MODE", "false")
+	store, err := NewMessageStore()
+	if err != nil {
+		t.Fatalf("NewMessageStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
 func TestNormalizeToUTCConvertsNonZeroTimestamp(t *testing.T) {
 	input := time.Date(2026, 3, 2, 14, 30, 0, 0, time.FixedZone("PST", -8*60*60))
 	got := normalizeToUTC(input)
@@ -26,3 +43,13 @@ func TestNormalizeToUTCKeepsZeroTimestamp(t *testing.T) {
 		t.Fatalf("expected zero timestamp, got %v", got)
 	}
 }
+
+func TestNormalizeToUTCDropsSubSecondPrecision(t *testing.T) {
+	input := time.Date(2026, 3, 8, 1, 59, 59, 750_000_000, time.FixedZone("PST", -8*60*60))
+	got := normalizeToUTC(input)
+
+	want := time.Date(2026, 3, 8, 9, 59, 59, 0, time.UTC)
+	if !got.Equal(want) || got.Nanosecond() != 0 {
+		t.Fatalf("unexpected normalized timestamp: got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"time"
+)
+
+// DefaultThreadGap is how long a conversation may pause before a new,
+// non-quoting message starts a separate thread.
+const DefaultThreadGap = 30 * time.Minute
+
+// ReplyThread is a run of related messages within a chat.
+type ReplyThread struct {
+	Messages []Message
+	// Unanswered lists inbound messages with no later outgoing message in the thread.
+	Unanswered []string
+}
+
+// GroupReplyThreads splits chronologically ordered messages into reply threads.
+// A message that quotes an earlier message in the window joins that message's
+// thread; otherwise it continues the previous message's thread unless more
+// than gap has passed, in which case it starts a new one.
+func GroupReplyThreads(messages []Message, gap time.Duration) []ReplyThread {
+	if gap <= 0 {
+		gap = DefaultThreadGap
+	}
+
+	var threads []ReplyThread
+	threadByMessage := map[string]int{}
+	previous := -1
+	for i, msg := range messages {
+		index := -1
+		if quoted, ok := threadByMessage[msg.QuotedMessageID]; ok && msg.QuotedMessageID != "" {
+			index = quoted
+		} else if i > 0 && msg.Time.Sub(messages[i-1].Time) <= gap {
+			index = previous
+		}
+		if index < 0 {
+			threads = append(threads, ReplyThread{})
+			index = len(threads) - 1
+		}
+		threads[index].Messages = append(threads[index].Messages, msg)
+		if msg.ID != "" {
+			threadByMessage[msg.ID] = index
+		}
+		previous = index
+	}
+
+	for i := range threads {
+		threads[i].Unanswered = unansweredMessageIDs(threads[i].Messages)
+	}
+	return threads
+}
+
+// unansweredMessageIDs returns inbound messages sent after the last outgoing one.
+func unansweredMessageIDs(messages []Message) []string {
+	var unanswered []string
+	for _, msg := range messages {
+		if msg.IsFromMe {
+			unanswered = nil
+			continue
+		}
+		unanswered = append(unanswered, msg.ID)
+	}
+	return unanswered
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGroupReplyThreadsSplitsOnGapAndFollowsQuotes(t *testing.T) {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	messages := []Message{
+		{ID: "a", Time: base, Content: "lunch?"},
+		{ID: "b", Time: base.Add(5 * time.Minute), IsFromMe: true, Content: "sure"},
+		{ID: "c", Time: base.Add(3 * time.Hour), Content: "did you see the doc?"},
+		{ID: "d", Time: base.Add(4 * time.Hour), Content: "where again?", QuotedMessageID: "a"},
+	}
+
+	threads := GroupReplyThreads(messages, 30*time.Minute)
+	if len(threads) != 2 {
+		t.Fatalf("expected 2 threads, got %d", len(threads))
+	}
+
+	var first []string
+	for _, msg := range threads[0].Messages {
+		first = append(first, msg.ID)
+	}
+	if !reflect.DeepEqual(first, []string{"a", "b", "d"}) {
+		t.Fatalf("unexpected first thread: %q", first)
+	}
+	if !reflect.DeepEqual(threads[0].Unanswered, []string{"d"}) {
+		t.Fatalf("unexpected unanswered in first thread: %q", threads[0].Unanswered)
+	}
+	if !reflect.DeepEqual(threads[1].Unanswered, []string{"c"}) {
+		t.Fatalf("unexpected unanswered in second thread: %q", threads[1].Unanswered)
+	}
+}
+
+func TestGroupReplyThreadsAnsweredThreadHasNoUnanswered(t *testing.T) {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	threads := GroupReplyThreads([]Message{
+		{ID: "a", Time: base},
+		{ID: "b", Time: base.Add(time.Minute)},
+		{ID: "c", Time: base.Add(2 * time.Minute), IsFromMe: true},
+	}, 0)
+	if len(threads) != 1 || len(threads[0].Unanswered) != 0 {
+		t.Fatalf("expected one fully answered thread, got %+v", threads)
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// UpstreamImportResult summarizes a migration from the upstream schema.
+type UpstreamImportResult struct {
+	Chats    int
+	Messages int
+	// Skipped counts rows with neither text nor media, which this store
+	// never keeps.
+	Skipped int
+}
+
+// upstreamMessageColumns are the upstream messages columns, in the order
+// they are scanned. Older upstream databases lack the media columns.
+var upstreamMessageColumns = []string{
+	"id", "chat_jid", "sender", "content", "timestamp", "is_from_me",
+	"media_type", "filename", "url", "media_key", "file_sha256", "file_enc_sha256", "file_length",
+}
+
+// upstreamColumnDefaults stand in for NULLs and for columns an upstream
+// database lacks; columns not listed default to an empty string.
+var upstreamColumnDefaults = map[string]string{
+	"is_from_me": "0", "media_key": "NULL", "file_sha256": "NULL", "file_enc_sha256": "NULL", "file_length": "0",
+}
+
+// tableColumns returns the column names of a table.
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := map[string]bool{}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// canonicalChatID normalizes an upstream chat JID to the key this store uses,
+// resolving personal chats through the alias table.
+func (store *MessageStore) canonicalChatID(rawJID string) (string, error) {
+	chatID := jid.NormalizeChat(rawJID)
+	if chatID == "" || !jid.IsPersonal(rawJID) {
+		return chatID, nil
+	}
+	var canonical string
+	err := store.db.QueryRow("SELECT canonical_id FROM sender_id_aliases WHERE alias_id = ?", chatID).Scan(&canonical)
+	if err == sql.ErrNoRows {
+		return chatID, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return canonical, nil
+}
+
+// ImportUpstreamDB copies chats and messages from a messages.db written by
+// the original lharries/whatsapp-mcp bridge. Upstream keeps full JIDs and
+// only the sender's user part; they are normalized the way this bridge
+// stores live messages, and media metadata is carried over so attachments
+// can still be downloaded. Existing rows are merged, never overwritten with
+// blanks, so the import can be repeated.
+func (store *MessageStore) ImportUpstreamDB(path string) (UpstreamImportResult, error) {
+	upstream, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return UpstreamImportResult{}, fmt.Errorf("failed to open upstream database: %v", err)
+	}
+	defer upstream.Close()
+
+	columns, err := tableColumns(upstream, "messages")
+	if err != nil {
+		return UpstreamImportResult{}, fmt.Errorf("failed to read upstream schema: %v", err)
+	}
+	if !columns["id"] || !columns["chat_jid"] || !columns["timestamp"] {
+		return UpstreamImportResult{}, fmt.Errorf("%s does not look like an upstream whatsapp-mcp database", path)
+	}
+
+	var result UpstreamImportResult
+	chatIDs := map[string]string{}
+	chatRows, err := upstream.Query("SELECT jid, COALESCE(name, ''), last_message_time FROM chats")
+	if err != nil {
+		return result, fmt.Errorf("failed to read upstream chats: %v", err)
+	}
+	defer chatRows.Close()
+	for chatRows.Next() {
+		var rawJID, name string
+		var lastMessageTime sql.NullTime
+		if err := chatRows.Scan(&rawJID, &name, &lastMessageTime); err != nil {
+			return result, err
+		}
+		chatID, err := store.canonicalChatID(rawJID)
+		if err != nil {
+			return result, err
+		}
+		if chatID == "" {
+			continue
+		}
+		chatIDs[rawJID] = chatID
+		if err := store.StoreImportedChat(chatID, name, lastMessageTime.Time); err != nil {
+			return result, err
+		}
+		result.Chats++
+	}
+	if err := chatRows.Err(); err != nil {
+		return result, err
+	}
+
+	selects := make([]string, 0, len(upstreamMessageColumns))
+	for _, column := range upstreamMessageColumns {
+		switch {
+		case column == "timestamp":
+			// Wrapping the column would lose its declared type, which the
+			// driver needs to parse it as a time.
+			selects = append(selects, column)
+		case columns[column] && upstreamColumnDefaults[column] != "":
+			selects = append(selects, fmt.Sprintf("COALESCE(%s, %s)", column, upstreamColumnDefaults[column]))
+		case columns[column]:
+			selects = append(selects, fmt.Sprintf("COALESCE(%s, '')", column))
+		case upstreamColumnDefaults[column] != "":
+			selects = append(selects, upstreamColumnDefaults[column])
+		default:
+			selects = append(selects, "''")
+		}
+	}
+	rows, err := upstream.Query("SELECT " + strings.Join(selects, ", ") + " FROM messages ORDER BY timestamp ASC")
+	if err != nil {
+		return result, fmt.Errorf("failed to read upstream messages: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var msg StoredMessage
+		var rawChatJID string
+		var timestamp time.Time
+		var fileLength int64
+		if err := rows.Scan(&msg.ID, &rawChatJID, &msg.Sender, &msg.Content, &timestamp, &msg.IsFromMe,
+			&msg.MediaType, &msg.Filename, &msg.URL, &msg.MediaKey, &msg.FileSHA256, &msg.FileEncSHA256, &fileLength); err != nil {
+			return result, err
+		}
+		chatID, ok := chatIDs[rawChatJID]
+		if !ok {
+			if chatID, err = store.canonicalChatID(rawChatJID); err != nil {
+				return result, err
+			}
+			if chatID == "" {
+				result.Skipped++
+				continue
+			}
+			if err := store.StoreImportedChat(chatID, "", timestamp); err != nil {
+				return result, err
+			}
+			chatIDs[rawChatJID] = chatID
+		}
+		if msg.Content == "" && msg.MediaType == "" {
+			result.Skipped++
+			continue
+		}
+
+		_, chatServer := jid.Split(rawChatJID)
+		msg.ChatJID = chatID
+		msg.ChatServer = chatServer
+		msg.RawSender = msg.Sender
+		msg.Timestamp = timestamp
+		if fileLength > 0 {
+			msg.FileLength = uint64(fileLength)
+		}
+		if err := store.StoreMessage(msg); err != nil {
+			return result, fmt.Errorf("failed to store message: %v", err)
+		}
+		result.Messages++
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/jid"
+)
+
+// ViewDefinition is a saved message filter. Empty fields do not filter.
+// WithinDays selects a rolling window ending at query time and is combined
+// with After/Before when both are set.
+type ViewDefinition struct {
+	ChatJIDs   []string   `json:"chat_jids,omitempty"`
+	Sender     string     `json:"sender,omitempty"`
+	Keywords   string     `json:"keywords,omitempty"`
+	After      *time.Time `json:"after,omitempty"`
+	Before     *time.Time `json:"before,omitempty"`
+	WithinDays int        `json:"within_days,omitempty"`
+	// Forwarded, when set, keeps only forwarded (true) or original (false) messages.
+	Forwarded *bool `json:"forwarded,omitempty"`
+}
+
+// SavedView is a named ViewDefinition.
+type SavedView struct {
+	Name       string
+	Definition ViewDefinition
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// ensureViewsSchema creates the saved_views table.
+func ensureViewsSchema(db *sql.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS saved_views (
+			name TEXT PRIMARY KEY,
+			definition TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("failed to ensure saved_views table: %v", err)
+	}
+	return nil
+}
+
+// SaveView creates or replaces a named view.
+func (store *MessageStore) SaveView(name string, definition ViewDefinition) error {
+	encoded, err := json.Marshal(definition)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	_, err = store.db.Exec(
+		`INSERT INTO saved_views (name, definition, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			definition = excluded.definition,
+			updated_at = excluded.updated_at`,
+		name, string(encoded), now, now,
+	)
+	return err
+}
+
+// GetView returns the named view, or nil when it does not exist.
+func (store *MessageStore) GetView(name string) (*SavedView, error) {
+	var view SavedView
+	var encoded string
+	err := store.db.QueryRow(
+		"SELECT name, definition, created_at, updated_at FROM saved_views WHERE name = ?",
+		name,
+	).Scan(&view.Name, &encoded, &view.CreatedAt, &view.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(encoded), &view.Definition); err != nil {
+		return nil, fmt.Errorf("failed to decode view %q: %v", name, err)
+	}
+	return &view, nil
+}
+
+// ListViews returns all saved views ordered by name.
+func (store *MessageStore) ListViews() ([]SavedView, error) {
+	rows, err := store.db.Query("SELECT name, definition, created_at, updated_at FROM saved_views ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []SavedView
+	for rows.Next() {
+		var view SavedView
+		var encoded string
+		if err := rows.Scan(&view.Name, &encoded, &view.CreatedAt, &view.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(encoded), &view.Definition); err != nil {
+			return nil, fmt.Errorf("failed to decode view %q: %v", view.Name, err)
+		}
+		views = append(views, view)
+	}
+	return views, rows.Err()
+}
+
+// DeleteView removes a named view and reports whether it existed.
+func (store *MessageStore) DeleteView(name string) (bool, error) {
+	result, err := store.db.Exec("DELETE FROM saved_views WHERE name = ?", name)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// FindMessages returns messages matching definition ordered by timestamp desc.
+// now anchors the WithinDays rolling window.
+func (store *MessageStore) FindMessages(definition ViewDefinition, now time.Time, limit int) ([]Message, error) {
+	// Quarantined messages are only listed by ListQuarantine.
+	conditions := []string{"COALESCE(m.quarantined, 0) = 0"}
+	var args []interface{}
+
+	if len(definition.ChatJIDs) > 0 {
+		placeholders := make([]string, len(definition.ChatJIDs))
+		for i, chatJID := range definition.ChatJIDs {
+			placeholders[i] = "?"
+			args = append(args, chatJID)
+		}
+		conditions = append(conditions, fmt.Sprintf("m.chat_jid IN (%s)", strings.Join(placeholders, ",")))
+	}
+	if definition.Sender != "" {
+		conditions = append(conditions, "m.sender = ?")
+		args = append(args, jid.NormalizeUser(definition.Sender))
+	}
+	for _, term := range strings.Fields(definition.Keywords) {
+		conditions = append(conditions, `m.content LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLikePattern(term)+"%")
+	}
+	if definition.After != nil {
+		conditions = append(conditions, "m.timestamp >= ?")
+		args = append(args, normalizeToUTC(*definition.After))
+	}
+	if definition.WithinDays > 0 {
+		conditions = append(conditions, "m.timestamp >= ?")
+		args = append(args, normalizeToUTC(now.AddDate(0, 0, -definition.WithinDays)))
+	}
+	if definition.Forwarded != nil {
+		conditions = append(conditions, "COALESCE(m.is_forwarded, 0) = ?")
+		args = append(args, *definition.Forwarded)
+	}
+	if definition.Before != nil {
+		conditions = append(conditions, "m.timestamp < ?")
+		args = append(args, normalizeToUTC(*definition.Before))
+	}
+	args = append(args, limit)
+
+	rows, err := store.db.Query(
+		fmt.Sprintf(
+			`SELECT m.id, m.chat_jid, COALESCE(m.sender, ''), %s, COALESCE(m.content, ''), m.timestamp,
+				COALESCE(m.is_from_me, 0), COALESCE(m.is_self_chat, 0), COALESCE(m.media_type, ''), COALESCE(m.filename, ''),
+				COALESCE(m.media_seconds, 0), COALESCE(m.is_voice_note, 0),
+				COALESCE(m.is_forwarded, 0), COALESCE(m.forwarding_score, 0), COALESCE(m.seq, 0)
+			FROM messages m
+			WHERE %s
+			ORDER BY m.timestamp DESC
+			LIMIT ?`,
+			resolvedSenderNameSQL,
+			strings.Join(conditions, " AND "),
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var msg Message
+		var timestamp time.Time
+		if err := rows.Scan(&msg.ID, &msg.ChatJID, &msg.Sender, &msg.SenderName, &msg.Content, &timestamp, &msg.IsFromMe, &msg.IsSelfChat, &msg.MediaType, &msg.Filename, &msg.MediaSeconds, &msg.VoiceNote, &msg.IsForwarded, &msg.ForwardingScore, &msg.Seq); err != nil {
+			return nil, err
+		}
+		msg.Time = timestamp
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"os"
+	"strings"
+
+	"whatsapp-client/internal/jid"
+)
+
+// Message types used by subscription filters. Media messages carry their
+// media type (image, video, audio, document, ...); MessageTypeMedia matches
+// any of them.
+const (
+	MessageTypeText  = "text"
+	MessageTypeMedia = "media"
+)
+
+// Subject identifies what an event is about, so subscriptions can be
+// evaluated without decoding its payload. Fields that do not apply are empty.
+type Subject struct {
+	ChatJID     string
+	SenderID    string
+	MessageType string
+}
+
+// Filter selects the events a consumer subscribes to. Each non-empty list
+// must contain the event's value; empty lists match everything. Events that
+// are not messages never match a MessageTypes list.
+type Filter struct {
+	Events       []string
+	ChatJIDs     []string
+	SenderIDs    []string
+	MessageTypes []string
+}
+
+// FilterFromEnv loads the webhook subscription from WHATSAPP_EVENTS_WEBHOOK_EVENTS,
+// WHATSAPP_EVENTS_WEBHOOK_CHATS, WHATSAPP_EVENTS_WEBHOOK_SENDERS and
+// WHATSAPP_EVENTS_WEBHOOK_MESSAGE_TYPES.
+func FilterFromEnv() Filter {
+	return NewFilter(
+		os.Getenv("WHATSAPP_EVENTS_WEBHOOK_EVENTS"),
+		os.Getenv("WHATSAPP_EVENTS_WEBHOOK_CHATS"),
+		os.Getenv("WHATSAPP_EVENTS_WEBHOOK_SENDERS"),
+		os.Getenv("WHATSAPP_EVENTS_WEBHOOK_MESSAGE_TYPES"),
+	)
+}
+
+// NewFilter builds a filter from comma-separated lists. Chat and sender IDs
+// are normalized the way the bridge stores them.
+func NewFilter(events, chatJIDs, senderIDs, messageTypes string) Filter {
+	return Filter{
+		Events:       splitList(events, strings.TrimSpace),
+		ChatJIDs:     splitList(chatJIDs, jid.NormalizeChat),
+		SenderIDs:    splitList(senderIDs, jid.NormalizeUser),
+		MessageTypes: splitList(messageTypes, func(value string) string { return strings.ToLower(strings.TrimSpace(value)) }),
+	}
+}
+
+func splitList(raw string, normalize func(string) string) []string {
+	var values []string
+	for _, part := range strings.Split(raw, ",") {
+		if value := normalize(part); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// IsEmpty reports whether the filter matches every event.
+func (f Filter) IsEmpty() bool {
+	return len(f.Events) == 0 && len(f.ChatJIDs) == 0 && len(f.SenderIDs) == 0 && len(f.MessageTypes) == 0
+}
+
+// Matches reports whether an event is part of the subscription.
+func (f Filter) Matches(eventType string, subject Subject) bool {
+	if len(f.Events) > 0 && !containsValue(f.Events, eventType) {
+		return false
+	}
+	if len(f.ChatJIDs) > 0 && !containsValue(f.ChatJIDs, subject.ChatJID) {
+		return false
+	}
+	if len(f.SenderIDs) > 0 && !containsValue(f.SenderIDs, subject.SenderID) {
+		return false
+	}
+	if len(f.MessageTypes) > 0 {
+		if subject.MessageType == "" {
+			return false
+		}
+		isMedia := subject.MessageType != MessageTypeText
+		if !containsValue(f.MessageTypes, subject.MessageType) && !(isMedia && containsValue(f.MessageTypes, MessageTypeMedia)) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package webhook
+
+import "testing"
+
+func TestFilterMatches(t *testing.T) {
+	groupMedia := Subject{ChatJID: "123-456@g.us", SenderID: "15551234567", MessageType: "image"}
+	directText := Subject{ChatJID: "15557654321", SenderID: "15557654321", MessageType: MessageTypeText}
+	membership := Subject{ChatJID: "123-456@g.us", SenderID: "15551234567"}
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		event   string
+		subject Subject
+		want    bool
+	}{
+		{"empty filter matches everything", Filter{}, "group.participant_joined", membership, true},
+		{"chat match", NewFilter("", "123-456@g.us", "", ""), "message.received", groupMedia, true},
+		{"chat mismatch", NewFilter("", "123-456@g.us", "", ""), "message.received", directText, false},
+		{"sender normalized from JID", NewFilter("", "", "15557654321@s.whatsapp.net", ""), "message.received", directText, true},
+		{"media matches any media type", NewFilter("", "", "", "media"), "message.received", groupMedia, true},
+		{"media excludes text", NewFilter("", "", "", "MEDIA"), "message.received", directText, false},
+		{"exact message type", NewFilter("", "", "", "text, video"), "message.received", directText, true},
+		{"message types exclude non-message events", NewFilter("", "", "", "image"), "group.participant_joined", membership, false},
+		{"event mismatch", NewFilter("message.received", "", "", ""), "group.participant_left", membership, false},
+		{"all lists must match", NewFilter("message.received", "123-456@g.us", "", "media"), "message.received", groupMedia, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event, tt.subject); got != tt.want {
+				t.Fatalf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
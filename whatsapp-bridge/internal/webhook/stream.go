@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stream queue bounds.
+const (
+	DefaultStreamQueueSize = 256
+	MaxStreamQueueSize     = 4096
+)
+
+// OverflowPolicy decides what happens when a subscriber's queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest discards the oldest queued event to make room.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDisconnect ends the subscription; the consumer reconnects and
+	// catches up from the journal.
+	OverflowDisconnect OverflowPolicy = "disconnect"
+)
+
+// ParseOverflowPolicy reads a policy name, defaulting to drop_oldest.
+func ParseOverflowPolicy(raw string) (OverflowPolicy, bool) {
+	switch OverflowPolicy(raw) {
+	case "", OverflowDropOldest:
+		return OverflowDropOldest, true
+	case OverflowDisconnect:
+		return OverflowDisconnect, true
+	default:
+		return "", false
+	}
+}
+
+// Stream fans events out to live subscribers. Publishing never blocks: each
+// subscriber has a bounded queue, and a consumer that falls behind loses
+// events or its subscription according to its policy, so one slow client
+// cannot make the bridge buffer without limit.
+type Stream struct {
+	mu          sync.Mutex
+	nextID      uint64
+	subscribers map[uint64]*Subscriber
+
+	published    atomic.Uint64
+	dropped      atomic.Uint64
+	disconnected atomic.Uint64
+}
+
+// NewStream returns a stream with no subscribers.
+func NewStream() *Stream {
+	return &Stream{subscribers: map[uint64]*Subscriber{}}
+}
+
+// Subscriber is one live consumer of a Stream.
+type Subscriber struct {
+	ID          uint64
+	Filter      Filter
+	Policy      OverflowPolicy
+	ConnectedAt time.Time
+
+	queue   chan Event
+	overrun chan struct{}
+	dropped atomic.Uint64
+}
+
+// Events delivers the subscriber's queued events.
+func (sub *Subscriber) Events() <-chan Event {
+	return sub.queue
+}
+
+// Overrun is closed when a disconnect-policy subscriber's queue overflows.
+func (sub *Subscriber) Overrun() <-chan struct{} {
+	return sub.overrun
+}
+
+// Subscribe registers a consumer. queueSize is clamped to
+// [1, MaxStreamQueueSize], with zero meaning DefaultStreamQueueSize.
+func (s *Stream) Subscribe(filter Filter, queueSize int, policy OverflowPolicy) *Subscriber {
+	if queueSize <= 0 {
+		queueSize = DefaultStreamQueueSize
+	}
+	if queueSize > MaxStreamQueueSize {
+		queueSize = MaxStreamQueueSize
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	sub := &Subscriber{
+		ID:          s.nextID,
+		Filter:      filter,
+		Policy:      policy,
+		ConnectedAt: time.Now().UTC(),
+		queue:       make(chan Event, queueSize),
+		overrun:     make(chan struct{}),
+	}
+	s.subscribers[sub.ID] = sub
+	return sub
+}
+
+// Unsubscribe removes a consumer. It is safe to call more than once.
+func (s *Stream) Unsubscribe(sub *Subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers, sub.ID)
+}
+
+// Publish offers an event to every subscriber whose filter matches it.
+func (s *Stream) Publish(event Event, subject Subject) {
+	if s == nil {
+		return
+	}
+	s.published.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sub := range s.subscribers {
+		if !sub.Filter.Matches(event.Type, subject) {
+			continue
+		}
+		select {
+		case sub.queue <- event:
+			continue
+		default:
+		}
+		if sub.Policy == OverflowDisconnect {
+			delete(s.subscribers, id)
+			close(sub.overrun)
+			s.disconnected.Add(1)
+			continue
+		}
+		// Publish holds the lock, so no other event can take the slot freed
+		// here; if the consumer drained one meanwhile, nothing is dropped.
+		select {
+		case <-sub.queue:
+			sub.dropped.Add(1)
+			s.dropped.Add(1)
+		default:
+		}
+		sub.queue <- event
+	}
+}
+
+// SubscriberStats describes one live subscriber.
+type SubscriberStats struct {
+	ID            uint64         `json:"id"`
+	Policy        OverflowPolicy `json:"overflow"`
+	QueueLength   int            `json:"queue_length"`
+	QueueCapacity int            `json:"queue_capacity"`
+	Dropped       uint64         `json:"dropped"`
+	ConnectedAt   string         `json:"connected_at"`
+}
+
+// StreamStats are a stream's counters since the bridge started.
+type StreamStats struct {
+	Published    uint64            `json:"published"`
+	Dropped      uint64            `json:"dropped"`
+	Disconnected uint64            `json:"disconnected"`
+	Subscribers  []SubscriberStats `json:"subscribers"`
+}
+
+// Stats snapshots the stream's counters and subscribers, oldest first.
+func (s *Stream) Stats() StreamStats {
+	stats := StreamStats{Subscribers: []SubscriberStats{}}
+	if s == nil {
+		return stats
+	}
+	stats.Published = s.published.Load()
+	stats.Dropped = s.dropped.Load()
+	stats.Disconnected = s.disconnected.Load()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subscribers {
+		stats.Subscribers = append(stats.Subscribers, SubscriberStats{
+			ID:            sub.ID,
+			Policy:        sub.Policy,
+			QueueLength:   len(sub.queue),
+			QueueCapacity: cap(sub.queue),
+			Dropped:       sub.dropped.Load(),
+			ConnectedAt:   sub.ConnectedAt.Format(time.RFC3339),
+		})
+	}
+	sort.Slice(stats.Subscribers, func(i, j int) bool { return stats.Subscribers[i].ID < stats.Subscribers[j].ID })
+	return stats
+}
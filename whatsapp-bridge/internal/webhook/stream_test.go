@@ -0,0 +1,55 @@
+package webhook
+
+import "testing"
+
+func TestStreamDropOldestKeepsNewest(t *testing.T) {
+	stream := NewStream()
+	subscriber := stream.Subscribe(Filter{}, 2, OverflowDropOldest)
+	for id := uint64(1); id <= 5; id++ {
+		stream.Publish(Event{Type: "message.received", DeliveryID: id}, Subject{})
+	}
+
+	var got []uint64
+	for len(subscriber.Events()) > 0 {
+		got = append(got, (<-subscriber.Events()).DeliveryID)
+	}
+	if len(got) != 2 || got[0] != 4 || got[1] != 5 {
+		t.Fatalf("expected the two newest events, got %v", got)
+	}
+	stats := stream.Stats()
+	if stats.Published != 5 || stats.Dropped != 3 || len(stats.Subscribers) != 1 || stats.Subscribers[0].Dropped != 3 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestStreamDisconnectPolicyEndsSubscription(t *testing.T) {
+	stream := NewStream()
+	subscriber := stream.Subscribe(Filter{}, 1, OverflowDisconnect)
+	stream.Publish(Event{DeliveryID: 1}, Subject{})
+	stream.Publish(Event{DeliveryID: 2}, Subject{})
+
+	select {
+	case <-subscriber.Overrun():
+	default:
+		t.Fatal("expected the subscriber to be overrun")
+	}
+	stats := stream.Stats()
+	if stats.Disconnected != 1 || len(stats.Subscribers) != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	stream.Unsubscribe(subscriber)
+}
+
+func TestStreamAppliesSubscriberFilter(t *testing.T) {
+	stream := NewStream()
+	subscriber := stream.Subscribe(NewFilter("", "1@g.us", "", ""), 0, OverflowDropOldest)
+	stream.Publish(Event{DeliveryID: 1}, Subject{ChatJID: "2@g.us"})
+	stream.Publish(Event{DeliveryID: 2}, Subject{ChatJID: "1@g.us"})
+
+	if len(subscriber.Events()) != 1 || (<-subscriber.Events()).DeliveryID != 2 {
+		t.Fatal("expected only the matching event")
+	}
+	if cap(subscriber.Events()) != DefaultStreamQueueSize {
+		t.Fatalf("expected default queue size, got %d", cap(subscriber.Events()))
+	}
+}
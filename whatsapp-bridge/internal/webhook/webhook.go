@@ -0,0 +1,435 @@
+// Package webhook delivers bridge events to an operator-configured HTTP endpoint.
+//
+// Every delivery carries a delivery ID that increases across deliveries and
+// restarts, and the time it was sent. Both are part of the signed body, so a
+// receiver holding the secret can use a Verifier to reject forged, stale and
+// replayed requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"whatsapp-client/internal/secrets"
+	"whatsapp-client/internal/storage"
+)
+
+const (
+	emitterQueueSize = 256
+	deliveryTimeout  = 10 * time.Second
+	// SignatureHeader carries the hex HMAC-SHA256 of the body when a secret is set.
+	SignatureHeader = "X-Bridge-Signature"
+	// DeliveryHeader and TimestampHeader repeat the body's delivery ID and
+	// send time (Unix seconds) for receivers that log before parsing.
+	DeliveryHeader  = "X-Bridge-Delivery"
+	TimestampHeader = "X-Bridge-Timestamp"
+	// DefaultTolerance is how old a delivery a Verifier accepts by default.
+	DefaultTolerance = 5 * time.Minute
+
+	defaultRetention     = 7 * 24 * time.Hour
+	journalRetryInterval = 30 * time.Second
+	journalPruneInterval = time.Hour
+	catchUpBatchSize     = 100
+	// webhookCursor names the journal cursor of the configured webhook.
+	webhookCursor = "webhook"
+)
+
+// Config controls event webhook delivery.
+type Config struct {
+	URL    string
+	Secret string
+	// SecretSource, when set, replaces Secret and is consulted on every
+	// delivery, so a rotated secret applies without a restart.
+	SecretSource func() (string, error)
+	// Retention is how long journaled events are kept for catch-up.
+	Retention time.Duration
+	// Filter selects the events posted to URL. Every event is journaled
+	// regardless.
+	Filter Filter
+}
+
+// ConfigFromEnv loads WHATSAPP_EVENTS_WEBHOOK_URL, WHATSAPP_EVENTS_WEBHOOK_SECRET,
+// WHATSAPP_EVENTS_JOURNAL_RETENTION_DAYS and the subscription filter.
+func ConfigFromEnv() Config {
+	config := Config{
+		URL: strings.TrimSpace(os.Getenv("WHATSAPP_EVENTS_WEBHOOK_URL")),
+		SecretSource: func() (string, error) {
+			return secrets.Lookup("WHATSAPP_EVENTS_WEBHOOK_SECRET")
+		},
+		Retention: defaultRetention,
+		Filter:    FilterFromEnv(),
+	}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_EVENTS_JOURNAL_RETENTION_DAYS")); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days <= 0 {
+			fmt.Printf("Warning: invalid WHATSAPP_EVENTS_JOURNAL_RETENTION_DAYS=%q, using %d\n", raw, int(defaultRetention/(24*time.Hour)))
+		} else {
+			config.Retention = time.Duration(days) * 24 * time.Hour
+		}
+	}
+	return config
+}
+
+// Enabled reports whether a webhook URL is configured.
+func (c Config) Enabled() bool {
+	return c.URL != ""
+}
+
+// Event is the JSON envelope posted for every bridge event.
+type Event struct {
+	Type      string `json:"event"`
+	Timestamp string `json:"timestamp"`
+	// DeliveryID increases with every event the bridge emits. For journaled
+	// events it is the journal sequence number.
+	DeliveryID uint64 `json:"delivery_id"`
+	// SentAt is when the delivery was attempted, RFC 3339 in UTC.
+	SentAt string      `json:"sent_at,omitempty"`
+	Data   interface{} `json:"data"`
+}
+
+// JournalSubject returns the filter subject recorded with a journaled event.
+func JournalSubject(event storage.JournalEvent) Subject {
+	return Subject{ChatJID: event.ChatJID, SenderID: event.SenderID, MessageType: event.MessageType}
+}
+
+// FromJournal converts a journaled event to its delivery envelope.
+func FromJournal(event storage.JournalEvent) Event {
+	return Event{
+		Type:       event.Type,
+		Timestamp:  event.OccurredAt.UTC().Format(time.RFC3339),
+		DeliveryID: uint64(event.Seq),
+		Data:       event.Data,
+	}
+}
+
+// Emitter posts events in the background so event handlers never wait on the
+// webhook endpoint. A nil Emitter drops everything.
+//
+// With a message store, events are first written to the event journal and
+// delivered from it in order; the last delivered sequence number is kept as
+// a cursor, so events missed while the endpoint was down, or the bridge was
+// stopped, are replayed once it accepts deliveries again. Without a store,
+// events are queued in memory and dropped on failure.
+type Emitter struct {
+	config Config
+	client *http.Client
+	store  func() *storage.MessageStore
+	wake   chan struct{}
+	queue  chan Event
+	stream *Stream
+	// lastDeliveryID numbers unjournaled events. It is seeded from the clock
+	// in microseconds, so IDs keep increasing across restarts.
+	lastDeliveryID atomic.Uint64
+}
+
+// NewEmitter starts a background emitter. store is consulted on every event
+// so journaling survives the message store being recreated; it may be nil.
+// NewEmitter returns nil when there is neither a URL nor a store.
+func NewEmitter(config Config, store func() *storage.MessageStore) *Emitter {
+	if !config.Enabled() && store == nil {
+		return nil
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaultRetention
+	}
+	emitter := &Emitter{
+		config: config,
+		client: &http.Client{Timeout: deliveryTimeout},
+		store:  store,
+		stream: NewStream(),
+	}
+	if store != nil {
+		emitter.wake = make(chan struct{}, 1)
+		// Events journaled before a webhook was configured are not sent to it.
+		emitter.startCursor()
+		go emitter.runJournal()
+		return emitter
+	}
+	emitter.queue = make(chan Event, emitterQueueSize)
+	emitter.lastDeliveryID.Store(uint64(time.Now().UnixMicro()))
+	go emitter.run()
+	return emitter
+}
+
+// Stream returns the live stream every emitted event is published to.
+func (e *Emitter) Stream() *Stream {
+	if e == nil {
+		return nil
+	}
+	return e.stream
+}
+
+// Emit journals or queues an event for delivery. subject describes the event
+// for subscription filters. Queued events are dropped when the queue is full.
+func (e *Emitter) Emit(eventType string, at time.Time, subject Subject, data interface{}) {
+	if e == nil {
+		return
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if e.store != nil {
+		e.journal(eventType, at, subject, data)
+		return
+	}
+	event := Event{
+		Type:       eventType,
+		Timestamp:  at.UTC().Format(time.RFC3339),
+		DeliveryID: e.lastDeliveryID.Add(1),
+		Data:       data,
+	}
+	e.stream.Publish(event, subject)
+	if !e.config.Enabled() || !e.config.Filter.Matches(eventType, subject) {
+		return
+	}
+	select {
+	case e.queue <- event:
+	default:
+		fmt.Printf("Warning: webhook queue is full, dropping %s event\n", eventType)
+	}
+}
+
+// Publish sends an event to live stream subscribers only. It is neither
+// journaled nor posted to the webhook, so it cannot be replayed; its
+// delivery_id is zero.
+func (e *Emitter) Publish(eventType string, at time.Time, subject Subject, data interface{}) {
+	if e == nil {
+		return
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	e.stream.Publish(Event{Type: eventType, Timestamp: at.UTC().Format(time.RFC3339), Data: data}, subject)
+}
+
+func (e *Emitter) journal(eventType string, at time.Time, subject Subject, data interface{}) {
+	store := e.store()
+	if store == nil {
+		fmt.Printf("Warning: message store unavailable, dropping %s event\n", eventType)
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		fmt.Printf("Warning: failed to encode %s event: %v\n", eventType, err)
+		return
+	}
+	journaled := storage.JournalEvent{
+		Type:        eventType,
+		OccurredAt:  at,
+		ChatJID:     subject.ChatJID,
+		SenderID:    subject.SenderID,
+		MessageType: subject.MessageType,
+		Data:        payload,
+	}
+	if journaled.Seq, err = store.AppendEvent(journaled); err != nil {
+		fmt.Printf("Warning: failed to journal %s event: %v\n", eventType, err)
+		return
+	}
+	e.stream.Publish(FromJournal(journaled), subject)
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (e *Emitter) run() {
+	for event := range e.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		if err := e.deliver(ctx, event); err != nil {
+			fmt.Printf("Warning: failed to deliver %s webhook: %v\n", event.Type, err)
+		}
+		cancel()
+	}
+}
+
+// startCursor points a new webhook cursor at the end of the journal.
+func (e *Emitter) startCursor() {
+	store := e.store()
+	if store == nil || !e.config.Enabled() {
+		return
+	}
+	if _, ok, err := store.GetEventCursor(webhookCursor); err != nil || ok {
+		return
+	}
+	latest, err := store.LatestEventSeq()
+	if err != nil {
+		return
+	}
+	if err := store.SetEventCursor(webhookCursor, latest); err != nil {
+		fmt.Printf("Warning: failed to initialize webhook cursor: %v\n", err)
+	}
+}
+
+func (e *Emitter) runJournal() {
+	retry := time.NewTicker(journalRetryInterval)
+	defer retry.Stop()
+	var lastPrune time.Time
+	for {
+		if store := e.store(); store != nil {
+			if e.config.Enabled() {
+				e.catchUp(store)
+			}
+			if time.Since(lastPrune) >= journalPruneInterval {
+				lastPrune = time.Now()
+				if _, err := store.PruneEvents(lastPrune.Add(-e.config.Retention)); err != nil {
+					fmt.Printf("Warning: failed to prune event journal: %v\n", err)
+				}
+			}
+		}
+		select {
+		case <-e.wake:
+		case <-retry.C:
+		}
+	}
+}
+
+// catchUp delivers journaled events after the webhook cursor, in order,
+// stopping at the first failure so it is retried before anything newer.
+// Events outside the subscription are passed over.
+func (e *Emitter) catchUp(store *storage.MessageStore) {
+	cursor, _, err := store.GetEventCursor(webhookCursor)
+	if err != nil {
+		fmt.Printf("Warning: failed to read webhook cursor: %v\n", err)
+		return
+	}
+	for {
+		events, err := store.GetEventsAfter(cursor, catchUpBatchSize)
+		if err != nil {
+			fmt.Printf("Warning: failed to read event journal: %v\n", err)
+			return
+		}
+		for _, journaled := range events {
+			if e.config.Filter.Matches(journaled.Type, JournalSubject(journaled)) {
+				ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+				err := e.deliver(ctx, FromJournal(journaled))
+				cancel()
+				if err != nil {
+					fmt.Printf("Warning: failed to deliver %s webhook, will retry from seq %d: %v\n", journaled.Type, journaled.Seq, err)
+					return
+				}
+			}
+			if err := store.SetEventCursor(webhookCursor, journaled.Seq); err != nil {
+				fmt.Printf("Warning: failed to advance webhook cursor: %v\n", err)
+				return
+			}
+			cursor = journaled.Seq
+		}
+		if len(events) < catchUpBatchSize {
+			return
+		}
+	}
+}
+
+func (e *Emitter) deliver(ctx context.Context, event Event) error {
+	sentAt := time.Now().UTC()
+	event.SentAt = sentAt.Format(time.RFC3339)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(event.DeliveryID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(sentAt.Unix(), 10))
+	secret := e.config.Secret
+	if e.config.SecretSource != nil {
+		if secret, err = e.config.SecretSource(); err != nil {
+			return fmt.Errorf("failed to load webhook secret: %w", err)
+		}
+	}
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, payload))
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the "sha256=<hex>" HMAC of payload under secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Errors returned by Verifier.Verify.
+var (
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+	ErrStaleDelivery    = errors.New("webhook delivery is outside the accepted time window")
+	ErrReplayedDelivery = errors.New("webhook delivery was already accepted")
+)
+
+// Verifier checks deliveries on the receiving side: the signature must match,
+// sent_at must be within Tolerance of now, and the delivery ID must be newer
+// than every ID accepted before. Receivers running several instances should
+// share the last accepted ID through their own storage instead.
+type Verifier struct {
+	Secret string
+	// Tolerance bounds clock skew and delivery delay; zero means
+	// DefaultTolerance.
+	Tolerance time.Duration
+
+	mu             sync.Mutex
+	lastDeliveryID uint64
+}
+
+// NewVerifier returns a Verifier for secret that has accepted nothing yet.
+func NewVerifier(secret string) *Verifier {
+	return &Verifier{Secret: secret}
+}
+
+// Verify authenticates one delivery given its raw body and signature header,
+// and returns the decoded event.
+func (v *Verifier) Verify(payload []byte, signature string, now time.Time) (Event, error) {
+	if v.Secret == "" || !hmac.Equal([]byte(signature), []byte(Sign(v.Secret, payload))) {
+		return Event{}, ErrInvalidSignature
+	}
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	sentAt, err := time.Parse(time.RFC3339, event.SentAt)
+	if err != nil {
+		return Event{}, ErrStaleDelivery
+	}
+	tolerance := v.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	if age := now.Sub(sentAt); age > tolerance || age < -tolerance {
+		return Event{}, ErrStaleDelivery
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if event.DeliveryID <= v.lastDeliveryID {
+		return Event{}, ErrReplayedDelivery
+	}
+	v.lastDeliveryID = event.DeliveryID
+	return event, nil
+}
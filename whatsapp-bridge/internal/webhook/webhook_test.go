@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewEmitterDisabledWithoutURL(t *testing.T) {
+	emitter := NewEmitter(Config{}, nil)
+	if emitter != nil {
+		t.Fatal("expected nil emitter without a URL")
+	}
+	emitter.Emit("group.participant_joined", time.Now(), Subject{}, nil)
+}
+
+func TestEmitterPostsSignedEvent(t *testing.T) {
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		event, err := NewVerifier("secret").Verify(body, r.Header.Get(SignatureHeader), time.Now())
+		if err != nil {
+			t.Errorf("delivery did not verify: %v", err)
+		}
+		if got, want := r.Header.Get(DeliveryHeader), strconv.FormatUint(event.DeliveryID, 10); got != want {
+			t.Errorf("unexpected delivery header: %q want %q", got, want)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	emitter := NewEmitter(Config{URL: server.URL, Secret: "secret"}, nil)
+	emitter.Emit("group.participant_left", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Subject{ChatJID: "1@g.us"}, map[string]string{"group_jid": "1@g.us"})
+
+	select {
+	case event := <-received:
+		if event.Type != "group.participant_left" || event.Timestamp != "2026-01-02T03:04:05Z" {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
+
+func TestEmitterDeliveryIDsIncrease(t *testing.T) {
+	received := make(chan Event, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	emitter := NewEmitter(Config{URL: server.URL}, nil)
+	emitter.Emit("a", time.Now(), Subject{}, nil)
+	emitter.Emit("b", time.Now(), Subject{}, nil)
+
+	var ids []uint64
+	for len(ids) < 2 {
+		select {
+		case event := <-received:
+			ids = append(ids, event.DeliveryID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not delivered")
+		}
+	}
+	if ids[0] == 0 || ids[1] != ids[0]+1 {
+		t.Fatalf("unexpected delivery IDs: %v", ids)
+	}
+}
+
+func TestVerifierRejectsForgedStaleAndReplayed(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	payload := func(id uint64, sentAt time.Time) []byte {
+		body, _ := json.Marshal(Event{Type: "x", DeliveryID: id, SentAt: sentAt.Format(time.RFC3339)})
+		return body
+	}
+	verifier := NewVerifier("secret")
+
+	fresh := payload(7, now)
+	if _, err := verifier.Verify(fresh, Sign("other", fresh), now); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected invalid signature, got %v", err)
+	}
+	stale := payload(8, now.Add(-time.Hour))
+	if _, err := verifier.Verify(stale, Sign("secret", stale), now); !errors.Is(err, ErrStaleDelivery) {
+		t.Fatalf("expected stale delivery, got %v", err)
+	}
+	event, err := verifier.Verify(fresh, Sign("secret", fresh), now)
+	if err != nil || event.DeliveryID != 7 {
+		t.Fatalf("expected delivery 7 to verify, got %+v, %v", event, err)
+	}
+	if _, err := verifier.Verify(fresh, Sign("secret", fresh), now); !errors.Is(err, ErrReplayedDelivery) {
+		t.Fatalf("expected replay, got %v", err)
+	}
+	older := payload(6, now)
+	if _, err := verifier.Verify(older, Sign("secret", older), now); !errors.Is(err, ErrReplayedDelivery) {
+		t.Fatalf("expected older delivery to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// accountHealthWindow is how far back signals count toward the score.
+	accountHealthWindow = time.Hour
+	// failureSpikeWindow and failureSpikeCount flag a burst of failed sends.
+	failureSpikeWindow = 10 * time.Minute
+	failureSpikeCount  = 5
+	// minSendsForFailureRate keeps a couple of failures among few sends from
+	// reading as a high failure rate.
+	minSendsForFailureRate = 5
+)
+
+// Account health levels.
+const (
+	AccountHealthOK       = "ok"
+	AccountHealthWarning  = "warning"
+	AccountHealthCritical = "critical"
+)
+
+type accountSignal int
+
+const (
+	signalSendOK accountSignal = iota
+	signalSendFailed
+	signalRateLimited
+	signalDisconnect
+)
+
+// AccountHealth scores how close the account looks to being restricted by
+// WhatsApp, from signals seen in the past hour, with advice for operators.
+type AccountHealth struct {
+	// Score runs from 100 (no warning signs) down to 0.
+	Score      int
+	Level      string
+	Advisories []string
+
+	SendsSucceeded int
+	SendsFailed    int
+	// RecentFailures counts failed sends in the last ten minutes.
+	RecentFailures int
+	RateLimited    int
+	Disconnects    int
+	// TemporaryBan describes a temporary ban in force until
+	// TemporaryBanUntil, if any.
+	TemporaryBan      string
+	TemporaryBanUntil time.Time
+}
+
+var accountHealth = struct {
+	mu      sync.Mutex
+	signals []accountHealthSignal
+	banText string
+	banEnds time.Time
+}{}
+
+type accountHealthSignal struct {
+	at   time.Time
+	kind accountSignal
+}
+
+func recordAccountSignal(kind accountSignal, at time.Time) {
+	accountHealth.mu.Lock()
+	defer accountHealth.mu.Unlock()
+	accountHealth.signals = pruneAccountSignals(accountHealth.signals, at)
+	accountHealth.signals = append(accountHealth.signals, accountHealthSignal{at: at, kind: kind})
+}
+
+func pruneAccountSignals(signals []accountHealthSignal, now time.Time) []accountHealthSignal {
+	cutoff := now.Add(-accountHealthWindow)
+	kept := signals[:0]
+	for _, signal := range signals {
+		if signal.at.After(cutoff) {
+			kept = append(kept, signal)
+		}
+	}
+	return kept
+}
+
+// recordSendOutcome counts a send WhatsApp accepted or refused. Rate limits
+// count on their own as well as failures.
+func recordSendOutcome(err error) {
+	now := time.Now()
+	if err == nil {
+		recordAccountSignal(signalSendOK, now)
+		return
+	}
+	var sendErr *SendError
+	if !errors.As(err, &sendErr) {
+		sendErr = classifySendError("", err)
+	}
+	recordAccountSignal(signalSendFailed, now)
+	if sendErr.Code == SendErrorRateLimited {
+		recordAccountSignal(signalRateLimited, now)
+	}
+}
+
+// handleAccountDisconnect counts a connection WhatsApp dropped.
+func handleAccountDisconnect(now time.Time) {
+	recordAccountSignal(signalDisconnect, now)
+}
+
+// handleTemporaryBan records a temporary ban WhatsApp announced.
+func handleTemporaryBan(evt *events.TemporaryBan, now time.Time) {
+	accountHealth.mu.Lock()
+	defer accountHealth.mu.Unlock()
+	accountHealth.banText = evt.String()
+	accountHealth.banEnds = now.Add(evt.Expire)
+}
+
+// GetAccountHealth returns the account's current health.
+func GetAccountHealth() AccountHealth {
+	now := time.Now()
+	accountHealth.mu.Lock()
+	accountHealth.signals = pruneAccountSignals(accountHealth.signals, now)
+	health := summarizeAccountSignals(accountHealth.signals, now)
+	if now.Before(accountHealth.banEnds) {
+		health.TemporaryBan, health.TemporaryBanUntil = accountHealth.banText, accountHealth.banEnds
+	}
+	accountHealth.mu.Unlock()
+
+	health.score()
+	return health
+}
+
+func summarizeAccountSignals(signals []accountHealthSignal, now time.Time) AccountHealth {
+	var health AccountHealth
+	for _, signal := range signals {
+		switch signal.kind {
+		case signalSendOK:
+			health.SendsSucceeded++
+		case signalSendFailed:
+			health.SendsFailed++
+			if now.Sub(signal.at) < failureSpikeWindow {
+				health.RecentFailures++
+			}
+		case signalRateLimited:
+			health.RateLimited++
+		case signalDisconnect:
+			health.Disconnects++
+		}
+	}
+	return health
+}
+
+// score computes Score, Level and Advisories from the counted signals.
+func (health *AccountHealth) score() {
+	health.Score, health.Advisories = 100, nil
+	if health.TemporaryBan != "" {
+		health.Score = 0
+		health.Advisories = append(health.Advisories, fmt.Sprintf(
+			"WhatsApp temporarily banned the account until %s (%s); stop sending until it lifts",
+			health.TemporaryBanUntil.UTC().Format(time.RFC3339), health.TemporaryBan,
+		))
+	}
+
+	if sends := health.SendsSucceeded + health.SendsFailed; sends >= minSendsForFailureRate {
+		rate := float64(health.SendsFailed) / float64(sends)
+		health.Score -= int(rate * 40)
+		if rate >= 0.2 {
+			health.Advisories = append(health.Advisories, fmt.Sprintf(
+				"%.0f%% of %d sends failed in the last hour; check the recipient list and slow down", rate*100, sends,
+			))
+		}
+	}
+	if health.RecentFailures >= failureSpikeCount {
+		health.Score -= 15
+		health.Advisories = append(health.Advisories, fmt.Sprintf(
+			"%d sends failed in the last ten minutes; pause bulk sends", health.RecentFailures,
+		))
+	}
+	if health.RateLimited > 0 {
+		health.Score -= min(health.RateLimited*10, 30)
+		health.Advisories = append(health.Advisories, fmt.Sprintf(
+			"WhatsApp rate limited %d sends in the last hour; switch to conservative pacing", health.RateLimited,
+		))
+	}
+	if health.Disconnects > 0 {
+		health.Score -= min(health.Disconnects*5, 20)
+		if health.Disconnects >= 3 {
+			health.Advisories = append(health.Advisories, fmt.Sprintf(
+				"WhatsApp dropped the connection %d times in the last hour", health.Disconnects,
+			))
+		}
+	}
+
+	health.Score = max(health.Score, 0)
+	switch {
+	case health.Score >= 80:
+		health.Level = AccountHealthOK
+	case health.Score >= 50:
+		health.Level = AccountHealthWarning
+	default:
+		health.Level = AccountHealthCritical
+	}
+}
@@ -0,0 +1,57 @@
+package whatsapp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarizeAccountSignals(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	signals := []accountHealthSignal{
+		{at: now.Add(-50 * time.Minute), kind: signalSendOK},
+		{at: now.Add(-40 * time.Minute), kind: signalSendFailed},
+		{at: now.Add(-5 * time.Minute), kind: signalSendFailed},
+		{at: now.Add(-5 * time.Minute), kind: signalRateLimited},
+		{at: now.Add(-time.Minute), kind: signalDisconnect},
+	}
+	health := summarizeAccountSignals(signals, now)
+	if health.SendsSucceeded != 1 || health.SendsFailed != 2 || health.RecentFailures != 1 || health.RateLimited != 1 || health.Disconnects != 1 {
+		t.Fatalf("unexpected counts: %+v", health)
+	}
+
+	pruned := pruneAccountSignals([]accountHealthSignal{{at: now.Add(-2 * time.Hour)}, {at: now.Add(-time.Minute)}}, now)
+	if len(pruned) != 1 {
+		t.Fatalf("expected signals older than the window to be dropped, got %d", len(pruned))
+	}
+}
+
+func TestAccountHealthScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		health     AccountHealth
+		level      string
+		advisories int
+	}{
+		{"quiet", AccountHealth{}, AccountHealthOK, 0},
+		{"few failures", AccountHealth{SendsSucceeded: 1, SendsFailed: 2}, AccountHealthOK, 0},
+		{"occasional disconnect", AccountHealth{SendsSucceeded: 50, SendsFailed: 1, Disconnects: 1}, AccountHealthOK, 0},
+		{"failure rate", AccountHealth{SendsSucceeded: 6, SendsFailed: 4}, AccountHealthOK, 1},
+		{"rate limited", AccountHealth{SendsSucceeded: 20, SendsFailed: 3, RateLimited: 3}, AccountHealthWarning, 1},
+		{"failure spike", AccountHealth{SendsSucceeded: 5, SendsFailed: 15, RecentFailures: 12, RateLimited: 2, Disconnects: 4}, AccountHealthCritical, 4},
+		{"temporary ban", AccountHealth{TemporaryBan: "sending too many messages", TemporaryBanUntil: time.Now().Add(time.Hour)}, AccountHealthCritical, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := tt.health
+			health.score()
+			if health.Level != tt.level || len(health.Advisories) != tt.advisories {
+				t.Fatalf("score %d level %s advisories %q, want level %s with %d advisories",
+					health.Score, health.Level, strings.Join(health.Advisories, "; "), tt.level, tt.advisories)
+			}
+			if health.Score < 0 || health.Score > 100 {
+				t.Fatalf("score %d out of range", health.Score)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package whatsapp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// audioDurationSeconds reads the duration of non-Opus audio from its
+// container: the mvhd box of MP4/M4A files or the frames of MP3 files.
+func audioDurationSeconds(mimeType string, data []byte) (uint32, error) {
+	var seconds float64
+	var err error
+	switch {
+	case strings.HasPrefix(mimeType, "audio/mp4"):
+		seconds, err = mp4DurationSeconds(data)
+	case strings.HasPrefix(mimeType, "audio/mpeg"):
+		seconds, err = mp3DurationSeconds(data)
+	default:
+		return 0, fmt.Errorf("unsupported audio type %q", mimeType)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uint32(math.Ceil(seconds)), nil
+}
+
+// mp4DurationSeconds finds moov/mvhd and divides its duration by its
+// timescale.
+func mp4DurationSeconds(data []byte) (float64, error) {
+	moov, ok := findMP4Box(data, "moov")
+	if !ok {
+		return 0, fmt.Errorf("no moov box in MP4 file")
+	}
+	mvhd, ok := findMP4Box(moov, "mvhd")
+	if !ok || len(mvhd) < 4 {
+		return 0, fmt.Errorf("no mvhd box in MP4 file")
+	}
+
+	var timescale uint32
+	var duration uint64
+	switch version := mvhd[0]; version {
+	case 0:
+		if len(mvhd) < 20 {
+			return 0, fmt.Errorf("truncated mvhd box")
+		}
+		timescale = binary.BigEndian.Uint32(mvhd[12:16])
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	case 1:
+		if len(mvhd) < 32 {
+			return 0, fmt.Errorf("truncated mvhd box")
+		}
+		timescale = binary.BigEndian.Uint32(mvhd[20:24])
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	default:
+		return 0, fmt.Errorf("unknown mvhd version %d", version)
+	}
+	if timescale == 0 {
+		return 0, fmt.Errorf("mvhd timescale is zero")
+	}
+	return float64(duration) / float64(timescale), nil
+}
+
+// findMP4Box returns the payload of the first box of the given type among
+// the boxes laid out in data.
+func findMP4Box(data []byte, boxType string) ([]byte, bool) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		header := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, false
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return nil, false
+		}
+		if string(data[4:8]) == boxType {
+			return data[header:size], true
+		}
+		data = data[size:]
+	}
+	return nil, false
+}
+
+var (
+	// mp3Bitrates are in kbit/s, indexed by [MPEG-1 ? 0 : 1][layer-1][index].
+	mp3Bitrates = [2][3][16]int{
+		{
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		},
+		{
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		},
+	}
+	mp3SampleRates = [3]int{44100, 48000, 32000}
+)
+
+// mp3Frame describes one MPEG audio frame header.
+type mp3Frame struct {
+	length     int
+	samples    int
+	sampleRate int
+}
+
+// parseMP3FrameHeader decodes a 4-byte MPEG audio frame header.
+func parseMP3FrameHeader(header []byte) (mp3Frame, bool) {
+	if len(header) < 4 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+	versionBits := (header[1] >> 3) & 0x03 // 0: MPEG-2.5, 2: MPEG-2, 3: MPEG-1
+	layerBits := (header[1] >> 1) & 0x03   // 1: layer III, 2: layer II, 3: layer I
+	bitrateIndex := header[2] >> 4
+	sampleRateIndex := (header[2] >> 2) & 0x03
+	padding := int((header[2] >> 1) & 0x01)
+	if versionBits == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	layer := int(4 - layerBits)
+	mpeg1 := versionBits == 3
+	table := 1
+	if mpeg1 {
+		table = 0
+	}
+	bitrate := mp3Bitrates[table][layer-1][bitrateIndex] * 1000
+	sampleRate := mp3SampleRates[sampleRateIndex]
+	switch versionBits {
+	case 2:
+		sampleRate /= 2
+	case 0:
+		sampleRate /= 4
+	}
+
+	frame := mp3Frame{sampleRate: sampleRate}
+	switch {
+	case layer == 1:
+		frame.samples = 384
+		frame.length = (12*bitrate/sampleRate + padding) * 4
+	case layer == 3 && !mpeg1:
+		frame.samples = 576
+		frame.length = 72*bitrate/sampleRate + padding
+	default:
+		frame.samples = 1152
+		frame.length = 144*bitrate/sampleRate + padding
+	}
+	return frame, frame.length > 4
+}
+
+// mp3DurationSeconds skips any ID3v2 tag and sums the samples of every
+// frame, which is exact for both constant and variable bitrate files.
+func mp3DurationSeconds(data []byte) (float64, error) {
+	offset := 0
+	if len(data) >= 10 && string(data[0:3]) == "ID3" {
+		// The tag size is a 28-bit synchsafe integer after a 10-byte header.
+		size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+		offset = 10 + size
+		if data[5]&0x10 != 0 {
+			offset += 10 // footer
+		}
+	}
+
+	var seconds float64
+	frames := 0
+	for offset+4 <= len(data) {
+		frame, ok := parseMP3FrameHeader(data[offset:])
+		if !ok {
+			if frames > 0 {
+				// Trailing tags such as ID3v1 end the audio.
+				break
+			}
+			offset++
+			continue
+		}
+		seconds += float64(frame.samples) / float64(frame.sampleRate)
+		frames++
+		offset += frame.length
+	}
+	if frames == 0 {
+		return 0, fmt.Errorf("no MPEG audio frames found")
+	}
+	return seconds, nil
+}
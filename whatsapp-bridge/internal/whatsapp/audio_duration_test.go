@@ -0,0 +1,57 @@
+package whatsapp
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func mp4Box(boxType string, payload []byte) []byte {
+	box := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(box[0:4], uint32(8+len(payload)))
+	copy(box[4:8], boxType)
+	return append(box, payload...)
+}
+
+func TestAudioDurationSecondsReadsMP4Header(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 1000)  // timescale
+	binary.BigEndian.PutUint32(mvhd[16:20], 12345) // duration
+	data := append(mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")), mp4Box("moov", mp4Box("mvhd", mvhd))...)
+
+	seconds, err := audioDurationSeconds("audio/mp4", data)
+	if err != nil {
+		t.Fatalf("audioDurationSeconds: %v", err)
+	}
+	if seconds != 13 {
+		t.Fatalf("expected 13 seconds, got %d", seconds)
+	}
+}
+
+func TestAudioDurationSecondsCountsMP3Frames(t *testing.T) {
+	// A 10-byte ID3v2 tag with a 20-byte body, then 200 MPEG-1 layer III
+	// frames at 128 kbit/s and 44.1 kHz: 417 bytes and 1152 samples each.
+	data := append([]byte("ID3\x04\x00\x00\x00\x00\x00\x14"), make([]byte, 20)...)
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	for i := 0; i < 200; i++ {
+		data = append(data, frame...)
+	}
+	data = append(data, []byte("TAG")...)
+
+	seconds, err := audioDurationSeconds("audio/mpeg", data)
+	if err != nil {
+		t.Fatalf("audioDurationSeconds: %v", err)
+	}
+	if seconds != 6 { // 200 * 1152 / 44100 = 5.22s
+		t.Fatalf("expected 6 seconds, got %d", seconds)
+	}
+}
+
+func TestAudioDurationSecondsRejectsUnknownData(t *testing.T) {
+	if _, err := audioDurationSeconds("audio/mpeg", []byte("not audio")); err == nil {
+		t.Fatal("expected an error for data without MPEG frames")
+	}
+	if _, err := audioDurationSeconds("audio/mp4", mp4Box("ftyp", nil)); err == nil {
+		t.Fatal("expected an error for MP4 data without moov")
+	}
+}
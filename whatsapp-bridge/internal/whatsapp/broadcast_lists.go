@@ -0,0 +1,159 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"google.golang.org/protobuf/proto"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+// broadcastParticipantJIDs returns the recipient JIDs listed in a history
+// sync conversation, if WhatsApp included any.
+func broadcastParticipantJIDs(participants []string) []string {
+	var recipients []string
+	for _, participant := range participants {
+		if participant = strings.TrimSpace(participant); participant != "" && jid.IsPersonal(participant) {
+			recipients = append(recipients, participant)
+		}
+	}
+	return recipients
+}
+
+// syncBroadcastRecipients stores the recipients a history sync reported for a
+// broadcast list. Conversations without participants leave the stored list
+// untouched, since WhatsApp often omits them.
+func syncBroadcastRecipients(store *storage.MessageStore, logger waLog.Logger, listID string, participants []*waHistorySync.GroupParticipant) {
+	raw := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		raw = append(raw, participant.GetUserJID())
+	}
+	recipients := broadcastParticipantJIDs(raw)
+	if len(recipients) == 0 {
+		return
+	}
+	if err := store.ReplaceBroadcastRecipients(listID, recipients); err != nil {
+		logger.Warnf("Failed to store broadcast list recipients (chat_ref=%s): %v", obfuscatedChatRef(listID), err)
+	}
+}
+
+// broadcastSendSummary reports the outcome of fanning a message out to the
+// recipients of a broadcast list.
+func broadcastSendSummary(listJID string, sent int, failures []string) (string, error) {
+	total := sent + len(failures)
+	if sent == 0 {
+		return "", fmt.Errorf("Failed to send to broadcast list %s: %s", listJID, strings.Join(failures, "; "))
+	}
+	summary := fmt.Sprintf("Message sent to %d of %d recipients of broadcast list %s", sent, total, listJID)
+	if len(failures) > 0 {
+		summary += ": " + strings.Join(failures, "; ")
+	}
+	return summary, nil
+}
+
+// sendToBroadcastList delivers msg to each stored recipient of a broadcast
+// list as an individual chat message, the way the phone delivers broadcasts.
+// whatsmeow cannot send to broadcast lists directly, so recipients must be
+// known; each recipient's own chat policy and opt-out still apply.
+func sendToBroadcastList(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore, listJID types.JID, msg *waProto.Message, opts SendOptions) (SendResult, error) {
+	if messageStore == nil {
+		return SendResult{}, fmt.Errorf("Message store is not initialized, cannot resolve broadcast list recipients")
+	}
+	listID := listJID.ToNonAD().String()
+	recipients, err := messageStore.GetBroadcastRecipients(listID)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("Error loading broadcast list recipients: %v", err)
+	}
+	if len(recipients) == 0 {
+		return SendResult{}, fmt.Errorf("No recipients are known for broadcast list %s", listID)
+	}
+
+	var result SendResult
+	var failures []string
+	for _, outcome := range fanOut(ctx, client, messageStore, recipients, msg, opts) {
+		if outcome.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", outcome.Recipient, outcome.Error))
+			continue
+		}
+		result.MessageIDs = append(result.MessageIDs, outcome.MessageID)
+	}
+	summary, err := broadcastSendSummary(listID, len(result.MessageIDs), failures)
+	if err != nil {
+		return SendResult{}, err
+	}
+	result.Summary = summary
+	return result, nil
+}
+
+// RecipientResult is the outcome of sending to one recipient of a fan-out:
+// the sent message's ID, or why it was not sent.
+type RecipientResult struct {
+	Recipient string
+	MessageID string
+	Error     string
+}
+
+// fanOut sends a copy of msg to each recipient as an individual chat message,
+// applying each recipient's chat policy and, unless overridden, opt-out.
+// Copies are spaced out by opts.Pacing.
+func fanOut(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore, recipients []string, msg *waProto.Message, opts SendOptions) []RecipientResult {
+	results := make([]RecipientResult, 0, len(recipients))
+	pace := newPacer(opts.Pacing)
+	for _, recipient := range recipients {
+		outcome := RecipientResult{Recipient: recipient}
+		if messageID, err := sendFanOutCopy(ctx, client, messageStore, recipient, msg, opts, pace); err != nil {
+			outcome.Error = err.Error()
+		} else {
+			outcome.MessageID = messageID
+		}
+		results = append(results, outcome)
+	}
+	return results
+}
+
+func sendFanOutCopy(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore, recipient string, msg *waProto.Message, opts SendOptions, pace *pacer) (string, error) {
+	recipientJID, err := types.ParseJID(recipient)
+	if err != nil {
+		return "", err
+	}
+	if err := checkChatSendPolicy(client, messageStore, recipientJID); err != nil {
+		return "", err
+	}
+	if !opts.OverrideOptOut {
+		if err := checkOptOut(client, messageStore, recipientJID); err != nil {
+			return "", err
+		}
+	}
+	if err := pace.wait(ctx, client, recipientJID, msg); err != nil {
+		return "", err
+	}
+	resp, err := client.SendMessage(ctx, recipientJID, proto.Clone(msg).(*waProto.Message))
+	recordSendOutcome(err)
+	if err != nil {
+		operationLogger(ctx, client).Warnf("Broadcast send to %s failed: %v", obfuscatedChatRef(recipient), err)
+		return "", err
+	}
+	recordSentMessage(client, messageStore, recipientJID, resp)
+	return resp.ID, nil
+}
+
+// SendToRecipients delivers one text or media message to each recipient as an
+// individual chat message, uploading any media once. It fails only when
+// nothing could be attempted; per-recipient failures are in the results.
+func SendToRecipients(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore, recipients []string, message string, mediaPath string, opts SendOptions) ([]RecipientResult, error) {
+	if !client.IsConnected() {
+		return nil, notConnectedError()
+	}
+	msg, err := buildOutgoingMessage(ctx, client, message, mediaPath, opts.GIF)
+	if err != nil {
+		return nil, err
+	}
+	return fanOut(ctx, client, messageStore, recipients, msg, opts), nil
+}
@@ -0,0 +1,24 @@
+package whatsapp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBroadcastParticipantJIDs(t *testing.T) {
+	got := broadcastParticipantJIDs([]string{" 15551234567@s.whatsapp.net ", "", "120363000000000000@g.us", "123456789012345@lid"})
+	want := []string{"15551234567@s.whatsapp.net", "123456789012345@lid"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("broadcastParticipantJIDs() = %v, want %v", got, want)
+	}
+}
+
+func TestBroadcastSendSummary(t *testing.T) {
+	summary, err := broadcastSendSummary("1700000000@broadcast", 2, []string{"155@s.whatsapp.net: chat is read-only"})
+	if err != nil || !strings.Contains(summary, "2 of 3") || !strings.Contains(summary, "read-only") {
+		t.Fatalf("partial send = %q, %v", summary, err)
+	}
+	if _, err = broadcastSendSummary("1700000000@broadcast", 0, []string{"155@s.whatsapp.net: send_timeout"}); err == nil || !strings.Contains(err.Error(), "Failed") {
+		t.Fatalf("failed send error = %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/storage"
+)
+
+var (
+	// ErrChatReadOnly rejects sends to chats marked observe-only.
+	ErrChatReadOnly = errors.New("chat is read-only, sending is disabled")
+	// ErrChatHandoff rejects sends while a person has taken over the chat.
+	ErrChatHandoff = errors.New("chat is in human handoff, automated sending is paused")
+	// ErrRecipientOptedOut rejects sends to contacts who opted out, unless
+	// the send overrides it.
+	ErrRecipientOptedOut = errors.New("recipient opted out of messages")
+)
+
+// checkChatSendPolicy refuses sends to read-only chats and chats handed off
+// to a person. Lookup failures also refuse the send so a broken store cannot
+// lift the restriction.
+func checkChatSendPolicy(client *whatsmeow.Client, messageStore *storage.MessageStore, recipientJID types.JID) error {
+	if messageStore == nil {
+		return nil
+	}
+	settings, err := messageStore.GetChatSettings(canonicalizeChatID(client, recipientJID))
+	if err != nil {
+		return fmt.Errorf("error checking chat policy: %w", err)
+	}
+	if settings.ReadOnly {
+		return ErrChatReadOnly
+	}
+	if settings.HumanHandoff {
+		return ErrChatHandoff
+	}
+	return nil
+}
+
+// CheckChatSendPolicy reports whether recipient may be sent to, returning
+// ErrChatReadOnly or ErrChatHandoff when the chat's settings forbid it.
+func CheckChatSendPolicy(client *whatsmeow.Client, messageStore *storage.MessageStore, recipient string) error {
+	recipientJID, err := resolveRecipientJID(client, messageStore, recipient)
+	if err != nil {
+		return err
+	}
+	return checkChatSendPolicy(client, messageStore, recipientJID)
+}
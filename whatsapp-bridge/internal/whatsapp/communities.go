@@ -0,0 +1,78 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/storage"
+)
+
+const communitySyncTimeout = 30 * time.Second
+
+// communityGroupsFromInfo keeps the joined groups that belong to a community:
+// community parents and groups linked to one.
+func communityGroupsFromInfo(groups []*types.GroupInfo) []storage.CommunityGroup {
+	var community []storage.CommunityGroup
+	for _, info := range groups {
+		if info == nil || (!info.IsParent && info.LinkedParentJID.IsEmpty()) {
+			continue
+		}
+		entry := storage.CommunityGroup{
+			GroupJID:       info.JID.ToNonAD().String(),
+			Name:           info.Name,
+			IsCommunity:    info.IsParent,
+			IsAnnouncement: info.IsDefaultSubGroup,
+		}
+		if !info.LinkedParentJID.IsEmpty() {
+			entry.ParentJID = info.LinkedParentJID.ToNonAD().String()
+		}
+		community = append(community, entry)
+	}
+	return community
+}
+
+// SyncCommunities refreshes the stored community structure from the account's
+// joined groups.
+func SyncCommunities(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore) error {
+	if client == nil || !client.IsConnected() {
+		return fmt.Errorf("Not connected to WhatsApp")
+	}
+	if messageStore == nil {
+		return fmt.Errorf("message store is not initialized")
+	}
+	groups, err := client.GetJoinedGroups(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching joined groups: %w", err)
+	}
+	return messageStore.ReplaceCommunityGroups(communityGroupsFromInfo(groups))
+}
+
+// syncCommunitiesInBackground refreshes communities without blocking event handling.
+func syncCommunitiesInBackground(client *whatsmeow.Client, messageStore *storage.MessageStore) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), communitySyncTimeout)
+		defer cancel()
+		if err := SyncCommunities(ctx, client, messageStore); err != nil {
+			fmt.Printf("Warning: failed to sync communities: %v\n", err)
+		}
+	}()
+}
+
+// resolveCommunityRecipient maps a community parent, which cannot receive
+// messages, to its announcement group.
+func resolveCommunityRecipient(messageStore *storage.MessageStore, recipientJID types.JID) (types.JID, error) {
+	if messageStore == nil || recipientJID.Server != types.GroupServer {
+		return recipientJID, nil
+	}
+	announcement, err := messageStore.CommunityAnnouncementJID(recipientJID.String())
+	if err != nil {
+		return types.JID{}, fmt.Errorf("error resolving community announcement group: %w", err)
+	}
+	if announcement == "" {
+		return recipientJID, nil
+	}
+	return types.ParseJID(announcement)
+}
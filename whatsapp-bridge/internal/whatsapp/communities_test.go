@@ -0,0 +1,32 @@
+package whatsapp
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/storage"
+)
+
+func TestCommunityGroupsFromInfoKeepsCommunityMembersOnly(t *testing.T) {
+	parent := types.NewJID("100", types.GroupServer)
+	groups := []*types.GroupInfo{
+		{JID: parent, GroupName: types.GroupName{Name: "Neighbours"}, GroupParent: types.GroupParent{IsParent: true}},
+		{
+			JID:               types.NewJID("101", types.GroupServer),
+			GroupName:         types.GroupName{Name: "Announcements"},
+			GroupLinkedParent: types.GroupLinkedParent{LinkedParentJID: parent},
+			GroupIsDefaultSub: types.GroupIsDefaultSub{IsDefaultSubGroup: true},
+		},
+		{JID: types.NewJID("102", types.GroupServer), GroupName: types.GroupName{Name: "Standalone"}},
+		nil,
+	}
+
+	want := []storage.CommunityGroup{
+		{GroupJID: "100@g.us", Name: "Neighbours", IsCommunity: true},
+		{GroupJID: "101@g.us", ParentJID: "100@g.us", Name: "Announcements", IsAnnouncement: true},
+	}
+	if got := communityGroupsFromInfo(groups); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected community groups: %+v", got)
+	}
+}
@@ -0,0 +1,196 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	defaultConnectionProbeInterval = time.Minute
+	defaultReconnectMissed         = 3
+	defaultReconnectMaxRTT         = 10 * time.Second
+	connectionProbeTimeout         = 15 * time.Second
+	// reconnectCooldown spaces proactive reconnects so a flaky network is
+	// not hammered with new sockets.
+	reconnectCooldown = 5 * time.Minute
+	// rttSmoothing weighs each new probe in the moving average RTT.
+	rttSmoothing = 0.3
+)
+
+// ConnectionQuality summarizes the health of the WhatsApp websocket: the
+// round trip of the bridge's own probes and the keepalives whatsmeow sends.
+type ConnectionQuality struct {
+	// LastRTT is the round trip of the latest successful probe and
+	// AverageRTT a moving average over recent probes.
+	LastRTT     time.Duration
+	AverageRTT  time.Duration
+	LastProbeAt time.Time
+	// FailedProbes counts consecutive probes without an answer.
+	FailedProbes int
+	// LastKeepaliveAt is the last time a keepalive was known to succeed.
+	LastKeepaliveAt time.Time
+	// MissedKeepalives counts consecutive keepalives without an answer, and
+	// TotalMissedKeepalives every one since start.
+	MissedKeepalives      int
+	TotalMissedKeepalives int
+	// Reconnects counts proactive reconnects and LastReconnectAt is the
+	// latest one.
+	Reconnects      int
+	LastReconnectAt time.Time
+}
+
+// ConnectionMonitorConfig says how often to probe the connection and when
+// it has degraded enough to reconnect.
+type ConnectionMonitorConfig struct {
+	ProbeInterval time.Duration
+	// ReconnectMissed reconnects after this many consecutive missed
+	// keepalives or failed probes; zero never does.
+	ReconnectMissed int
+	// ReconnectMaxRTT reconnects when the average probe RTT exceeds it; zero
+	// never does.
+	ReconnectMaxRTT time.Duration
+}
+
+// ConnectionMonitorConfigFromEnv reads WHATSAPP_CONNECTION_PROBE_SECONDS (0
+// turns probing off), WHATSAPP_RECONNECT_MISSED_KEEPALIVES and
+// WHATSAPP_RECONNECT_MAX_RTT_MS.
+func ConnectionMonitorConfigFromEnv() ConnectionMonitorConfig {
+	return ConnectionMonitorConfig{
+		ProbeInterval:   time.Duration(connectionEnvInt("WHATSAPP_CONNECTION_PROBE_SECONDS", int(defaultConnectionProbeInterval/time.Second))) * time.Second,
+		ReconnectMissed: connectionEnvInt("WHATSAPP_RECONNECT_MISSED_KEEPALIVES", defaultReconnectMissed),
+		ReconnectMaxRTT: time.Duration(connectionEnvInt("WHATSAPP_RECONNECT_MAX_RTT_MS", int(defaultReconnectMaxRTT/time.Millisecond))) * time.Millisecond,
+	}
+}
+
+func connectionEnvInt(name string, fallback int) int {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		fmt.Printf("Warning: invalid %s=%q, using %d\n", name, raw, fallback)
+		return fallback
+	}
+	return value
+}
+
+var connectionQuality = struct {
+	mu      sync.Mutex
+	quality ConnectionQuality
+}{}
+
+// GetConnectionQuality returns the current connection quality.
+func GetConnectionQuality() ConnectionQuality {
+	connectionQuality.mu.Lock()
+	defer connectionQuality.mu.Unlock()
+	return connectionQuality.quality
+}
+
+func updateConnectionQuality(update func(*ConnectionQuality)) {
+	connectionQuality.mu.Lock()
+	defer connectionQuality.mu.Unlock()
+	update(&connectionQuality.quality)
+}
+
+// handleKeepAliveTimeout records a keepalive whatsmeow sent without answer.
+func handleKeepAliveTimeout(evt *events.KeepAliveTimeout) {
+	updateConnectionQuality(func(quality *ConnectionQuality) {
+		quality.MissedKeepalives = evt.ErrorCount
+		quality.TotalMissedKeepalives++
+		if evt.LastSuccess.After(quality.LastKeepaliveAt) {
+			quality.LastKeepaliveAt = evt.LastSuccess
+		}
+	})
+}
+
+// handleKeepAliveRestored records keepalives being answered again.
+func handleKeepAliveRestored(now time.Time) {
+	updateConnectionQuality(func(quality *ConnectionQuality) {
+		quality.MissedKeepalives = 0
+		quality.LastKeepaliveAt = now
+	})
+}
+
+// handleConnectionEstablished starts the counters afresh on a new socket.
+func handleConnectionEstablished(now time.Time) {
+	updateConnectionQuality(func(quality *ConnectionQuality) {
+		quality.MissedKeepalives = 0
+		quality.FailedProbes = 0
+		quality.LastKeepaliveAt = now
+	})
+}
+
+// recordProbe adds the outcome of one probe.
+func (quality *ConnectionQuality) recordProbe(rtt time.Duration, err error, now time.Time) {
+	quality.LastProbeAt = now
+	if err != nil {
+		quality.FailedProbes++
+		return
+	}
+	quality.FailedProbes = 0
+	quality.LastRTT = rtt
+	// A probe answered proves the socket alive, as a keepalive would.
+	quality.LastKeepaliveAt = now
+	if quality.AverageRTT == 0 {
+		quality.AverageRTT = rtt
+	} else {
+		quality.AverageRTT = time.Duration(rttSmoothing*float64(rtt) + (1-rttSmoothing)*float64(quality.AverageRTT))
+	}
+}
+
+// degraded returns why the connection should be re-established, or "" while
+// it is healthy.
+func (quality ConnectionQuality) degraded(cfg ConnectionMonitorConfig) string {
+	switch {
+	case cfg.ReconnectMissed > 0 && quality.MissedKeepalives >= cfg.ReconnectMissed:
+		return fmt.Sprintf("%d keepalives missed", quality.MissedKeepalives)
+	case cfg.ReconnectMissed > 0 && quality.FailedProbes >= cfg.ReconnectMissed:
+		return fmt.Sprintf("%d probes failed", quality.FailedProbes)
+	case cfg.ReconnectMaxRTT > 0 && quality.AverageRTT > cfg.ReconnectMaxRTT:
+		return fmt.Sprintf("average round trip %s", quality.AverageRTT.Round(time.Millisecond))
+	default:
+		return ""
+	}
+}
+
+// ProbeConnection measures a round trip to WhatsApp with a small query and
+// returns whether the connection has degraded enough to re-establish, and
+// why. Reconnects are held back for a cooldown after the previous one.
+func ProbeConnection(ctx context.Context, client *whatsmeow.Client, cfg ConnectionMonitorConfig) (string, bool) {
+	if client == nil || !client.IsConnected() || client.Store == nil || client.Store.ID == nil {
+		return "", false
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, connectionProbeTimeout)
+	defer cancel()
+	started := time.Now()
+	_, err := client.GetBlocklist(probeCtx)
+	rtt := time.Since(started)
+	if ctx.Err() != nil {
+		return "", false
+	}
+
+	now := time.Now()
+	var reason string
+	updateConnectionQuality(func(quality *ConnectionQuality) {
+		quality.recordProbe(rtt, err, now)
+		reason = quality.degraded(cfg)
+		if reason != "" && now.Sub(quality.LastReconnectAt) < reconnectCooldown {
+			reason = ""
+		}
+		if reason != "" {
+			quality.Reconnects++
+			quality.LastReconnectAt = now
+			quality.AverageRTT = 0
+		}
+	})
+	return reason, reason != ""
+}
@@ -0,0 +1,54 @@
+package whatsapp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConnectionQualityRecordProbe(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var quality ConnectionQuality
+
+	quality.recordProbe(100*time.Millisecond, nil, now)
+	if quality.AverageRTT != 100*time.Millisecond || !quality.LastKeepaliveAt.Equal(now) {
+		t.Fatalf("unexpected quality after first probe: %+v", quality)
+	}
+	quality.recordProbe(200*time.Millisecond, nil, now)
+	if quality.AverageRTT != 130*time.Millisecond || quality.LastRTT != 200*time.Millisecond {
+		t.Fatalf("expected a smoothed average of 130ms, got %+v", quality)
+	}
+
+	quality.recordProbe(0, errors.New("timeout"), now.Add(time.Minute))
+	quality.recordProbe(0, errors.New("timeout"), now.Add(2*time.Minute))
+	if quality.FailedProbes != 2 || quality.LastRTT != 200*time.Millisecond {
+		t.Fatalf("expected failed probes to be counted without touching RTT, got %+v", quality)
+	}
+	quality.recordProbe(50*time.Millisecond, nil, now.Add(3*time.Minute))
+	if quality.FailedProbes != 0 {
+		t.Fatalf("expected a successful probe to reset failures, got %d", quality.FailedProbes)
+	}
+}
+
+func TestConnectionQualityDegraded(t *testing.T) {
+	cfg := ConnectionMonitorConfig{ReconnectMissed: 3, ReconnectMaxRTT: time.Second}
+	tests := []struct {
+		name     string
+		quality  ConnectionQuality
+		cfg      ConnectionMonitorConfig
+		degraded bool
+	}{
+		{"healthy", ConnectionQuality{MissedKeepalives: 2, FailedProbes: 2, AverageRTT: 500 * time.Millisecond}, cfg, false},
+		{"missed keepalives", ConnectionQuality{MissedKeepalives: 3}, cfg, true},
+		{"failed probes", ConnectionQuality{FailedProbes: 4}, cfg, true},
+		{"slow", ConnectionQuality{AverageRTT: 2 * time.Second}, cfg, true},
+		{"checks disabled", ConnectionQuality{MissedKeepalives: 9, AverageRTT: time.Minute}, ConnectionMonitorConfig{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reason := tt.quality.degraded(tt.cfg); (reason != "") != tt.degraded {
+				t.Fatalf("degraded = %q, want degraded %v", reason, tt.degraded)
+			}
+		})
+	}
+}
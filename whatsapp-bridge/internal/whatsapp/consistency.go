@@ -0,0 +1,91 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/storage"
+)
+
+// ConsistencyReport lists disagreements between the device store
+// (whatsapp.db) and the message store (messages.db).
+type ConsistencyReport struct {
+	// OrphanedChats are chats without messages whose ID was merged into
+	// another identity.
+	OrphanedChats []string
+	// MessagesWithoutChats are chat IDs with messages but no chat row.
+	MessagesWithoutChats []storage.MissingChat
+	// MappableLIDs are LIDs the message store has not paired with a phone
+	// number although the device store's LID map has one.
+	MappableLIDs []string
+	// UnmappedLIDs are LIDs neither store can pair with a phone number.
+	UnmappedLIDs []string
+	// Repaired counts what a repair run fixed.
+	Repaired ConsistencyRepairs
+}
+
+// ConsistencyRepairs counts the fixes of a repair run.
+type ConsistencyRepairs struct {
+	ChatsDeleted int
+	ChatsCreated int
+	LIDsPaired   int
+}
+
+// CheckConsistency cross-checks the message store against the device store's
+// LID map. With repair set it also deletes orphaned chats, recreates missing
+// chat rows and pairs LIDs the device store can map; the report then lists
+// what was found before repairing.
+func CheckConsistency(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore, repair bool) (ConsistencyReport, error) {
+	var report ConsistencyReport
+	if messageStore == nil {
+		return report, fmt.Errorf("message store is not initialized")
+	}
+	var err error
+	if report.OrphanedChats, err = messageStore.FindOrphanedChats(); err != nil {
+		return report, fmt.Errorf("failed to find orphaned chats: %v", err)
+	}
+	if report.MessagesWithoutChats, err = messageStore.FindMessagesWithoutChats(); err != nil {
+		return report, fmt.Errorf("failed to find messages without chats: %v", err)
+	}
+	lids, err := messageStore.FindUnpairedLIDs()
+	if err != nil {
+		return report, fmt.Errorf("failed to find unpaired LIDs: %v", err)
+	}
+	pnForLID, _ := lidMappers(client)
+	for _, lid := range lids {
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		if _, ok := pnForLID(types.NewJID(lid, types.HiddenUserServer)); ok {
+			report.MappableLIDs = append(report.MappableLIDs, lid)
+		} else {
+			report.UnmappedLIDs = append(report.UnmappedLIDs, lid)
+		}
+	}
+	if !repair {
+		return report, nil
+	}
+
+	if report.Repaired.ChatsCreated, err = messageStore.RecreateMissingChats(); err != nil {
+		return report, fmt.Errorf("failed to recreate missing chats: %v", err)
+	}
+	if report.Repaired.ChatsDeleted, err = messageStore.DeleteOrphanedChats(); err != nil {
+		return report, fmt.Errorf("failed to delete orphaned chats: %v", err)
+	}
+	for _, lid := range report.MappableLIDs {
+		lidJID := types.NewJID(lid, types.HiddenUserServer)
+		canonical := canonicalizeSender(client, lidJID, types.JID{})
+		aliases := senderAliasIDs(client, lidJID, types.JID{}, canonical)
+		if _, err := messageStore.StoreSenderAliases(canonical, aliases, time.Now()); err != nil {
+			return report, fmt.Errorf("failed to store aliases of %s: %v", lid, err)
+		}
+		if err := messageStore.PromoteCanonicalSender(canonical, aliases); err != nil {
+			return report, fmt.Errorf("failed to promote aliases of %s: %v", lid, err)
+		}
+		report.Repaired.LIDsPaired++
+	}
+	return report, nil
+}
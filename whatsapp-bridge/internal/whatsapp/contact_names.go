@@ -0,0 +1,153 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+const defaultChatNameRetry = 15 * time.Minute
+
+// nameMissCache remembers chats whose name lookup recently found nothing, so
+// every message in an unnamed chat doesn't repeat the lookup.
+type nameMissCache struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	now    func() time.Time
+	misses map[string]time.Time
+}
+
+func newNameMissCache(ttl time.Duration) *nameMissCache {
+	return &nameMissCache{ttl: ttl, now: time.Now, misses: make(map[string]time.Time)}
+}
+
+// missed reports whether a lookup for chatID failed within the TTL.
+func (cache *nameMissCache) missed(chatID string) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	at, ok := cache.misses[chatID]
+	if !ok {
+		return false
+	}
+	if cache.now().Sub(at) >= cache.ttl {
+		delete(cache.misses, chatID)
+		return false
+	}
+	return true
+}
+
+func (cache *nameMissCache) record(chatID string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.misses[chatID] = cache.now()
+}
+
+func (cache *nameMissCache) forget(chatID string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	delete(cache.misses, chatID)
+}
+
+// reset drops every miss, so the next message in each chat retries.
+func (cache *nameMissCache) reset() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.misses = make(map[string]time.Time)
+}
+
+var (
+	chatNameMissesOnce  sync.Once
+	chatNameMissesValue *nameMissCache
+)
+
+// chatNameMisses returns the process-wide miss cache, with its TTL read once
+// from WHATSAPP_CHAT_NAME_RETRY_SECONDS.
+func chatNameMisses() *nameMissCache {
+	chatNameMissesOnce.Do(func() {
+		ttl := defaultChatNameRetry
+		if raw := strings.TrimSpace(os.Getenv("WHATSAPP_CHAT_NAME_RETRY_SECONDS")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 0 {
+				fmt.Printf("Warning: invalid WHATSAPP_CHAT_NAME_RETRY_SECONDS=%q, using %d\n", raw, int(defaultChatNameRetry.Seconds()))
+			} else {
+				ttl = time.Duration(parsed) * time.Second
+			}
+		}
+		chatNameMissesValue = newNameMissCache(ttl)
+	})
+	return chatNameMissesValue
+}
+
+// isPlaceholderChatName reports whether name is one stored when no real name
+// was known, mirroring the storage package's placeholder check.
+func isPlaceholderChatName(chatID, name string) bool {
+	if name == "" || name == chatID {
+		return true
+	}
+	if user, _, ok := strings.Cut(chatID, "@"); ok && name == "Group "+user {
+		return true
+	}
+	return false
+}
+
+// contactDisplayName picks the best name the contact store has for someone.
+func contactDisplayName(contact types.ContactInfo) string {
+	for _, name := range []string{contact.FullName, contact.BusinessName, contact.PushName} {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// backfillChatName names a chat still stored under a placeholder once a name
+// for it turns up, for example from a push name or contact change.
+func backfillChatName(messageStore *storage.MessageStore, chatID, name string, logger waLog.Logger) {
+	if chatID == "" || name == "" {
+		return
+	}
+	updated, err := messageStore.UpdateChatNameIfPlaceholder(chatID, name)
+	if err != nil {
+		logger.Warnf("Failed to backfill chat name (chat_ref=%s): %v", obfuscatedChatRef(chatID), err)
+		return
+	}
+	if updated {
+		chatNameMisses().forget(chatID)
+		logger.Infof("Backfilled chat name: chat_ref=%s", obfuscatedChatRef(chatID))
+	}
+}
+
+// backfillChatNamesFromContacts retries every direct chat still under a
+// placeholder name against the contact store, after a contact sync filled it.
+func backfillChatNamesFromContacts(client *whatsmeow.Client, messageStore *storage.MessageStore, logger waLog.Logger) {
+	chatNameMisses().reset()
+	chatIDs, err := messageStore.ListPlaceholderChatJIDs(jid.ChatTypeDirect)
+	if err != nil {
+		logger.Warnf("Failed to list unnamed chats: %v", err)
+		return
+	}
+	for _, chatID := range chatIDs {
+		// Direct chats are stored under the bare user, which may be a phone
+		// number or a LID.
+		for _, server := range []string{types.DefaultUserServer, types.HiddenUserServer} {
+			contact, err := client.Store.Contacts.GetContact(context.Background(), types.NewJID(chatID, server))
+			if err != nil {
+				continue
+			}
+			if name := contactDisplayName(contact); name != "" {
+				backfillChatName(messageStore, chatID, name, logger)
+				break
+			}
+		}
+	}
+}
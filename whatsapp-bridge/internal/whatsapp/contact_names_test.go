@@ -0,0 +1,50 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsPlaceholderChatName(t *testing.T) {
+	cases := []struct {
+		chatID, name string
+		want         bool
+	}{
+		{"15551234567", "", true},
+		{"15551234567", "15551234567", true},
+		{"15551234567", "Alice", false},
+		{"120363000000000000@g.us", "Group 120363000000000000", true},
+		{"120363000000000000@g.us", "Book club", false},
+		{"15551234567", "Group 15551234567", false},
+	}
+	for _, tc := range cases {
+		if got := isPlaceholderChatName(tc.chatID, tc.name); got != tc.want {
+			t.Errorf("isPlaceholderChatName(%q, %q) = %v, want %v", tc.chatID, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNameMissCacheExpires(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newNameMissCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	if cache.missed("15551234567") {
+		t.Fatal("unrecorded chat reported as missed")
+	}
+	cache.record("15551234567")
+	now = now.Add(30 * time.Second)
+	if !cache.missed("15551234567") {
+		t.Fatal("miss forgotten before TTL")
+	}
+	now = now.Add(30 * time.Second)
+	if cache.missed("15551234567") {
+		t.Fatal("miss kept past TTL")
+	}
+
+	cache.record("15551234567")
+	cache.reset()
+	if cache.missed("15551234567") {
+		t.Fatal("miss kept after reset")
+	}
+}
@@ -0,0 +1,97 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"whatsapp-client/internal/storage"
+)
+
+// handleContactEvent stores a contact's saved address book name, which names
+// their chat over any push name. Contacts without a saved name only fill
+// chats still under a placeholder.
+func handleContactEvent(client *whatsmeow.Client, messageStore *storage.MessageStore, evt *events.Contact, logger waLog.Logger) {
+	if evt.Action == nil {
+		return
+	}
+	chatID := canonicalizeChatID(client, evt.JID)
+	fullName := evt.Action.GetFullName()
+	if fullName == "" {
+		backfillChatName(messageStore, chatID, evt.Action.GetFirstName(), logger)
+		return
+	}
+	renamed, err := messageStore.SaveContacts([]storage.SavedContact{{
+		UserID:    chatID,
+		FullName:  fullName,
+		FirstName: evt.Action.GetFirstName(),
+		UpdatedAt: evt.Timestamp,
+	}})
+	if err != nil {
+		logger.Warnf("Failed to store saved contact (chat_ref=%s): %v", obfuscatedChatRef(chatID), err)
+		return
+	}
+	chatNameMisses().forget(chatID)
+	if renamed > 0 {
+		logger.Infof("Named chat after saved contact: chat_ref=%s", obfuscatedChatRef(chatID))
+	}
+}
+
+// ImportSavedContacts copies every saved contact name the device's contact
+// store holds from app state into the message store, renaming their chats.
+// It returns how many contacts were imported and chats renamed.
+func ImportSavedContacts(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore) (int, int, error) {
+	if client == nil || client.Store == nil || client.Store.Contacts == nil {
+		return 0, 0, fmt.Errorf("WhatsApp client is not initialized")
+	}
+	if messageStore == nil {
+		return 0, 0, fmt.Errorf("Message store is not initialized")
+	}
+	all, err := client.Store.Contacts.GetAllContacts(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read contacts: %w", err)
+	}
+	now := time.Now()
+	contacts := savedContactsFrom(all, func(contactJID types.JID) string { return canonicalizeChatID(client, contactJID) }, now)
+	renamed, err := messageStore.SaveContacts(contacts)
+	if err != nil {
+		return 0, renamed, err
+	}
+	return len(contacts), renamed, nil
+}
+
+// savedContactsFrom picks the contacts with an address book name, keyed by
+// the chat ID chatID gives their JID. A person known under both a phone
+// number and a LID is imported once.
+func savedContactsFrom(all map[types.JID]types.ContactInfo, chatID func(types.JID) string, at time.Time) []storage.SavedContact {
+	seen := make(map[string]struct{}, len(all))
+	var contacts []storage.SavedContact
+	for contactJID, info := range all {
+		if info.FullName == "" || contactJID.Server == types.GroupServer {
+			continue
+		}
+		userID := chatID(contactJID)
+		if _, ok := seen[userID]; ok || userID == "" {
+			continue
+		}
+		seen[userID] = struct{}{}
+		contacts = append(contacts, storage.SavedContact{UserID: userID, FullName: info.FullName, FirstName: info.FirstName, UpdatedAt: at})
+	}
+	return contacts
+}
+
+// importSavedContactsAfterSync imports the address book once a contact
+// sync completed, then names any chats still unnamed from the contact store.
+func importSavedContactsAfterSync(client *whatsmeow.Client, messageStore *storage.MessageStore, logger waLog.Logger) {
+	imported, renamed, err := ImportSavedContacts(context.Background(), client, messageStore)
+	if err != nil {
+		logger.Warnf("Failed to import saved contacts: %v", err)
+	} else {
+		logger.Infof("Imported %d saved contacts, renamed %d chats", imported, renamed)
+	}
+	backfillChatNamesFromContacts(client, messageStore, logger)
+}
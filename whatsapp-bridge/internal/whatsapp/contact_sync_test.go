@@ -0,0 +1,35 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestSavedContactsFrom(t *testing.T) {
+	phone := types.NewJID("15551234567", types.DefaultUserServer)
+	lid := types.NewJID("987654321", types.HiddenUserServer)
+	all := map[types.JID]types.ContactInfo{
+		phone: {FullName: "Ada Lovelace", FirstName: "Ada", PushName: "ada"},
+		lid:   {FullName: "Ada Lovelace", PushName: "ada"},
+		types.NewJID("15550000000", types.DefaultUserServer):  {PushName: "push only"},
+		types.NewJID("120363000000000000", types.GroupServer): {FullName: "Not a person"},
+	}
+	// The LID maps to the same person as the phone number.
+	chatID := func(contactJID types.JID) string {
+		if contactJID == lid {
+			return phone.User
+		}
+		return contactJID.User
+	}
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	contacts := savedContactsFrom(all, chatID, at)
+	if len(contacts) != 1 {
+		t.Fatalf("expected one saved contact, got %+v", contacts)
+	}
+	if contact := contacts[0]; contact.UserID != phone.User || contact.FullName != "Ada Lovelace" || !contact.UpdatedAt.Equal(at) {
+		t.Fatalf("unexpected contact %+v", contact)
+	}
+}
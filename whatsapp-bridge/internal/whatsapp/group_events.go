@@ -0,0 +1,147 @@
+package whatsapp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/webhook"
+)
+
+const (
+	EventGroupParticipantJoined = "group.participant_joined"
+	EventGroupParticipantLeft   = "group.participant_left"
+)
+
+// Join and leave methods reported with group participant events.
+const (
+	groupMethodAdded      = "added"
+	groupMethodInviteLink = "invite_link"
+	groupMethodJoined     = "joined"
+	groupMethodLeft       = "left"
+	groupMethodRemoved    = "removed"
+)
+
+// GroupParticipantEvent is the webhook payload for a member joining or leaving a group.
+type GroupParticipantEvent struct {
+	GroupJID       string `json:"group_jid"`
+	GroupName      string `json:"group_name,omitempty"`
+	ParticipantID  string `json:"participant_id"`
+	ParticipantJID string `json:"participant_jid"`
+	ActorID        string `json:"actor_id,omitempty"`
+	Method         string `json:"method"`
+}
+
+// groupParticipantChange is one join or leave carried by a GroupInfo event.
+type groupParticipantChange struct {
+	eventType   string
+	participant types.JID
+	method      string
+}
+
+// groupParticipantChanges splits a GroupInfo event into per-member changes.
+// The sender is whoever made the change: the inviter for adds, the admin for
+// removals, or the member themselves.
+func groupParticipantChanges(evt *events.GroupInfo) []groupParticipantChange {
+	var actor types.JID
+	if evt.Sender != nil {
+		actor = evt.Sender.ToNonAD()
+	}
+
+	var changes []groupParticipantChange
+	for _, participant := range evt.Join {
+		participant = participant.ToNonAD()
+		method := groupMethodJoined
+		switch {
+		case evt.JoinReason == "invite":
+			method = groupMethodInviteLink
+		case !actor.IsEmpty() && actor.User != participant.User:
+			method = groupMethodAdded
+		}
+		changes = append(changes, groupParticipantChange{EventGroupParticipantJoined, participant, method})
+	}
+	for _, participant := range evt.Leave {
+		participant = participant.ToNonAD()
+		method := groupMethodLeft
+		if !actor.IsEmpty() && actor.User != participant.User {
+			method = groupMethodRemoved
+		}
+		changes = append(changes, groupParticipantChange{EventGroupParticipantLeft, participant, method})
+	}
+	return changes
+}
+
+// monitoredGroups returns the groups listed in WHATSAPP_GROUP_EVENTS_GROUPS, or
+// nil when every group is monitored.
+func monitoredGroups() map[string]struct{} {
+	var groups map[string]struct{}
+	for _, part := range strings.Split(os.Getenv("WHATSAPP_GROUP_EVENTS_GROUPS"), ",") {
+		if normalized := jid.NormalizeChat(part); normalized != "" {
+			if groups == nil {
+				groups = map[string]struct{}{}
+			}
+			groups[normalized] = struct{}{}
+		}
+	}
+	return groups
+}
+
+// handleGroupInfo settles pending invites for members who joined and emits
+// participant join and leave events for monitored groups.
+func handleGroupInfo(client *whatsmeow.Client, messageStore *storage.MessageStore, emitter *webhook.Emitter, evt *events.GroupInfo) {
+	changes := groupParticipantChanges(evt)
+	if len(changes) == 0 {
+		return
+	}
+	groupJID := evt.JID.ToNonAD().String()
+
+	participantIDs := make([]string, len(changes))
+	var joined []string
+	for i, change := range changes {
+		participantIDs[i] = canonicalizeSender(client, change.participant, types.JID{})
+		if change.eventType == EventGroupParticipantJoined {
+			joined = append(joined, participantIDs[i], change.participant.User)
+		}
+	}
+	if len(joined) > 0 {
+		if err := messageStore.MarkGroupInvitesJoined(groupJID, joined, evt.Timestamp); err != nil {
+			fmt.Printf("Warning: failed to update group invites: %v\n", err)
+		}
+	}
+
+	if emitter == nil {
+		return
+	}
+	if groups := monitoredGroups(); groups != nil {
+		if _, ok := groups[groupJID]; !ok {
+			return
+		}
+	}
+
+	groupName, _ := messageStore.GetChatName(groupJID)
+	actorID := ""
+	if evt.Sender != nil {
+		var senderPN types.JID
+		if evt.SenderPN != nil {
+			senderPN = *evt.SenderPN
+		}
+		actorID = canonicalizeSender(client, *evt.Sender, senderPN)
+	}
+
+	for i, change := range changes {
+		subject := webhook.Subject{ChatJID: groupJID, SenderID: participantIDs[i]}
+		emitter.Emit(change.eventType, evt.Timestamp, subject, GroupParticipantEvent{
+			GroupJID:       groupJID,
+			GroupName:      groupName,
+			ParticipantID:  participantIDs[i],
+			ParticipantJID: change.participant.String(),
+			ActorID:        actorID,
+			Method:         change.method,
+		})
+	}
+}
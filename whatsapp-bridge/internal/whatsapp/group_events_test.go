@@ -0,0 +1,64 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestGroupParticipantChangesMethods(t *testing.T) {
+	admin := types.NewJID("111", types.DefaultUserServer)
+	alice := types.NewJID("222", types.DefaultUserServer)
+	bob := types.JID{User: "333", Device: 2, Server: types.HiddenUserServer}
+
+	cases := []struct {
+		name  string
+		event events.GroupInfo
+		want  []groupParticipantChange
+	}{
+		{
+			name:  "added by admin",
+			event: events.GroupInfo{Sender: &admin, Join: []types.JID{alice}},
+			want:  []groupParticipantChange{{EventGroupParticipantJoined, alice, groupMethodAdded}},
+		},
+		{
+			name:  "invite link",
+			event: events.GroupInfo{JoinReason: "invite", Join: []types.JID{bob}},
+			want:  []groupParticipantChange{{EventGroupParticipantJoined, bob.ToNonAD(), groupMethodInviteLink}},
+		},
+		{
+			name:  "joined without actor",
+			event: events.GroupInfo{Join: []types.JID{alice}},
+			want:  []groupParticipantChange{{EventGroupParticipantJoined, alice, groupMethodJoined}},
+		},
+		{
+			name:  "left and removed",
+			event: events.GroupInfo{Sender: &alice, Leave: []types.JID{alice}},
+			want:  []groupParticipantChange{{EventGroupParticipantLeft, alice, groupMethodLeft}},
+		},
+		{
+			name:  "removed by admin",
+			event: events.GroupInfo{Sender: &admin, Leave: []types.JID{alice, bob}},
+			want: []groupParticipantChange{
+				{EventGroupParticipantLeft, alice, groupMethodRemoved},
+				{EventGroupParticipantLeft, bob.ToNonAD(), groupMethodRemoved},
+			},
+		},
+		{
+			name:  "no membership change",
+			event: events.GroupInfo{Sender: &admin},
+		},
+	}
+	for _, tc := range cases {
+		got := groupParticipantChanges(&tc.event)
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got %d changes, want %d", tc.name, len(got), len(tc.want))
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: change %d = %+v, want %+v", tc.name, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
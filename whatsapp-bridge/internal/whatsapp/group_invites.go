@@ -0,0 +1,183 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+// DefaultGroupInviteTemplate is sent to numbers that cannot be added directly.
+// {group_name} and {link} are replaced with the group's name and invite link.
+const DefaultGroupInviteTemplate = "You're invited to join {group_name} on WhatsApp: {link}"
+
+// maxGroupInvitePhones bounds how many numbers one invite request may target.
+const maxGroupInvitePhones = 50
+
+// WhatsApp error codes returned per participant when adding to a group.
+const (
+	groupAddErrorPrivacy       = 403
+	groupAddErrorAlreadyMember = 409
+)
+
+// GroupInviteResult is the outcome for one phone number.
+type GroupInviteResult struct {
+	Phone         string
+	ParticipantID string
+	Status        string
+	Detail        string
+}
+
+// renderGroupInviteTemplate fills the invite message placeholders.
+func renderGroupInviteTemplate(template, groupName, link string) string {
+	if strings.TrimSpace(template) == "" {
+		template = DefaultGroupInviteTemplate
+	}
+	if groupName == "" {
+		groupName = "our group"
+	}
+	return strings.NewReplacer("{group_name}", groupName, "{link}", link).Replace(template)
+}
+
+// normalizeInvitePhone strips formatting from a phone number, rejecting
+// anything that is not a plain international number.
+func normalizeInvitePhone(raw string) (string, bool) {
+	phone := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(raw))
+	phone = strings.TrimPrefix(phone, "+")
+	if strings.Contains(phone, "@") {
+		user, server := jid.Split(phone)
+		if server != jid.UserServer {
+			return "", false
+		}
+		phone = user
+	}
+	if len(phone) < 6 || len(phone) > 15 {
+		return "", false
+	}
+	for _, r := range phone {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return phone, true
+}
+
+// InviteToGroup adds phone numbers to a group. Numbers whose privacy settings
+// block direct adds are sent the group's invite link by DM, rendered from
+// template, and tracked until they join.
+func InviteToGroup(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore, group string, phones []string, template string) ([]GroupInviteResult, error) {
+	if client == nil || !client.IsConnected() {
+		return nil, fmt.Errorf("Not connected to WhatsApp")
+	}
+	groupJID, err := parseRecipientJID(group)
+	if err != nil {
+		return nil, err
+	}
+	if groupJID.Server != types.GroupServer {
+		return nil, fmt.Errorf("invites are only supported for group chats")
+	}
+	if len(phones) == 0 {
+		return nil, fmt.Errorf("at least one phone number is required")
+	}
+	if len(phones) > maxGroupInvitePhones {
+		return nil, fmt.Errorf("at most %d phone numbers can be invited at once", maxGroupInvitePhones)
+	}
+
+	results := make([]GroupInviteResult, 0, len(phones))
+	indexByPhone := map[string]int{}
+	var participants []types.JID
+	for _, raw := range phones {
+		phone, ok := normalizeInvitePhone(raw)
+		if !ok {
+			results = append(results, GroupInviteResult{Phone: raw, Status: storage.GroupInviteFailed, Detail: "invalid phone number"})
+			continue
+		}
+		if _, dup := indexByPhone[phone]; dup {
+			continue
+		}
+		participant := types.NewJID(phone, types.DefaultUserServer)
+		indexByPhone[phone] = len(results)
+		results = append(results, GroupInviteResult{
+			Phone:         phone,
+			ParticipantID: canonicalizeSender(client, participant, types.JID{}),
+		})
+		participants = append(participants, participant)
+	}
+
+	if len(participants) > 0 {
+		added, err := client.UpdateGroupParticipants(ctx, groupJID, participants, whatsmeow.ParticipantChangeAdd)
+		if err != nil {
+			return nil, fmt.Errorf("error adding group participants: %w", err)
+		}
+		for _, participant := range added {
+			phone := participant.PhoneNumber.User
+			if phone == "" {
+				phone = participant.JID.User
+			}
+			i, ok := indexByPhone[phone]
+			if !ok {
+				continue
+			}
+			switch {
+			case participant.Error == 0:
+				results[i].Status = storage.GroupInviteAdded
+			case participant.Error == groupAddErrorAlreadyMember:
+				results[i].Status = storage.GroupInviteAlreadyMember
+			case participant.Error == groupAddErrorPrivacy || participant.AddRequest != nil:
+				results[i].Status = storage.GroupInviteInvited
+			default:
+				results[i].Status = storage.GroupInviteFailed
+				results[i].Detail = fmt.Sprintf("WhatsApp error %d", participant.Error)
+			}
+		}
+	}
+
+	var link, groupName string
+	for i := range results {
+		if results[i].Status == "" {
+			results[i].Status = storage.GroupInviteFailed
+			results[i].Detail = "no result from WhatsApp"
+		}
+		if results[i].Status != storage.GroupInviteInvited {
+			continue
+		}
+		if link == "" {
+			if link, err = client.GetGroupInviteLink(ctx, groupJID, false); err != nil {
+				return nil, fmt.Errorf("error fetching group invite link: %w", err)
+			}
+			if info, err := client.GetGroupInfo(ctx, groupJID); err == nil {
+				groupName = info.Name
+			}
+		}
+		message := renderGroupInviteTemplate(template, groupName, link)
+		if ok, detail := SendWhatsAppMessage(client, messageStore, results[i].Phone, message, "", SendOptions{}); !ok {
+			results[i].Status = storage.GroupInviteFailed
+			results[i].Detail = detail
+		}
+	}
+
+	if messageStore != nil {
+		now := time.Now()
+		for _, result := range results {
+			if result.ParticipantID == "" {
+				continue
+			}
+			if err := messageStore.RecordGroupInvite(storage.GroupInvite{
+				GroupJID:      groupJID.String(),
+				Phone:         result.Phone,
+				ParticipantID: result.ParticipantID,
+				Status:        result.Status,
+				Detail:        result.Detail,
+				InvitedAt:     now,
+			}); err != nil {
+				fmt.Printf("Warning: failed to record group invite: %v\n", err)
+			}
+		}
+	}
+	return results, nil
+}
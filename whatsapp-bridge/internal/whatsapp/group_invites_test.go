@@ -0,0 +1,30 @@
+package whatsapp
+
+import "testing"
+
+func TestRenderGroupInviteTemplate(t *testing.T) {
+	link := "https://chat.whatsapp.com/abc"
+	if got, want := renderGroupInviteTemplate("", "Runners", link), "You're invited to join Runners on WhatsApp: "+link; got != want {
+		t.Fatalf("default template: got %q want %q", got, want)
+	}
+	if got, want := renderGroupInviteTemplate("Join {group_name}! {link} ({link})", "", link), "Join our group! "+link+" ("+link+")"; got != want {
+		t.Fatalf("custom template: got %q want %q", got, want)
+	}
+}
+
+func TestNormalizeInvitePhone(t *testing.T) {
+	cases := map[string]string{
+		"+1 (555) 123-4567":          "15551234567",
+		"15551234567@s.whatsapp.net": "15551234567",
+		"12345":                      "",
+		"1555abc4567":                "",
+		"123@g.us":                   "",
+		"1234567890123456":           "",
+	}
+	for raw, want := range cases {
+		got, ok := normalizeInvitePhone(raw)
+		if ok != (want != "") || got != want {
+			t.Errorf("normalizeInvitePhone(%q) = (%q, %v), want %q", raw, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/storage"
+)
+
+const defaultGroupRefreshInterval = time.Second
+
+// GroupRefreshInterval is the pause between groups in a bulk metadata
+// refresh, from WHATSAPP_GROUP_REFRESH_INTERVAL_MS, keeping the bridge well
+// under WhatsApp's rate limits.
+func GroupRefreshInterval() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("WHATSAPP_GROUP_REFRESH_INTERVAL_MS"))
+	if raw == "" {
+		return defaultGroupRefreshInterval
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed < 0 {
+		fmt.Printf("Warning: invalid WHATSAPP_GROUP_REFRESH_INTERVAL_MS=%q, using %d\n", raw, defaultGroupRefreshInterval.Milliseconds())
+		return defaultGroupRefreshInterval
+	}
+	return time.Duration(parsed) * time.Millisecond
+}
+
+// groupParticipants converts WhatsApp's member list to stored participants
+// under their canonical IDs.
+func groupParticipants(client *whatsmeow.Client, members []types.GroupParticipant) []storage.GroupParticipant {
+	participants := make([]storage.GroupParticipant, 0, len(members))
+	for _, member := range members {
+		alt := member.LID
+		if member.JID.Server == types.HiddenUserServer {
+			alt = member.PhoneNumber
+		}
+		participantID := canonicalizeSender(client, member.JID, alt)
+		if participantID == "" {
+			continue
+		}
+		participants = append(participants, storage.GroupParticipant{
+			ParticipantID: participantID,
+			IsAdmin:       member.IsAdmin || member.IsSuperAdmin,
+			IsSuperAdmin:  member.IsSuperAdmin,
+		})
+	}
+	return participants
+}
+
+// RefreshGroupMetadata fetches a group's name, topic, members and photo from
+// WhatsApp and stores them, replacing placeholder chat names. The photo is
+// only refetched when it changed. WhatsApp failures are returned as
+// *SendError so callers can back off when rate limited.
+func RefreshGroupMetadata(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore, groupJID string) error {
+	if client == nil || !client.IsConnected() {
+		return notConnectedError()
+	}
+	if messageStore == nil {
+		return fmt.Errorf("message store is not initialized")
+	}
+	parsed, err := types.ParseJID(groupJID)
+	if err != nil || parsed.Server != types.GroupServer {
+		return fmt.Errorf("invalid group JID %q", groupJID)
+	}
+
+	info, err := client.GetGroupInfo(ctx, parsed)
+	if err != nil {
+		return classifySendError("Error fetching group info", err)
+	}
+	metadata := storage.GroupMetadata{
+		GroupJID:     parsed.String(),
+		Name:         info.Name,
+		Topic:        info.Topic,
+		Participants: groupParticipants(client, info.Participants),
+		RefreshedAt:  time.Now(),
+	}
+
+	previous, err := messageStore.GetGroupMetadata(parsed.String())
+	if err != nil {
+		return fmt.Errorf("error loading stored group metadata: %v", err)
+	}
+	existingPhotoID := ""
+	if previous != nil {
+		existingPhotoID = previous.PhotoID
+		metadata.PhotoID, metadata.PhotoURL = previous.PhotoID, previous.PhotoURL
+	}
+	photo, err := client.GetProfilePictureInfo(ctx, parsed, &whatsmeow.GetProfilePictureParams{ExistingID: existingPhotoID})
+	switch {
+	case errors.Is(err, whatsmeow.ErrProfilePictureNotSet), errors.Is(err, whatsmeow.ErrProfilePictureUnauthorized):
+		metadata.PhotoID, metadata.PhotoURL = "", ""
+	case err != nil:
+		return classifySendError("Error fetching group photo", err)
+	case photo != nil:
+		// A nil photo means it still matches existingPhotoID.
+		metadata.PhotoID, metadata.PhotoURL = photo.ID, photo.URL
+	}
+
+	return messageStore.SaveGroupMetadata(metadata)
+}
@@ -0,0 +1,56 @@
+package whatsapp
+
+import (
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"whatsapp-client/internal/storage"
+)
+
+// historySenderJID resolves who sent a history-synced message and whether it
+// was the account itself. Group messages name their author in the key's
+// participant or, failing that, in the message info's participant; when both
+// are missing the sender is left empty, since an unknown author beats
+// attributing the message to the group.
+func historySenderJID(ownJID types.JID, chatJID types.JID, info *waProto.WebMessageInfo) (types.JID, bool) {
+	key := info.GetKey()
+	if key.GetFromMe() {
+		if !ownJID.IsEmpty() {
+			return ownJID.ToNonAD(), true
+		}
+		return chatJID.ToNonAD(), true
+	}
+	for _, participant := range []string{key.GetParticipant(), info.GetParticipant()} {
+		if participant != "" {
+			return parseSenderJID(participant), false
+		}
+	}
+	if chatJID.Server == types.GroupServer {
+		return types.JID{}, false
+	}
+	return chatJID.ToNonAD(), false
+}
+
+// attributeQuotedSender fills in the sender of a stored group message whose
+// author is unknown from a reply quoting it, which names the quoted author.
+func attributeQuotedSender(client *whatsmeow.Client, messageStore *storage.MessageStore, chatJID types.JID, chatID string, msg *waProto.Message, logger waLog.Logger) {
+	if chatJID.Server != types.GroupServer {
+		return
+	}
+	contextInfo := messageContextInfo(msg)
+	quotedID, participant := contextInfo.GetStanzaID(), contextInfo.GetParticipant()
+	if quotedID == "" || participant == "" {
+		return
+	}
+	senderJID := parseSenderJID(participant)
+	sender := canonicalizeSender(client, senderJID, types.JID{})
+	updated, err := messageStore.AttributeUnknownSender(quotedID, chatID, sender, senderJID.User, senderJID.Server)
+	if err != nil {
+		logger.Warnf("Failed to attribute quoted message sender (message_ref=%s): %v", obfuscatedMessageRef(quotedID), err)
+		return
+	}
+	if updated {
+		logger.Infof("Attributed quoted message sender: message_ref=%s", obfuscatedMessageRef(quotedID))
+	}
+}
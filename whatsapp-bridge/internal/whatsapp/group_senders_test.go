@@ -0,0 +1,39 @@
+package whatsapp
+
+import (
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHistorySenderJIDAttributesGroupMessages(t *testing.T) {
+	group := types.NewJID("120363000000000001", types.GroupServer)
+	own := types.NewJID("15550000000", types.DefaultUserServer)
+
+	fromKey := &waProto.WebMessageInfo{Key: &waProto.MessageKey{Participant: proto.String("15551111111@s.whatsapp.net")}}
+	if sender, fromMe := historySenderJID(own, group, fromKey); sender.User != "15551111111" || fromMe {
+		t.Fatalf("expected key participant as sender, got %v (from me %v)", sender, fromMe)
+	}
+
+	fromInfo := &waProto.WebMessageInfo{Key: &waProto.MessageKey{}, Participant: proto.String("15552222222@s.whatsapp.net")}
+	if sender, _ := historySenderJID(own, group, fromInfo); sender.User != "15552222222" {
+		t.Fatalf("expected info participant as sender, got %v", sender)
+	}
+
+	unknown := &waProto.WebMessageInfo{Key: &waProto.MessageKey{}}
+	if sender, _ := historySenderJID(own, group, unknown); !sender.IsEmpty() {
+		t.Fatalf("expected no sender rather than the group, got %v", sender)
+	}
+
+	mine := &waProto.WebMessageInfo{Key: &waProto.MessageKey{FromMe: proto.Bool(true)}}
+	if sender, fromMe := historySenderJID(own, group, mine); sender != own || !fromMe {
+		t.Fatalf("expected own messages attributed to the account, got %v (from me %v)", sender, fromMe)
+	}
+
+	direct := types.NewJID("15553333333", types.DefaultUserServer)
+	if sender, _ := historySenderJID(own, direct, unknown); sender != direct {
+		t.Fatalf("expected direct chat messages attributed to the chat, got %v", sender)
+	}
+}
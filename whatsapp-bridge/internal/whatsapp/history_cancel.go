@@ -0,0 +1,71 @@
+package whatsapp
+
+import "sync"
+
+// historySyncControl lets an operator stop history sync ingestion, for
+// example to configure caps or ingest rules before the full dump is stored.
+// A cancelled sync finishes the conversation it is storing; history batches
+// WhatsApp sends afterwards are skipped until ingestion is resumed.
+var historySyncControl = struct {
+	mu        sync.Mutex
+	running   int
+	cancelled bool
+}{}
+
+// HistorySyncState reports whether a history sync batch is being stored and
+// whether ingestion is cancelled.
+type HistorySyncState struct {
+	Running   bool
+	Cancelled bool
+}
+
+// GetHistorySyncState returns the current history sync state.
+func GetHistorySyncState() HistorySyncState {
+	historySyncControl.mu.Lock()
+	defer historySyncControl.mu.Unlock()
+	return HistorySyncState{Running: historySyncControl.running > 0, Cancelled: historySyncControl.cancelled}
+}
+
+// CancelHistorySync stops history sync ingestion after the current
+// conversation and skips later batches.
+func CancelHistorySync() HistorySyncState {
+	historySyncControl.mu.Lock()
+	defer historySyncControl.mu.Unlock()
+	historySyncControl.cancelled = true
+	return HistorySyncState{Running: historySyncControl.running > 0, Cancelled: true}
+}
+
+// ResumeHistorySync lets history batches WhatsApp sends from now on be
+// stored again. Batches skipped while cancelled are not recovered.
+func ResumeHistorySync() HistorySyncState {
+	historySyncControl.mu.Lock()
+	defer historySyncControl.mu.Unlock()
+	historySyncControl.cancelled = false
+	return HistorySyncState{Running: historySyncControl.running > 0}
+}
+
+// beginHistorySync marks a batch as being stored. It returns false, without
+// marking anything, when ingestion is cancelled.
+func beginHistorySync() bool {
+	historySyncControl.mu.Lock()
+	defer historySyncControl.mu.Unlock()
+	if historySyncControl.cancelled {
+		return false
+	}
+	historySyncControl.running++
+	return true
+}
+
+// endHistorySync marks a batch started with beginHistorySync as done.
+func endHistorySync() {
+	historySyncControl.mu.Lock()
+	defer historySyncControl.mu.Unlock()
+	historySyncControl.running--
+}
+
+// historySyncCancelled reports whether the running batch should stop.
+func historySyncCancelled() bool {
+	historySyncControl.mu.Lock()
+	defer historySyncControl.mu.Unlock()
+	return historySyncControl.cancelled
+}
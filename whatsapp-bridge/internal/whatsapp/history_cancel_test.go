@@ -0,0 +1,29 @@
+package whatsapp
+
+import "testing"
+
+func TestCancelHistorySync(t *testing.T) {
+	t.Cleanup(func() { ResumeHistorySync() })
+
+	if !beginHistorySync() {
+		t.Fatal("expected a batch to start while not cancelled")
+	}
+	if state := CancelHistorySync(); !state.Running || !state.Cancelled {
+		t.Fatalf("unexpected state after cancel: %+v", state)
+	}
+	if !historySyncCancelled() {
+		t.Fatal("expected the running batch to see the cancellation")
+	}
+	endHistorySync()
+
+	if beginHistorySync() {
+		t.Fatal("expected later batches to be skipped while cancelled")
+	}
+	if state := ResumeHistorySync(); state.Running || state.Cancelled {
+		t.Fatalf("unexpected state after resume: %+v", state)
+	}
+	if !beginHistorySync() {
+		t.Fatal("expected batches to be stored again after resume")
+	}
+	endHistorySync()
+}
@@ -0,0 +1,107 @@
+package whatsapp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryCaps limit how much of a history sync is stored, for users who only
+// want recent context rather than a dump of every chat. Zero values mean no
+// limit. Counts are kept across the history sync batches of one run.
+type HistoryCaps struct {
+	// MaxConversations stores history for at most this many chats. WhatsApp
+	// sends the most recently active chats first, so these are the ones kept.
+	MaxConversations int
+	// MaxMessagesPerChat stores at most this many of each chat's newest
+	// history messages.
+	MaxMessagesPerChat int
+	// MaxAge skips history messages older than this, and chats whose latest
+	// message is.
+	MaxAge time.Duration
+
+	counts *historyCounts
+}
+
+// historyCounts tracks the chats admitted so far and how many history
+// messages each has stored.
+type historyCounts struct {
+	mu    sync.Mutex
+	chats map[string]int
+}
+
+func newHistoryCaps(maxConversations int, maxMessagesPerChat int, maxAge time.Duration) HistoryCaps {
+	return HistoryCaps{
+		MaxConversations:   maxConversations,
+		MaxMessagesPerChat: maxMessagesPerChat,
+		MaxAge:             maxAge,
+		counts:             &historyCounts{chats: map[string]int{}},
+	}
+}
+
+// HistoryCapsFromEnv loads WHATSAPP_HISTORY_MAX_CONVERSATIONS,
+// WHATSAPP_HISTORY_MAX_MESSAGES_PER_CHAT and WHATSAPP_HISTORY_MAX_AGE_DAYS.
+func HistoryCapsFromEnv() HistoryCaps {
+	maxAgeDays := parseHistoryCap("WHATSAPP_HISTORY_MAX_AGE_DAYS")
+	return newHistoryCaps(
+		parseHistoryCap("WHATSAPP_HISTORY_MAX_CONVERSATIONS"),
+		parseHistoryCap("WHATSAPP_HISTORY_MAX_MESSAGES_PER_CHAT"),
+		time.Duration(maxAgeDays)*24*time.Hour,
+	)
+}
+
+func parseHistoryCap(name string) int {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed < 0 {
+		fmt.Printf("Warning: invalid %s=%q, ignoring\n", name, raw)
+		return 0
+	}
+	return parsed
+}
+
+// tooOld reports whether a history message sent at ts is past MaxAge.
+func (c HistoryCaps) tooOld(ts time.Time, now time.Time) bool {
+	return c.MaxAge > 0 && ts.Before(now.Add(-c.MaxAge))
+}
+
+// admitsConversation reports whether history for chatID is stored, admitting
+// it when MaxConversations leaves room. Chats admitted earlier stay admitted
+// when later batches carry more of their history.
+func (c HistoryCaps) admitsConversation(chatID string) bool {
+	if c.MaxConversations <= 0 || c.counts == nil {
+		return true
+	}
+	c.counts.mu.Lock()
+	defer c.counts.mu.Unlock()
+	if _, ok := c.counts.chats[chatID]; ok {
+		return true
+	}
+	if len(c.counts.chats) >= c.MaxConversations {
+		return false
+	}
+	c.counts.chats[chatID] = 0
+	return true
+}
+
+// admitsMessage reports whether another history message of chatID is stored
+// under MaxMessagesPerChat, and counts it when it is. History sync lists a
+// chat's messages newest first, so the newest ones are kept.
+func (c HistoryCaps) admitsMessage(chatID string) bool {
+	if c.MaxMessagesPerChat <= 0 || c.counts == nil {
+		return true
+	}
+	c.counts.mu.Lock()
+	defer c.counts.mu.Unlock()
+	if c.counts.chats[chatID] >= c.MaxMessagesPerChat {
+		return false
+	}
+	c.counts.chats[chatID]++
+	return true
+}
@@ -0,0 +1,69 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryCapsFromEnv(t *testing.T) {
+	t.Setenv("WHATSAPP_HISTORY_MAX_CONVERSATIONS", "100")
+	t.Setenv("WHATSAPP_HISTORY_MAX_MESSAGES_PER_CHAT", "500")
+	t.Setenv("WHATSAPP_HISTORY_MAX_AGE_DAYS", "90")
+
+	caps := HistoryCapsFromEnv()
+	if caps.MaxConversations != 100 || caps.MaxMessagesPerChat != 500 || caps.MaxAge != 90*24*time.Hour {
+		t.Fatalf("unexpected caps: %+v", caps)
+	}
+
+	t.Setenv("WHATSAPP_HISTORY_MAX_CONVERSATIONS", "-1")
+	if caps := HistoryCapsFromEnv(); caps.MaxConversations != 0 {
+		t.Fatalf("expected an invalid cap to be ignored, got %d", caps.MaxConversations)
+	}
+}
+
+func TestHistoryCapsAdmitsConversation(t *testing.T) {
+	caps := newHistoryCaps(2, 0, 0)
+	for _, chatID := range []string{"a", "b", "a"} {
+		if !caps.admitsConversation(chatID) {
+			t.Fatalf("expected %q to be admitted", chatID)
+		}
+	}
+	if caps.admitsConversation("c") {
+		t.Fatal("expected a third chat to be capped")
+	}
+	if !caps.admitsConversation("b") {
+		t.Fatal("expected an admitted chat to stay admitted")
+	}
+}
+
+func TestHistoryCapsAdmitsMessage(t *testing.T) {
+	caps := newHistoryCaps(0, 2, 0)
+	for i := 0; i < 2; i++ {
+		if !caps.admitsMessage("a") {
+			t.Fatalf("expected message %d to be admitted", i)
+		}
+	}
+	if caps.admitsMessage("a") {
+		t.Fatal("expected the third message of a chat to be capped")
+	}
+	if !caps.admitsMessage("b") {
+		t.Fatal("expected messages of other chats to be counted separately")
+	}
+	if !(HistoryCaps{}).admitsMessage("a") {
+		t.Fatal("expected no limit by default")
+	}
+}
+
+func TestHistoryCapsTooOld(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	caps := newHistoryCaps(0, 0, 90*24*time.Hour)
+	if caps.tooOld(now.AddDate(0, 0, -89), now) {
+		t.Fatal("expected a recent message to be kept")
+	}
+	if !caps.tooOld(now.AddDate(0, 0, -91), now) {
+		t.Fatal("expected an old message to be skipped")
+	}
+	if (HistoryCaps{}).tooOld(now.AddDate(-10, 0, 0), now) {
+		t.Fatal("expected no age limit by default")
+	}
+}
@@ -0,0 +1,67 @@
+package whatsapp
+
+import (
+	"time"
+
+	"whatsapp-client/internal/bootstrap"
+	"whatsapp-client/internal/webhook"
+)
+
+// EventHistorySyncProgress reports a history sync batch's progress after each
+// conversation. It only reaches live stream subscribers.
+const EventHistorySyncProgress = "history_sync.progress"
+
+// HistorySyncProgressEvent is the stream payload of EventHistorySyncProgress.
+// ChatRef is an obfuscated reference to the conversation just processed, so
+// the stream does not reveal who the account talks to.
+type HistorySyncProgressEvent struct {
+	Progress       int    `json:"progress"`
+	Conversation   int    `json:"conversation"`
+	Conversations  int    `json:"conversations"`
+	ChatRef        string `json:"chat_ref,omitempty"`
+	MessagesStored int    `json:"messages_stored"`
+	ETASeconds     int    `json:"eta_seconds"`
+}
+
+// historyProgress tracks one history sync batch for AuthStatus and the event
+// stream.
+type historyProgress struct {
+	emitter *webhook.Emitter
+	total   int
+	started time.Time
+}
+
+// report records that processed of the batch's conversations are done, the
+// last being chatID, with stored messages stored so far.
+func (p historyProgress) report(processed int, chatID string, stored int) {
+	if p.total <= 0 {
+		return
+	}
+	progress := 25 + int(float64(processed)/float64(p.total)*70)
+	if progress > 95 {
+		progress = 95
+	}
+	chatRef := ""
+	if chatID != "" {
+		chatRef = obfuscatedChatRef(chatID)
+	}
+	eta := syncETA(time.Since(p.started), processed, p.total)
+	bootstrap.SetSyncingConversation(progress, processed, p.total, chatRef, stored, eta)
+	p.emitter.Publish(EventHistorySyncProgress, time.Now(), webhook.Subject{}, HistorySyncProgressEvent{
+		Progress:       progress,
+		Conversation:   processed,
+		Conversations:  p.total,
+		ChatRef:        chatRef,
+		MessagesStored: stored,
+		ETASeconds:     int(eta.Round(time.Second) / time.Second),
+	})
+}
+
+// syncETA estimates the time left to process total conversations when
+// processed of them took elapsed, assuming the rest go at the same pace.
+func syncETA(elapsed time.Duration, processed int, total int) time.Duration {
+	if processed <= 0 || processed >= total || elapsed <= 0 {
+		return 0
+	}
+	return elapsed / time.Duration(processed) * time.Duration(total-processed)
+}
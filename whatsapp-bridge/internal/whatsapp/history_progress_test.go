@@ -0,0 +1,28 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncETA(t *testing.T) {
+	tests := []struct {
+		name      string
+		elapsed   time.Duration
+		processed int
+		total     int
+		want      time.Duration
+	}{
+		{"quarter done", 10 * time.Second, 25, 100, 30 * time.Second},
+		{"nothing done yet", 10 * time.Second, 0, 100, 0},
+		{"all done", time.Minute, 100, 100, 0},
+		{"no time elapsed", 0, 10, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := syncETA(tt.elapsed, tt.processed, tt.total); got != tt.want {
+				t.Fatalf("syncETA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,24 +2,16 @@ package whatsapp
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"strings"
+	"sync"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/jid"
 )
 
-// normalizeSenderID strips server suffixes and surrounding whitespace.
-func normalizeSenderID(id string) string {
-	normalized := strings.TrimSpace(id)
-	if normalized == "" {
-		return ""
-	}
-	if strings.Contains(normalized, "@") {
-		return strings.SplitN(normalized, "@", 2)[0]
-	}
-	return normalized
-}
-
 // parseSenderJID parses a sender string into a non-AD JID.
 func parseSenderJID(sender string) types.JID {
 	sender = strings.TrimSpace(sender)
@@ -41,7 +33,7 @@ func parseSenderJID(sender string) types.JID {
 func senderAliasIDs(client *whatsmeow.Client, senderJID types.JID, senderAlt types.JID, canonicalID string) []string {
 	ids := map[string]struct{}{}
 	add := func(id string) {
-		normalized := normalizeSenderID(id)
+		normalized := jid.NormalizeUser(id)
 		if normalized != "" {
 			ids[normalized] = struct{}{}
 		}
@@ -83,8 +75,72 @@ func senderAliasIDs(client *whatsmeow.Client, senderJID types.JID, senderAlt typ
 	return aliases
 }
 
-// canonicalizeSender resolves a sender into a canonical personal identifier.
-func canonicalizeSender(client *whatsmeow.Client, senderJID types.JID, senderAlt types.JID) string {
+// IdentityStrategy selects which form of a person's ID becomes canonical.
+type IdentityStrategy string
+
+const (
+	// IdentityStrategyPN prefers phone numbers, resolving LIDs when known.
+	IdentityStrategyPN IdentityStrategy = "pn"
+	// IdentityStrategyLID prefers LIDs, so phone numbers are not stored where avoidable.
+	IdentityStrategyLID IdentityStrategy = "lid"
+	// IdentityStrategyAsReceived keeps whichever ID WhatsApp delivered.
+	IdentityStrategyAsReceived IdentityStrategy = "as-received"
+)
+
+var (
+	identityStrategyOnce  sync.Once
+	identityStrategyValue IdentityStrategy
+)
+
+// parseIdentityStrategy maps a configured value to a strategy, defaulting to pn.
+func parseIdentityStrategy(raw string) (IdentityStrategy, bool) {
+	switch strategy := IdentityStrategy(strings.ToLower(strings.TrimSpace(raw))); strategy {
+	case "":
+		return IdentityStrategyPN, true
+	case IdentityStrategyPN, IdentityStrategyLID, IdentityStrategyAsReceived:
+		return strategy, true
+	default:
+		return IdentityStrategyPN, false
+	}
+}
+
+// currentIdentityStrategy returns the strategy configured via
+// WHATSAPP_IDENTITY_STRATEGY, read once so live and history sync agree.
+func currentIdentityStrategy() IdentityStrategy {
+	identityStrategyOnce.Do(func() {
+		raw := os.Getenv("WHATSAPP_IDENTITY_STRATEGY")
+		strategy, ok := parseIdentityStrategy(raw)
+		if !ok {
+			fmt.Printf("Warning: invalid WHATSAPP_IDENTITY_STRATEGY=%q, using %s\n", raw, strategy)
+		}
+		identityStrategyValue = strategy
+	})
+	return identityStrategyValue
+}
+
+// jidMapper resolves a JID to its counterpart on the other server, if known.
+type jidMapper func(types.JID) (types.JID, bool)
+
+// lidMappers returns PN-for-LID and LID-for-PN lookups backed by the client's LID store.
+func lidMappers(client *whatsmeow.Client) (pnForLID, lidForPN jidMapper) {
+	none := func(types.JID) (types.JID, bool) { return types.JID{}, false }
+	if client == nil || client.Store == nil || client.Store.LIDs == nil {
+		return none, none
+	}
+	pnForLID = func(lid types.JID) (types.JID, bool) {
+		pn, err := client.Store.LIDs.GetPNForLID(context.Background(), lid)
+		return pn.ToNonAD(), err == nil && !pn.IsEmpty()
+	}
+	lidForPN = func(pn types.JID) (types.JID, bool) {
+		lid, err := client.Store.LIDs.GetLIDForPN(context.Background(), pn)
+		return lid.ToNonAD(), err == nil && !lid.IsEmpty()
+	}
+	return pnForLID, lidForPN
+}
+
+// canonicalSenderID picks the canonical user ID for a sender under a strategy.
+// senderAlt is the alternate-server JID WhatsApp sometimes attaches.
+func canonicalSenderID(strategy IdentityStrategy, senderJID, senderAlt types.JID, pnForLID, lidForPN jidMapper) string {
 	senderJID = senderJID.ToNonAD()
 	senderAlt = senderAlt.ToNonAD()
 
@@ -92,14 +148,22 @@ func canonicalizeSender(client *whatsmeow.Client, senderJID types.JID, senderAlt
 		return ""
 	}
 
+	preferred, other, lookup := types.DefaultUserServer, types.HiddenUserServer, pnForLID
+	switch strategy {
+	case IdentityStrategyAsReceived:
+		return senderJID.User
+	case IdentityStrategyLID:
+		preferred, other, lookup = types.HiddenUserServer, types.DefaultUserServer, lidForPN
+	}
+
 	canonical := senderJID
-	if !senderAlt.IsEmpty() && senderAlt.Server == types.DefaultUserServer {
+	if !senderAlt.IsEmpty() && senderAlt.Server == preferred {
 		canonical = senderAlt
 	}
 
-	if canonical.Server == types.HiddenUserServer && client != nil && client.Store != nil && client.Store.LIDs != nil {
-		if pn, err := client.Store.LIDs.GetPNForLID(context.Background(), canonical); err == nil && !pn.IsEmpty() {
-			canonical = pn.ToNonAD()
+	if canonical.Server == other {
+		if mapped, ok := lookup(canonical); ok {
+			canonical = mapped
 		}
 	}
 
@@ -110,23 +174,72 @@ func canonicalizeSender(client *whatsmeow.Client, senderJID types.JID, senderAlt
 	return senderJID.User
 }
 
+// canonicalizeSender resolves a sender into a canonical personal identifier
+// using the configured identity strategy.
+func canonicalizeSender(client *whatsmeow.Client, senderJID types.JID, senderAlt types.JID) string {
+	pnForLID, lidForPN := lidMappers(client)
+	return canonicalSenderID(currentIdentityStrategy(), senderJID, senderAlt, pnForLID, lidForPN)
+}
+
 // canonicalizeChatID resolves personal chat IDs to canonical sender IDs.
 func canonicalizeChatID(client *whatsmeow.Client, chatJID types.JID) string {
 	normalized := chatJID.ToNonAD()
 	if normalized.IsEmpty() {
 		return ""
 	}
-	if normalized.Server == "g.us" {
-		return normalized.String()
+	if !isPersonalChat(normalized) {
+		return jid.NormalizeChat(normalized.String())
 	}
 	return canonicalizeSender(client, normalized, types.JID{})
 }
 
+// isPersonalChat reports whether a chat is a one-to-one chat, whose IDs are
+// aliased to a canonical person rather than kept whole.
+func isPersonalChat(chatJID types.JID) bool {
+	return jid.IsPersonalServer(chatJID.Server)
+}
+
 // chatAliasIDs returns aliases used for non-group chat ID normalization.
 func chatAliasIDs(client *whatsmeow.Client, chatJID types.JID, canonicalChatID string) []string {
 	normalized := chatJID.ToNonAD()
-	if normalized.IsEmpty() || normalized.Server == "g.us" {
+	if normalized.IsEmpty() || !isPersonalChat(normalized) {
 		return nil
 	}
 	return senderAliasIDs(client, normalized, types.JID{}, canonicalChatID)
 }
+
+// OwnUserIDs returns the linked account's own phone-number and LID user IDs.
+func OwnUserIDs(client *whatsmeow.Client) []string {
+	if client == nil || client.Store == nil || client.Store.ID == nil {
+		return nil
+	}
+	ids := []string{client.Store.ID.User}
+	if lid := client.Store.GetLID(); !lid.IsEmpty() && lid.User != client.Store.ID.User {
+		ids = append(ids, lid.User)
+	}
+	return ids
+}
+
+// SelfChatRecipient is the /api/send recipient alias for the account's own chat.
+const SelfChatRecipient = "me"
+
+// ownChatJID returns the linked account's own non-AD phone-number JID.
+func ownChatJID(client *whatsmeow.Client) (types.JID, bool) {
+	if client == nil || client.Store == nil || client.Store.ID == nil {
+		return types.JID{}, false
+	}
+	return client.Store.ID.ToNonAD(), true
+}
+
+// isSelfChat reports whether a canonical chat ID is the account's own chat.
+func isSelfChat(client *whatsmeow.Client, chatID string) bool {
+	if chatID == "" || !jid.IsPersonal(chatID) {
+		return false
+	}
+	for _, id := range OwnUserIDs(client) {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseIdentityStrategy(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want IdentityStrategy
+		ok   bool
+	}{
+		{"", IdentityStrategyPN, true},
+		{"pn", IdentityStrategyPN, true},
+		{" LID ", IdentityStrategyLID, true},
+		{"as-received", IdentityStrategyAsReceived, true},
+		{"phone", IdentityStrategyPN, false},
+	}
+	for _, tc := range cases {
+		got, ok := parseIdentityStrategy(tc.raw)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseIdentityStrategy(%q) = (%q, %v), want (%q, %v)", tc.raw, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestCanonicalSenderIDStrategies(t *testing.T) {
+	pn := types.NewJID("15551234567", types.DefaultUserServer)
+	lid := types.NewJID("987654321", types.HiddenUserServer)
+	pnForLID := func(j types.JID) (types.JID, bool) { return pn, j == lid }
+	lidForPN := func(j types.JID) (types.JID, bool) { return lid, j == pn }
+	unknown := func(types.JID) (types.JID, bool) { return types.JID{}, false }
+
+	cases := []struct {
+		name              string
+		strategy          IdentityStrategy
+		sender, senderAlt types.JID
+		pnForLID          jidMapper
+		lidForPN          jidMapper
+		want              string
+	}{
+		{"pn keeps phone", IdentityStrategyPN, pn, types.JID{}, pnForLID, lidForPN, "15551234567"},
+		{"pn resolves lid", IdentityStrategyPN, lid, types.JID{}, pnForLID, lidForPN, "15551234567"},
+		{"pn prefers phone alt", IdentityStrategyPN, lid, pn, unknown, unknown, "15551234567"},
+		{"pn unresolved lid", IdentityStrategyPN, lid, types.JID{}, unknown, unknown, "987654321"},
+		{"lid keeps lid", IdentityStrategyLID, lid, types.JID{}, pnForLID, lidForPN, "987654321"},
+		{"lid resolves phone", IdentityStrategyLID, pn, types.JID{}, pnForLID, lidForPN, "987654321"},
+		{"lid prefers lid alt", IdentityStrategyLID, pn, lid, unknown, unknown, "987654321"},
+		{"lid unresolved phone", IdentityStrategyLID, pn, types.JID{}, unknown, unknown, "15551234567"},
+		{"as-received lid", IdentityStrategyAsReceived, lid, pn, pnForLID, lidForPN, "987654321"},
+		{"as-received phone", IdentityStrategyAsReceived, pn, lid, pnForLID, lidForPN, "15551234567"},
+		{"device suffix dropped", IdentityStrategyPN, types.JID{User: "15551234567", Device: 3, Server: types.DefaultUserServer}, types.JID{}, unknown, unknown, "15551234567"},
+		{"empty sender", IdentityStrategyPN, types.JID{}, pn, pnForLID, lidForPN, ""},
+	}
+	for _, tc := range cases {
+		if got := canonicalSenderID(tc.strategy, tc.sender, tc.senderAlt, tc.pnForLID, tc.lidForPN); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/secrets"
+	"whatsapp-client/internal/storage"
+)
+
+// groupSizeTTL is how long a fetched group size is trusted.
+const groupSizeTTL = time.Hour
+
+// IngestRules decide which messages are stored at all, for users who only
+// care about a handful of chats. They apply to live messages and history
+// sync alike, before anything is written.
+type IngestRules struct {
+	// IgnoreStatus drops status updates (status@broadcast).
+	IgnoreStatus bool
+	// IgnoredIDs are chat JIDs and sender IDs whose messages are dropped.
+	IgnoredIDs map[string]struct{}
+	// MaxGroupSize drops messages from groups with more participants; zero
+	// means no limit.
+	MaxGroupSize int
+	// TextOnly keeps only text: media metadata is dropped, captions are kept,
+	// and media without a caption is not stored.
+	TextOnly bool
+	// Allowlist stores only chats opted in through /api/chats/{jid}/tracking.
+	Allowlist bool
+	// Untracked decides what happens to other chats in allowlist mode:
+	// UntrackedStream, UntrackedDrop or UntrackedRedact.
+	Untracked string
+	// Redactor reduces untracked messages under UntrackedRedact.
+	Redactor Redactor
+	// History limits how much of a history sync is stored.
+	History HistoryCaps
+
+	groupSizes *groupSizeCache
+}
+
+// Ingest modes accepted by WHATSAPP_INGEST_MODE.
+const (
+	IngestModeAll       = "all"
+	IngestModeAllowlist = "allowlist"
+)
+
+// Policies for untracked chats accepted by WHATSAPP_INGEST_UNTRACKED.
+const (
+	// UntrackedStream publishes live messages to event stream subscribers
+	// without storing them.
+	UntrackedStream = "stream"
+	UntrackedDrop   = "drop"
+	// UntrackedRedact stores live and history messages with hashed IDs and a
+	// truncated preview instead of their content.
+	UntrackedRedact = "redact"
+)
+
+// IngestRulesFromEnv loads WHATSAPP_INGEST_IGNORE_STATUS, WHATSAPP_INGEST_IGNORE_JIDS,
+// WHATSAPP_INGEST_MAX_GROUP_SIZE, WHATSAPP_INGEST_TEXT_ONLY, WHATSAPP_INGEST_MODE,
+// WHATSAPP_INGEST_UNTRACKED, WHATSAPP_INGEST_REDACTION_KEY and
+// WHATSAPP_INGEST_REDACTED_PREVIEW_CHARS, and the history caps read by
+// HistoryCapsFromEnv.
+func IngestRulesFromEnv() IngestRules {
+	rules := IngestRules{
+		IgnoreStatus: parseIngestBool("WHATSAPP_INGEST_IGNORE_STATUS"),
+		IgnoredIDs:   map[string]struct{}{},
+		TextOnly:     parseIngestBool("WHATSAPP_INGEST_TEXT_ONLY"),
+		Redactor:     Redactor{PreviewChars: DefaultRedactedPreviewChars},
+		History:      HistoryCapsFromEnv(),
+		groupSizes:   &groupSizeCache{sizes: map[string]groupSize{}},
+	}
+	if key, err := secrets.Lookup("WHATSAPP_INGEST_REDACTION_KEY"); err != nil {
+		fmt.Printf("Warning: failed to load WHATSAPP_INGEST_REDACTION_KEY: %v\n", err)
+	} else {
+		rules.Redactor.Key = []byte(key)
+	}
+	for _, part := range strings.Split(os.Getenv("WHATSAPP_INGEST_IGNORE_JIDS"), ",") {
+		if normalized := jid.NormalizeChat(part); normalized != "" {
+			rules.IgnoredIDs[normalized] = struct{}{}
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_INGEST_MAX_GROUP_SIZE")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			fmt.Printf("Warning: invalid WHATSAPP_INGEST_MAX_GROUP_SIZE=%q, ignoring\n", raw)
+		} else {
+			rules.MaxGroupSize = parsed
+		}
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("WHATSAPP_INGEST_MODE"))); mode {
+	case "", IngestModeAll:
+	case IngestModeAllowlist:
+		rules.Allowlist = true
+	default:
+		fmt.Printf("Warning: invalid WHATSAPP_INGEST_MODE=%q, storing all chats\n", mode)
+	}
+	switch untracked := strings.ToLower(strings.TrimSpace(os.Getenv("WHATSAPP_INGEST_UNTRACKED"))); untracked {
+	case "":
+		rules.Untracked = UntrackedStream
+	case UntrackedStream, UntrackedDrop, UntrackedRedact:
+		rules.Untracked = untracked
+	default:
+		fmt.Printf("Warning: invalid WHATSAPP_INGEST_UNTRACKED=%q, dropping untracked messages\n", untracked)
+		rules.Untracked = UntrackedDrop
+	}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_INGEST_REDACTED_PREVIEW_CHARS")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			fmt.Printf("Warning: invalid WHATSAPP_INGEST_REDACTED_PREVIEW_CHARS=%q, using %d\n", raw, DefaultRedactedPreviewChars)
+		} else {
+			rules.Redactor.PreviewChars = parsed
+		}
+	}
+	if rules.Allowlist && rules.Untracked == UntrackedRedact && len(rules.Redactor.Key) == 0 {
+		fmt.Println("Warning: WHATSAPP_INGEST_REDACTION_KEY is not set; redacted phone numbers can be recovered from their hashes")
+	}
+	return rules
+}
+
+// Mode names the rules' ingest mode.
+func (r IngestRules) Mode() string {
+	if r.Allowlist {
+		return IngestModeAllowlist
+	}
+	return IngestModeAll
+}
+
+// Stores reports whether a chat's messages are persisted. In allowlist mode a
+// chat whose tracking cannot be read is not stored.
+func (r IngestRules) Stores(messageStore *storage.MessageStore, chatID string) (bool, error) {
+	if !r.Allowlist {
+		return true, nil
+	}
+	return messageStore.IsChatTracked(chatID)
+}
+
+func parseIngestBool(name string) bool {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return false
+	}
+	parsed, err := strconv.ParseBool(raw)
+	if err != nil {
+		fmt.Printf("Warning: invalid %s=%q, treating as false\n", name, raw)
+		return false
+	}
+	return parsed
+}
+
+// IgnoresChat reports whether a chat is dropped regardless of its messages.
+// knownSize is the group's participant count when the caller has it, or
+// zero to look it up.
+func (r IngestRules) IgnoresChat(client *whatsmeow.Client, chat types.JID, chatID string, knownSize int) bool {
+	if r.IgnoreStatus && chat.User == types.StatusBroadcastJID.User && chat.Server == types.BroadcastServer {
+		return true
+	}
+	if _, ok := r.IgnoredIDs[chatID]; ok {
+		return true
+	}
+	if r.MaxGroupSize > 0 && chat.Server == types.GroupServer {
+		size := knownSize
+		if size == 0 {
+			size = r.groupSizes.get(client, chat)
+		}
+		// An unknown size never drops messages.
+		return size > r.MaxGroupSize
+	}
+	return false
+}
+
+// IgnoresSender reports whether messages from a sender are dropped.
+func (r IngestRules) IgnoresSender(senderID string) bool {
+	_, ok := r.IgnoredIDs[senderID]
+	return ok
+}
+
+// Filter applies text-only mode to a message's media and reports whether
+// anything is left to store.
+func (r IngestRules) Filter(content string, media storage.MessageMedia) (storage.MessageMedia, bool) {
+	if r.TextOnly {
+		media = storage.MessageMedia{}
+	}
+	return media, content != "" || media.MediaType != ""
+}
+
+type groupSize struct {
+	participants int
+	fetchedAt    time.Time
+}
+
+// groupSizeCache remembers group participant counts so the size limit does
+// not query WhatsApp for every message.
+type groupSizeCache struct {
+	mu    sync.Mutex
+	sizes map[string]groupSize
+}
+
+func (c *groupSizeCache) get(client *whatsmeow.Client, group types.JID) int {
+	if c == nil {
+		return 0
+	}
+	key := group.String()
+	c.mu.Lock()
+	cached, ok := c.sizes[key]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < groupSizeTTL {
+		return cached.participants
+	}
+	if client == nil {
+		return cached.participants
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info, err := client.GetGroupInfo(ctx, group)
+	if err != nil {
+		return cached.participants
+	}
+	c.mu.Lock()
+	c.sizes[key] = groupSize{participants: len(info.Participants), fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return len(info.Participants)
+}
@@ -0,0 +1,89 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/storage"
+)
+
+func TestIngestRulesFromEnv(t *testing.T) {
+	t.Setenv("WHATSAPP_INGEST_IGNORE_STATUS", "true")
+	t.Setenv("WHATSAPP_INGEST_IGNORE_JIDS", "15551234567@s.whatsapp.net, 120363000000000000@g.us,")
+	t.Setenv("WHATSAPP_INGEST_MAX_GROUP_SIZE", "50")
+	t.Setenv("WHATSAPP_INGEST_TEXT_ONLY", "1")
+
+	rules := IngestRulesFromEnv()
+	if !rules.IgnoreStatus || !rules.TextOnly || rules.MaxGroupSize != 50 {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if !rules.IgnoresSender("15551234567") || rules.IgnoresSender("15557654321") {
+		t.Fatal("expected only the listed sender to be ignored")
+	}
+}
+
+func TestIngestRulesIgnoresChat(t *testing.T) {
+	rules := IngestRules{
+		IgnoreStatus: true,
+		IgnoredIDs:   map[string]struct{}{"15551234567": {}},
+		MaxGroupSize: 10,
+	}
+	group := types.NewJID("120363000000000000", types.GroupServer)
+
+	tests := []struct {
+		name      string
+		chat      types.JID
+		chatID    string
+		knownSize int
+		want      bool
+	}{
+		{"status broadcast", types.StatusBroadcastJID, "status@broadcast", 0, true},
+		{"ignored personal chat", types.NewJID("15551234567", types.DefaultUserServer), "15551234567", 0, true},
+		{"other personal chat", types.NewJID("15557654321", types.DefaultUserServer), "15557654321", 0, false},
+		{"large group", group, group.String(), 11, true},
+		{"small group", group, group.String(), 10, false},
+		{"unknown group size", group, group.String(), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.IgnoresChat(nil, tt.chat, tt.chatID, tt.knownSize); got != tt.want {
+				t.Fatalf("IgnoresChat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngestRulesTextOnlyFilter(t *testing.T) {
+	image := storage.MessageMedia{MediaType: "image", Filename: "a.jpg"}
+
+	if media, keep := (IngestRules{}).Filter("", image); !keep || media.MediaType != "image" {
+		t.Fatalf("expected media to be kept without text-only mode, got %+v %v", media, keep)
+	}
+	textOnly := IngestRules{TextOnly: true}
+	if media, keep := textOnly.Filter("caption", image); !keep || media.MediaType != "" {
+		t.Fatalf("expected caption without media, got %+v %v", media, keep)
+	}
+	if _, keep := textOnly.Filter("", image); keep {
+		t.Fatal("expected uncaptioned media to be dropped in text-only mode")
+	}
+}
+
+func TestIngestRulesAllowlistMode(t *testing.T) {
+	t.Setenv("WHATSAPP_INGEST_MODE", "allowlist")
+	t.Setenv("WHATSAPP_INGEST_UNTRACKED", "drop")
+	rules := IngestRulesFromEnv()
+	if !rules.Allowlist || rules.Untracked != UntrackedDrop || rules.Mode() != IngestModeAllowlist {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+
+	t.Setenv("WHATSAPP_INGEST_MODE", "")
+	t.Setenv("WHATSAPP_INGEST_UNTRACKED", "")
+	rules = IngestRulesFromEnv()
+	if rules.Allowlist || rules.Untracked != UntrackedStream || rules.Mode() != IngestModeAll {
+		t.Fatalf("unexpected default rules: %+v", rules)
+	}
+	// Without the allowlist every chat is stored and the store is not consulted.
+	if stored, err := rules.Stores(nil, "15551234567"); !stored || err != nil {
+		t.Fatalf("Stores = %v, %v; want true, nil", stored, err)
+	}
+}
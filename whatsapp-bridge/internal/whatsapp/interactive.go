@@ -0,0 +1,33 @@
+package whatsapp
+
+import (
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"whatsapp-client/internal/storage"
+)
+
+// extractSelection returns the list row or button picked by an interactive
+// reply, or a zero Selection for any other message.
+func extractSelection(msg *waProto.Message) storage.Selection {
+	if list := msg.GetListResponseMessage(); list != nil {
+		return storage.Selection{
+			Kind:  storage.SelectionList,
+			ID:    list.GetSingleSelectReply().GetSelectedRowID(),
+			Title: list.GetTitle(),
+		}
+	}
+	if buttons := msg.GetButtonsResponseMessage(); buttons != nil {
+		return storage.Selection{
+			Kind:  storage.SelectionButton,
+			ID:    buttons.GetSelectedButtonID(),
+			Title: buttons.GetSelectedDisplayText(),
+		}
+	}
+	if template := msg.GetTemplateButtonReplyMessage(); template != nil {
+		return storage.Selection{
+			Kind:  storage.SelectionButton,
+			ID:    template.GetSelectedID(),
+			Title: template.GetSelectedDisplayText(),
+		}
+	}
+	return storage.Selection{}
+}
@@ -0,0 +1,41 @@
+package whatsapp
+
+import (
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+	"whatsapp-client/internal/storage"
+)
+
+func TestExtractSelectionReadsListAndButtonReplies(t *testing.T) {
+	list := &waProto.Message{ListResponseMessage: &waProto.ListResponseMessage{
+		Title:             proto.String("Tomorrow 10:00"),
+		SingleSelectReply: &waProto.ListResponseMessage_SingleSelectReply{SelectedRowID: proto.String("slot-2")},
+		ContextInfo:       &waProto.ContextInfo{StanzaID: proto.String("LIST1")},
+	}}
+	want := storage.Selection{Kind: storage.SelectionList, ID: "slot-2", Title: "Tomorrow 10:00"}
+	if got := extractSelection(list); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if content := extractTextContent(list); content != "Tomorrow 10:00" {
+		t.Fatalf("expected list reply content to be its title, got %q", content)
+	}
+	if quoted := extractQuotedMessageID(list); quoted != "LIST1" {
+		t.Fatalf("expected list reply to quote LIST1, got %q", quoted)
+	}
+
+	button := &waProto.Message{TemplateButtonReplyMessage: &waProto.TemplateButtonReplyMessage{
+		SelectedID:          proto.String("confirm"),
+		SelectedDisplayText: proto.String("Confirm"),
+	}}
+	want = storage.Selection{Kind: storage.SelectionButton, ID: "confirm", Title: "Confirm"}
+	if got := extractSelection(button); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	plain := &waProto.Message{Conversation: proto.String("hello")}
+	if got := extractSelection(plain); got != (storage.Selection{}) {
+		t.Fatalf("expected no selection for plain text, got %+v", got)
+	}
+}
@@ -0,0 +1,164 @@
+package whatsapp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minLanguageLetters is the fewest letters worth detecting a language from;
+// replies like "ok" or "haha" say nothing about it.
+const minLanguageLetters = 12
+
+// scriptLanguages maps scripts written mainly in one language to that
+// language's ISO 639-1 code.
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Thai, "th"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Georgian, "ka"},
+	{unicode.Armenian, "hy"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Bengali, "bn"},
+	{unicode.Gujarati, "gu"},
+	{unicode.Gurmukhi, "pa"},
+	{unicode.Tamil, "ta"},
+	{unicode.Telugu, "te"},
+	{unicode.Kannada, "kn"},
+	{unicode.Malayalam, "ml"},
+}
+
+// latinStopwords are frequent words that tell Latin-script languages apart.
+// Words shared by several languages count for each of them.
+var latinStopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "to", "of", "it", "that", "this", "for", "with", "have", "was", "what", "not", "will", "can", "my", "your", "we", "thanks"},
+	"es": {"el", "la", "los", "las", "que", "de", "y", "es", "en", "un", "una", "por", "para", "con", "no", "lo", "pero", "muy", "está", "gracias", "hola", "qué", "estoy", "usted"},
+	"pt": {"o", "os", "as", "que", "de", "e", "é", "em", "um", "uma", "não", "com", "para", "você", "obrigado", "obrigada", "está", "mas", "muito", "isso", "tudo", "bem"},
+	"fr": {"le", "la", "les", "et", "est", "un", "une", "des", "que", "pas", "pour", "vous", "je", "tu", "nous", "avec", "dans", "merci", "c'est", "bonjour", "oui", "suis"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "du", "wir", "sie", "ein", "eine", "mit", "auf", "für", "danke", "auch", "was", "wie", "es", "bin", "habe"},
+	"it": {"il", "la", "che", "di", "e", "è", "non", "un", "una", "per", "con", "sono", "sei", "ciao", "grazie", "anche", "ma", "questo", "come", "molto"},
+	"nl": {"de", "het", "een", "en", "is", "niet", "ik", "je", "we", "van", "met", "op", "dat", "voor", "maar", "ook", "bedankt", "wat", "hoe", "graag"},
+	"id": {"yang", "dan", "di", "ini", "itu", "tidak", "saya", "kamu", "dengan", "untuk", "ada", "apa", "sudah", "akan", "terima", "kasih", "bisa", "mau"},
+	"tr": {"ve", "bir", "bu", "da", "de", "ne", "için", "ile", "çok", "değil", "ben", "sen", "var", "yok", "teşekkür", "merhaba", "nasıl", "mi"},
+}
+
+// latinStopwordIndex maps each stopword to the languages it belongs to.
+var latinStopwordIndex = func() map[string][]string {
+	index := make(map[string][]string)
+	for language, words := range latinStopwords {
+		for _, word := range words {
+			index[word] = append(index[word], language)
+		}
+	}
+	return index
+}()
+
+// detectLanguage returns the ISO 639-1 code of the language text is written
+// in, or "" when the text is too short or ambiguous to tell. Scripts used by
+// one main language decide on their own; Latin text is told apart by its
+// most frequent words.
+func detectLanguage(text string) string {
+	var latin, han, kana, arabic, cyrillic, letters int
+	scriptCounts := make([]int, len(scriptLanguages))
+	var persian, urdu, ukrainian bool
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+			persian = persian || strings.ContainsRune("پچژگ", r)
+			urdu = urdu || strings.ContainsRune("ٹڈڑںے", r)
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			ukrainian = ukrainian || strings.ContainsRune("іїєґІЇЄҐ", r)
+		default:
+			for i, entry := range scriptLanguages {
+				if unicode.Is(entry.script, r) {
+					scriptCounts[i]++
+					break
+				}
+			}
+		}
+	}
+	if letters < minLanguageLetters {
+		return ""
+	}
+
+	// Japanese mixes kanji with kana, so any kana makes Han text Japanese.
+	best, language := latin, ""
+	if han+kana > best {
+		best, language = han+kana, "zh"
+		if kana > 0 {
+			language = "ja"
+		}
+	}
+	if arabic > best {
+		best, language = arabic, "ar"
+		if urdu {
+			language = "ur"
+		} else if persian {
+			language = "fa"
+		}
+	}
+	if cyrillic > best {
+		best, language = cyrillic, "ru"
+		if ukrainian {
+			language = "uk"
+		}
+	}
+	for i, count := range scriptCounts {
+		if count > best {
+			best, language = count, scriptLanguages[i].language
+		}
+	}
+	if language != "" {
+		return language
+	}
+	return detectLatinLanguage(text)
+}
+
+// detectLatinLanguage scores text against each language's stopwords. It needs
+// two hits and a clear winner.
+func detectLatinLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	scores := make(map[string]int)
+	for _, word := range words {
+		for _, language := range latinStopwordIndex[word] {
+			scores[language]++
+		}
+	}
+	best, runnerUp, language := 0, 0, ""
+	for candidate, score := range scores {
+		switch {
+		case score > best:
+			best, runnerUp, language = score, best, candidate
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+	if best < 2 || best == runnerUp {
+		return ""
+	}
+	return language
+}
+
+// LanguageDetectionFromEnv reports whether WHATSAPP_LANGUAGE_DETECTION turns
+// on tagging stored messages, and through their incoming ones chats, with
+// their language.
+func LanguageDetectionFromEnv() bool {
+	return parseIngestBool("WHATSAPP_LANGUAGE_DETECTION")
+}
@@ -0,0 +1,30 @@
+package whatsapp
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"ok", ""},
+		{"Can you send me the invoice for this month?", "en"},
+		{"Hola, ¿me puedes enviar la factura por favor? Gracias", "es"},
+		{"Olá, você pode me enviar a fatura? Muito obrigado", "pt"},
+		{"Bonjour, est-ce que vous pouvez m'envoyer la facture? Merci", "fr"},
+		{"Kannst du mir bitte die Rechnung schicken? Danke", "de"},
+		{"Можете прислать счёт за этот месяц?", "ru"},
+		{"Чи можете ви надіслати рахунок?", "uk"},
+		{"هل يمكنك إرسال الفاتورة من فضلك", "ar"},
+		{"請把這個月的發票寄給我好嗎謝謝", "zh"},
+		{"今月の請求書を送っていただけますか", "ja"},
+		{"이번 달 청구서를 보내주실 수 있나요", "ko"},
+		{"क्या आप मुझे इस महीने का बिल भेज सकते हैं", "hi"},
+		{"Invoice 2026-03 #4471 ABC-DEF-GHI", ""},
+	}
+	for _, tc := range cases {
+		if got := detectLanguage(tc.text); got != tc.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
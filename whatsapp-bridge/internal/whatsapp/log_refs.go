@@ -1,11 +1,42 @@
 package whatsapp
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"strings"
+
+	"go.mau.fi/whatsmeow"
+	waLog "go.mau.fi/whatsmeow/util/log"
 )
 
+type requestIDKey struct{}
+
+// WithRequestID marks ctx as serving the API request with the given ID, so
+// the WhatsApp operations it drives are logged under that ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the API request ID recorded by WithRequestID, if any.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// operationLogger returns the client logger, scoped to the request driving
+// the operation when there is one.
+func operationLogger(ctx context.Context, client *whatsmeow.Client) waLog.Logger {
+	logger := client.Log
+	if logger == nil {
+		logger = waLog.Noop
+	}
+	if requestID := RequestID(ctx); requestID != "" {
+		return logger.Sub("request_id=" + requestID)
+	}
+	return logger
+}
+
 // obfuscatedRef returns a stable, non-reversible short reference for logs.
 func obfuscatedRef(prefix string, raw string) string {
 	cleaned := strings.TrimSpace(raw)
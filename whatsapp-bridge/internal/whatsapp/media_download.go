@@ -1,13 +1,18 @@
 package whatsapp
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
 	"go.mau.fi/whatsmeow"
+	"whatsapp-client/internal/mediacrypt"
 	"whatsapp-client/internal/storage"
 )
 
@@ -68,19 +73,57 @@ func DownloadMedia(client *whatsmeow.Client, messageStore *storage.MessageStore,
 		return false, "", "", "", fmt.Errorf("not a media message")
 	}
 
-	chatDir := filepath.Join(runtimePaths.HotMediaRoot, strings.ReplaceAll(chatJID, ":", "_"))
+	quarantine, err := MediaPolicyFromEnv().CheckDownload(mediaType, filename, fileLength)
+	if err != nil {
+		return false, "", "", "", err
+	}
+	keys, err := mediacrypt.KeyringFromEnv()
+	if err != nil {
+		return false, "", "", "", fmt.Errorf("invalid media encryption key: %w", err)
+	}
+
+	mediaRoot := runtimePaths.HotMediaRoot
+	if quarantine {
+		mediaRoot = filepath.Join(runtimePaths.HotMediaRoot, "quarantine")
+	}
+	chatDir := filepath.Join(mediaRoot, sanitizeMediaFilename(strings.ReplaceAll(chatJID, ":", "_"), "chat"))
 	if err := os.MkdirAll(chatDir, 0o755); err != nil {
 		return false, "", "", "", fmt.Errorf("failed to create chat directory: %v", err)
 	}
 
-	localPath := filepath.Join(chatDir, filename)
+	filename = sanitizeMediaFilename(filename, mediaType)
+	localPath, err := joinWithinDir(chatDir, filename)
+	if err != nil {
+		return false, "", "", "", err
+	}
 	absPath, err := filepath.Abs(localPath)
 	if err != nil {
 		return false, "", "", "", fmt.Errorf("failed to get absolute path: %v", err)
 	}
 
 	if _, err := os.Stat(localPath); err == nil {
-		return true, mediaType, filename, absPath, nil
+		matches := len(fileSHA256) == 0
+		if !matches {
+			matches, err = fileMatchesSHA256(localPath, fileSHA256, keys)
+		}
+		if err == nil && matches {
+			// Files downloaded before encryption was enabled are encrypted
+			// the next time they are requested.
+			if keys != nil {
+				if encrypted, _ := mediacrypt.IsEncrypted(localPath); !encrypted {
+					err = keys.EncryptFile(localPath)
+				}
+			}
+			if err != nil {
+				return false, "", "", "", fmt.Errorf("failed to encrypt media file: %v", err)
+			}
+			_ = messageStore.MarkMediaDownloaded(messageID, chatJID, absPath)
+			return true, mediaType, filename, absPath, nil
+		}
+		fmt.Printf(
+			"Existing media file failed checksum verification, re-downloading (message_ref=%s)\n",
+			obfuscatedMessageRef(messageID),
+		)
 	}
 
 	if url == "" || len(mediaKey) == 0 || len(fileSHA256) == 0 || len(fileEncSHA256) == 0 || fileLength == 0 {
@@ -113,24 +156,205 @@ func DownloadMedia(client *whatsmeow.Client, messageStore *storage.MessageStore,
 		MediaType:     waMediaType,
 	}
 
-	mediaData, err := client.Download(context.Background(), downloader)
+	fileMode := os.FileMode(0o644)
+	if quarantine {
+		fileMode = 0o600
+	}
+	size, err := downloadMediaFileAtomic(context.Background(), client, downloader, localPath, fileSHA256, fileMode, keys)
+	if err != nil && needsMediaRetry(err) {
+		size, err = retryExpiredMediaDownload(client, messageStore, messageID, chatJID, downloader, localPath, fileMode, keys, err)
+	}
 	if err != nil {
-		return false, "", "", "", fmt.Errorf("failed to download media: %v", err)
+		return false, "", "", "", classifySendError("failed to download media", err)
 	}
-
-	if err := os.WriteFile(localPath, mediaData, 0o644); err != nil {
-		return false, "", "", "", fmt.Errorf("failed to save media file: %v", err)
+	if quarantine {
+		fmt.Printf("Quarantined executable document (message_ref=%s)\n", obfuscatedMessageRef(messageID))
+	}
+	if err := messageStore.MarkMediaDownloaded(messageID, chatJID, absPath); err != nil {
+		fmt.Printf("Warning: failed to record media download (message_ref=%s): %v\n", obfuscatedMessageRef(messageID), err)
 	}
 
 	fmt.Printf(
 		"Successfully downloaded %s media (message_ref=%s, size=%d bytes)\n",
 		mediaType,
 		obfuscatedMessageRef(messageID),
-		len(mediaData),
+		size,
 	)
 	return true, mediaType, filename, absPath, nil
 }
 
+// maxMediaFilenameBytes caps sanitized filenames well below common filesystem limits.
+const maxMediaFilenameBytes = 128
+
+// windowsReservedNames are device names that cannot be used as file names on Windows.
+var windowsReservedNames = map[string]struct{}{
+	"CON": {}, "PRN": {}, "AUX": {}, "NUL": {},
+	"COM1": {}, "COM2": {}, "COM3": {}, "COM4": {}, "COM5": {}, "COM6": {}, "COM7": {}, "COM8": {}, "COM9": {},
+	"LPT1": {}, "LPT2": {}, "LPT3": {}, "LPT4": {}, "LPT5": {}, "LPT6": {}, "LPT7": {}, "LPT8": {}, "LPT9": {},
+}
+
+// sanitizeMediaFilename reduces a sender-provided filename to a single safe path
+// component, stripping directories, reserved characters and excess length.
+func sanitizeMediaFilename(name string, fallback string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	var builder strings.Builder
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			continue
+		case strings.ContainsRune(`<>:"|?*`, r):
+			builder.WriteRune('_')
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	cleaned := strings.Trim(builder.String(), " .")
+	if cleaned == "" {
+		cleaned = fallback
+	}
+
+	base := cleaned
+	if dot := strings.Index(base, "."); dot >= 0 {
+		base = base[:dot]
+	}
+	if _, reserved := windowsReservedNames[strings.ToUpper(base)]; reserved {
+		cleaned = "_" + cleaned
+	}
+
+	if len(cleaned) > maxMediaFilenameBytes {
+		ext := filepath.Ext(cleaned)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		stem := strings.TrimSuffix(cleaned, ext)
+		limit := maxMediaFilenameBytes - len(ext)
+		for limit > 0 && !utf8.RuneStart(stem[limit]) {
+			limit--
+		}
+		cleaned = stem[:limit] + ext
+	}
+	return cleaned
+}
+
+// joinWithinDir joins name onto dir and rejects results that escape dir.
+func joinWithinDir(dir string, name string) (string, error) {
+	joined := filepath.Join(dir, name)
+	rel, err := filepath.Rel(dir, joined)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("media filename resolves outside the chat directory")
+	}
+	return joined, nil
+}
+
+// fileMatchesSHA256 reports whether the file at path hashes to the expected
+// digest, decrypting it first when it is encrypted.
+func fileMatchesSHA256(path string, expected []byte, keys *mediacrypt.Keyring) (bool, error) {
+	file, _, err := mediacrypt.Open(path, keys)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return false, err
+	}
+	return bytes.Equal(hasher.Sum(nil), expected), nil
+}
+
+// downloadMediaFileAtomic streams media into a temp file next to path, verifies it,
+// fsyncs it and renames it into place so readers never observe partial files.
+// With keys set the media is encrypted as it is written, so its plaintext is
+// never stored on disk.
+func downloadMediaFileAtomic(ctx context.Context, client *whatsmeow.Client, downloader *MediaDownloader, path string, expectedSHA256 []byte, mode os.FileMode, keys *mediacrypt.Keyring) (int64, error) {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".download-*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	var size int64
+	if keys != nil {
+		size, err = downloadMediaEncrypted(ctx, client, downloader, tmpFile, expectedSHA256, keys)
+	} else {
+		err = client.DownloadToFile(ctx, downloader, tmpFile)
+	}
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if err != nil {
+		tmpFile.Close()
+		return 0, err
+	}
+	info, err := tmpFile.Stat()
+	if err != nil {
+		tmpFile.Close()
+		return 0, err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return 0, err
+	}
+
+	if keys == nil {
+		size = info.Size()
+		if len(expectedSHA256) > 0 {
+			matches, err := fileMatchesSHA256(tmpPath, expectedSHA256, nil)
+			if err != nil {
+				return 0, err
+			}
+			if !matches {
+				return 0, fmt.Errorf("media checksum mismatch")
+			}
+		}
+	}
+
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// downloadMediaEncrypted downloads media into memory and encrypts it into
+// dst, hashing the plaintext on its way to the encryptor so it is verified
+// without ever being written out. It returns the plaintext size.
+func downloadMediaEncrypted(ctx context.Context, client *whatsmeow.Client, downloader *MediaDownloader, dst io.Writer, expectedSHA256 []byte, keys *mediacrypt.Keyring) (int64, error) {
+	data, err := client.Download(ctx, downloader)
+	if err != nil {
+		return 0, err
+	}
+	hasher := sha256.New()
+	if err := keys.Encrypt(dst, io.TeeReader(bytes.NewReader(data), hasher), int64(len(data))); err != nil {
+		return 0, fmt.Errorf("failed to encrypt media file: %w", err)
+	}
+	if len(expectedSHA256) > 0 && !bytes.Equal(hasher.Sum(nil), expectedSHA256) {
+		return 0, fmt.Errorf("media checksum mismatch")
+	}
+	return int64(len(data)), nil
+}
+
+// readMediaFile reads a local media file, decrypting it when it was encrypted
+// at rest.
+func readMediaFile(path string) ([]byte, error) {
+	keys, err := mediacrypt.KeyringFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("invalid media encryption key: %w", err)
+	}
+	file, _, err := mediacrypt.Open(path, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
 // extractDirectPathFromURL derives a WhatsApp direct path from media URL.
 func extractDirectPathFromURL(url string) string {
 	parts := strings.SplitN(url, ".net/", 2)
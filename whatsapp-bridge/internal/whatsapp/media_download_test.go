@@ -0,0 +1,60 @@
+package whatsapp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeMediaFilename(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain", input: "report.pdf", want: "report.pdf"},
+		{name: "unix traversal", input: "../../etc/passwd", want: "passwd"},
+		{name: "windows traversal", input: `..\..\Windows\system.ini`, want: "system.ini"},
+		{name: "reserved characters", input: `inv<o>ice:"2024"|?*.pdf`, want: "inv_o_ice__2024____.pdf"},
+		{name: "control characters", input: "bad\x00name\n.txt", want: "badname.txt"},
+		{name: "dots only", input: "..", want: "document"},
+		{name: "empty", input: "", want: "document"},
+		{name: "leading and trailing dots", input: " .hidden. ", want: "hidden"},
+		{name: "windows device", input: "CON.txt", want: "_CON.txt"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sanitizeMediaFilename(tc.input, "document"); got != tc.want {
+				t.Fatalf("sanitizeMediaFilename(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeMediaFilenameCapsLengthAndKeepsExtension(t *testing.T) {
+	got := sanitizeMediaFilename(strings.Repeat("é", 200)+".pdf", "document")
+	if len(got) > maxMediaFilenameBytes {
+		t.Fatalf("expected at most %d bytes, got %d", maxMediaFilenameBytes, len(got))
+	}
+	if !strings.HasSuffix(got, ".pdf") {
+		t.Fatalf("expected extension to be preserved, got %q", got)
+	}
+	if !utf8.ValidString(got) {
+		t.Fatalf("expected valid UTF-8 truncation, got %q", got)
+	}
+}
+
+func TestJoinWithinDirRejectsEscapes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "chat")
+
+	if _, err := joinWithinDir(dir, "photo.jpg"); err != nil {
+		t.Fatalf("unexpected error for plain name: %v", err)
+	}
+	for _, name := range []string{"..", "../other/photo.jpg", ""} {
+		if _, err := joinWithinDir(dir, name); err == nil {
+			t.Fatalf("expected error for %q", name)
+		}
+	}
+}
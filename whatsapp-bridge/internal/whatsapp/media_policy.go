@@ -0,0 +1,235 @@
+package whatsapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"whatsapp-client/internal/storage"
+)
+
+const (
+	defaultMaxUploadBytes   int64 = 100 << 20
+	defaultMaxDownloadBytes int64 = 100 << 20
+
+	executablePolicyAllow      = "allow"
+	executablePolicyRefuse     = "refuse"
+	executablePolicyQuarantine = "quarantine"
+
+	MediaErrorTooLarge       = "media_too_large"
+	MediaErrorTypeBlocked    = "media_type_blocked"
+	MediaErrorPathNotAllowed = "media_path_not_allowed"
+	MediaErrorGIFNeedsVideo  = "gif_requires_mp4"
+)
+
+// executableExtensions lists document extensions treated as executable content.
+var executableExtensions = map[string]struct{}{
+	"apk": {}, "app": {}, "bat": {}, "cmd": {}, "com": {}, "cpl": {}, "deb": {},
+	"dll": {}, "dmg": {}, "exe": {}, "hta": {}, "jar": {}, "js": {}, "lnk": {},
+	"msi": {}, "pkg": {}, "ps1": {}, "rpm": {}, "scr": {}, "sh": {}, "vbs": {},
+	"wsf": {},
+}
+
+// MediaPolicyError is a structured media policy rejection surfaced to API callers.
+type MediaPolicyError struct {
+	Code    string
+	Message string
+	Limit   int64
+}
+
+func (e *MediaPolicyError) Error() string {
+	return e.Message
+}
+
+// MediaPolicy caps media sizes and decides how executable documents are handled.
+type MediaPolicy struct {
+	MaxUploadBytes   int64
+	MaxDownloadBytes int64
+	ExecutablePolicy string
+	AllowedRoots     []string
+}
+
+func parseByteLimitEnv(name string, fallback int64) int64 {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || parsed <= 0 {
+		fmt.Printf("Warning: invalid %s=%q, using %d\n", name, raw, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// MediaPolicyFromEnv loads media limits and the executable document policy.
+func MediaPolicyFromEnv() MediaPolicy {
+	executablePolicy := strings.ToLower(strings.TrimSpace(os.Getenv("WHATSAPP_MEDIA_EXECUTABLE_POLICY")))
+	switch executablePolicy {
+	case executablePolicyAllow, executablePolicyQuarantine:
+	default:
+		executablePolicy = executablePolicyRefuse
+	}
+
+	return MediaPolicy{
+		MaxUploadBytes:   parseByteLimitEnv("WHATSAPP_MEDIA_MAX_UPLOAD_BYTES", defaultMaxUploadBytes),
+		MaxDownloadBytes: parseByteLimitEnv("WHATSAPP_MEDIA_MAX_DOWNLOAD_BYTES", defaultMaxDownloadBytes),
+		ExecutablePolicy: executablePolicy,
+		AllowedRoots:     allowedMediaRootsFromEnv(),
+	}
+}
+
+// allowedMediaRootsFromEnv returns directories outbound media may be read from.
+// Without explicit configuration only the runtime media directory and its
+// sibling outbox are allowed, keeping the device and message databases out of reach.
+func allowedMediaRootsFromEnv() []string {
+	var roots []string
+	for _, part := range strings.Split(os.Getenv("WHATSAPP_MEDIA_ALLOWED_ROOTS"), ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			roots = append(roots, trimmed)
+		}
+	}
+	if len(roots) > 0 {
+		return roots
+	}
+
+	runtimePaths, err := storage.ResolveRuntimePathsFromEnv()
+	if err != nil {
+		return nil
+	}
+	return []string{
+		runtimePaths.HotMediaRoot,
+		filepath.Join(runtimePaths.PersistentUserStorePath, "outbox"),
+	}
+}
+
+// resolvePathWithinRoots resolves symlinks in mediaPath and returns the real
+// path only when it is located inside one of the allowed root directories.
+func resolvePathWithinRoots(mediaPath string, roots []string) (string, error) {
+	notAllowed := &MediaPolicyError{
+		Code:    MediaErrorPathNotAllowed,
+		Message: "media path is outside the allowed media directories",
+	}
+
+	trimmed := strings.TrimSpace(mediaPath)
+	if trimmed == "" {
+		return "", notAllowed
+	}
+	for _, segment := range strings.FieldsFunc(filepath.ToSlash(trimmed), func(r rune) bool { return r == '/' }) {
+		if segment == ".." {
+			return "", notAllowed
+		}
+	}
+
+	absPath, err := filepath.Abs(trimmed)
+	if err != nil {
+		return "", notAllowed
+	}
+	resolvedPath, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading media file: %w", err)
+	}
+
+	for _, root := range roots {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		resolvedRoot, err := filepath.EvalSymlinks(absRoot)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(resolvedRoot, resolvedPath)
+		if err != nil {
+			continue
+		}
+		if rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolvedPath, nil
+		}
+	}
+	return "", notAllowed
+}
+
+// isExecutableFilename reports whether a filename carries an executable extension.
+func isExecutableFilename(filename string) bool {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	_, ok := executableExtensions[ext]
+	return ok
+}
+
+// CheckUpload validates a local file against the allowed roots and the outbound
+// size and type policy, returning the resolved path that is safe to read.
+func (p MediaPolicy) CheckUpload(mediaPath string) (string, error) {
+	resolvedPath, err := resolvePathWithinRoots(mediaPath, p.AllowedRoots)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(resolvedPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading media file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("media path is a directory")
+	}
+	if info.Size() > p.MaxUploadBytes {
+		return "", &MediaPolicyError{
+			Code:    MediaErrorTooLarge,
+			Message: fmt.Sprintf("media file is %d bytes, exceeding the %d byte upload limit", info.Size(), p.MaxUploadBytes),
+			Limit:   p.MaxUploadBytes,
+		}
+	}
+	if p.ExecutablePolicy != executablePolicyAllow && isExecutableFilename(resolvedPath) {
+		return "", &MediaPolicyError{
+			Code:    MediaErrorTypeBlocked,
+			Message: "executable document types cannot be sent",
+		}
+	}
+	return resolvedPath, nil
+}
+
+// CheckGIF validates media sent as a looping GIF. WhatsApp plays GIFs as
+// muted MP4 videos, so .gif files have to be converted first.
+func CheckGIF(mediaPath string) error {
+	if mediaPath == "" {
+		return &MediaPolicyError{Code: MediaErrorGIFNeedsVideo, Message: "is_gif requires a media path"}
+	}
+	switch ext := strings.ToLower(filepath.Ext(mediaPath)); {
+	case ext == ".gif":
+		return &MediaPolicyError{
+			Code:    MediaErrorGIFNeedsVideo,
+			Message: ".gif files must be converted to MP4 before sending as a GIF",
+		}
+	case ext != ".mp4":
+		return &MediaPolicyError{Code: MediaErrorGIFNeedsVideo, Message: "is_gif requires an MP4 video"}
+	}
+	return nil
+}
+
+// CheckDownload validates inbound media metadata before it is fetched. It
+// returns true when the file must be quarantined rather than stored normally.
+func (p MediaPolicy) CheckDownload(mediaType string, filename string, fileLength uint64) (bool, error) {
+	if fileLength > uint64(p.MaxDownloadBytes) {
+		return false, &MediaPolicyError{
+			Code:    MediaErrorTooLarge,
+			Message: fmt.Sprintf("media is %d bytes, exceeding the %d byte download limit", fileLength, p.MaxDownloadBytes),
+			Limit:   p.MaxDownloadBytes,
+		}
+	}
+	if mediaType != "document" || !isExecutableFilename(filename) {
+		return false, nil
+	}
+	switch p.ExecutablePolicy {
+	case executablePolicyAllow:
+		return false, nil
+	case executablePolicyQuarantine:
+		return true, nil
+	default:
+		return false, &MediaPolicyError{
+			Code:    MediaErrorTypeBlocked,
+			Message: "executable document downloads are disabled",
+		}
+	}
+}
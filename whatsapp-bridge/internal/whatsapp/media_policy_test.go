@@ -0,0 +1,100 @@
+package whatsapp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+}
+
+func assertPathNotAllowed(t *testing.T, err error) {
+	t.Helper()
+	var policyErr *MediaPolicyError
+	if !errors.As(err, &policyErr) || policyErr.Code != MediaErrorPathNotAllowed {
+		t.Fatalf("expected %s error, got %v", MediaErrorPathNotAllowed, err)
+	}
+}
+
+func TestResolvePathWithinRootsAllowsFileInsideRoot(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "chat", "photo.jpg")
+	writeTestFile(t, target)
+
+	resolved, err := resolvePathWithinRoots(target, []string{root})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(target)
+	if resolved != want {
+		t.Fatalf("unexpected resolved path: got %q want %q", resolved, want)
+	}
+}
+
+func TestResolvePathWithinRootsRejectsFileOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "whatsapp.db")
+	writeTestFile(t, outside)
+
+	_, err := resolvePathWithinRoots(outside, []string{root})
+	assertPathNotAllowed(t, err)
+}
+
+func TestResolvePathWithinRootsRejectsTraversal(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "media")
+	writeTestFile(t, filepath.Join(parent, "whatsapp.db"))
+	writeTestFile(t, filepath.Join(root, "photo.jpg"))
+
+	_, err := resolvePathWithinRoots(filepath.Join(root, "..", "whatsapp.db"), []string{root})
+	assertPathNotAllowed(t, err)
+}
+
+func TestResolvePathWithinRootsRejectsSymlinkEscape(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	writeTestFile(t, outside)
+
+	link := filepath.Join(root, "link.txt")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	_, err := resolvePathWithinRoots(link, []string{root})
+	assertPathNotAllowed(t, err)
+}
+
+func TestResolvePathWithinRootsRejectsRootItselfAndSiblingPrefix(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "media")
+	sibling := filepath.Join(parent, "media-private", "photo.jpg")
+	writeTestFile(t, filepath.Join(root, "photo.jpg"))
+	writeTestFile(t, sibling)
+
+	_, err := resolvePathWithinRoots(root, []string{root})
+	assertPathNotAllowed(t, err)
+
+	_, err = resolvePathWithinRoots(sibling, []string{root})
+	assertPathNotAllowed(t, err)
+}
+
+func TestCheckGIFRequiresMP4(t *testing.T) {
+	if err := CheckGIF("/media/loop.MP4"); err != nil {
+		t.Fatalf("expected MP4 to be accepted, got %v", err)
+	}
+	for _, path := range []string{"/media/loop.gif", "/media/photo.jpg", ""} {
+		var policyErr *MediaPolicyError
+		if err := CheckGIF(path); !errors.As(err, &policyErr) || policyErr.Code != MediaErrorGIFNeedsVideo {
+			t.Fatalf("CheckGIF(%q): expected %s error, got %v", path, MediaErrorGIFNeedsVideo, err)
+		}
+	}
+}
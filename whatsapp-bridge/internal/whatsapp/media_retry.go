@@ -0,0 +1,146 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waMmsRetry"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"whatsapp-client/internal/mediacrypt"
+	"whatsapp-client/internal/storage"
+)
+
+// mediaRetryTimeout bounds how long a download waits for the phone to
+// re-upload expired media.
+const mediaRetryTimeout = 30 * time.Second
+
+// mediaRetryWaiters routes media retry notifications to the downloads
+// waiting on them.
+var mediaRetryWaiters = struct {
+	sync.Mutex
+	byID map[types.MessageID]chan *events.MediaRetry
+}{byID: map[types.MessageID]chan *events.MediaRetry{}}
+
+// handleMediaRetry hands a media retry notification to the download that
+// requested it, if one is still waiting.
+func handleMediaRetry(evt *events.MediaRetry) {
+	mediaRetryWaiters.Lock()
+	waiter, ok := mediaRetryWaiters.byID[evt.MessageID]
+	mediaRetryWaiters.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case waiter <- evt:
+	default:
+	}
+}
+
+// needsMediaRetry reports whether a download failed because the media's
+// direct path expired on WhatsApp's servers.
+func needsMediaRetry(err error) bool {
+	return errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith403) ||
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) ||
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410)
+}
+
+// storedMessageInfo rebuilds the WhatsApp message info of a stored message,
+// restoring the JID servers stripped when IDs were normalized.
+func storedMessageInfo(messageID, chatJID string, origin storage.MessageOrigin) (types.MessageInfo, error) {
+	var chat types.JID
+	if strings.Contains(chatJID, "@") {
+		parsed, err := types.ParseJID(chatJID)
+		if err != nil {
+			return types.MessageInfo{}, fmt.Errorf("error parsing chat JID: %w", err)
+		}
+		chat = parsed
+	} else {
+		server := origin.ChatServer
+		if server == "" {
+			server = types.DefaultUserServer
+		}
+		chat = types.NewJID(chatJID, server)
+	}
+
+	info := types.MessageInfo{
+		MessageSource: types.MessageSource{Chat: chat, IsFromMe: origin.IsFromMe, IsGroup: chat.Server == types.GroupServer},
+		ID:            messageID,
+	}
+	if origin.Sender != "" {
+		server := origin.SenderServer
+		if server == "" {
+			server = types.DefaultUserServer
+		}
+		info.Sender = types.NewJID(origin.Sender, server)
+	}
+	if info.IsGroup && info.Sender.IsEmpty() {
+		return types.MessageInfo{}, fmt.Errorf("group message has no stored sender")
+	}
+	return info, nil
+}
+
+// requestMediaReupload asks the phone to re-upload a message's media and
+// returns the new direct path once it has.
+func requestMediaReupload(ctx context.Context, client *whatsmeow.Client, info types.MessageInfo, mediaKey []byte) (string, error) {
+	waiter := make(chan *events.MediaRetry, 1)
+	mediaRetryWaiters.Lock()
+	mediaRetryWaiters.byID[info.ID] = waiter
+	mediaRetryWaiters.Unlock()
+	defer func() {
+		mediaRetryWaiters.Lock()
+		delete(mediaRetryWaiters.byID, info.ID)
+		mediaRetryWaiters.Unlock()
+	}()
+
+	if err := client.SendMediaRetryReceipt(ctx, &info, mediaKey); err != nil {
+		return "", fmt.Errorf("failed to request media re-upload: %w", err)
+	}
+
+	select {
+	case evt := <-waiter:
+		notification, err := whatsmeow.DecryptMediaRetryNotification(evt, mediaKey)
+		if err != nil {
+			return "", err
+		}
+		if notification.GetResult() != waMmsRetry.MediaRetryNotification_SUCCESS || notification.GetDirectPath() == "" {
+			return "", fmt.Errorf("phone could not re-upload media: %s", notification.GetResult())
+		}
+		return notification.GetDirectPath(), nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("timed out waiting for media re-upload: %w", ctx.Err())
+	}
+}
+
+// retryExpiredMediaDownload re-requests expired media from the phone and
+// downloads it from the new direct path. It returns the original download
+// error, annotated with why the retry failed, when the phone cannot help.
+func retryExpiredMediaDownload(client *whatsmeow.Client, messageStore *storage.MessageStore, messageID, chatJID string, downloader *MediaDownloader, path string, mode os.FileMode, keys *mediacrypt.Keyring, downloadErr error) (int64, error) {
+	origin, err := messageStore.GetMessageOrigin(messageID, chatJID)
+	if err != nil {
+		return 0, fmt.Errorf("%w; media re-upload not attempted: %v", downloadErr, err)
+	}
+	info, err := storedMessageInfo(messageID, chatJID, origin)
+	if err != nil {
+		return 0, fmt.Errorf("%w; media re-upload not attempted: %v", downloadErr, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mediaRetryTimeout)
+	defer cancel()
+	directPath, err := requestMediaReupload(ctx, client, info, downloader.MediaKey)
+	if err != nil {
+		return 0, fmt.Errorf("%w; media re-upload failed: %w", downloadErr, err)
+	}
+
+	fmt.Printf("Media re-uploaded by phone, retrying download (message_ref=%s)\n", obfuscatedMessageRef(messageID))
+	retried := *downloader
+	retried.URL = ""
+	retried.DirectPath = directPath
+	return downloadMediaFileAtomic(context.Background(), client, &retried, path, downloader.FileSHA256, mode, keys)
+}
@@ -0,0 +1,43 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/storage"
+)
+
+func TestStoredMessageInfo(t *testing.T) {
+	info, err := storedMessageInfo("ABC", "123456789012345", storage.MessageOrigin{
+		ChatServer: types.HiddenUserServer, Sender: "123456789012345", SenderServer: types.HiddenUserServer,
+	})
+	if err != nil || info.Chat.String() != "123456789012345@lid" || info.IsGroup || info.ID != "ABC" {
+		t.Fatalf("personal chat info = %+v, %v", info, err)
+	}
+
+	info, err = storedMessageInfo("ABC", "15551234567", storage.MessageOrigin{IsFromMe: true})
+	if err != nil || info.Chat.String() != "15551234567@s.whatsapp.net" || !info.IsFromMe {
+		t.Fatalf("legacy row info = %+v, %v", info, err)
+	}
+
+	info, err = storedMessageInfo("ABC", "120363000000000000@g.us", storage.MessageOrigin{Sender: "15551234567", SenderServer: types.DefaultUserServer})
+	if err != nil || !info.IsGroup || info.Sender.String() != "15551234567@s.whatsapp.net" {
+		t.Fatalf("group info = %+v, %v", info, err)
+	}
+
+	if _, err := storedMessageInfo("ABC", "120363000000000000@g.us", storage.MessageOrigin{}); err == nil {
+		t.Fatal("group message without a sender should be rejected")
+	}
+}
+
+func TestNeedsMediaRetry(t *testing.T) {
+	if !needsMediaRetry(fmt.Errorf("download: %w", whatsmeow.ErrMediaDownloadFailedWith410)) {
+		t.Error("410 downloads should be retried")
+	}
+	if needsMediaRetry(whatsmeow.ErrInvalidMediaHMAC) || needsMediaRetry(errors.New("boom")) {
+		t.Error("only expired-media failures should be retried")
+	}
+}
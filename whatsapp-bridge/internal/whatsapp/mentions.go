@@ -0,0 +1,82 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+const defaultMentionAllMaxParticipants = 512
+
+// mentionAllMaxParticipants returns the largest group mention_all may expand,
+// configurable via WHATSAPP_MENTION_ALL_MAX_PARTICIPANTS.
+func mentionAllMaxParticipants() int {
+	raw := strings.TrimSpace(os.Getenv("WHATSAPP_MENTION_ALL_MAX_PARTICIPANTS"))
+	if raw == "" {
+		return defaultMentionAllMaxParticipants
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed <= 0 {
+		fmt.Printf("Warning: invalid WHATSAPP_MENTION_ALL_MAX_PARTICIPANTS=%q, using %d\n", raw, defaultMentionAllMaxParticipants)
+		return defaultMentionAllMaxParticipants
+	}
+	return parsed
+}
+
+// groupMentionJIDs returns the JIDs of every current group participant except
+// the linked account, refusing groups larger than maxParticipants.
+func groupMentionJIDs(ctx context.Context, client *whatsmeow.Client, groupJID types.JID, maxParticipants int) ([]string, error) {
+	if groupJID.Server != types.GroupServer {
+		return nil, fmt.Errorf("mention_all is only supported for group chats")
+	}
+	info, err := client.GetGroupInfo(ctx, groupJID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching group participants: %w", err)
+	}
+	if len(info.Participants) > maxParticipants {
+		return nil, fmt.Errorf("group has %d participants, mention_all is limited to %d", len(info.Participants), maxParticipants)
+	}
+
+	own := map[string]struct{}{}
+	for _, id := range OwnUserIDs(client) {
+		own[id] = struct{}{}
+	}
+	mentions := make([]string, 0, len(info.Participants))
+	for _, participant := range info.Participants {
+		if _, ok := own[participant.JID.User]; ok {
+			continue
+		}
+		mentions = append(mentions, participant.JID.ToNonAD().String())
+	}
+	return mentions, nil
+}
+
+// setMessageContextInfo attaches contextInfo to an outgoing message, upgrading
+// plain text to an extended text message since conversations carry no context.
+func setMessageContextInfo(msg *waProto.Message, contextInfo *waProto.ContextInfo) {
+	switch {
+	case msg.Conversation != nil:
+		msg.ExtendedTextMessage = &waProto.ExtendedTextMessage{
+			Text:        proto.String(msg.GetConversation()),
+			ContextInfo: contextInfo,
+		}
+		msg.Conversation = nil
+	case msg.ExtendedTextMessage != nil:
+		msg.ExtendedTextMessage.ContextInfo = contextInfo
+	case msg.ImageMessage != nil:
+		msg.ImageMessage.ContextInfo = contextInfo
+	case msg.VideoMessage != nil:
+		msg.VideoMessage.ContextInfo = contextInfo
+	case msg.AudioMessage != nil:
+		msg.AudioMessage.ContextInfo = contextInfo
+	case msg.DocumentMessage != nil:
+		msg.DocumentMessage.ContextInfo = contextInfo
+	}
+}
@@ -0,0 +1,180 @@
+package whatsapp
+
+import (
+	"time"
+
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/webhook"
+)
+
+const (
+	EventMessageReceived = "message.received"
+	// EventContactFirstMessage fires once per sender, for the first direct
+	// message from someone with no history with the account.
+	EventContactFirstMessage = "contact.first_message"
+	// EventChatReturned fires when a snoozed chat returns to the inbox.
+	EventChatReturned = "chat.returned_to_inbox"
+)
+
+// Reasons a snoozed chat returns to the inbox.
+const (
+	ChatReturnedSnoozeExpired = "snooze_expired"
+	ChatReturnedNewMessage    = "new_message"
+)
+
+// MessageEvent is the webhook payload for a live message, sent or received.
+type MessageEvent struct {
+	MessageID   string `json:"message_id"`
+	ChatJID     string `json:"chat_jid"`
+	SenderID    string `json:"sender_id"`
+	IsFromMe    bool   `json:"is_from_me"`
+	MessageType string `json:"message_type"`
+	Content     string `json:"content,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	// Selection is set for replies to interactive list and button messages.
+	Selection *SelectionEvent `json:"selection,omitempty"`
+	// Payment is set for order and payment messages.
+	Payment *PaymentEvent `json:"payment,omitempty"`
+	// QuotedMessageID is the message replied to; for an interactive reply,
+	// the list or button message it answers.
+	QuotedMessageID string `json:"quoted_message_id,omitempty"`
+}
+
+// PaymentEvent carries an order or payment message's fields; Amount1000 is
+// in thousandths of the currency unit.
+type PaymentEvent struct {
+	Kind       string `json:"kind"`
+	Amount1000 int64  `json:"amount_1000,omitempty"`
+	Currency   string `json:"currency,omitempty"`
+	Reference  string `json:"reference,omitempty"`
+	ItemCount  int    `json:"item_count,omitempty"`
+	Title      string `json:"title,omitempty"`
+}
+
+// SelectionEvent is the row or button an interactive reply picked.
+type SelectionEvent struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// FirstContactEvent is the webhook payload for a new contact's first message.
+type FirstContactEvent struct {
+	SenderID    string `json:"sender_id"`
+	ChatJID     string `json:"chat_jid"`
+	PushName    string `json:"push_name,omitempty"`
+	MessageID   string `json:"message_id"`
+	MessageType string `json:"message_type"`
+	Content     string `json:"content,omitempty"`
+}
+
+// ChatReturnedEvent is the webhook payload for a snoozed chat returning to
+// the inbox, either because its snooze ended or because a message arrived.
+type ChatReturnedEvent struct {
+	ChatJID      string `json:"chat_jid"`
+	Reason       string `json:"reason"`
+	SnoozedUntil string `json:"snoozed_until"`
+	MessageID    string `json:"message_id,omitempty"`
+	SenderID     string `json:"sender_id,omitempty"`
+}
+
+// messageEventType is the subscription type of a message: its media type, or
+// text when it has none.
+func messageEventType(mediaType string) string {
+	if mediaType == "" {
+		return webhook.MessageTypeText
+	}
+	return mediaType
+}
+
+// emitMessageEvent reports a live message. History sync backfill is not
+// emitted. Transient messages only reach live stream subscribers, so nothing
+// about them is written to the event journal.
+func emitMessageEvent(emitter *webhook.Emitter, msg storage.StoredMessage, transient bool) {
+	if emitter == nil {
+		return
+	}
+	emit := emitter.Emit
+	if transient {
+		emit = emitter.Publish
+	}
+	messageType := messageEventType(msg.MediaType)
+	event := MessageEvent{
+		MessageID:       msg.ID,
+		ChatJID:         msg.ChatJID,
+		SenderID:        msg.Sender,
+		IsFromMe:        msg.IsFromMe,
+		MessageType:     messageType,
+		Content:         msg.Content,
+		Filename:        msg.Filename,
+		QuotedMessageID: msg.QuotedMessageID,
+	}
+	if msg.Selection.Kind != "" {
+		event.Selection = &SelectionEvent{Kind: msg.Selection.Kind, ID: msg.Selection.ID, Title: msg.Selection.Title}
+	}
+	if payment := msg.Payment; payment.Kind != "" {
+		event.Payment = &PaymentEvent{
+			Kind:       payment.Kind,
+			Amount1000: payment.Amount1000,
+			Currency:   payment.Currency,
+			Reference:  payment.Reference,
+			ItemCount:  payment.ItemCount,
+			Title:      payment.Title,
+		}
+	}
+	emit(EventMessageReceived, msg.Timestamp, webhook.Subject{
+		ChatJID:     msg.ChatJID,
+		SenderID:    msg.Sender,
+		MessageType: messageType,
+	}, event)
+}
+
+// emitFirstContactEvent reports msg as its sender's first contact when it is
+// an incoming direct message from someone the store has no history with.
+func emitFirstContactEvent(emitter *webhook.Emitter, messageStore *storage.MessageStore, msg storage.StoredMessage) error {
+	if emitter == nil || msg.IsFromMe || jid.ChatType(msg.ChatJID) != jid.ChatTypeDirect {
+		return nil
+	}
+	first, err := messageStore.RecordFirstContact(msg.Sender, msg.ChatJID, msg.ID, msg.PushName, msg.Timestamp)
+	if err != nil || !first {
+		return err
+	}
+	messageType := messageEventType(msg.MediaType)
+	emitter.Emit(EventContactFirstMessage, msg.Timestamp, webhook.Subject{
+		ChatJID:     msg.ChatJID,
+		SenderID:    msg.Sender,
+		MessageType: messageType,
+	}, FirstContactEvent{
+		SenderID:    msg.Sender,
+		ChatJID:     msg.ChatJID,
+		PushName:    msg.PushName,
+		MessageID:   msg.ID,
+		MessageType: messageType,
+		Content:     msg.Content,
+	})
+	return nil
+}
+
+// EmitChatReturned reports a snoozed chat returning to the inbox. msg is the
+// incoming message that woke it, or nil when the snooze expired.
+func EmitChatReturned(emitter *webhook.Emitter, chatJID string, snoozedUntil time.Time, msg *storage.StoredMessage) {
+	if emitter == nil {
+		return
+	}
+	event := ChatReturnedEvent{
+		ChatJID:      chatJID,
+		Reason:       ChatReturnedSnoozeExpired,
+		SnoozedUntil: snoozedUntil.UTC().Format(time.RFC3339),
+	}
+	at := snoozedUntil
+	subject := webhook.Subject{ChatJID: chatJID}
+	if msg != nil {
+		event.Reason = ChatReturnedNewMessage
+		event.MessageID = msg.ID
+		event.SenderID = msg.Sender
+		at = msg.Timestamp
+		subject.SenderID = msg.Sender
+	}
+	emitter.Emit(EventChatReturned, at, subject, event)
+}
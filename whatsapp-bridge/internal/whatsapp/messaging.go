@@ -3,7 +3,6 @@ package whatsapp
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -12,9 +11,12 @@ import (
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
 	"google.golang.org/protobuf/proto"
+	"whatsapp-client/internal/storage"
 )
 
 // extractTextContent returns best-effort text content from a protobuf message.
+// Interactive replies are rendered as the title of the picked row or button,
+// and orders and payments as their note or a summary.
 func extractTextContent(msg *waProto.Message) string {
 	if msg == nil {
 		return ""
@@ -26,10 +28,80 @@ func extractTextContent(msg *waProto.Message) string {
 	if extendedText := msg.GetExtendedTextMessage(); extendedText != nil {
 		return extendedText.GetText()
 	}
+	if selection := extractSelection(msg); selection.Kind != "" {
+		return selection.Title
+	}
+	if payment := extractPayment(msg); payment.Kind != "" {
+		return paymentText(msg, payment)
+	}
 
 	return ""
 }
 
+// extractLinkPreview returns the matched URL and title of an attached link preview.
+func extractLinkPreview(msg *waProto.Message) (string, string) {
+	extendedText := msg.GetExtendedTextMessage()
+	if extendedText == nil {
+		return "", ""
+	}
+	return extendedText.GetMatchedText(), extendedText.GetTitle()
+}
+
+// messageContextInfo returns the context info of the message's content, if any.
+func messageContextInfo(msg *waProto.Message) *waProto.ContextInfo {
+	var contextInfo *waProto.ContextInfo
+	switch {
+	case msg.GetExtendedTextMessage() != nil:
+		contextInfo = msg.GetExtendedTextMessage().GetContextInfo()
+	case msg.GetImageMessage() != nil:
+		contextInfo = msg.GetImageMessage().GetContextInfo()
+	case msg.GetVideoMessage() != nil:
+		contextInfo = msg.GetVideoMessage().GetContextInfo()
+	case msg.GetAudioMessage() != nil:
+		contextInfo = msg.GetAudioMessage().GetContextInfo()
+	case msg.GetDocumentMessage() != nil:
+		contextInfo = msg.GetDocumentMessage().GetContextInfo()
+	case msg.GetListResponseMessage() != nil:
+		contextInfo = msg.GetListResponseMessage().GetContextInfo()
+	case msg.GetButtonsResponseMessage() != nil:
+		contextInfo = msg.GetButtonsResponseMessage().GetContextInfo()
+	case msg.GetTemplateButtonReplyMessage() != nil:
+		contextInfo = msg.GetTemplateButtonReplyMessage().GetContextInfo()
+	case msg.GetOrderMessage() != nil:
+		contextInfo = msg.GetOrderMessage().GetContextInfo()
+	}
+	return contextInfo
+}
+
+// extractQuotedMessageID returns the ID of the message being replied to, if any.
+func extractQuotedMessageID(msg *waProto.Message) string {
+	return messageContextInfo(msg).GetStanzaID()
+}
+
+// extractForwarding reports whether a message was forwarded and how many
+// times, as counted by WhatsApp's forwarding score.
+func extractForwarding(msg *waProto.Message) (bool, uint32) {
+	contextInfo := messageContextInfo(msg)
+	return contextInfo.GetIsForwarded(), contextInfo.GetForwardingScore()
+}
+
+// resolveRecipientJID parses a send recipient, resolving the "me" alias to
+// the account's own chat and a community to its announcement group.
+func resolveRecipientJID(client *whatsmeow.Client, messageStore *storage.MessageStore, recipient string) (types.JID, error) {
+	if strings.EqualFold(strings.TrimSpace(recipient), SelfChatRecipient) {
+		ownJID, ok := ownChatJID(client)
+		if !ok {
+			return types.JID{}, fmt.Errorf("No linked device, cannot resolve own chat")
+		}
+		return ownJID, nil
+	}
+	recipientJID, err := parseRecipientJID(recipient)
+	if err != nil {
+		return types.JID{}, err
+	}
+	return resolveCommunityRecipient(messageStore, recipientJID)
+}
+
 // parseRecipientJID accepts either full JID or bare phone number input.
 func parseRecipientJID(recipient string) (types.JID, error) {
 	recipient = strings.TrimSpace(recipient)
@@ -58,6 +130,10 @@ func detectMediaTypeAndMime(mediaPath string) (whatsmeow.MediaType, string) {
 		return whatsmeow.MediaImage, "image/webp"
 	case "ogg":
 		return whatsmeow.MediaAudio, "audio/ogg; codecs=opus"
+	case "mp3":
+		return whatsmeow.MediaAudio, "audio/mpeg"
+	case "m4a":
+		return whatsmeow.MediaAudio, "audio/mp4"
 	case "mp4":
 		return whatsmeow.MediaVideo, "video/mp4"
 	case "avi":
@@ -70,7 +146,8 @@ func detectMediaTypeAndMime(mediaPath string) (whatsmeow.MediaType, string) {
 }
 
 // buildMediaMessage builds the outbound media payload for SendMessage.
-func buildMediaMessage(resp whatsmeow.UploadResponse, mediaType whatsmeow.MediaType, mimeType, mediaPath, caption string, mediaData []byte) (*waProto.Message, error) {
+// Videos with gifPlayback loop inline as GIFs.
+func buildMediaMessage(resp whatsmeow.UploadResponse, mediaType whatsmeow.MediaType, mimeType, mediaPath, caption string, mediaData []byte, gifPlayback bool) (*waProto.Message, error) {
 	msg := &waProto.Message{}
 
 	switch mediaType {
@@ -89,13 +166,20 @@ func buildMediaMessage(resp whatsmeow.UploadResponse, mediaType whatsmeow.MediaT
 		seconds := uint32(30)
 		var waveform []byte
 
-		if strings.Contains(mimeType, "ogg") {
+		// Only Ogg Opus plays as a voice note; other formats are sent as
+		// audio files with their duration read from the container.
+		voiceNote := strings.Contains(mimeType, "ogg")
+		if voiceNote {
 			analyzedSeconds, analyzedWaveform, err := analyzeOggOpus(mediaData)
 			if err != nil {
 				return nil, fmt.Errorf("failed to analyze Ogg Opus file: %w", err)
 			}
 			seconds = analyzedSeconds
 			waveform = analyzedWaveform
+		} else if analyzedSeconds, err := audioDurationSeconds(mimeType, mediaData); err == nil {
+			seconds = analyzedSeconds
+		} else {
+			fmt.Printf("Could not read audio duration, using %d seconds: %v\n", seconds, err)
 		}
 
 		msg.AudioMessage = &waProto.AudioMessage{
@@ -107,7 +191,7 @@ func buildMediaMessage(resp whatsmeow.UploadResponse, mediaType whatsmeow.MediaT
 			FileSHA256:    resp.FileSHA256,
 			FileLength:    &resp.FileLength,
 			Seconds:       proto.Uint32(seconds),
-			PTT:           proto.Bool(true),
+			PTT:           proto.Bool(voiceNote),
 			Waveform:      waveform,
 		}
 	case whatsmeow.MediaVideo:
@@ -121,6 +205,9 @@ func buildMediaMessage(resp whatsmeow.UploadResponse, mediaType whatsmeow.MediaT
 			FileSHA256:    resp.FileSHA256,
 			FileLength:    &resp.FileLength,
 		}
+		if gifPlayback {
+			msg.VideoMessage.GifPlayback = proto.Bool(true)
+		}
 	case whatsmeow.MediaDocument:
 		msg.DocumentMessage = &waProto.DocumentMessage{
 			Title:         proto.String(filepath.Base(mediaPath)),
@@ -140,71 +227,193 @@ func buildMediaMessage(resp whatsmeow.UploadResponse, mediaType whatsmeow.MediaT
 	return msg, nil
 }
 
-// SendWhatsAppMessage sends text or media messages through the connected client.
-func SendWhatsAppMessage(client *whatsmeow.Client, recipient string, message string, mediaPath string) (bool, string) {
-	if !client.IsConnected() {
-		return false, "Not connected to WhatsApp"
-	}
+// SendOptions holds optional behaviour for SendWhatsAppMessage.
+type SendOptions struct {
+	// MentionAll mentions every current participant of the recipient group.
+	MentionAll bool
+	// GIF sends an MP4 video as a GIF that loops inline (see CheckGIF).
+	GIF bool
+	// OverrideOptOut sends to contacts who opted out.
+	OverrideOptOut bool
+	// Pacing spaces out the copies of a message sent to many recipients.
+	Pacing PacingProfile
+}
 
-	recipientJID, err := parseRecipientJID(recipient)
+// SendWhatsAppMessage sends text or media messages through the connected client.
+func SendWhatsAppMessage(client *whatsmeow.Client, messageStore *storage.MessageStore, recipient string, message string, mediaPath string, opts SendOptions) (bool, string) {
+	result, err := SendMessage(context.Background(), client, messageStore, recipient, message, mediaPath, opts)
 	if err != nil {
 		return false, err.Error()
 	}
+	return true, result.Summary
+}
 
-	msg := &waProto.Message{}
-	if mediaPath != "" {
-		mediaData, err := os.ReadFile(mediaPath)
-		if err != nil {
-			return false, fmt.Sprintf("Error reading media file: %v", err)
+// SendResult describes a completed send.
+type SendResult struct {
+	Summary string
+	// MessageIDs are the WhatsApp IDs of the messages sent: one for a chat,
+	// one per reached recipient for a broadcast list.
+	MessageIDs []string
+}
+
+// recordSentMessage notes a sent message so its delivery status can be
+// queried before WhatsApp syncs it back.
+func recordSentMessage(client *whatsmeow.Client, messageStore *storage.MessageStore, recipientJID types.JID, resp whatsmeow.SendResponse) {
+	if messageStore == nil {
+		return
+	}
+	if err := messageStore.RecordMessageSent(resp.ID, canonicalizeChatID(client, recipientJID), resp.Timestamp); err != nil {
+		fmt.Printf("Failed to record sent message (message_ref=%s): %v\n", obfuscatedMessageRef(resp.ID), err)
+	}
+}
+
+// buildOutgoingMessage builds a text message, or uploads mediaPath and builds
+// a media message captioned with message.
+func buildOutgoingMessage(ctx context.Context, client *whatsmeow.Client, message string, mediaPath string, gif bool) (*waProto.Message, error) {
+	if mediaPath == "" {
+		return &waProto.Message{Conversation: proto.String(message)}, nil
+	}
+	logger := operationLogger(ctx, client)
+	resolvedPath, err := MediaPolicyFromEnv().CheckUpload(mediaPath)
+	if err != nil {
+		return nil, err
+	}
+	mediaPath = resolvedPath
+	if gif {
+		if err := CheckGIF(mediaPath); err != nil {
+			return nil, err
 		}
+	}
 
-		mediaType, mimeType := detectMediaTypeAndMime(mediaPath)
-		resp, err := client.Upload(context.Background(), mediaData, mediaType)
-		if err != nil {
-			return false, fmt.Sprintf("Error uploading media: %v", err)
+	mediaData, err := readMediaFile(mediaPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading media file: %v", err)
+	}
+
+	mediaType, mimeType := detectMediaTypeAndMime(mediaPath)
+	resp, err := client.Upload(ctx, mediaData, mediaType)
+	if err != nil {
+		logger.Warnf("Media upload failed (%s, %d bytes): %v", mediaType, len(mediaData), err)
+		return nil, classifySendError("Error uploading media", err)
+	}
+	logger.Infof("Uploaded media (%s, %d bytes)", mediaType, len(mediaData))
+
+	return buildMediaMessage(resp, mediaType, mimeType, mediaPath, message, mediaData, gif)
+}
+
+// SendMessage sends text or media messages through the connected client and
+// returns a summary of the delivery with the sent message IDs. WhatsApp failures are returned as
+// *SendError so callers can tell retryable failures from permanent ones.
+// WhatsApp operations are logged under the request ID carried by ctx.
+func SendMessage(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore, recipient string, message string, mediaPath string, opts SendOptions) (SendResult, error) {
+	if !client.IsConnected() {
+		return SendResult{}, notConnectedError()
+	}
+
+	recipientJID, err := resolveRecipientJID(client, messageStore, recipient)
+	if err != nil {
+		return SendResult{}, err
+	}
+	if err := checkChatSendPolicy(client, messageStore, recipientJID); err != nil {
+		return SendResult{}, err
+	}
+	if !opts.OverrideOptOut {
+		if err := checkOptOut(client, messageStore, recipientJID); err != nil {
+			return SendResult{}, err
 		}
+	}
+	logger := operationLogger(ctx, client)
+
+	msg, err := buildOutgoingMessage(ctx, client, message, mediaPath, opts.GIF)
+	if err != nil {
+		return SendResult{}, err
+	}
 
-		msg, err = buildMediaMessage(resp, mediaType, mimeType, mediaPath, message, mediaData)
+	if opts.MentionAll {
+		mentions, err := groupMentionJIDs(ctx, client, recipientJID, mentionAllMaxParticipants())
 		if err != nil {
-			return false, err.Error()
+			return SendResult{}, err
 		}
-	} else {
-		msg.Conversation = proto.String(message)
+		setMessageContextInfo(msg, &waProto.ContextInfo{MentionedJID: mentions})
+	}
+
+	if recipientJID.IsBroadcastList() {
+		return sendToBroadcastList(ctx, client, messageStore, recipientJID, msg, opts)
 	}
 
-	if _, err := client.SendMessage(context.Background(), recipientJID, msg); err != nil {
-		return false, fmt.Sprintf("Error sending message: %v", err)
+	resp, err := client.SendMessage(ctx, recipientJID, msg)
+	recordSendOutcome(err)
+	if err != nil {
+		logger.Warnf("Send to %s failed: %v", obfuscatedChatRef(recipientJID.String()), err)
+		return SendResult{}, confirmRecipientMissing(client, recipientJID, classifySendError("Error sending message", err))
 	}
+	logger.Infof("Sent message %s to %s", obfuscatedMessageRef(resp.ID), obfuscatedChatRef(recipientJID.String()))
+	recordSentMessage(client, messageStore, recipientJID, resp)
 
-	return true, fmt.Sprintf("Message sent to %s", recipient)
+	return SendResult{Summary: fmt.Sprintf("Message sent to %s", recipient), MessageIDs: []string{resp.ID}}, nil
 }
 
 // extractMediaInfo extracts media metadata needed for persistence and download.
-func extractMediaInfo(msg *waProto.Message) (mediaType string, filename string, url string, mediaKey []byte, fileSHA256 []byte, fileEncSHA256 []byte, fileLength uint64) {
+func extractMediaInfo(msg *waProto.Message) storage.MessageMedia {
 	if msg == nil {
-		return "", "", "", nil, nil, nil, 0
+		return storage.MessageMedia{}
 	}
 
 	if img := msg.GetImageMessage(); img != nil {
-		return "image", "image_" + time.Now().Format("20060102_150405") + ".jpg",
-			img.GetURL(), img.GetMediaKey(), img.GetFileSHA256(), img.GetFileEncSHA256(), img.GetFileLength()
+		return storage.MessageMedia{
+			MediaType:     "image",
+			Filename:      "image_" + time.Now().Format("20060102_150405") + ".jpg",
+			URL:           img.GetURL(),
+			MediaKey:      img.GetMediaKey(),
+			FileSHA256:    img.GetFileSHA256(),
+			FileEncSHA256: img.GetFileEncSHA256(),
+			FileLength:    img.GetFileLength(),
+			Thumbnail:     img.GetJPEGThumbnail(),
+		}
 	}
 	if vid := msg.GetVideoMessage(); vid != nil {
-		return "video", "video_" + time.Now().Format("20060102_150405") + ".mp4",
-			vid.GetURL(), vid.GetMediaKey(), vid.GetFileSHA256(), vid.GetFileEncSHA256(), vid.GetFileLength()
+		return storage.MessageMedia{
+			MediaType:     "video",
+			Filename:      "video_" + time.Now().Format("20060102_150405") + ".mp4",
+			URL:           vid.GetURL(),
+			MediaKey:      vid.GetMediaKey(),
+			FileSHA256:    vid.GetFileSHA256(),
+			FileEncSHA256: vid.GetFileEncSHA256(),
+			FileLength:    vid.GetFileLength(),
+			Thumbnail:     vid.GetJPEGThumbnail(),
+			Seconds:       vid.GetSeconds(),
+		}
 	}
 	if aud := msg.GetAudioMessage(); aud != nil {
-		return "audio", "audio_" + time.Now().Format("20060102_150405") + ".ogg",
-			aud.GetURL(), aud.GetMediaKey(), aud.GetFileSHA256(), aud.GetFileEncSHA256(), aud.GetFileLength()
+		return storage.MessageMedia{
+			MediaType:     "audio",
+			Filename:      "audio_" + time.Now().Format("20060102_150405") + ".ogg",
+			URL:           aud.GetURL(),
+			MediaKey:      aud.GetMediaKey(),
+			FileSHA256:    aud.GetFileSHA256(),
+			FileEncSHA256: aud.GetFileEncSHA256(),
+			FileLength:    aud.GetFileLength(),
+			Seconds:       aud.GetSeconds(),
+			Waveform:      aud.GetWaveform(),
+			VoiceNote:     aud.GetPTT(),
+		}
 	}
 	if doc := msg.GetDocumentMessage(); doc != nil {
-		docFilename := doc.GetFileName()
+		docFilename := sanitizeMediaFilename(doc.GetFileName(), "")
 		if docFilename == "" {
 			docFilename = "document_" + time.Now().Format("20060102_150405")
 		}
-		return "document", docFilename,
-			doc.GetURL(), doc.GetMediaKey(), doc.GetFileSHA256(), doc.GetFileEncSHA256(), doc.GetFileLength()
+		return storage.MessageMedia{
+			MediaType:     "document",
+			Filename:      docFilename,
+			URL:           doc.GetURL(),
+			MediaKey:      doc.GetMediaKey(),
+			FileSHA256:    doc.GetFileSHA256(),
+			FileEncSHA256: doc.GetFileEncSHA256(),
+			FileLength:    doc.GetFileLength(),
+			Thumbnail:     doc.GetJPEGThumbnail(),
+		}
 	}
 
-	return "", "", "", nil, nil, nil, 0
+	return storage.MessageMedia{}
 }
@@ -0,0 +1,22 @@
+package whatsapp
+
+import (
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestExtractForwardingReadsContextInfo(t *testing.T) {
+	msg := &waProto.Message{ImageMessage: &waProto.ImageMessage{
+		ContextInfo: &waProto.ContextInfo{IsForwarded: proto.Bool(true), ForwardingScore: proto.Uint32(6)},
+	}}
+	if forwarded, score := extractForwarding(msg); !forwarded || score != 6 {
+		t.Fatalf("expected forwarded with score 6, got %v %d", forwarded, score)
+	}
+
+	plain := &waProto.Message{Conversation: proto.String("hello")}
+	if forwarded, score := extractForwarding(plain); forwarded || score != 0 {
+		t.Fatalf("expected plain message not forwarded, got %v %d", forwarded, score)
+	}
+}
@@ -0,0 +1,99 @@
+package whatsapp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+// defaultOptOutKeywords are the replies that opt a contact out when
+// WHATSAPP_OPT_OUT_KEYWORDS is unset.
+var defaultOptOutKeywords = []string{"STOP", "UNSUBSCRIBE"}
+
+// OptOutKeywordsFromEnv returns the stop keywords from
+// WHATSAPP_OPT_OUT_KEYWORDS, a comma-separated list, or the defaults.
+func OptOutKeywordsFromEnv() []string {
+	var keywords []string
+	for _, keyword := range strings.Split(os.Getenv("WHATSAPP_OPT_OUT_KEYWORDS"), ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	if len(keywords) == 0 {
+		return defaultOptOutKeywords
+	}
+	return keywords
+}
+
+// matchOptOutKeyword returns the stop keyword a message consists of: one of
+// keywords on its own, in any case, optionally with punctuation.
+func matchOptOutKeyword(content string, keywords []string) (string, bool) {
+	word := strings.TrimFunc(content, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	for _, keyword := range keywords {
+		if strings.EqualFold(word, keyword) {
+			return keyword, true
+		}
+	}
+	return "", false
+}
+
+// recordOptOutReply opts the sender of a direct message consisting of a stop
+// keyword out of further messages and of the campaigns that reached them.
+func recordOptOutReply(messageStore *storage.MessageStore, keywords []string, msg storage.StoredMessage) error {
+	if msg.IsFromMe || !jid.IsPersonal(msg.ChatJID) {
+		return nil
+	}
+	keyword, ok := matchOptOutKeyword(msg.Content, keywords)
+	if !ok {
+		return nil
+	}
+	if _, err := messageStore.RecordOptOut(storage.OptOut{
+		ContactID:  msg.ChatJID,
+		Source:     storage.OptOutSourceKeyword,
+		Keyword:    keyword,
+		MessageID:  msg.ID,
+		OptedOutAt: msg.Timestamp,
+	}); err != nil {
+		return err
+	}
+	_, err := messageStore.RecordCampaignOptOut(msg.ChatJID, msg.Timestamp)
+	return err
+}
+
+// checkOptOut refuses sends to personal chats whose contact opted out.
+// Lookup failures also refuse the send, as checkChatSendPolicy does.
+func checkOptOut(client *whatsmeow.Client, messageStore *storage.MessageStore, recipientJID types.JID) error {
+	if messageStore == nil {
+		return nil
+	}
+	contactID := canonicalizeChatID(client, recipientJID)
+	if !jid.IsPersonal(contactID) {
+		return nil
+	}
+	optOut, err := messageStore.GetOptOut(contactID)
+	if err != nil {
+		return fmt.Errorf("error checking opt-out: %w", err)
+	}
+	if optOut != nil {
+		return ErrRecipientOptedOut
+	}
+	return nil
+}
+
+// CheckOptOut reports whether recipient may be sent to, returning
+// ErrRecipientOptedOut when the contact opted out.
+func CheckOptOut(client *whatsmeow.Client, messageStore *storage.MessageStore, recipient string) error {
+	recipientJID, err := resolveRecipientJID(client, messageStore, recipient)
+	if err != nil {
+		return err
+	}
+	return checkOptOut(client, messageStore, recipientJID)
+}
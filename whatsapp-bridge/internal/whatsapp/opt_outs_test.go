@@ -0,0 +1,34 @@
+package whatsapp
+
+import "testing"
+
+func TestMatchOptOutKeyword(t *testing.T) {
+	keywords := []string{"STOP", "UNSUBSCRIBE"}
+	cases := map[string]string{
+		"STOP":         "STOP",
+		" stop ":       "STOP",
+		"Stop.":        "STOP",
+		"unsubscribe!": "UNSUBSCRIBE",
+		"stop sending": "",
+		"don't stop":   "",
+		"unstoppable":  "",
+		"":             "",
+	}
+	for content, want := range cases {
+		got, ok := matchOptOutKeyword(content, keywords)
+		if got != want || ok != (want != "") {
+			t.Errorf("matchOptOutKeyword(%q) = %q, %v, want %q", content, got, ok, want)
+		}
+	}
+}
+
+func TestOptOutKeywordsFromEnv(t *testing.T) {
+	t.Setenv("WHATSAPP_OPT_OUT_KEYWORDS", "")
+	if got := OptOutKeywordsFromEnv(); len(got) != 2 || got[0] != "STOP" {
+		t.Fatalf("default keywords = %v", got)
+	}
+	t.Setenv("WHATSAPP_OPT_OUT_KEYWORDS", " halt, ,BAJA ")
+	if got := OptOutKeywordsFromEnv(); len(got) != 2 || got[0] != "halt" || got[1] != "BAJA" {
+		t.Fatalf("keywords = %v", got)
+	}
+}
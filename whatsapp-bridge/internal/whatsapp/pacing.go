@@ -0,0 +1,210 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Pacing presets, from the gentlest on the account to the fastest.
+const (
+	PacingConservative = "conservative"
+	PacingNormal       = "normal"
+	PacingAggressive   = "aggressive"
+	PacingOff          = "off"
+)
+
+const (
+	typingPerChar  = 40 * time.Millisecond
+	minTypingDelay = time.Second
+	maxTypingDelay = 5 * time.Second
+)
+
+// PacingProfile spaces out bulk sends so they look less like automation to
+// WhatsApp's abuse detection. The zero profile sends without pacing.
+type PacingProfile struct {
+	Name string
+	// MinDelay and MaxDelay bound the random pause between two sends.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// Typing shows "typing…" in the recipient's chat for a time in
+	// proportion to the message before sending it.
+	Typing bool
+	// HourlyCap holds sends back once this many went out in the past hour;
+	// zero means no cap.
+	HourlyCap int
+}
+
+var pacingPresets = map[string]PacingProfile{
+	PacingConservative: {Name: PacingConservative, MinDelay: 8 * time.Second, MaxDelay: 20 * time.Second, Typing: true, HourlyCap: 60},
+	PacingNormal:       {Name: PacingNormal, MinDelay: 3 * time.Second, MaxDelay: 8 * time.Second, Typing: true, HourlyCap: 200},
+	PacingAggressive:   {Name: PacingAggressive, MinDelay: time.Second, MaxDelay: 2 * time.Second, HourlyCap: 600},
+	PacingOff:          {Name: PacingOff},
+}
+
+// PacingPreset returns the named preset.
+func PacingPreset(name string) (PacingProfile, bool) {
+	profile, ok := pacingPresets[strings.ToLower(strings.TrimSpace(name))]
+	return profile, ok
+}
+
+// PacingFromEnv returns the preset named by WHATSAPP_SEND_PACING (normal by
+// default), with its hourly cap replaced by WHATSAPP_SEND_PACING_HOURLY_CAP
+// when set (0 lifts the cap).
+func PacingFromEnv() PacingProfile {
+	profile := pacingPresets[PacingNormal]
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_SEND_PACING")); raw != "" {
+		if preset, ok := PacingPreset(raw); ok {
+			profile = preset
+		} else {
+			fmt.Printf("Warning: invalid WHATSAPP_SEND_PACING=%q, using %s\n", raw, PacingNormal)
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_SEND_PACING_HOURLY_CAP")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			fmt.Printf("Warning: invalid WHATSAPP_SEND_PACING_HOURLY_CAP=%q, using %d\n", raw, profile.HourlyCap)
+		} else {
+			profile.HourlyCap = parsed
+		}
+	}
+	return profile
+}
+
+// Enabled reports whether the profile paces sends at all.
+func (p PacingProfile) Enabled() bool {
+	return p.MinDelay > 0 || p.MaxDelay > 0 || p.Typing || p.HourlyCap > 0
+}
+
+// delay picks the pause before the next send.
+func (p PacingProfile) delay(rng *rand.Rand) time.Duration {
+	if p.MaxDelay <= p.MinDelay {
+		return p.MinDelay
+	}
+	return p.MinDelay + time.Duration(rng.Int63n(int64(p.MaxDelay-p.MinDelay)))
+}
+
+// typingDuration is how long a person would take to type text.
+func typingDuration(text string) time.Duration {
+	return min(max(time.Duration(len([]rune(text)))*typingPerChar, minTypingDelay), maxTypingDelay)
+}
+
+// outgoingText returns the text or caption a message carries.
+func outgoingText(msg *waProto.Message) string {
+	switch {
+	case msg.GetConversation() != "":
+		return msg.GetConversation()
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetText()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetCaption()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetCaption()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetCaption()
+	default:
+		return ""
+	}
+}
+
+// hourlySends counts sends in a sliding hour for the pacing cap, shared by
+// every paced send of the account.
+type hourlySends struct {
+	mu   sync.Mutex
+	sent []time.Time
+}
+
+var pacedSends hourlySends
+
+// reserve records a send at now when fewer than limit went out in the hour
+// before, and otherwise returns how long until one may.
+func (h *hourlySends) reserve(now time.Time, limit int) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cutoff := now.Add(-time.Hour)
+	kept := h.sent[:0]
+	for _, at := range h.sent {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	h.sent = kept
+	if limit > 0 && len(h.sent) >= limit {
+		return h.sent[len(h.sent)-limit].Sub(cutoff)
+	}
+	h.sent = append(h.sent, now)
+	return 0
+}
+
+// pacer applies a profile across the sends of one fan-out.
+type pacer struct {
+	profile PacingProfile
+	rng     *rand.Rand
+	sends   *hourlySends
+	started bool
+}
+
+// newPacer returns a pacer for profile, or nil when it does not pace.
+func newPacer(profile PacingProfile) *pacer {
+	if !profile.Enabled() {
+		return nil
+	}
+	return &pacer{profile: profile, rng: rand.New(rand.NewSource(time.Now().UnixNano())), sends: &pacedSends}
+}
+
+// wait blocks until msg may be sent to recipient: after the pause since the
+// previous send, within the hourly cap, and after showing the recipient
+// "typing…". It returns early with the context's error.
+func (p *pacer) wait(ctx context.Context, client *whatsmeow.Client, recipient types.JID, msg *waProto.Message) error {
+	if p == nil {
+		return nil
+	}
+	if p.started {
+		if err := sleepContext(ctx, p.profile.delay(p.rng)); err != nil {
+			return err
+		}
+	}
+	p.started = true
+	for {
+		wait := p.sends.reserve(time.Now(), p.profile.HourlyCap)
+		if wait <= 0 {
+			break
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+	if p.profile.Typing {
+		if err := client.SendChatPresence(ctx, recipient, types.ChatPresenceComposing, types.ChatPresenceMediaText); err != nil {
+			operationLogger(ctx, client).Debugf("Failed to show typing to %s: %v", obfuscatedChatRef(recipient.String()), err)
+			return nil
+		}
+		err := sleepContext(ctx, typingDuration(outgoingText(msg)))
+		_ = client.SendChatPresence(ctx, recipient, types.ChatPresencePaused, types.ChatPresenceMediaText)
+		return err
+	}
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,75 @@
+package whatsapp
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPacingFromEnv(t *testing.T) {
+	t.Setenv("WHATSAPP_SEND_PACING", "Conservative")
+	t.Setenv("WHATSAPP_SEND_PACING_HOURLY_CAP", "")
+	if profile := PacingFromEnv(); profile.Name != PacingConservative || profile.HourlyCap != 60 {
+		t.Fatalf("expected the conservative preset, got %+v", profile)
+	}
+
+	t.Setenv("WHATSAPP_SEND_PACING", "reckless")
+	t.Setenv("WHATSAPP_SEND_PACING_HOURLY_CAP", "0")
+	profile := PacingFromEnv()
+	if profile.Name != PacingNormal || profile.HourlyCap != 0 {
+		t.Fatalf("expected the normal preset without a cap, got %+v", profile)
+	}
+
+	t.Setenv("WHATSAPP_SEND_PACING", PacingOff)
+	t.Setenv("WHATSAPP_SEND_PACING_HOURLY_CAP", "")
+	if profile := PacingFromEnv(); profile.Enabled() {
+		t.Fatalf("expected off to disable pacing, got %+v", profile)
+	}
+}
+
+func TestPacingDelayWithinBounds(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, name := range []string{PacingConservative, PacingNormal, PacingAggressive} {
+		profile, _ := PacingPreset(name)
+		for i := 0; i < 100; i++ {
+			if delay := profile.delay(rng); delay < profile.MinDelay || delay >= profile.MaxDelay {
+				t.Fatalf("%s: delay %s outside [%s, %s)", name, delay, profile.MinDelay, profile.MaxDelay)
+			}
+		}
+	}
+}
+
+func TestTypingDuration(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Duration
+	}{
+		{"", minTypingDelay},
+		{"hello there, how are you doing", 30 * typingPerChar},
+		{string(make([]rune, 1000)), maxTypingDelay},
+	}
+	for _, tt := range tests {
+		if got := typingDuration(tt.text); got != tt.want {
+			t.Fatalf("typingDuration(%d chars) = %s, want %s", len([]rune(tt.text)), got, tt.want)
+		}
+	}
+}
+
+func TestHourlySendsReserve(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var sends hourlySends
+	for i := 0; i < 3; i++ {
+		if wait := sends.reserve(now.Add(time.Duration(i)*time.Minute), 3); wait != 0 {
+			t.Fatalf("send %d: expected no wait under the cap, got %s", i, wait)
+		}
+	}
+	if wait := sends.reserve(now.Add(10*time.Minute), 3); wait != 50*time.Minute {
+		t.Fatalf("expected to wait until the oldest send leaves the hour, got %s", wait)
+	}
+	if wait := sends.reserve(now.Add(time.Hour+time.Second), 3); wait != 0 {
+		t.Fatalf("expected room once the oldest send aged out, got %s", wait)
+	}
+	if wait := sends.reserve(now.Add(time.Hour+2*time.Second), 0); wait != 0 {
+		t.Fatalf("expected no cap at zero, got %s", wait)
+	}
+}
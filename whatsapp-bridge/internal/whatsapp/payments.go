@@ -0,0 +1,120 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"whatsapp-client/internal/storage"
+)
+
+// extractPayment returns the order or payment details of a WhatsApp Business
+// order or payment message, or a zero Payment for any other message.
+func extractPayment(msg *waProto.Message) storage.Payment {
+	switch {
+	case msg.GetOrderMessage() != nil:
+		order := msg.GetOrderMessage()
+		return storage.Payment{
+			Kind:       storage.PaymentKindOrder,
+			Amount1000: int64(order.GetTotalAmount1000()),
+			Currency:   order.GetTotalCurrencyCode(),
+			Reference:  order.GetOrderID(),
+			ItemCount:  int(order.GetItemCount()),
+			Title:      order.GetOrderTitle(),
+		}
+	case msg.GetRequestPaymentMessage() != nil:
+		request := msg.GetRequestPaymentMessage()
+		payment := storage.Payment{
+			Kind:       storage.PaymentKindRequest,
+			Amount1000: int64(request.GetAmount1000()),
+			Currency:   request.GetCurrencyCodeIso4217(),
+		}
+		if amount := request.GetAmount(); amount != nil && amount.GetOffset() > 0 {
+			payment.Amount1000 = int64(amount.GetValue()) * 1000 / int64(amount.GetOffset())
+			payment.Currency = amount.GetCurrencyCode()
+		}
+		return payment
+	case msg.GetSendPaymentMessage() != nil:
+		return storage.Payment{
+			Kind:      storage.PaymentKindSent,
+			Reference: msg.GetSendPaymentMessage().GetRequestMessageKey().GetID(),
+		}
+	case msg.GetPaymentInviteMessage() != nil:
+		return storage.Payment{Kind: storage.PaymentKindInvite}
+	case msg.GetDeclinePaymentRequestMessage() != nil:
+		return storage.Payment{
+			Kind:      storage.PaymentKindDeclined,
+			Reference: msg.GetDeclinePaymentRequestMessage().GetKey().GetID(),
+		}
+	case msg.GetCancelPaymentRequestMessage() != nil:
+		return storage.Payment{
+			Kind:      storage.PaymentKindCancelled,
+			Reference: msg.GetCancelPaymentRequestMessage().GetKey().GetID(),
+		}
+	}
+	return storage.Payment{}
+}
+
+// paymentText renders an order or payment message as text: the note or
+// message sent with it when there is one, otherwise a short summary.
+func paymentText(msg *waProto.Message, payment storage.Payment) string {
+	var note string
+	switch payment.Kind {
+	case storage.PaymentKindOrder:
+		note = msg.GetOrderMessage().GetMessage()
+	case storage.PaymentKindRequest:
+		note = extractTextContent(msg.GetRequestPaymentMessage().GetNoteMessage())
+	case storage.PaymentKindSent:
+		note = extractTextContent(msg.GetSendPaymentMessage().GetNoteMessage())
+	}
+	if note != "" {
+		return note
+	}
+
+	switch payment.Kind {
+	case storage.PaymentKindOrder:
+		name := payment.Title
+		if name == "" {
+			name = payment.Reference
+		}
+		details := make([]string, 0, 2)
+		if payment.ItemCount == 1 {
+			details = append(details, "1 item")
+		} else if payment.ItemCount > 1 {
+			details = append(details, fmt.Sprintf("%d items", payment.ItemCount))
+		}
+		if amount := formatAmount1000(payment.Amount1000, payment.Currency); amount != "" {
+			details = append(details, amount)
+		}
+		text := strings.TrimSpace("Order " + name)
+		if len(details) > 0 {
+			text += " (" + strings.Join(details, ", ") + ")"
+		}
+		return text
+	case storage.PaymentKindRequest:
+		if amount := formatAmount1000(payment.Amount1000, payment.Currency); amount != "" {
+			return "Payment request: " + amount
+		}
+		return "Payment request"
+	case storage.PaymentKindSent:
+		return "Payment sent"
+	case storage.PaymentKindInvite:
+		return "Payment invite"
+	case storage.PaymentKindDeclined:
+		return "Payment request declined"
+	case storage.PaymentKindCancelled:
+		return "Payment request cancelled"
+	}
+	return ""
+}
+
+// formatAmount1000 renders an amount in thousandths of a currency unit, the
+// way WhatsApp encodes them, with two decimals and the currency code.
+func formatAmount1000(amount1000 int64, currency string) string {
+	if amount1000 == 0 {
+		return ""
+	}
+	amount := strconv.FormatFloat(float64(amount1000)/1000, 'f', 2, 64)
+	return strings.TrimSpace(amount + " " + currency)
+}
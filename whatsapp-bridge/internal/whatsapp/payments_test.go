@@ -0,0 +1,53 @@
+package whatsapp
+
+import (
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+	"whatsapp-client/internal/storage"
+)
+
+func TestExtractPaymentReadsOrders(t *testing.T) {
+	msg := &waProto.Message{OrderMessage: &waProto.OrderMessage{
+		OrderID:           proto.String("ORD-77"),
+		OrderTitle:        proto.String("Coffee beans"),
+		ItemCount:         proto.Int32(3),
+		TotalAmount1000:   proto.Int64(45500),
+		TotalCurrencyCode: proto.String("EUR"),
+	}}
+	want := storage.Payment{
+		Kind:       storage.PaymentKindOrder,
+		Amount1000: 45500,
+		Currency:   "EUR",
+		Reference:  "ORD-77",
+		ItemCount:  3,
+		Title:      "Coffee beans",
+	}
+	if got := extractPayment(msg); got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if content := extractTextContent(msg); content != "Order Coffee beans (3 items, 45.50 EUR)" {
+		t.Fatalf("unexpected order summary %q", content)
+	}
+}
+
+func TestExtractPaymentPrefersRequestNote(t *testing.T) {
+	msg := &waProto.Message{RequestPaymentMessage: &waProto.RequestPaymentMessage{
+		Amount1000:          proto.Uint64(12000),
+		CurrencyCodeIso4217: proto.String("INR"),
+		NoteMessage:         &waProto.Message{Conversation: proto.String("Dinner split")},
+	}}
+	payment := extractPayment(msg)
+	if payment.Kind != storage.PaymentKindRequest || payment.Amount1000 != 12000 || payment.Currency != "INR" {
+		t.Fatalf("unexpected payment request %+v", payment)
+	}
+	if content := extractTextContent(msg); content != "Dinner split" {
+		t.Fatalf("expected the request note as content, got %q", content)
+	}
+
+	msg.RequestPaymentMessage.NoteMessage = nil
+	if content := extractTextContent(msg); content != "Payment request: 12.00 INR" {
+		t.Fatalf("unexpected request summary %q", content)
+	}
+}
@@ -0,0 +1,172 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"whatsapp-client/internal/embedding"
+	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/webhook"
+)
+
+// MessageProcessor is a stage of live message ingestion. Stages run after the
+// message has been stored, in the order they were registered. Messages from
+// chats that are not stored are processed transiently; stages must check
+// IsTransient before persisting anything about them.
+type MessageProcessor interface {
+	OnMessage(ctx context.Context, msg storage.StoredMessage) error
+}
+
+// MessageProcessorFunc adapts a function to MessageProcessor.
+type MessageProcessorFunc func(ctx context.Context, msg storage.StoredMessage) error
+
+// OnMessage calls f.
+func (f MessageProcessorFunc) OnMessage(ctx context.Context, msg storage.StoredMessage) error {
+	return f(ctx, msg)
+}
+
+// ErrStopPipeline, returned by a stage, skips the stages after it without
+// being logged as a failure.
+var ErrStopPipeline = errors.New("stop message pipeline")
+
+type transientKey struct{}
+
+// WithTransient marks ctx as processing a message that was not stored.
+func WithTransient(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transientKey{}, true)
+}
+
+// IsTransient reports whether the message being processed was not stored.
+func IsTransient(ctx context.Context) bool {
+	transient, _ := ctx.Value(transientKey{}).(bool)
+	return transient
+}
+
+type pipelineStage struct {
+	name      string
+	processor MessageProcessor
+}
+
+// Pipeline runs registered message processors in order. A failing stage is
+// logged and the remaining stages still run.
+type Pipeline struct {
+	mu     sync.RWMutex
+	stages []pipelineStage
+}
+
+// NewPipeline returns a pipeline with no stages.
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// Register appends a stage; name identifies it in logs.
+func (p *Pipeline) Register(name string, processor MessageProcessor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stages = append(p.stages, pipelineStage{name: name, processor: processor})
+}
+
+// Stages returns the registered stage names in run order.
+func (p *Pipeline) Stages() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, 0, len(p.stages))
+	for _, stage := range p.stages {
+		names = append(names, stage.name)
+	}
+	return names
+}
+
+// Process runs msg through every stage.
+func (p *Pipeline) Process(ctx context.Context, msg storage.StoredMessage, logger waLog.Logger) {
+	if p == nil {
+		return
+	}
+	p.mu.RLock()
+	stages := append([]pipelineStage(nil), p.stages...)
+	p.mu.RUnlock()
+
+	for _, stage := range stages {
+		err := stage.processor.OnMessage(ctx, msg)
+		if errors.Is(err, ErrStopPipeline) {
+			return
+		}
+		if err != nil {
+			logger.Warnf("Message processor %s failed: %v", stage.name, err)
+		}
+	}
+}
+
+// NewMessagePipeline returns the bridge's standard stages: spam scoring when
+// a classifier is configured, language detection when enabled, semantic
+// search indexing, first-contact detection, waking snoozed chats, opt-out
+// recording, then event emission.
+func NewMessagePipeline(messageStore *storage.MessageStore, indexer *embedding.Indexer, emitter *webhook.Emitter, classifier *SpamClassifier) *Pipeline {
+	pipeline := NewPipeline()
+	if classifier != nil {
+		pipeline.Register("spam", MessageProcessorFunc(func(ctx context.Context, msg storage.StoredMessage) error {
+			if IsTransient(ctx) || msg.IsFromMe || msg.Content == "" {
+				return nil
+			}
+			verdict, hookErr := classifier.Classify(ctx, msg)
+			// Clean messages are not written, so most traffic costs one
+			// regex pass.
+			if verdict.Score > 0 {
+				if err := messageStore.SetSpamScore(verdict); err != nil {
+					return err
+				}
+			}
+			return hookErr
+		}))
+	}
+	if LanguageDetectionFromEnv() {
+		pipeline.Register("language", MessageProcessorFunc(func(ctx context.Context, msg storage.StoredMessage) error {
+			if IsTransient(ctx) {
+				return nil
+			}
+			language := detectLanguage(msg.Content)
+			if language == "" {
+				return nil
+			}
+			return messageStore.SetMessageLanguage(msg.ID, msg.ChatJID, language)
+		}))
+	}
+	pipeline.Register("embedding", MessageProcessorFunc(func(ctx context.Context, msg storage.StoredMessage) error {
+		if IsTransient(ctx) {
+			return nil
+		}
+		indexer.Enqueue(messageStore, msg.ID, msg.ChatJID, msg.Content)
+		return nil
+	}))
+	pipeline.Register("first_contact", MessageProcessorFunc(func(ctx context.Context, msg storage.StoredMessage) error {
+		if IsTransient(ctx) {
+			return nil
+		}
+		return emitFirstContactEvent(emitter, messageStore, msg)
+	}))
+	pipeline.Register("snooze", MessageProcessorFunc(func(ctx context.Context, msg storage.StoredMessage) error {
+		if IsTransient(ctx) || msg.IsFromMe {
+			return nil
+		}
+		snoozedUntil, err := messageStore.WakeSnoozedChat(msg.ChatJID)
+		if err != nil || snoozedUntil == nil {
+			return err
+		}
+		EmitChatReturned(emitter, msg.ChatJID, *snoozedUntil, &msg)
+		return nil
+	}))
+	optOutKeywords := OptOutKeywordsFromEnv()
+	pipeline.Register("opt_out", MessageProcessorFunc(func(ctx context.Context, msg storage.StoredMessage) error {
+		if IsTransient(ctx) {
+			return nil
+		}
+		return recordOptOutReply(messageStore, optOutKeywords, msg)
+	}))
+	pipeline.Register("events", MessageProcessorFunc(func(ctx context.Context, msg storage.StoredMessage) error {
+		emitMessageEvent(emitter, msg, IsTransient(ctx))
+		return nil
+	}))
+	return pipeline
+}
@@ -0,0 +1,50 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"whatsapp-client/internal/storage"
+)
+
+func TestPipelineRunsStagesInOrder(t *testing.T) {
+	var calls []string
+	stage := func(name string, err error) MessageProcessor {
+		return MessageProcessorFunc(func(ctx context.Context, msg storage.StoredMessage) error {
+			calls = append(calls, name+":"+msg.ID)
+			return err
+		})
+	}
+
+	pipeline := NewPipeline()
+	pipeline.Register("first", stage("first", nil))
+	pipeline.Register("failing", stage("failing", errors.New("boom")))
+	pipeline.Register("stop", stage("stop", ErrStopPipeline))
+	pipeline.Register("skipped", stage("skipped", nil))
+
+	pipeline.Process(context.Background(), storage.StoredMessage{ID: "m1"}, waLog.Noop)
+
+	if want := []string{"first:m1", "failing:m1", "stop:m1"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	if want := []string{"first", "failing", "stop", "skipped"}; !reflect.DeepEqual(pipeline.Stages(), want) {
+		t.Fatalf("Stages() = %v, want %v", pipeline.Stages(), want)
+	}
+}
+
+func TestNilPipelineIsNoop(t *testing.T) {
+	var pipeline *Pipeline
+	pipeline.Process(context.Background(), storage.StoredMessage{ID: "m1"}, waLog.Noop)
+}
+
+func TestTransientContext(t *testing.T) {
+	if IsTransient(context.Background()) {
+		t.Fatal("background context should not be transient")
+	}
+	if !IsTransient(WithTransient(context.Background())) {
+		t.Fatal("expected transient context")
+	}
+}
@@ -0,0 +1,163 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+const (
+	PlayedStatusSent     = "played"
+	PlayedStatusNotFound = "not_found"
+	PlayedStatusNotAudio = "not_audio"
+	PlayedStatusFromMe   = "from_me"
+	PlayedStatusFailed   = "failed"
+)
+
+// PlayedReceiptResult is the outcome of marking one message as played.
+type PlayedReceiptResult struct {
+	MessageID string
+	Status    string
+	Detail    string
+}
+
+// playedBatch is a set of messages acknowledged by a single receipt, which
+// WhatsApp requires to share a chat and a sender.
+type playedBatch struct {
+	Chat   types.JID
+	Sender types.JID
+	IDs    []types.MessageID
+}
+
+// groupPlayedReceipts batches messages by chat and sender, keeping the order
+// in which each batch first appears.
+func groupPlayedReceipts(infos []types.MessageInfo) []playedBatch {
+	var batches []playedBatch
+	index := map[string]int{}
+	for _, info := range infos {
+		key := info.Chat.String() + "|" + info.Sender.String()
+		i, ok := index[key]
+		if !ok {
+			i = len(batches)
+			index[key] = i
+			batches = append(batches, playedBatch{Chat: info.Chat, Sender: info.Sender})
+		}
+		batches[i].IDs = append(batches[i].IDs, info.ID)
+	}
+	return batches
+}
+
+// MarkVoiceNotesPlayed sends played receipts (the blue microphone) for audio
+// messages in a chat. Unlike read receipts these tell the sender the voice
+// note was listened to. Messages that are missing, not audio or sent by the
+// account itself are reported and skipped.
+func MarkVoiceNotesPlayed(ctx context.Context, client *whatsmeow.Client, messageStore *storage.MessageStore, chatJID string, messageIDs []string) ([]PlayedReceiptResult, error) {
+	if !client.IsConnected() {
+		return nil, notConnectedError()
+	}
+	chatID := jid.NormalizeChat(chatJID)
+	if chatID == "" {
+		return nil, fmt.Errorf("chat JID is required")
+	}
+
+	results := make([]PlayedReceiptResult, 0, len(messageIDs))
+	resultIndex := map[string]int{}
+	var infos []types.MessageInfo
+	for _, messageID := range messageIDs {
+		if _, seen := resultIndex[messageID]; seen || messageID == "" {
+			continue
+		}
+		resultIndex[messageID] = len(results)
+		result := PlayedReceiptResult{MessageID: messageID, Status: PlayedStatusSent}
+
+		mediaType, _, err := messageStore.GetMessageMediaTypeAndFilename(messageID, chatID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			result.Status = PlayedStatusNotFound
+		case err != nil:
+			result.Status, result.Detail = PlayedStatusFailed, err.Error()
+		case mediaType != "audio":
+			result.Status = PlayedStatusNotAudio
+		}
+		if result.Status != PlayedStatusSent {
+			results = append(results, result)
+			continue
+		}
+
+		origin, err := messageStore.GetMessageOrigin(messageID, chatID)
+		if err == nil && origin.IsFromMe {
+			result.Status = PlayedStatusFromMe
+		} else if err == nil {
+			var info types.MessageInfo
+			if info, err = storedMessageInfo(messageID, chatID, origin); err == nil {
+				infos = append(infos, info)
+			}
+		}
+		if err != nil {
+			result.Status, result.Detail = PlayedStatusFailed, err.Error()
+		}
+		results = append(results, result)
+	}
+
+	now := time.Now()
+	for _, batch := range groupPlayedReceipts(infos) {
+		if err := client.MarkRead(ctx, batch.IDs, now, batch.Chat, batch.Sender, types.ReceiptTypePlayed); err != nil {
+			for _, id := range batch.IDs {
+				results[resultIndex[id]].Status = PlayedStatusFailed
+				results[resultIndex[id]].Detail = fmt.Sprintf("Error sending played receipt: %v", err)
+			}
+		}
+	}
+	return results, nil
+}
+
+// receiptStatus maps an incoming receipt to the delivery stage it reports,
+// or "" for receipts that say nothing about the recipient's progress.
+func receiptStatus(receiptType types.ReceiptType) string {
+	switch receiptType {
+	case types.ReceiptTypeDelivered, types.ReceiptTypeInactive:
+		return storage.MessageStatusDelivered
+	case types.ReceiptTypeRead:
+		return storage.MessageStatusRead
+	case types.ReceiptTypePlayed:
+		return storage.MessageStatusPlayed
+	default:
+		return ""
+	}
+}
+
+// handleReceipt stores delivery, read and played receipts from other users
+// for messages the account sent. Read receipts from the account's own devices
+// mark the chat read up to the receipt's time; other receipts from them are
+// ignored.
+func handleReceipt(client *whatsmeow.Client, messageStore *storage.MessageStore, evt *events.Receipt, logger waLog.Logger) {
+	if evt.Type == types.ReceiptTypeReadSelf {
+		if chatID := canonicalizeChatID(client, evt.Chat); chatID != "" {
+			if err := messageStore.MarkChatRead(chatID, evt.Timestamp); err != nil {
+				logger.Warnf("Failed to store read marker (chat_ref=%s): %v", obfuscatedChatRef(chatID), err)
+			}
+		}
+		return
+	}
+	status := receiptStatus(evt.Type)
+	if status == "" || evt.IsFromMe || len(evt.MessageIDs) == 0 {
+		return
+	}
+	chatID := canonicalizeChatID(client, evt.Chat)
+	recipientID := canonicalizeSender(client, evt.Sender, evt.SenderAlt)
+	if chatID == "" || recipientID == "" {
+		return
+	}
+	if err := messageStore.RecordReceipt(chatID, recipientID, evt.MessageIDs, status, evt.Timestamp); err != nil {
+		logger.Warnf("Failed to store %s receipt (chat_ref=%s): %v", status, obfuscatedChatRef(chatID), err)
+	}
+}
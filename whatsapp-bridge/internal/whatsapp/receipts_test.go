@@ -0,0 +1,48 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestGroupPlayedReceipts(t *testing.T) {
+	group := types.NewJID("120363000000000000", types.GroupServer)
+	alice := types.NewJID("15551234567", types.DefaultUserServer)
+	bob := types.NewJID("15557654321", types.DefaultUserServer)
+	message := func(id string, chat, sender types.JID) types.MessageInfo {
+		return types.MessageInfo{MessageSource: types.MessageSource{Chat: chat, Sender: sender}, ID: id}
+	}
+
+	batches := groupPlayedReceipts([]types.MessageInfo{
+		message("1", group, alice),
+		message("2", group, bob),
+		message("3", group, alice),
+	})
+	if len(batches) != 2 {
+		t.Fatalf("got %d batches, want 2", len(batches))
+	}
+	if batches[0].Sender != alice || len(batches[0].IDs) != 2 || batches[0].IDs[1] != "3" {
+		t.Fatalf("first batch = %+v", batches[0])
+	}
+	if batches[1].Sender != bob || len(batches[1].IDs) != 1 {
+		t.Fatalf("second batch = %+v", batches[1])
+	}
+}
+
+func TestReceiptStatus(t *testing.T) {
+	cases := map[types.ReceiptType]string{
+		types.ReceiptTypeDelivered: "delivered",
+		types.ReceiptTypeInactive:  "delivered",
+		types.ReceiptTypeRead:      "read",
+		types.ReceiptTypePlayed:    "played",
+		types.ReceiptTypeReadSelf:  "",
+		types.ReceiptTypeSender:    "",
+		types.ReceiptTypeRetry:     "",
+	}
+	for receiptType, want := range cases {
+		if got := receiptStatus(receiptType); got != want {
+			t.Errorf("receiptStatus(%q) = %q, want %q", receiptType, got, want)
+		}
+	}
+}
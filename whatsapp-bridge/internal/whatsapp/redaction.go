@@ -0,0 +1,79 @@
+package whatsapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+const (
+	// DefaultRedactedPreviewChars is how much content a redacted message keeps.
+	DefaultRedactedPreviewChars = 32
+	// redactedIDPrefix marks pseudonymous IDs, which never collide with real
+	// phone numbers or LIDs.
+	redactedIDPrefix = "anon-"
+)
+
+// Redactor reduces a message to operational metadata: who (pseudonymously),
+// where, when and what kind, plus a short content preview.
+type Redactor struct {
+	// Key salts the ID hashes. Without one, a hashed phone number can be
+	// recovered by hashing candidate numbers.
+	Key []byte
+	// PreviewChars is how many characters of content are kept; zero keeps
+	// none.
+	PreviewChars int
+}
+
+// HashID returns a stable pseudonym for a user ID. The same ID always maps to
+// the same pseudonym under the same key, so activity can still be counted
+// per sender.
+func (r Redactor) HashID(id string) string {
+	if id == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, r.Key)
+	mac.Write([]byte(id))
+	return redactedIDPrefix + hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// Preview truncates content to PreviewChars characters, marking the cut.
+// Content cut entirely is reduced to the marker, so the message still counts.
+func (r Redactor) Preview(content string) string {
+	content = strings.TrimSpace(content)
+	if utf8.RuneCountInString(content) <= r.PreviewChars {
+		return content
+	}
+	runes := []rune(content)
+	return string(runes[:max(r.PreviewChars, 0)]) + "…"
+}
+
+// ChatID pseudonymizes a personal chat, whose ID is the other person's;
+// group and other chat IDs are kept.
+func (r Redactor) ChatID(chatID string) string {
+	if jid.ChatType(chatID) == jid.ChatTypeDirect {
+		return r.HashID(chatID)
+	}
+	return chatID
+}
+
+// Redact returns msg with hashed sender and personal chat IDs, truncated
+// content, no link preview, and no media, interactive selection or payment
+// details beyond their type.
+func (r Redactor) Redact(msg storage.StoredMessage) storage.StoredMessage {
+	msg.ChatJID = r.ChatID(msg.ChatJID)
+	msg.Sender = r.HashID(msg.Sender)
+	msg.RawSender = r.HashID(msg.RawSender)
+	msg.Content = r.Preview(msg.Content)
+	msg.LinkURL = ""
+	msg.LinkTitle = ""
+	msg.Selection = storage.Selection{Kind: msg.Selection.Kind}
+	msg.Payment = storage.Payment{Kind: msg.Payment.Kind}
+	msg.MessageMedia = storage.MessageMedia{MediaType: msg.MediaType}
+	return msg
+}
@@ -0,0 +1,79 @@
+package whatsapp
+
+import (
+	"strings"
+	"testing"
+
+	"whatsapp-client/internal/storage"
+)
+
+func TestRedactorHashID(t *testing.T) {
+	keyed := Redactor{Key: []byte("secret")}
+	first := keyed.HashID("15551234567")
+	if first != keyed.HashID("15551234567") {
+		t.Fatal("expected a stable pseudonym")
+	}
+	if !strings.HasPrefix(first, redactedIDPrefix) || strings.Contains(first, "15551234567") {
+		t.Fatalf("unexpected pseudonym %q", first)
+	}
+	if first == keyed.HashID("15557654321") {
+		t.Fatal("expected different IDs to get different pseudonyms")
+	}
+	if first == (Redactor{Key: []byte("other")}).HashID("15551234567") {
+		t.Fatal("expected the key to change the pseudonym")
+	}
+	if keyed.HashID("") != "" {
+		t.Fatal("expected an empty ID to stay empty")
+	}
+}
+
+func TestRedactorPreview(t *testing.T) {
+	tests := []struct {
+		chars   int
+		content string
+		want    string
+	}{
+		{5, "hello", "hello"},
+		{5, "hello world", "hello…"},
+		{3, "héllo", "hél…"},
+		{0, "hello", "…"},
+		{0, "", ""},
+	}
+	for _, tc := range tests {
+		if got := (Redactor{PreviewChars: tc.chars}).Preview(tc.content); got != tc.want {
+			t.Fatalf("Preview(%d, %q) = %q, want %q", tc.chars, tc.content, got, tc.want)
+		}
+	}
+}
+
+func TestRedactorRedact(t *testing.T) {
+	redactor := Redactor{Key: []byte("secret"), PreviewChars: 4}
+	msg := storage.StoredMessage{
+		ID:           "ABC",
+		ChatJID:      "15551234567",
+		Sender:       "15551234567",
+		RawSender:    "15551234567",
+		SenderServer: "s.whatsapp.net",
+		Content:      "meet me at the station",
+		LinkURL:      "https://example.com",
+		MessageMedia: storage.MessageMedia{MediaType: "image", Filename: "me.jpg", URL: "https://mmg", MediaKey: []byte{1}},
+	}
+	got := redactor.Redact(msg)
+	if got.ChatJID != redactor.HashID("15551234567") || got.Sender != got.ChatJID || got.RawSender != got.Sender {
+		t.Fatalf("expected hashed IDs, got %+v", got)
+	}
+	if got.Content != "meet…" || got.LinkURL != "" {
+		t.Fatalf("expected truncated content without link, got %+v", got)
+	}
+	if got.MediaType != "image" || got.Filename != "" || got.URL != "" || got.MediaKey != nil {
+		t.Fatalf("expected only the media type, got %+v", got.MessageMedia)
+	}
+	if got.ID != "ABC" || got.SenderServer != "s.whatsapp.net" {
+		t.Fatalf("expected metadata to be kept, got %+v", got)
+	}
+
+	group := redactor.Redact(storage.StoredMessage{ChatJID: "120363000000000000@g.us", Sender: "15551234567", Content: "hi"})
+	if group.ChatJID != "120363000000000000@g.us" {
+		t.Fatalf("expected group chat ID to be kept, got %q", group.ChatJID)
+	}
+}
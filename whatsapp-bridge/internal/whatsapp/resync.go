@@ -0,0 +1,62 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/jid"
+	"whatsapp-client/internal/storage"
+)
+
+// ResyncMessagesPerChat is how many messages before its anchor a resync asks
+// WhatsApp to send for each chat.
+const ResyncMessagesPerChat = 500
+
+// anchorChatJID rebuilds the WhatsApp JID of an anchor's chat. Personal chats
+// are stored under their canonical user ID alone, so their server follows the
+// identity strategy, or the server they were received with under as-received.
+func anchorChatJID(anchor storage.HistoryAnchor, strategy IdentityStrategy) (types.JID, error) {
+	if anchor.ChatJID == "" {
+		return types.JID{}, fmt.Errorf("anchor has no chat")
+	}
+	if strings.Contains(anchor.ChatJID, "@") {
+		return types.ParseJID(anchor.ChatJID)
+	}
+	server := types.DefaultUserServer
+	switch {
+	case strategy == IdentityStrategyLID:
+		server = types.HiddenUserServer
+	case strategy == IdentityStrategyAsReceived && jid.IsPersonalServer(anchor.ChatServer):
+		server = anchor.ChatServer
+	}
+	return types.NewJID(anchor.ChatJID, server), nil
+}
+
+// RequestChatHistory asks the phone for up to count messages of a chat sent
+// before its anchor. They arrive later as an on-demand history sync, which is
+// stored like any other.
+func RequestChatHistory(ctx context.Context, client *whatsmeow.Client, anchor storage.HistoryAnchor, count int) error {
+	if client == nil || !client.IsConnected() {
+		return notConnectedError()
+	}
+	if client.Store == nil || client.Store.ID == nil {
+		return &SendError{Code: SendErrorNotLoggedIn, Message: "Not logged in to WhatsApp", Err: whatsmeow.ErrNotLoggedIn}
+	}
+	chatJID, err := anchorChatJID(anchor, currentIdentityStrategy())
+	if err != nil {
+		return fmt.Errorf("invalid chat %q: %v", anchor.ChatJID, err)
+	}
+
+	request := client.BuildHistorySyncRequest(&types.MessageInfo{
+		MessageSource: types.MessageSource{Chat: chatJID, IsFromMe: anchor.IsFromMe},
+		ID:            anchor.MessageID,
+		Timestamp:     anchor.Timestamp,
+	}, count)
+	if _, err := client.SendMessage(ctx, client.Store.ID.ToNonAD(), request, whatsmeow.SendRequestExtra{Peer: true}); err != nil {
+		return classifySendError("Error requesting chat history", err)
+	}
+	return nil
+}
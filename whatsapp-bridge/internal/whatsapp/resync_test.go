@@ -0,0 +1,37 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+	"whatsapp-client/internal/storage"
+)
+
+func TestAnchorChatJID(t *testing.T) {
+	tests := []struct {
+		name     string
+		anchor   storage.HistoryAnchor
+		strategy IdentityStrategy
+		want     types.JID
+	}{
+		{"group", storage.HistoryAnchor{ChatJID: "120363000000000000@g.us"}, IdentityStrategyPN, types.NewJID("120363000000000000", types.GroupServer)},
+		{"pn strategy", storage.HistoryAnchor{ChatJID: "15551234567", ChatServer: types.HiddenUserServer}, IdentityStrategyPN, types.NewJID("15551234567", types.DefaultUserServer)},
+		{"lid strategy", storage.HistoryAnchor{ChatJID: "123456789012345"}, IdentityStrategyLID, types.NewJID("123456789012345", types.HiddenUserServer)},
+		{"as received", storage.HistoryAnchor{ChatJID: "123456789012345", ChatServer: types.HiddenUserServer}, IdentityStrategyAsReceived, types.NewJID("123456789012345", types.HiddenUserServer)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := anchorChatJID(tt.anchor, tt.strategy)
+			if err != nil {
+				t.Fatalf("anchorChatJID() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("anchorChatJID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := anchorChatJID(storage.HistoryAnchor{}, IdentityStrategyPN); err == nil {
+		t.Fatal("expected an anchor without a chat to be rejected")
+	}
+}
@@ -0,0 +1,127 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	SendErrorNotConnected           = "not_connected"
+	SendErrorNotLoggedIn            = "not_logged_in"
+	SendErrorRecipientNotOnWhatsApp = "recipient_not_on_whatsapp"
+	SendErrorRateLimited            = "rate_limited"
+	SendErrorMediaReuploadNeeded    = "media_reupload_needed"
+	SendErrorTimeout                = "send_timeout"
+	SendErrorFailed                 = "send_failed"
+)
+
+const (
+	notConnectedRetryAfter = 5 * time.Second
+	rateLimitRetryAfter    = 60 * time.Second
+	timeoutRetryAfter      = 10 * time.Second
+)
+
+// SendError is a classified WhatsApp send failure surfaced to API callers,
+// with a hint on whether and when the send may be retried.
+type SendError struct {
+	Code       string
+	Message    string
+	Retryable  bool
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *SendError) Error() string {
+	return e.Message
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// serverErrorCode extracts the status whatsmeow appends to
+// ErrServerReturnedError ("server returned error 429").
+func serverErrorCode(err error) int {
+	if !errors.Is(err, whatsmeow.ErrServerReturnedError) {
+		return 0
+	}
+	message := err.Error()
+	index := strings.LastIndex(message, whatsmeow.ErrServerReturnedError.Error())
+	if index < 0 {
+		return 0
+	}
+	fields := strings.Fields(message[index+len(whatsmeow.ErrServerReturnedError.Error()):])
+	if len(fields) == 0 {
+		return 0
+	}
+	code, _ := strconv.Atoi(fields[0])
+	return code
+}
+
+// classifySendError maps a whatsmeow failure to a SendError. action prefixes
+// the message, e.g. "Error sending message".
+func classifySendError(action string, err error) *SendError {
+	sendErr := &SendError{Code: SendErrorFailed, Message: fmt.Sprintf("%s: %v", action, err), Err: err}
+	switch {
+	case errors.Is(err, whatsmeow.ErrNotLoggedIn):
+		sendErr.Code = SendErrorNotLoggedIn
+	case errors.Is(err, whatsmeow.ErrNotConnected):
+		sendErr.Code = SendErrorNotConnected
+		sendErr.Retryable, sendErr.RetryAfter = true, notConnectedRetryAfter
+	case errors.Is(err, whatsmeow.ErrIQRateOverLimit), serverErrorCode(err) == 429:
+		sendErr.Code = SendErrorRateLimited
+		sendErr.Retryable, sendErr.RetryAfter = true, rateLimitRetryAfter
+	case errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith403),
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404),
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410),
+		errors.Is(err, whatsmeow.ErrIQGone):
+		sendErr.Code = SendErrorMediaReuploadNeeded
+		sendErr.Retryable = !errors.Is(err, whatsmeow.ErrMediaNotAvailableOnPhone)
+	case errors.Is(err, whatsmeow.ErrMessageTimedOut),
+		errors.Is(err, whatsmeow.ErrIQTimedOut),
+		errors.Is(err, context.DeadlineExceeded):
+		sendErr.Code = SendErrorTimeout
+		sendErr.Retryable, sendErr.RetryAfter = true, timeoutRetryAfter
+	case errors.Is(err, whatsmeow.ErrPhoneNumberTooShort),
+		errors.Is(err, whatsmeow.ErrPhoneNumberIsNotInternational),
+		strings.Contains(err.Error(), "no LID found"):
+		sendErr.Code = SendErrorRecipientNotOnWhatsApp
+	}
+	return sendErr
+}
+
+// notConnectedError reports a send attempted while the client is offline.
+func notConnectedError() *SendError {
+	return &SendError{
+		Code:       SendErrorNotConnected,
+		Message:    "Not connected to WhatsApp",
+		Retryable:  true,
+		RetryAfter: notConnectedRetryAfter,
+		Err:        whatsmeow.ErrNotConnected,
+	}
+}
+
+// confirmRecipientMissing reclassifies an unexplained send failure to a phone
+// number as recipient_not_on_whatsapp when WhatsApp says the number is not
+// registered.
+func confirmRecipientMissing(client *whatsmeow.Client, recipientJID types.JID, sendErr *SendError) *SendError {
+	if sendErr.Code != SendErrorFailed || recipientJID.Server != types.DefaultUserServer {
+		return sendErr
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	results, err := client.IsOnWhatsApp(ctx, []string{"+" + recipientJID.User})
+	if err != nil || len(results) == 0 || results[0].IsIn {
+		return sendErr
+	}
+	sendErr.Code = SendErrorRecipientNotOnWhatsApp
+	sendErr.Message = fmt.Sprintf("%s is not registered on WhatsApp", recipientJID.User)
+	return sendErr
+}
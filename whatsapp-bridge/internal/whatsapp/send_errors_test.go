@@ -0,0 +1,50 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+func TestClassifySendError(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		code       string
+		retryable  bool
+		retryAfter time.Duration
+	}{
+		{"not logged in", whatsmeow.ErrNotLoggedIn, SendErrorNotLoggedIn, false, 0},
+		{"not connected", fmt.Errorf("failed to send message node: %w", whatsmeow.ErrNotConnected), SendErrorNotConnected, true, notConnectedRetryAfter},
+		{"iq rate limit", whatsmeow.ErrIQRateOverLimit, SendErrorRateLimited, true, rateLimitRetryAfter},
+		{"server 429", fmt.Errorf("%w %d", whatsmeow.ErrServerReturnedError, 429), SendErrorRateLimited, true, rateLimitRetryAfter},
+		{"server 479", fmt.Errorf("%w %d", whatsmeow.ErrServerReturnedError, 479), SendErrorFailed, false, 0},
+		{"media gone", whatsmeow.ErrMediaDownloadFailedWith410, SendErrorMediaReuploadNeeded, true, 0},
+		{"message timeout", whatsmeow.ErrMessageTimedOut, SendErrorTimeout, true, timeoutRetryAfter},
+		{"context deadline", context.DeadlineExceeded, SendErrorTimeout, true, timeoutRetryAfter},
+		{"no lid", errors.New("no LID found for 15551234567@s.whatsapp.net from server"), SendErrorRecipientNotOnWhatsApp, false, 0},
+		{"other", errors.New("boom"), SendErrorFailed, false, 0},
+	}
+	for _, tc := range cases {
+		got := classifySendError("Error sending message", tc.err)
+		if got.Code != tc.code || got.Retryable != tc.retryable || got.RetryAfter != tc.retryAfter {
+			t.Errorf("%s: got code=%q retryable=%v retry_after=%v, want %q %v %v", tc.name, got.Code, got.Retryable, got.RetryAfter, tc.code, tc.retryable, tc.retryAfter)
+		}
+		if !errors.Is(got, tc.err) {
+			t.Errorf("%s: classified error does not wrap the original", tc.name)
+		}
+	}
+}
+
+func TestServerErrorCode(t *testing.T) {
+	if got := serverErrorCode(fmt.Errorf("%w %d", whatsmeow.ErrServerReturnedError, 463)); got != 463 {
+		t.Fatalf("serverErrorCode() = %d, want 463", got)
+	}
+	if got := serverErrorCode(errors.New("server returned error 429")); got != 0 {
+		t.Fatalf("serverErrorCode() on unrelated error = %d, want 0", got)
+	}
+}
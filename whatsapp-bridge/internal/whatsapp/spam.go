@@ -0,0 +1,187 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-client/internal/storage"
+)
+
+const (
+	// DefaultSpamThreshold is the score from which messages are quarantined.
+	DefaultSpamThreshold = 60
+	// defaultSpamPatternScore is what a match of WHATSAPP_SPAM_PATTERN adds.
+	defaultSpamPatternScore = 60
+	maxSpamScore            = 100
+	spamHookTimeout         = 5 * time.Second
+)
+
+// spamRule is one heuristic: a match adds score and names the reason.
+type spamRule struct {
+	reason  string
+	score   int
+	pattern *regexp.Regexp
+}
+
+// builtinSpamRules are deliberately conservative; no single rule reaches the
+// default threshold on its own.
+var builtinSpamRules = []spamRule{
+	{"short_link", 25, regexp.MustCompile(`(?i)\b(bit\.ly|tinyurl\.com|t\.co|goo\.gl|cutt\.ly|is\.gd|rb\.gy|shorturl\.at)/`)},
+	{"prize", 35, regexp.MustCompile(`(?i)\b(you('ve| have)? won|winner|lottery|jackpot|gift ?card|free (iphone|prize|gift))\b`)},
+	{"money_lure", 35, regexp.MustCompile(`(?i)\b(crypto|bitcoin|usdt|forex|guaranteed (profit|returns?)|double your (money|investment)|earn \$?\d+)`)},
+	{"job_offer", 25, regexp.MustCompile(`(?i)\b(work from home|part[- ]time job|daily (income|salary|commission)|like (videos|posts) (and|to) earn)\b`)},
+	{"urgency", 20, regexp.MustCompile(`(?i)\b(act now|urgent(ly)?|limited time|expires? today|click (here|the link|below)|verify your account|claim (your|now))\b`)},
+}
+
+// SpamClassifier scores incoming messages with regex heuristics and,
+// optionally, an external hook, and quarantines those at or above the
+// threshold so they stay out of normal queries until reviewed.
+type SpamClassifier struct {
+	rules     []spamRule
+	Threshold int
+	// HookURL, when set, receives each scored message and may replace its
+	// score.
+	HookURL    string
+	httpClient *http.Client
+}
+
+// SpamClassifierFromEnv loads WHATSAPP_SPAM_CLASSIFIER, WHATSAPP_SPAM_THRESHOLD,
+// WHATSAPP_SPAM_PATTERN, WHATSAPP_SPAM_PATTERN_SCORE and WHATSAPP_SPAM_HOOK_URL.
+// It returns nil unless the classifier is enabled.
+func SpamClassifierFromEnv() *SpamClassifier {
+	if !parseIngestBool("WHATSAPP_SPAM_CLASSIFIER") {
+		return nil
+	}
+	classifier := &SpamClassifier{
+		rules:      append([]spamRule(nil), builtinSpamRules...),
+		Threshold:  DefaultSpamThreshold,
+		HookURL:    strings.TrimSpace(os.Getenv("WHATSAPP_SPAM_HOOK_URL")),
+		httpClient: &http.Client{Timeout: spamHookTimeout},
+	}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_SPAM_THRESHOLD")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxSpamScore {
+			fmt.Printf("Warning: invalid WHATSAPP_SPAM_THRESHOLD=%q, using %d\n", raw, DefaultSpamThreshold)
+		} else {
+			classifier.Threshold = parsed
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv("WHATSAPP_SPAM_PATTERN")); raw != "" {
+		pattern, err := regexp.Compile(raw)
+		if err != nil {
+			fmt.Printf("Warning: invalid WHATSAPP_SPAM_PATTERN: %v\n", err)
+		} else {
+			score := defaultSpamPatternScore
+			if rawScore := strings.TrimSpace(os.Getenv("WHATSAPP_SPAM_PATTERN_SCORE")); rawScore != "" {
+				parsed, err := strconv.Atoi(rawScore)
+				if err != nil || parsed < 0 || parsed > maxSpamScore {
+					fmt.Printf("Warning: invalid WHATSAPP_SPAM_PATTERN_SCORE=%q, using %d\n", rawScore, defaultSpamPatternScore)
+				} else {
+					score = parsed
+				}
+			}
+			classifier.rules = append(classifier.rules, spamRule{reason: "pattern", score: score, pattern: pattern})
+		}
+	}
+	return classifier
+}
+
+// scoreSpam sums the scores of the rules content matches, capped at 100.
+func scoreSpam(rules []spamRule, content string) (int, []string) {
+	score := 0
+	var reasons []string
+	for _, rule := range rules {
+		if rule.pattern.MatchString(content) {
+			score += rule.score
+			reasons = append(reasons, rule.reason)
+		}
+	}
+	if score > maxSpamScore {
+		score = maxSpamScore
+	}
+	return score, reasons
+}
+
+type spamHookRequest struct {
+	MessageID string   `json:"message_id"`
+	ChatJID   string   `json:"chat_jid"`
+	Sender    string   `json:"sender"`
+	Content   string   `json:"content"`
+	MediaType string   `json:"media_type,omitempty"`
+	Score     int      `json:"score"`
+	Reasons   []string `json:"reasons,omitempty"`
+}
+
+type spamHookResponse struct {
+	Score   *int     `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// callHook posts the message and heuristic verdict to HookURL. A response
+// with a score replaces the heuristic score; its reasons are added.
+func (classifier *SpamClassifier) callHook(ctx context.Context, msg storage.StoredMessage, score int, reasons []string) (int, []string, error) {
+	payload, err := json.Marshal(spamHookRequest{
+		MessageID: msg.ID,
+		ChatJID:   msg.ChatJID,
+		Sender:    msg.Sender,
+		Content:   msg.Content,
+		MediaType: msg.MediaType,
+		Score:     score,
+		Reasons:   reasons,
+	})
+	if err != nil {
+		return score, reasons, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, classifier.HookURL, bytes.NewReader(payload))
+	if err != nil {
+		return score, reasons, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := classifier.httpClient.Do(req)
+	if err != nil {
+		return score, reasons, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return score, reasons, fmt.Errorf("spam hook returned status %d", resp.StatusCode)
+	}
+	var verdict spamHookResponse
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return score, reasons, fmt.Errorf("failed to decode spam hook response: %w", err)
+	}
+	if verdict.Score != nil {
+		score = *verdict.Score
+		if score < 0 {
+			score = 0
+		}
+		if score > maxSpamScore {
+			score = maxSpamScore
+		}
+	}
+	return score, append(reasons, verdict.Reasons...), nil
+}
+
+// Classify scores an incoming message. A hook failure is returned along with
+// the heuristic verdict, which is still usable.
+func (classifier *SpamClassifier) Classify(ctx context.Context, msg storage.StoredMessage) (storage.SpamScore, error) {
+	score, reasons := scoreSpam(classifier.rules, msg.Content)
+	var err error
+	if classifier.HookURL != "" {
+		score, reasons, err = classifier.callHook(ctx, msg, score, reasons)
+	}
+	return storage.SpamScore{
+		MessageID:   msg.ID,
+		ChatJID:     msg.ChatJID,
+		Score:       score,
+		Reasons:     reasons,
+		Quarantined: score >= classifier.Threshold,
+	}, err
+}
@@ -0,0 +1,73 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"whatsapp-client/internal/storage"
+)
+
+func TestScoreSpam(t *testing.T) {
+	tests := []struct {
+		content     string
+		wantScore   int
+		wantReasons []string
+	}{
+		{"see you at lunch tomorrow?", 0, nil},
+		{"Congratulations, you have won a gift card! Claim your prize at bit.ly/abc", 80, []string{"short_link", "prize", "urgency"}},
+		{"Work from home and earn $500 daily with crypto", 60, []string{"money_lure", "job_offer"}},
+	}
+	for _, tt := range tests {
+		score, reasons := scoreSpam(builtinSpamRules, tt.content)
+		if score != tt.wantScore || !reflect.DeepEqual(reasons, tt.wantReasons) {
+			t.Errorf("scoreSpam(%q) = %d %v, want %d %v", tt.content, score, reasons, tt.wantScore, tt.wantReasons)
+		}
+	}
+}
+
+func TestSpamClassifierFromEnv(t *testing.T) {
+	t.Setenv("WHATSAPP_SPAM_CLASSIFIER", "")
+	if SpamClassifierFromEnv() != nil {
+		t.Fatal("expected the classifier to be disabled by default")
+	}
+
+	t.Setenv("WHATSAPP_SPAM_CLASSIFIER", "true")
+	t.Setenv("WHATSAPP_SPAM_THRESHOLD", "40")
+	t.Setenv("WHATSAPP_SPAM_PATTERN", `(?i)\bjoin my channel\b`)
+	t.Setenv("WHATSAPP_SPAM_PATTERN_SCORE", "45")
+	classifier := SpamClassifierFromEnv()
+	if classifier == nil || classifier.Threshold != 40 {
+		t.Fatalf("unexpected classifier: %+v", classifier)
+	}
+	verdict, err := classifier.Classify(context.Background(), storage.StoredMessage{ID: "m1", ChatJID: "15551234567", Content: "Join my channel"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict.Score != 45 || !verdict.Quarantined {
+		t.Fatalf("unexpected verdict: %+v", verdict)
+	}
+}
+
+func TestSpamClassifierHookReplacesScore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req spamHookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Score != 25 {
+			t.Errorf("unexpected hook request: %+v %v", req, err)
+		}
+		_, _ = w.Write([]byte(`{"score": 90, "reasons": ["phishing"]}`))
+	}))
+	defer server.Close()
+
+	classifier := &SpamClassifier{rules: builtinSpamRules, Threshold: DefaultSpamThreshold, HookURL: server.URL, httpClient: server.Client()}
+	verdict, err := classifier.Classify(context.Background(), storage.StoredMessage{ID: "m1", ChatJID: "15551234567", Content: "docs at bit.ly/x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict.Score != 90 || !verdict.Quarantined || !reflect.DeepEqual(verdict.Reasons, []string{"short_link", "phishing"}) {
+		t.Fatalf("unexpected verdict: %+v", verdict)
+	}
+}
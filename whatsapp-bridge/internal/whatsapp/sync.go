@@ -7,43 +7,59 @@ import (
 	"time"
 
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	"whatsapp-client/internal/bootstrap"
+	"whatsapp-client/internal/embedding"
 	"whatsapp-client/internal/storage"
+	"whatsapp-client/internal/webhook"
 )
 
-// syncSenderAliases upserts sender aliases and rewrites old sender IDs.
+// syncSenderAliases upserts a sender's or chat's aliases and, when a mapping
+// is new, queues promoting the stored IDs in the background.
 func syncSenderAliases(store *storage.MessageStore, logger waLog.Logger, canonicalID string, aliases []string, ts time.Time, contextLabel string) {
-	if err := store.StoreSenderAliases(canonicalID, aliases, ts); err != nil {
+	learned, err := store.StoreSenderAliases(canonicalID, aliases, ts)
+	if err != nil {
 		logger.Warnf("Failed to store %s aliases: %v", contextLabel, err)
+		return
 	}
-	if err := store.PromoteCanonicalSender(canonicalID, aliases); err != nil {
-		logger.Warnf("Failed to promote %s IDs: %v", contextLabel, err)
-	}
-}
-
-// syncChatAliases upserts chat aliases and rewrites old chat IDs.
-func syncChatAliases(store *storage.MessageStore, logger waLog.Logger, canonicalID string, aliases []string, ts time.Time, contextLabel string) {
-	if err := store.StoreSenderAliases(canonicalID, aliases, ts); err != nil {
-		logger.Warnf("Failed to store %s chat aliases: %v", contextLabel, err)
-	}
-	if err := store.PromoteCanonicalChat(canonicalID, aliases); err != nil {
-		logger.Warnf("Failed to promote %s chat IDs: %v", contextLabel, err)
+	if learned {
+		store.QueueAliasPromotion(canonicalID, aliases)
 	}
 }
 
 // WireEventHandlers attaches WhatsApp event processors for live + history sync.
-func WireEventHandlers(client *whatsmeow.Client, messageStore *storage.MessageStore, logger waLog.Logger) {
+// Stored live messages run through pipeline; history sync text is handed to
+// indexer for embedding when semantic search is enabled, and group membership
+// changes are journaled and posted through emitter.
+func WireEventHandlers(client *whatsmeow.Client, messageStore *storage.MessageStore, indexer *embedding.Indexer, pipeline *Pipeline, emitter *webhook.Emitter, logger waLog.Logger) {
+	rules := IngestRulesFromEnv()
 	client.AddEventHandler(func(evt interface{}) {
 		switch v := evt.(type) {
 		case *events.Message:
-			handleMessage(client, messageStore, v, logger)
+			handleMessage(client, messageStore, pipeline, rules, v, logger)
 		case *events.HistorySync:
-			handleHistorySync(client, messageStore, v, logger)
+			handleHistorySync(client, messageStore, indexer, emitter, rules, v, logger)
+		case *events.MediaRetry:
+			handleMediaRetry(v)
+		case *events.Receipt:
+			handleReceipt(client, messageStore, v, logger)
+		case *events.GroupInfo:
+			handleGroupInfo(client, messageStore, emitter, v)
+			if v.Link != nil || v.Unlink != nil || v.Delete != nil {
+				syncCommunitiesInBackground(client, messageStore)
+			}
+		case *events.JoinedGroup:
+			if v.IsParent || !v.LinkedParentJID.IsEmpty() {
+				syncCommunitiesInBackground(client, messageStore)
+			}
 		case *events.Connected:
 			logger.Infof("Connected to WhatsApp")
+			handleConnectionEstablished(time.Now())
+			syncCommunitiesInBackground(client, messageStore)
 			status := bootstrap.GetAuthStatus()
 			if status.State == "awaiting_qr" || status.State == "logging_in" || status.State == "syncing" {
 				bootstrap.SetSyncing("Syncing WhatsApp messages", 20, 0, 0)
@@ -60,57 +76,130 @@ func WireEventHandlers(client *whatsmeow.Client, messageStore *storage.MessageSt
 			} else {
 				bootstrap.SetConnected("WhatsApp connected")
 			}
+		case *events.PushName:
+			backfillChatName(messageStore, canonicalizeChatID(client, v.JID), v.NewPushName, logger)
+		case *events.Contact:
+			handleContactEvent(client, messageStore, v, logger)
+		case *events.AppStateSyncComplete:
+			if v.Name == appstate.WAPatchCriticalUnblockLow {
+				go importSavedContactsAfterSync(client, messageStore, logger)
+			}
 		case *events.LoggedOut:
 			logger.Warnf("Device logged out, please scan QR code to log in again")
 			bootstrap.SetLoggedOut("WhatsApp logged out, reconnect required")
+		case *events.KeepAliveTimeout:
+			logger.Warnf("WhatsApp keepalive timed out (%d in a row)", v.ErrorCount)
+			handleKeepAliveTimeout(v)
+		case *events.KeepAliveRestored:
+			logger.Infof("WhatsApp keepalive restored")
+			handleKeepAliveRestored(time.Now())
+		case *events.StreamReplaced:
+			logger.Warnf("Another client connected with this device; not reconnecting")
+			bootstrap.SetDisconnectedReason(bootstrap.DisconnectStreamReplaced, "WhatsApp session replaced by another client, reconnect to take it back")
+		case *events.Disconnected:
+			// Logouts and replaced streams already recorded a more specific reason.
+			if status := bootstrap.GetAuthStatus(); status.State != "logged_out" && status.DisconnectReason != bootstrap.DisconnectStreamReplaced {
+				bootstrap.SetDisconnectedReason(bootstrap.DisconnectNetworkError, "WhatsApp connection lost, reconnecting")
+				handleAccountDisconnect(time.Now())
+			}
+		case *events.TemporaryBan:
+			logger.Errorf("WhatsApp temporarily banned this account: %s", v)
+			handleTemporaryBan(v, time.Now())
 		}
 	})
 }
 
 // handleMessage processes live incoming messages and stores them in sqlite.
-func handleMessage(client *whatsmeow.Client, messageStore *storage.MessageStore, msg *events.Message, logger waLog.Logger) {
+func handleMessage(client *whatsmeow.Client, messageStore *storage.MessageStore, pipeline *Pipeline, rules IngestRules, msg *events.Message, logger waLog.Logger) {
 	chatJID := msg.Info.Chat.ToNonAD()
 	chatID := canonicalizeChatID(client, chatJID)
 	sender := canonicalizeSender(client, msg.Info.Sender, msg.Info.SenderAlt)
+	if rules.IgnoresChat(client, chatJID, chatID, 0) || rules.IgnoresSender(sender) {
+		return
+	}
+	persist, err := rules.Stores(messageStore, chatID)
+	if err != nil {
+		logger.Warnf("Failed to read chat tracking, not storing message: %v", err)
+	}
+	if !persist && rules.Untracked == UntrackedDrop {
+		return
+	}
+	redact := !persist && rules.Untracked == UntrackedRedact
 
-	name := getChatName(client, messageStore, chatJID, chatID, nil, sender, logger)
-	if err := messageStore.StoreChat(chatID, name, msg.Info.Timestamp); err != nil {
-		logger.Warnf("Failed to store chat: %v", err)
+	if persist {
+		pushName := ""
+		if !msg.Info.IsFromMe && !msg.Info.IsGroup {
+			pushName = msg.Info.PushName
+		}
+		name := getChatName(client, messageStore, chatJID, chatID, nil, pushName, logger)
+		if err := messageStore.StoreChat(chatID, name, msg.Info.Timestamp); err != nil {
+			logger.Warnf("Failed to store chat: %v", err)
+		}
+	} else if redact {
+		if err := messageStore.StoreChat(rules.Redactor.ChatID(chatID), "", msg.Info.Timestamp); err != nil {
+			logger.Warnf("Failed to store chat: %v", err)
+		}
 	}
 
 	content := extractTextContent(msg.Message)
-	mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength := extractMediaInfo(msg.Message)
-	if content == "" && mediaType == "" {
+	media, keep := rules.Filter(content, extractMediaInfo(msg.Message))
+	if !keep {
+		return
+	}
+
+	linkURL, linkTitle := extractLinkPreview(msg.Message)
+	forwarded, forwardingScore := extractForwarding(msg.Message)
+	stored := storage.StoredMessage{
+		ID:              msg.Info.ID,
+		ChatJID:         chatID,
+		Sender:          sender,
+		RawSender:       msg.Info.Sender.ToNonAD().User,
+		SenderServer:    msg.Info.Sender.Server,
+		ChatServer:      chatJID.Server,
+		Content:         content,
+		Timestamp:       msg.Info.Timestamp,
+		IsFromMe:        msg.Info.IsFromMe,
+		IsSelfChat:      isSelfChat(client, chatID),
+		QuotedMessageID: extractQuotedMessageID(msg.Message),
+		LinkURL:         linkURL,
+		LinkTitle:       linkTitle,
+		IsForwarded:     forwarded,
+		ForwardingScore: forwardingScore,
+		Selection:       extractSelection(msg.Message),
+		Payment:         extractPayment(msg.Message),
+		MessageMedia:    media,
+	}
+	if !msg.Info.IsFromMe {
+		stored.PushName = msg.Info.PushName
+	}
+	if redact {
+		if err := messageStore.StoreMessage(rules.Redactor.Redact(stored)); err != nil {
+			logger.Warnf("Failed to store redacted message: %v", err)
+		}
+		return
+	}
+	if !persist {
+		pipeline.Process(WithTransient(context.Background()), stored, logger)
 		return
 	}
 
 	aliasIDs := senderAliasIDs(client, msg.Info.Sender, msg.Info.SenderAlt, sender)
 	syncSenderAliases(messageStore, logger, sender, aliasIDs, msg.Info.Timestamp, "sender")
 
-	if chatJID.Server != "g.us" {
+	if isPersonalChat(chatJID) {
 		chatAliases := chatAliasIDs(client, chatJID, chatID)
-		syncChatAliases(messageStore, logger, chatID, chatAliases, msg.Info.Timestamp, "live")
-	}
-
-	err := messageStore.StoreMessage(
-		msg.Info.ID,
-		chatID,
-		sender,
-		content,
-		msg.Info.Timestamp,
-		msg.Info.IsFromMe,
-		mediaType,
-		filename,
-		url,
-		mediaKey,
-		fileSHA256,
-		fileEncSHA256,
-		fileLength,
-	)
-	if err != nil {
+		syncSenderAliases(messageStore, logger, chatID, chatAliases, msg.Info.Timestamp, "live chat")
+	}
+
+	if err := messageStore.StoreMessage(stored); err != nil {
 		logger.Warnf("Failed to store message: %v", err)
 		return
 	}
+	pipeline.Process(context.Background(), stored, logger)
+	if err := messageStore.RecordSenderPushName(sender, stored.PushName, msg.Info.Timestamp); err != nil {
+		logger.Warnf("Failed to record sender push name: %v", err)
+	}
+	attributeQuotedSender(client, messageStore, chatJID, chatID, msg.Message, logger)
 
 	timestamp := msg.Info.Timestamp.Format("2006-01-02 15:04:05")
 	direction := "←"
@@ -118,12 +207,12 @@ func handleMessage(client *whatsmeow.Client, messageStore *storage.MessageStore,
 		direction = "→"
 	}
 	messageRef := obfuscatedMessageRef(msg.Info.ID)
-	if mediaType != "" {
+	if media.MediaType != "" {
 		logger.Infof(
 			"Stored live media message: message_ref=%s direction=%s type=%s ts=%s",
 			messageRef,
 			direction,
-			mediaType,
+			media.MediaType,
 			timestamp,
 		)
 	} else if content != "" {
@@ -136,86 +225,130 @@ func handleMessage(client *whatsmeow.Client, messageStore *storage.MessageStore,
 	}
 }
 
-// getChatName determines the best available chat display name.
-func getChatName(client *whatsmeow.Client, messageStore *storage.MessageStore, jid types.JID, chatJID string, conversation interface{}, sender string, logger waLog.Logger) string {
+// getChatName determines the best available chat display name. A direct
+// chat's saved contact name comes first; placeholder names are re-resolved,
+// falling back to pushName for direct chats when the contact store has no
+// name yet.
+func getChatName(client *whatsmeow.Client, messageStore *storage.MessageStore, jid types.JID, chatJID string, conversation interface{}, pushName string, logger waLog.Logger) string {
 	chatRef := obfuscatedChatRef(chatJID)
+	// A name saved in the address book wins over push names and stored names.
+	if jid.Server == types.DefaultUserServer || jid.Server == types.HiddenUserServer {
+		if saved, err := messageStore.GetSavedContactName(chatJID); err == nil && saved != "" {
+			return saved
+		}
+	}
 	existingName, err := messageStore.GetChatName(chatJID)
-	if err == nil && existingName != "" {
+	if err == nil && !isPlaceholderChatName(chatJID, existingName) {
 		logger.Infof("Using existing chat name: chat_ref=%s", chatRef)
 		return existingName
 	}
 
-	var name string
-	if jid.Server == "g.us" {
-		logger.Infof("Resolving group chat name: chat_ref=%s", chatRef)
-		if conversation != nil {
-			var displayName, convName *string
-			v := reflect.ValueOf(conversation)
-			if v.Kind() == reflect.Ptr && !v.IsNil() {
-				v = v.Elem()
-				if displayNameField := v.FieldByName("DisplayName"); displayNameField.IsValid() && displayNameField.Kind() == reflect.Ptr && !displayNameField.IsNil() {
-					dn := displayNameField.Elem().String()
-					displayName = &dn
-				}
-				if nameField := v.FieldByName("Name"); nameField.IsValid() && nameField.Kind() == reflect.Ptr && !nameField.IsNil() {
-					n := nameField.Elem().String()
-					convName = &n
-				}
-			}
-			if displayName != nil && *displayName != "" {
-				name = *displayName
-			} else if convName != nil && *convName != "" {
-				name = *convName
-			}
+	if jid.Server == types.BroadcastServer {
+		name := conversationName(conversation)
+		if name == "" && jid.User == types.StatusBroadcastJID.User {
+			name = "Status updates"
+		} else if name == "" {
+			name = fmt.Sprintf("Broadcast list %s", jid.User)
 		}
+		logger.Infof("Resolved broadcast chat name: chat_ref=%s", chatRef)
+		return name
+	}
 
-		if name == "" {
-			groupInfo, err := client.GetGroupInfo(context.Background(), jid)
-			if err == nil && groupInfo.Name != "" {
+	// Lookups that recently found nothing are not repeated until the retry
+	// window passes or a contact sync completes.
+	misses := chatNameMisses()
+	name := conversationName(conversation)
+	if name == "" && !misses.missed(chatJID) {
+		if jid.Server == types.GroupServer {
+			logger.Infof("Resolving group chat name: chat_ref=%s", chatRef)
+			if groupInfo, err := client.GetGroupInfo(context.Background(), jid); err == nil {
 				name = groupInfo.Name
-			} else {
-				name = fmt.Sprintf("Group %s", jid.User)
 			}
+		} else {
+			logger.Infof("Resolving contact chat name: chat_ref=%s", chatRef)
+			if contact, err := client.Store.Contacts.GetContact(context.Background(), jid); err == nil {
+				name = contactDisplayName(contact)
+			}
+		}
+		if name == "" {
+			misses.record(chatJID)
 		}
-		logger.Infof("Resolved group chat name: chat_ref=%s", chatRef)
+	}
+	if name == "" && jid.Server != types.GroupServer {
+		name = pushName
+	}
+	if name != "" {
+		misses.forget(chatJID)
+		logger.Infof("Resolved chat name: chat_ref=%s", chatRef)
 		return name
 	}
 
-	logger.Infof("Resolving contact chat name: chat_ref=%s", chatRef)
-	contact, err := client.Store.Contacts.GetContact(context.Background(), jid)
-	if err == nil && contact.FullName != "" {
-		name = contact.FullName
-	} else if sender != "" {
-		name = sender
-	} else {
-		name = jid.User
+	if existingName != "" {
+		return existingName
+	}
+	if jid.Server == types.GroupServer {
+		return fmt.Sprintf("Group %s", jid.User)
 	}
-	logger.Infof("Resolved contact chat name: chat_ref=%s", chatRef)
-	return name
+	return chatJID
 }
 
-// handleHistorySync processes historical conversation snapshots pushed by WhatsApp.
-func handleHistorySync(client *whatsmeow.Client, messageStore *storage.MessageStore, historySync *events.HistorySync, logger waLog.Logger) {
+// conversationName returns the display name, or failing that the name, carried
+// by a history sync conversation.
+func conversationName(conversation interface{}) string {
+	v := reflect.ValueOf(conversation)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ""
+	}
+	v = v.Elem()
+	for _, field := range []string{"DisplayName", "Name"} {
+		value := v.FieldByName(field)
+		if value.IsValid() && value.Kind() == reflect.Ptr && !value.IsNil() {
+			if name := value.Elem().String(); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// handleHistorySync processes historical conversation snapshots pushed by
+// WhatsApp, reporting progress after each conversation.
+func handleHistorySync(client *whatsmeow.Client, messageStore *storage.MessageStore, indexer *embedding.Indexer, emitter *webhook.Emitter, rules IngestRules, historySync *events.HistorySync, logger waLog.Logger) {
 	totalConversations := len(historySync.Data.Conversations)
 	logger.Infof("Received history sync event with %d conversations", totalConversations)
+	if !beginHistorySync() {
+		logger.Infof("History sync is cancelled, skipping %d conversations", totalConversations)
+		if bootstrap.GetAuthStatus().State == "syncing" {
+			bootstrap.SetConnected("WhatsApp connected")
+		}
+		return
+	}
+	defer endHistorySync()
 	if totalConversations > 0 {
 		bootstrap.SetSyncing("Syncing WhatsApp messages", 25, 0, totalConversations)
 	}
 
-	updateProgress := func(processed int) {
-		if totalConversations <= 0 {
-			return
-		}
-		progress := 25 + int(float64(processed)/float64(totalConversations)*70)
-		if progress > 95 {
-			progress = 95
-		}
-		bootstrap.SetSyncingProgress(progress, processed, totalConversations)
+	var ownJID types.JID
+	if client != nil && client.Store != nil && client.Store.ID != nil {
+		ownJID = *client.Store.ID
 	}
 
+	now := time.Now()
 	syncedCount := 0
+	cappedConversations, cappedMessages := 0, 0
+	currentChatID := ""
+	progress := historyProgress{emitter: emitter, total: totalConversations, started: now}
+	updateProgress := func(processed int) {
+		progress.report(processed, currentChatID, syncedCount)
+	}
+	cancelledAt := -1
 	for idx, conversation := range historySync.Data.Conversations {
+		if historySyncCancelled() {
+			cancelledAt = idx
+			break
+		}
 		processedConversations := idx + 1
+		currentChatID = ""
 		if conversation.ID == nil {
 			updateProgress(processedConversations)
 			continue
@@ -230,7 +363,26 @@ func handleHistorySync(client *whatsmeow.Client, messageStore *storage.MessageSt
 		}
 
 		chatID := canonicalizeChatID(client, jid)
-		name := getChatName(client, messageStore, jid, chatID, conversation, "", logger)
+		currentChatID = chatID
+		if rules.IgnoresChat(client, jid, chatID, len(conversation.GetParticipant())) {
+			updateProgress(processedConversations)
+			continue
+		}
+		persist, err := rules.Stores(messageStore, chatID)
+		if err != nil {
+			logger.Warnf("Failed to read chat tracking, not storing history (chat_ref=%s): %v", obfuscatedChatRef(chatID), err)
+		}
+		// History is never streamed, so untracked chats are skipped unless
+		// they are kept redacted.
+		redact := !persist && rules.Untracked == UntrackedRedact
+		if !persist && !redact {
+			updateProgress(processedConversations)
+			continue
+		}
+		name := ""
+		if persist {
+			name = getChatName(client, messageStore, jid, chatID, conversation, "", logger)
+		}
 
 		messages := conversation.Messages
 		if len(messages) == 0 {
@@ -251,62 +403,47 @@ func handleHistorySync(client *whatsmeow.Client, messageStore *storage.MessageSt
 			updateProgress(processedConversations)
 			continue
 		}
+		if rules.History.tooOld(timestamp, now) || !rules.History.admitsConversation(chatID) {
+			cappedConversations++
+			updateProgress(processedConversations)
+			continue
+		}
 
-		if err := messageStore.StoreChat(chatID, name, timestamp); err != nil {
+		storedChatID := chatID
+		if redact {
+			storedChatID = rules.Redactor.ChatID(chatID)
+		}
+		if err := messageStore.StoreChat(storedChatID, name, timestamp); err != nil {
 			logger.Warnf("Failed to store history chat: %v", err)
 		}
 
-		if jid.Server != "g.us" {
+		if persist && isPersonalChat(jid) {
 			chatAliases := chatAliasIDs(client, jid, chatID)
-			syncChatAliases(messageStore, logger, chatID, chatAliases, timestamp, "history")
+			syncSenderAliases(messageStore, logger, chatID, chatAliases, timestamp, "history chat")
+		}
+
+		if persist && jid.IsBroadcastList() {
+			syncBroadcastRecipients(messageStore, logger, chatID, conversation.GetParticipant())
 		}
 
+		var batch []storage.StoredMessage
+		var quoted []*waProto.Message
 		for _, msg := range messages {
 			if msg == nil || msg.Message == nil {
 				continue
 			}
 
-			var content string
-			if msg.Message.Message != nil {
-				if conv := msg.Message.Message.GetConversation(); conv != "" {
-					content = conv
-				} else if ext := msg.Message.Message.GetExtendedTextMessage(); ext != nil {
-					content = ext.GetText()
-				}
-			}
-
-			var mediaType, filename, url string
-			var mediaKey, fileSHA256, fileEncSHA256 []byte
-			var fileLength uint64
-			if msg.Message.Message != nil {
-				mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength = extractMediaInfo(msg.Message.Message)
-			}
-
-			if content == "" && mediaType == "" {
+			content := extractTextContent(msg.Message.Message)
+			media, keep := rules.Filter(content, extractMediaInfo(msg.Message.Message))
+			if !keep {
 				continue
 			}
 
-			var senderJID types.JID
-			isFromMe := false
-			if msg.Message.Key != nil {
-				if msg.Message.Key.FromMe != nil {
-					isFromMe = *msg.Message.Key.FromMe
-				}
-				if !isFromMe && msg.Message.Key.Participant != nil && *msg.Message.Key.Participant != "" {
-					senderJID = parseSenderJID(*msg.Message.Key.Participant)
-				} else if isFromMe {
-					if client != nil && client.Store != nil && client.Store.ID != nil {
-						senderJID = client.Store.ID.ToNonAD()
-					} else {
-						senderJID = jid.ToNonAD()
-					}
-				} else {
-					senderJID = jid.ToNonAD()
-				}
-			} else {
-				senderJID = jid.ToNonAD()
-			}
+			senderJID, isFromMe := historySenderJID(ownJID, jid, msg.Message)
 			sender := canonicalizeSender(client, senderJID, types.JID{})
+			if rules.IgnoresSender(sender) {
+				continue
+			}
 
 			msgID := ""
 			if msg.Message.Key != nil && msg.Message.Key.ID != nil {
@@ -319,44 +456,82 @@ func handleHistorySync(client *whatsmeow.Client, messageStore *storage.MessageSt
 			} else {
 				continue
 			}
-
-			aliasIDs := senderAliasIDs(client, senderJID, types.JID{}, sender)
-			syncSenderAliases(messageStore, logger, sender, aliasIDs, timestamp, "history sender")
-
-			err = messageStore.StoreMessage(
-				msgID,
-				chatID,
-				sender,
-				content,
-				timestamp,
-				isFromMe,
-				mediaType,
-				filename,
-				url,
-				mediaKey,
-				fileSHA256,
-				fileEncSHA256,
-				fileLength,
-			)
-			if err != nil {
-				logger.Warnf("Failed to store history message: %v", err)
+			if rules.History.tooOld(timestamp, now) || !rules.History.admitsMessage(chatID) {
+				cappedMessages++
 				continue
 			}
 
+			if persist && sender != "" {
+				aliasIDs := senderAliasIDs(client, senderJID, types.JID{}, sender)
+				syncSenderAliases(messageStore, logger, sender, aliasIDs, timestamp, "history sender")
+			}
+
+			linkURL, linkTitle := extractLinkPreview(msg.Message.Message)
+			forwarded, forwardingScore := extractForwarding(msg.Message.Message)
+			stored := storage.StoredMessage{
+				ID:              msgID,
+				ChatJID:         chatID,
+				Sender:          sender,
+				RawSender:       senderJID.User,
+				SenderServer:    senderJID.Server,
+				ChatServer:      jid.Server,
+				Content:         content,
+				Timestamp:       timestamp,
+				IsFromMe:        isFromMe,
+				IsSelfChat:      isSelfChat(client, chatID),
+				QuotedMessageID: extractQuotedMessageID(msg.Message.Message),
+				LinkURL:         linkURL,
+				LinkTitle:       linkTitle,
+				IsForwarded:     forwarded,
+				ForwardingScore: forwardingScore,
+				Selection:       extractSelection(msg.Message.Message),
+				Payment:         extractPayment(msg.Message.Message),
+				MessageMedia:    media,
+			}
+			if redact {
+				stored = rules.Redactor.Redact(stored)
+			}
+			batch = append(batch, stored)
+			quoted = append(quoted, msg.Message.Message)
+		}
+
+		// A conversation's messages are journaled together, so the ingest
+		// buffer syncs its journal once per conversation.
+		if err := messageStore.BufferMessages(batch); err != nil {
+			logger.Warnf("Failed to store history messages (chat_ref=%s): %v", obfuscatedChatRef(chatID), err)
+			updateProgress(processedConversations)
+			continue
+		}
+		for i, stored := range batch {
+			if persist {
+				indexer.Enqueue(messageStore, stored.ID, chatID, stored.Content)
+				attributeQuotedSender(client, messageStore, jid, chatID, quoted[i], logger)
+			}
+
 			syncedCount++
-			if mediaType != "" {
+			if stored.MediaType != "" {
 				logger.Infof("Stored history media message: message_ref=%s type=%s ts=%s",
-					obfuscatedMessageRef(msgID), mediaType, timestamp.Format("2006-01-02 15:04:05"))
+					obfuscatedMessageRef(stored.ID), stored.MediaType, stored.Timestamp.Format("2006-01-02 15:04:05"))
 			} else {
 				logger.Infof("Stored history text message: message_ref=%s ts=%s",
-					obfuscatedMessageRef(msgID), timestamp.Format("2006-01-02 15:04:05"))
+					obfuscatedMessageRef(stored.ID), stored.Timestamp.Format("2006-01-02 15:04:05"))
 			}
 		}
 
 		updateProgress(processedConversations)
 	}
+	if err := messageStore.FlushIngestBuffer(); err != nil {
+		logger.Warnf("Failed to flush ingest buffer after history sync: %v", err)
+	}
 
-	logger.Infof("History sync complete. Stored %d messages.", syncedCount)
+	if cancelledAt >= 0 {
+		logger.Infof("History sync cancelled after %d of %d conversations. Stored %d messages.", cancelledAt, totalConversations, syncedCount)
+	} else {
+		logger.Infof("History sync complete. Stored %d messages.", syncedCount)
+	}
+	if cappedConversations > 0 || cappedMessages > 0 {
+		logger.Infof("History sync caps skipped %d conversations and %d messages", cappedConversations, cappedMessages)
+	}
 	if totalConversations > 0 {
 		bootstrap.SetConnected("WhatsApp connected")
 	}
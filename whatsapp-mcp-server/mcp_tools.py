@@ -52,6 +52,13 @@ def register_tools(mcp: FastMCP) -> None:
         before_iso: str | None = None,
         lookback_value: int | None = None,
         lookback_unit: str | None = None,
+        media_type: str | None = None,
+        is_from_me: bool | None = None,
+        has_link: bool | None = None,
+        is_forwarded: bool | None = None,
+        language: str | None = None,
+        min_length: int | None = None,
+        max_length: int | None = None,
     ) -> list[dict[str, Any]]:
         """
         - Search WhatsApp messages by content query globally.
@@ -65,6 +72,13 @@ def register_tools(mcp: FastMCP) -> None:
             before_iso: Optional upper ISO-8601 timestamp bound (exclusive)
             lookback_value: Optional relative lookback amount
             lookback_unit: Optional lookback unit, one of h, d, w
+            media_type: Optional kind filter, one of text, image, video, audio, document
+            is_from_me: Optional direction filter, true for sent and false for received messages
+            has_link: Optional filter on whether the message contains a link
+            is_forwarded: Optional filter on whether the message was forwarded
+            language: Optional ISO 639-1 code the bridge detected for the message, e.g. en or es
+            min_length: Optional minimum text length in characters
+            max_length: Optional maximum text length in characters
 
         Rules:
             - Use either absolute bounds (after_iso/before_iso) or relative bounds (lookback_value + lookback_unit), not both.
@@ -74,7 +88,10 @@ def register_tools(mcp: FastMCP) -> None:
         Returns:
             list[dict] of messages metadata with fields:
             timestamp (str), sender_id (str), content (str), is_from_me (bool),
-            chat_jid (str), id (str), chat_name (str | None), media_type (str | None)
+            chat_jid (str), id (str), chat_name (str | None), media_type (str | None),
+            is_forwarded (bool), forwarding_score (int), language (str | None),
+            sender_name (str | None): best-known display name, resolved by the bridge,
+            seq (int | None): local sequence number, increasing in the order messages were stored
         """
         messages = whatsapp_search_messages(
             query=query,
@@ -85,6 +102,13 @@ def register_tools(mcp: FastMCP) -> None:
             before_iso=before_iso,
             lookback_value=lookback_value,
             lookback_unit=lookback_unit,
+            media_type=media_type,
+            is_from_me=is_from_me,
+            has_link=has_link,
+            is_forwarded=is_forwarded,
+            language=language,
+            min_length=min_length,
+            max_length=max_length,
         )
         return serialize_for_mcp(messages)
 
@@ -98,6 +122,13 @@ def register_tools(mcp: FastMCP) -> None:
         before_iso: str | None = None,
         lookback_value: int | None = None,
         lookback_unit: str | None = None,
+        media_type: str | None = None,
+        is_from_me: bool | None = None,
+        has_link: bool | None = None,
+        is_forwarded: bool | None = None,
+        language: str | None = None,
+        min_length: int | None = None,
+        max_length: int | None = None,
     ) -> list[dict[str, Any]]:
         """
         - Search WhatsApp messages by content query within a specific chat.
@@ -111,6 +142,13 @@ def register_tools(mcp: FastMCP) -> None:
             before_iso: Optional upper ISO-8601 timestamp bound (exclusive)
             lookback_value: Optional relative lookback amount
             lookback_unit: Optional lookback unit, one of h, d, w
+            media_type: Optional kind filter, one of text, image, video, audio, document
+            is_from_me: Optional direction filter, true for sent and false for received messages
+            has_link: Optional filter on whether the message contains a link
+            is_forwarded: Optional filter on whether the message was forwarded
+            language: Optional ISO 639-1 code the bridge detected for the message, e.g. en or es
+            min_length: Optional minimum text length in characters
+            max_length: Optional maximum text length in characters
 
         Rules:
             - Use either absolute bounds (after_iso/before_iso) or relative bounds (lookback_value + lookback_unit), not both.
@@ -118,7 +156,10 @@ def register_tools(mcp: FastMCP) -> None:
         Returns:
             list[dict] of messages metadata with fields:
             timestamp (str), sender_id (str), content (str), is_from_me (bool),
-            chat_jid (str), id (str), chat_name (str | None), media_type (str | None)
+            chat_jid (str), id (str), chat_name (str | None), media_type (str | None),
+            is_forwarded (bool), forwarding_score (int), language (str | None),
+            sender_name (str | None): best-known display name, resolved by the bridge,
+            seq (int | None): local sequence number, increasing in the order messages were stored
         """
         messages = whatsapp_search_chat_messages(
             chat_jid=chat_jid,
@@ -129,6 +170,13 @@ def register_tools(mcp: FastMCP) -> None:
             before_iso=before_iso,
             lookback_value=lookback_value,
             lookback_unit=lookback_unit,
+            media_type=media_type,
+            is_from_me=is_from_me,
+            has_link=has_link,
+            is_forwarded=is_forwarded,
+            language=language,
+            min_length=min_length,
+            max_length=max_length,
         )
         return serialize_for_mcp(messages)
 
@@ -145,6 +193,13 @@ def register_tools(mcp: FastMCP) -> None:
         include_context: bool = True,
         context_before: int = 1,
         context_after: int = 1,
+        media_type: str | None = None,
+        is_from_me: bool | None = None,
+        has_link: bool | None = None,
+        is_forwarded: bool | None = None,
+        language: str | None = None,
+        min_length: int | None = None,
+        max_length: int | None = None,
     ) -> list[dict[str, Any]]:
         """
         - Get messages for one sender_id with optional date filters and context.
@@ -160,12 +215,22 @@ def register_tools(mcp: FastMCP) -> None:
             include_context: Whether to include messages before and after each result (default True)
             context_before: Number of context messages before each result (default 1)
             context_after: Number of context messages after each result (default 1)
+            media_type: Optional kind filter, one of text, image, video, audio, document
+            is_from_me: Optional direction filter, true for sent and false for received messages
+            has_link: Optional filter on whether the message contains a link
+            is_forwarded: Optional filter on whether the message was forwarded
+            language: Optional ISO 639-1 code the bridge detected for the message, e.g. en or es
+            min_length: Optional minimum text length in characters
+            max_length: Optional maximum text length in characters
 
         Returns:
             When include_context=False:
             - list[dict] of messages metadata with fields:
               timestamp (str), sender_id (str), content (str), is_from_me (bool),
-              chat_jid (str), id (str), chat_name (str | None), media_type (str | None)
+              chat_jid (str), id (str), chat_name (str | None), media_type (str | None),
+              is_forwarded (bool), forwarding_score (int), language (str | None),
+              sender_name (str | None): best-known display name, resolved by the bridge,
+              seq (int | None): local sequence number, increasing in the order messages were stored
             When include_context=True:
             - list[dict] of message contexts with fields:
               message (dict), before (list[dict]), after (list[dict])
@@ -184,6 +249,13 @@ def register_tools(mcp: FastMCP) -> None:
             include_context=include_context,
             context_before=context_before,
             context_after=context_after,
+            media_type=media_type,
+            is_from_me=is_from_me,
+            has_link=has_link,
+            is_forwarded=is_forwarded,
+            language=language,
+            min_length=min_length,
+            max_length=max_length,
         )
         return serialize_for_mcp(messages)
 
@@ -199,6 +271,13 @@ def register_tools(mcp: FastMCP) -> None:
         include_context: bool = True,
         context_before: int = 1,
         context_after: int = 1,
+        media_type: str | None = None,
+        is_from_me: bool | None = None,
+        has_link: bool | None = None,
+        is_forwarded: bool | None = None,
+        language: str | None = None,
+        min_length: int | None = None,
+        max_length: int | None = None,
     ) -> list[dict[str, Any]]:
         """
         - Get messages for one chat_jid with optional date filters and context.
@@ -214,12 +293,22 @@ def register_tools(mcp: FastMCP) -> None:
             include_context: Whether to include messages before and after each result (default True)
             context_before: Number of context messages before each result (default 1)
             context_after: Number of context messages after each result (default 1)
+            media_type: Optional kind filter, one of text, image, video, audio, document
+            is_from_me: Optional direction filter, true for sent and false for received messages
+            has_link: Optional filter on whether the message contains a link
+            is_forwarded: Optional filter on whether the message was forwarded
+            language: Optional ISO 639-1 code the bridge detected for the message, e.g. en or es
+            min_length: Optional minimum text length in characters
+            max_length: Optional maximum text length in characters
 
         Returns:
             When include_context=False:
             - list[dict] of messages metadata with fields:
               timestamp (str), sender_id (str), content (str), is_from_me (bool),
-              chat_jid (str), id (str), chat_name (str | None), media_type (str | None)
+              chat_jid (str), id (str), chat_name (str | None), media_type (str | None),
+              is_forwarded (bool), forwarding_score (int), language (str | None),
+              sender_name (str | None): best-known display name, resolved by the bridge,
+              seq (int | None): local sequence number, increasing in the order messages were stored
             When include_context=True:
             - list[dict] of message contexts with fields:
               message (dict), before (list[dict]), after (list[dict])
@@ -238,6 +327,13 @@ def register_tools(mcp: FastMCP) -> None:
             include_context=include_context,
             context_before=context_before,
             context_after=context_after,
+            media_type=media_type,
+            is_from_me=is_from_me,
+            has_link=has_link,
+            is_forwarded=is_forwarded,
+            language=language,
+            min_length=min_length,
+            max_length=max_length,
         )
         return serialize_for_mcp(messages)
 
@@ -254,7 +350,7 @@ def register_tools(mcp: FastMCP) -> None:
         Returns:
             dict | None:
             - When found, returns chat object with fields:
-              chat_jid, name, last_message_time, last_message, last_sender_id, last_is_from_me
+              chat_jid, name, last_message_time, last_message, last_sender_id, last_is_from_me, human_handoff, language
             - When not found, returns None
         """
         chat = whatsapp_get_chat(chat_jid, include_last_message)
@@ -270,7 +366,7 @@ def register_tools(mcp: FastMCP) -> None:
         Returns:
             dict | None:
             - Direct chat object for the contact with fields:
-              chat_jid, name, last_message_time, last_message, last_sender_id, last_is_from_me
+              chat_jid, name, last_message_time, last_message, last_sender_id, last_is_from_me, human_handoff, language
             - None if no matching direct chat is found
         """
         chat = whatsapp_get_direct_chat_by_contact(sender_id)
@@ -287,7 +383,7 @@ def register_tools(mcp: FastMCP) -> None:
 
         Returns:
             list[dict] of chats involving the contact. Each chat has:
-            chat_jid, name, last_message_time, last_message, last_sender_id, last_is_from_me
+            chat_jid, name, last_message_time, last_message, last_sender_id, last_is_from_me, human_handoff, language
         """
         chats = whatsapp_get_contact_chats(sender_id, limit, page)
         return serialize_for_mcp(chats)
@@ -335,13 +431,20 @@ def register_tools(mcp: FastMCP) -> None:
     def send_message(
         recipient: str,
         message: str,
+        mention_all: bool = False,
     ) -> dict[str, Any]:
         """Send a WhatsApp message to a person or group. For group chats use the JID.
 
         Args:
             recipient: The recipient - either a phone number with country code but no + or other symbols,
-                     or a JID (e.g., "123456789@s.whatsapp.net" or a group JID like "123456789@g.us")
+                     a JID (e.g., "123456789@s.whatsapp.net" or a group JID like "123456789@g.us"),
+                     or "me" to send to your own chat
             message: The message text to send
+            mention_all: For group recipients, mention every current participant (default False)
+
+        Rules:
+            - Sends are refused for chats marked read-only or in human handoff (human_handoff=true in chat metadata).
+            - A broadcast list JID ("...@broadcast") is delivered as individual messages to its recorded recipients.
 
         Returns:
             dict with fields:
@@ -362,6 +465,7 @@ def register_tools(mcp: FastMCP) -> None:
         success, status_message = whatsapp_send_message(
             recipient,
             message,
+            mention_all=mention_all,
             auth_headers=bridge_auth_headers,
         )
         return {
@@ -370,13 +474,15 @@ def register_tools(mcp: FastMCP) -> None:
         }
 
     @mcp.tool()
-    def send_file(recipient: str, media_path: str) -> dict[str, Any]:
-        """Send a file such as a picture, raw audio, video or document via WhatsApp to the specified recipient. For group messages use the JID.
+    def send_file(recipient: str, media_path: str, is_gif: bool = False) -> dict[str, Any]:
+        """Send a file such as a picture, raw audio, video or document via WhatsApp to the specified recipient. For group messages use the JID. .gif files are converted to MP4 (needs ffmpeg) and sent as looping GIFs.
 
         Args:
             recipient: The recipient - either a phone number with country code but no + or other symbols,
-                     or a JID (e.g., "123456789@s.whatsapp.net" or a group JID like "123456789@g.us")
+                     a JID (e.g., "123456789@s.whatsapp.net" or a group JID like "123456789@g.us"),
+                     or "me" to send to your own chat
             media_path: The absolute path to the media file to send (image, video, document)
+            is_gif: Send an MP4 video as a GIF that loops inline (default False)
 
         Returns:
             dict with fields:
@@ -391,6 +497,7 @@ def register_tools(mcp: FastMCP) -> None:
         success, status_message = whatsapp_send_file(
             recipient,
             media_path,
+            is_gif,
             auth_headers=bridge_auth_headers,
         )
         return {
@@ -404,7 +511,8 @@ def register_tools(mcp: FastMCP) -> None:
 
         Args:
             recipient: The recipient - either a phone number with country code but no + or other symbols,
-                     or a JID (e.g., "123456789@s.whatsapp.net" or a group JID like "123456789@g.us")
+                     a JID (e.g., "123456789@s.whatsapp.net" or a group JID like "123456789@g.us"),
+                     or "me" to send to your own chat
             media_path: The absolute path to the audio file to send (will be converted to Opus .ogg if it's not a .ogg file)
 
         Returns:
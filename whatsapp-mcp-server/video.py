@@ -0,0 +1,79 @@
+import os
+import subprocess
+import tempfile
+
+def convert_gif_to_mp4(input_file, output_file=None):
+    """
+    Convert an animated GIF to a muted H.264 MP4, the format WhatsApp plays as a GIF.
+    
+    Args:
+        input_file (str): Path to the input GIF file
+        output_file (str, optional): Path to save the output file. If None, replaces the
+                                    extension of input_file with .mp4
+    
+    Returns:
+        str: Path to the converted file
+        
+    Raises:
+        FileNotFoundError: If the input file doesn't exist
+        RuntimeError: If the ffmpeg conversion fails
+    """
+    if not os.path.isfile(input_file):
+        raise FileNotFoundError(f"Input file not found: {input_file}")
+    
+    if output_file is None:
+        output_file = os.path.splitext(input_file)[0] + ".mp4"
+    
+    output_dir = os.path.dirname(output_file)
+    if output_dir and not os.path.exists(output_dir):
+        os.makedirs(output_dir)
+    
+    cmd = [
+        "ffmpeg",
+        "-i", input_file,
+        "-movflags", "+faststart",   # Playable before fully downloaded
+        "-pix_fmt", "yuv420p",       # Required by most players
+        "-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2",  # H.264 needs even dimensions
+        "-c:v", "libx264",
+        "-an",                       # GIFs are muted
+        "-y",                        # Overwrite output file if it exists
+        output_file
+    ]
+    
+    try:
+        subprocess.run(
+            cmd,
+            stdout=subprocess.PIPE,
+            stderr=subprocess.PIPE,
+            text=True,
+            check=True
+        )
+        return output_file
+    except subprocess.CalledProcessError as e:
+        raise RuntimeError(f"Failed to convert GIF. You likely need to install ffmpeg {e.stderr}")
+
+
+def convert_gif_to_mp4_temp(input_file):
+    """
+    Convert an animated GIF to MP4 and store it in a temporary file.
+    
+    Args:
+        input_file (str): Path to the input GIF file
+    
+    Returns:
+        str: Path to the temporary file with the converted video
+        
+    Raises:
+        FileNotFoundError: If the input file doesn't exist
+        RuntimeError: If the ffmpeg conversion fails
+    """
+    temp_file = tempfile.NamedTemporaryFile(suffix=".mp4", delete=False)
+    temp_file.close()
+    
+    try:
+        convert_gif_to_mp4(input_file, temp_file.name)
+        return temp_file.name
+    except Exception as e:
+        if os.path.exists(temp_file.name):
+            os.unlink(temp_file.name)
+        raise e
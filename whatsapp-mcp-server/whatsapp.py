@@ -1,19 +1,24 @@
 import sqlite3
-from datetime import datetime, timedelta
+from datetime import datetime, timedelta, timezone
 from dataclasses import dataclass
 from pathlib import Path
 import re
-from typing import Optional, Tuple
+from typing import Any, Optional, Tuple
 import os
 import requests
 import json
 import audio
+import video
 from dotenv import load_dotenv
 
 load_dotenv(dotenv_path=Path(__file__).resolve().parent / ".env", override=False)
 
 WHATSAPP_API_BASE_URL = os.getenv("WHATSAPP_BRIDGE_API_BASE_URL", "http://127.0.0.1:8080")
 
+# Bridge calls share one session so connections are kept alive and reused
+# instead of being opened for every request.
+_BRIDGE_SESSION = requests.Session()
+
 _RUNTIME_SCOPE_UUID_RE = re.compile(
     r"^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[1-5][0-9a-fA-F]{3}-"
     r"[89abAB][0-9a-fA-F]{3}-[0-9a-fA-F]{12}$"
@@ -102,6 +107,13 @@ class Message:
     id: str
     chat_name: Optional[str] = None
     media_type: Optional[str] = None
+    media_seconds: Optional[int] = None
+    is_voice_note: bool = False
+    is_forwarded: bool = False
+    forwarding_score: int = 0
+    language: Optional[str] = None
+    sender_name: Optional[str] = None
+    seq: Optional[int] = None
 
 @dataclass
 class Chat:
@@ -111,12 +123,19 @@ class Chat:
     last_message: Optional[str] = None
     last_sender_id: Optional[str] = None
     last_is_from_me: Optional[bool] = None
+    human_handoff: bool = False
+    language: Optional[str] = None
 
     @property
     def is_group(self) -> bool:
         """Determine if chat is a group based on JID pattern."""
         return self.chat_jid.endswith("@g.us")
 
+    @property
+    def is_broadcast(self) -> bool:
+        """Determine if chat is a broadcast list based on JID pattern."""
+        return self.chat_jid.endswith("@broadcast")
+
 @dataclass
 class Contact:
     sender_id: str
@@ -130,8 +149,17 @@ class MessageContext:
     after: list[Message]
 
 
+def _storage_timestamp(value: datetime) -> str:
+    """Format an aware datetime the way the bridge stores timestamps.
+
+    The bridge writes UTC as "YYYY-MM-DD HH:MM:SS+00:00", so bounds must use the
+    same form to compare correctly as text regardless of offset or DST.
+    """
+    return value.astimezone(timezone.utc).strftime("%Y-%m-%d %H:%M:%S+00:00")
+
+
 def _parse_iso_timestamp(value: str, field_name: str) -> str:
-    """Parse strict ISO timestamp input into ISO-8601 with timezone."""
+    """Parse strict ISO timestamp input into the bridge's UTC storage format."""
     raw = value.strip()
     if not raw:
         raise ValueError(f"Invalid empty timestamp for '{field_name}'")
@@ -142,7 +170,7 @@ def _parse_iso_timestamp(value: str, field_name: str) -> str:
         parsed = datetime.fromisoformat(candidate)
         if parsed.tzinfo is None:
             parsed = parsed.replace(tzinfo=now_tz)
-        return parsed.isoformat()
+        return _storage_timestamp(parsed)
     except ValueError as exc:
         raise ValueError(
             f"Invalid ISO timestamp for '{field_name}': {value}. "
@@ -157,7 +185,7 @@ def _resolve_time_window(
     lookback_value: Optional[int] = None,
     lookback_unit: Optional[str] = None,
 ) -> tuple[Optional[str], Optional[str]]:
-    """Resolve absolute/relative time window into (after, before) ISO-8601 timestamps.
+    """Resolve absolute/relative time window into (after, before) UTC storage timestamps.
 
     Rules:
     - Absolute mode: use after_iso and/or before_iso
@@ -192,7 +220,7 @@ def _resolve_time_window(
             raise ValueError("lookback_unit must be one of: h, d, w.")
 
         now = datetime.now().astimezone()
-        return (now - delta).isoformat(), now.isoformat()
+        return _storage_timestamp(now - delta), _storage_timestamp(now)
 
     resolved_after = _parse_iso_timestamp(after_iso, "after_iso") if after_iso else None
     resolved_before = _parse_iso_timestamp(before_iso, "before_iso") if before_iso else None
@@ -286,6 +314,16 @@ def get_sender_name(sender_id: str) -> str:
         if 'conn' in locals():
             conn.close()
 
+def _media_label(message: Message) -> str:
+    """Describe a message's media, e.g. "voice note, 0:42"."""
+    label = message.media_type or ""
+    if label == "audio" and message.is_voice_note:
+        label = "voice note"
+    if message.media_seconds and message.media_type in ("audio", "video"):
+        minutes, seconds = divmod(int(message.media_seconds), 60)
+        label += f", {minutes}:{seconds:02d}"
+    return label
+
 def format_message(message: Message, show_chat_info: bool = True) -> str:
     """Format a single message as text."""
     output = ""
@@ -297,10 +335,16 @@ def format_message(message: Message, show_chat_info: bool = True) -> str:
         
     content_prefix = ""
     if hasattr(message, 'media_type') and message.media_type:
-        content_prefix = f"[{message.media_type} - Message ID: {message.id} - Chat JID: {message.chat_jid}] "
+        content_prefix = f"[{_media_label(message)} - Message ID: {message.id} - Chat JID: {message.chat_jid}] "
     
     try:
-        sender_name = get_sender_name(message.sender_id) if not message.is_from_me else "Me"
+        if message.is_from_me:
+            sender_name = "Me"
+        else:
+            sender_name = message.sender_name or get_sender_name(message.sender_id)
+        if message.is_forwarded:
+            # WhatsApp labels messages forwarded five or more times "Forwarded many times".
+            content_prefix = ("[forwarded many times] " if message.forwarding_score >= 5 else "[forwarded] ") + content_prefix
         output += f"From: {sender_name}: {content_prefix}{message.content}\n"
     except Exception as e:
         print(f"Error formatting message: {e}")
@@ -317,6 +361,78 @@ def format_messages_list(messages: list[Message], show_chat_info: bool = True) -
         output += format_message(message, show_chat_info)
     return output
 
+MESSAGE_MEDIA_TYPE_FILTERS = ("text", "image", "video", "audio", "document")
+
+
+def _message_filter_clauses(
+    media_type: Optional[str] = None,
+    is_from_me: Optional[bool] = None,
+    has_link: Optional[bool] = None,
+    is_forwarded: Optional[bool] = None,
+    language: Optional[str] = None,
+    min_length: Optional[int] = None,
+    max_length: Optional[int] = None,
+) -> tuple[list[str], list[Any]]:
+    """Build SQL filters for message kind, direction, links, forwarding, language, and text length.
+
+    media_type "text" matches messages without media. has_link uses the
+    bridge's links index rather than scanning content. Messages the bridge's
+    spam classifier quarantined are always left out; they are reviewed through
+    the bridge's /api/quarantine.
+    """
+    where_clauses: list[str] = ["COALESCE(messages.quarantined, 0) = 0"]
+    params: list[Any] = []
+
+    if media_type is not None:
+        normalized_media_type = media_type.strip().lower()
+        if normalized_media_type not in MESSAGE_MEDIA_TYPE_FILTERS:
+            raise ValueError(
+                "media_type must be one of " + ", ".join(MESSAGE_MEDIA_TYPE_FILTERS)
+            )
+        if normalized_media_type == "text":
+            where_clauses.append("(messages.media_type IS NULL OR messages.media_type = '')")
+        else:
+            where_clauses.append("messages.media_type = ?")
+            params.append(normalized_media_type)
+
+    if is_from_me is not None:
+        where_clauses.append("messages.is_from_me = ?")
+        params.append(1 if is_from_me else 0)
+
+    if has_link is not None:
+        link_exists = (
+            "EXISTS (SELECT 1 FROM links WHERE links.message_id = messages.id "
+            "AND links.chat_jid = messages.chat_jid)"
+        )
+        where_clauses.append(link_exists if has_link else f"NOT {link_exists}")
+
+    if is_forwarded is not None:
+        where_clauses.append("COALESCE(messages.is_forwarded, 0) = ?")
+        params.append(1 if is_forwarded else 0)
+
+    if language is not None:
+        normalized_language = language.strip().lower()
+        if not normalized_language:
+            raise ValueError("language must be a non-empty ISO 639-1 code")
+        where_clauses.append("messages.language = ?")
+        params.append(normalized_language)
+
+    if min_length is not None and min_length < 0:
+        raise ValueError("min_length must be greater than or equal to 0")
+    if max_length is not None and max_length < 0:
+        raise ValueError("max_length must be greater than or equal to 0")
+    if min_length is not None and max_length is not None and min_length > max_length:
+        raise ValueError("min_length must not be greater than max_length")
+    if min_length is not None:
+        where_clauses.append("LENGTH(messages.content) >= ?")
+        params.append(min_length)
+    if max_length is not None:
+        where_clauses.append("LENGTH(messages.content) <= ?")
+        params.append(max_length)
+
+    return where_clauses, params
+
+
 def _query_messages(
     after: Optional[str] = None,
     before: Optional[str] = None,
@@ -327,7 +443,14 @@ def _query_messages(
     page: int = 0,
     include_context: bool = True,
     context_before: int = 1,
-    context_after: int = 1
+    context_after: int = 1,
+    media_type: Optional[str] = None,
+    is_from_me: Optional[bool] = None,
+    has_link: Optional[bool] = None,
+    is_forwarded: Optional[bool] = None,
+    language: Optional[str] = None,
+    min_length: Optional[int] = None,
+    max_length: Optional[int] = None,
 ) -> list[Message] | list[MessageContext]:
     """Internal query helper for message retrieval and full-text search.
 
@@ -340,7 +463,7 @@ def _query_messages(
         cursor = conn.cursor()
         
         # Build base query
-        query_parts = ["SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type FROM messages"]
+        query_parts = ["SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.media_seconds, messages.is_voice_note, messages.is_forwarded, messages.forwarding_score, messages.language, messages.sender_name, messages.seq FROM messages_with_names AS messages"]
         query_parts.append("JOIN chats ON messages.chat_jid = chats.jid")
         where_clauses = []
         params = []
@@ -369,6 +492,18 @@ def _query_messages(
         if query:
             where_clauses.append("LOWER(messages.content) LIKE LOWER(?)")
             params.append(f"%{query}%")
+
+        filter_clauses, filter_params = _message_filter_clauses(
+            media_type=media_type,
+            is_from_me=is_from_me,
+            has_link=has_link,
+            is_forwarded=is_forwarded,
+            language=language,
+            min_length=min_length,
+            max_length=max_length,
+        )
+        where_clauses.extend(filter_clauses)
+        params.extend(filter_params)
             
         if where_clauses:
             query_parts.append("WHERE " + " AND ".join(where_clauses))
@@ -392,7 +527,14 @@ def _query_messages(
                 is_from_me=msg[4],
                 chat_jid=msg[5],
                 id=msg[6],
-                media_type=msg[7]
+                media_type=msg[7],
+                media_seconds=msg[8],
+                is_voice_note=bool(msg[9]),
+                is_forwarded=bool(msg[10]),
+                forwarding_score=msg[11] or 0,
+                language=msg[12],
+                sender_name=msg[13] or None,
+                seq=msg[14]
             )
             result.append(message)
             
@@ -423,7 +565,14 @@ def list_messages(
     page: int = 0,
     include_context: bool = True,
     context_before: int = 1,
-    context_after: int = 1
+    context_after: int = 1,
+    media_type: Optional[str] = None,
+    is_from_me: Optional[bool] = None,
+    has_link: Optional[bool] = None,
+    is_forwarded: Optional[bool] = None,
+    language: Optional[str] = None,
+    min_length: Optional[int] = None,
+    max_length: Optional[int] = None,
 ) -> list[Message] | list[MessageContext]:
     """Get messages with optional sender/chat/date filters and context.
 
@@ -442,6 +591,13 @@ def list_messages(
         include_context=include_context,
         context_before=context_before,
         context_after=context_after,
+        media_type=media_type,
+        is_from_me=is_from_me,
+        has_link=has_link,
+        is_forwarded=is_forwarded,
+        language=language,
+        min_length=min_length,
+        max_length=max_length,
     )
 
 
@@ -456,6 +612,13 @@ def list_messages_for_sender_id(
     include_context: bool = True,
     context_before: int = 1,
     context_after: int = 1,
+    media_type: Optional[str] = None,
+    is_from_me: Optional[bool] = None,
+    has_link: Optional[bool] = None,
+    is_forwarded: Optional[bool] = None,
+    language: Optional[str] = None,
+    min_length: Optional[int] = None,
+    max_length: Optional[int] = None,
 ) -> list[Message] | list[MessageContext]:
     """Get messages for a specific sender_id with optional time window and context."""
     normalized_sender_id = _canonical_sender_id(sender_id)
@@ -478,6 +641,13 @@ def list_messages_for_sender_id(
         include_context=include_context,
         context_before=context_before,
         context_after=context_after,
+        media_type=media_type,
+        is_from_me=is_from_me,
+        has_link=has_link,
+        is_forwarded=is_forwarded,
+        language=language,
+        min_length=min_length,
+        max_length=max_length,
     )
 
 
@@ -492,6 +662,13 @@ def list_messages_for_chat_id(
     include_context: bool = True,
     context_before: int = 1,
     context_after: int = 1,
+    media_type: Optional[str] = None,
+    is_from_me: Optional[bool] = None,
+    has_link: Optional[bool] = None,
+    is_forwarded: Optional[bool] = None,
+    language: Optional[str] = None,
+    min_length: Optional[int] = None,
+    max_length: Optional[int] = None,
 ) -> list[Message] | list[MessageContext]:
     """Get messages for a specific chat_jid with optional time window and context."""
     normalized_chat_jid = chat_jid.strip()
@@ -514,6 +691,13 @@ def list_messages_for_chat_id(
         include_context=include_context,
         context_before=context_before,
         context_after=context_after,
+        media_type=media_type,
+        is_from_me=is_from_me,
+        has_link=has_link,
+        is_forwarded=is_forwarded,
+        language=language,
+        min_length=min_length,
+        max_length=max_length,
     )
 
 
@@ -526,6 +710,13 @@ def search_messages(
     before_iso: Optional[str] = None,
     lookback_value: Optional[int] = None,
     lookback_unit: Optional[str] = None,
+    media_type: Optional[str] = None,
+    is_from_me: Optional[bool] = None,
+    has_link: Optional[bool] = None,
+    is_forwarded: Optional[bool] = None,
+    language: Optional[str] = None,
+    min_length: Optional[int] = None,
+    max_length: Optional[int] = None,
 ) -> list[Message]:
     """Search message content with pagination and optional time window.
 
@@ -538,6 +729,13 @@ def search_messages(
         before_iso: Optional upper ISO-8601 timestamp bound (exclusive)
         lookback_value: Optional relative lookback amount
         lookback_unit: Optional lookback unit, one of h, d, w
+        media_type: Optional kind filter, one of text, image, video, audio, document
+        is_from_me: Optional direction filter, True for sent and False for received
+        has_link: Optional filter on whether the message contains a link
+        is_forwarded: Optional filter on whether the message was forwarded
+        language: Optional ISO 639-1 code the bridge detected for the message, e.g. en or es
+        min_length: Optional minimum text length in characters
+        max_length: Optional maximum text length in characters
     """
     normalized_query = (query or "").strip()
     if page < 0:
@@ -566,6 +764,13 @@ def search_messages(
         include_context=False,
         context_before=1,
         context_after=1,
+        media_type=media_type,
+        is_from_me=is_from_me,
+        has_link=has_link,
+        is_forwarded=is_forwarded,
+        language=language,
+        min_length=min_length,
+        max_length=max_length,
     )
 
 
@@ -578,6 +783,13 @@ def search_chat_messages(
     before_iso: Optional[str] = None,
     lookback_value: Optional[int] = None,
     lookback_unit: Optional[str] = None,
+    media_type: Optional[str] = None,
+    is_from_me: Optional[bool] = None,
+    has_link: Optional[bool] = None,
+    is_forwarded: Optional[bool] = None,
+    language: Optional[str] = None,
+    min_length: Optional[int] = None,
+    max_length: Optional[int] = None,
 ) -> list[Message]:
     """Search message content within a specific chat and optional time window.
 
@@ -590,6 +802,13 @@ def search_chat_messages(
         before_iso: Optional upper ISO-8601 timestamp bound (exclusive)
         lookback_value: Optional relative lookback amount
         lookback_unit: Optional lookback unit, one of h, d, w
+        media_type: Optional kind filter, one of text, image, video, audio, document
+        is_from_me: Optional direction filter, True for sent and False for received
+        has_link: Optional filter on whether the message contains a link
+        is_forwarded: Optional filter on whether the message was forwarded
+        language: Optional ISO 639-1 code the bridge detected for the message, e.g. en or es
+        min_length: Optional minimum text length in characters
+        max_length: Optional maximum text length in characters
     """
     normalized_chat_jid = chat_jid.strip()
     normalized_query = query.strip()
@@ -620,6 +839,13 @@ def search_chat_messages(
         include_context=False,
         context_before=1,
         context_after=1,
+        media_type=media_type,
+        is_from_me=is_from_me,
+        has_link=has_link,
+        is_forwarded=is_forwarded,
+        language=language,
+        min_length=min_length,
+        max_length=max_length,
     )
 
 
@@ -635,8 +861,8 @@ def get_message_context(
         
         # Get the target message first
         cursor.execute("""
-            SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.chat_jid, messages.media_type
-            FROM messages
+            SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.chat_jid, messages.media_type, messages.sender_name, messages.seq
+            FROM messages_with_names AS messages
             JOIN chats ON messages.chat_jid = chats.jid
             WHERE messages.id = ?
         """, (message_id,))
@@ -653,13 +879,15 @@ def get_message_context(
             is_from_me=msg_data[4],
             chat_jid=msg_data[5],
             id=msg_data[6],
-            media_type=msg_data[8]
+            media_type=msg_data[8],
+            sender_name=msg_data[9] or None,
+            seq=msg_data[10]
         )
         
         # Get messages before
         cursor.execute("""
-            SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type
-            FROM messages
+            SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.sender_name, messages.seq
+            FROM messages_with_names AS messages
             JOIN chats ON messages.chat_jid = chats.jid
             WHERE messages.chat_jid = ? AND messages.timestamp < ?
             ORDER BY messages.timestamp DESC
@@ -676,13 +904,15 @@ def get_message_context(
                 is_from_me=msg[4],
                 chat_jid=msg[5],
                 id=msg[6],
-                media_type=msg[7]
+                media_type=msg[7],
+                sender_name=msg[8] or None,
+                seq=msg[9]
             ))
         
         # Get messages after
         cursor.execute("""
-            SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type
-            FROM messages
+            SELECT messages.timestamp, messages.sender, chats.name, messages.content, messages.is_from_me, chats.jid, messages.id, messages.media_type, messages.sender_name, messages.seq
+            FROM messages_with_names AS messages
             JOIN chats ON messages.chat_jid = chats.jid
             WHERE messages.chat_jid = ? AND messages.timestamp > ?
             ORDER BY messages.timestamp ASC
@@ -699,7 +929,9 @@ def get_message_context(
                 is_from_me=msg[4],
                 chat_jid=msg[5],
                 id=msg[6],
-                media_type=msg[7]
+                media_type=msg[7],
+                sender_name=msg[8] or None,
+                seq=msg[9]
             ))
         
         return MessageContext(
@@ -736,7 +968,9 @@ def list_chats(
                 chats.last_message_time,
                 messages.content as last_message,
                 messages.sender as last_sender_id,
-                messages.is_from_me as last_is_from_me
+                messages.is_from_me as last_is_from_me,
+                COALESCE((SELECT human_handoff FROM chat_settings WHERE chat_settings.chat_jid = chats.jid), 0) as human_handoff,
+                (SELECT language FROM chat_languages WHERE chat_languages.chat_jid = chats.jid) as language
             FROM chats
         """]
         
@@ -776,7 +1010,9 @@ def list_chats(
                 last_message_time=datetime.fromisoformat(chat_data[2]) if chat_data[2] else None,
                 last_message=chat_data[3],
                 last_sender_id=chat_data[4],
-                last_is_from_me=chat_data[5]
+                last_is_from_me=chat_data[5],
+                human_handoff=bool(chat_data[6]),
+                language=chat_data[7]
             )
             result.append(chat)
             
@@ -807,6 +1043,7 @@ def search_contacts(query: str) -> list[Contact]:
             WHERE 
                 (LOWER(name) LIKE LOWER(?) OR LOWER(jid) LIKE LOWER(?))
                 AND jid NOT LIKE '%@g.us'
+                AND jid NOT LIKE '%@broadcast'
             ORDER BY name, jid
             LIMIT 50
         """, (search_pattern, search_pattern))
@@ -818,8 +1055,8 @@ def search_contacts(query: str) -> list[Contact]:
             chat_jid = (contact_data[0] or "").strip()
             name = contact_data[1]
 
-            # Contacts search should return users only, even if group rows are present.
-            if not chat_jid or chat_jid.endswith("@g.us"):
+            # Contacts search should return users only, even if group or broadcast rows are present.
+            if not chat_jid or chat_jid.endswith(("@g.us", "@broadcast")):
                 continue
 
             sender_alias = chat_jid.split("@", 1)[0].strip() if "@" in chat_jid else chat_jid
@@ -880,7 +1117,9 @@ def get_contact_chats(sender_id: str, limit: int = 20, page: int = 0) -> list[Ch
                 c.last_message_time,
                 m.content as last_message,
                 m.sender as last_sender_id,
-                m.is_from_me as last_is_from_me
+                m.is_from_me as last_is_from_me,
+                COALESCE((SELECT human_handoff FROM chat_settings WHERE chat_settings.chat_jid = c.jid), 0) as human_handoff,
+                (SELECT language FROM chat_languages WHERE chat_languages.chat_jid = c.jid) as language
             FROM chats c
             JOIN messages m ON c.jid = m.chat_jid
             WHERE m.sender IN (""" + sender_placeholders + """)
@@ -899,7 +1138,9 @@ def get_contact_chats(sender_id: str, limit: int = 20, page: int = 0) -> list[Ch
                 last_message_time=datetime.fromisoformat(chat_data[2]) if chat_data[2] else None,
                 last_message=chat_data[3],
                 last_sender_id=chat_data[4],
-                last_is_from_me=chat_data[5]
+                last_is_from_me=chat_data[5],
+                human_handoff=bool(chat_data[6]),
+                language=chat_data[7]
             )
             result.append(chat)
             
@@ -980,7 +1221,9 @@ def get_chat(chat_jid: str, include_last_message: bool = True) -> Optional[Chat]
                 c.last_message_time,
                 m.content as last_message,
                 m.sender as last_sender_id,
-                m.is_from_me as last_is_from_me
+                m.is_from_me as last_is_from_me,
+                COALESCE((SELECT human_handoff FROM chat_settings WHERE chat_settings.chat_jid = c.jid), 0) as human_handoff,
+                (SELECT language FROM chat_languages WHERE chat_languages.chat_jid = c.jid) as language
             FROM chats c
         """
         
@@ -1004,7 +1247,9 @@ def get_chat(chat_jid: str, include_last_message: bool = True) -> Optional[Chat]
             last_message_time=datetime.fromisoformat(chat_data[2]) if chat_data[2] else None,
             last_message=chat_data[3],
             last_sender_id=chat_data[4],
-            last_is_from_me=chat_data[5]
+            last_is_from_me=chat_data[5],
+            human_handoff=bool(chat_data[6]),
+            language=chat_data[7]
         )
         
     except sqlite3.Error as e:
@@ -1034,7 +1279,9 @@ def get_direct_chat_by_contact(sender_id: str) -> Optional[Chat]:
                 c.last_message_time,
                 m.content as last_message,
                 m.sender as last_sender_id,
-                m.is_from_me as last_is_from_me
+                m.is_from_me as last_is_from_me,
+                COALESCE((SELECT human_handoff FROM chat_settings WHERE chat_settings.chat_jid = c.jid), 0) as human_handoff,
+                (SELECT language FROM chat_languages WHERE chat_languages.chat_jid = c.jid) as language
             FROM chats c
             LEFT JOIN messages m ON c.jid = m.chat_jid 
                 AND c.last_message_time = m.timestamp
@@ -1054,7 +1301,9 @@ def get_direct_chat_by_contact(sender_id: str) -> Optional[Chat]:
             last_message_time=datetime.fromisoformat(chat_data[2]) if chat_data[2] else None,
             last_message=chat_data[3],
             last_sender_id=chat_data[4],
-            last_is_from_me=chat_data[5]
+            last_is_from_me=chat_data[5],
+            human_handoff=bool(chat_data[6]),
+            language=chat_data[7]
         )
         
     except sqlite3.Error as e:
@@ -1064,7 +1313,13 @@ def get_direct_chat_by_contact(sender_id: str) -> Optional[Chat]:
         if 'conn' in locals():
             conn.close()
 
-def send_message(recipient: str, message: str, *, auth_headers: dict[str, str]) -> Tuple[bool, str]:
+def send_message(
+    recipient: str,
+    message: str,
+    *,
+    mention_all: bool = False,
+    auth_headers: dict[str, str],
+) -> Tuple[bool, str]:
     try:
         # Validate input
         if not recipient:
@@ -1075,8 +1330,10 @@ def send_message(recipient: str, message: str, *, auth_headers: dict[str, str])
             "recipient": recipient,
             "message": message,
         }
+        if mention_all:
+            payload["mention_all"] = True
         
-        response = requests.post(
+        response = _BRIDGE_SESSION.post(
             url,
             json=payload,
             headers=_validated_bridge_auth_headers(auth_headers),
@@ -1097,7 +1354,7 @@ def send_message(recipient: str, message: str, *, auth_headers: dict[str, str])
     except Exception as e:
         return False, f"Unexpected error: {str(e)}"
 
-def send_file(recipient: str, media_path: str, *, auth_headers: dict[str, str]) -> Tuple[bool, str]:
+def send_file(recipient: str, media_path: str, is_gif: bool = False, *, auth_headers: dict[str, str]) -> Tuple[bool, str]:
     try:
         # Validate input
         if not recipient:
@@ -1108,14 +1365,24 @@ def send_file(recipient: str, media_path: str, *, auth_headers: dict[str, str])
         
         if not os.path.isfile(media_path):
             return False, f"Media file not found: {media_path}"
+
+        # WhatsApp only animates GIFs sent as MP4 videos flagged for GIF playback.
+        if media_path.lower().endswith(".gif"):
+            try:
+                media_path = video.convert_gif_to_mp4_temp(media_path)
+            except Exception as e:
+                return False, f"Error converting GIF to MP4. You likely need to install ffmpeg: {str(e)}"
+            is_gif = True
         
         url = f"{WHATSAPP_API_BASE_URL}/api/send"
         payload = {
             "recipient": recipient,
             "media_path": media_path
         }
+        if is_gif:
+            payload["is_gif"] = True
         
-        response = requests.post(
+        response = _BRIDGE_SESSION.post(
             url,
             json=payload,
             headers=_validated_bridge_auth_headers(auth_headers),
@@ -1160,7 +1427,7 @@ def send_audio_message(recipient: str, media_path: str, *, auth_headers: dict[st
             "media_path": media_path
         }
         
-        response = requests.post(
+        response = _BRIDGE_SESSION.post(
             url,
             json=payload,
             headers=_validated_bridge_auth_headers(auth_headers),
@@ -1198,7 +1465,7 @@ def download_media(message_id: str, chat_jid: str, *, auth_headers: dict[str, st
             "chat_jid": chat_jid
         }
         
-        response = requests.post(
+        response = _BRIDGE_SESSION.post(
             url,
             json=payload,
             headers=_validated_bridge_auth_headers(auth_headers),